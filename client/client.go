@@ -57,6 +57,17 @@ func NewClient(baseURL string, options ...ClientOption) *Client {
 func (c *Client) doRequest(ctx context.Context,
 	method, path string, body interface{}, query url.Values,
 ) (*http.Response, error) {
+	req, err := c.newRequest(ctx, method, path, body, query)
+	if err != nil {
+		return nil, err
+	}
+	return c.httpClient.Do(req)
+}
+
+// newRequest creates an authenticated HTTP request
+func (c *Client) newRequest(ctx context.Context,
+	method, path string, body interface{}, query url.Values,
+) (*http.Request, error) {
 	var reqBody io.Reader
 	if body != nil {
 		jsonData, err := json.Marshal(body)
@@ -84,7 +95,7 @@ func (c *Client) doRequest(ctx context.Context,
 		req.Header.Set("X-Request-ID", requestID.(string))
 	}
 
-	return c.httpClient.Do(req)
+	return req, nil
 }
 
 // parseResponse parses an HTTP response
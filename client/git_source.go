@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// GitSourceSyncStats counts the files handled by the last sync of a Git source.
+type GitSourceSyncStats struct {
+	Added      int `json:"added"`
+	Updated    int `json:"updated"`
+	Deleted    int `json:"deleted"`
+	Unchanged  int `json:"unchanged"`
+	Duplicated int `json:"duplicated"`
+	Skipped    int `json:"skipped"`
+	Failed     int `json:"failed"`
+}
+
+// GitSource represents a Git repository synced into a knowledge base.
+type GitSource struct {
+	ID              string             `json:"id"`
+	TenantID        uint64             `json:"tenant_id"`
+	KnowledgeBaseID string             `json:"knowledge_base_id"`
+	Name            string             `json:"name"`
+	RepoURL         string             `json:"repo_url"`
+	Branch          string             `json:"branch"`
+	Include         []string           `json:"include"`
+	Exclude         []string           `json:"exclude"`
+	LastCommit      string             `json:"last_commit"`
+	SyncStatus      string             `json:"sync_status"`
+	SyncStats       GitSourceSyncStats `json:"sync_stats"`
+	ErrorMessage    string             `json:"error_message"`
+	LastSyncedAt    *time.Time         `json:"last_synced_at"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+}
+
+// GitSourceFile records the knowledge a repository file was ingested as.
+type GitSourceFile struct {
+	SourceID    string    `json:"source_id"`
+	Path        string    `json:"path"`
+	KnowledgeID string    `json:"knowledge_id"`
+	BlobSHA     string    `json:"blob_sha"`
+	CommitSHA   string    `json:"commit_sha"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// GitSourceRequest is used to create or update a Git source.
+type GitSourceRequest struct {
+	Name    string   `json:"name,omitempty"`
+	RepoURL string   `json:"repo_url"`
+	Branch  string   `json:"branch,omitempty"`
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// GitSourceResponse wraps a single Git source response.
+type GitSourceResponse struct {
+	Success bool       `json:"success"`
+	Data    *GitSource `json:"data"`
+	Message string     `json:"message,omitempty"`
+	Code    string     `json:"code,omitempty"`
+}
+
+// GitSourceListResponse wraps the Git source list response.
+type GitSourceListResponse struct {
+	Success bool         `json:"success"`
+	Data    []*GitSource `json:"data"`
+	Message string       `json:"message,omitempty"`
+	Code    string       `json:"code,omitempty"`
+}
+
+// GitSourceFilesResponse wraps the Git source file list response.
+type GitSourceFilesResponse struct {
+	Success bool             `json:"success"`
+	Data    []*GitSourceFile `json:"data"`
+	Message string           `json:"message,omitempty"`
+	Code    string           `json:"code,omitempty"`
+}
+
+type gitSourceSimpleResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	Code    string `json:"code,omitempty"`
+}
+
+// ListGitSources returns the Git sources of a knowledge base.
+func (c *Client) ListGitSources(ctx context.Context, knowledgeBaseID string) ([]*GitSource, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/git-sources", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GitSourceListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// CreateGitSource creates a Git source under a knowledge base. Its first sync is enqueued immediately.
+func (c *Client) CreateGitSource(ctx context.Context,
+	knowledgeBaseID string, request *GitSourceRequest,
+) (*GitSource, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/git-sources", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GitSourceResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetGitSource returns a Git source with its sync status.
+func (c *Client) GetGitSource(ctx context.Context, knowledgeBaseID, sourceID string) (*GitSource, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/git-sources/%s", knowledgeBaseID, sourceID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GitSourceResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// UpdateGitSource updates the repository settings of a Git source, they apply from the next sync.
+func (c *Client) UpdateGitSource(ctx context.Context,
+	knowledgeBaseID, sourceID string, request *GitSourceRequest,
+) (*GitSource, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/git-sources/%s", knowledgeBaseID, sourceID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GitSourceResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DeleteGitSource deletes a Git source.
+// Set deleteKnowledge to true to also delete the knowledge the source created.
+func (c *Client) DeleteGitSource(ctx context.Context,
+	knowledgeBaseID, sourceID string, deleteKnowledge bool,
+) error {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/git-sources/%s", knowledgeBaseID, sourceID)
+	query := url.Values{}
+	if deleteKnowledge {
+		query.Add("delete_knowledge", "true")
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, query)
+	if err != nil {
+		return err
+	}
+
+	var response gitSourceSimpleResponse
+	return parseResponse(resp, &response)
+}
+
+// SyncGitSource enqueues a sync of a Git source.
+func (c *Client) SyncGitSource(ctx context.Context, knowledgeBaseID, sourceID string) (*GitSource, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/git-sources/%s/sync", knowledgeBaseID, sourceID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GitSourceResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// ListGitSourceFiles returns the files a Git source has synced with their commit SHAs.
+func (c *Client) ListGitSourceFiles(ctx context.Context,
+	knowledgeBaseID, sourceID string,
+) ([]*GitSourceFile, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/git-sources/%s/files", knowledgeBaseID, sourceID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response GitSourceFilesResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
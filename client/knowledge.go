@@ -44,6 +44,10 @@ type Knowledge struct {
 	UpdatedAt        time.Time       `json:"updated_at"`
 	ProcessedAt      *time.Time      `json:"processed_at"`
 	ErrorMessage     string          `json:"error_message"`
+	RefreshInterval  int             `json:"refresh_interval"` // Seconds between re-crawls of URL knowledge, 0 disables
+	ContentHash      string          `json:"content_hash"`
+	LastCheckedAt    *time.Time      `json:"last_checked_at"`
+	LastChangedAt    *time.Time      `json:"last_changed_at"`
 }
 
 // KnowledgeResponse represents the API response containing a single knowledge entry
@@ -309,15 +313,153 @@ func (c *Client) DeleteKnowledge(ctx context.Context, knowledgeID string) error
 	return parseResponse(resp, &response)
 }
 
+// SetKnowledgeRefreshInterval sets how often URL knowledge is re-crawled in seconds, 0 disables the refresh
+func (c *Client) SetKnowledgeRefreshInterval(ctx context.Context,
+	knowledgeID string, refreshInterval int,
+) (*Knowledge, error) {
+	path := fmt.Sprintf("/api/v1/knowledge/%s/refresh-schedule", knowledgeID)
+	reqBody := struct {
+		RefreshInterval int `json:"refresh_interval"`
+	}{
+		RefreshInterval: refreshInterval,
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodPut, path, reqBody, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response KnowledgeResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// RefreshKnowledge re-crawls URL knowledge now, it is re-indexed when the page changed
+func (c *Client) RefreshKnowledge(ctx context.Context, knowledgeID string) error {
+	path := fmt.Sprintf("/api/v1/knowledge/%s/refresh", knowledgeID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool   `json:"success"`
+		Message string `json:"message,omitempty"`
+	}
+
+	return parseResponse(resp, &response)
+}
+
+// WebCrawlRequest represents a website crawl request, exactly one of URL and SitemapURL is set
+type WebCrawlRequest struct {
+	URL              string   `json:"url,omitempty"`         // Seed page of the crawl
+	SitemapURL       string   `json:"sitemap_url,omitempty"` // sitemap.xml or sitemap index
+	MaxDepth         *int     `json:"max_depth,omitempty"`   // Link depth from the start pages, default 2
+	MaxPages         int      `json:"max_pages,omitempty"`   // Maximum pages to create, default 100
+	SameHost         *bool    `json:"same_host,omitempty"`   // Stay on the start host, default true
+	Include          []string `json:"include,omitempty"`     // Globs a page must match one of
+	Exclude          []string `json:"exclude,omitempty"`     // Globs of pages to skip
+	EnableMultimodel *bool    `json:"enable_multimodel,omitempty"`
+	RefreshInterval  int      `json:"refresh_interval,omitempty"` // Re-crawl interval of each page in seconds
+}
+
+// WebCrawlProgress represents the progress of a website crawl task
+type WebCrawlProgress struct {
+	TaskID          string `json:"task_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	SeedURL         string `json:"seed_url"`
+	SitemapURL      string `json:"sitemap_url"`
+	Status          string `json:"status"`     // pending, processing, completed or failed
+	Progress        int    `json:"progress"`   // 0-100
+	MaxPages        int    `json:"max_pages"`  // Page limit of the crawl
+	Discovered      int    `json:"discovered"` // Distinct URLs found
+	Processed       int    `json:"processed"`  // Pages submitted for import
+	Created         int    `json:"created"`    // Knowledge entries created
+	Duplicated      int    `json:"duplicated"` // Pages already in the knowledge base
+	Skipped         int    `json:"skipped"`    // URLs dropped by robots.txt, fetch errors or non-HTML content
+	Failed          int    `json:"failed"`     // Pages whose knowledge could not be created
+	Message         string `json:"message"`
+	Error           string `json:"error"`
+	CreatedAt       int64  `json:"created_at"`
+	UpdatedAt       int64  `json:"updated_at"`
+}
+
+// CrawlWebsite starts an asynchronous crawl that creates one knowledge entry per page of a website
+func (c *Client) CrawlWebsite(
+	ctx context.Context,
+	knowledgeBaseID string,
+	request *WebCrawlRequest,
+) (*WebCrawlProgress, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/knowledge/crawl", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool             `json:"success"`
+		Data    WebCrawlProgress `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// GetWebCrawlProgress gets the progress of a website crawl task
+func (c *Client) GetWebCrawlProgress(ctx context.Context, taskID string) (*WebCrawlProgress, error) {
+	path := fmt.Sprintf("/api/v1/knowledge/crawl/progress/%s", taskID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool             `json:"success"`
+		Data    WebCrawlProgress `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
 // DownloadKnowledgeFile downloads a knowledge file to the specified local path
 func (c *Client) DownloadKnowledgeFile(ctx context.Context, knowledgeID string, destPath string) error {
 	path := fmt.Sprintf("/api/v1/knowledge/%s/download", knowledgeID)
-	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	// The server may redirect to a presigned storage URL, which is fetched
+	// without credentials so the API key is not sent to the storage host
+	noRedirect := *c.httpClient
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := noRedirect.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
+	if location := resp.Header.Get("Location"); resp.StatusCode == http.StatusFound && location != "" {
+		fileReq, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		resp, err = c.httpClient.Do(fileReq)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+	}
+
 	// Check for HTTP errors
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
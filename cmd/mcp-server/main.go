@@ -0,0 +1,46 @@
+// Command mcp-server serves the WeKnora knowledge bases of a tenant to local MCP clients over stdio.
+//
+// It uses the same configuration and storage as the WeKnora server. The tenant is selected by its API key:
+//
+//	WEKNORA_API_KEY=sk-... mcp-server
+//
+// The SSE and streamable HTTP transports are served by the WeKnora server itself under /api/v1/mcp.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/dig"
+
+	"github.com/Tencent/WeKnora/internal/container"
+	"github.com/Tencent/WeKnora/internal/mcpserver"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+func main() {
+	apiKey := flag.String("api-key", os.Getenv("WEKNORA_API_KEY"),
+		"API key of the tenant to serve, defaults to $WEKNORA_API_KEY")
+	flag.Parse()
+
+	// Stdout carries the MCP protocol, anything else printed while starting up goes to stderr
+	protocolOut := os.Stdout
+	os.Stdout = os.Stderr
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c := container.BuildContainer(dig.New())
+	err := c.Invoke(func(server *mcpserver.Server, cleaner interfaces.ResourceCleaner) error {
+		defer cleaner.Cleanup(context.Background())
+		return server.ServeStdio(ctx, *apiKey, os.Stdin, protocolOut)
+	})
+	if err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "mcp-server: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,33 @@
+// Command rotate-secrets re-encrypts the secrets stored in the database with the current master key.
+//
+// It uses the same configuration as the WeKnora server. To rotate the master key, configure the new key
+// and keep the old one as a previous key until the command has run:
+//
+//	WEKNORA_MASTER_KEY=new-key WEKNORA_PREVIOUS_MASTER_KEYS=old-key rotate-secrets
+//
+// It also encrypts the secrets stored in plaintext before encryption at rest was enabled.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.uber.org/dig"
+	"gorm.io/gorm"
+
+	"github.com/Tencent/WeKnora/internal/container"
+	"github.com/Tencent/WeKnora/internal/database"
+)
+
+func main() {
+	c := container.BuildDatabaseContainer(dig.New())
+	err := c.Invoke(func(db *gorm.DB) error {
+		return database.RotateSecrets(context.Background(), db)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rotate-secrets: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("rotate-secrets: all secrets are encrypted with the current master key")
+}
@@ -85,7 +85,8 @@ export function delKnowledgeDetails(id: string) {
 }
 
 export function downKnowledgeDetails(id: string) {
-  return getDown(`/api/v1/knowledge/${id}/download`);
+  // 通过带鉴权的请求下载为 blob，跨域重定向到对象存储会被浏览器拦截，因此由服务端转发
+  return getDown(`/api/v1/knowledge/${id}/download?redirect=false`);
 }
 
 export function batchQueryKnowledge(idsQueryString: string) {
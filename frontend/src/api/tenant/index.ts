@@ -1,4 +1,4 @@
-import { get } from '@/utils/request'
+import { get, post } from '@/utils/request'
 
 // 租户信息接口
 export interface TenantInfo {
@@ -81,3 +81,17 @@ export async function searchTenants(params: SearchTenantsParams = {}): Promise<S
   }
 }
 
+/**
+ * 获取租户完整的 API Key（查询接口只返回脱敏后的值，需要管理员权限）
+ */
+export async function revealTenantApiKey(tenantId: number | string): Promise<{ success: boolean; data?: { api_key: string }; message?: string }> {
+  try {
+    const response = await post(`/api/v1/tenants/${tenantId}/api-key/reveal`)
+    return response as unknown as { success: boolean; data?: { api_key: string }; message?: string }
+  } catch (error: any) {
+    return {
+      success: false,
+      message: error.message || '获取 API Key 失败'
+    }
+  }
+}
@@ -1502,7 +1502,8 @@ export default {
       createdAtDescription: 'Time when the account was created',
       noKey: 'No API Key available',
       copySuccess: 'API Key copied to clipboard',
-      copyFailed: 'Copy failed, please copy manually'
+      copyFailed: 'Copy failed, please copy manually',
+      revealFailed: 'Failed to get the API Key, only administrators can view it'
     }
   },
   system: {
@@ -851,6 +851,7 @@ export default {
       noKey: "API 키 없음",
       copySuccess: "API 키가 클립보드에 복사되었습니다",
       copyFailed: "복사 실패, 수동으로 복사해주세요",
+      revealFailed: "API 키를 가져오지 못했습니다. 관리자만 확인할 수 있습니다",
     },
   },
   system: {
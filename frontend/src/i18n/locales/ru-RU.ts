@@ -759,7 +759,8 @@ export default {
       createdAtDescription: 'Время создания учётной записи',
       noKey: 'API Key отсутствует',
       copySuccess: 'API Key скопирован в буфер обмена',
-      copyFailed: 'Не удалось скопировать, пожалуйста, сделайте это вручную'
+      copyFailed: 'Не удалось скопировать, пожалуйста, сделайте это вручную',
+      revealFailed: 'Не удалось получить API Key, его могут просматривать только администраторы'
     }
   },
   system: {
@@ -1043,6 +1043,7 @@ export default {
       noKey: "暂无 API Key",
       copySuccess: "API Key 已复制到剪贴板",
       copyFailed: "复制失败，请手动复制",
+      revealFailed: "获取 API Key 失败，仅管理员可查看",
     },
   },
   system: {
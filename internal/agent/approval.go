@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// approvalSnapshot is the engine state persisted while a run waits for tool approval.
+// It holds everything needed to execute the pending round and continue the ReAct loop.
+type approvalSnapshot struct {
+	Query     string              `json:"query"`
+	Messages  []chat.Message      `json:"messages"`
+	State     *types.AgentState   `json:"state"`
+	Step      types.AgentStep     `json:"step"`
+	ToolCalls []types.LLMToolCall `json:"tool_calls"`
+}
+
+// toolCallsRequiringApproval returns the tool calls gated by the approval policy
+func (e *AgentEngine) toolCallsRequiringApproval(toolCalls []types.LLMToolCall) types.PendingToolCalls {
+	var pending types.PendingToolCalls
+	for _, tc := range toolCalls {
+		if !e.config.ToolApproval.RequiresApproval(tc.Function.Name) {
+			continue
+		}
+		var args map[string]interface{}
+		_ = json.Unmarshal([]byte(tc.Function.Arguments), &args)
+		pending = append(pending, types.PendingToolCall{
+			ID:        tc.ID,
+			Name:      tc.Function.Name,
+			Arguments: args,
+		})
+	}
+	return pending
+}
+
+// pauseForApproval stops the run before executing a round with gated tool calls.
+// The returned state carries the snapshot in state.Pause, which the caller persists.
+func (e *AgentEngine) pauseForApproval(
+	ctx context.Context,
+	state *types.AgentState,
+	query string,
+	messages []chat.Message,
+	step types.AgentStep,
+	toolCalls []types.LLMToolCall,
+	pending types.PendingToolCalls,
+	sessionID, messageID string,
+) (*types.AgentState, error) {
+	snapshot, err := json.Marshal(&approvalSnapshot{
+		Query:     query,
+		Messages:  messages,
+		State:     state,
+		Step:      step,
+		ToolCalls: toolCalls,
+	})
+	if err != nil {
+		return state, fmt.Errorf("failed to snapshot agent state: %w", err)
+	}
+	state.Pause = &types.AgentPause{
+		ToolCalls: pending,
+		Snapshot:  snapshot,
+	}
+
+	logger.Infof(ctx, "[Agent][Round-%d] Paused for approval of %d tool call(s)", state.CurrentRound+1, len(pending))
+	common.PipelineInfo(ctx, "Agent", "approval_required", map[string]interface{}{
+		"iteration":  state.CurrentRound,
+		"message_id": messageID,
+		"tool_calls": len(pending),
+	})
+	e.eventBus.Emit(ctx, event.Event{
+		ID:        generateEventID("approval"),
+		Type:      event.EventAgentApprovalRequired,
+		SessionID: sessionID,
+		Data: event.AgentApprovalRequiredData{
+			MessageID: messageID,
+			ToolCalls: pending,
+			Iteration: state.CurrentRound,
+		},
+	})
+	return state, nil
+}
+
+// Resume continues a run paused for tool approval.
+// When approved, the pending round is executed and the ReAct loop goes on; otherwise the run is aborted.
+func (e *AgentEngine) Resume(
+	ctx context.Context,
+	sessionID, messageID string,
+	snapshot types.AgentSnapshot,
+	approved bool,
+	reason string,
+) (*types.AgentState, error) {
+	logger.Infof(ctx, "========== Agent Resume Started (approved: %v) ==========", approved)
+	defer e.cleanupTools(ctx)
+
+	var snap approvalSnapshot
+	if err := json.Unmarshal(snapshot, &snap); err != nil || snap.State == nil {
+		return nil, fmt.Errorf("invalid agent snapshot: %v", err)
+	}
+	state, step := snap.State, snap.Step
+	startTime := time.Now()
+
+	if !approved {
+		e.rejectToolCalls(ctx, state, &step, snap.ToolCalls, reason, sessionID)
+		e.emitComplete(ctx, state, sessionID, messageID, startTime)
+		return state, nil
+	}
+
+	e.executeToolCalls(ctx, state, &step, snap.ToolCalls, sessionID)
+	messages := e.completeRound(ctx, state, snap.Messages, step)
+
+	if _, err := e.executeLoop(ctx, state, snap.Query, messages, e.buildToolsForLLM(), sessionID, messageID); err != nil {
+		logger.Errorf(ctx, "[Agent] Resumed execution failed: %v", err)
+		e.eventBus.Emit(ctx, event.Event{
+			ID:        generateEventID("error"),
+			Type:      event.EventError,
+			SessionID: sessionID,
+			Data: event.ErrorData{
+				Error:     err.Error(),
+				Stage:     "agent_execution",
+				SessionID: sessionID,
+			},
+		})
+		return nil, err
+	}
+	return state, nil
+}
+
+// rejectToolCalls records the pending tool calls as rejected and finishes the run
+func (e *AgentEngine) rejectToolCalls(
+	ctx context.Context,
+	state *types.AgentState,
+	step *types.AgentStep,
+	toolCalls []types.LLMToolCall,
+	reason, sessionID string,
+) {
+	rejection := "Tool call rejected by operator"
+	if reason != "" {
+		rejection += ": " + reason
+	}
+	for _, tc := range toolCalls {
+		var args map[string]any
+		_ = json.Unmarshal([]byte(tc.Function.Arguments), &args)
+		result := &types.ToolResult{Success: false, Error: rejection}
+		step.ToolCalls = append(step.ToolCalls, types.ToolCall{
+			ID:     tc.ID,
+			Name:   tc.Function.Name,
+			Args:   args,
+			Result: result,
+		})
+		e.eventBus.Emit(ctx, event.Event{
+			ID:        tc.ID + "-tool-result",
+			Type:      event.EventAgentToolResult,
+			SessionID: sessionID,
+			Data: event.AgentToolResultData{
+				ToolCallID: tc.ID,
+				ToolName:   tc.Function.Name,
+				Error:      result.Error,
+				Success:    false,
+				Iteration:  state.CurrentRound,
+			},
+		})
+	}
+	state.RoundSteps = append(state.RoundSteps, *step)
+
+	state.FinalAnswer = "工具调用未获批准，已终止本次执行。"
+	if reason != "" {
+		state.FinalAnswer += "原因：" + reason
+	}
+	state.IsComplete = true
+	e.eventBus.Emit(ctx, event.Event{
+		ID:        generateEventID("answer"),
+		Type:      event.EventAgentFinalAnswer,
+		SessionID: sessionID,
+		Data: event.AgentFinalAnswerData{
+			Content: state.FinalAnswer,
+			Done:    true,
+		},
+	})
+	common.PipelineWarn(ctx, "Agent", "approval_rejected", map[string]interface{}{
+		"iteration":  state.CurrentRound,
+		"tool_calls": len(toolCalls),
+		"reason":     reason,
+	})
+}
@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// scriptedChat requests a single tool call, then answers with "done"
+type scriptedChat struct {
+	toolName string
+	calls    int
+}
+
+func (m *scriptedChat) Chat(ctx context.Context, messages []chat.Message, opts *chat.ChatOptions) (*types.ChatResponse, error) {
+	return &types.ChatResponse{Content: "done", FinishReason: "stop"}, nil
+}
+
+func (m *scriptedChat) ChatStream(
+	ctx context.Context,
+	messages []chat.Message,
+	opts *chat.ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	m.calls++
+	ch := make(chan types.StreamResponse, 1)
+	if m.calls == 1 {
+		tc := types.LLMToolCall{ID: "call-1", Type: "function"}
+		tc.Function.Name = m.toolName
+		tc.Function.Arguments = `{"sql":"DELETE FROM t"}`
+		ch <- types.StreamResponse{ToolCalls: []types.LLMToolCall{tc}, Done: true}
+	} else {
+		ch <- types.StreamResponse{ResponseType: types.ResponseTypeAnswer, Content: "done", Done: true}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (m *scriptedChat) GetModelName() string { return "scripted" }
+
+func (m *scriptedChat) GetModelID() string { return "scripted" }
+
+type countingTool struct {
+	name  string
+	calls int
+}
+
+func (t *countingTool) Name() string                { return t.name }
+func (t *countingTool) Description() string         { return "test tool" }
+func (t *countingTool) Parameters() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+
+func (t *countingTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	t.calls++
+	return &types.ToolResult{Success: true, Output: "ok"}, nil
+}
+
+func newApprovalTestEngine(chatModel chat.Chat, tool *countingTool, eventBus *event.EventBus) *AgentEngine {
+	registry := tools.NewToolRegistry()
+	registry.RegisterTool(tool)
+	config := &types.AgentConfig{
+		MaxIterations: 3,
+		AllowedTools:  []string{tool.name},
+		ToolApproval:  &types.ToolApprovalPolicy{Tools: []string{"db_*"}},
+	}
+	return NewAgentEngine(config, chatModel, registry, eventBus, nil, nil, nil, "session-1", "")
+}
+
+func TestAgentEngineApproval(t *testing.T) {
+	tests := []struct {
+		name          string
+		approved      bool
+		wantToolCalls int
+		wantAnswer    string
+	}{
+		{name: "approved", approved: true, wantToolCalls: 1, wantAnswer: "done"},
+		{name: "rejected", approved: false, wantToolCalls: 0, wantAnswer: "工具调用未获批准"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tool := &countingTool{name: "db_write"}
+			chatModel := &scriptedChat{toolName: tool.name}
+
+			eventBus := event.NewEventBus()
+			var approvalEvents int
+			eventBus.On(event.EventAgentApprovalRequired, func(ctx context.Context, evt event.Event) error {
+				approvalEvents++
+				return nil
+			})
+
+			state, err := newApprovalTestEngine(chatModel, tool, eventBus).Execute(ctx, "session-1", "message-1", "clean up", nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if state.Pause == nil || len(state.Pause.ToolCalls) != 1 || state.Pause.ToolCalls[0].Name != tool.name {
+				t.Fatalf("expected run to pause on %s, got %+v", tool.name, state.Pause)
+			}
+			if tool.calls != 0 || approvalEvents != 1 {
+				t.Fatalf("gated tool ran before approval: calls=%d events=%d", tool.calls, approvalEvents)
+			}
+
+			// Resume from the persisted snapshot with a fresh engine, as after a restart
+			resumed, err := newApprovalTestEngine(chatModel, tool, nil).Resume(
+				ctx, "session-1", "message-1", state.Pause.Snapshot, tt.approved, "not allowed")
+			if err != nil {
+				t.Fatalf("unexpected resume error: %v", err)
+			}
+			if tool.calls != tt.wantToolCalls {
+				t.Fatalf("tool executed %d times, want %d", tool.calls, tt.wantToolCalls)
+			}
+			if !resumed.IsComplete || !strings.HasPrefix(resumed.FinalAnswer, tt.wantAnswer) {
+				t.Fatalf("unexpected final state: complete=%v answer=%q", resumed.IsComplete, resumed.FinalAnswer)
+			}
+			if resumed.Pause != nil {
+				t.Fatal("resumed run should not pause again")
+			}
+		})
+	}
+}
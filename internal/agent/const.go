@@ -1,5 +1,7 @@
 package agent
 
+import "time"
+
 const (
 	// DefaultAgentTemperature is the default temperature for the agent
 	DefaultAgentTemperature = 0.7
@@ -9,4 +11,12 @@ const (
 	DefaultAgentReflectionEnabled = false
 	// DefaultUseCustomSystemPrompt is the default whether to use custom system prompt for the agent
 	DefaultUseCustomSystemPrompt = false
+	// DefaultToolConcurrency is the default maximum number of tool calls of one round running at once
+	DefaultToolConcurrency = 4
+	// DefaultToolTimeout is the default timeout of a tool call
+	DefaultToolTimeout = 2 * time.Minute
+	// DefaultSubAgentToolTimeout is the default timeout of a call delegating to a sub-agent
+	DefaultSubAgentToolTimeout = 10 * time.Minute
+	// MaxMCPContextItemChars is the number of characters of a pinned MCP prompt or resource put in the prompt
+	MaxMCPContextItemChars = 8000
 )
@@ -3,8 +3,11 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/agent/tools"
@@ -33,6 +36,10 @@ type AgentEngine struct {
 	contextManager       interfaces.ContextManager // Context manager for writing agent conversation to LLM context
 	sessionID            string                    // Session ID for context management
 	systemPromptTemplate string                    // System prompt template (optional, uses default if empty)
+
+	lastSerialCall *toolCallRun   // Last call of a serial tool, the next one waits for it to return
+	runningCalls   sync.WaitGroup // Tool executions that have not returned yet, including abandoned ones
+	abandonedCalls atomic.Int32   // Tool executions abandoned after a timeout that have not returned yet
 }
 
 // listToolNames returns tool.function names for logging
@@ -72,6 +79,17 @@ func NewAgentEngine(
 	}
 }
 
+// buildSystemPrompt builds the progressive RAG system prompt followed by the pinned MCP context
+func (e *AgentEngine) buildSystemPrompt() string {
+	systemPrompt := BuildSystemPrompt(
+		e.knowledgeBasesInfo,
+		e.config.WebSearchEnabled,
+		e.selectedDocs,
+		e.systemPromptTemplate,
+	)
+	return systemPrompt + formatMCPContext(e.config.MCPContext)
+}
+
 // Execute executes the agent with conversation history and streaming output
 // All events are emitted to EventBus and handled by subscribers (like Handler layer)
 func (e *AgentEngine) Execute(
@@ -81,7 +99,7 @@ func (e *AgentEngine) Execute(
 ) (*types.AgentState, error) {
 	logger.Infof(ctx, "========== Agent Execution Started ==========")
 	// Ensure tools are cleaned up after execution
-	defer e.toolRegistry.Cleanup(ctx)
+	defer e.cleanupTools(ctx)
 
 	logger.Infof(ctx, "[Agent] SessionID: %s, MessageID: %s", sessionID, messageID)
 	logger.Infof(ctx, "[Agent] User Query: %s", query)
@@ -102,12 +120,7 @@ func (e *AgentEngine) Execute(
 	}
 
 	// Build system prompt using progressive RAG prompt
-	systemPrompt := BuildSystemPrompt(
-		e.knowledgeBasesInfo,
-		e.config.WebSearchEnabled,
-		e.selectedDocs,
-		e.systemPromptTemplate,
-	)
+	systemPrompt := e.buildSystemPrompt()
 	logger.Debugf(ctx, "[Agent] SystemPrompt Length: %d characters", len(systemPrompt))
 	logger.Debugf(ctx, "[Agent] SystemPrompt (stream)\n----\n%s\n----", systemPrompt)
 
@@ -161,7 +174,7 @@ func (e *AgentEngine) executeLoop(
 	state *types.AgentState,
 	query string,
 	messages []chat.Message,
-	tools []chat.Tool,
+	llmTools []chat.Tool,
 	sessionID string,
 	messageID string,
 ) (*types.AgentState, error) {
@@ -177,18 +190,18 @@ func (e *AgentEngine) executeLoop(
 			"iteration":      state.CurrentRound,
 			"round":          state.CurrentRound + 1,
 			"message_count":  len(messages),
-			"pending_tools":  len(tools),
+			"pending_tools":  len(llmTools),
 			"max_iterations": e.config.MaxIterations,
 		})
 
 		// 1. Think: Call LLM with function calling and stream thinking through EventBus
-		logger.Infof(ctx, "[Agent][Round-%d] Calling LLM with %d tools available...", state.CurrentRound+1, len(tools))
+		logger.Infof(ctx, "[Agent][Round-%d] Calling LLM with %d tools available...", state.CurrentRound+1, len(llmTools))
 		common.PipelineInfo(ctx, "Agent", "think_start", map[string]interface{}{
 			"iteration": state.CurrentRound,
 			"round":     state.CurrentRound + 1,
-			"tool_cnt":  len(tools),
+			"tool_cnt":  len(llmTools),
 		})
-		response, err := e.streamThinkingToEventBus(ctx, messages, tools, state.CurrentRound, sessionID)
+		response, err := e.streamThinkingToEventBus(ctx, messages, llmTools, state.CurrentRound, sessionID)
 		if err != nil {
 			logger.Errorf(ctx, "[Agent][Round-%d] LLM call failed: %v", state.CurrentRound+1, err)
 			common.PipelineError(ctx, "Agent", "think_failed", map[string]interface{}{
@@ -247,6 +260,7 @@ func (e *AgentEngine) executeLoop(
 				Data: event.AgentFinalAnswerData{
 					Content: "",
 					Done:    true,
+					ModelID: response.ModelID,
 				},
 			})
 			logger.Infof(
@@ -260,6 +274,11 @@ func (e *AgentEngine) executeLoop(
 
 		// 3. Act: Execute tool calls if any
 		if len(response.ToolCalls) > 0 {
+			// Pause before running tools that need an operator's approval
+			if pending := e.toolCallsRequiringApproval(response.ToolCalls); len(pending) > 0 {
+				return e.pauseForApproval(ctx, state, query, messages, step, response.ToolCalls, pending, sessionID, messageID)
+			}
+
 			logger.Infof(
 				ctx,
 				"[Agent][Round-%d] Executing %d tool calls...",
@@ -267,186 +286,11 @@ func (e *AgentEngine) executeLoop(
 				len(response.ToolCalls),
 			)
 
-			for i, tc := range response.ToolCalls {
-				logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool: %s, ID: %s",
-					state.CurrentRound+1, i+1, len(response.ToolCalls), tc.Function.Name, tc.ID)
-
-				var args map[string]any
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-					logger.Errorf(ctx, "[Agent][Round-%d][Tool-%d/%d] Failed to parse tool arguments: %v",
-						state.CurrentRound+1, i+1, len(response.ToolCalls), err)
-					continue
-				}
-
-				// Log the arguments in a readable format
-				argsJSON, _ := json.MarshalIndent(args, "", "  ")
-				logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Arguments:\n%s",
-					state.CurrentRound+1, i+1, len(response.ToolCalls), string(argsJSON))
-
-				toolCallStartTime := time.Now()
-				e.eventBus.Emit(ctx, event.Event{
-					ID:        tc.ID + "-tool-call",
-					Type:      event.EventAgentToolCall,
-					SessionID: sessionID,
-					Data: event.AgentToolCallData{
-						ToolCallID: tc.ID,
-						ToolName:   tc.Function.Name,
-						Arguments:  args,
-						Iteration:  state.CurrentRound,
-					},
-				})
-				logger.Debugf(ctx, "[Agent] ToolCall -> %s args=%s", tc.Function.Name, tc.Function.Arguments)
-
-				// Execute tool
-				logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Executing tool: %s...",
-					state.CurrentRound+1, i+1, len(response.ToolCalls), tc.Function.Name)
-				common.PipelineInfo(ctx, "Agent", "tool_call_start", map[string]interface{}{
-					"iteration":    state.CurrentRound,
-					"round":        state.CurrentRound + 1,
-					"tool":         tc.Function.Name,
-					"tool_call_id": tc.ID,
-					"tool_index":   fmt.Sprintf("%d/%d", i+1, len(response.ToolCalls)),
-				})
-				result, err := e.toolRegistry.ExecuteTool(ctx, tc.Function.Name, json.RawMessage(tc.Function.Arguments))
-				duration := time.Since(toolCallStartTime).Milliseconds()
-				logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool execution completed in %dms",
-					state.CurrentRound+1, i+1, len(response.ToolCalls), duration)
-
-				toolCall := types.ToolCall{
-					ID:       tc.ID,
-					Name:     tc.Function.Name,
-					Args:     args,
-					Result:   result,
-					Duration: duration,
-				}
-
-				if err != nil {
-					logger.Errorf(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool call failed: %s, error: %v",
-						state.CurrentRound+1, i+1, len(response.ToolCalls), tc.Function.Name, err)
-					toolCall.Result = &types.ToolResult{
-						Success: false,
-						Error:   err.Error(),
-					}
-				}
-
-				toolSuccess := toolCall.Result != nil && toolCall.Result.Success
-				pipelineFields := map[string]interface{}{
-					"iteration":    state.CurrentRound,
-					"round":        state.CurrentRound + 1,
-					"tool":         tc.Function.Name,
-					"tool_call_id": tc.ID,
-					"duration_ms":  duration,
-					"success":      toolSuccess,
-				}
-				if toolCall.Result != nil && toolCall.Result.Error != "" {
-					pipelineFields["error"] = toolCall.Result.Error
-				}
-				if err != nil {
-					common.PipelineError(ctx, "Agent", "tool_call_result", pipelineFields)
-				} else if toolSuccess {
-					common.PipelineInfo(ctx, "Agent", "tool_call_result", pipelineFields)
-				} else {
-					common.PipelineWarn(ctx, "Agent", "tool_call_result", pipelineFields)
-				}
-
-				if toolCall.Result != nil {
-					logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool result: success=%v, output_length=%d",
-						state.CurrentRound+1, i+1, len(response.ToolCalls),
-						toolCall.Result.Success, len(toolCall.Result.Output))
-					logger.Debugf(ctx, "[Agent] ToolResult <- %s success=%v len(output)=%d",
-						tc.Function.Name, toolCall.Result.Success, len(toolCall.Result.Output))
-
-					// Log the output content for debugging
-					if toolCall.Result.Output != "" {
-						// Truncate if too long for logging
-						outputPreview := toolCall.Result.Output
-						if len(outputPreview) > 500 {
-							outputPreview = outputPreview[:500] + "... (truncated)"
-						}
-						logger.Debugf(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool output preview:\n%s",
-							state.CurrentRound+1, i+1, len(response.ToolCalls), outputPreview)
-					}
-
-					if toolCall.Result.Error != "" {
-						logger.Warnf(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool error: %s",
-							state.CurrentRound+1, i+1, len(response.ToolCalls), toolCall.Result.Error)
-					}
-
-					// Log structured data if present
-					if toolCall.Result.Data != nil {
-						dataJSON, _ := json.MarshalIndent(toolCall.Result.Data, "", "  ")
-						logger.Debugf(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool data:\n%s",
-							state.CurrentRound+1, i+1, len(response.ToolCalls), string(dataJSON))
-					}
-				}
-
-				// Store tool call (Observations are now derived from ToolCall.Result.Output)
-				step.ToolCalls = append(step.ToolCalls, toolCall)
-
-				// Emit tool result event (include structured data from tool result)
-				e.eventBus.Emit(ctx, event.Event{
-					ID:        tc.ID + "-tool-result",
-					Type:      event.EventAgentToolResult,
-					SessionID: sessionID,
-					Data: event.AgentToolResultData{
-						ToolCallID: tc.ID,
-						ToolName:   tc.Function.Name,
-						Output:     result.Output,
-						Error:      result.Error,
-						Success:    result.Success,
-						Duration:   duration,
-						Iteration:  state.CurrentRound,
-						Data:       result.Data, // Pass structured data for frontend rendering
-					},
-				})
-
-				// Emit tool execution event (for internal monitoring)
-				e.eventBus.Emit(ctx, event.Event{
-					ID:        tc.ID + "-tool-exec",
-					Type:      event.EventAgentTool,
-					SessionID: sessionID,
-					Data: event.AgentActionData{
-						Iteration:  state.CurrentRound,
-						ToolName:   tc.Function.Name,
-						ToolInput:  args,
-						ToolOutput: result.Output,
-						Success:    result.Success,
-						Error:      result.Error,
-						Duration:   duration,
-					},
-				})
-
-				// Optional: Reflection after each tool call (streaming)
-				if e.config.ReflectionEnabled && result != nil {
-					reflection, err := e.streamReflectionToEventBus(
-						ctx, tc.ID, tc.Function.Name, result.Output,
-						state.CurrentRound, sessionID,
-					)
-					if err != nil {
-						logger.Warnf(ctx, "Reflection failed: %v", err)
-					} else if reflection != "" {
-						// Store reflection in the corresponding tool call
-						// Find the tool call we just added and update it
-						if len(step.ToolCalls) > 0 {
-							lastIdx := len(step.ToolCalls) - 1
-							step.ToolCalls[lastIdx].Reflection = reflection
-						}
-					}
-				}
-			}
+			e.executeToolCalls(ctx, state, &step, response.ToolCalls, sessionID)
 		}
 
-		state.RoundSteps = append(state.RoundSteps, step)
 		// 4. Observe: Add tool results to messages and write to context
-		messages = e.appendToolResults(ctx, messages, step)
-		common.PipelineInfo(ctx, "Agent", "round_end", map[string]interface{}{
-			"iteration":   state.CurrentRound,
-			"round":       state.CurrentRound + 1,
-			"tool_calls":  len(step.ToolCalls),
-			"thought_len": len(step.Thought),
-		})
-		// 5. Check if we should continue
-		state.CurrentRound++
+		messages = e.completeRound(ctx, state, messages, step)
 	}
 
 	// If loop finished without final answer, generate one
@@ -468,7 +312,39 @@ func (e *AgentEngine) executeLoop(
 		state.IsComplete = true
 	}
 
-	// Emit completion event
+	e.emitComplete(ctx, state, sessionID, messageID, startTime)
+
+	logger.Infof(ctx, "Agent execution completed in %d rounds", state.CurrentRound)
+	return state, nil
+}
+
+// completeRound records the step of a finished round and appends its tool results to the messages
+func (e *AgentEngine) completeRound(
+	ctx context.Context,
+	state *types.AgentState,
+	messages []chat.Message,
+	step types.AgentStep,
+) []chat.Message {
+	state.RoundSteps = append(state.RoundSteps, step)
+	messages = e.appendToolResults(ctx, messages, step)
+	common.PipelineInfo(ctx, "Agent", "round_end", map[string]interface{}{
+		"iteration":   state.CurrentRound,
+		"round":       state.CurrentRound + 1,
+		"tool_calls":  len(step.ToolCalls),
+		"thought_len": len(step.Thought),
+	})
+	// Check if we should continue
+	state.CurrentRound++
+	return messages
+}
+
+// emitComplete emits the completion event carrying the final state of the run
+func (e *AgentEngine) emitComplete(
+	ctx context.Context,
+	state *types.AgentState,
+	sessionID, messageID string,
+	startTime time.Time,
+) {
 	// Convert knowledge refs to interface{} slice for event data
 	knowledgeRefsInterface := make([]interface{}, 0, len(state.KnowledgeRefs))
 	for _, ref := range state.KnowledgeRefs {
@@ -488,9 +364,348 @@ func (e *AgentEngine) executeLoop(
 			MessageID:       messageID, // Include message ID for proper message update
 		},
 	})
+}
 
-	logger.Infof(ctx, "Agent execution completed in %d rounds", state.CurrentRound)
-	return state, nil
+// toolCallRun is a tool call of the current round together with the outcome of its execution
+type toolCallRun struct {
+	index      int
+	call       types.LLMToolCall
+	args       map[string]any
+	result     *types.ToolResult
+	err        error
+	startedAt  time.Time
+	finishedAt time.Time
+	done       chan struct{} // Closed once the call finished or timed out
+	returned   chan struct{} // Closed once the tool returned, which can be after the call timed out
+}
+
+// executeToolCalls executes the tool calls of one round and records them on the step.
+// Calls run concurrently up to the configured cap, while their results, events and the step trace keep
+// the order in which the model requested them.
+func (e *AgentEngine) executeToolCalls(
+	ctx context.Context,
+	state *types.AgentState,
+	step *types.AgentStep,
+	toolCalls []types.LLMToolCall,
+	sessionID string,
+) {
+	actStart := time.Now()
+	runs := make([]*toolCallRun, 0, len(toolCalls))
+	for i, tc := range toolCalls {
+		logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool: %s, ID: %s",
+			state.CurrentRound+1, i+1, len(toolCalls), tc.Function.Name, tc.ID)
+
+		var args map[string]any
+		if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+			logger.Errorf(ctx, "[Agent][Round-%d][Tool-%d/%d] Failed to parse tool arguments: %v",
+				state.CurrentRound+1, i+1, len(toolCalls), err)
+			continue
+		}
+
+		// Log the arguments in a readable format
+		argsJSON, _ := json.MarshalIndent(args, "", "  ")
+		logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Arguments:\n%s",
+			state.CurrentRound+1, i+1, len(toolCalls), string(argsJSON))
+
+		e.eventBus.Emit(ctx, event.Event{
+			ID:        tc.ID + "-tool-call",
+			Type:      event.EventAgentToolCall,
+			SessionID: sessionID,
+			Data: event.AgentToolCallData{
+				ToolCallID: tc.ID,
+				ToolName:   tc.Function.Name,
+				Arguments:  args,
+				Iteration:  state.CurrentRound,
+			},
+		})
+		logger.Debugf(ctx, "[Agent] ToolCall -> %s args=%s", tc.Function.Name, tc.Function.Arguments)
+
+		runs = append(runs, &toolCallRun{
+			index: i, call: tc, args: args, done: make(chan struct{}), returned: make(chan struct{}),
+		})
+	}
+
+	go e.dispatchToolCalls(ctx, state.CurrentRound, runs, len(toolCalls))
+
+	// Record the calls in request order, each as soon as it and the calls before it are done
+	var totalDuration int64
+	for _, run := range runs {
+		<-run.done
+		totalDuration += run.finishedAt.Sub(run.startedAt).Milliseconds()
+		e.recordToolCall(ctx, state, step, run, len(toolCalls), sessionID)
+	}
+
+	step.ToolsDuration = time.Since(actStart).Milliseconds()
+	if len(runs) > 1 {
+		logger.Infof(ctx, "[Agent][Round-%d] %d tool calls took %dms wall-clock, %dms in total",
+			state.CurrentRound+1, len(runs), step.ToolsDuration, totalDuration)
+	}
+}
+
+// dispatchToolCalls starts the tool calls in request order, keeping at most the configured number
+// running at once. Calls of serial tools additionally wait for the previous call of a serial tool,
+// including one of an earlier round, to return.
+func (e *AgentEngine) dispatchToolCalls(ctx context.Context, iteration int, runs []*toolCallRun, total int) {
+	slots := make(chan struct{}, e.config.ToolExecution.Concurrency(DefaultToolConcurrency))
+	for _, run := range runs {
+		var after *toolCallRun
+		if e.toolRegistry.IsSerial(run.call.Function.Name) {
+			after, e.lastSerialCall = e.lastSerialCall, run
+		}
+		slots <- struct{}{}
+		go func(run, after *toolCallRun) {
+			defer func() { <-slots }()
+			e.runToolCall(ctx, iteration, run, after, total)
+		}(run, after)
+	}
+}
+
+// runToolCall executes a tool call with its timeout, once the previous serial call given as after has
+// returned. A tool that does not return once its context is done is abandoned, and the call fails with
+// a timeout error.
+func (e *AgentEngine) runToolCall(ctx context.Context, iteration int, run, after *toolCallRun, total int) {
+	defer close(run.done)
+
+	name := run.call.Function.Name
+	timeout := e.toolTimeout(name)
+	logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Executing tool: %s (timeout %s)...",
+		iteration+1, run.index+1, total, name, timeout)
+	common.PipelineInfo(ctx, "Agent", "tool_call_start", map[string]interface{}{
+		"iteration":    iteration,
+		"round":        iteration + 1,
+		"tool":         name,
+		"tool_call_id": run.call.ID,
+		"tool_index":   fmt.Sprintf("%d/%d", run.index+1, total),
+	})
+
+	toolCtx, cancel := context.WithTimeout(tools.WithToolCallID(ctx, run.call.ID), timeout)
+	defer cancel()
+
+	run.startedAt = time.Now()
+	if after != nil {
+		select {
+		case <-after.returned:
+		case <-toolCtx.Done():
+			// The call never runs, the next serial call still has to wait for the one still running
+			go func() {
+				<-after.returned
+				close(run.returned)
+			}()
+			run.err = fmt.Errorf("tool %s timed out waiting for the previous %s call to return",
+				name, after.call.Function.Name)
+			run.finishedAt = time.Now()
+			return
+		}
+	}
+
+	type outcome struct {
+		result *types.ToolResult
+		err    error
+	}
+	outcomeCh := make(chan outcome, 1)
+	e.runningCalls.Add(1)
+	go func() {
+		defer e.runningCalls.Done()
+		defer close(run.returned)
+		defer func() {
+			if r := recover(); r != nil {
+				outcomeCh <- outcome{err: fmt.Errorf("tool %s panicked: %v", name, r)}
+			}
+		}()
+		result, err := e.toolRegistry.ExecuteTool(toolCtx, name, json.RawMessage(run.call.Function.Arguments))
+		outcomeCh <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case o := <-outcomeCh:
+		run.result, run.err = o.result, o.err
+	case <-toolCtx.Done():
+		if errors.Is(toolCtx.Err(), context.DeadlineExceeded) {
+			run.err = fmt.Errorf("tool %s timed out after %s", name, timeout)
+		} else {
+			run.err = fmt.Errorf("tool %s was cancelled: %w", name, toolCtx.Err())
+		}
+		e.abandonedCalls.Add(1)
+		go func() {
+			<-run.returned
+			e.abandonedCalls.Add(-1)
+		}()
+	}
+	run.finishedAt = time.Now()
+}
+
+// cleanupTools cleans up the tools once every tool execution returned. When executions abandoned
+// after a timeout are still running, the cleanup happens in the background once they return.
+func (e *AgentEngine) cleanupTools(ctx context.Context) {
+	if e.abandonedCalls.Load() == 0 {
+		e.runningCalls.Wait()
+		e.toolRegistry.Cleanup(ctx)
+		return
+	}
+	logger.Warnf(ctx, "[Agent] Timed out tool calls are still running, cleaning up tools once they return")
+	cleanupCtx := context.WithoutCancel(ctx)
+	go func() {
+		e.runningCalls.Wait()
+		e.toolRegistry.Cleanup(cleanupCtx)
+	}()
+}
+
+// toolTimeout returns the timeout of a call of the given tool
+func (e *AgentEngine) toolTimeout(name string) time.Duration {
+	fallback := DefaultToolTimeout
+	if strings.HasPrefix(name, tools.ToolSubAgentPrefix) {
+		fallback = DefaultSubAgentToolTimeout
+	}
+	return e.config.ToolExecution.TimeoutFor(name, fallback)
+}
+
+// recordToolCall records a finished tool call on the step and emits its result events
+func (e *AgentEngine) recordToolCall(
+	ctx context.Context,
+	state *types.AgentState,
+	step *types.AgentStep,
+	run *toolCallRun,
+	total int,
+	sessionID string,
+) {
+	tc, i, result, err := run.call, run.index, run.result, run.err
+	duration := run.finishedAt.Sub(run.startedAt).Milliseconds()
+	logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool execution completed in %dms",
+		state.CurrentRound+1, i+1, total, duration)
+
+	toolCall := types.ToolCall{
+		ID:         tc.ID,
+		Name:       tc.Function.Name,
+		Args:       run.args,
+		Result:     result,
+		Duration:   duration,
+		StartedAt:  run.startedAt,
+		FinishedAt: run.finishedAt,
+	}
+
+	// Sub-agent tools hand over their execution trace through the result data
+	if result != nil {
+		if trace, ok := result.Data[tools.SubAgentTraceKey].(*types.SubAgentTrace); ok {
+			delete(result.Data, tools.SubAgentTraceKey)
+			trace.ToolCallID = tc.ID
+			toolCall.SubAgent = trace
+			state.SubAgentTraces = append(state.SubAgentTraces, trace)
+		}
+	}
+
+	if err != nil {
+		logger.Errorf(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool call failed: %s, error: %v",
+			state.CurrentRound+1, i+1, total, tc.Function.Name, err)
+		toolCall.Result = &types.ToolResult{
+			Success: false,
+			Error:   err.Error(),
+		}
+	}
+	if toolCall.Result == nil {
+		toolCall.Result = &types.ToolResult{
+			Success: false,
+			Error:   "tool returned no result",
+		}
+	}
+
+	toolSuccess := toolCall.Result.Success
+	pipelineFields := map[string]interface{}{
+		"iteration":    state.CurrentRound,
+		"round":        state.CurrentRound + 1,
+		"tool":         tc.Function.Name,
+		"tool_call_id": tc.ID,
+		"duration_ms":  duration,
+		"success":      toolSuccess,
+	}
+	if toolCall.Result.Error != "" {
+		pipelineFields["error"] = toolCall.Result.Error
+	}
+	if err != nil {
+		common.PipelineError(ctx, "Agent", "tool_call_result", pipelineFields)
+	} else if toolSuccess {
+		common.PipelineInfo(ctx, "Agent", "tool_call_result", pipelineFields)
+	} else {
+		common.PipelineWarn(ctx, "Agent", "tool_call_result", pipelineFields)
+	}
+
+	logger.Infof(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool result: success=%v, output_length=%d",
+		state.CurrentRound+1, i+1, total,
+		toolCall.Result.Success, len(toolCall.Result.Output))
+	logger.Debugf(ctx, "[Agent] ToolResult <- %s success=%v len(output)=%d",
+		tc.Function.Name, toolCall.Result.Success, len(toolCall.Result.Output))
+
+	// Log the output content for debugging
+	if toolCall.Result.Output != "" {
+		// Truncate if too long for logging
+		outputPreview := toolCall.Result.Output
+		if len(outputPreview) > 500 {
+			outputPreview = outputPreview[:500] + "... (truncated)"
+		}
+		logger.Debugf(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool output preview:\n%s",
+			state.CurrentRound+1, i+1, total, outputPreview)
+	}
+
+	if toolCall.Result.Error != "" {
+		logger.Warnf(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool error: %s",
+			state.CurrentRound+1, i+1, total, toolCall.Result.Error)
+	}
+
+	// Log structured data if present
+	if toolCall.Result.Data != nil {
+		dataJSON, _ := json.MarshalIndent(toolCall.Result.Data, "", "  ")
+		logger.Debugf(ctx, "[Agent][Round-%d][Tool-%d/%d] Tool data:\n%s",
+			state.CurrentRound+1, i+1, total, string(dataJSON))
+	}
+
+	// Store tool call (Observations are now derived from ToolCall.Result.Output)
+	step.ToolCalls = append(step.ToolCalls, toolCall)
+
+	// Emit tool result event (include structured data from tool result)
+	e.eventBus.Emit(ctx, event.Event{
+		ID:        tc.ID + "-tool-result",
+		Type:      event.EventAgentToolResult,
+		SessionID: sessionID,
+		Data: event.AgentToolResultData{
+			ToolCallID: tc.ID,
+			ToolName:   tc.Function.Name,
+			Output:     toolCall.Result.Output,
+			Error:      toolCall.Result.Error,
+			Success:    toolCall.Result.Success,
+			Duration:   duration,
+			Iteration:  state.CurrentRound,
+			Data:       toolCall.Result.Data, // Pass structured data for frontend rendering
+		},
+	})
+
+	// Emit tool execution event (for internal monitoring)
+	e.eventBus.Emit(ctx, event.Event{
+		ID:        tc.ID + "-tool-exec",
+		Type:      event.EventAgentTool,
+		SessionID: sessionID,
+		Data: event.AgentActionData{
+			Iteration:  state.CurrentRound,
+			ToolName:   tc.Function.Name,
+			ToolInput:  run.args,
+			ToolOutput: toolCall.Result.Output,
+			Success:    toolCall.Result.Success,
+			Error:      toolCall.Result.Error,
+			Duration:   duration,
+		},
+	})
+
+	// Optional: Reflection after each tool call (streaming)
+	if e.config.ReflectionEnabled && result != nil {
+		reflection, err := e.streamReflectionToEventBus(
+			ctx, tc.ID, tc.Function.Name, result.Output,
+			state.CurrentRound, sessionID,
+		)
+		if err != nil {
+			logger.Warnf(ctx, "Reflection failed: %v", err)
+		} else if reflection != "" {
+			// Store reflection in the tool call just added
+			step.ToolCalls[len(step.ToolCalls)-1].Reflection = reflection
+		}
+	}
 }
 
 // buildToolsForLLM builds the tools list for LLM function calling
@@ -698,6 +913,7 @@ func (e *AgentEngine) streamThinkingToEventBus(
 	logger.Debug(context.Background(), "[Agent] streamLLM opts tool_choice=auto temperature=", e.config.Temperature)
 
 	pendingToolCalls := make(map[string]bool)
+	modelID := e.chatModel.GetModelID()
 
 	// Generate a single ID for this entire thinking stream
 	thinkingID := generateEventID("thinking")
@@ -708,6 +924,9 @@ func (e *AgentEngine) streamThinkingToEventBus(
 		messages,
 		opts,
 		func(chunk *types.StreamResponse, fullContent string) {
+			if chunk.ModelID != "" {
+				modelID = chunk.ModelID
+			}
 			if chunk.ResponseType == types.ResponseTypeToolCall && chunk.Data != nil {
 				toolCallID, _ := chunk.Data["tool_call_id"].(string)
 				toolName, _ := chunk.Data["tool_name"].(string)
@@ -756,6 +975,7 @@ func (e *AgentEngine) streamThinkingToEventBus(
 		Content:      fullContent,
 		ToolCalls:    toolCalls,
 		FinishReason: "stop",
+		ModelID:      modelID,
 	}, nil
 }
 
@@ -778,12 +998,7 @@ func (e *AgentEngine) streamFinalAnswerToEventBus(
 	})
 
 	// Build messages with all context
-	systemPrompt := BuildSystemPrompt(
-		e.knowledgeBasesInfo,
-		e.config.WebSearchEnabled,
-		e.selectedDocs,
-		e.systemPromptTemplate,
-	)
+	systemPrompt := e.buildSystemPrompt()
 
 	messages := []chat.Message{
 		{Role: "system", Content: systemPrompt},
@@ -835,6 +1050,10 @@ func (e *AgentEngine) streamFinalAnswerToEventBus(
 		&chat.ChatOptions{Temperature: e.config.Temperature},
 		func(chunk *types.StreamResponse, fullContent string) {
 			if chunk.Content != "" {
+				modelID := chunk.ModelID
+				if modelID == "" {
+					modelID = e.chatModel.GetModelID()
+				}
 				logger.Debugf(ctx, "[Agent][FinalAnswer] Emitting answer chunk: %d chars", len(chunk.Content))
 				e.eventBus.Emit(ctx, event.Event{
 					ID:        answerID, // Same ID for all chunks in this stream
@@ -843,6 +1062,7 @@ func (e *AgentEngine) streamFinalAnswerToEventBus(
 					Data: event.AgentFinalAnswerData{
 						Content: chunk.Content,
 						Done:    chunk.Done,
+						ModelID: modelID,
 					},
 				})
 			}
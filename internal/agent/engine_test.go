@@ -0,0 +1,262 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// multiCallChat requests the given tool calls in its first round, then answers with "done"
+type multiCallChat struct {
+	toolNames []string
+	calls     int
+}
+
+func (m *multiCallChat) Chat(ctx context.Context, messages []chat.Message, opts *chat.ChatOptions) (*types.ChatResponse, error) {
+	return &types.ChatResponse{Content: "done", FinishReason: "stop"}, nil
+}
+
+func (m *multiCallChat) ChatStream(
+	ctx context.Context,
+	messages []chat.Message,
+	opts *chat.ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	m.calls++
+	ch := make(chan types.StreamResponse, 1)
+	if m.calls == 1 {
+		toolCalls := make([]types.LLMToolCall, 0, len(m.toolNames))
+		for i, name := range m.toolNames {
+			tc := types.LLMToolCall{ID: "call-" + string(rune('a'+i)), Type: "function"}
+			tc.Function.Name = name
+			tc.Function.Arguments = `{}`
+			toolCalls = append(toolCalls, tc)
+		}
+		ch <- types.StreamResponse{ToolCalls: toolCalls, Done: true}
+	} else {
+		ch <- types.StreamResponse{ResponseType: types.ResponseTypeAnswer, Content: "done", Done: true}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (m *multiCallChat) GetModelName() string { return "scripted" }
+
+func (m *multiCallChat) GetModelID() string { return "scripted" }
+
+// sleepTool sleeps unless its context is done first, tracking how many calls overlap
+type sleepTool struct {
+	name    string
+	delay   time.Duration
+	serial  bool
+	running *int32
+	peak    *int32
+}
+
+func (t *sleepTool) Name() string                { return t.name }
+func (t *sleepTool) Description() string         { return "test tool" }
+func (t *sleepTool) Parameters() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *sleepTool) Serial() bool                { return t.serial }
+
+func (t *sleepTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	n := atomic.AddInt32(t.running, 1)
+	defer atomic.AddInt32(t.running, -1)
+	for {
+		peak := atomic.LoadInt32(t.peak)
+		if n <= peak || atomic.CompareAndSwapInt32(t.peak, peak, n) {
+			break
+		}
+	}
+	select {
+	case <-time.After(t.delay):
+		return &types.ToolResult{Success: true, Output: t.name}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestAgentEngineParallelToolCalls(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	tests := []struct {
+		name         string
+		tools        []string
+		serial       map[string]bool
+		policy       *types.ToolExecutionPolicy
+		wantPeak     int32
+		wantFailed   []string
+		maxWallClock time.Duration
+	}{
+		{
+			name:         "concurrent",
+			tools:        []string{"search_a", "search_b", "search_c"},
+			wantPeak:     3,
+			maxWallClock: 2 * delay,
+		},
+		{
+			name:     "capped",
+			tools:    []string{"search_a", "search_b", "search_c"},
+			policy:   &types.ToolExecutionPolicy{MaxConcurrency: 1},
+			wantPeak: 1,
+		},
+		{
+			name:     "serial tools",
+			tools:    []string{"think_a", "think_b"},
+			serial:   map[string]bool{"think_a": true, "think_b": true},
+			wantPeak: 1,
+		},
+		{
+			name:  "per-tool timeout",
+			tools: []string{"search_a", "slow_b"},
+			policy: &types.ToolExecutionPolicy{
+				ToolTimeouts: map[string]int{"slow_*": 1},
+			},
+			wantPeak:     2,
+			wantFailed:   []string{"slow_b"},
+			maxWallClock: 1500 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, peak int32
+			registry := tools.NewToolRegistry()
+			for _, name := range tt.tools {
+				d := delay
+				if strings.HasPrefix(name, "slow_") {
+					d = 10 * time.Second
+				}
+				registry.RegisterTool(&sleepTool{
+					name: name, delay: d, serial: tt.serial[name], running: &running, peak: &peak,
+				})
+			}
+			config := &types.AgentConfig{MaxIterations: 3, ToolExecution: tt.policy}
+
+			eventBus := event.NewEventBus()
+			var mu sync.Mutex
+			var resultOrder []string
+			eventBus.On(event.EventAgentToolResult, func(ctx context.Context, evt event.Event) error {
+				mu.Lock()
+				defer mu.Unlock()
+				resultOrder = append(resultOrder, evt.Data.(event.AgentToolResultData).ToolName)
+				return nil
+			})
+
+			engine := NewAgentEngine(config, &multiCallChat{toolNames: tt.tools}, registry, eventBus,
+				nil, nil, nil, "session-1", "")
+			start := time.Now()
+			state, err := engine.Execute(context.Background(), "session-1", "message-1", "query", nil)
+			elapsed := time.Since(start)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if peak != tt.wantPeak {
+				t.Errorf("peak concurrent calls = %d, want %d", peak, tt.wantPeak)
+			}
+			if tt.maxWallClock > 0 && elapsed > tt.maxWallClock {
+				t.Errorf("round took %s, want at most %s", elapsed, tt.maxWallClock)
+			}
+			if got, want := strings.Join(resultOrder, ","), strings.Join(tt.tools, ","); got != want {
+				t.Errorf("tool result events in order %s, want %s", got, want)
+			}
+
+			step := state.RoundSteps[0]
+			if len(step.ToolCalls) != len(tt.tools) {
+				t.Fatalf("recorded %d tool calls, want %d", len(step.ToolCalls), len(tt.tools))
+			}
+			var sum int64
+			for i, call := range step.ToolCalls {
+				if call.Name != tt.tools[i] {
+					t.Errorf("tool call %d = %s, want %s", i, call.Name, tt.tools[i])
+				}
+				failed := false
+				for _, name := range tt.wantFailed {
+					failed = failed || name == call.Name
+				}
+				if call.Result.Success == failed {
+					t.Errorf("tool %s success = %v, error %q", call.Name, call.Result.Success, call.Result.Error)
+				}
+				if call.FinishedAt.Before(call.StartedAt) {
+					t.Errorf("tool %s finished before it started", call.Name)
+				}
+				sum += call.Duration
+			}
+			if tt.wantPeak > 1 && step.ToolsDuration >= sum {
+				t.Errorf("tools wall-clock %dms should be less than the %dms sum of overlapping calls",
+					step.ToolsDuration, sum)
+			}
+		})
+	}
+}
+
+// stubbornTool ignores its context and records its calls in a history that is not safe for concurrent use
+type stubbornTool struct {
+	name    string
+	delay   time.Duration
+	history *[]string
+	running *int32
+	peak    *int32
+}
+
+func (t *stubbornTool) Name() string                { return t.name }
+func (t *stubbornTool) Description() string         { return "test tool" }
+func (t *stubbornTool) Parameters() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t *stubbornTool) Serial() bool                { return true }
+
+func (t *stubbornTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	if n := atomic.AddInt32(t.running, 1); n > atomic.LoadInt32(t.peak) {
+		atomic.StoreInt32(t.peak, n)
+	}
+	defer atomic.AddInt32(t.running, -1)
+	time.Sleep(t.delay)
+	*t.history = append(*t.history, t.name)
+	return &types.ToolResult{Success: true, Output: t.name}, nil
+}
+
+func TestAgentEngineSerialToolTimeout(t *testing.T) {
+	var history []string
+	var running, peak int32
+	registry := tools.NewToolRegistry()
+	registry.RegisterTool(&stubbornTool{
+		name: "slow_think", delay: 1500 * time.Millisecond, history: &history, running: &running, peak: &peak,
+	})
+	registry.RegisterTool(&stubbornTool{
+		name: "think", delay: 10 * time.Millisecond, history: &history, running: &running, peak: &peak,
+	})
+	config := &types.AgentConfig{
+		MaxIterations: 3,
+		ToolExecution: &types.ToolExecutionPolicy{ToolTimeouts: map[string]int{"slow_*": 1, "think": 5}},
+	}
+
+	engine := NewAgentEngine(config, &multiCallChat{toolNames: []string{"slow_think", "think"}}, registry,
+		event.NewEventBus(), nil, nil, nil, "session-1", "")
+	state, err := engine.Execute(context.Background(), "session-1", "message-1", "query", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	calls := state.RoundSteps[0].ToolCalls
+	if len(calls) != 2 {
+		t.Fatalf("recorded %d tool calls, want 2", len(calls))
+	}
+	if calls[0].Result.Success || !strings.Contains(calls[0].Result.Error, "timed out") {
+		t.Errorf("slow_think result = %+v, want a timeout", calls[0].Result)
+	}
+	if !calls[1].Result.Success {
+		t.Errorf("think failed: %s", calls[1].Result.Error)
+	}
+	if peak != 1 {
+		t.Errorf("peak concurrent serial calls = %d, want 1", peak)
+	}
+	if got := strings.Join(history, ","); got != "slow_think,think" {
+		t.Errorf("calls ran in order %s, want slow_think,think", got)
+	}
+}
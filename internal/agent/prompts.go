@@ -225,6 +225,39 @@ func formatSelectedDocuments(docs []*SelectedDocumentInfo) string {
 	return builder.String()
 }
 
+// formatMCPContext formats the pinned MCP prompts and resources appended to the system prompt.
+// Prompts are instructions and come first, resources are reference material.
+func formatMCPContext(items []*types.MCPContextItem) string {
+	var prompts, resources strings.Builder
+	for _, item := range items {
+		content := strings.TrimSpace(item.Content)
+		if content == "" {
+			continue
+		}
+		if runes := []rune(content); len(runes) > MaxMCPContextItemChars {
+			content = string(runes[:MaxMCPContextItemChars]) + "\n... (truncated)"
+		}
+		switch item.Kind {
+		case types.MCPContextPrompt:
+			prompts.WriteString(fmt.Sprintf("\n<!-- MCP prompt %s from %s -->\n%s\n", item.Name, item.ServiceName, content))
+		case types.MCPContextResource:
+			resources.WriteString(fmt.Sprintf("\n#### %s (MCP service: %s)\n```\n%s\n```\n", item.Name, item.ServiceName, content))
+		}
+	}
+
+	var builder strings.Builder
+	if prompts.Len() > 0 {
+		builder.WriteString("\n### Additional Instructions\n")
+		builder.WriteString(prompts.String())
+	}
+	if resources.Len() > 0 {
+		builder.WriteString("\n### Attached Resources\n")
+		builder.WriteString("The following resources from MCP services are attached as reference material.\n")
+		builder.WriteString(resources.String())
+	}
+	return builder.String()
+}
+
 // renderPromptPlaceholdersWithStatus renders placeholders including web search status
 // Supported placeholders:
 //   - {{knowledge_bases}}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestFormatMCPContext(t *testing.T) {
+	if got := formatMCPContext(nil); got != "" {
+		t.Errorf("no pinned context should add nothing, got %q", got)
+	}
+
+	got := formatMCPContext([]*types.MCPContextItem{
+		{Kind: types.MCPContextResource, ServiceName: "Notes", Name: "file:///notes.txt", Content: "release notes"},
+		{Kind: types.MCPContextPrompt, ServiceName: "Style", Name: "tone", Content: "Answer formally."},
+		{Kind: types.MCPContextPrompt, ServiceName: "Style", Name: "empty", Content: "  "},
+		{Kind: types.MCPContextResource, ServiceName: "Notes", Name: "file:///big.txt",
+			Content: strings.Repeat("x", MaxMCPContextItemChars+10)},
+	})
+
+	instructions := strings.Index(got, "### Additional Instructions")
+	resources := strings.Index(got, "### Attached Resources")
+	if instructions < 0 || resources < instructions {
+		t.Fatalf("prompts should come before resources:\n%s", got)
+	}
+	for _, want := range []string{"Answer formally.", "#### file:///notes.txt (MCP service: Notes)", "... (truncated)"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("context does not contain %q", want)
+		}
+	}
+	if strings.Contains(got, "empty") {
+		t.Errorf("empty prompt should be skipped:\n%s", got)
+	}
+	if strings.Contains(got, strings.Repeat("x", MaxMCPContextItemChars+1)) {
+		t.Errorf("large resource should be truncated")
+	}
+}
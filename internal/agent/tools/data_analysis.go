@@ -77,6 +77,11 @@ func (t *DataAnalysisTool) Cleanup(ctx context.Context) {
 	t.createdTables = nil
 }
 
+// Serial reports that calls must not overlap, they share the tables created in the session
+func (t *DataAnalysisTool) Serial() bool {
+	return true
+}
+
 // Execute executes the SQL query on DuckDB (only read-only queries are allowed)
 func (t *DataAnalysisTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
 	logger.Infof(ctx, "[Tool][DataAnalysis] Execute started for session: %s", t.sessionID)
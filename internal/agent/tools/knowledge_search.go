@@ -72,6 +72,9 @@ Avoid:
 - queries (required): 1–5 semantic questions or conceptual statements.
   These should reflect the meaning or topic you want embeddings to capture.
 - knowledge_base_ids (optional): limit the search scope.
+- filter (optional): restrict results by document attributes, e.g. only documents tagged "Policy" created in 2025:
+  {"tag_ids": ["<tag id>"], "created_after": "2025-01-01T00:00:00Z", "created_before": "2026-01-01T00:00:00Z"}.
+  Supports tag_ids, file_types (e.g. "pdf", "docx"), created_after/created_before (RFC 3339) and metadata (exact key/value matches).
 
 ## Output
 Returns chunks ranked by semantic similarity, reranked when applicable.  
@@ -96,6 +99,32 @@ Results represent conceptual relevance, not literal keyword overlap.`,
       },
       "minItems": 0,
       "maxItems": 10
+    },
+    "filter": {
+      "type": "object",
+      "description": "Optional: restrict results by tag, file type, creation time or chunk metadata",
+      "properties": {
+        "tag_ids": {
+          "type": "array",
+          "items": {"type": "string"}
+        },
+        "file_types": {
+          "type": "array",
+          "items": {"type": "string"}
+        },
+        "created_after": {
+          "type": "string",
+          "description": "RFC 3339 timestamp, inclusive"
+        },
+        "created_before": {
+          "type": "string",
+          "description": "RFC 3339 timestamp, exclusive"
+        },
+        "metadata": {
+          "type": "object",
+          "additionalProperties": {"type": "string"}
+        }
+      }
     }
   },
   "required": ["queries"]
@@ -104,8 +133,9 @@ Results represent conceptual relevance, not literal keyword overlap.`,
 
 // KnowledgeSearchInput defines the input parameters for knowledge search tool
 type KnowledgeSearchInput struct {
-	Queries          []string `json:"queries"`
-	KnowledgeBaseIDs []string `json:"knowledge_base_ids,omitempty"`
+	Queries          []string              `json:"queries"`
+	KnowledgeBaseIDs []string              `json:"knowledge_base_ids,omitempty"`
+	Filter           *types.RetrieveFilter `json:"filter,omitempty"`
 }
 
 // searchResultWithMeta wraps search result with metadata about which query matched it
@@ -125,8 +155,9 @@ type KnowledgeSearchTool struct {
 	chunkService         interfaces.ChunkService
 	searchTargets        types.SearchTargets // Pre-computed unified search targets
 	rerankModel          rerank.Reranker
-	chatModel            chat.Chat      // Optional chat model for LLM-based reranking
-	config               *config.Config // Global config for fallback values
+	chatModel            chat.Chat           // Optional chat model for LLM-based reranking
+	config               *config.Config      // Global config for fallback values
+	fusion               *types.FusionConfig // Optional result fusion override from the agent config
 }
 
 // NewKnowledgeSearchTool creates a new knowledge search tool
@@ -138,6 +169,7 @@ func NewKnowledgeSearchTool(
 	rerankModel rerank.Reranker,
 	chatModel chat.Chat,
 	cfg *config.Config,
+	fusion *types.FusionConfig,
 ) *KnowledgeSearchTool {
 	return &KnowledgeSearchTool{
 		BaseTool:             knowledgeSearchTool,
@@ -148,6 +180,7 @@ func NewKnowledgeSearchTool(
 		rerankModel:          rerankModel,
 		chatModel:            chatModel,
 		config:               cfg,
+		fusion:               fusion,
 	}
 }
 
@@ -165,6 +198,14 @@ func (t *KnowledgeSearchTool) Execute(ctx context.Context, args json.RawMessage)
 		}, err
 	}
 
+	if err := input.Filter.Validate(); err != nil {
+		logger.Errorf(ctx, "[Tool][KnowledgeSearch] Invalid filter: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Invalid filter: %v", err),
+		}, err
+	}
+
 	// Log input arguments
 	argsJSON, _ := json.MarshalIndent(input, "", "  ")
 	logger.Debugf(ctx, "[Tool][KnowledgeSearch] Input args:\n%s", string(argsJSON))
@@ -281,7 +322,7 @@ func (t *KnowledgeSearchTool) Execute(ctx context.Context, args json.RawMessage)
 	kbTypeMap := t.getKnowledgeBaseTypes(ctx, kbIDs)
 
 	allResults := t.concurrentSearchByTargets(ctx, queries, searchTargets,
-		topK, vectorThreshold, keywordThreshold, kbTypeMap)
+		topK, vectorThreshold, keywordThreshold, input.Filter, kbTypeMap)
 	logger.Infof(ctx, "[Tool][KnowledgeSearch] Concurrent search completed: %d raw results", len(allResults))
 
 	// Note: HybridSearch now uses RRF (Reciprocal Rank Fusion) which produces normalized scores
@@ -438,6 +479,7 @@ func (t *KnowledgeSearchTool) concurrentSearchByTargets(
 	searchTargets types.SearchTargets,
 	topK int,
 	vectorThreshold, keywordThreshold float64,
+	filter *types.RetrieveFilter,
 	kbTypeMap map[string]string,
 ) []*searchResultWithMeta {
 	var wg sync.WaitGroup
@@ -457,6 +499,8 @@ func (t *KnowledgeSearchTool) concurrentSearchByTargets(
 					MatchCount:       topK,
 					VectorThreshold:  vectorThreshold,
 					KeywordThreshold: keywordThreshold,
+					Fusion:           t.fusion,
+					Filter:           filter,
 				}
 
 				// If target has specific knowledge IDs, add them to search params
@@ -0,0 +1,226 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/mcp"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// ToolReadMCPResource is the name of the tool reading resources of the agent's MCP services
+const ToolReadMCPResource = "read_mcp_resource"
+
+const (
+	// maxListedMCPResources is the number of resources listed in the tool description
+	maxListedMCPResources = 50
+	// maxMCPResourceChars is the number of characters of a resource returned to the model
+	maxMCPResourceChars = 20000
+)
+
+var readMCPResourceSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"service_id": {
+			"type": "string",
+			"description": "ID of the MCP service exposing the resource"
+		},
+		"uri": {
+			"type": "string",
+			"description": "URI of the resource to read"
+		}
+	},
+	"required": ["service_id", "uri"]
+}`)
+
+// ReadMCPResourceInput defines the input parameters for the read MCP resource tool
+type ReadMCPResourceInput struct {
+	ServiceID string `json:"service_id"`
+	URI       string `json:"uri"`
+}
+
+// ReadMCPResourceTool reads resources of the MCP services available to an agent on demand
+type ReadMCPResourceTool struct {
+	BaseTool
+	services   map[string]*types.MCPService // service ID -> service
+	mcpManager *mcp.MCPManager
+}
+
+// mcpServiceResources are the resources an MCP service lists
+type mcpServiceResources struct {
+	service   *types.MCPService
+	resources []*types.MCPResource
+}
+
+// NewReadMCPResourceTool creates a tool reading resources of the given MCP services.
+// The listed resources are described to the model, other URIs of these services can be read as well.
+func NewReadMCPResourceTool(listed []mcpServiceResources, mcpManager *mcp.MCPManager) *ReadMCPResourceTool {
+	services := make(map[string]*types.MCPService, len(listed))
+	for _, item := range listed {
+		services[item.service.ID] = item.service
+	}
+	return &ReadMCPResourceTool{
+		BaseTool:   NewBaseTool(ToolReadMCPResource, describeMCPResources(listed), readMCPResourceSchema),
+		services:   services,
+		mcpManager: mcpManager,
+	}
+}
+
+// describeMCPResources builds the tool description listing the available resources
+func describeMCPResources(listed []mcpServiceResources) string {
+	var builder strings.Builder
+	builder.WriteString("Read a resource (file, document, record, ...) exposed by an MCP service. ")
+	builder.WriteString("Use it when one of the resources below is relevant to the task.\n\nAvailable resources:\n")
+	count := 0
+	for _, item := range listed {
+		for _, resource := range item.resources {
+			if count == maxListedMCPResources {
+				builder.WriteString("- ... more resources are available\n")
+				return builder.String()
+			}
+			fmt.Fprintf(&builder, "- service_id=%s (%s) uri=%s", item.service.ID, item.service.Name, resource.URI)
+			if resource.Name != "" {
+				fmt.Fprintf(&builder, " name=%q", resource.Name)
+			}
+			if resource.Description != "" {
+				builder.WriteString(": " + resource.Description)
+			}
+			builder.WriteString("\n")
+			count++
+		}
+	}
+	return builder.String()
+}
+
+// Execute reads the resource from its MCP service
+func (t *ReadMCPResourceTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input ReadMCPResourceInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse args: %v", err),
+		}, err
+	}
+	service, ok := t.services[input.ServiceID]
+	if !ok {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Unknown MCP service: %s", input.ServiceID),
+		}, nil
+	}
+	if input.URI == "" {
+		return &types.ToolResult{Success: false, Error: "uri is required"}, nil
+	}
+	logger.GetLogger(ctx).Infof("Reading MCP resource %s from service: %s", input.URI, service.Name)
+
+	content, err := ReadMCPResource(ctx, t.mcpManager, service, input.URI)
+	if err != nil {
+		logger.GetLogger(ctx).Errorf("Failed to read MCP resource: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to read resource: %v", err),
+		}, nil
+	}
+	if content == "" {
+		content = "(empty resource)"
+	}
+
+	runes := []rune(content)
+	truncated := len(runes) > maxMCPResourceChars
+	if truncated {
+		content = string(runes[:maxMCPResourceChars]) + "\n... (truncated)"
+	}
+	return &types.ToolResult{
+		Success: true,
+		Output:  fmt.Sprintf("Resource %s from MCP service %s:\n\n%s", input.URI, service.Name, content),
+		Data: map[string]interface{}{
+			"service_id": service.ID,
+			"uri":        input.URI,
+			"truncated":  truncated,
+		},
+	}, nil
+}
+
+// ReadMCPResource reads the text of a resource of an MCP service
+func ReadMCPResource(
+	ctx context.Context, mcpManager *mcp.MCPManager, service *types.MCPService, uri string,
+) (string, error) {
+	client, err := mcpManager.GetOrCreateClient(service)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to MCP service: %w", err)
+	}
+	// For stdio transport, ensure connection is released after use
+	if service.TransportType == types.MCPTransportStdio {
+		defer client.Disconnect()
+	}
+	result, err := client.ReadResource(ctx, uri)
+	if err != nil {
+		return "", err
+	}
+	return result.Text(), nil
+}
+
+// GetMCPPrompt renders a prompt of an MCP service to text
+func GetMCPPrompt(
+	ctx context.Context, mcpManager *mcp.MCPManager, service *types.MCPService,
+	name string, args map[string]string,
+) (string, error) {
+	client, err := mcpManager.GetOrCreateClient(service)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to MCP service: %w", err)
+	}
+	// For stdio transport, ensure connection is released after use
+	if service.TransportType == types.MCPTransportStdio {
+		defer client.Disconnect()
+	}
+	result, err := client.GetPrompt(ctx, name, args)
+	if err != nil {
+		return "", err
+	}
+	return result.Text(), nil
+}
+
+// RegisterMCPResourceTool registers the read_mcp_resource tool when the given services expose resources
+func RegisterMCPResourceTool(
+	ctx context.Context,
+	registry *ToolRegistry,
+	services []*types.MCPService,
+	mcpManager *mcp.MCPManager,
+) {
+	listed := make([]mcpServiceResources, 0, len(services))
+	for _, service := range services {
+		if !service.Enabled {
+			continue
+		}
+		client, err := mcpManager.GetOrCreateClient(service)
+		if err != nil {
+			logger.GetLogger(ctx).Errorf("Failed to create MCP client for service %s: %v", service.Name, err)
+			continue
+		}
+
+		listCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		resources, err := client.ListResources(listCtx)
+		cancel()
+		if service.TransportType == types.MCPTransportStdio {
+			client.Disconnect()
+		}
+		if err != nil {
+			// Services without the resources capability fail to list them
+			logger.GetLogger(ctx).Debugf("No resources listed by MCP service %s: %v", service.Name, err)
+			continue
+		}
+		if len(resources) > 0 {
+			listed = append(listed, mcpServiceResources{service: service, resources: resources})
+		}
+	}
+	if len(listed) == 0 {
+		return
+	}
+
+	registry.RegisterTool(NewReadMCPResourceTool(listed, mcpManager))
+	logger.GetLogger(ctx).Infof("Registered %s tool over %d MCP services", ToolReadMCPResource, len(listed))
+}
@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	mcpgo "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/Tencent/WeKnora/internal/mcp"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// newTestMCPService starts an MCP server exposing a resource and a prompt over streamable HTTP
+func newTestMCPService(t *testing.T) *types.MCPService {
+	s := server.NewMCPServer("test", "1.0.0",
+		server.WithResourceCapabilities(false, false),
+		server.WithPromptCapabilities(false),
+	)
+	s.AddResource(mcpgo.NewResource("file:///notes.txt", "notes", mcpgo.WithResourceDescription("Team notes")),
+		func(ctx context.Context, request mcpgo.ReadResourceRequest) ([]mcpgo.ResourceContents, error) {
+			return []mcpgo.ResourceContents{mcpgo.TextResourceContents{
+				URI: request.Params.URI, MIMEType: "text/plain", Text: "deploy on fridays is forbidden",
+			}}, nil
+		})
+	s.AddPrompt(mcpgo.NewPrompt("style", mcpgo.WithArgument("tone", mcpgo.RequiredArgument())),
+		func(ctx context.Context, request mcpgo.GetPromptRequest) (*mcpgo.GetPromptResult, error) {
+			return &mcpgo.GetPromptResult{Messages: []mcpgo.PromptMessage{
+				mcpgo.NewPromptMessage(mcpgo.RoleUser,
+					mcpgo.NewTextContent("Answer in a "+request.Params.Arguments["tone"]+" tone.")),
+			}}, nil
+		})
+	httpServer := server.NewTestStreamableHTTPServer(s)
+	t.Cleanup(httpServer.Close)
+
+	url := httpServer.URL + "/mcp"
+	return &types.MCPService{
+		ID: "svc-1", Name: "Notes", Enabled: true, TransportType: types.MCPTransportHTTPStreamable, URL: &url,
+	}
+}
+
+func TestReadMCPResourceTool(t *testing.T) {
+	service := newTestMCPService(t)
+	manager := mcp.NewMCPManager()
+	t.Cleanup(manager.Shutdown)
+	ctx := context.Background()
+
+	registry := NewToolRegistry()
+	RegisterMCPResourceTool(ctx, registry, []*types.MCPService{service}, manager)
+	tool, err := registry.GetTool(ToolReadMCPResource)
+	if err != nil {
+		t.Fatalf("read_mcp_resource tool not registered: %v", err)
+	}
+	if !strings.Contains(tool.Description(), "service_id=svc-1 (Notes) uri=file:///notes.txt") {
+		t.Errorf("description does not list the resource:\n%s", tool.Description())
+	}
+
+	tests := []struct {
+		name        string
+		input       ReadMCPResourceInput
+		wantSuccess bool
+		wantOutput  string
+	}{
+		{
+			name:        "listed resource",
+			input:       ReadMCPResourceInput{ServiceID: "svc-1", URI: "file:///notes.txt"},
+			wantSuccess: true,
+			wantOutput:  "deploy on fridays is forbidden",
+		},
+		{
+			name:  "unknown service",
+			input: ReadMCPResourceInput{ServiceID: "svc-2", URI: "file:///notes.txt"},
+		},
+		{
+			name:  "unknown resource",
+			input: ReadMCPResourceInput{ServiceID: "svc-1", URI: "file:///missing.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, _ := json.Marshal(tt.input)
+			result, err := tool.Execute(ctx, args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Fatalf("success = %v, error %q", result.Success, result.Error)
+			}
+			if !strings.Contains(result.Output, tt.wantOutput) {
+				t.Errorf("output = %q, want it to contain %q", result.Output, tt.wantOutput)
+			}
+		})
+	}
+
+	prompt, err := GetMCPPrompt(ctx, manager, service, "style", map[string]string{"tone": "formal"})
+	if err != nil {
+		t.Fatalf("get prompt: %v", err)
+	}
+	if prompt != "Answer in a formal tone." {
+		t.Errorf("prompt = %q", prompt)
+	}
+}
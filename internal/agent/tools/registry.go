@@ -57,6 +57,23 @@ func (r *ToolRegistry) GetFunctionDefinitions() []types.FunctionDefinition {
 	return definitions
 }
 
+// SerialTool is implemented by tools keeping state across calls. Their calls run one after another
+// in the order the model requested them, never concurrently: a call waits for the previous one to
+// return even when that one timed out.
+type SerialTool interface {
+	Serial() bool
+}
+
+// IsSerial reports whether calls of the tool must not run concurrently
+func (r *ToolRegistry) IsSerial(name string) bool {
+	tool, exists := r.tools[name]
+	if !exists {
+		return false
+	}
+	serial, ok := tool.(SerialTool)
+	return ok && serial.Serial()
+}
+
 // ExecuteTool executes a tool by name with the given arguments
 func (r *ToolRegistry) ExecuteTool(
 	ctx context.Context,
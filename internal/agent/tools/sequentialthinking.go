@@ -158,6 +158,11 @@ func NewSequentialThinkingTool() *SequentialThinkingTool {
 	}
 }
 
+// Serial reports that calls must run in order, each thought builds on the history of the previous ones
+func (t *SequentialThinkingTool) Serial() bool {
+	return true
+}
+
 // Execute executes the sequential thinking tool
 func (t *SequentialThinkingTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
 	logger.Infof(ctx, "[Tool][SequentialThinking] Execute started")
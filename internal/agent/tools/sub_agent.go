@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// ToolSubAgentPrefix is the name prefix of tools that delegate to another custom agent
+const ToolSubAgentPrefix = "agent_"
+
+// SubAgentTraceKey is the ToolResult.Data key carrying the *types.SubAgentTrace of a sub-agent call.
+// The engine moves it to ToolCall.SubAgent before the result is streamed.
+const SubAgentTraceKey = "_sub_agent_trace"
+
+// subAgentForwardedEvents are the sub-agent events re-emitted to the parent EventBus
+var subAgentForwardedEvents = []event.EventType{
+	event.EventAgentThought,
+	event.EventAgentToolCall,
+	event.EventAgentToolResult,
+	event.EventAgentReflection,
+	event.EventAgentReferences,
+	event.EventAgentFinalAnswer,
+	event.EventError,
+}
+
+type toolCallIDContextKey struct{}
+
+// WithToolCallID returns a context carrying the ID of the tool call being executed
+func WithToolCallID(ctx context.Context, toolCallID string) context.Context {
+	return context.WithValue(ctx, toolCallIDContextKey{}, toolCallID)
+}
+
+// ToolCallIDFromContext returns the ID of the tool call being executed, if any
+func ToolCallIDFromContext(ctx context.Context) string {
+	toolCallID, _ := ctx.Value(toolCallIDContextKey{}).(string)
+	return toolCallID
+}
+
+// SubAgentInput defines the input parameters for a sub-agent tool
+type SubAgentInput struct {
+	Task string `json:"task"`
+}
+
+// SubAgentTool exposes a custom agent as a tool of another agent.
+// The sub-agent runs with its own knowledge bases, tools and model.
+type SubAgentTool struct {
+	BaseTool
+	agent     *types.SubAgentInfo
+	runner    interfaces.SubAgentRunner
+	eventBus  *event.EventBus
+	sessionID string
+}
+
+// NewSubAgentTool creates a tool delegating tasks to the given custom agent
+func NewSubAgentTool(
+	agent *types.SubAgentInfo,
+	runner interfaces.SubAgentRunner,
+	eventBus *event.EventBus,
+	sessionID string,
+) *SubAgentTool {
+	description := fmt.Sprintf(
+		"[Agent: %s] Delegate a self-contained task to the \"%s\" agent, which works with its own "+
+			"knowledge bases and tools and returns its final answer. Describe the task completely, "+
+			"the agent cannot see this conversation.", agent.Name, agent.Name)
+	if agent.Description != "" {
+		description += "\n\nAgent description: " + agent.Description
+	}
+	return &SubAgentTool{
+		BaseTool: NewBaseTool(SubAgentToolName(agent.ID), description, json.RawMessage(`{
+			"type": "object",
+			"properties": {
+				"task": {
+					"type": "string",
+					"description": "The complete task or question for the agent, including all necessary context"
+				}
+			},
+			"required": ["task"]
+		}`)),
+		agent:     agent,
+		runner:    runner,
+		eventBus:  eventBus,
+		sessionID: sessionID,
+	}
+}
+
+// SubAgentToolName returns the tool name of a custom agent
+func SubAgentToolName(agentID string) string {
+	name := ToolSubAgentPrefix + sanitizeName(agentID)
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// Execute runs the sub-agent on the task and returns its final answer
+func (t *SubAgentTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input SubAgentInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		logger.Errorf(ctx, "[Tool][SubAgent] Failed to parse args: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse args: %v", err),
+		}, err
+	}
+	task := strings.TrimSpace(input.Task)
+	if task == "" {
+		return &types.ToolResult{
+			Success: false,
+			Error:   "task is required",
+		}, nil
+	}
+
+	parentToolCallID := ToolCallIDFromContext(ctx)
+	logger.Infof(ctx, "[Tool][SubAgent] Delegating to agent %s (%s), parent tool call: %s",
+		t.agent.Name, t.agent.ID, parentToolCallID)
+
+	// Sub-agent events are nested under the parent tool call
+	childBus := event.NewEventBus()
+	for _, eventType := range subAgentForwardedEvents {
+		childBus.On(eventType, t.forwardEvent(parentToolCallID))
+	}
+
+	startTime := time.Now()
+	state, err := t.runner.RunSubAgent(ctx, t.sessionID, t.agent.ID, task, childBus)
+	trace := &types.SubAgentTrace{
+		ToolCallID: parentToolCallID,
+		AgentID:    t.agent.ID,
+		AgentName:  t.agent.Name,
+		Task:       task,
+		Duration:   time.Since(startTime).Milliseconds(),
+	}
+	if state != nil {
+		trace.Steps = state.RoundSteps
+		trace.FinalAnswer = state.FinalAnswer
+	}
+	data := map[string]interface{}{
+		"display_type":   "sub_agent",
+		"agent_id":       t.agent.ID,
+		"agent_name":     t.agent.Name,
+		"task":           task,
+		SubAgentTraceKey: trace,
+	}
+
+	if err != nil {
+		logger.Errorf(ctx, "[Tool][SubAgent] Agent %s failed: %v", t.agent.ID, err)
+		trace.Error = err.Error()
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Agent %s failed: %v", t.agent.Name, err),
+			Data:    data,
+		}, nil
+	}
+
+	output := trace.FinalAnswer
+	if output == "" {
+		output = fmt.Sprintf("Agent %s returned no answer.", t.agent.Name)
+	}
+	return &types.ToolResult{
+		Success: true,
+		Output:  output,
+		Data:    data,
+	}, nil
+}
+
+// forwardEvent re-emits a sub-agent event on the parent EventBus as a sub_agent event
+func (t *SubAgentTool) forwardEvent(parentToolCallID string) event.EventHandler {
+	return func(ctx context.Context, evt event.Event) error {
+		if t.eventBus == nil {
+			return nil
+		}
+		content, done := subAgentEventContent(evt)
+		return t.eventBus.Emit(ctx, event.Event{
+			ID:        parentToolCallID + "-" + evt.ID,
+			Type:      event.EventAgentSubAgent,
+			SessionID: evt.SessionID,
+			Data: event.AgentSubAgentData{
+				ParentToolCallID: parentToolCallID,
+				AgentID:          t.agent.ID,
+				AgentName:        t.agent.Name,
+				EventType:        evt.Type,
+				Content:          content,
+				Done:             done,
+				Data:             evt.Data,
+			},
+		})
+	}
+}
+
+// subAgentEventContent extracts the streamed content and completion flag of an agent event
+func subAgentEventContent(evt event.Event) (string, bool) {
+	switch data := evt.Data.(type) {
+	case event.AgentThoughtData:
+		return data.Content, data.Done
+	case event.AgentFinalAnswerData:
+		return data.Content, data.Done
+	case event.AgentReflectionData:
+		return data.Content, data.Done
+	case event.AgentToolResultData:
+		return data.Output, true
+	case event.ErrorData:
+		return data.Error, true
+	}
+	return "", false
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+type fakeSubAgentRunner struct {
+	answer string
+	err    error
+}
+
+func (r *fakeSubAgentRunner) RunSubAgent(
+	ctx context.Context,
+	sessionID, agentID, task string,
+	eventBus *event.EventBus,
+) (*types.AgentState, error) {
+	eventBus.Emit(ctx, event.Event{
+		ID:   "thought-1",
+		Type: event.EventAgentThought,
+		Data: event.AgentThoughtData{Content: "thinking about " + task},
+	})
+	if r.err != nil {
+		return nil, r.err
+	}
+	eventBus.Emit(ctx, event.Event{
+		ID:   "answer-1",
+		Type: event.EventAgentFinalAnswer,
+		Data: event.AgentFinalAnswerData{Content: r.answer, Done: true},
+	})
+	return &types.AgentState{FinalAnswer: r.answer, IsComplete: true}, nil
+}
+
+func TestSubAgentTool(t *testing.T) {
+	tests := []struct {
+		name        string
+		runner      *fakeSubAgentRunner
+		wantSuccess bool
+		wantOutput  string
+		wantEvents  int
+	}{
+		{
+			name:        "answer",
+			runner:      &fakeSubAgentRunner{answer: "42"},
+			wantSuccess: true,
+			wantOutput:  "42",
+			wantEvents:  2,
+		},
+		{
+			name:        "failure",
+			runner:      &fakeSubAgentRunner{err: errors.New("model unavailable")},
+			wantSuccess: false,
+			wantEvents:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parentBus := event.NewEventBus()
+			var forwarded []event.AgentSubAgentData
+			parentBus.On(event.EventAgentSubAgent, func(ctx context.Context, evt event.Event) error {
+				forwarded = append(forwarded, evt.Data.(event.AgentSubAgentData))
+				return nil
+			})
+
+			agent := &types.SubAgentInfo{ID: "research-Agent", Name: "Research"}
+			tool := NewSubAgentTool(agent, tt.runner, parentBus, "session-1")
+			if tool.Name() != "agent_research_agent" {
+				t.Fatalf("unexpected tool name %q", tool.Name())
+			}
+
+			args, _ := json.Marshal(SubAgentInput{Task: "answer"})
+			result, err := tool.Execute(WithToolCallID(context.Background(), "call-1"), args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Success != tt.wantSuccess || result.Output != tt.wantOutput {
+				t.Fatalf("got success=%v output=%q", result.Success, result.Output)
+			}
+
+			trace, ok := result.Data[SubAgentTraceKey].(*types.SubAgentTrace)
+			if !ok {
+				t.Fatal("missing sub-agent trace")
+			}
+			if trace.ToolCallID != "call-1" || trace.AgentID != agent.ID || trace.FinalAnswer != tt.wantOutput {
+				t.Fatalf("unexpected trace %+v", trace)
+			}
+			if tt.runner.err != nil && trace.Error == "" {
+				t.Fatal("expected trace error")
+			}
+
+			if len(forwarded) != tt.wantEvents {
+				t.Fatalf("got %d forwarded events, want %d", len(forwarded), tt.wantEvents)
+			}
+			for _, data := range forwarded {
+				if data.ParentToolCallID != "call-1" || data.AgentID != agent.ID {
+					t.Fatalf("event not nested under parent tool call: %+v", data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// ErrAgentApprovalNotFound is returned when no pending approval exists
+var ErrAgentApprovalNotFound = errors.New("agent approval not found")
+
+// agentApprovalRepository implements the AgentApprovalRepository interface
+type agentApprovalRepository struct {
+	db *gorm.DB
+}
+
+// NewAgentApprovalRepository creates a new agent approval repository
+func NewAgentApprovalRepository(db *gorm.DB) interfaces.AgentApprovalRepository {
+	return &agentApprovalRepository{db: db}
+}
+
+// Create stores a pending approval
+func (r *agentApprovalRepository) Create(ctx context.Context, approval *types.AgentApproval) error {
+	return r.db.WithContext(ctx).Create(approval).Error
+}
+
+// GetPendingByMessage gets the latest pending approval of an assistant message
+func (r *agentApprovalRepository) GetPendingByMessage(
+	ctx context.Context, tenantID uint64, sessionID, messageID string,
+) (*types.AgentApproval, error) {
+	var approval types.AgentApproval
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND session_id = ? AND message_id = ? AND status = ?",
+			tenantID, sessionID, messageID, types.AgentApprovalStatusPending).
+		Order("created_at DESC").
+		First(&approval).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAgentApprovalNotFound
+		}
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// Resolve records the decision on a pending approval, only one concurrent request can succeed
+func (r *agentApprovalRepository) Resolve(
+	ctx context.Context, tenantID uint64, id string, status types.AgentApprovalStatus, reason, resolvedBy string,
+) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&types.AgentApproval{}).
+		Where("id = ? AND tenant_id = ? AND status = ?", id, tenantID, types.AgentApprovalStatusPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reason":      reason,
+			"resolved_by": resolvedBy,
+			"resolved_at": now,
+			"updated_at":  now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateStatus moves an approval from one status to another, only one concurrent request can succeed
+func (r *agentApprovalRepository) UpdateStatus(
+	ctx context.Context, tenantID uint64, id string, from, to types.AgentApprovalStatus,
+) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&types.AgentApproval{}).
+		Where("id = ? AND tenant_id = ? AND status = ?", id, tenantID, from).
+		Updates(map[string]interface{}{
+			"status":     to,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListByStatusBefore lists the approvals of all tenants in the given status last updated before the given time
+func (r *agentApprovalRepository) ListByStatusBefore(
+	ctx context.Context, status types.AgentApprovalStatus, before time.Time,
+) ([]*types.AgentApproval, error) {
+	var approvals []*types.AgentApproval
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND updated_at < ?", status, before).
+		Order("updated_at ASC").
+		Find(&approvals).Error; err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
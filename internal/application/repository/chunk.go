@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/common"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -378,6 +379,41 @@ func (r *chunkRepository) DeleteChunksByTagID(ctx context.Context, tenantID uint
 	return toDelete, nil
 }
 
+// DeleteChunksByKnowledgeIDExcept deletes the chunks of a knowledge except the given ones
+// Returns the IDs of deleted chunks for index cleanup
+func (r *chunkRepository) DeleteChunksByKnowledgeIDExcept(
+	ctx context.Context, tenantID uint64, knowledgeID string, excludeIDs []string,
+) ([]string, error) {
+	excludeSet := make(map[string]struct{}, len(excludeIDs))
+	for _, id := range excludeIDs {
+		excludeSet[id] = struct{}{}
+	}
+
+	var allIDs []string
+	if err := r.db.WithContext(ctx).Model(&types.Chunk{}).
+		Where("tenant_id = ? AND knowledge_id = ?", tenantID, knowledgeID).
+		Pluck("id", &allIDs).Error; err != nil {
+		return nil, err
+	}
+
+	toDelete := make([]string, 0, len(allIDs))
+	for _, id := range allIDs {
+		if _, excluded := excludeSet[id]; !excluded {
+			toDelete = append(toDelete, id)
+		}
+	}
+
+	const batchSize = 1000
+	for i := 0; i < len(toDelete); i += batchSize {
+		batch := toDelete[i:min(i+batchSize, len(toDelete))]
+		if err := r.db.WithContext(ctx).Where("tenant_id = ? AND id IN ?", tenantID, batch).
+			Delete(&types.Chunk{}).Error; err != nil {
+			return toDelete[:i], err
+		}
+	}
+	return toDelete, nil
+}
+
 // CountChunksByKnowledgeBaseID counts the number of chunks in a knowledge base
 func (r *chunkRepository) CountChunksByKnowledgeBaseID(
 	ctx context.Context,
@@ -391,6 +427,32 @@ func (r *chunkRepository) CountChunksByKnowledgeBaseID(
 	return count, err
 }
 
+// ListChunksByKnowledgeBaseIDAfter lists up to limit chunks of a knowledge base whose ID is greater than afterID,
+// ordered by ID, so that large knowledge bases can be walked page by page while chunks are being modified.
+// A non-zero since restricts the list to chunks updated at or after it.
+func (r *chunkRepository) ListChunksByKnowledgeBaseIDAfter(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	since time.Time,
+	afterID string,
+	limit int,
+) ([]*types.Chunk, error) {
+	var chunks []*types.Chunk
+	query := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND id > ?", tenantID, kbID, afterID)
+	if !since.IsZero() {
+		query = query.Where("updated_at >= ?", since)
+	}
+	if err := query.
+		Order("id ASC").
+		Limit(limit).
+		Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
+
 // DeleteUnindexedChunks by knowledge id and chunk index range
 func (r *chunkRepository) DeleteUnindexedChunks(
 	ctx context.Context,
@@ -632,9 +694,9 @@ func (r *chunkRepository) UpdateChunkFieldsByTagID(
 	newTagID *string,
 	excludeIDs []string,
 ) ([]string, error) {
-	// First, get the IDs of chunks that will be affected (for is_enabled sync)
+	// First, get the IDs of chunks that will be affected (for is_enabled and tag sync)
 	var affectedIDs []string
-	if isEnabled != nil {
+	if isEnabled != nil || newTagID != nil {
 		var chunks []*types.Chunk
 		query := r.db.WithContext(ctx).
 			Select("id").
@@ -650,8 +712,10 @@ func (r *chunkRepository) UpdateChunkFieldsByTagID(
 			query = query.Where("id NOT IN ?", excludeIDs)
 		}
 
-		// Only get chunks that need to change
-		query = query.Where("is_enabled != ?", *isEnabled)
+		// Only get chunks that need to change, a tag move affects all of them
+		if newTagID == nil {
+			query = query.Where("is_enabled != ?", *isEnabled)
+		}
 		if err := query.Find(&chunks).Error; err != nil {
 			return nil, err
 		}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// ChunkFilterSubquery builds a SQL subquery selecting the IDs of chunks that match the filter.
+// It uses "?" placeholders and is shared by the chunk repository and the Postgres retriever,
+// whose embeddings table lives in the same database as chunks and knowledges.
+func ChunkFilterSubquery(filter *types.RetrieveFilter) (string, []interface{}) {
+	conds := []string{"c.deleted_at IS NULL", "k.deleted_at IS NULL"}
+	vars := make([]interface{}, 0)
+
+	if len(filter.TagIDs) > 0 {
+		placeholders := sqlPlaceholders(len(filter.TagIDs))
+		conds = append(conds, fmt.Sprintf("(c.tag_id IN (%s) OR k.tag_id IN (%s))", placeholders, placeholders))
+		vars = append(vars, common.ToInterfaceSlice(filter.TagIDs)...)
+		vars = append(vars, common.ToInterfaceSlice(filter.TagIDs)...)
+	}
+	if len(filter.FileTypes) > 0 {
+		conds = append(conds, fmt.Sprintf("k.file_type IN (%s)", sqlPlaceholders(len(filter.FileTypes))))
+		vars = append(vars, common.ToInterfaceSlice(filter.FileTypes)...)
+	}
+	if filter.CreatedAfter != nil {
+		conds = append(conds, "k.created_at >= ?")
+		vars = append(vars, *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		conds = append(conds, "k.created_at < ?")
+		vars = append(vars, *filter.CreatedBefore)
+	}
+	// Sort keys so the generated SQL is stable
+	keys := make([]string, 0, len(filter.Metadata))
+	for key := range filter.Metadata {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		conds = append(conds, "c.metadata::jsonb ->> ? = ?")
+		vars = append(vars, key, filter.Metadata[key])
+	}
+
+	return "SELECT c.id FROM chunks c JOIN knowledges k ON k.id = c.knowledge_id WHERE " +
+		strings.Join(conds, " AND "), vars
+}
+
+// ListChunkIDsByFilter lists the IDs of chunks in the given knowledge bases that match the filter.
+// It returns at most limit+1 IDs so callers can detect when the filter is too broad.
+func (r *chunkRepository) ListChunkIDsByFilter(
+	ctx context.Context,
+	tenantID uint64,
+	kbIDs []string,
+	knowledgeIDs []string,
+	filter *types.RetrieveFilter,
+	limit int,
+) ([]string, error) {
+	subquery, vars := ChunkFilterSubquery(filter)
+	query := subquery + " AND c.tenant_id = ?"
+	vars = append(vars, tenantID)
+	if len(kbIDs) > 0 {
+		query += fmt.Sprintf(" AND c.knowledge_base_id IN (%s)", sqlPlaceholders(len(kbIDs)))
+		vars = append(vars, common.ToInterfaceSlice(kbIDs)...)
+	}
+	if len(knowledgeIDs) > 0 {
+		query += fmt.Sprintf(" AND c.knowledge_id IN (%s)", sqlPlaceholders(len(knowledgeIDs)))
+		vars = append(vars, common.ToInterfaceSlice(knowledgeIDs)...)
+	}
+	query += " LIMIT ?"
+	vars = append(vars, limit+1)
+
+	var ids []string
+	if err := r.db.WithContext(ctx).Raw(query, vars...).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// sqlPlaceholders returns n comma-separated "?" placeholders
+func sqlPlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// ListChunksForIndexAttributes lists the chunks of the given knowledge with only the fields
+// their index attributes are built from
+func (r *chunkRepository) ListChunksForIndexAttributes(
+	ctx context.Context, tenantID uint64, knowledgeIDs []string,
+) ([]*types.Chunk, error) {
+	var chunks []*types.Chunk
+	if len(knowledgeIDs) == 0 {
+		return chunks, nil
+	}
+	if err := r.db.WithContext(ctx).
+		Select("id", "knowledge_id", "knowledge_base_id", "tag_id", "metadata").
+		Where("tenant_id = ? AND knowledge_id IN ?", tenantID, knowledgeIDs).
+		Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
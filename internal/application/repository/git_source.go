@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gitSourceRepository is a repository for Git sources and their file records
+type gitSourceRepository struct {
+	db *gorm.DB
+}
+
+// NewGitSourceRepository creates a new Git source repository
+func NewGitSourceRepository(db *gorm.DB) interfaces.GitSourceRepository {
+	return &gitSourceRepository{db: db}
+}
+
+// Create creates a Git source
+func (r *gitSourceRepository) Create(ctx context.Context, source *types.GitSource) error {
+	return r.db.WithContext(ctx).Create(source).Error
+}
+
+// Update saves a Git source
+func (r *gitSourceRepository) Update(ctx context.Context, source *types.GitSource) error {
+	return r.db.WithContext(ctx).Save(source).Error
+}
+
+// GetByID gets a Git source by ID
+func (r *gitSourceRepository) GetByID(ctx context.Context, tenantID uint64, id string) (*types.GitSource, error) {
+	var source types.GitSource
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		First(&source).Error; err != nil {
+		return nil, err
+	}
+	return &source, nil
+}
+
+// ListByKB lists the Git sources of a knowledge base
+func (r *gitSourceRepository) ListByKB(
+	ctx context.Context, tenantID uint64, kbID string,
+) ([]*types.GitSource, error) {
+	var sources []*types.GitSource
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+		Order("created_at ASC").
+		Find(&sources).Error; err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// Delete deletes a Git source and its file records
+func (r *gitSourceRepository) Delete(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ? AND source_id = ?", tenantID, id).
+			Delete(&types.GitSourceFile{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("tenant_id = ? AND id = ?", tenantID, id).Delete(&types.GitSource{}).Error
+	})
+}
+
+// UpdateSyncStatus sets the sync status of a Git source
+func (r *gitSourceRepository) UpdateSyncStatus(ctx context.Context, tenantID uint64, id string, status string) error {
+	return r.db.WithContext(ctx).Model(&types.GitSource{}).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Updates(map[string]interface{}{"sync_status": status, "updated_at": time.Now()}).Error
+}
+
+// UpdateSyncResult saves the sync status, counters, error and commit of a Git source, leaving the
+// settings a concurrent update may have changed untouched
+func (r *gitSourceRepository) UpdateSyncResult(ctx context.Context, source *types.GitSource) error {
+	return r.db.WithContext(ctx).Model(&types.GitSource{}).
+		Where("tenant_id = ? AND id = ?", source.TenantID, source.ID).
+		Updates(map[string]interface{}{
+			"sync_status":    source.SyncStatus,
+			"sync_stats":     source.SyncStats,
+			"error_message":  source.ErrorMessage,
+			"last_commit":    source.LastCommit,
+			"last_synced_at": source.LastSyncedAt,
+			"updated_at":     time.Now(),
+		}).Error
+}
+
+// ListFiles lists the file records of a Git source
+func (r *gitSourceRepository) ListFiles(
+	ctx context.Context, tenantID uint64, sourceID string,
+) ([]*types.GitSourceFile, error) {
+	var files []*types.GitSourceFile
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND source_id = ?", tenantID, sourceID).
+		Order("path ASC").
+		Find(&files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// SaveFile creates or updates a file record
+func (r *gitSourceRepository) SaveFile(ctx context.Context, file *types.GitSourceFile) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "source_id"}, {Name: "path"}},
+		DoUpdates: clause.AssignmentColumns([]string{"knowledge_id", "blob_sha", "commit_sha", "updated_at"}),
+	}).Create(file).Error
+}
+
+// DeleteFile deletes a file record
+func (r *gitSourceRepository) DeleteFile(ctx context.Context, tenantID uint64, sourceID string, path string) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND source_id = ? AND path = ?", tenantID, sourceID, path).
+		Delete(&types.GitSourceFile{}).Error
+}
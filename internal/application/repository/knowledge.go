@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -347,3 +348,24 @@ func (r *knowledgeRepository) SearchKnowledge(
 	}
 	return knowledges, hasMore, nil
 }
+
+// ListURLKnowledgeDueForRefresh lists URL knowledge of all tenants whose refresh interval has elapsed.
+// Knowledge still being parsed or deleted is skipped, it is picked up by a later scan.
+func (r *knowledgeRepository) ListURLKnowledgeDueForRefresh(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+) ([]*types.Knowledge, error) {
+	var knowledgeList []*types.Knowledge
+	err := r.db.WithContext(ctx).
+		Where("type = ? AND refresh_interval > 0", types.KnowledgeTypeURL).
+		Where("parse_status IN ?", []string{types.ParseStatusCompleted, types.ParseStatusFailed}).
+		Where("(last_checked_at IS NULL OR last_checked_at + make_interval(secs => refresh_interval) <= ?)", now).
+		Order("last_checked_at ASC NULLS FIRST").
+		Limit(limit).
+		Find(&knowledgeList).Error
+	if err != nil {
+		return nil, err
+	}
+	return knowledgeList, nil
+}
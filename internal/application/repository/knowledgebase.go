@@ -80,3 +80,24 @@ func (r *knowledgeBaseRepository) UpdateKnowledgeBase(ctx context.Context, kb *t
 func (r *knowledgeBaseRepository) DeleteKnowledgeBase(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&types.KnowledgeBase{}).Error
 }
+
+// SwitchEmbeddingModel points a knowledge base and all of its knowledge at another embedding model
+// in one transaction
+func (r *knowledgeBaseRepository) SwitchEmbeddingModel(
+	ctx context.Context, tenantID uint64, id string, modelID string,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&types.KnowledgeBase{}).
+			Where("id = ? AND tenant_id = ?", id, tenantID).
+			Update("embedding_model_id", modelID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrKnowledgeBaseNotFound
+		}
+		return tx.Model(&types.Knowledge{}).
+			Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, id).
+			Update("embedding_model_id", modelID).Error
+	})
+}
@@ -133,6 +133,19 @@ func (r *mcpServiceRepository) Update(ctx context.Context, service *types.MCPSer
 		Updates(updateMap).Error
 }
 
+// UpdateAuthConfig updates the auth config of an MCP service only
+func (r *mcpServiceRepository) UpdateAuthConfig(
+	ctx context.Context,
+	tenantID uint64,
+	id string,
+	authConfig *types.MCPAuthConfig,
+) error {
+	return r.db.WithContext(ctx).
+		Model(&types.MCPService{}).
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		Update("auth_config", authConfig).Error
+}
+
 // Delete deletes an MCP service (soft delete)
 func (r *mcpServiceRepository) Delete(ctx context.Context, tenantID uint64, id string) error {
 	return r.db.WithContext(ctx).
@@ -1,6 +1,7 @@
 package elasticsearch
 
 import (
+	"encoding/json"
 	"maps"
 	"slices"
 
@@ -16,7 +17,83 @@ type VectorEmbedding struct {
 	KnowledgeID     string    `json:"knowledge_id"      gorm:"column:knowledge_id"`         // ID of the knowledge item
 	KnowledgeBaseID string    `json:"knowledge_base_id" gorm:"column:knowledge_base_id"`    // ID of the knowledge base
 	Embedding       []float32 `json:"embedding"         gorm:"column:embedding;not null"`   // Vector embedding of the content
+	Dimension       int       `json:"dimension,omitempty"`                                  // Dimension of the vector embedding
 	IsEnabled       bool      `json:"is_enabled"`                                           // Whether the chunk is enabled
+
+	// Filter attributes, see types.IndexAttributes
+	TagIDs             []string `json:"tag_ids,omitempty"`              // Tags of the chunk and of its knowledge
+	FileType           string   `json:"file_type,omitempty"`            // File type of the knowledge
+	KnowledgeCreatedAt int64    `json:"knowledge_created_at,omitempty"` // Creation time of the knowledge, Unix milliseconds
+	MetadataPairs      []string `json:"metadata_pairs,omitempty"`       // Chunk metadata encoded with types.MetadataPair
+}
+
+// SetAttributes stores the filter attributes on the document, nil clears them
+func (v *VectorEmbedding) SetAttributes(attrs *types.IndexAttributes) {
+	if attrs == nil {
+		attrs = &types.IndexAttributes{}
+	}
+	v.TagIDs = attrs.TagIDs
+	v.FileType = attrs.FileType
+	v.KnowledgeCreatedAt = attrs.CreatedAt
+	v.MetadataPairs = attrs.MetadataPairs
+}
+
+// Attributes returns the filter attributes stored on the document, nil if it was indexed without them
+func (v *VectorEmbedding) Attributes() *types.IndexAttributes {
+	if len(v.TagIDs) == 0 && v.FileType == "" && v.KnowledgeCreatedAt == 0 && len(v.MetadataPairs) == 0 {
+		return nil
+	}
+	return &types.IndexAttributes{
+		TagIDs:        v.TagIDs,
+		FileType:      v.FileType,
+		CreatedAt:     v.KnowledgeCreatedAt,
+		MetadataPairs: v.MetadataPairs,
+	}
+}
+
+// AttributeScriptParams returns the script parameters that replace the filter attributes of a document,
+// used with AttributeScript
+func AttributeScriptParams(attrs *types.IndexAttributes) map[string]interface{} {
+	doc := &VectorEmbedding{}
+	doc.SetAttributes(attrs)
+	return map[string]interface{}{
+		"tag_ids":              nonNil(doc.TagIDs),
+		"file_type":            doc.FileType,
+		"knowledge_created_at": doc.KnowledgeCreatedAt,
+		"metadata_pairs":       nonNil(doc.MetadataPairs),
+	}
+}
+
+// AttributeScript is the painless script that replaces the filter attributes of a document
+const AttributeScript = "ctx._source.tag_ids = params.tag_ids; ctx._source.file_type = params.file_type; " +
+	"ctx._source.knowledge_created_at = params.knowledge_created_at; ctx._source.metadata_pairs = params.metadata_pairs"
+
+// AttributeGroup is a set of chunks sharing the same filter attributes
+type AttributeGroup struct {
+	ChunkIDs   []string
+	Attributes *types.IndexAttributes
+}
+
+// GroupByAttributes groups chunks with identical attributes, so each group can be updated with a single request
+func GroupByAttributes(chunkAttributes map[string]*types.IndexAttributes) []*AttributeGroup {
+	groups := make(map[string]*AttributeGroup)
+	for chunkID, attrs := range chunkAttributes {
+		key, _ := json.Marshal(attrs)
+		group, ok := groups[string(key)]
+		if !ok {
+			group = &AttributeGroup{Attributes: attrs}
+			groups[string(key)] = group
+		}
+		group.ChunkIDs = append(group.ChunkIDs, chunkID)
+	}
+	return slices.Collect(maps.Values(groups))
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
 }
 
 // VectorEmbeddingWithScore extends VectorEmbedding with similarity score
@@ -36,10 +113,12 @@ func ToDBVectorEmbedding(embedding *types.IndexInfo, additionalParams map[string
 		KnowledgeBaseID: embedding.KnowledgeBaseID,
 		IsEnabled:       true, // Default to enabled
 	}
+	vector.SetAttributes(embedding.Attributes)
 	// Add embedding data if available in additionalParams
 	if additionalParams != nil && slices.Contains(slices.Collect(maps.Keys(additionalParams)), "embedding") {
 		if embeddingMap, ok := additionalParams["embedding"].(map[string][]float32); ok {
 			vector.Embedding = embeddingMap[embedding.SourceID]
+			vector.Dimension = len(vector.Embedding)
 		}
 	}
 	// Get is_enabled from additionalParams if available
@@ -319,6 +319,42 @@ func (e *elasticsearchRepository) deleteByFieldList(ctx context.Context, field s
 	}
 
 	query := fmt.Sprintf(`{"query": {"terms": {"%s": %s}}}`, field, ids)
+	return e.deleteByQuery(ctx, field, query)
+}
+
+// DeleteByKnowledgeBaseIDList Delete indices of the given dimension by knowledge base ID list.
+// Documents written before the dimension field existed are treated as the old dimension.
+func (e *elasticsearchRepository) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(knowledgeBaseIDList) == 0 {
+		log.Warn("[ElasticsearchV7] Empty knowledge base ID list provided for deletion, skipping")
+		return nil
+	}
+
+	log.Infof("[ElasticsearchV7] Deleting indices by knowledge base IDs, count: %d, dimension: %d",
+		len(knowledgeBaseIDList), dimension)
+	ids, err := json.Marshal(knowledgeBaseIDList)
+	if err != nil {
+		log.Errorf("[ElasticsearchV7] Failed to marshal knowledge base ID list: %v", err)
+		return err
+	}
+
+	query := fmt.Sprintf(`{"query": {"bool": {
+		"filter": [{"terms": {"knowledge_base_id.keyword": %s}}],
+		"should": [
+			{"term": {"dimension": %d}},
+			{"bool": {"must": [{"exists": {"field": "embedding"}}], "must_not": [{"exists": {"field": "dimension"}}]}}
+		],
+		"minimum_should_match": 1
+	}}}`, ids, dimension)
+	return e.deleteByQuery(ctx, "knowledge_base_id.keyword", query)
+}
+
+// deleteByQuery Delete documents matching the query, field is only used for logging
+func (e *elasticsearchRepository) deleteByQuery(ctx context.Context, field string, query string) error {
+	log := logger.GetLogger(ctx)
 	log.Debugf("[ElasticsearchV7] Executing delete by query: %s", query)
 
 	resp, err := e.client.DeleteByQuery(
@@ -353,6 +389,42 @@ func (e *elasticsearchRepository) deleteByFieldList(ctx context.Context, field s
 	return nil
 }
 
+// filterConds translates a metadata filter into conditions on the document attributes
+func filterConds(filter *typesLocal.RetrieveFilter) []map[string]interface{} {
+	if filter.IsEmpty() {
+		return nil
+	}
+	conds := make([]map[string]interface{}, 0)
+	if len(filter.TagIDs) > 0 {
+		conds = append(conds, map[string]interface{}{
+			"terms": map[string]interface{}{"tag_ids.keyword": filter.TagIDs},
+		})
+	}
+	if len(filter.FileTypes) > 0 {
+		conds = append(conds, map[string]interface{}{
+			"terms": map[string]interface{}{"file_type.keyword": filter.FileTypes},
+		})
+	}
+	if filter.CreatedAfter != nil || filter.CreatedBefore != nil {
+		createdAt := map[string]interface{}{}
+		if filter.CreatedAfter != nil {
+			createdAt["gte"] = filter.CreatedAfter.UnixMilli()
+		}
+		if filter.CreatedBefore != nil {
+			createdAt["lt"] = filter.CreatedBefore.UnixMilli()
+		}
+		conds = append(conds, map[string]interface{}{
+			"range": map[string]interface{}{"knowledge_created_at": createdAt},
+		})
+	}
+	for _, pair := range filter.MetadataPairs() {
+		conds = append(conds, map[string]interface{}{
+			"term": map[string]interface{}{"metadata_pairs.keyword": pair},
+		})
+	}
+	return conds
+}
+
 // getBaseConds Construct base Elasticsearch query conditions based on retrieval parameters
 // It creates MUST conditions for required fields and MUST_NOT conditions for excluded fields
 // KnowledgeBaseIDs and KnowledgeIDs use AND logic (search specific documents within knowledge bases)
@@ -379,6 +451,8 @@ func (e *elasticsearchRepository) getBaseConds(params typesLocal.RetrieveParams)
 			},
 		})
 	}
+	// Metadata filter, evaluated against the attributes stored with each document
+	must = append(must, filterConds(params.Filter)...)
 
 	// Build MUST_NOT conditions (negative filters)
 	mustNot := make([]map[string]interface{}, 0)
@@ -503,7 +577,7 @@ func (e *elasticsearchRepository) buildVectorSearchQuery(ctx context.Context,
 			"script_score": map[string]interface{}{
 				"query": map[string]interface{}{
 					"bool": map[string]interface{}{
-						"filter": []interface{}{filterQuery},
+						"filter": []interface{}{filterQuery, dimensionFilter(len(params.Embedding))},
 					},
 				},
 				"script": map[string]interface{}{
@@ -530,6 +604,23 @@ func (e *elasticsearchRepository) buildVectorSearchQuery(ctx context.Context,
 	return query, nil
 }
 
+// dimensionFilter matches documents whose vectors have the given dimension, so vectors written by an
+// embedding model of another dimension (e.g. during re-embedding) are skipped. Documents written before
+// the dimension field existed are still matched.
+func dimensionFilter(dimension int) map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should": []interface{}{
+				map[string]interface{}{"term": map[string]interface{}{"dimension": dimension}},
+				map[string]interface{}{"bool": map[string]interface{}{
+					"must_not": []interface{}{map[string]interface{}{"exists": map[string]interface{}{"field": "dimension"}}},
+				}},
+			},
+			"minimum_should_match": 1,
+		},
+	}
+}
+
 // executeVectorSearch executes the vector search query
 func (e *elasticsearchRepository) executeVectorSearch(
 	ctx context.Context,
@@ -1092,11 +1183,25 @@ func (e *elasticsearchRepository) processSingleHit(ctx context.Context,
 		KnowledgeBaseID: targetKnowledgeBaseID,
 		Content:         content,
 		SourceType:      typesLocal.SourceType(sourceType),
+		Attributes:      attributesFromSource(sourceObj),
 	}
 
 	return indexInfo, embedding, nil
 }
 
+// attributesFromSource extracts the filter attributes from a document source
+func attributesFromSource(sourceObj map[string]interface{}) *typesLocal.IndexAttributes {
+	raw, err := json.Marshal(sourceObj)
+	if err != nil {
+		return nil
+	}
+	var doc elasticsearchRetriever.VectorEmbedding
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil
+	}
+	return doc.Attributes()
+}
+
 // saveCopiedIndices saves the copied indices
 func (e *elasticsearchRepository) saveCopiedIndices(ctx context.Context, indexInfoList []*typesLocal.IndexInfo) error {
 	log := logger.GetLogger(ctx)
@@ -1227,3 +1332,67 @@ func (e *elasticsearchRepository) BatchUpdateChunkEnabledStatus(
 	log.Infof("[ElasticsearchV7] Successfully batch updated chunk enabled status")
 	return nil
 }
+
+// BatchUpdateChunkAttributes replaces the filter attributes of chunks in batch
+func (e *elasticsearchRepository) BatchUpdateChunkAttributes(
+	ctx context.Context,
+	chunkAttributes map[string]*typesLocal.IndexAttributes,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkAttributes) == 0 {
+		log.Warnf("[ElasticsearchV7] Chunk attributes map is empty, skipping update")
+		return nil
+	}
+
+	log.Infof("[ElasticsearchV7] Batch updating chunk attributes, count: %d", len(chunkAttributes))
+
+	// Chunks with identical attributes share one update_by_query
+	for _, group := range elasticsearchRetriever.GroupByAttributes(chunkAttributes) {
+		if err := e.updateChunkAttributes(ctx, group); err != nil {
+			return err
+		}
+	}
+
+	log.Infof("[ElasticsearchV7] Successfully batch updated chunk attributes")
+	return nil
+}
+
+// updateChunkAttributes sets the attributes of one group of chunks using update_by_query
+func (e *elasticsearchRepository) updateChunkAttributes(
+	ctx context.Context,
+	group *elasticsearchRetriever.AttributeGroup,
+) error {
+	log := logger.GetLogger(ctx)
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"terms": map[string]interface{}{
+				"chunk_id.keyword": group.ChunkIDs,
+			},
+		},
+		"script": map[string]interface{}{
+			"source": elasticsearchRetriever.AttributeScript,
+			"lang":   "painless",
+			"params": elasticsearchRetriever.AttributeScriptParams(group.Attributes),
+		},
+	}
+	queryJSON, _ := json.Marshal(query)
+	res, err := esapi.UpdateByQueryRequest{
+		Index: []string{e.index},
+		Body:  strings.NewReader(string(queryJSON)),
+	}.Do(ctx, e.client)
+	if err != nil {
+		log.Errorf("[ElasticsearchV7] Failed to update chunk attributes: %v", err)
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		var e map[string]interface{}
+		if err := json.NewDecoder(res.Body).Decode(&e); err != nil {
+			log.Errorf("[ElasticsearchV7] Error parsing the response body: %v", err)
+		} else {
+			log.Errorf("[ElasticsearchV7] Error updating chunk attributes: %v", e["error"])
+		}
+		return fmt.Errorf("elasticsearch update_by_query failed with status: %d", res.StatusCode)
+	}
+	return nil
+}
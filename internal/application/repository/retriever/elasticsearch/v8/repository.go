@@ -234,6 +234,58 @@ func (e *elasticsearchRepository) DeleteByKnowledgeIDList(ctx context.Context,
 	return nil
 }
 
+// DeleteByKnowledgeBaseIDList removes documents of the given dimension based on knowledge base IDs.
+// Documents written before the dimension field existed are treated as the old dimension.
+func (e *elasticsearchRepository) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(knowledgeBaseIDList) == 0 {
+		log.Warn("[Elasticsearch] Empty knowledge base ID list provided for deletion, skipping")
+		return nil
+	}
+
+	log.Infof("[Elasticsearch] Deleting indices by knowledge base IDs, count: %d, dimension: %d",
+		len(knowledgeBaseIDList), dimension)
+	minimumShouldMatch := types.MinimumShouldMatch(1)
+	_, err := e.client.DeleteByQuery(e.index).Query(&types.Query{
+		Bool: &types.BoolQuery{
+			Filter: []types.Query{{Terms: &types.TermsQuery{
+				TermsQuery: map[string]types.TermsQueryField{"knowledge_base_id.keyword": knowledgeBaseIDList},
+			}}},
+			Should: []types.Query{
+				{Term: map[string]types.TermQuery{"dimension": {Value: dimension}}},
+				{Bool: &types.BoolQuery{
+					Must:    []types.Query{{Exists: &types.ExistsQuery{Field: "embedding"}}},
+					MustNot: []types.Query{{Exists: &types.ExistsQuery{Field: "dimension"}}},
+				}},
+			},
+			MinimumShouldMatch: minimumShouldMatch,
+		},
+	}).Do(ctx)
+	if err != nil {
+		log.Errorf("[Elasticsearch] Failed to delete by knowledge base IDs: %v", err)
+		return fmt.Errorf("failed to delete by query: %w", err)
+	}
+
+	log.Infof("[Elasticsearch] Successfully deleted documents by knowledge base IDs")
+	return nil
+}
+
+// dimensionFilter matches documents whose vectors have the given dimension, so vectors written by an
+// embedding model of another dimension (e.g. during re-embedding) are skipped. Documents written before
+// the dimension field existed are still matched.
+func dimensionFilter(dimension int) types.Query {
+	minimumShouldMatch := types.MinimumShouldMatch(1)
+	return types.Query{Bool: &types.BoolQuery{
+		Should: []types.Query{
+			{Term: map[string]types.TermQuery{"dimension": {Value: dimension}}},
+			{Bool: &types.BoolQuery{MustNot: []types.Query{{Exists: &types.ExistsQuery{Field: "dimension"}}}}},
+		},
+		MinimumShouldMatch: minimumShouldMatch,
+	}}
+}
+
 // getBaseConds creates the base query conditions for retrieval operations
 // Returns a slice of Query objects with must and must_not conditions
 // KnowledgeBaseIDs and KnowledgeIDs use AND logic (search specific documents within knowledge bases)
@@ -258,6 +310,8 @@ func (e *elasticsearchRepository) getBaseConds(params typesLocal.RetrieveParams)
 			},
 		}})
 	}
+	// Metadata filter, evaluated against the attributes stored with each document
+	must = append(must, filterConds(params.Filter)...)
 
 	mustNot := make([]types.Query, 0)
 	// Exclude disabled chunks (is_enabled = false)
@@ -280,6 +334,42 @@ func (e *elasticsearchRepository) getBaseConds(params typesLocal.RetrieveParams)
 
 // createIndexIfNotExists checks if the specified index exists and creates it if not
 // Returns an error if the operation fails
+// filterConds translates a metadata filter into conditions on the document attributes
+func filterConds(filter *typesLocal.RetrieveFilter) []types.Query {
+	if filter.IsEmpty() {
+		return nil
+	}
+	conds := make([]types.Query, 0)
+	if len(filter.TagIDs) > 0 {
+		conds = append(conds, types.Query{Terms: &types.TermsQuery{
+			TermsQuery: map[string]types.TermsQueryField{"tag_ids.keyword": filter.TagIDs},
+		}})
+	}
+	if len(filter.FileTypes) > 0 {
+		conds = append(conds, types.Query{Terms: &types.TermsQuery{
+			TermsQuery: map[string]types.TermsQueryField{"file_type.keyword": filter.FileTypes},
+		}})
+	}
+	if filter.CreatedAfter != nil || filter.CreatedBefore != nil {
+		createdAt := types.NumberRangeQuery{}
+		if filter.CreatedAfter != nil {
+			after := types.Float64(filter.CreatedAfter.UnixMilli())
+			createdAt.Gte = &after
+		}
+		if filter.CreatedBefore != nil {
+			before := types.Float64(filter.CreatedBefore.UnixMilli())
+			createdAt.Lt = &before
+		}
+		conds = append(conds, types.Query{Range: map[string]types.RangeQuery{"knowledge_created_at": createdAt}})
+	}
+	for _, pair := range filter.MetadataPairs() {
+		conds = append(conds, types.Query{Term: map[string]types.TermQuery{
+			"metadata_pairs.keyword": {Value: pair},
+		}})
+	}
+	return conds
+}
+
 func (e *elasticsearchRepository) createIndexIfNotExists(ctx context.Context) error {
 	log := logger.GetLogger(ctx)
 	log.Debugf("[Elasticsearch] Checking if index exists: %s", e.index)
@@ -338,7 +428,7 @@ func (e *elasticsearchRepository) VectorRetrieve(ctx context.Context,
 	log.Infof("[Elasticsearch] Vector retrieval: dim=%d, topK=%d, threshold=%.4f",
 		len(params.Embedding), params.TopK, params.Threshold)
 
-	filter := e.getBaseConds(params)
+	filter := append(e.getBaseConds(params), dimensionFilter(len(params.Embedding)))
 
 	// Build script scoring query with cosine similarity
 	queryVectorJSON, err := json.Marshal(params.Embedding)
@@ -567,6 +657,7 @@ func (e *elasticsearchRepository) CopyIndices(ctx context.Context,
 				ChunkID:         targetChunkID,
 				KnowledgeID:     targetKnowledgeID,
 				KnowledgeBaseID: targetKnowledgeBaseID,
+				Attributes:      sourceDoc.Attributes(),
 			}
 
 			indexInfoList = append(indexInfoList, indexInfo)
@@ -682,3 +773,54 @@ func (e *elasticsearchRepository) BatchUpdateChunkEnabledStatus(
 	log.Infof("[Elasticsearch] Successfully batch updated chunk enabled status")
 	return nil
 }
+
+// BatchUpdateChunkAttributes replaces the filter attributes of chunks in batch
+func (e *elasticsearchRepository) BatchUpdateChunkAttributes(
+	ctx context.Context,
+	chunkAttributes map[string]*typesLocal.IndexAttributes,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkAttributes) == 0 {
+		log.Warnf("[Elasticsearch] Chunk attributes map is empty, skipping update")
+		return nil
+	}
+
+	log.Infof("[Elasticsearch] Batch updating chunk attributes, count: %d", len(chunkAttributes))
+
+	// Chunks with identical attributes share one update_by_query
+	for _, group := range elasticsearchRetriever.GroupByAttributes(chunkAttributes) {
+		query := types.NewQuery()
+		query.Bool = &types.BoolQuery{
+			Must: []types.Query{
+				{Terms: &types.TermsQuery{
+					TermsQuery: map[string]types.TermsQueryField{
+						"chunk_id.keyword": group.ChunkIDs,
+					},
+				}},
+			},
+		}
+		params := make(map[string]json.RawMessage)
+		for name, value := range elasticsearchRetriever.AttributeScriptParams(group.Attributes) {
+			raw, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			params[name] = raw
+		}
+		source := elasticsearchRetriever.AttributeScript
+		lang := scriptlanguage.Painless
+		script := types.Script{
+			Source: &source,
+			Lang:   &lang,
+			Params: params,
+		}
+		_, err := e.client.UpdateByQuery(e.index).Query(query).Script(&script).Do(ctx)
+		if err != nil {
+			log.Errorf("[Elasticsearch] Failed to update chunk attributes: %v", err)
+			return err
+		}
+	}
+
+	log.Infof("[Elasticsearch] Successfully batch updated chunk attributes")
+	return nil
+}
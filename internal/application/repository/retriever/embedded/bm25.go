@@ -0,0 +1,158 @@
+package embedded
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// bm25K1 controls term frequency saturation
+	bm25K1 = 1.2
+	// bm25B controls document length normalization
+	bm25B = 0.75
+)
+
+// bm25Index is an in-memory inverted index scored with Okapi BM25.
+// It is rebuilt from the persisted documents on startup.
+// It is not safe for concurrent use; the repository serializes access.
+type bm25Index struct {
+	// postings maps term -> document ID -> term frequency
+	postings map[string]map[string]int
+	// docLengths maps document ID -> number of terms
+	docLengths map[string]int
+	// docTerms maps document ID -> distinct terms, used for removal
+	docTerms    map[string][]string
+	totalLength int
+}
+
+// newBM25Index creates an empty inverted index
+func newBM25Index() *bm25Index {
+	return &bm25Index{
+		postings:   make(map[string]map[string]int),
+		docLengths: make(map[string]int),
+		docTerms:   make(map[string][]string),
+	}
+}
+
+// Add indexes the content of a document, replacing any previous content for the same ID
+func (b *bm25Index) Add(id string, content string) {
+	b.Remove(id)
+
+	terms := tokenize(content)
+	distinct := make([]string, 0, len(terms))
+	for _, term := range terms {
+		postings, ok := b.postings[term]
+		if !ok {
+			postings = make(map[string]int)
+			b.postings[term] = postings
+		}
+		if postings[id] == 0 {
+			distinct = append(distinct, term)
+		}
+		postings[id]++
+	}
+	b.docLengths[id] = len(terms)
+	b.docTerms[id] = distinct
+	b.totalLength += len(terms)
+}
+
+// Remove drops a document from the index
+func (b *bm25Index) Remove(id string) {
+	length, ok := b.docLengths[id]
+	if !ok {
+		return
+	}
+	for _, term := range b.docTerms[id] {
+		postings := b.postings[term]
+		delete(postings, id)
+		if len(postings) == 0 {
+			delete(b.postings, term)
+		}
+	}
+	delete(b.docLengths, id)
+	delete(b.docTerms, id)
+	b.totalLength -= length
+}
+
+// Search scores documents against the query terms and returns the top k that pass accept
+func (b *bm25Index) Search(query string, k int, accept func(id string) bool) []scoredKey {
+	docCount := len(b.docLengths)
+	if docCount == 0 || k <= 0 {
+		return nil
+	}
+	avgLength := float64(b.totalLength) / float64(docCount)
+
+	scores := make(map[string]float64)
+	for _, term := range uniqueTerms(query) {
+		postings, ok := b.postings[term]
+		if !ok {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (float64(docCount)-df+0.5)/(df+0.5))
+		for id, tf := range postings {
+			if accept != nil && !accept(id) {
+				continue
+			}
+			freq := float64(tf)
+			norm := freq + bm25K1*(1-bm25B+bm25B*float64(b.docLengths[id])/avgLength)
+			scores[id] += idf * freq * (bm25K1 + 1) / norm
+		}
+	}
+
+	results := make([]scoredKey, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, scoredKey{key: id, score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score == results[j].score {
+			return results[i].key < results[j].key
+		}
+		return results[i].score > results[j].score
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// tokenize splits text into lowercase terms using jieba, keeping multi-character words
+func tokenize(text string) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	words := types.Jieba.CutForSearch(text, true)
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.TrimSpace(strings.ToLower(word))
+		if utf8.RuneCountInString(word) < 2 && !isCJK(word) {
+			continue
+		}
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// uniqueTerms tokenizes the query and removes duplicates
+func uniqueTerms(query string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, term := range tokenize(query) {
+		if !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+	return result
+}
+
+// isCJK reports whether a single-rune word is a CJK ideograph, which carries meaning on its own
+func isCJK(word string) bool {
+	r, size := utf8.DecodeRuneInString(word)
+	return size > 0 && size == len(word) && r >= 0x4E00 && r <= 0x9FFF
+}
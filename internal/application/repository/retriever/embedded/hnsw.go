@@ -0,0 +1,381 @@
+package embedded
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+)
+
+const (
+	// defaultM is the number of neighbors kept per node on upper layers
+	defaultM = 16
+	// defaultEfConstruction is the candidate list size used while inserting
+	defaultEfConstruction = 200
+	// defaultEfSearch is the minimum candidate list size used while searching
+	defaultEfSearch = 64
+)
+
+// hnswNode is a single vector in the graph
+type hnswNode struct {
+	Key       string     // document ID
+	Vector    []float32  // L2-normalized vector
+	Level     int        // highest layer the node lives on
+	Neighbors [][]uint32 // neighbors per layer
+	Deleted   bool       // tombstone, skipped in results but kept for connectivity
+}
+
+// hnswGraph is a hierarchical navigable small world graph using cosine similarity.
+// Vectors are normalized on insert, so similarity is the dot product.
+// It is not safe for concurrent use; the repository serializes access.
+type hnswGraph struct {
+	Dimension      int
+	M              int
+	EfConstruction int
+	EntryPoint     int
+	MaxLevel       int
+	Nodes          []*hnswNode
+	// keyIndex maps document IDs to node positions, rebuilt on load
+	keyIndex map[string]uint32
+	// deleted counts tombstoned nodes
+	deleted int
+	rng     *rand.Rand
+}
+
+// newHNSWGraph creates an empty graph for vectors of the given dimension
+func newHNSWGraph(dimension int) *hnswGraph {
+	return &hnswGraph{
+		Dimension:      dimension,
+		M:              defaultM,
+		EfConstruction: defaultEfConstruction,
+		EntryPoint:     -1,
+		keyIndex:       make(map[string]uint32),
+		rng:            rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+// restore rebuilds the derived state after the graph has been decoded from disk
+func (g *hnswGraph) restore() {
+	g.keyIndex = make(map[string]uint32, len(g.Nodes))
+	g.deleted = 0
+	for i, node := range g.Nodes {
+		if node.Deleted {
+			g.deleted++
+			continue
+		}
+		g.keyIndex[node.Key] = uint32(i)
+	}
+	g.rng = rand.New(rand.NewSource(rand.Int63()))
+}
+
+// Len returns the number of live vectors in the graph
+func (g *hnswGraph) Len() int {
+	return len(g.Nodes) - g.deleted
+}
+
+// Insert adds a vector under the given key, replacing any existing vector for that key
+func (g *hnswGraph) Insert(key string, vector []float32) {
+	g.Delete(key)
+
+	level := g.randomLevel()
+	node := &hnswNode{
+		Key:       key,
+		Vector:    normalize(vector),
+		Level:     level,
+		Neighbors: make([][]uint32, level+1),
+	}
+	id := uint32(len(g.Nodes))
+	g.Nodes = append(g.Nodes, node)
+	g.keyIndex[key] = id
+
+	if g.EntryPoint < 0 {
+		g.EntryPoint = int(id)
+		g.MaxLevel = level
+		return
+	}
+
+	entry := uint32(g.EntryPoint)
+	// Greedy descent through the layers above the new node's level
+	for l := g.MaxLevel; l > level; l-- {
+		entry = g.greedyClosest(node.Vector, entry, l)
+	}
+
+	for l := min(level, g.MaxLevel); l >= 0; l-- {
+		candidates := g.searchLayer(node.Vector, []uint32{entry}, g.EfConstruction, l, nil)
+		neighbors := g.selectNeighbors(candidates, g.maxNeighbors(l))
+		node.Neighbors[l] = neighbors
+		for _, n := range neighbors {
+			g.link(n, id, l)
+		}
+		if len(candidates) > 0 {
+			entry = candidates[0].id
+		}
+	}
+
+	if level > g.MaxLevel {
+		g.MaxLevel = level
+		g.EntryPoint = int(id)
+	}
+}
+
+// Delete tombstones the vector stored under key
+func (g *hnswGraph) Delete(key string) bool {
+	id, ok := g.keyIndex[key]
+	if !ok {
+		return false
+	}
+	g.Nodes[id].Deleted = true
+	delete(g.keyIndex, key)
+	g.deleted++
+	return true
+}
+
+// NeedsCompaction reports whether tombstones make up a large share of the graph
+func (g *hnswGraph) NeedsCompaction() bool {
+	return g.deleted > 1000 && g.deleted > len(g.Nodes)/3
+}
+
+// Compact rebuilds the graph without tombstoned nodes
+func (g *hnswGraph) Compact() *hnswGraph {
+	fresh := newHNSWGraph(g.Dimension)
+	for _, node := range g.Nodes {
+		if !node.Deleted {
+			fresh.Insert(node.Key, node.Vector)
+		}
+	}
+	return fresh
+}
+
+// Search returns up to k keys most similar to the query that pass the accept filter
+func (g *hnswGraph) Search(query []float32, k int, efSearch int, accept func(key string) bool) []scoredKey {
+	if g.EntryPoint < 0 || k <= 0 || len(query) != g.Dimension {
+		return nil
+	}
+	q := normalize(query)
+
+	entry := uint32(g.EntryPoint)
+	for l := g.MaxLevel; l > 0; l-- {
+		entry = g.greedyClosest(q, entry, l)
+	}
+
+	ef := max(efSearch, k)
+	candidates := g.searchLayer(q, []uint32{entry}, ef, 0, accept)
+
+	results := make([]scoredKey, 0, k)
+	for _, c := range candidates {
+		if len(results) == k {
+			break
+		}
+		results = append(results, scoredKey{key: g.Nodes[c.id].Key, score: c.score})
+	}
+	return results
+}
+
+// Vector returns the normalized vector stored under key
+func (g *hnswGraph) Vector(key string) ([]float32, bool) {
+	id, ok := g.keyIndex[key]
+	if !ok {
+		return nil, false
+	}
+	return g.Nodes[id].Vector, true
+}
+
+// scoredKey is a search hit
+type scoredKey struct {
+	key   string
+	score float64
+}
+
+// candidate is a node with its similarity to the current query
+type candidate struct {
+	id    uint32
+	score float64
+}
+
+// searchLayer runs the best-first search on a single layer and returns candidates sorted by
+// descending similarity. When accept is set, rejected and deleted nodes are still traversed
+// but are not returned, so filtering does not break graph connectivity.
+func (g *hnswGraph) searchLayer(query []float32, entries []uint32, ef int, layer int,
+	accept func(key string) bool,
+) []candidate {
+	visited := make(map[uint32]struct{}, ef*4)
+	toVisit := &maxHeap{}
+	found := &minHeap{}
+
+	admit := func(id uint32) bool {
+		node := g.Nodes[id]
+		if node.Deleted {
+			return false
+		}
+		return accept == nil || accept(node.Key)
+	}
+
+	for _, e := range entries {
+		visited[e] = struct{}{}
+		c := candidate{id: e, score: dot(query, g.Nodes[e].Vector)}
+		heap.Push(toVisit, c)
+		if admit(e) {
+			heap.Push(found, c)
+		}
+	}
+
+	for toVisit.Len() > 0 {
+		current := heap.Pop(toVisit).(candidate)
+		if found.Len() >= ef && current.score < (*found)[0].score {
+			break
+		}
+		node := g.Nodes[current.id]
+		if layer >= len(node.Neighbors) {
+			continue
+		}
+		for _, n := range node.Neighbors[layer] {
+			if _, ok := visited[n]; ok {
+				continue
+			}
+			visited[n] = struct{}{}
+			c := candidate{id: n, score: dot(query, g.Nodes[n].Vector)}
+			if found.Len() < ef || c.score > (*found)[0].score {
+				heap.Push(toVisit, c)
+				if admit(n) {
+					heap.Push(found, c)
+					if found.Len() > ef {
+						heap.Pop(found)
+					}
+				}
+			}
+		}
+	}
+
+	result := make([]candidate, found.Len())
+	for i := len(result) - 1; i >= 0; i-- {
+		result[i] = heap.Pop(found).(candidate)
+	}
+	return result
+}
+
+// greedyClosest walks a single layer towards the node closest to the query
+func (g *hnswGraph) greedyClosest(query []float32, entry uint32, layer int) uint32 {
+	best := entry
+	bestScore := dot(query, g.Nodes[entry].Vector)
+	for changed := true; changed; {
+		changed = false
+		node := g.Nodes[best]
+		if layer >= len(node.Neighbors) {
+			break
+		}
+		for _, n := range node.Neighbors[layer] {
+			if score := dot(query, g.Nodes[n].Vector); score > bestScore {
+				best, bestScore, changed = n, score, true
+			}
+		}
+	}
+	return best
+}
+
+// selectNeighbors keeps the m most similar candidates
+func (g *hnswGraph) selectNeighbors(candidates []candidate, m int) []uint32 {
+	neighbors := make([]uint32, 0, m)
+	for _, c := range candidates {
+		if len(neighbors) == m {
+			break
+		}
+		neighbors = append(neighbors, c.id)
+	}
+	return neighbors
+}
+
+// link adds target to the neighbor list of node on the given layer, pruning if needed
+func (g *hnswGraph) link(node uint32, target uint32, layer int) {
+	n := g.Nodes[node]
+	if layer >= len(n.Neighbors) {
+		return
+	}
+	n.Neighbors[layer] = append(n.Neighbors[layer], target)
+	limit := g.maxNeighbors(layer)
+	if len(n.Neighbors[layer]) <= limit {
+		return
+	}
+
+	// Keep the closest neighbors
+	candidates := make([]candidate, len(n.Neighbors[layer]))
+	for i, id := range n.Neighbors[layer] {
+		candidates[i] = candidate{id: id, score: dot(n.Vector, g.Nodes[id].Vector)}
+	}
+	h := minHeap(candidates)
+	heap.Init(&h)
+	for h.Len() > limit {
+		heap.Pop(&h)
+	}
+	n.Neighbors[layer] = n.Neighbors[layer][:0]
+	for _, c := range h {
+		n.Neighbors[layer] = append(n.Neighbors[layer], c.id)
+	}
+}
+
+// maxNeighbors returns the neighbor limit for a layer; layer 0 is twice as dense
+func (g *hnswGraph) maxNeighbors(layer int) int {
+	if layer == 0 {
+		return g.M * 2
+	}
+	return g.M
+}
+
+// randomLevel draws a level from the exponentially decaying HNSW distribution
+func (g *hnswGraph) randomLevel() int {
+	ml := 1 / math.Log(float64(g.M))
+	return int(math.Floor(-math.Log(1-g.rng.Float64()) * ml))
+}
+
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	out := make([]float32, len(v))
+	if norm == 0 {
+		copy(out, v)
+		return out
+	}
+	inv := 1 / math.Sqrt(norm)
+	for i, x := range v {
+		out[i] = float32(float64(x) * inv)
+	}
+	return out
+}
+
+func dot(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// minHeap keeps the lowest similarity on top
+type minHeap []candidate
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].score < h[j].score }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// maxHeap keeps the highest similarity on top
+type maxHeap []candidate
+
+func (h maxHeap) Len() int           { return len(h) }
+func (h maxHeap) Less(i, j int) bool { return h[i].score > h[j].score }
+func (h maxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
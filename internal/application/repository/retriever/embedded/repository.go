@@ -0,0 +1,501 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+const (
+	fieldEmbedding = "embedding"
+	// defaultIndexDir is used when no directory is configured
+	defaultIndexDir = "./data/embedded_index"
+)
+
+// NewEmbeddedRetrieveEngineRepository opens (or creates) an embedded index in dir.
+// Vectors are kept in per-dimension HNSW graphs and content in a BM25 inverted index;
+// both are restored from disk on startup, so nothing needs to be re-embedded.
+func NewEmbeddedRetrieveEngineRepository(dir string) (interfaces.RetrieveEngineRepository, error) {
+	log := logger.GetLogger(context.Background())
+	if dir == "" {
+		log.Warnf("[Embedded] Index directory not set, using default %s", defaultIndexDir)
+		dir = defaultIndexDir
+	}
+	log.Infof("[Embedded] Initializing embedded retriever engine repository at %s", dir)
+
+	repo := &embeddedRepository{
+		dir:       dir,
+		documents: make(map[string]*document),
+		graphs:    make(map[int]*hnswGraph),
+		keywords:  newBM25Index(),
+	}
+	if err := repo.load(); err != nil {
+		return nil, err
+	}
+
+	log.Infof("[Embedded] Successfully loaded %d documents", len(repo.documents))
+	return repo, nil
+}
+
+func (e *embeddedRepository) EngineType() types.RetrieverEngineType {
+	return types.EmbeddedRetrieverEngineType
+}
+
+func (e *embeddedRepository) Support() []types.RetrieverType {
+	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
+}
+
+// EstimateStorageSize calculates the estimated storage size for a list of indices
+func (e *embeddedRepository) EstimateStorageSize(ctx context.Context,
+	indexInfoList []*types.IndexInfo, params map[string]any,
+) int64 {
+	var totalStorageSize int64
+	for _, indexInfo := range indexInfoList {
+		doc := toWALDocument(indexInfo, params)
+		totalStorageSize += calculateStorageSize(doc)
+	}
+	logger.GetLogger(ctx).Infof(
+		"[Embedded] Storage size for %d indices: %d bytes", len(indexInfoList), totalStorageSize,
+	)
+	return totalStorageSize
+}
+
+// Save stores a single index entry
+func (e *embeddedRepository) Save(ctx context.Context,
+	indexInfo *types.IndexInfo, additionalParams map[string]any,
+) error {
+	return e.BatchSave(ctx, []*types.IndexInfo{indexInfo}, additionalParams)
+}
+
+// BatchSave stores multiple index entries in one logged operation
+func (e *embeddedRepository) BatchSave(ctx context.Context,
+	indexInfoList []*types.IndexInfo, additionalParams map[string]any,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(indexInfoList) == 0 {
+		log.Warn("[Embedded] Empty list provided to BatchSave, skipping")
+		return nil
+	}
+
+	docs := make([]*walDocument, 0, len(indexInfoList))
+	for _, indexInfo := range indexInfoList {
+		docs = append(docs, toWALDocument(indexInfo, additionalParams))
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.commit(&walRecord{Op: opUpsert, Documents: docs}); err != nil {
+		log.Errorf("[Embedded] Failed to save indices: %v", err)
+		return err
+	}
+
+	log.Infof("[Embedded] Successfully batch saved %d indices", len(docs))
+	return nil
+}
+
+// deleteWhere removes every document matching the predicate in one logged operation
+func (e *embeddedRepository) deleteWhere(match func(doc *document) bool) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var ids []string
+	for id, doc := range e.documents {
+		if match(doc) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return e.commit(&walRecord{Op: opDelete, IDs: ids})
+}
+
+// DeleteByChunkIDList removes documents by chunk IDs across all dimensions
+func (e *embeddedRepository) DeleteByChunkIDList(ctx context.Context,
+	chunkIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkIDList) == 0 {
+		log.Warn("[Embedded] Empty chunk ID list provided for deletion, skipping")
+		return nil
+	}
+	chunkIDs := toSet(chunkIDList)
+	if err := e.deleteWhere(func(doc *document) bool { return chunkIDs[doc.ChunkID] }); err != nil {
+		log.Errorf("[Embedded] Failed to delete by chunk IDs: %v", err)
+		return fmt.Errorf("failed to delete by chunk IDs: %w", err)
+	}
+	log.Infof("[Embedded] Successfully deleted documents by chunk IDs, count: %d", len(chunkIDList))
+	return nil
+}
+
+// DeleteBySourceIDList removes documents by source IDs across all dimensions
+func (e *embeddedRepository) DeleteBySourceIDList(ctx context.Context,
+	sourceIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(sourceIDList) == 0 {
+		log.Warn("[Embedded] Empty source ID list provided for deletion, skipping")
+		return nil
+	}
+	sourceIDs := toSet(sourceIDList)
+	if err := e.deleteWhere(func(doc *document) bool { return sourceIDs[doc.SourceID] }); err != nil {
+		log.Errorf("[Embedded] Failed to delete by source IDs: %v", err)
+		return fmt.Errorf("failed to delete by source IDs: %w", err)
+	}
+	log.Infof("[Embedded] Successfully deleted documents by source IDs, count: %d", len(sourceIDList))
+	return nil
+}
+
+// DeleteByKnowledgeIDList removes documents by knowledge IDs across all dimensions
+func (e *embeddedRepository) DeleteByKnowledgeIDList(ctx context.Context,
+	knowledgeIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(knowledgeIDList) == 0 {
+		log.Warn("[Embedded] Empty knowledge ID list provided for deletion, skipping")
+		return nil
+	}
+	knowledgeIDs := toSet(knowledgeIDList)
+	if err := e.deleteWhere(func(doc *document) bool { return knowledgeIDs[doc.KnowledgeID] }); err != nil {
+		log.Errorf("[Embedded] Failed to delete by knowledge IDs: %v", err)
+		return fmt.Errorf("failed to delete by knowledge IDs: %w", err)
+	}
+	log.Infof("[Embedded] Successfully deleted documents by knowledge IDs, count: %d", len(knowledgeIDList))
+	return nil
+}
+
+// DeleteByKnowledgeBaseIDList removes documents of the given dimension by knowledge base IDs
+func (e *embeddedRepository) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(knowledgeBaseIDList) == 0 {
+		log.Warn("[Embedded] Empty knowledge base ID list provided for deletion, skipping")
+		return nil
+	}
+	knowledgeBaseIDs := toSet(knowledgeBaseIDList)
+	if err := e.deleteWhere(func(doc *document) bool {
+		return knowledgeBaseIDs[doc.KnowledgeBaseID] && doc.Dimension == dimension
+	}); err != nil {
+		log.Errorf("[Embedded] Failed to delete by knowledge base IDs: %v", err)
+		return fmt.Errorf("failed to delete by knowledge base IDs: %w", err)
+	}
+	log.Infof("[Embedded] Successfully deleted documents by knowledge base IDs, count: %d, dimension: %d",
+		len(knowledgeBaseIDList), dimension)
+	return nil
+}
+
+// BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
+func (e *embeddedRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkStatusMap) == 0 {
+		log.Warn("[Embedded] Empty chunk status map provided, skipping")
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.commit(&walRecord{Op: opStatus, Status: chunkStatusMap}); err != nil {
+		log.Errorf("[Embedded] Failed to update chunk enabled status: %v", err)
+		return err
+	}
+	log.Infof("[Embedded] Batch update chunk enabled status completed, count: %d", len(chunkStatusMap))
+	return nil
+}
+
+// BatchUpdateChunkAttributes replaces the filter attributes of chunks in batch
+func (e *embeddedRepository) BatchUpdateChunkAttributes(ctx context.Context,
+	chunkAttributes map[string]*types.IndexAttributes,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkAttributes) == 0 {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.commit(&walRecord{Op: opAttributes, Attributes: chunkAttributes}); err != nil {
+		log.Errorf("[Embedded] Failed to update chunk attributes: %v", err)
+		return err
+	}
+	log.Infof("[Embedded] Batch update chunk attributes completed, count: %d", len(chunkAttributes))
+	return nil
+}
+
+// Retrieve dispatches the retrieval operation to the appropriate method based on retriever type
+func (e *embeddedRepository) Retrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	log := logger.GetLogger(ctx)
+	log.Debugf("[Embedded] Processing retrieval request of type: %s", params.RetrieverType)
+
+	switch params.RetrieverType {
+	case types.VectorRetrieverType:
+		return e.VectorRetrieve(ctx, params)
+	case types.KeywordsRetrieverType:
+		return e.KeywordsRetrieve(ctx, params)
+	}
+
+	err := fmt.Errorf("invalid retriever type: %v", params.RetrieverType)
+	log.Errorf("[Embedded] %v", err)
+	return nil, err
+}
+
+// VectorRetrieve performs approximate nearest neighbor search on the HNSW graph
+func (e *embeddedRepository) VectorRetrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	log := logger.GetLogger(ctx)
+	dimension := len(params.Embedding)
+	log.Infof("[Embedded] Vector retrieval: dim=%d, topK=%d, threshold=%.4f",
+		dimension, params.TopK, params.Threshold)
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	graph, ok := e.graphs[dimension]
+	if !ok {
+		log.Warnf("[Embedded] No vectors with dimension %d, returning empty results", dimension)
+		return buildRetrieveResult(nil, types.VectorRetrieverType), nil
+	}
+
+	accept := e.matcher(params)
+	var results []*types.IndexWithScore
+	for _, hit := range graph.Search(params.Embedding, params.TopK, defaultEfSearch, accept) {
+		if hit.score < params.Threshold {
+			continue
+		}
+		results = append(results, fromDocument(e.documents[hit.key], hit.score, types.MatchTypeEmbedding))
+	}
+
+	if len(results) == 0 {
+		log.Warnf("[Embedded] No vector matches found that meet threshold %.4f", params.Threshold)
+	} else {
+		log.Infof("[Embedded] Vector retrieval found %d results", len(results))
+		log.Debugf("[Embedded] Top result score: %.4f", results[0].Score)
+	}
+	return buildRetrieveResult(results, types.VectorRetrieverType), nil
+}
+
+// KeywordsRetrieve performs BM25 ranked search over document content
+func (e *embeddedRepository) KeywordsRetrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	log := logger.GetLogger(ctx)
+	log.Infof("[Embedded] Performing keywords retrieval with query: %s, topK: %d", params.Query, params.TopK)
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	var results []*types.IndexWithScore
+	for _, hit := range e.keywords.Search(params.Query, params.TopK, e.matcher(params)) {
+		results = append(results, fromDocument(e.documents[hit.key], hit.score, types.MatchTypeKeywords))
+	}
+
+	if len(results) == 0 {
+		log.Warnf("[Embedded] No keyword matches found for query: %s", params.Query)
+	} else {
+		log.Infof("[Embedded] Keywords retrieval found %d results", len(results))
+	}
+	return buildRetrieveResult(results, types.KeywordsRetrieverType), nil
+}
+
+// CopyIndices copies index data from source knowledge base to target knowledge base
+func (e *embeddedRepository) CopyIndices(ctx context.Context,
+	sourceKnowledgeBaseID string,
+	sourceToTargetKBIDMap map[string]string,
+	sourceToTargetChunkIDMap map[string]string,
+	targetKnowledgeBaseID string,
+	dimension int,
+	knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	log.Infof(
+		"[Embedded] Copying indices from source knowledge base %s to target knowledge base %s, count: %d, dimension: %d",
+		sourceKnowledgeBaseID, targetKnowledgeBaseID, len(sourceToTargetChunkIDMap), dimension,
+	)
+
+	if len(sourceToTargetChunkIDMap) == 0 {
+		log.Warn("[Embedded] Empty mapping, skipping copy")
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	graph := e.graphs[dimension]
+	var targets []*walDocument
+	for _, source := range e.documents {
+		if source.KnowledgeBaseID != sourceKnowledgeBaseID || source.Dimension != dimension {
+			continue
+		}
+		targetChunkID, ok := sourceToTargetChunkIDMap[source.ChunkID]
+		if !ok {
+			log.Warnf("[Embedded] Source chunk %s not found in target mapping, skipping", source.ChunkID)
+			continue
+		}
+		targetKnowledgeID, ok := sourceToTargetKBIDMap[source.KnowledgeID]
+		if !ok {
+			log.Warnf("[Embedded] Source knowledge %s not found in target mapping, skipping", source.KnowledgeID)
+			continue
+		}
+
+		var vector []float32
+		if graph != nil {
+			vector, _ = graph.Vector(source.ID)
+		}
+		if dimension > 0 && vector == nil {
+			log.Warnf("[Embedded] No vectors found for source document with chunk %s, skipping", source.ChunkID)
+			continue
+		}
+
+		// Handle SourceID transformation for generated questions
+		// Generated questions have SourceID format: {chunkID}-{questionID}
+		// Regular chunks have SourceID == ChunkID
+		var targetSourceID string
+		if source.SourceID == source.ChunkID {
+			targetSourceID = targetChunkID
+		} else if strings.HasPrefix(source.SourceID, source.ChunkID+"-") {
+			questionID := strings.TrimPrefix(source.SourceID, source.ChunkID+"-")
+			targetSourceID = fmt.Sprintf("%s-%s", targetChunkID, questionID)
+		} else {
+			targetSourceID = uuid.New().String()
+		}
+
+		targets = append(targets, &walDocument{
+			document: document{
+				ID:              uuid.New().String(),
+				Content:         source.Content,
+				SourceID:        targetSourceID,
+				SourceType:      source.SourceType,
+				ChunkID:         targetChunkID,
+				KnowledgeID:     targetKnowledgeID,
+				KnowledgeBaseID: targetKnowledgeBaseID,
+				IsEnabled:       true,
+				Attributes:      source.Attributes,
+			},
+			Embedding: slices.Clone(vector),
+		})
+	}
+
+	if len(targets) == 0 {
+		log.Warn("[Embedded] No source documents to copy")
+		return nil
+	}
+	if err := e.commit(&walRecord{Op: opUpsert, Documents: targets}); err != nil {
+		log.Errorf("[Embedded] Failed to copy indices: %v", err)
+		return err
+	}
+
+	log.Infof("[Embedded] Index copy completed, total copied: %d", len(targets))
+	return nil
+}
+
+// matcher builds the filter applied to candidates during retrieval
+func (e *embeddedRepository) matcher(params types.RetrieveParams) func(id string) bool {
+	knowledgeBaseIDs := toSet(params.KnowledgeBaseIDs)
+	knowledgeIDs := toSet(params.KnowledgeIDs)
+	excludeKnowledgeIDs := toSet(params.ExcludeKnowledgeIDs)
+	excludeChunkIDs := toSet(params.ExcludeChunkIDs)
+
+	return func(id string) bool {
+		doc, ok := e.documents[id]
+		// Only retrieve enabled chunks
+		if !ok || !doc.IsEnabled {
+			return false
+		}
+		// KnowledgeBaseIDs and KnowledgeIDs use AND logic
+		if len(knowledgeBaseIDs) > 0 && !knowledgeBaseIDs[doc.KnowledgeBaseID] {
+			return false
+		}
+		if len(knowledgeIDs) > 0 && !knowledgeIDs[doc.KnowledgeID] {
+			return false
+		}
+		if !params.Filter.Matches(doc.Attributes) {
+			return false
+		}
+		return !excludeKnowledgeIDs[doc.KnowledgeID] && !excludeChunkIDs[doc.ChunkID]
+	}
+}
+
+func buildRetrieveResult(results []*types.IndexWithScore, retrieverType types.RetrieverType) []*types.RetrieveResult {
+	return []*types.RetrieveResult{
+		{
+			Results:             results,
+			RetrieverEngineType: types.EmbeddedRetrieverEngineType,
+			RetrieverType:       retrieverType,
+			Error:               nil,
+		},
+	}
+}
+
+// calculateStorageSize estimates the bytes used by the document, its vector, graph links and postings
+func calculateStorageSize(doc *walDocument) int64 {
+	size := int64(len(doc.Content) + len(doc.SourceID) + len(doc.ChunkID) +
+		len(doc.KnowledgeID) + len(doc.KnowledgeBaseID) + len(doc.ID))
+	size += 8 + 1 // source_type and is_enabled
+
+	if len(doc.Embedding) > 0 {
+		size += int64(len(doc.Embedding)) * 4
+		// Layer 0 keeps up to 2*M neighbor IDs of 4 bytes each
+		size += defaultM * 2 * 4
+	}
+
+	// Inverted index: roughly one posting entry per content byte is a safe upper bound
+	size += int64(len(doc.Content))
+	return size
+}
+
+// toWALDocument converts IndexInfo to the stored document format
+func toWALDocument(indexInfo *types.IndexInfo, additionalParams map[string]any) *walDocument {
+	doc := &walDocument{
+		document: document{
+			ID:              uuid.New().String(),
+			Content:         indexInfo.Content,
+			SourceID:        indexInfo.SourceID,
+			SourceType:      int(indexInfo.SourceType),
+			ChunkID:         indexInfo.ChunkID,
+			KnowledgeID:     indexInfo.KnowledgeID,
+			KnowledgeBaseID: indexInfo.KnowledgeBaseID,
+			IsEnabled:       true, // Default to enabled
+			Attributes:      indexInfo.Attributes,
+		},
+	}
+	if additionalParams != nil && slices.Contains(slices.Collect(maps.Keys(additionalParams)), fieldEmbedding) {
+		if embeddingMap, ok := additionalParams[fieldEmbedding].(map[string][]float32); ok {
+			doc.Embedding = embeddingMap[indexInfo.SourceID]
+		}
+	}
+	return doc
+}
+
+// fromDocument converts a stored document to IndexWithScore domain model
+func fromDocument(doc *document, score float64, matchType types.MatchType) *types.IndexWithScore {
+	return &types.IndexWithScore{
+		ID:              doc.ID,
+		SourceID:        doc.SourceID,
+		SourceType:      types.SourceType(doc.SourceType),
+		ChunkID:         doc.ChunkID,
+		KnowledgeID:     doc.KnowledgeID,
+		KnowledgeBaseID: doc.KnowledgeBaseID,
+		Content:         doc.Content,
+		Score:           score,
+		MatchType:       matchType,
+		IsEnabled:       doc.IsEnabled,
+	}
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
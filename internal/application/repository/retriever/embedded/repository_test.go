@@ -0,0 +1,316 @@
+package embedded
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func newTestIndexInfo(chunkID, knowledgeID, content string) *types.IndexInfo {
+	return &types.IndexInfo{
+		Content:         content,
+		SourceID:        chunkID,
+		ChunkID:         chunkID,
+		KnowledgeID:     knowledgeID,
+		KnowledgeBaseID: "kb-1",
+	}
+}
+
+func TestEmbeddedRepositorySurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	repo, err := NewEmbeddedRetrieveEngineRepository(dir)
+	if err != nil {
+		t.Fatalf("failed to open index: %v", err)
+	}
+
+	infos := []*types.IndexInfo{
+		newTestIndexInfo("c1", "k1", "golang vector search"),
+		newTestIndexInfo("c2", "k1", "postgres keyword search"),
+		newTestIndexInfo("c3", "k2", "qdrant payload filter"),
+	}
+	params := map[string]any{
+		fieldEmbedding: map[string][]float32{
+			"c1": {1, 0, 0},
+			"c2": {0, 1, 0},
+			"c3": {0, 0, 1},
+		},
+	}
+	if err := repo.BatchSave(ctx, infos, params); err != nil {
+		t.Fatalf("BatchSave failed: %v", err)
+	}
+	if err := repo.BatchUpdateChunkEnabledStatus(ctx, map[string]bool{"c3": false}); err != nil {
+		t.Fatalf("BatchUpdateChunkEnabledStatus failed: %v", err)
+	}
+
+	// Reopen without closing to exercise write-ahead log replay
+	reopened, err := NewEmbeddedRetrieveEngineRepository(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen index: %v", err)
+	}
+
+	vector, err := reopened.Retrieve(ctx, types.RetrieveParams{
+		Embedding:        []float32{0.9, 0.1, 0},
+		KnowledgeBaseIDs: []string{"kb-1"},
+		TopK:             2,
+		RetrieverType:    types.VectorRetrieverType,
+	})
+	if err != nil {
+		t.Fatalf("vector retrieve failed: %v", err)
+	}
+	hits := vector[0].Results
+	if len(hits) != 2 || hits[0].ChunkID != "c1" || hits[1].ChunkID != "c2" {
+		t.Fatalf("unexpected vector results: %+v", hits)
+	}
+
+	keywords, err := reopened.Retrieve(ctx, types.RetrieveParams{
+		Query:         "search filter",
+		TopK:          10,
+		RetrieverType: types.KeywordsRetrieverType,
+	})
+	if err != nil {
+		t.Fatalf("keywords retrieve failed: %v", err)
+	}
+	for _, hit := range keywords[0].Results {
+		if hit.ChunkID == "c3" {
+			t.Fatalf("disabled chunk c3 returned by keyword search")
+		}
+	}
+	if len(keywords[0].Results) != 2 {
+		t.Fatalf("expected 2 keyword results, got %d", len(keywords[0].Results))
+	}
+
+	if err := reopened.DeleteByKnowledgeIDList(ctx, []string{"k1"}, 3, ""); err != nil {
+		t.Fatalf("DeleteByKnowledgeIDList failed: %v", err)
+	}
+	if err := reopened.(*embeddedRepository).Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	// Reopen from the snapshot written on close
+	final, err := NewEmbeddedRetrieveEngineRepository(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen index from snapshot: %v", err)
+	}
+	vector, err = final.Retrieve(ctx, types.RetrieveParams{
+		Embedding:     []float32{1, 0, 0},
+		TopK:          5,
+		RetrieverType: types.VectorRetrieverType,
+	})
+	if err != nil {
+		t.Fatalf("vector retrieve after snapshot failed: %v", err)
+	}
+	if len(vector[0].Results) != 0 {
+		t.Fatalf("expected no enabled results after deleting k1, got %+v", vector[0].Results)
+	}
+}
+
+func TestEmbeddedRepositoryDropsTornWALTail(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// A crash during the first write leaves a torn record and nothing before it
+	if err := os.WriteFile(filepath.Join(dir, walFileName), []byte(`{"op":"upsert","documents":[{"id"`), 0o644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	repo, err := NewEmbeddedRetrieveEngineRepository(dir)
+	if err != nil {
+		t.Fatalf("failed to open index: %v", err)
+	}
+	params := map[string]any{fieldEmbedding: map[string][]float32{"c1": {1, 0, 0}}}
+	if err := repo.BatchSave(ctx, []*types.IndexInfo{newTestIndexInfo("c1", "k1", "golang")}, params); err != nil {
+		t.Fatalf("BatchSave failed: %v", err)
+	}
+
+	// Reopen without closing, the record appended after the torn one must replay
+	reopened, err := NewEmbeddedRetrieveEngineRepository(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen index: %v", err)
+	}
+	results, err := reopened.Retrieve(ctx, types.RetrieveParams{
+		Embedding:     []float32{1, 0, 0},
+		TopK:          1,
+		RetrieverType: types.VectorRetrieverType,
+	})
+	if err != nil {
+		t.Fatalf("vector retrieve failed: %v", err)
+	}
+	if hits := results[0].Results; len(hits) != 1 || hits[0].ChunkID != "c1" {
+		t.Fatalf("unexpected vector results: %+v", hits)
+	}
+}
+
+func TestEmbeddedRepositoryDeleteByKnowledgeBaseDimension(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewEmbeddedRetrieveEngineRepository(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open index: %v", err)
+	}
+
+	// The same chunk embedded by an old 3-dimensional and a new 2-dimensional model
+	info := []*types.IndexInfo{newTestIndexInfo("c1", "k1", "re-embedded chunk")}
+	if err := repo.BatchSave(ctx, info, map[string]any{
+		fieldEmbedding: map[string][]float32{"c1": {1, 0, 0}},
+	}); err != nil {
+		t.Fatalf("BatchSave old dimension failed: %v", err)
+	}
+	if err := repo.BatchSave(ctx, info, map[string]any{
+		fieldEmbedding: map[string][]float32{"c1": {0, 1}},
+	}); err != nil {
+		t.Fatalf("BatchSave new dimension failed: %v", err)
+	}
+
+	if err := repo.DeleteByKnowledgeBaseIDList(ctx, []string{"kb-1"}, 3, ""); err != nil {
+		t.Fatalf("DeleteByKnowledgeBaseIDList failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		embedding []float32
+		want      int
+	}{
+		{name: "old dimension removed", embedding: []float32{1, 0, 0}, want: 0},
+		{name: "new dimension kept", embedding: []float32{0, 1}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := repo.Retrieve(ctx, types.RetrieveParams{
+				Embedding:     tt.embedding,
+				TopK:          5,
+				RetrieverType: types.VectorRetrieverType,
+			})
+			if err != nil {
+				t.Fatalf("vector retrieve failed: %v", err)
+			}
+			if len(results[0].Results) != tt.want {
+				t.Fatalf("expected %d results, got %+v", tt.want, results[0].Results)
+			}
+		})
+	}
+}
+
+func TestHNSWGraphRecall(t *testing.T) {
+	graph := newHNSWGraph(8)
+	vectors := make(map[string][]float32)
+	for i := 0; i < 500; i++ {
+		v := make([]float32, 8)
+		for j := range v {
+			v[j] = graph.rng.Float32() - 0.5
+		}
+		key := fmt.Sprintf("doc-%d", i)
+		vectors[key] = v
+		graph.Insert(key, v)
+	}
+
+	found := 0
+	for key, v := range vectors {
+		hits := graph.Search(v, 1, defaultEfSearch, nil)
+		if len(hits) > 0 && hits[0].key == key {
+			found++
+		}
+	}
+	if recall := float64(found) / float64(len(vectors)); recall < 0.95 {
+		t.Fatalf("expected recall@1 >= 0.95, got %.3f", recall)
+	}
+
+	// Deleted vectors must not be returned
+	graph.Delete("doc-0")
+	for _, hit := range graph.Search(vectors["doc-0"], 5, defaultEfSearch, nil) {
+		if hit.key == "doc-0" {
+			t.Fatalf("deleted vector returned by search")
+		}
+	}
+}
+
+func TestEmbeddedRepositoryMetadataFilter(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo, err := NewEmbeddedRetrieveEngineRepository(dir)
+	if err != nil {
+		t.Fatalf("failed to open index: %v", err)
+	}
+
+	created := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	infos := []*types.IndexInfo{
+		newTestIndexInfo("c1", "k1", "quarterly sales report"),
+		newTestIndexInfo("c2", "k1", "annual sales report"),
+		newTestIndexInfo("c3", "k2", "sales team handbook"),
+	}
+	infos[0].Attributes = &types.IndexAttributes{
+		TagIDs: []string{"t1"}, FileType: "pdf", CreatedAt: created.UnixMilli(),
+		MetadataPairs: []string{types.MetadataPair("region", "eu")},
+	}
+	infos[1].Attributes = &types.IndexAttributes{
+		TagIDs: []string{"t2"}, FileType: "pdf", CreatedAt: created.UnixMilli(),
+		MetadataPairs: []string{types.MetadataPair("region", "us")},
+	}
+	infos[2].Attributes = &types.IndexAttributes{
+		FileType: "docx", CreatedAt: created.AddDate(0, 1, 0).UnixMilli(),
+	}
+	if err := repo.BatchSave(ctx, infos, map[string]any{
+		fieldEmbedding: map[string][]float32{
+			"c1": {1, 0, 0},
+			"c2": {0.9, 0.1, 0},
+			"c3": {0.8, 0.2, 0},
+		},
+	}); err != nil {
+		t.Fatalf("BatchSave failed: %v", err)
+	}
+	// c3 is tagged afterwards, and the change must survive a restart
+	if err := repo.BatchUpdateChunkAttributes(ctx, map[string]*types.IndexAttributes{
+		"c3": {TagIDs: []string{"t1"}, FileType: "docx", CreatedAt: created.AddDate(0, 1, 0).UnixMilli()},
+	}); err != nil {
+		t.Fatalf("BatchUpdateChunkAttributes failed: %v", err)
+	}
+	repo, err = NewEmbeddedRetrieveEngineRepository(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen index: %v", err)
+	}
+
+	after := created.AddDate(0, 0, 1)
+	tests := []struct {
+		name   string
+		filter *types.RetrieveFilter
+		want   []string
+	}{
+		{name: "no filter", want: []string{"c1", "c2", "c3"}},
+		{name: "empty filter", filter: &types.RetrieveFilter{}, want: []string{"c1", "c2", "c3"}},
+		{name: "file type", filter: &types.RetrieveFilter{FileTypes: []string{"pdf"}}, want: []string{"c1", "c2"}},
+		{name: "updated tag", filter: &types.RetrieveFilter{TagIDs: []string{"t1"}}, want: []string{"c1", "c3"}},
+		{name: "created after", filter: &types.RetrieveFilter{CreatedAfter: &after}, want: []string{"c3"}},
+		{name: "metadata", filter: &types.RetrieveFilter{Metadata: map[string]string{"region": "eu"}},
+			want: []string{"c1"}},
+		{name: "matching nothing", filter: &types.RetrieveFilter{FileTypes: []string{"pdf"}, CreatedAfter: &after}},
+	}
+	for _, tt := range tests {
+		for _, retrieverType := range []types.RetrieverType{types.VectorRetrieverType, types.KeywordsRetrieverType} {
+			t.Run(fmt.Sprintf("%s/%s", tt.name, retrieverType), func(t *testing.T) {
+				results, err := repo.Retrieve(ctx, types.RetrieveParams{
+					Query:         "sales report",
+					Embedding:     []float32{1, 0, 0},
+					TopK:          10,
+					RetrieverType: retrieverType,
+					Filter:        tt.filter,
+				})
+				if err != nil {
+					t.Fatalf("retrieve failed: %v", err)
+				}
+				var got []string
+				for _, hit := range results[0].Results {
+					got = append(got, hit.ChunkID)
+				}
+				slices.Sort(got)
+				if !slices.Equal(got, tt.want) {
+					t.Fatalf("expected chunks %v, got %v", tt.want, got)
+				}
+			})
+		}
+	}
+}
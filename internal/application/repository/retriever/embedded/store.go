@@ -0,0 +1,263 @@
+package embedded
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	snapshotFileName = "index.snapshot"
+	walFileName      = "index.wal"
+	// walCompactThreshold is the number of logged operations that triggers a snapshot
+	walCompactThreshold = 500
+
+	opUpsert = "upsert"
+	opDelete = "delete"
+	opStatus = "status"
+	// opAttributes replaces the filter attributes of chunks
+	opAttributes = "attributes"
+)
+
+// load restores the snapshot and replays the write-ahead log
+func (e *embeddedRepository) load() error {
+	if err := os.MkdirAll(e.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create index directory: %w", err)
+	}
+
+	if err := e.loadSnapshot(); err != nil {
+		return err
+	}
+
+	replayed, end, err := e.replayWAL()
+	if err != nil {
+		return err
+	}
+
+	wal, err := os.OpenFile(filepath.Join(e.dir, walFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open write-ahead log: %w", err)
+	}
+	e.wal = wal
+
+	// Drop a torn tail record before anything is appended after it, even when no record before it survived
+	if err := e.wal.Truncate(end); err != nil {
+		return fmt.Errorf("failed to truncate write-ahead log: %w", err)
+	}
+
+	// Fold the replayed log into a fresh snapshot
+	if replayed > 0 {
+		return e.compact()
+	}
+	return nil
+}
+
+// loadSnapshot decodes the last snapshot, if any, and rebuilds the keyword index from it
+func (e *embeddedRepository) loadSnapshot() error {
+	file, err := os.Open(filepath.Join(e.dir, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	var snap snapshot
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(&snap); err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	if snap.Documents != nil {
+		e.documents = snap.Documents
+	}
+	if snap.Graphs != nil {
+		e.graphs = snap.Graphs
+	}
+	for _, graph := range e.graphs {
+		graph.restore()
+	}
+	for id, doc := range e.documents {
+		e.keywords.Add(id, doc.Content)
+	}
+	return nil
+}
+
+// replayWAL applies every logged operation written after the snapshot. It returns the number of
+// records applied and the byte offset where the last intact record ends.
+func (e *embeddedRepository) replayWAL() (int, int64, error) {
+	file, err := os.Open(filepath.Join(e.dir, walFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open write-ahead log: %w", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	count := 0
+	var end int64
+	for {
+		var record walRecord
+		if err := decoder.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			// A torn write at the tail is expected after a crash; everything before it is intact
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				break
+			}
+			return count, end, fmt.Errorf("failed to decode write-ahead log record %d: %w", count, err)
+		}
+		e.apply(&record)
+		count++
+		end = decoder.InputOffset()
+		// Keep the newline written after the record, when it is already buffered
+		var next [1]byte
+		if n, _ := decoder.Buffered().Read(next[:]); n == 1 && next[0] == '\n' {
+			end++
+		}
+	}
+	return count, end, nil
+}
+
+// apply mutates the in-memory state according to a record
+func (e *embeddedRepository) apply(record *walRecord) {
+	switch record.Op {
+	case opUpsert:
+		for _, doc := range record.Documents {
+			e.removeDocument(doc.ID)
+			stored := doc.document
+			stored.Dimension = len(doc.Embedding)
+			e.documents[stored.ID] = &stored
+			e.keywords.Add(stored.ID, stored.Content)
+			if stored.Dimension > 0 {
+				graph, ok := e.graphs[stored.Dimension]
+				if !ok {
+					graph = newHNSWGraph(stored.Dimension)
+					e.graphs[stored.Dimension] = graph
+				}
+				graph.Insert(stored.ID, doc.Embedding)
+			}
+		}
+	case opDelete:
+		for _, id := range record.IDs {
+			e.removeDocument(id)
+		}
+	case opStatus:
+		for _, doc := range e.documents {
+			if enabled, ok := record.Status[doc.ChunkID]; ok {
+				doc.IsEnabled = enabled
+			}
+		}
+	case opAttributes:
+		for _, doc := range e.documents {
+			if attrs, ok := record.Attributes[doc.ChunkID]; ok {
+				doc.Attributes = attrs
+			}
+		}
+	}
+}
+
+// removeDocument drops a document from all indexes
+func (e *embeddedRepository) removeDocument(id string) {
+	doc, ok := e.documents[id]
+	if !ok {
+		return
+	}
+	if graph, ok := e.graphs[doc.Dimension]; ok {
+		graph.Delete(id)
+	}
+	e.keywords.Remove(id)
+	delete(e.documents, id)
+}
+
+// commit logs the record, applies it and compacts the log when it grows too long.
+// Callers must hold the write lock.
+func (e *embeddedRepository) commit(record *walRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode write-ahead log record: %w", err)
+	}
+	if _, err := e.wal.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append write-ahead log: %w", err)
+	}
+	if err := e.wal.Sync(); err != nil {
+		return fmt.Errorf("failed to sync write-ahead log: %w", err)
+	}
+
+	e.apply(record)
+	e.walRecords++
+
+	if e.walRecords >= walCompactThreshold {
+		return e.compact()
+	}
+	return nil
+}
+
+// compact writes a full snapshot and truncates the write-ahead log.
+// Callers must hold the write lock.
+func (e *embeddedRepository) compact() error {
+	for dimension, graph := range e.graphs {
+		if graph.Len() == 0 {
+			delete(e.graphs, dimension)
+			continue
+		}
+		if graph.NeedsCompaction() {
+			e.graphs[dimension] = graph.Compact()
+		}
+	}
+
+	tmpPath := filepath.Join(e.dir, snapshotFileName+".tmp")
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	if err := gob.NewEncoder(writer).Encode(&snapshot{Documents: e.documents, Graphs: e.graphs}); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+	// Rename is atomic, so a crash leaves either the old or the new snapshot in place
+	if err := os.Rename(tmpPath, filepath.Join(e.dir, snapshotFileName)); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	if err := e.wal.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate write-ahead log: %w", err)
+	}
+	e.walRecords = 0
+	return nil
+}
+
+// Close flushes a final snapshot and releases the write-ahead log
+func (e *embeddedRepository) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.wal == nil {
+		return nil
+	}
+	err := e.compact()
+	if closeErr := e.wal.Close(); err == nil {
+		err = closeErr
+	}
+	e.wal = nil
+	return err
+}
@@ -0,0 +1,58 @@
+package embedded
+
+import (
+	"os"
+	"sync"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+type embeddedRepository struct {
+	mu  sync.RWMutex
+	dir string
+	// documents maps document ID -> stored document
+	documents map[string]*document
+	// graphs holds one HNSW graph per vector dimension
+	graphs   map[int]*hnswGraph
+	keywords *bm25Index
+	// wal is the append-only log of operations since the last snapshot
+	wal        *os.File
+	walRecords int
+}
+
+// document is the stored form of an index entry; the vector lives in the HNSW graph
+type document struct {
+	ID              string `json:"id"`
+	Content         string `json:"content"`
+	SourceID        string `json:"source_id"`
+	SourceType      int    `json:"source_type"`
+	ChunkID         string `json:"chunk_id"`
+	KnowledgeID     string `json:"knowledge_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	IsEnabled       bool   `json:"is_enabled"`
+	Dimension       int    `json:"dimension"`
+	// Attributes evaluated by metadata filters
+	Attributes *types.IndexAttributes `json:"attributes,omitempty"`
+}
+
+// walDocument is a document together with its raw vector, as written to the log
+type walDocument struct {
+	document
+	Embedding []float32 `json:"embedding,omitempty"`
+}
+
+// walRecord is a single logged mutation
+type walRecord struct {
+	Op        string          `json:"op"`
+	Documents []*walDocument  `json:"documents,omitempty"`
+	IDs       []string        `json:"ids,omitempty"`
+	Status    map[string]bool `json:"status,omitempty"`
+	// Attributes maps chunk IDs to their new filter attributes
+	Attributes map[string]*types.IndexAttributes `json:"attributes,omitempty"`
+}
+
+// snapshot is the full on-disk state written during compaction
+type snapshot struct {
+	Documents map[string]*document
+	Graphs    map[int]*hnswGraph
+}
@@ -0,0 +1,1198 @@
+package milvus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/milvus-io/milvus/client/v2/column"
+	"github.com/milvus-io/milvus/client/v2/entity"
+	"github.com/milvus-io/milvus/client/v2/index"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+)
+
+const (
+	envMilvusCollection   = "MILVUS_COLLECTION"
+	defaultCollectionName = "weknora_embeddings"
+	fieldID               = "id"
+	fieldContent          = "content"
+	fieldSourceID         = "source_id"
+	fieldSourceType       = "source_type"
+	fieldChunkID          = "chunk_id"
+	fieldKnowledgeID      = "knowledge_id"
+	fieldKnowledgeBaseID  = "knowledge_base_id"
+	fieldEmbedding        = "embedding"
+	fieldIsEnabled        = "is_enabled"
+	// Filter attributes of the chunk and its knowledge
+	fieldTagIDs             = "tag_ids"
+	fieldFileType           = "file_type"
+	fieldKnowledgeCreatedAt = "knowledge_created_at"
+	fieldMetadataPairs      = "metadata_pairs"
+
+	// maxContentBytes is the VARCHAR limit Milvus enforces on the content field
+	maxContentBytes = 65535
+	// maxIDLength is the VARCHAR length used for ID-like fields
+	maxIDLength = 256
+	// batchSize is used when paging through collections
+	batchSize = 64
+	// maxTagIDs is the capacity of the tag array, a chunk has its own tag and the tag of its knowledge
+	maxTagIDs = 4
+	// maxMetadataPairs and maxMetadataPairLength bound the metadata array, longer pairs cannot be filtered on
+	maxMetadataPairs      = 64
+	maxMetadataPairLength = 1024
+)
+
+// scalarFields are the fields returned by queries that do not need the vector
+var scalarFields = []string{
+	fieldID, fieldContent, fieldSourceID, fieldSourceType,
+	fieldChunkID, fieldKnowledgeID, fieldKnowledgeBaseID, fieldIsEnabled,
+	fieldTagIDs, fieldFileType, fieldKnowledgeCreatedAt, fieldMetadataPairs,
+}
+
+// NewMilvusRetrieveEngineRepository creates and initializes a new Milvus repository
+func NewMilvusRetrieveEngineRepository(client *milvusclient.Client) interfaces.RetrieveEngineRepository {
+	log := logger.GetLogger(context.Background())
+	log.Info("[Milvus] Initializing Milvus retriever engine repository")
+
+	collectionBaseName := os.Getenv(envMilvusCollection)
+	if collectionBaseName == "" {
+		log.Warn("[Milvus] MILVUS_COLLECTION environment variable not set, using default collection name")
+		collectionBaseName = defaultCollectionName
+	}
+
+	res := &milvusRepository{
+		client:             client,
+		collectionBaseName: collectionBaseName,
+	}
+
+	log.Info("[Milvus] Successfully initialized repository")
+	return res
+}
+
+// getCollectionName returns the collection name for a specific dimension
+func (m *milvusRepository) getCollectionName(dimension int) string {
+	return fmt.Sprintf("%s_%d", m.collectionBaseName, dimension)
+}
+
+// isOwnCollection reports whether the collection was created by this repository
+func (m *milvusRepository) isOwnCollection(collectionName string) bool {
+	return strings.HasPrefix(collectionName, m.collectionBaseName+"_")
+}
+
+// listOwnCollections returns all collections that belong to this repository
+func (m *milvusRepository) listOwnCollections(ctx context.Context) ([]string, error) {
+	collections, err := m.client.ListCollections(ctx, milvusclient.NewListCollectionOption())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	result := make([]string, 0, len(collections))
+	for _, name := range collections {
+		if m.isOwnCollection(name) {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+// collectionExists checks whether the collection for the given dimension exists
+func (m *milvusRepository) collectionExists(ctx context.Context, dimension int) (bool, error) {
+	if _, ok := m.initializedCollections.Load(dimension); ok {
+		return true, nil
+	}
+	return m.client.HasCollection(ctx, milvusclient.NewHasCollectionOption(m.getCollectionName(dimension)))
+}
+
+// ensureCollection ensures the collection exists and is loaded for the given dimension
+func (m *milvusRepository) ensureCollection(ctx context.Context, dimension int) error {
+	collectionName := m.getCollectionName(dimension)
+
+	// Check cache first
+	if _, ok := m.initializedCollections.Load(dimension); ok {
+		return nil
+	}
+
+	log := logger.GetLogger(ctx)
+
+	exists, err := m.client.HasCollection(ctx, milvusclient.NewHasCollectionOption(collectionName))
+	if err != nil {
+		log.Errorf("[Milvus] Failed to check collection existence: %v", err)
+		return fmt.Errorf("failed to check collection existence: %w", err)
+	}
+
+	if !exists {
+		log.Infof("[Milvus] Creating collection %s with dimension %d", collectionName, dimension)
+
+		schema := entity.NewSchema().
+			WithName(collectionName).
+			WithAutoID(false).
+			WithField(entity.NewField().WithName(fieldID).WithDataType(entity.FieldTypeVarChar).
+				WithMaxLength(64).WithIsPrimaryKey(true)).
+			// Content uses the jieba tokenizer so that text match works for Chinese as well as English
+			WithField(entity.NewField().WithName(fieldContent).WithDataType(entity.FieldTypeVarChar).
+				WithMaxLength(maxContentBytes).
+				WithEnableAnalyzer(true).
+				WithAnalyzerParams(map[string]any{"tokenizer": "jieba", "filter": []string{"lowercase"}}).
+				WithEnableMatch(true)).
+			WithField(entity.NewField().WithName(fieldSourceID).WithDataType(entity.FieldTypeVarChar).
+				WithMaxLength(maxIDLength)).
+			WithField(entity.NewField().WithName(fieldSourceType).WithDataType(entity.FieldTypeInt64)).
+			WithField(entity.NewField().WithName(fieldChunkID).WithDataType(entity.FieldTypeVarChar).
+				WithMaxLength(maxIDLength)).
+			WithField(entity.NewField().WithName(fieldKnowledgeID).WithDataType(entity.FieldTypeVarChar).
+				WithMaxLength(maxIDLength)).
+			WithField(entity.NewField().WithName(fieldKnowledgeBaseID).WithDataType(entity.FieldTypeVarChar).
+				WithMaxLength(maxIDLength)).
+			WithField(entity.NewField().WithName(fieldIsEnabled).WithDataType(entity.FieldTypeBool)).
+			WithField(entity.NewField().WithName(fieldTagIDs).WithDataType(entity.FieldTypeArray).
+				WithElementType(entity.FieldTypeVarChar).WithMaxCapacity(maxTagIDs).WithMaxLength(maxIDLength)).
+			WithField(entity.NewField().WithName(fieldFileType).WithDataType(entity.FieldTypeVarChar).
+				WithMaxLength(maxIDLength)).
+			WithField(entity.NewField().WithName(fieldKnowledgeCreatedAt).WithDataType(entity.FieldTypeInt64)).
+			WithField(entity.NewField().WithName(fieldMetadataPairs).WithDataType(entity.FieldTypeArray).
+				WithElementType(entity.FieldTypeVarChar).WithMaxCapacity(maxMetadataPairs).
+				WithMaxLength(maxMetadataPairLength)).
+			WithField(entity.NewField().WithName(fieldEmbedding).WithDataType(entity.FieldTypeFloatVector).
+				WithDim(int64(dimension)))
+
+		// Vector index plus inverted indexes for the fields used in filters
+		indexOptions := []milvusclient.CreateIndexOption{
+			milvusclient.NewCreateIndexOption(collectionName, fieldEmbedding, index.NewAutoIndex(entity.COSINE)),
+		}
+		for _, field := range []string{
+			fieldChunkID, fieldKnowledgeID, fieldKnowledgeBaseID, fieldSourceID, fieldIsEnabled,
+			fieldTagIDs, fieldFileType, fieldKnowledgeCreatedAt, fieldMetadataPairs,
+		} {
+			indexOptions = append(indexOptions,
+				milvusclient.NewCreateIndexOption(collectionName, field, index.NewInvertedIndex()))
+		}
+
+		err = m.client.CreateCollection(ctx,
+			milvusclient.NewCreateCollectionOption(collectionName, schema).WithIndexOptions(indexOptions...),
+		)
+		if err != nil {
+			log.Errorf("[Milvus] Failed to create collection: %v", err)
+			return fmt.Errorf("failed to create collection: %w", err)
+		}
+		log.Infof("[Milvus] Successfully created collection %s", collectionName)
+	}
+
+	// Collections must be loaded into memory before they can be searched or queried
+	loadTask, err := m.client.LoadCollection(ctx, milvusclient.NewLoadCollectionOption(collectionName))
+	if err != nil {
+		log.Errorf("[Milvus] Failed to load collection %s: %v", collectionName, err)
+		return fmt.Errorf("failed to load collection: %w", err)
+	}
+	if err := loadTask.Await(ctx); err != nil {
+		log.Errorf("[Milvus] Failed to wait for collection %s to load: %v", collectionName, err)
+		return fmt.Errorf("failed to load collection: %w", err)
+	}
+
+	// Mark as initialized
+	m.initializedCollections.Store(dimension, true)
+	return nil
+}
+
+func (m *milvusRepository) EngineType() types.RetrieverEngineType {
+	return types.MilvusRetrieverEngineType
+}
+
+func (m *milvusRepository) Support() []types.RetrieverType {
+	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
+}
+
+// EstimateStorageSize calculates the estimated storage size for a list of indices
+func (m *milvusRepository) EstimateStorageSize(ctx context.Context,
+	indexInfoList []*types.IndexInfo, params map[string]any,
+) int64 {
+	var totalStorageSize int64
+	for _, embedding := range indexInfoList {
+		embeddingDB := toMilvusVectorEmbedding(embedding, params)
+		totalStorageSize += m.calculateStorageSize(embeddingDB)
+	}
+	logger.GetLogger(ctx).Infof(
+		"[Milvus] Storage size for %d indices: %d bytes", len(indexInfoList), totalStorageSize,
+	)
+	return totalStorageSize
+}
+
+// Save stores a single entity in Milvus
+func (m *milvusRepository) Save(ctx context.Context,
+	embedding *types.IndexInfo,
+	additionalParams map[string]any,
+) error {
+	log := logger.GetLogger(ctx)
+	log.Debugf("[Milvus] Saving index for chunk ID: %s", embedding.ChunkID)
+
+	embeddingDB := toMilvusVectorEmbedding(embedding, additionalParams)
+	if len(embeddingDB.Embedding) == 0 {
+		err := fmt.Errorf("empty embedding vector for chunk ID: %s", embedding.ChunkID)
+		log.Errorf("[Milvus] %v", err)
+		return err
+	}
+
+	dimension := len(embeddingDB.Embedding)
+	if err := m.ensureCollection(ctx, dimension); err != nil {
+		return err
+	}
+
+	pointID := uuid.New().String()
+	if err := m.upsert(ctx, dimension, []string{pointID}, []*MilvusVectorEmbedding{embeddingDB}); err != nil {
+		log.Errorf("[Milvus] Failed to save index: %v", err)
+		return err
+	}
+
+	log.Infof("[Milvus] Successfully saved index for chunk ID: %s, point ID: %s", embedding.ChunkID, pointID)
+	return nil
+}
+
+// BatchSave stores multiple entities in Milvus using batch upsert
+func (m *milvusRepository) BatchSave(ctx context.Context,
+	embeddingList []*types.IndexInfo, additionalParams map[string]any,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(embeddingList) == 0 {
+		log.Warn("[Milvus] Empty list provided to BatchSave, skipping")
+		return nil
+	}
+
+	log.Infof("[Milvus] Batch saving %d indices", len(embeddingList))
+
+	// Group entities by dimension
+	rowsByDimension := make(map[int][]*MilvusVectorEmbedding)
+	for _, embedding := range embeddingList {
+		embeddingDB := toMilvusVectorEmbedding(embedding, additionalParams)
+		if len(embeddingDB.Embedding) == 0 {
+			log.Warnf("[Milvus] Skipping empty embedding for chunk ID: %s", embedding.ChunkID)
+			continue
+		}
+		dimension := len(embeddingDB.Embedding)
+		rowsByDimension[dimension] = append(rowsByDimension[dimension], embeddingDB)
+		log.Debugf("[Milvus] Added chunk ID %s to batch request (dimension: %d)", embedding.ChunkID, dimension)
+	}
+
+	if len(rowsByDimension) == 0 {
+		log.Warn("[Milvus] No valid entities to save after filtering")
+		return nil
+	}
+
+	// Save entities to each dimension-specific collection
+	totalSaved := 0
+	for dimension, rows := range rowsByDimension {
+		if err := m.ensureCollection(ctx, dimension); err != nil {
+			return err
+		}
+
+		ids := make([]string, len(rows))
+		for i := range rows {
+			ids[i] = uuid.New().String()
+		}
+		if err := m.upsert(ctx, dimension, ids, rows); err != nil {
+			log.Errorf("[Milvus] Failed to execute batch operation for dimension %d: %v", dimension, err)
+			return fmt.Errorf("failed to batch save (dimension %d): %w", dimension, err)
+		}
+		totalSaved += len(rows)
+		log.Infof("[Milvus] Saved %d entities to collection %s", len(rows), m.getCollectionName(dimension))
+	}
+
+	log.Infof("[Milvus] Successfully batch saved %d indices", totalSaved)
+	return nil
+}
+
+// deleteByExpr removes entities matching the expression from the collection of the given dimension
+func (m *milvusRepository) deleteByExpr(ctx context.Context, dimension int, expr string) error {
+	exists, err := m.collectionExists(ctx, dimension)
+	if err != nil {
+		return fmt.Errorf("failed to check collection existence: %w", err)
+	}
+	if !exists {
+		logger.GetLogger(ctx).Warnf("[Milvus] Collection %s does not exist, nothing to delete",
+			m.getCollectionName(dimension))
+		return nil
+	}
+	// Deletes by expression query the collection first, so it has to be loaded
+	if err := m.ensureCollection(ctx, dimension); err != nil {
+		return err
+	}
+	_, err = m.client.Delete(ctx, milvusclient.NewDeleteOption(m.getCollectionName(dimension)).WithExpr(expr))
+	return err
+}
+
+// DeleteByChunkIDList removes entities from the collection based on chunk IDs
+func (m *milvusRepository) DeleteByChunkIDList(ctx context.Context,
+	chunkIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkIDList) == 0 {
+		log.Warn("[Milvus] Empty chunk ID list provided for deletion, skipping")
+		return nil
+	}
+
+	log.Infof("[Milvus] Deleting indices by chunk IDs from %s, count: %d",
+		m.getCollectionName(dimension), len(chunkIDList))
+
+	if err := m.deleteByExpr(ctx, dimension, inExpr(fieldChunkID, chunkIDList)); err != nil {
+		log.Errorf("[Milvus] Failed to delete by chunk IDs: %v", err)
+		return fmt.Errorf("failed to delete by chunk IDs: %w", err)
+	}
+
+	log.Infof("[Milvus] Successfully deleted documents by chunk IDs")
+	return nil
+}
+
+// DeleteByKnowledgeIDList removes entities from the collection based on knowledge IDs
+func (m *milvusRepository) DeleteByKnowledgeIDList(ctx context.Context,
+	knowledgeIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(knowledgeIDList) == 0 {
+		log.Warn("[Milvus] Empty knowledge ID list provided for deletion, skipping")
+		return nil
+	}
+
+	log.Infof("[Milvus] Deleting indices by knowledge IDs from %s, count: %d",
+		m.getCollectionName(dimension), len(knowledgeIDList))
+
+	if err := m.deleteByExpr(ctx, dimension, inExpr(fieldKnowledgeID, knowledgeIDList)); err != nil {
+		log.Errorf("[Milvus] Failed to delete by knowledge IDs: %v", err)
+		return fmt.Errorf("failed to delete by knowledge IDs: %w", err)
+	}
+
+	log.Infof("[Milvus] Successfully deleted documents by knowledge IDs")
+	return nil
+}
+
+// DeleteByKnowledgeBaseIDList removes entities from the collection of the given dimension
+// based on knowledge base IDs
+func (m *milvusRepository) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(knowledgeBaseIDList) == 0 {
+		log.Warn("[Milvus] Empty knowledge base ID list provided for deletion, skipping")
+		return nil
+	}
+
+	log.Infof("[Milvus] Deleting indices by knowledge base IDs from %s, count: %d",
+		m.getCollectionName(dimension), len(knowledgeBaseIDList))
+
+	if err := m.deleteByExpr(ctx, dimension, inExpr(fieldKnowledgeBaseID, knowledgeBaseIDList)); err != nil {
+		log.Errorf("[Milvus] Failed to delete by knowledge base IDs: %v", err)
+		return fmt.Errorf("failed to delete by knowledge base IDs: %w", err)
+	}
+
+	log.Infof("[Milvus] Successfully deleted documents by knowledge base IDs")
+	return nil
+}
+
+// DeleteBySourceIDList removes entities from the collection based on source IDs
+func (m *milvusRepository) DeleteBySourceIDList(ctx context.Context,
+	sourceIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(sourceIDList) == 0 {
+		log.Warn("[Milvus] Empty source ID list provided for deletion, skipping")
+		return nil
+	}
+
+	log.Infof("[Milvus] Deleting indices by source IDs from %s, count: %d",
+		m.getCollectionName(dimension), len(sourceIDList))
+
+	if err := m.deleteByExpr(ctx, dimension, inExpr(fieldSourceID, sourceIDList)); err != nil {
+		log.Errorf("[Milvus] Failed to delete by source IDs: %v", err)
+		return fmt.Errorf("failed to delete by source IDs: %w", err)
+	}
+
+	log.Infof("[Milvus] Successfully deleted documents by source IDs")
+	return nil
+}
+
+// BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
+// Milvus has no partial update for scalar fields, so matching entities are read back
+// with their vectors and upserted with the new status.
+// This method operates on all collections since dimension is not provided
+func (m *milvusRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkStatusMap) == 0 {
+		log.Warn("[Milvus] Empty chunk status map provided, skipping")
+		return nil
+	}
+
+	log.Infof("[Milvus] Batch updating chunk enabled status, count: %d", len(chunkStatusMap))
+
+	collections, err := m.listOwnCollections(ctx)
+	if err != nil {
+		log.Errorf("[Milvus] %v", err)
+		return err
+	}
+
+	chunkIDs := slices.Collect(maps.Keys(chunkStatusMap))
+	filter := inExpr(fieldChunkID, chunkIDs)
+
+	// Every collection is updated even if one fails, the failures are returned together
+	var errs []error
+	for _, collectionName := range collections {
+		dimension, ok := m.dimensionOf(collectionName)
+		if !ok {
+			continue
+		}
+		if err := m.ensureCollection(ctx, dimension); err != nil {
+			log.Errorf("[Milvus] Failed to prepare collection %s: %v", collectionName, err)
+			errs = append(errs, fmt.Errorf("%s: %w", collectionName, err))
+			continue
+		}
+
+		updated := 0
+		err := m.iterate(ctx, collectionName, filter, true, func(ids []string, rows []*MilvusVectorEmbedding) error {
+			for _, row := range rows {
+				row.IsEnabled = chunkStatusMap[row.ChunkID]
+			}
+			if err := m.upsert(ctx, dimension, ids, rows); err != nil {
+				return err
+			}
+			updated += len(rows)
+			return nil
+		})
+		if err != nil {
+			log.Errorf("[Milvus] Failed to update chunk status in %s: %v", collectionName, err)
+			errs = append(errs, fmt.Errorf("%s: %w", collectionName, err))
+			continue
+		}
+		log.Debugf("[Milvus] Updated %d entities in %s", updated, collectionName)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to update chunk enabled status: %w", err)
+	}
+
+	log.Infof("[Milvus] Batch update chunk enabled status completed")
+	return nil
+}
+
+// BatchUpdateChunkAttributes replaces the filter attributes of chunks in batch.
+// Like the enabled status, entities are read with their vectors and upserted with the new attributes.
+// This method operates on all collections since dimension is not provided
+func (m *milvusRepository) BatchUpdateChunkAttributes(ctx context.Context,
+	chunkAttributes map[string]*types.IndexAttributes,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkAttributes) == 0 {
+		return nil
+	}
+
+	log.Infof("[Milvus] Batch updating chunk attributes, count: %d", len(chunkAttributes))
+
+	collections, err := m.listOwnCollections(ctx)
+	if err != nil {
+		log.Errorf("[Milvus] %v", err)
+		return err
+	}
+
+	filter := inExpr(fieldChunkID, slices.Collect(maps.Keys(chunkAttributes)))
+
+	// Every collection is updated even if one fails, the failures are returned together
+	var errs []error
+	for _, collectionName := range collections {
+		dimension, ok := m.dimensionOf(collectionName)
+		if !ok {
+			continue
+		}
+		if err := m.ensureCollection(ctx, dimension); err != nil {
+			log.Errorf("[Milvus] Failed to prepare collection %s: %v", collectionName, err)
+			errs = append(errs, fmt.Errorf("%s: %w", collectionName, err))
+			continue
+		}
+
+		err := m.iterate(ctx, collectionName, filter, true, func(ids []string, rows []*MilvusVectorEmbedding) error {
+			for _, row := range rows {
+				setAttributes(row, chunkAttributes[row.ChunkID])
+			}
+			return m.upsert(ctx, dimension, ids, rows)
+		})
+		if err != nil {
+			log.Errorf("[Milvus] Failed to update chunk attributes in %s: %v", collectionName, err)
+			errs = append(errs, fmt.Errorf("%s: %w", collectionName, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to update chunk attributes: %w", err)
+	}
+
+	log.Infof("[Milvus] Batch update chunk attributes completed")
+	return nil
+}
+
+// getBaseFilter builds the boolean expression shared by vector and keyword retrieval
+func (m *milvusRepository) getBaseFilter(params types.RetrieveParams) string {
+	// Only retrieve enabled chunks
+	conditions := []string{fmt.Sprintf("%s == true", fieldIsEnabled)}
+
+	// KnowledgeBaseIDs and KnowledgeIDs use AND logic
+	// - If only KnowledgeBaseIDs: search entire knowledge bases
+	// - If only KnowledgeIDs: search specific documents
+	// - If both: search specific documents within the knowledge bases (AND)
+	if len(params.KnowledgeBaseIDs) > 0 {
+		conditions = append(conditions, inExpr(fieldKnowledgeBaseID, params.KnowledgeBaseIDs))
+	}
+	if len(params.KnowledgeIDs) > 0 {
+		conditions = append(conditions, inExpr(fieldKnowledgeID, params.KnowledgeIDs))
+	}
+	if !params.Filter.IsEmpty() {
+		conditions = append(conditions, filterExprs(params.Filter)...)
+	}
+
+	if len(params.ExcludeKnowledgeIDs) > 0 {
+		conditions = append(conditions, "not ("+inExpr(fieldKnowledgeID, params.ExcludeKnowledgeIDs)+")")
+	}
+
+	if len(params.ExcludeChunkIDs) > 0 {
+		conditions = append(conditions, "not ("+inExpr(fieldChunkID, params.ExcludeChunkIDs)+")")
+	}
+
+	return strings.Join(conditions, " and ")
+}
+
+// Retrieve dispatches the retrieval operation to the appropriate method based on retriever type
+func (m *milvusRepository) Retrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	log := logger.GetLogger(ctx)
+	log.Debugf("[Milvus] Processing retrieval request of type: %s", params.RetrieverType)
+
+	switch params.RetrieverType {
+	case types.VectorRetrieverType:
+		return m.VectorRetrieve(ctx, params)
+	case types.KeywordsRetrieverType:
+		return m.KeywordsRetrieve(ctx, params)
+	}
+
+	err := fmt.Errorf("invalid retriever type: %v", params.RetrieverType)
+	log.Errorf("[Milvus] %v", err)
+	return nil, err
+}
+
+// VectorRetrieve performs vector similarity search
+func (m *milvusRepository) VectorRetrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	log := logger.GetLogger(ctx)
+	dimension := len(params.Embedding)
+	log.Infof("[Milvus] Vector retrieval: dim=%d, topK=%d, threshold=%.4f",
+		dimension, params.TopK, params.Threshold)
+
+	// Get collection name based on embedding dimension
+	collectionName := m.getCollectionName(dimension)
+
+	exists, err := m.collectionExists(ctx, dimension)
+	if err != nil {
+		log.Errorf("[Milvus] Failed to check collection existence: %v", err)
+		return nil, fmt.Errorf("failed to check collection: %w", err)
+	}
+	if !exists {
+		log.Warnf("[Milvus] Collection %s does not exist, returning empty results", collectionName)
+		return buildRetrieveResult(nil, types.VectorRetrieverType), nil
+	}
+	if err := m.ensureCollection(ctx, dimension); err != nil {
+		return nil, err
+	}
+
+	searchResult, err := m.client.Search(ctx,
+		milvusclient.NewSearchOption(collectionName, params.TopK, []entity.Vector{entity.FloatVector(params.Embedding)}).
+			WithANNSField(fieldEmbedding).
+			WithFilter(m.getBaseFilter(params)).
+			WithOutputFields(scalarFields...),
+	)
+	if err != nil {
+		log.Errorf("[Milvus] Vector search failed: %v", err)
+		return nil, fmt.Errorf("%s: %w", collectionName, err)
+	}
+
+	var results []*types.IndexWithScore
+	for _, resultSet := range searchResult {
+		ids, rows, err := readResultSet(resultSet, false)
+		if err != nil {
+			log.Errorf("[Milvus] Failed to parse search result: %v", err)
+			return nil, err
+		}
+		for i, row := range rows {
+			score := float64(resultSet.Scores[i])
+			// COSINE scores are similarities, so the threshold is applied client side
+			if score < params.Threshold {
+				continue
+			}
+			embedding := &MilvusVectorEmbeddingWithScore{MilvusVectorEmbedding: *row, Score: score}
+			results = append(results, fromMilvusVectorEmbedding(ids[i], embedding, types.MatchTypeEmbedding))
+		}
+	}
+
+	if len(results) == 0 {
+		log.Warnf("[Milvus] No vector matches found that meet threshold %.4f", params.Threshold)
+	} else {
+		log.Infof("[Milvus] Vector retrieval found %d results", len(results))
+		log.Debugf("[Milvus] Top result score: %.4f", results[0].Score)
+	}
+
+	return buildRetrieveResult(results, types.VectorRetrieverType), nil
+}
+
+// KeywordsRetrieve performs keyword-based search in document content
+// This searches across all collections since keyword search doesn't depend on dimension
+func (m *milvusRepository) KeywordsRetrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	log := logger.GetLogger(ctx)
+	log.Infof("[Milvus] Performing keywords retrieval with query: %s, topK: %d", params.Query, params.TopK)
+
+	collections, err := m.listOwnCollections(ctx)
+	if err != nil {
+		log.Errorf("[Milvus] %v", err)
+		return nil, err
+	}
+
+	// TEXT_MATCH treats space separated terms as OR, which mirrors the Qdrant should conditions
+	queryTokens := tokenizeQuery(params.Query)
+	log.Debugf("[Milvus] Tokenized query into %d tokens: %v", len(queryTokens), queryTokens)
+	matchTerms := params.Query
+	if len(queryTokens) > 0 {
+		matchTerms = strings.Join(queryTokens, " ")
+	}
+	filter := fmt.Sprintf("%s and TEXT_MATCH(%s, '%s')",
+		m.getBaseFilter(params), fieldContent, escapeSingleQuoted(matchTerms))
+
+	var (
+		allResults []*types.IndexWithScore
+		errs       []error
+	)
+	for _, collectionName := range collections {
+		dimension, ok := m.dimensionOf(collectionName)
+		if !ok {
+			continue
+		}
+		if err := m.ensureCollection(ctx, dimension); err != nil {
+			log.Errorf("[Milvus] Failed to prepare collection %s: %v", collectionName, err)
+			errs = append(errs, fmt.Errorf("%s: %w", collectionName, err))
+			continue
+		}
+
+		queryResult, err := m.client.Query(ctx, milvusclient.NewQueryOption(collectionName).
+			WithFilter(filter).
+			WithOutputFields(scalarFields...).
+			WithLimit(params.TopK),
+		)
+		if err != nil {
+			log.Errorf("[Milvus] Keywords search failed in %s: %v", collectionName, err)
+			errs = append(errs, fmt.Errorf("%s: %w", collectionName, err))
+			continue
+		}
+
+		ids, rows, err := readResultSet(queryResult, false)
+		if err != nil {
+			log.Errorf("[Milvus] Failed to parse keywords result in %s: %v", collectionName, err)
+			errs = append(errs, fmt.Errorf("%s: %w", collectionName, err))
+			continue
+		}
+		log.Debugf("[Milvus] Found %d results in collection %s", len(rows), collectionName)
+
+		for i, row := range rows {
+			embedding := &MilvusVectorEmbeddingWithScore{MilvusVectorEmbedding: *row, Score: 1.0}
+			allResults = append(allResults, fromMilvusVectorEmbedding(ids[i], embedding, types.MatchTypeKeywords))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("keywords search failed: %w", err)
+	}
+
+	// Limit results to topK
+	if len(allResults) > params.TopK {
+		allResults = allResults[:params.TopK]
+	}
+
+	if len(allResults) == 0 {
+		log.Warnf("[Milvus] No keyword matches found for query: %s", params.Query)
+	} else {
+		log.Infof("[Milvus] Keywords retrieval found %d results", len(allResults))
+	}
+
+	return buildRetrieveResult(allResults, types.KeywordsRetrieverType), nil
+}
+
+// CopyIndices copies index data from source knowledge base to target knowledge base
+func (m *milvusRepository) CopyIndices(ctx context.Context,
+	sourceKnowledgeBaseID string,
+	sourceToTargetKBIDMap map[string]string,
+	sourceToTargetChunkIDMap map[string]string,
+	targetKnowledgeBaseID string,
+	dimension int,
+	knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	log.Infof(
+		"[Milvus] Copying indices from source knowledge base %s to target knowledge base %s, count: %d, dimension: %d",
+		sourceKnowledgeBaseID, targetKnowledgeBaseID, len(sourceToTargetChunkIDMap), dimension,
+	)
+
+	if len(sourceToTargetChunkIDMap) == 0 {
+		log.Warn("[Milvus] Empty mapping, skipping copy")
+		return nil
+	}
+
+	// Ensure target collection exists
+	if err := m.ensureCollection(ctx, dimension); err != nil {
+		return err
+	}
+
+	collectionName := m.getCollectionName(dimension)
+	totalCopied := 0
+
+	err := m.iterate(ctx, collectionName, eqExpr(fieldKnowledgeBaseID, sourceKnowledgeBaseID), true,
+		func(_ []string, sourceRows []*MilvusVectorEmbedding) error {
+			log.Infof("[Milvus] Found %d source entities in batch", len(sourceRows))
+
+			targetIDs := make([]string, 0, len(sourceRows))
+			targetRows := make([]*MilvusVectorEmbedding, 0, len(sourceRows))
+			for _, source := range sourceRows {
+				targetChunkID, ok := sourceToTargetChunkIDMap[source.ChunkID]
+				if !ok {
+					log.Warnf("[Milvus] Source chunk %s not found in target mapping, skipping", source.ChunkID)
+					continue
+				}
+
+				targetKnowledgeID, ok := sourceToTargetKBIDMap[source.KnowledgeID]
+				if !ok {
+					log.Warnf("[Milvus] Source knowledge %s not found in target mapping, skipping", source.KnowledgeID)
+					continue
+				}
+
+				if len(source.Embedding) == 0 {
+					log.Warnf("[Milvus] No vectors found for source entity with chunk %s, skipping", source.ChunkID)
+					continue
+				}
+
+				// Handle SourceID transformation for generated questions
+				// Generated questions have SourceID format: {chunkID}-{questionID}
+				// Regular chunks have SourceID == ChunkID
+				var targetSourceID string
+				if source.SourceID == source.ChunkID {
+					targetSourceID = targetChunkID
+				} else if strings.HasPrefix(source.SourceID, source.ChunkID+"-") {
+					questionID := strings.TrimPrefix(source.SourceID, source.ChunkID+"-")
+					targetSourceID = fmt.Sprintf("%s-%s", targetChunkID, questionID)
+				} else {
+					targetSourceID = uuid.New().String()
+				}
+
+				targetIDs = append(targetIDs, uuid.New().String())
+				targetRows = append(targetRows, &MilvusVectorEmbedding{
+					Content:         source.Content,
+					SourceID:        targetSourceID,
+					SourceType:      source.SourceType,
+					ChunkID:         targetChunkID,
+					KnowledgeID:     targetKnowledgeID,
+					KnowledgeBaseID: targetKnowledgeBaseID,
+					Embedding:       source.Embedding,
+					IsEnabled:       true,
+					// The caller refreshes the attributes for the target knowledge afterwards
+					TagIDs:             source.TagIDs,
+					FileType:           source.FileType,
+					KnowledgeCreatedAt: source.KnowledgeCreatedAt,
+					MetadataPairs:      source.MetadataPairs,
+				})
+			}
+
+			if len(targetRows) == 0 {
+				return nil
+			}
+			if err := m.upsert(ctx, dimension, targetIDs, targetRows); err != nil {
+				log.Errorf("[Milvus] Failed to batch upsert target entities: %v", err)
+				return err
+			}
+			totalCopied += len(targetRows)
+			log.Infof("[Milvus] Successfully copied batch, batch size: %d, total copied: %d",
+				len(targetRows), totalCopied)
+			return nil
+		})
+	if err != nil {
+		log.Errorf("[Milvus] Failed to copy indices: %v", err)
+		return err
+	}
+
+	log.Infof("[Milvus] Index copy completed, total copied: %d", totalCopied)
+	return nil
+}
+
+// iterate pages through all entities in a collection that match the filter,
+// using the primary key of the last entity as the cursor of the next page
+func (m *milvusRepository) iterate(ctx context.Context,
+	collectionName string,
+	filter string,
+	withVector bool,
+	fn func(ids []string, rows []*MilvusVectorEmbedding) error,
+) error {
+	outputFields := scalarFields
+	if withVector {
+		outputFields = append(slices.Clone(scalarFields), fieldEmbedding)
+	}
+
+	cursor := ""
+	for {
+		resultSet, err := m.client.Query(ctx, milvusclient.NewQueryOption(collectionName).
+			WithFilter(pageExpr(filter, cursor)).
+			WithOutputFields(outputFields...).
+			WithLimit(batchSize),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to query entities: %w", err)
+		}
+		if resultSet.ResultCount == 0 {
+			return nil
+		}
+
+		ids, rows, err := readResultSet(resultSet, withVector)
+		if err != nil {
+			return err
+		}
+		if err := fn(ids, rows); err != nil {
+			return err
+		}
+		if len(ids) < batchSize {
+			return nil
+		}
+		cursor = slices.Max(ids)
+	}
+}
+
+// upsert writes the rows with the given primary keys into the collection of the given dimension
+func (m *milvusRepository) upsert(ctx context.Context,
+	dimension int, ids []string, rows []*MilvusVectorEmbedding,
+) error {
+	var (
+		contents         = make([]string, len(rows))
+		sourceIDs        = make([]string, len(rows))
+		sourceTypes      = make([]int64, len(rows))
+		chunkIDs         = make([]string, len(rows))
+		knowledgeIDs     = make([]string, len(rows))
+		knowledgeBaseIDs = make([]string, len(rows))
+		isEnabled        = make([]bool, len(rows))
+		tagIDs           = make([][]string, len(rows))
+		fileTypes        = make([]string, len(rows))
+		createdAt        = make([]int64, len(rows))
+		metadataPairs    = make([][]string, len(rows))
+		embeddings       = make([][]float32, len(rows))
+	)
+	for i, row := range rows {
+		contents[i] = truncateContent(row.Content)
+		sourceIDs[i] = row.SourceID
+		sourceTypes[i] = int64(row.SourceType)
+		chunkIDs[i] = row.ChunkID
+		knowledgeIDs[i] = row.KnowledgeID
+		knowledgeBaseIDs[i] = row.KnowledgeBaseID
+		isEnabled[i] = row.IsEnabled
+		tagIDs[i] = nonNil(row.TagIDs)
+		fileTypes[i] = row.FileType
+		createdAt[i] = row.KnowledgeCreatedAt
+		metadataPairs[i] = nonNil(row.MetadataPairs)
+		embeddings[i] = row.Embedding
+	}
+
+	_, err := m.client.Upsert(ctx, milvusclient.NewColumnBasedInsertOption(m.getCollectionName(dimension)).
+		WithVarcharColumn(fieldID, ids).
+		WithVarcharColumn(fieldContent, contents).
+		WithVarcharColumn(fieldSourceID, sourceIDs).
+		WithInt64Column(fieldSourceType, sourceTypes).
+		WithVarcharColumn(fieldChunkID, chunkIDs).
+		WithVarcharColumn(fieldKnowledgeID, knowledgeIDs).
+		WithVarcharColumn(fieldKnowledgeBaseID, knowledgeBaseIDs).
+		WithBoolColumn(fieldIsEnabled, isEnabled).
+		WithColumns(
+			column.NewColumnVarCharArray(fieldTagIDs, tagIDs),
+			column.NewColumnVarCharArray(fieldMetadataPairs, metadataPairs),
+		).
+		WithVarcharColumn(fieldFileType, fileTypes).
+		WithInt64Column(fieldKnowledgeCreatedAt, createdAt).
+		WithFloatVectorColumn(fieldEmbedding, dimension, embeddings),
+	)
+	return err
+}
+
+// dimensionOf extracts the vector dimension from a collection name
+func (m *milvusRepository) dimensionOf(collectionName string) (int, bool) {
+	dimension, err := strconv.Atoi(strings.TrimPrefix(collectionName, m.collectionBaseName+"_"))
+	if err != nil {
+		return 0, false
+	}
+	return dimension, true
+}
+
+// readResultSet converts a Milvus result set into primary keys and embeddings
+func readResultSet(resultSet milvusclient.ResultSet, withVector bool) ([]string, []*MilvusVectorEmbedding, error) {
+	count := resultSet.ResultCount
+	ids := make([]string, count)
+	rows := make([]*MilvusVectorEmbedding, count)
+
+	columns := make(map[string]column.Column, len(resultSet.Fields))
+	for _, col := range resultSet.Fields {
+		columns[col.Name()] = col
+	}
+	getString := func(name string, i int) string {
+		col, ok := columns[name]
+		if !ok {
+			return ""
+		}
+		value, _ := col.GetAsString(i)
+		return value
+	}
+
+	for i := 0; i < count; i++ {
+		if resultSet.IDs != nil {
+			ids[i], _ = resultSet.IDs.GetAsString(i)
+		} else {
+			ids[i] = getString(fieldID, i)
+		}
+
+		row := &MilvusVectorEmbedding{
+			Content:         getString(fieldContent, i),
+			SourceID:        getString(fieldSourceID, i),
+			ChunkID:         getString(fieldChunkID, i),
+			KnowledgeID:     getString(fieldKnowledgeID, i),
+			KnowledgeBaseID: getString(fieldKnowledgeBaseID, i),
+			FileType:        getString(fieldFileType, i),
+		}
+		if col, ok := columns[fieldSourceType]; ok {
+			sourceType, _ := col.GetAsInt64(i)
+			row.SourceType = int(sourceType)
+		}
+		if col, ok := columns[fieldIsEnabled]; ok {
+			row.IsEnabled, _ = col.GetAsBool(i)
+		}
+		if col, ok := columns[fieldKnowledgeCreatedAt]; ok {
+			row.KnowledgeCreatedAt, _ = col.GetAsInt64(i)
+		}
+		if col, ok := columns[fieldTagIDs].(*column.ColumnVarCharArray); ok {
+			row.TagIDs, _ = col.Value(i)
+		}
+		if col, ok := columns[fieldMetadataPairs].(*column.ColumnVarCharArray); ok {
+			row.MetadataPairs, _ = col.Value(i)
+		}
+		if withVector {
+			col, ok := columns[fieldEmbedding].(*column.ColumnFloatVector)
+			if !ok {
+				return nil, nil, fmt.Errorf("missing %s column in result set", fieldEmbedding)
+			}
+			value, err := col.Get(i)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to read vector: %w", err)
+			}
+			if vector, ok := value.(entity.FloatVector); ok {
+				row.Embedding = vector
+			}
+		}
+		rows[i] = row
+	}
+	return ids, rows, nil
+}
+
+func buildRetrieveResult(results []*types.IndexWithScore, retrieverType types.RetrieverType) []*types.RetrieveResult {
+	return []*types.RetrieveResult{
+		{
+			Results:             results,
+			RetrieverEngineType: types.MilvusRetrieverEngineType,
+			RetrieverType:       retrieverType,
+			Error:               nil,
+		},
+	}
+}
+
+// calculateStorageSize follows the Qdrant estimate: scalar payload, raw vector and HNSW graph
+func (m *milvusRepository) calculateStorageSize(embedding *MilvusVectorEmbedding) int64 {
+	// Scalar fields
+	payloadSizeBytes := int64(0)
+	payloadSizeBytes += int64(len(embedding.Content))         // content string
+	payloadSizeBytes += int64(len(embedding.SourceID))        // source_id string
+	payloadSizeBytes += int64(len(embedding.ChunkID))         // chunk_id string
+	payloadSizeBytes += int64(len(embedding.KnowledgeID))     // knowledge_id string
+	payloadSizeBytes += int64(len(embedding.KnowledgeBaseID)) // knowledge_base_id string
+	payloadSizeBytes += 8                                     // source_type int64
+	payloadSizeBytes += 1                                     // is_enabled bool
+	payloadSizeBytes += 36                                    // id (uuid string)
+	payloadSizeBytes += int64(len(embedding.FileType)) + 8    // file_type string and knowledge_created_at int64
+	for _, value := range append(slices.Clone(embedding.TagIDs), embedding.MetadataPairs...) {
+		payloadSizeBytes += int64(len(value)) // tag_ids and metadata_pairs arrays
+	}
+
+	// Vector storage and index
+	var vectorSizeBytes int64 = 0
+	var hnswIndexBytes int64 = 0
+	if embedding.Embedding != nil {
+		dimensions := int64(len(embedding.Embedding))
+		vectorSizeBytes = dimensions * 4
+
+		// HNSW index: dimensions × (M × 2) × 4 bytes, AUTOINDEX uses M=16 by default
+		const hnswM = 16
+		hnswIndexBytes = dimensions * (hnswM * 2) * 4
+	}
+
+	return payloadSizeBytes + vectorSizeBytes + hnswIndexBytes
+}
+
+// toMilvusVectorEmbedding converts IndexInfo to the Milvus row format
+func toMilvusVectorEmbedding(embedding *types.IndexInfo, additionalParams map[string]interface{}) *MilvusVectorEmbedding {
+	vector := &MilvusVectorEmbedding{
+		Content:         embedding.Content,
+		SourceID:        embedding.SourceID,
+		SourceType:      int(embedding.SourceType),
+		ChunkID:         embedding.ChunkID,
+		KnowledgeID:     embedding.KnowledgeID,
+		KnowledgeBaseID: embedding.KnowledgeBaseID,
+		IsEnabled:       true, // Default to enabled
+	}
+	setAttributes(vector, embedding.Attributes)
+	if additionalParams != nil && slices.Contains(slices.Collect(maps.Keys(additionalParams)), fieldEmbedding) {
+		if embeddingMap, ok := additionalParams[fieldEmbedding].(map[string][]float32); ok {
+			vector.Embedding = embeddingMap[embedding.SourceID]
+		}
+	}
+	return vector
+}
+
+// fromMilvusVectorEmbedding converts a Milvus entity to IndexWithScore domain model
+func fromMilvusVectorEmbedding(id string,
+	embedding *MilvusVectorEmbeddingWithScore,
+	matchType types.MatchType,
+) *types.IndexWithScore {
+	return &types.IndexWithScore{
+		ID:              id,
+		SourceID:        embedding.SourceID,
+		SourceType:      types.SourceType(embedding.SourceType),
+		ChunkID:         embedding.ChunkID,
+		KnowledgeID:     embedding.KnowledgeID,
+		KnowledgeBaseID: embedding.KnowledgeBaseID,
+		Content:         embedding.Content,
+		Score:           embedding.Score,
+		MatchType:       matchType,
+	}
+}
+
+// setAttributes copies the filter attributes into the row within the capacity of the array fields
+func setAttributes(row *MilvusVectorEmbedding, attrs *types.IndexAttributes) {
+	if attrs == nil {
+		return
+	}
+	row.TagIDs = attrs.TagIDs[:min(len(attrs.TagIDs), maxTagIDs)]
+	row.FileType = attrs.FileType
+	row.KnowledgeCreatedAt = attrs.CreatedAt
+	row.MetadataPairs = make([]string, 0, len(attrs.MetadataPairs))
+	for _, pair := range attrs.MetadataPairs {
+		if len(pair) <= maxMetadataPairLength && len(row.MetadataPairs) < maxMetadataPairs {
+			row.MetadataPairs = append(row.MetadataPairs, pair)
+		}
+	}
+}
+
+// filterExprs translates a metadata filter into expressions on the attribute fields
+func filterExprs(filter *types.RetrieveFilter) []string {
+	var exprs []string
+	if len(filter.TagIDs) > 0 {
+		literal, _ := json.Marshal(filter.TagIDs)
+		exprs = append(exprs, fmt.Sprintf("array_contains_any(%s, %s)", fieldTagIDs, literal))
+	}
+	if len(filter.FileTypes) > 0 {
+		exprs = append(exprs, inExpr(fieldFileType, filter.FileTypes))
+	}
+	if filter.CreatedAfter != nil {
+		exprs = append(exprs, fmt.Sprintf("%s >= %d", fieldKnowledgeCreatedAt, filter.CreatedAfter.UnixMilli()))
+	}
+	if filter.CreatedBefore != nil {
+		exprs = append(exprs, fmt.Sprintf("%s < %d", fieldKnowledgeCreatedAt, filter.CreatedBefore.UnixMilli()))
+	}
+	if pairs := filter.MetadataPairs(); len(pairs) > 0 {
+		literal, _ := json.Marshal(pairs)
+		exprs = append(exprs, fmt.Sprintf("array_contains_all(%s, %s)", fieldMetadataPairs, literal))
+	}
+	return exprs
+}
+
+// nonNil returns an empty slice for nil, array columns do not accept null rows
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// inExpr builds a `field in [...]` expression; JSON string literals are valid Milvus literals
+func inExpr(field string, values []string) string {
+	if values == nil {
+		values = []string{}
+	}
+	literal, _ := json.Marshal(values)
+	return fmt.Sprintf("%s in %s", field, literal)
+}
+
+// pageExpr restricts the filter to the entities after the cursor primary key
+func pageExpr(filter string, cursor string) string {
+	if cursor == "" {
+		return filter
+	}
+	literal, _ := json.Marshal(cursor)
+	return fmt.Sprintf("(%s) and %s > %s", filter, fieldID, literal)
+}
+
+// eqExpr builds a `field == "value"` expression
+func eqExpr(field string, value string) string {
+	literal, _ := json.Marshal(value)
+	return fmt.Sprintf("%s == %s", field, literal)
+}
+
+// escapeSingleQuoted escapes a string for use inside a single quoted expression literal
+func escapeSingleQuoted(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
+
+// truncateContent cuts content to the VARCHAR limit without splitting a UTF-8 sequence
+func truncateContent(content string) string {
+	if len(content) <= maxContentBytes {
+		return content
+	}
+	cut := maxContentBytes
+	for cut > 0 && !utf8.RuneStart(content[cut]) {
+		cut--
+	}
+	return content[:cut]
+}
+
+// tokenizeQuery splits a query string into tokens for OR-based full-text search.
+// It uses jieba for professional Chinese word segmentation.
+func tokenizeQuery(query string) []string {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+
+	// Use jieba for segmentation (search mode for better recall)
+	words := types.Jieba.CutForSearch(query, true)
+
+	// Filter and deduplicate
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.TrimSpace(strings.ToLower(word))
+		// Skip empty, single-char, and already seen words
+		if utf8.RuneCountInString(word) < 2 || seen[word] {
+			continue
+		}
+		seen[word] = true
+		result = append(result, word)
+	}
+
+	return result
+}
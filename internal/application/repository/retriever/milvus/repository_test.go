@@ -0,0 +1,211 @@
+package milvus
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestExpressions(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{
+			name: "in",
+			got:  inExpr(fieldChunkID, []string{"c1", "c2"}),
+			want: `chunk_id in ["c1","c2"]`,
+		},
+		{
+			name: "in without values",
+			got:  inExpr(fieldChunkID, nil),
+			want: `chunk_id in []`,
+		},
+		{
+			name: "in with quotes",
+			got:  inExpr(fieldKnowledgeID, []string{`k"1`, `k\2`}),
+			want: `knowledge_id in ["k\"1","k\\2"]`,
+		},
+		{
+			name: "equal",
+			got:  eqExpr(fieldKnowledgeBaseID, `kb"1`),
+			want: `knowledge_base_id == "kb\"1"`,
+		},
+		{
+			name: "first page",
+			got:  pageExpr(`chunk_id in ["c1"]`, ""),
+			want: `chunk_id in ["c1"]`,
+		},
+		{
+			name: "next page",
+			got:  pageExpr(`chunk_id in ["c1"] or chunk_id in ["c2"]`, "id-64"),
+			want: `(chunk_id in ["c1"] or chunk_id in ["c2"]) and id > "id-64"`,
+		},
+		{
+			name: "single quoted",
+			got:  escapeSingleQuoted(`it's a \ test`),
+			want: `it\'s a \\ test`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %s, want %s", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBaseFilter(t *testing.T) {
+	m := &milvusRepository{collectionBaseName: defaultCollectionName}
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		params types.RetrieveParams
+		want   string
+	}{
+		{
+			name: "enabled only",
+			want: `is_enabled == true`,
+		},
+		{
+			name: "knowledge bases and documents",
+			params: types.RetrieveParams{
+				KnowledgeBaseIDs: []string{"kb1"},
+				KnowledgeIDs:     []string{"k1", "k2"},
+			},
+			want: `is_enabled == true and knowledge_base_id in ["kb1"] and knowledge_id in ["k1","k2"]`,
+		},
+		{
+			name: "file type filter ignores resolved chunk IDs",
+			params: types.RetrieveParams{
+				Filter:         &types.RetrieveFilter{FileTypes: []string{"pdf"}},
+				FilterChunkIDs: []string{"c1"},
+			},
+			want: `is_enabled == true and file_type in ["pdf"]`,
+		},
+		{
+			name: "metadata filter",
+			params: types.RetrieveParams{
+				Filter: &types.RetrieveFilter{
+					TagIDs:        []string{"t1", "t2"},
+					CreatedAfter:  &after,
+					CreatedBefore: &before,
+					Metadata:      map[string]string{"region": "eu", "lang": "en"},
+				},
+			},
+			want: `is_enabled == true and array_contains_any(tag_ids, ["t1","t2"]) and ` +
+				`knowledge_created_at >= 1735689600000 and knowledge_created_at < 1738368000000 and ` +
+				`array_contains_all(metadata_pairs, ["[\"lang\",\"en\"]","[\"region\",\"eu\"]"])`,
+		},
+		{
+			name: "exclusions",
+			params: types.RetrieveParams{
+				ExcludeKnowledgeIDs: []string{"k1"},
+				ExcludeChunkIDs:     []string{"c1"},
+			},
+			want: `is_enabled == true and not (knowledge_id in ["k1"]) and not (chunk_id in ["c1"])`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.getBaseFilter(tt.params); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectionRouting(t *testing.T) {
+	m := &milvusRepository{collectionBaseName: "weknora"}
+
+	if got := m.getCollectionName(768); got != "weknora_768" {
+		t.Errorf("collection name = %s, want weknora_768", got)
+	}
+
+	tests := []struct {
+		collection    string
+		wantOwn       bool
+		wantDimension int
+	}{
+		{collection: "weknora_768", wantOwn: true, wantDimension: 768},
+		{collection: "weknora_1024", wantOwn: true, wantDimension: 1024},
+		{collection: "weknora_backup", wantOwn: true},
+		{collection: "weknora", wantOwn: false},
+		{collection: "other_768", wantOwn: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.collection, func(t *testing.T) {
+			if got := m.isOwnCollection(tt.collection); got != tt.wantOwn {
+				t.Errorf("own collection = %v, want %v", got, tt.wantOwn)
+			}
+			if !tt.wantOwn {
+				return
+			}
+			dimension, ok := m.dimensionOf(tt.collection)
+			if ok != (tt.wantDimension != 0) || dimension != tt.wantDimension {
+				t.Errorf("dimension = %d, %v, want %d", dimension, ok, tt.wantDimension)
+			}
+		})
+	}
+}
+
+func TestToMilvusVectorEmbedding(t *testing.T) {
+	params := map[string]any{
+		fieldEmbedding: map[string][]float32{"s1": {0.1, 0.2, 0.3}},
+	}
+	row := toMilvusVectorEmbedding(&types.IndexInfo{
+		SourceID: "s1", ChunkID: "c1", KnowledgeID: "k1", KnowledgeBaseID: "kb1", Content: "content",
+	}, params)
+	if len(row.Embedding) != 3 {
+		t.Errorf("embedding dimension = %d, want 3", len(row.Embedding))
+	}
+	if !row.IsEnabled {
+		t.Error("new rows must be enabled")
+	}
+
+	missing := toMilvusVectorEmbedding(&types.IndexInfo{SourceID: "s2", ChunkID: "c2"}, params)
+	if len(missing.Embedding) != 0 {
+		t.Errorf("embedding dimension = %d, want no embedding", len(missing.Embedding))
+	}
+
+	pairs := []string{types.MetadataPair("lang", "en"), types.MetadataPair("notes", strings.Repeat("x", maxMetadataPairLength))}
+	attributed := toMilvusVectorEmbedding(&types.IndexInfo{
+		SourceID: "s3", ChunkID: "c3",
+		Attributes: &types.IndexAttributes{
+			TagIDs: []string{"t1"}, FileType: "pdf", CreatedAt: 1735689600000, MetadataPairs: pairs,
+		},
+	}, params)
+	if !slices.Equal(attributed.TagIDs, []string{"t1"}) || attributed.FileType != "pdf" ||
+		attributed.KnowledgeCreatedAt != 1735689600000 {
+		t.Errorf("attributes = %v %s %d, want [t1] pdf 1735689600000",
+			attributed.TagIDs, attributed.FileType, attributed.KnowledgeCreatedAt)
+	}
+	// Pairs longer than the array element limit cannot be stored
+	if !slices.Equal(attributed.MetadataPairs, pairs[:1]) {
+		t.Errorf("metadata pairs = %v, want %v", attributed.MetadataPairs, pairs[:1])
+	}
+}
+
+func TestTruncateContent(t *testing.T) {
+	short := "短文本"
+	if got := truncateContent(short); got != short {
+		t.Errorf("short content changed to %q", got)
+	}
+
+	long := strings.Repeat("a", maxContentBytes-1) + "中文"
+	got := truncateContent(long)
+	if len(got) > maxContentBytes || !utf8.ValidString(got) {
+		t.Errorf("truncated content has %d bytes, valid UTF-8 %v", len(got), utf8.ValidString(got))
+	}
+	if got != long[:maxContentBytes-1] {
+		t.Error("content was not cut at the last complete character")
+	}
+}
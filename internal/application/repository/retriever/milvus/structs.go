@@ -0,0 +1,35 @@
+package milvus
+
+import (
+	"sync"
+
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
+)
+
+type milvusRepository struct {
+	client             *milvusclient.Client
+	collectionBaseName string
+	// Cache for initialized collections (dimension -> true)
+	initializedCollections sync.Map
+}
+
+type MilvusVectorEmbedding struct {
+	Content         string    `json:"content"`
+	SourceID        string    `json:"source_id"`
+	SourceType      int       `json:"source_type"`
+	ChunkID         string    `json:"chunk_id"`
+	KnowledgeID     string    `json:"knowledge_id"`
+	KnowledgeBaseID string    `json:"knowledge_base_id"`
+	Embedding       []float32 `json:"embedding"`
+	IsEnabled       bool      `json:"is_enabled"`
+	// Filter attributes of the chunk and its knowledge
+	TagIDs             []string `json:"tag_ids"`
+	FileType           string   `json:"file_type"`
+	KnowledgeCreatedAt int64    `json:"knowledge_created_at"`
+	MetadataPairs      []string `json:"metadata_pairs"`
+}
+
+type MilvusVectorEmbeddingWithScore struct {
+	MilvusVectorEmbedding
+	Score float64
+}
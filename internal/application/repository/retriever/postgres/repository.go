@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Tencent/WeKnora/internal/application/repository"
 	"github.com/Tencent/WeKnora/internal/common"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -146,6 +147,26 @@ func (g *pgRepository) DeleteByKnowledgeIDList(ctx context.Context, knowledgeIDL
 	return nil
 }
 
+// DeleteByKnowledgeBaseIDList deletes indices of the given dimension by knowledge base IDs
+func (g *pgRepository) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	if len(knowledgeBaseIDList) == 0 {
+		return nil
+	}
+	logger.GetLogger(ctx).Infof("[Postgres] Deleting indices by knowledge base IDs, count: %d, dimension: %d",
+		len(knowledgeBaseIDList), dimension)
+	result := g.db.WithContext(ctx).
+		Where("knowledge_base_id IN ? AND dimension = ?", knowledgeBaseIDList, dimension).
+		Delete(&pgVector{})
+	if result.Error != nil {
+		logger.GetLogger(ctx).Errorf("[Postgres] Failed to delete indices by knowledge base IDs: %v", result.Error)
+		return result.Error
+	}
+	logger.GetLogger(ctx).Infof("[Postgres] Successfully deleted %d indices by knowledge base IDs", result.RowsAffected)
+	return nil
+}
+
 // Retrieve handles retrieval requests and routes to appropriate method
 func (g *pgRepository) Retrieve(ctx context.Context, params types.RetrieveParams) ([]*types.RetrieveResult, error) {
 	logger.GetLogger(ctx).Debugf("[Postgres] Processing retrieval request of type: %s", params.RetrieverType)
@@ -185,6 +206,11 @@ func (g *pgRepository) KeywordsRetrieve(ctx context.Context,
 			Values: common.ToInterfaceSlice(params.KnowledgeIDs),
 		})
 	}
+	if !params.Filter.IsEmpty() {
+		subquery, vars := repository.ChunkFilterSubquery(params.Filter)
+		logger.GetLogger(ctx).Debugf("[Postgres] Filtering by metadata filter: %+v", params.Filter)
+		conds = append(conds, clause.Expr{SQL: "chunk_id IN (" + subquery + ")", Vars: vars})
+	}
 	conds = append(conds, clause.Expr{
 		SQL:  "id @@@ paradedb.match(field => 'content', value => ?, distance => 1)",
 		Vars: []interface{}{params.Query},
@@ -294,6 +320,13 @@ func (g *pgRepository) VectorRetrieve(ctx context.Context,
 			strings.Join(placeholders, ", ")))
 	}
 
+	if !params.Filter.IsEmpty() {
+		logger.GetLogger(ctx).Debugf("[Postgres] Filtering vector search by metadata filter: %+v", params.Filter)
+		subquery, vars := repository.ChunkFilterSubquery(params.Filter)
+		whereParts = append(whereParts, fmt.Sprintf("chunk_id IN (%s)", numberPlaceholders(subquery, len(allVars)+1)))
+		allVars = append(allVars, vars...)
+	}
+
 	// is_enabled filter
 	whereParts = append(whereParts, fmt.Sprintf("(is_enabled IS NULL OR is_enabled = $%d)", len(allVars)+1))
 	allVars = append(allVars, true)
@@ -561,3 +594,25 @@ func (g *pgRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, chunkS
 	logger.GetLogger(ctx).Infof("[Postgres] Successfully batch updated chunk enabled status")
 	return nil
 }
+
+// BatchUpdateChunkAttributes is a no-op, filters are evaluated against the chunk and knowledge tables
+func (g *pgRepository) BatchUpdateChunkAttributes(ctx context.Context,
+	chunkAttributes map[string]*types.IndexAttributes,
+) error {
+	return nil
+}
+
+// numberPlaceholders rewrites "?" placeholders as numbered "$n" placeholders starting at start
+func numberPlaceholders(query string, start int) string {
+	var builder strings.Builder
+	n := start
+	for _, r := range query {
+		if r == '?' {
+			builder.WriteString(fmt.Sprintf("$%d", n))
+			n++
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
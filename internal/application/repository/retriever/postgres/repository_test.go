@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestNumberPlaceholders(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		start int
+		want  string
+	}{
+		{name: "no placeholders", query: "SELECT 1", start: 1, want: "SELECT 1"},
+		{name: "from the first", query: "a = ? AND b IN (?, ?)", start: 1, want: "a = $1 AND b IN ($2, $3)"},
+		{name: "after other conditions", query: "a = ?", start: 4, want: "a = $4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := numberPlaceholders(tt.query, tt.start); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkFilterSubquery(t *testing.T) {
+	after := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	filter := &types.RetrieveFilter{
+		TagIDs:       []string{"t1", "t2"},
+		FileTypes:    []string{"pdf"},
+		CreatedAfter: &after,
+		Metadata:     map[string]string{"region": "eu", "lang": "en"},
+	}
+
+	subquery, vars := repository.ChunkFilterSubquery(filter)
+	numbered := numberPlaceholders(subquery, 3)
+
+	if strings.Contains(numbered, "?") {
+		t.Fatalf("placeholders left in %q", numbered)
+	}
+	if !strings.Contains(numbered, "($3, $4)") || !strings.HasSuffix(numbered, "$12") {
+		t.Errorf("placeholders of %q are not numbered $3 to $12", numbered)
+	}
+	// Tags are matched twice, then file type, creation time and the metadata keys in sorted order
+	want := []interface{}{"t1", "t2", "t1", "t2", "pdf", after, "lang", "en", "region", "eu"}
+	if len(vars) != len(want) {
+		t.Fatalf("got %d vars %v, want %v", len(vars), vars, want)
+	}
+	for i := range want {
+		if vars[i] != want[i] {
+			t.Errorf("var %d = %v, want %v", i, vars[i], want[i])
+		}
+	}
+}
@@ -2,6 +2,7 @@ package qdrant
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"maps"
 	"os"
@@ -27,6 +28,11 @@ const (
 	fieldKnowledgeBaseID  = "knowledge_base_id"
 	fieldEmbedding        = "embedding"
 	fieldIsEnabled        = "is_enabled"
+	// Filter attributes of the chunk and its knowledge
+	fieldTagIDs             = "tag_ids"
+	fieldFileType           = "file_type"
+	fieldKnowledgeCreatedAt = "knowledge_created_at"
+	fieldMetadataPairs      = "metadata_pairs"
 )
 
 // NewQdrantRetrieveEngineRepository creates and initializes a new Qdrant repository
@@ -133,6 +139,24 @@ func (q *qdrantRepository) ensureCollection(ctx context.Context, dimension int)
 		log.Infof("[Qdrant] Successfully created collection %s", collectionName)
 	}
 
+	// Collections created before filter attributes were stored get their indexes here as well
+	attributeIndexes := map[string]qdrant.FieldType{
+		fieldTagIDs:             qdrant.FieldType_FieldTypeKeyword,
+		fieldFileType:           qdrant.FieldType_FieldTypeKeyword,
+		fieldMetadataPairs:      qdrant.FieldType_FieldTypeKeyword,
+		fieldKnowledgeCreatedAt: qdrant.FieldType_FieldTypeInteger,
+	}
+	for field, fieldType := range attributeIndexes {
+		_, err = q.client.CreateFieldIndex(ctx, &qdrant.CreateFieldIndexCollection{
+			CollectionName: collectionName,
+			FieldName:      field,
+			FieldType:      fieldType.Enum(),
+		})
+		if err != nil {
+			log.Warnf("[Qdrant] Failed to create index for field %s: %v", field, err)
+		}
+	}
+
 	// Mark as initialized
 	q.initializedCollections.Store(dimension, true)
 	return nil
@@ -321,6 +345,38 @@ func (q *qdrantRepository) DeleteByKnowledgeIDList(ctx context.Context,
 	return nil
 }
 
+// DeleteByKnowledgeBaseIDList removes points from the collection of the given dimension
+// based on knowledge base IDs
+func (q *qdrantRepository) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(knowledgeBaseIDList) == 0 {
+		log.Warn("[Qdrant] Empty knowledge base ID list provided for deletion, skipping")
+		return nil
+	}
+
+	collectionName := q.getCollectionName(dimension)
+	log.Infof("[Qdrant] Deleting indices by knowledge base IDs from %s, count: %d",
+		collectionName, len(knowledgeBaseIDList))
+
+	_, err := q.client.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: collectionName,
+		Points: qdrant.NewPointsSelectorFilter(&qdrant.Filter{
+			Must: []*qdrant.Condition{
+				qdrant.NewMatchKeywords(fieldKnowledgeBaseID, knowledgeBaseIDList...),
+			},
+		}),
+	})
+	if err != nil {
+		log.Errorf("[Qdrant] Failed to delete by knowledge base IDs: %v", err)
+		return fmt.Errorf("failed to delete by knowledge base IDs: %w", err)
+	}
+
+	log.Infof("[Qdrant] Successfully deleted documents by knowledge base IDs")
+	return nil
+}
+
 // DeleteBySourceIDList removes points from the collection based on source IDs
 func (q *qdrantRepository) DeleteBySourceIDList(ctx context.Context,
 	sourceIDList []string, dimension int, knowledgeType string,
@@ -426,6 +482,59 @@ func (q *qdrantRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, ch
 	return nil
 }
 
+// BatchUpdateChunkAttributes replaces the filter attributes of chunks in batch
+// This method operates on all collections since dimension is not provided
+func (q *qdrantRepository) BatchUpdateChunkAttributes(ctx context.Context,
+	chunkAttributes map[string]*types.IndexAttributes,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(chunkAttributes) == 0 {
+		return nil
+	}
+
+	log.Infof("[Qdrant] Batch updating chunk attributes, count: %d", len(chunkAttributes))
+
+	collections, err := q.client.ListCollections(ctx)
+	if err != nil {
+		log.Errorf("[Qdrant] Failed to list collections: %v", err)
+		return fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	// Chunks of one knowledge usually share their attributes, so they are set together
+	groups := make(map[string][]string)
+	groupAttributes := make(map[string]*types.IndexAttributes)
+	for chunkID, attrs := range chunkAttributes {
+		encoded, _ := json.Marshal(attrs)
+		key := string(encoded)
+		groups[key] = append(groups[key], chunkID)
+		groupAttributes[key] = attrs
+	}
+
+	for _, collectionName := range collections {
+		if !strings.HasPrefix(collectionName, q.collectionBaseName+"_") {
+			continue
+		}
+		for key, chunkIDs := range groups {
+			_, err := q.client.SetPayload(ctx, &qdrant.SetPayloadPoints{
+				CollectionName: collectionName,
+				Payload:        qdrant.NewValueMap(attributePayload(groupAttributes[key])),
+				PointsSelector: qdrant.NewPointsSelectorFilter(&qdrant.Filter{
+					Must: []*qdrant.Condition{
+						qdrant.NewMatchKeywords(fieldChunkID, chunkIDs...),
+					},
+				}),
+			})
+			if err != nil {
+				log.Errorf("[Qdrant] Failed to update chunk attributes in %s: %v", collectionName, err)
+				return fmt.Errorf("failed to update chunk attributes in %s: %w", collectionName, err)
+			}
+		}
+	}
+
+	log.Infof("[Qdrant] Batch update chunk attributes completed")
+	return nil
+}
+
 func (q *qdrantRepository) getBaseFilter(params types.RetrieveParams) *qdrant.Filter {
 	must := make([]*qdrant.Condition, 0)
 	mustNot := make([]*qdrant.Condition, 0)
@@ -443,6 +552,9 @@ func (q *qdrantRepository) getBaseFilter(params types.RetrieveParams) *qdrant.Fi
 	if len(params.KnowledgeIDs) > 0 {
 		must = append(must, qdrant.NewMatchKeywords(fieldKnowledgeID, params.KnowledgeIDs...))
 	}
+	if !params.Filter.IsEmpty() {
+		must = append(must, filterConditions(params.Filter)...)
+	}
 
 	if len(params.ExcludeKnowledgeIDs) > 0 {
 		mustNot = append(mustNot, qdrant.NewMatchKeywords(fieldKnowledgeID, params.ExcludeKnowledgeIDs...))
@@ -747,6 +859,11 @@ func (q *qdrantRepository) CopyIndices(ctx context.Context,
 				fieldKnowledgeBaseID: targetKnowledgeBaseID,
 				fieldIsEnabled:       true,
 			})
+			for _, field := range []string{fieldTagIDs, fieldFileType, fieldKnowledgeCreatedAt, fieldMetadataPairs} {
+				if value, ok := payload[field]; ok {
+					newPayload[field] = value
+				}
+			}
 
 			var vectors *qdrant.Vectors
 			if vectorOutput := sourcePoint.Vectors.GetVector(); vectorOutput != nil {
@@ -807,9 +924,59 @@ func createPayload(embedding *QdrantVectorEmbedding) map[string]*qdrant.Value {
 		fieldKnowledgeBaseID: embedding.KnowledgeBaseID,
 		fieldIsEnabled:       embedding.IsEnabled,
 	}
+	if embedding.Attributes != nil {
+		maps.Copy(payload, attributePayload(embedding.Attributes))
+	}
 	return qdrant.NewValueMap(payload)
 }
 
+// attributePayload returns the payload fields holding the filter attributes
+func attributePayload(attrs *types.IndexAttributes) map[string]any {
+	return map[string]any{
+		fieldTagIDs:             toAnySlice(attrs.TagIDs),
+		fieldFileType:           attrs.FileType,
+		fieldKnowledgeCreatedAt: attrs.CreatedAt,
+		fieldMetadataPairs:      toAnySlice(attrs.MetadataPairs),
+	}
+}
+
+// filterConditions translates a metadata filter into conditions on the attribute payload
+func filterConditions(filter *types.RetrieveFilter) []*qdrant.Condition {
+	var conditions []*qdrant.Condition
+	if len(filter.TagIDs) > 0 {
+		conditions = append(conditions, qdrant.NewMatchKeywords(fieldTagIDs, filter.TagIDs...))
+	}
+	if len(filter.FileTypes) > 0 {
+		conditions = append(conditions, qdrant.NewMatchKeywords(fieldFileType, filter.FileTypes...))
+	}
+	if filter.CreatedAfter != nil || filter.CreatedBefore != nil {
+		createdRange := &qdrant.Range{}
+		if filter.CreatedAfter != nil {
+			after := float64(filter.CreatedAfter.UnixMilli())
+			createdRange.Gte = &after
+		}
+		if filter.CreatedBefore != nil {
+			before := float64(filter.CreatedBefore.UnixMilli())
+			createdRange.Lt = &before
+		}
+		conditions = append(conditions, qdrant.NewRange(fieldKnowledgeCreatedAt, createdRange))
+	}
+	// Every metadata condition must hold, so each pair is matched on its own
+	for _, pair := range filter.MetadataPairs() {
+		conditions = append(conditions, qdrant.NewMatchKeyword(fieldMetadataPairs, pair))
+	}
+	return conditions
+}
+
+// toAnySlice converts strings to the list form payload values are built from
+func toAnySlice(values []string) []any {
+	list := make([]any, len(values))
+	for i, value := range values {
+		list[i] = value
+	}
+	return list
+}
+
 func buildRetrieveResult(results []*types.IndexWithScore, retrieverType types.RetrieverType) []*types.RetrieveResult {
 	return []*types.RetrieveResult{
 		{
@@ -863,6 +1030,7 @@ func toQdrantVectorEmbedding(embedding *types.IndexInfo, additionalParams map[st
 		KnowledgeID:     embedding.KnowledgeID,
 		KnowledgeBaseID: embedding.KnowledgeBaseID,
 		IsEnabled:       true, // Default to enabled
+		Attributes:      embedding.Attributes,
 	}
 	if additionalParams != nil && slices.Contains(slices.Collect(maps.Keys(additionalParams)), fieldEmbedding) {
 		if embeddingMap, ok := additionalParams[fieldEmbedding].(map[string][]float32); ok {
@@ -40,32 +40,33 @@ func (r *sessionRepository) Get(ctx context.Context, tenantID uint64, id string)
 	return &session, nil
 }
 
-// GetByTenantID retrieves all sessions for a tenant
-func (r *sessionRepository) GetByTenantID(ctx context.Context, tenantID uint64) ([]*types.Session, error) {
+// GetByTenantID retrieves all sessions for a tenant, narrowed by filter when it is not nil
+func (r *sessionRepository) GetByTenantID(
+	ctx context.Context, tenantID uint64, filter *types.SessionListFilter,
+) ([]*types.Session, error) {
 	var sessions []*types.Session
-	err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).Order("created_at DESC").Find(&sessions).Error
+	err := r.listQuery(ctx, tenantID, filter).Order("created_at DESC").Find(&sessions).Error
 	if err != nil {
 		return nil, err
 	}
 	return sessions, nil
 }
 
-// GetPagedByTenantID retrieves sessions for a tenant with pagination
+// GetPagedByTenantID retrieves sessions for a tenant with pagination, narrowed by filter when it is not nil
 func (r *sessionRepository) GetPagedByTenantID(
-	ctx context.Context, tenantID uint64, page *types.Pagination,
+	ctx context.Context, tenantID uint64, filter *types.SessionListFilter, page *types.Pagination,
 ) ([]*types.Session, int64, error) {
 	var sessions []*types.Session
 	var total int64
 
 	// First query the total count
-	err := r.db.WithContext(ctx).Model(&types.Session{}).Where("tenant_id = ?", tenantID).Count(&total).Error
+	err := r.listQuery(ctx, tenantID, filter).Model(&types.Session{}).Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Then query the paginated data
-	err = r.db.WithContext(ctx).
-		Where("tenant_id = ?", tenantID).
+	err = r.listQuery(ctx, tenantID, filter).
 		Order("created_at DESC").
 		Offset(page.Offset()).
 		Limit(page.Limit()).
@@ -77,6 +78,24 @@ func (r *sessionRepository) GetPagedByTenantID(
 	return sessions, total, nil
 }
 
+// listQuery builds the query of the sessions of a tenant visible through filter
+func (r *sessionRepository) listQuery(ctx context.Context, tenantID uint64, filter *types.SessionListFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if filter == nil {
+		return query
+	}
+	if filter.Shared {
+		// Shared with the user directly or with the whole tenant, excluding the user's own sessions
+		return query.Where("user_id <> ?", filter.UserID).Where(
+			"id IN (?)",
+			r.db.Model(&types.SessionShare{}).Select("session_id").
+				Where("tenant_id = ? AND (share_type = ? OR (share_type = ? AND user_id = ?))",
+					tenantID, types.SessionShareTypeTenant, types.SessionShareTypeUser, filter.UserID),
+		)
+	}
+	return query.Where("user_id = ? OR user_id = ''", filter.UserID)
+}
+
 // Update updates a session
 func (r *sessionRepository) Update(ctx context.Context, session *types.Session) error {
 	session.UpdatedAt = time.Now()
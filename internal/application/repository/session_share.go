@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrSessionShareNotFound is returned when a session share does not exist
+var ErrSessionShareNotFound = errors.New("session share not found")
+
+// sessionShareRepository implements the SessionShareRepository interface
+type sessionShareRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionShareRepository creates a new session share repository
+func NewSessionShareRepository(db *gorm.DB) interfaces.SessionShareRepository {
+	return &sessionShareRepository{db: db}
+}
+
+// Create creates a share, sharing a session twice with the same audience returns the existing share
+func (r *sessionShareRepository) Create(ctx context.Context, share *types.SessionShare) (*types.SessionShare, error) {
+	share.CreatedAt = time.Now()
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(share).Error; err != nil {
+		return nil, err
+	}
+
+	var existing types.SessionShare
+	if err := r.db.WithContext(ctx).
+		Where("session_id = ? AND share_type = ? AND user_id = ?", share.SessionID, share.ShareType, share.UserID).
+		First(&existing).Error; err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// ListBySession lists the shares of a session
+func (r *sessionShareRepository) ListBySession(
+	ctx context.Context, tenantID uint64, sessionID string,
+) ([]*types.SessionShare, error) {
+	var shares []*types.SessionShare
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND session_id = ?", tenantID, sessionID).
+		Order("created_at ASC").
+		Find(&shares).Error
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// Delete deletes a share of a session
+func (r *sessionShareRepository) Delete(ctx context.Context, tenantID uint64, sessionID, id string) error {
+	result := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND session_id = ? AND id = ?", tenantID, sessionID, id).
+		Delete(&types.SessionShare{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionShareNotFound
+	}
+	return nil
+}
+
+// DeleteBySession deletes all shares of a session
+func (r *sessionShareRepository) DeleteBySession(ctx context.Context, tenantID uint64, sessionID string) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND session_id = ?", tenantID, sessionID).
+		Delete(&types.SessionShare{}).Error
+}
+
+// IsSharedWith reports whether a session is shared with the user, directly or through its tenant
+func (r *sessionShareRepository) IsSharedWith(
+	ctx context.Context, tenantID uint64, sessionID, userID string,
+) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&types.SessionShare{}).
+		Where("tenant_id = ? AND session_id = ?", tenantID, sessionID).
+		Where("share_type = ? OR (share_type = ? AND user_id = ?)",
+			types.SessionShareTypeTenant, types.SessionShareTypeUser, userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrTenantMemberNotFound is returned when a user is not a member of a tenant
+	ErrTenantMemberNotFound = errors.New("tenant member not found")
+	// ErrKnowledgeBaseGrantNotFound is returned when a knowledge base grant does not exist
+	ErrKnowledgeBaseGrantNotFound = errors.New("knowledge base grant not found")
+)
+
+// tenantMemberRepository implements the TenantMemberRepository interface
+type tenantMemberRepository struct {
+	db *gorm.DB
+}
+
+// NewTenantMemberRepository creates a new tenant member repository
+func NewTenantMemberRepository(db *gorm.DB) interfaces.TenantMemberRepository {
+	return &tenantMemberRepository{db: db}
+}
+
+// Create creates a member
+func (r *tenantMemberRepository) Create(ctx context.Context, member *types.TenantMember) error {
+	return r.db.WithContext(ctx).Omit("User").Create(member).Error
+}
+
+// Get gets the membership of a user in a tenant
+func (r *tenantMemberRepository) Get(ctx context.Context, tenantID uint64, userID string) (*types.TenantMember, error) {
+	var member types.TenantMember
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTenantMemberNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// List lists the members of a tenant with their users
+func (r *tenantMemberRepository) List(ctx context.Context, tenantID uint64) ([]*types.TenantMember, error) {
+	var members []*types.TenantMember
+	if err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("tenant_id = ?", tenantID).
+		Order("created_at ASC").
+		Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// UpdateRole updates the role of a member
+func (r *tenantMemberRepository) UpdateRole(
+	ctx context.Context, tenantID uint64, userID string, role types.TenantRole,
+) error {
+	result := r.db.WithContext(ctx).Model(&types.TenantMember{}).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Updates(map[string]interface{}{"role": role, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTenantMemberNotFound
+	}
+	return nil
+}
+
+// Delete deletes a member
+func (r *tenantMemberRepository) Delete(ctx context.Context, tenantID uint64, userID string) error {
+	result := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Delete(&types.TenantMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTenantMemberNotFound
+	}
+	return nil
+}
+
+// CountByRole counts the members of a tenant with the role
+func (r *tenantMemberRepository) CountByRole(
+	ctx context.Context, tenantID uint64, role types.TenantRole,
+) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&types.TenantMember{}).
+		Where("tenant_id = ? AND role = ?", tenantID, role).
+		Count(&count).Error
+	return count, err
+}
+
+// knowledgeBaseGrantRepository implements the KnowledgeBaseGrantRepository interface
+type knowledgeBaseGrantRepository struct {
+	db *gorm.DB
+}
+
+// NewKnowledgeBaseGrantRepository creates a new knowledge base grant repository
+func NewKnowledgeBaseGrantRepository(db *gorm.DB) interfaces.KnowledgeBaseGrantRepository {
+	return &knowledgeBaseGrantRepository{db: db}
+}
+
+// Upsert creates a grant or updates the role of the existing grant
+func (r *knowledgeBaseGrantRepository) Upsert(
+	ctx context.Context, grant *types.KnowledgeBaseGrant,
+) (*types.KnowledgeBaseGrant, error) {
+	now := time.Now()
+	grant.CreatedAt, grant.UpdatedAt = now, now
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "knowledge_base_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "created_by", "updated_at"}),
+	}).Create(grant).Error; err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, grant.TenantID, grant.KnowledgeBaseID, grant.UserID)
+}
+
+// Get gets the grant of a user on a knowledge base
+func (r *knowledgeBaseGrantRepository) Get(
+	ctx context.Context, tenantID uint64, knowledgeBaseID, userID string,
+) (*types.KnowledgeBaseGrant, error) {
+	var grant types.KnowledgeBaseGrant
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND user_id = ?", tenantID, knowledgeBaseID, userID).
+		First(&grant).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrKnowledgeBaseGrantNotFound
+		}
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// ListByKnowledgeBase lists the grants of a knowledge base
+func (r *knowledgeBaseGrantRepository) ListByKnowledgeBase(
+	ctx context.Context, tenantID uint64, knowledgeBaseID string,
+) ([]*types.KnowledgeBaseGrant, error) {
+	var grants []*types.KnowledgeBaseGrant
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, knowledgeBaseID).
+		Order("created_at ASC").
+		Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// Delete deletes the grant of a user on a knowledge base
+func (r *knowledgeBaseGrantRepository) Delete(
+	ctx context.Context, tenantID uint64, knowledgeBaseID, userID string,
+) error {
+	result := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND user_id = ?", tenantID, knowledgeBaseID, userID).
+		Delete(&types.KnowledgeBaseGrant{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrKnowledgeBaseGrantNotFound
+	}
+	return nil
+}
+
+// DeleteByUser deletes all grants of a user in a tenant
+func (r *knowledgeBaseGrantRepository) DeleteByUser(ctx context.Context, tenantID uint64, userID string) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Delete(&types.KnowledgeBaseGrant{}).Error
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// tokenUsageSummaryColumns sums the usage records of a group
+const tokenUsageSummaryColumns = "COUNT(*) AS calls, " +
+	"COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, " +
+	"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, " +
+	"COALESCE(SUM(total_tokens), 0) AS total_tokens"
+
+// tokenUsageRepository implements the TokenUsageRepository interface
+type tokenUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewTokenUsageRepository creates a new token usage repository
+func NewTokenUsageRepository(db *gorm.DB) interfaces.TokenUsageRepository {
+	return &tokenUsageRepository{db: db}
+}
+
+// Create creates a usage record
+func (r *tokenUsageRepository) Create(ctx context.Context, usage *types.TokenUsage) error {
+	return r.db.WithContext(ctx).Create(usage).Error
+}
+
+// SumSince sums the tokens used by a tenant since the given time
+func (r *tokenUsageRepository) SumSince(ctx context.Context, tenantID uint64, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&types.TokenUsage{}).
+		Select("COALESCE(SUM(total_tokens), 0)").
+		Where("tenant_id = ? AND created_at >= ?", tenantID, since).
+		Scan(&total).Error
+	return total, err
+}
+
+// Daily sums the usage of a tenant per day
+func (r *tokenUsageRepository) Daily(
+	ctx context.Context, tenantID uint64, query *types.TokenUsageQuery,
+) ([]*types.DailyTokenUsage, error) {
+	var daily []*types.DailyTokenUsage
+	err := r.filter(ctx, tenantID, query).
+		Select("to_char(created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS date, " + tokenUsageSummaryColumns).
+		Group("date").
+		Order("date ASC").
+		Scan(&daily).Error
+	return daily, err
+}
+
+// ByModel sums the usage of a tenant per model
+func (r *tokenUsageRepository) ByModel(
+	ctx context.Context, tenantID uint64, query *types.TokenUsageQuery,
+) ([]*types.ModelTokenUsage, error) {
+	var models []*types.ModelTokenUsage
+	err := r.filter(ctx, tenantID, query).
+		Select("model_id, model_type, " + tokenUsageSummaryColumns).
+		Group("model_id, model_type").
+		Order("total_tokens DESC").
+		Scan(&models).Error
+	return models, err
+}
+
+// filter selects the usage records of a tenant matching the query
+func (r *tokenUsageRepository) filter(ctx context.Context, tenantID uint64, query *types.TokenUsageQuery) *gorm.DB {
+	db := r.db.WithContext(ctx).Model(&types.TokenUsage{}).
+		Where("tenant_id = ? AND created_at >= ? AND created_at < ?", tenantID, query.From, query.To)
+	if query.ModelID != "" {
+		db = db.Where("model_id = ?", query.ModelID)
+	}
+	if query.SessionID != "" {
+		db = db.Where("session_id = ?", query.SessionID)
+	}
+	return db
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/agent"
 	"github.com/Tencent/WeKnora/internal/agent/tools"
@@ -77,6 +78,7 @@ func (s *agentService) CreateAgentEngine(
 	eventBus *event.EventBus,
 	contextManager interfaces.ContextManager,
 	sessionID string,
+	subAgentRunner interfaces.SubAgentRunner,
 ) (interfaces.AgentEngine, error) {
 	logger.Infof(ctx, "Creating agent engine with custom EventBus")
 
@@ -100,6 +102,17 @@ func (s *agentService) CreateAgentEngine(
 		return nil, fmt.Errorf("failed to register tools: %w", err)
 	}
 
+	// Register custom agents this agent may delegate to (not subject to AllowedTools)
+	if subAgentRunner != nil {
+		for _, subAgent := range config.SubAgents {
+			if subAgent == nil {
+				continue
+			}
+			toolRegistry.RegisterTool(tools.NewSubAgentTool(subAgent, subAgentRunner, eventBus, sessionID))
+			logger.Infof(ctx, "Registered sub-agent tool for agent: %s (%s)", subAgent.Name, subAgent.ID)
+		}
+	}
+
 	// Register MCP tools from enabled services for this tenant
 	tenantID := uint64(0)
 	if tid, ok := ctx.Value(types.TenantIDContextKey).(uint64); ok {
@@ -151,11 +164,17 @@ func (s *agentService) CreateAgentEngine(
 					} else {
 						logger.Infof(ctx, "Registered MCP tools from %d enabled services", len(enabledServices))
 					}
+					tools.RegisterMCPResourceTool(ctx, toolRegistry, enabledServices, s.mcpManager)
 				}
 			}
 		}
 	}
 
+	// Fetch the MCP prompts and resources pinned as context of the agent
+	if tenantID > 0 && s.mcpServiceService != nil && s.mcpManager != nil {
+		config.MCPContext = s.resolveMCPContext(ctx, tenantID, config)
+	}
+
 	// Get knowledge base detailed information for prompt
 	kbInfos, err := s.getKnowledgeBaseInfos(ctx, config.KnowledgeBases)
 	if err != nil {
@@ -200,6 +219,74 @@ func (s *agentService) CreateAgentEngine(
 	return engine, nil
 }
 
+// resolveMCPContext fetches the MCP prompts and resources pinned by the agent configuration.
+// Items that cannot be fetched are skipped so that an unavailable MCP service does not fail the agent.
+func (s *agentService) resolveMCPContext(
+	ctx context.Context,
+	tenantID uint64,
+	config *types.AgentConfig,
+) []*types.MCPContextItem {
+	if len(config.MCPPrompts) == 0 && len(config.MCPResources) == 0 {
+		return nil
+	}
+
+	serviceIDs := make([]string, 0, len(config.MCPPrompts)+len(config.MCPResources))
+	for _, ref := range config.MCPPrompts {
+		serviceIDs = append(serviceIDs, ref.ServiceID)
+	}
+	for _, ref := range config.MCPResources {
+		serviceIDs = append(serviceIDs, ref.ServiceID)
+	}
+	services, err := s.mcpServiceService.ListMCPServicesByIDs(ctx, tenantID, serviceIDs)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to list MCP services of pinned context: %v", err)
+		return nil
+	}
+	servicesByID := make(map[string]*types.MCPService, len(services))
+	for _, svc := range services {
+		if svc != nil && svc.Enabled {
+			servicesByID[svc.ID] = svc
+		}
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	items := make([]*types.MCPContextItem, 0, len(config.MCPPrompts)+len(config.MCPResources))
+	for _, ref := range config.MCPPrompts {
+		svc, ok := servicesByID[ref.ServiceID]
+		if !ok {
+			logger.Warnf(ctx, "MCP service %s of pinned prompt %s is not available", ref.ServiceID, ref.Name)
+			continue
+		}
+		content, err := tools.GetMCPPrompt(fetchCtx, s.mcpManager, svc, ref.Name, ref.Arguments)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to get MCP prompt %s from service %s: %v", ref.Name, svc.Name, err)
+			continue
+		}
+		items = append(items, &types.MCPContextItem{
+			Kind: types.MCPContextPrompt, ServiceName: svc.Name, Name: ref.Name, Content: content,
+		})
+	}
+	for _, ref := range config.MCPResources {
+		svc, ok := servicesByID[ref.ServiceID]
+		if !ok {
+			logger.Warnf(ctx, "MCP service %s of pinned resource %s is not available", ref.ServiceID, ref.URI)
+			continue
+		}
+		content, err := tools.ReadMCPResource(fetchCtx, s.mcpManager, svc, ref.URI)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to read MCP resource %s from service %s: %v", ref.URI, svc.Name, err)
+			continue
+		}
+		items = append(items, &types.MCPContextItem{
+			Kind: types.MCPContextResource, ServiceName: svc.Name, Name: ref.URI, Content: content,
+		})
+	}
+	logger.Infof(ctx, "Pinned %d MCP prompts and resources as agent context", len(items))
+	return items
+}
+
 // registerTools registers tools based on the agent configuration
 func (s *agentService) registerTools(
 	ctx context.Context,
@@ -274,6 +361,7 @@ func (s *agentService) registerTools(
 				rerankModel,
 				chatModel,
 				s.cfg,
+				config.FusionConfig,
 			)
 		case tools.ToolGrepChunks:
 			toolToRegister = tools.NewGrepChunksTool(s.db, config.KnowledgeBases, config.KnowledgeIDs)
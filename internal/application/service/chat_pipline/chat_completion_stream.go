@@ -102,8 +102,13 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 	go func() {
 		answerID := fmt.Sprintf("%s-answer", uuid.New().String()[:8])
 		var finalContent string
+		// The answering model differs from the configured one when the request failed over
+		modelID := chatModel.GetModelID()
 
 		for response := range responseChan {
+			if response.ModelID != "" {
+				modelID = response.ModelID
+			}
 			// Emit event for each answer chunk
 			if response.ResponseType == types.ResponseTypeAnswer {
 				finalContent += response.Content
@@ -114,6 +119,7 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 					Data: event.AgentFinalAnswerData{
 						Content: response.Content,
 						Done:    response.Done,
+						ModelID: modelID,
 					},
 				}); err != nil {
 					logger.Errorf(ctx, "Failed to emit answer event: %v", err)
@@ -2,6 +2,7 @@ package chatpipline
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Tencent/WeKnora/internal/types"
 )
@@ -77,6 +78,67 @@ func (e *EventManager) Trigger(ctx context.Context,
 	return nil
 }
 
+// HasHandler reports whether at least one registered plugin handles the event type
+func (e *EventManager) HasHandler(eventType types.EventType) bool {
+	_, ok := e.handlers[eventType]
+	return ok
+}
+
+// pipelinePhases orders the events a declarative pipeline may use. Each stage reads what the
+// stages of earlier phases produced, so the phases of a pipeline must never decrease.
+var pipelinePhases = map[types.EventType]int{
+	types.LOAD_HISTORY:           0,
+	types.REWRITE_QUERY:          0,
+	types.RESPONSE_CACHE:         0,
+	types.HYDE_GENERATE:          0,
+	types.CHUNK_SEARCH:           1,
+	types.CHUNK_SEARCH_PARALLEL:  1,
+	types.ENTITY_SEARCH:          1,
+	types.CHUNK_RERANK:           2,
+	types.CHUNK_MERGE:            2,
+	types.FILTER_TOP_K:           2,
+	types.DATA_ANALYSIS:          2,
+	types.INTO_CHAT_MESSAGE:      3,
+	types.CHAT_COMPLETION_STREAM: 4,
+	types.STREAM_FILTER:          5,
+}
+
+// ValidatePipeline checks a declarative pipeline against the registered plugins.
+// Every stage must be handled by a plugin, and the pipeline must search, build the chat message
+// and stream the answer in that order: search → … → into_chat_message → chat_completion_stream.
+func (e *EventManager) ValidatePipeline(stages []types.PipelineStage) error {
+	counts := make(map[types.EventType]int)
+	lastPhase := 0
+	var lastEvent types.EventType
+	for i, stage := range stages {
+		if err := stage.Validate(); err != nil {
+			return fmt.Errorf("pipeline stage %d: %w", i, err)
+		}
+		phase, ok := pipelinePhases[stage.Event]
+		if !ok {
+			return fmt.Errorf("pipeline stage %d: event %s cannot be used in a pipeline", i, stage.Event)
+		}
+		if !e.HasHandler(stage.Event) {
+			return fmt.Errorf("pipeline stage %d: no plugin handles event %s", i, stage.Event)
+		}
+		if phase < lastPhase {
+			return fmt.Errorf("pipeline stage %d: %s must run before %s", i, stage.Event, lastEvent)
+		}
+		lastPhase, lastEvent = phase, stage.Event
+		counts[stage.Event]++
+	}
+
+	if counts[types.CHUNK_SEARCH]+counts[types.CHUNK_SEARCH_PARALLEL] == 0 {
+		return fmt.Errorf("pipeline must contain a %s or %s stage", types.CHUNK_SEARCH, types.CHUNK_SEARCH_PARALLEL)
+	}
+	for _, event := range []types.EventType{types.INTO_CHAT_MESSAGE, types.CHAT_COMPLETION_STREAM} {
+		if counts[event] != 1 {
+			return fmt.Errorf("pipeline must contain exactly one %s stage", event)
+		}
+	}
+	return nil
+}
+
 // PluginError represents an error in plugin execution
 type PluginError struct {
 	Err         error  // Original error
@@ -122,6 +184,11 @@ var (
 		Description: "Failed to get conversation history",
 		ErrorType:   "get_history_failed",
 	}
+	// ErrResponseCacheHit stops the pipeline after the answer was served from the response cache
+	ErrResponseCacheHit = &PluginError{
+		Description: "Answered from response cache",
+		ErrorType:   "response_cache_hit",
+	}
 )
 
 // clone creates a copy of the PluginError
@@ -123,3 +123,170 @@ func TestTrigger(t *testing.T) {
 		}
 	})
 }
+
+func TestValidatePipeline(t *testing.T) {
+	manager := &EventManager{}
+	manager.Register(&testPlugin{
+		name: "registered",
+		events: []types.EventType{
+			types.CHUNK_SEARCH, types.ENTITY_SEARCH, types.FILTER_TOP_K, types.INTO_CHAT_MESSAGE,
+			types.CHAT_COMPLETION, types.CHAT_COMPLETION_STREAM, types.STREAM_FILTER,
+		},
+	})
+
+	tests := []struct {
+		name    string
+		stages  []types.PipelineStage
+		wantErr bool
+	}{
+		{
+			name: "skip rerank and run entity search",
+			stages: []types.PipelineStage{
+				{Event: types.CHUNK_SEARCH},
+				{Event: types.ENTITY_SEARCH},
+				{Event: types.INTO_CHAT_MESSAGE},
+				{Event: types.CHAT_COMPLETION_STREAM},
+				{Event: types.STREAM_FILTER},
+			},
+		},
+		{
+			name: "filter top k twice with parameters",
+			stages: []types.PipelineStage{
+				{Event: types.CHUNK_SEARCH, Params: map[string]interface{}{"embedding_top_k": float64(50)}},
+				{Event: types.FILTER_TOP_K, Params: map[string]interface{}{"top_k": float64(20)}},
+				{Event: types.FILTER_TOP_K, Params: map[string]interface{}{"top_k": 5}},
+				{Event: types.INTO_CHAT_MESSAGE},
+				{Event: types.CHAT_COMPLETION_STREAM},
+			},
+		},
+		{
+			name: "unregistered event",
+			stages: []types.PipelineStage{
+				{Event: types.CHUNK_SEARCH},
+				{Event: types.CHUNK_RERANK},
+				{Event: types.INTO_CHAT_MESSAGE},
+				{Event: types.CHAT_COMPLETION_STREAM},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing completion stage",
+			stages: []types.PipelineStage{
+				{Event: types.CHUNK_SEARCH},
+				{Event: types.INTO_CHAT_MESSAGE},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing search stage",
+			stages: []types.PipelineStage{
+				{Event: types.ENTITY_SEARCH},
+				{Event: types.INTO_CHAT_MESSAGE},
+				{Event: types.CHAT_COMPLETION_STREAM},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing chat message stage",
+			stages: []types.PipelineStage{
+				{Event: types.CHUNK_SEARCH},
+				{Event: types.CHAT_COMPLETION_STREAM},
+			},
+			wantErr: true,
+		},
+		{
+			name: "completion twice",
+			stages: []types.PipelineStage{
+				{Event: types.CHUNK_SEARCH},
+				{Event: types.INTO_CHAT_MESSAGE},
+				{Event: types.CHAT_COMPLETION_STREAM},
+				{Event: types.CHAT_COMPLETION_STREAM},
+			},
+			wantErr: true,
+		},
+		{
+			name: "filter before search",
+			stages: []types.PipelineStage{
+				{Event: types.FILTER_TOP_K},
+				{Event: types.CHUNK_SEARCH},
+				{Event: types.INTO_CHAT_MESSAGE},
+				{Event: types.CHAT_COMPLETION_STREAM},
+			},
+			wantErr: true,
+		},
+		{
+			name: "chat message after completion",
+			stages: []types.PipelineStage{
+				{Event: types.CHUNK_SEARCH},
+				{Event: types.CHAT_COMPLETION_STREAM},
+				{Event: types.INTO_CHAT_MESSAGE},
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-streaming completion",
+			stages: []types.PipelineStage{
+				{Event: types.CHUNK_SEARCH},
+				{Event: types.INTO_CHAT_MESSAGE},
+				{Event: types.CHAT_COMPLETION},
+				{Event: types.CHAT_COMPLETION_STREAM},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown parameter",
+			stages: []types.PipelineStage{
+				{Event: types.CHUNK_SEARCH},
+				{Event: types.FILTER_TOP_K, Params: map[string]interface{}{"limit": 3}},
+				{Event: types.INTO_CHAT_MESSAGE},
+				{Event: types.CHAT_COMPLETION_STREAM},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fractional top k",
+			stages: []types.PipelineStage{
+				{Event: types.CHUNK_SEARCH},
+				{Event: types.FILTER_TOP_K, Params: map[string]interface{}{"top_k": 2.5}},
+				{Event: types.INTO_CHAT_MESSAGE},
+				{Event: types.CHAT_COMPLETION_STREAM},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := manager.ValidatePipeline(tt.stages)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePipeline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPipelineStageApply(t *testing.T) {
+	chatManage := &types.ChatManage{RerankTopK: 10, VectorThreshold: 0.5, RerankModelID: "default"}
+	stage := types.PipelineStage{
+		Event: types.FILTER_TOP_K,
+		Params: map[string]interface{}{
+			"top_k":           float64(3),
+			"rerank_model_id": "other",
+		},
+	}
+
+	restore := stage.Apply(chatManage)
+	if chatManage.RerankTopK != 3 || chatManage.RerankModelID != "other" {
+		t.Fatalf("stage parameters not applied: top_k=%d, rerank_model_id=%s",
+			chatManage.RerankTopK, chatManage.RerankModelID)
+	}
+	if chatManage.VectorThreshold != 0.5 {
+		t.Errorf("unrelated field changed: vector_threshold=%f", chatManage.VectorThreshold)
+	}
+
+	restore()
+	if chatManage.RerankTopK != 10 || chatManage.RerankModelID != "default" {
+		t.Errorf("stage parameters not restored: top_k=%d, rerank_model_id=%s",
+			chatManage.RerankTopK, chatManage.RerankModelID)
+	}
+}
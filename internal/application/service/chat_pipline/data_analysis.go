@@ -88,7 +88,7 @@ func (p *PluginDataAnalysis) OnEvent(
 	}
 
 	// Ask LLM to generate SQL for data analysis
-	chatModel, err := p.modelService.GetChatModel(ctx, chatManage.ChatModelID)
+	chatModel, err := p.modelService.GetChatModelWithFallback(ctx, chatManage.ChatModelID, chatManage.ModelFallback)
 	if err != nil {
 		return ErrGetChatModel.WithError(err)
 	}
@@ -62,7 +62,7 @@ func (p *PluginExtractEntity) OnEvent(ctx context.Context,
 
 	query := chatManage.Query
 
-	model, err := p.modelService.GetChatModel(ctx, chatManage.ChatModelID)
+	model, err := p.modelService.GetChatModelWithFallback(ctx, chatManage.ChatModelID, chatManage.ModelFallback)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get model, session_id: %s, error: %v", chatManage.SessionID, err)
 		return next()
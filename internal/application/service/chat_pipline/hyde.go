@@ -0,0 +1,118 @@
+package chatpipline
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// Default prompt for generating the hypothetical answer passage
+const defaultHyDEPrompt = `请针对下面的问题写一段简洁、信息密集的说明性文字，就像它摘自一份能够回答该问题的文档。
+- 直接陈述事实，不要复述问题，不要使用"可能"、"我认为"等推测性措辞
+- 使用与问题相同的语言
+- 长度控制在200字以内
+- 只输出这段文字，不要添加标题或其他说明
+
+问题：{{query}}`
+
+// hydeMaxCompletionTokens bounds the length of the hypothetical passage
+const hydeMaxCompletionTokens = 300
+
+// PluginHyDE generates a hypothetical answer passage (HyDE) with the chat model.
+// The passage is embedded by the search stage in addition to, or instead of, the query,
+// which helps short or vague questions that embed poorly.
+type PluginHyDE struct {
+	modelService interfaces.ModelService
+	config       *config.Config
+}
+
+// NewPluginHyDE creates a new HyDE plugin instance and registers it with the event manager
+func NewPluginHyDE(eventManager *EventManager,
+	modelService interfaces.ModelService, config *config.Config,
+) *PluginHyDE {
+	res := &PluginHyDE{
+		modelService: modelService,
+		config:       config,
+	}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the event types this plugin handles
+func (p *PluginHyDE) ActivationEvents() []types.EventType {
+	return []types.EventType{types.HYDE_GENERATE}
+}
+
+// OnEvent generates the hypothetical document for the (rewritten) query.
+// Failures are logged and the pipeline continues with plain query retrieval.
+func (p *PluginHyDE) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	chatManage.HypotheticalDoc = ""
+	if !chatManage.EnableHyDE {
+		pipelineInfo(ctx, "HyDE", "skip", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"reason":     "hyde_disabled",
+		})
+		return next()
+	}
+
+	query := strings.TrimSpace(chatManage.RewriteQuery)
+	if query == "" {
+		query = strings.TrimSpace(chatManage.Query)
+	}
+	if query == "" {
+		return next()
+	}
+
+	pipelineInfo(ctx, "HyDE", "input", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"query":      query,
+		"mode":       chatManage.HyDEMode,
+	})
+
+	prompt := p.config.Conversation.HyDEPrompt
+	if prompt == "" {
+		prompt = defaultHyDEPrompt
+	}
+
+	chatModel, err := p.modelService.GetChatModelWithFallback(ctx, chatManage.ChatModelID, chatManage.ModelFallback)
+	if err != nil {
+		pipelineError(ctx, "HyDE", "get_model", map[string]interface{}{
+			"session_id":    chatManage.SessionID,
+			"chat_model_id": chatManage.ChatModelID,
+			"error":         err.Error(),
+		})
+		return next()
+	}
+
+	thinking := false
+	response, err := chatModel.Chat(ctx, []chat.Message{
+		{
+			Role:    "user",
+			Content: strings.ReplaceAll(prompt, "{{query}}", query),
+		},
+	}, &chat.ChatOptions{
+		Temperature:         0.3,
+		MaxCompletionTokens: hydeMaxCompletionTokens,
+		Thinking:            &thinking,
+	})
+	if err != nil {
+		pipelineError(ctx, "HyDE", "model_call", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+
+	chatManage.HypotheticalDoc = strings.TrimSpace(reg.ReplaceAllString(response.Content, ""))
+	pipelineInfo(ctx, "HyDE", "output", map[string]interface{}{
+		"session_id":       chatManage.SessionID,
+		"hypothetical_doc": chatManage.HypotheticalDoc,
+	})
+	return next()
+}
@@ -0,0 +1,147 @@
+package chatpipline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// hydeChat answers with a fixed passage and records the prompts it receives
+type hydeChat struct {
+	answer  string
+	err     error
+	prompts []string
+}
+
+func (c *hydeChat) Chat(ctx context.Context,
+	messages []chat.Message, opts *chat.ChatOptions,
+) (*types.ChatResponse, error) {
+	c.prompts = append(c.prompts, messages[len(messages)-1].Content)
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &types.ChatResponse{Content: c.answer}, nil
+}
+
+func (c *hydeChat) ChatStream(ctx context.Context,
+	messages []chat.Message, opts *chat.ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *hydeChat) GetModelName() string { return "hyde" }
+func (c *hydeChat) GetModelID() string   { return "hyde" }
+
+type hydeModelService struct {
+	interfaces.ModelService
+	chat *hydeChat
+	err  error
+}
+
+func (s *hydeModelService) GetChatModelWithFallback(ctx context.Context,
+	modelID string, fallback *types.ModelFallback,
+) (chat.Chat, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.chat, nil
+}
+
+func TestPluginHyDE(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		query       string
+		rewrite     string
+		answer      string
+		chatErr     error
+		modelErr    error
+		wantDoc     string
+		wantPrompts int
+		wantInQuery string
+	}{
+		{
+			name:        "enabled",
+			enabled:     true,
+			query:       "what is weknora",
+			answer:      "  WeKnora is a document understanding and retrieval framework.  ",
+			wantDoc:     "WeKnora is a document understanding and retrieval framework.",
+			wantPrompts: 1,
+			wantInQuery: "what is weknora",
+		},
+		{
+			name:        "enabled with rewritten query and thinking output",
+			enabled:     true,
+			query:       "and its license?",
+			rewrite:     "what is the license of weknora",
+			answer:      "<think>the user asks about the license</think>WeKnora is released under the MIT license.",
+			wantDoc:     "WeKnora is released under the MIT license.",
+			wantPrompts: 1,
+			wantInQuery: "what is the license of weknora",
+		},
+		{
+			name:    "disabled",
+			query:   "what is weknora",
+			answer:  "unused",
+			wantDoc: "",
+		},
+		{
+			name:        "generation fails",
+			enabled:     true,
+			query:       "what is weknora",
+			chatErr:     errors.New("model is overloaded"),
+			wantDoc:     "",
+			wantPrompts: 1,
+		},
+		{
+			name:     "model not available",
+			enabled:  true,
+			query:    "what is weknora",
+			modelErr: errors.New("model not found"),
+			wantDoc:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chatModel := &hydeChat{answer: tt.answer, err: tt.chatErr}
+			plugin := NewPluginHyDE(&EventManager{},
+				&hydeModelService{chat: chatModel, err: tt.modelErr},
+				&config.Config{Conversation: &config.ConversationConfig{}},
+			)
+			chatManage := &types.ChatManage{
+				EnableHyDE:      tt.enabled,
+				Query:           tt.query,
+				RewriteQuery:    tt.rewrite,
+				HypotheticalDoc: "stale passage of the previous turn",
+			}
+
+			nextCalled := false
+			err := plugin.OnEvent(context.Background(), types.HYDE_GENERATE, chatManage, func() *PluginError {
+				nextCalled = true
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("OnEvent returned %v, want the pipeline to continue", err)
+			}
+			if !nextCalled {
+				t.Error("next stage was not called")
+			}
+			if chatManage.HypotheticalDoc != tt.wantDoc {
+				t.Errorf("hypothetical doc = %q, want %q", chatManage.HypotheticalDoc, tt.wantDoc)
+			}
+			if len(chatModel.prompts) != tt.wantPrompts {
+				t.Fatalf("model called %d times, want %d", len(chatModel.prompts), tt.wantPrompts)
+			}
+			if tt.wantInQuery != "" && !strings.Contains(chatModel.prompts[0], tt.wantInQuery) {
+				t.Errorf("prompt %q does not contain the query %q", chatModel.prompts[0], tt.wantInQuery)
+			}
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package chatpipline
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// PluginResponseCache answers repeated questions from the semantic response cache.
+// On a hit it replays the cached answer and references and stops the pipeline; on a miss
+// it captures the streamed answer and caches it once the stream is done.
+type PluginResponseCache struct {
+	modelService         interfaces.ModelService
+	knowledgeBaseService interfaces.KnowledgeBaseService
+	responseCache        interfaces.ResponseCacheService
+}
+
+// NewPluginResponseCache creates a new response cache plugin instance and registers it with the event manager
+func NewPluginResponseCache(eventManager *EventManager,
+	modelService interfaces.ModelService,
+	knowledgeBaseService interfaces.KnowledgeBaseService,
+	responseCache interfaces.ResponseCacheService,
+) *PluginResponseCache {
+	res := &PluginResponseCache{
+		modelService:         modelService,
+		knowledgeBaseService: knowledgeBaseService,
+		responseCache:        responseCache,
+	}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the event types this plugin handles
+func (p *PluginResponseCache) ActivationEvents() []types.EventType {
+	return []types.EventType{types.RESPONSE_CACHE}
+}
+
+// OnEvent looks up the (rewritten) query in the response cache.
+// Failures are logged and the pipeline continues without the cache.
+func (p *PluginResponseCache) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	if reason := p.skipReason(chatManage); reason != "" {
+		pipelineInfo(ctx, "ResponseCache", "skip", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"reason":     reason,
+		})
+		return next()
+	}
+
+	kbIDs := chatManage.SearchTargets.GetAllKnowledgeBaseIDs()
+	kb, err := p.knowledgeBaseService.GetKnowledgeBaseByID(ctx, kbIDs[0])
+	if err != nil {
+		pipelineWarn(ctx, "ResponseCache", "get_knowledge_base", map[string]interface{}{
+			"knowledge_base_id": kbIDs[0],
+			"error":             err.Error(),
+		})
+		return next()
+	}
+
+	scopeKey, err := p.responseCache.ScopeKey(ctx, &types.ResponseCacheScope{
+		TenantID:         chatManage.TenantID,
+		KnowledgeBaseIDs: kbIDs,
+		KnowledgeIDs:     chatManage.KnowledgeIDs,
+		EmbeddingModelID: kb.EmbeddingModelID,
+		ConfigHash:       responseCacheConfigHash(chatManage),
+	})
+	if err != nil {
+		pipelineWarn(ctx, "ResponseCache", "scope_key", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return next()
+	}
+
+	embedder, err := p.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+	if err != nil {
+		pipelineWarn(ctx, "ResponseCache", "get_embedding_model", map[string]interface{}{
+			"embedding_model_id": kb.EmbeddingModelID,
+			"error":              err.Error(),
+		})
+		return next()
+	}
+	query := chatManage.RewriteQuery
+	vector, err := embedder.Embed(ctx, query)
+	if err != nil {
+		pipelineWarn(ctx, "ResponseCache", "embed_query", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return next()
+	}
+
+	entry, score, err := p.responseCache.Lookup(ctx, scopeKey, vector)
+	if err != nil {
+		pipelineWarn(ctx, "ResponseCache", "lookup", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return next()
+	}
+	if entry != nil {
+		pipelineInfo(ctx, "ResponseCache", "hit", map[string]interface{}{
+			"session_id":   chatManage.SessionID,
+			"query":        query,
+			"cached_query": entry.Query,
+			"similarity":   fmt.Sprintf("%.4f", score),
+			"references":   len(entry.References),
+		})
+		p.replay(ctx, chatManage, entry)
+		return ErrResponseCacheHit
+	}
+
+	pipelineInfo(ctx, "ResponseCache", "miss", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"query":      query,
+	})
+	p.captureAnswer(chatManage, scopeKey, query, vector)
+	return next()
+}
+
+// skipReason returns why the request cannot be served from the cache, empty when it can
+func (p *PluginResponseCache) skipReason(chatManage *types.ChatManage) string {
+	switch {
+	case !p.responseCache.Enabled():
+		return "cache_disabled"
+	case chatManage.EventBus == nil:
+		return "eventbus_missing"
+	case chatManage.WebSearchEnabled:
+		// Web results change independently of the knowledge bases, so they cannot be invalidated
+		return "web_search_enabled"
+	case len(chatManage.History) > 0:
+		// Follow-up questions are answered with the conversation in the prompt
+		return "has_history"
+	case len(chatManage.SearchTargets.GetAllKnowledgeBaseIDs()) == 0:
+		return "no_knowledge_base"
+	}
+	return ""
+}
+
+// replay sets the cached references and emits the cached answer
+func (p *PluginResponseCache) replay(ctx context.Context,
+	chatManage *types.ChatManage, entry *types.ResponseCacheEntry,
+) {
+	chatManage.MergeResult = entry.References
+	chatManage.ChatResponse = &types.ChatResponse{Content: entry.Answer, ModelID: entry.ModelID}
+
+	if err := chatManage.EventBus.Emit(ctx, types.Event{
+		ID:        fmt.Sprintf("%s-cache", uuid.New().String()[:8]),
+		Type:      types.EventType(event.EventAgentFinalAnswer),
+		SessionID: chatManage.SessionID,
+		Data: event.AgentFinalAnswerData{
+			Content: entry.Answer,
+			Done:    true,
+			ModelID: entry.ModelID,
+		},
+	}); err != nil {
+		pipelineError(ctx, "ResponseCache", "emit_answer", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
+
+// captureAnswer accumulates the streamed answer and caches it with the references once it is done.
+// Fallback answers and answers without references are not cached.
+func (p *PluginResponseCache) captureAnswer(chatManage *types.ChatManage,
+	scopeKey string, query string, vector []float32,
+) {
+	var answer strings.Builder
+	var modelID string
+	stored := false
+	onAnswer := func(ctx context.Context, evt types.Event) error {
+		data, ok := evt.Data.(event.AgentFinalAnswerData)
+		if !ok || stored {
+			return nil
+		}
+		answer.WriteString(data.Content)
+		if data.ModelID != "" {
+			modelID = data.ModelID
+		}
+		if !data.Done {
+			return nil
+		}
+		stored = true
+
+		content := answer.String()
+		if strings.TrimSpace(content) == "" || content == chatManage.FallbackResponse ||
+			len(chatManage.MergeResult) == 0 {
+			return nil
+		}
+		references := make([]*types.SearchResult, len(chatManage.MergeResult))
+		copy(references, chatManage.MergeResult)
+
+		// The answer is complete, a request canceled now must not lose it
+		if err := p.responseCache.Store(context.WithoutCancel(ctx), scopeKey, &types.ResponseCacheEntry{
+			Query:      query,
+			Embedding:  vector,
+			Answer:     content,
+			References: references,
+			ModelID:    modelID,
+		}); err != nil {
+			pipelineWarn(ctx, "ResponseCache", "store", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return nil
+		}
+		pipelineInfo(ctx, "ResponseCache", "stored", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"query":      query,
+			"references": len(references),
+		})
+		return nil
+	}
+	chatManage.EventBus.On(types.EventType(event.EventAgentFinalAnswer), onAnswer)
+}
+
+// responseCacheConfigHash hashes the settings that shape the answer, so cached answers are only
+// shared between requests answered the same way
+func responseCacheConfigHash(chatManage *types.ChatManage) string {
+	data, _ := json.Marshal(struct {
+		AgentConfigHash  string                 `json:"agent_config_hash"`
+		ChatModelID      string                 `json:"chat_model_id"`
+		SummaryConfig    types.SummaryConfig    `json:"summary_config"`
+		VectorThreshold  float64                `json:"vector_threshold"`
+		KeywordThreshold float64                `json:"keyword_threshold"`
+		EmbeddingTopK    int                    `json:"embedding_top_k"`
+		FusionConfig     *types.FusionConfig    `json:"fusion_config"`
+		RetrieveFilter   *types.RetrieveFilter  `json:"retrieve_filter"`
+		RerankModelID    string                 `json:"rerank_model_id"`
+		RerankTopK       int                    `json:"rerank_top_k"`
+		RerankThreshold  float64                `json:"rerank_threshold"`
+		EnableHyDE       bool                   `json:"enable_hyde"`
+		HyDEMode         types.HyDEMode         `json:"hyde_mode"`
+		FallbackStrategy types.FallbackStrategy `json:"fallback_strategy"`
+		FAQPriority      bool                   `json:"faq_priority"`
+		FAQThreshold     float64                `json:"faq_threshold"`
+		FAQScoreBoost    float64                `json:"faq_score_boost"`
+	}{
+		AgentConfigHash:  chatManage.AgentConfigHash,
+		ChatModelID:      chatManage.ChatModelID,
+		SummaryConfig:    chatManage.SummaryConfig,
+		VectorThreshold:  chatManage.VectorThreshold,
+		KeywordThreshold: chatManage.KeywordThreshold,
+		EmbeddingTopK:    chatManage.EmbeddingTopK,
+		FusionConfig:     chatManage.FusionConfig,
+		RetrieveFilter:   chatManage.RetrieveFilter,
+		RerankModelID:    chatManage.RerankModelID,
+		RerankTopK:       chatManage.RerankTopK,
+		RerankThreshold:  chatManage.RerankThreshold,
+		EnableHyDE:       chatManage.EnableHyDE,
+		HyDEMode:         chatManage.HyDEMode,
+		FallbackStrategy: chatManage.FallbackStrategy,
+		FAQPriority:      chatManage.FAQPriorityEnabled,
+		FAQThreshold:     chatManage.FAQDirectAnswerThreshold,
+		FAQScoreBoost:    chatManage.FAQScoreBoost,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
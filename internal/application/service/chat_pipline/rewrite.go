@@ -164,7 +164,7 @@ func (p *PluginRewrite) OnEvent(ctx context.Context,
 	systemContent = strings.ReplaceAll(systemContent, "{{current_time}}", currentTime)
 	systemContent = strings.ReplaceAll(systemContent, "{{yesterday}}", yesterday)
 
-	rewriteModel, err := p.modelService.GetChatModel(ctx, chatManage.ChatModelID)
+	rewriteModel, err := p.modelService.GetChatModelWithFallback(ctx, chatManage.ChatModelID, chatManage.ModelFallback)
 	if err != nil {
 		pipelineError(ctx, "Rewrite", "get_model", map[string]interface{}{
 			"session_id":    chatManage.SessionID,
@@ -169,6 +169,8 @@ func (p *PluginSearch) OnEvent(ctx context.Context,
 							MatchCount:           expTopK,
 							DisableVectorMatch:   true,
 							DisableKeywordsMatch: false,
+							Fusion:               chatManage.FusionConfig,
+							Filter:               chatManage.RetrieveFilter,
 						}
 						// Apply knowledge ID filter if this is a partial KB search
 						if t.Type == types.SearchTargetTypeKnowledge {
@@ -368,6 +370,13 @@ func (p *PluginSearch) searchByTargets(
 				VectorThreshold:  chatManage.VectorThreshold,
 				KeywordThreshold: chatManage.KeywordThreshold,
 				MatchCount:       chatManage.EmbeddingTopK,
+				Fusion:           chatManage.FusionConfig,
+				Filter:           chatManage.RetrieveFilter,
+			}
+			// Add the HyDE passage as an extra (or replacement) vector query
+			if chatManage.HypotheticalDoc != "" {
+				params.HypotheticalDoc = chatManage.HypotheticalDoc
+				params.HypotheticalOnly = chatManage.HyDEMode == types.HyDEModeReplace
 			}
 			// Apply knowledge ID filter if this is a partial KB search
 			if t.Type == types.SearchTargetTypeKnowledge {
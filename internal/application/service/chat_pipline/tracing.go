@@ -35,6 +35,7 @@ func (p *PluginTracing) ActivationEvents() []types.EventType {
 		types.FILTER_TOP_K,
 		types.REWRITE_QUERY,
 		types.CHUNK_SEARCH_PARALLEL,
+		types.HYDE_GENERATE,
 	}
 }
 
@@ -71,6 +72,8 @@ func (p *PluginTracing) OnEvent(ctx context.Context,
 		return p.RewriteQuery(ctx, eventType, chatManage, next)
 	case types.CHUNK_SEARCH_PARALLEL:
 		return p.SearchParallel(ctx, eventType, chatManage, next)
+	case types.HYDE_GENERATE:
+		return p.HyDE(ctx, eventType, chatManage, next)
 	}
 	return next()
 }
@@ -264,6 +267,24 @@ func (p *PluginTracing) RewriteQuery(ctx context.Context,
 	return err
 }
 
+// HyDE traces hypothetical document generation, exposing the generated passage
+func (p *PluginTracing) HyDE(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	_, span := tracing.ContextWithSpan(ctx, "PluginTracing.HyDE")
+	defer span.End()
+	span.SetAttributes(
+		attribute.String("rewrite_query", chatManage.RewriteQuery),
+		attribute.Bool("enable_hyde", chatManage.EnableHyDE),
+		attribute.String("hyde_mode", string(chatManage.HyDEMode)),
+	)
+	err := next()
+	span.SetAttributes(
+		attribute.String("hypothetical_doc", chatManage.HypotheticalDoc),
+	)
+	return err
+}
+
 // SearchParallel traces parallel search operations (chunk + entity)
 func (p *PluginTracing) SearchParallel(ctx context.Context,
 	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
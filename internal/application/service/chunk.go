@@ -19,8 +19,10 @@ import (
 type chunkService struct {
 	chunkRepository interfaces.ChunkRepository // Repository for chunk data persistence
 	kbRepository    interfaces.KnowledgeBaseRepository
+	knowledgeRepo   interfaces.KnowledgeRepository
 	modelService    interfaces.ModelService
 	retrieveEngine  interfaces.RetrieveEngineRegistry
+	responseCache   interfaces.ResponseCacheService
 }
 
 // NewChunkService creates a new chunk service
@@ -33,14 +35,18 @@ type chunkService struct {
 func NewChunkService(
 	chunkRepository interfaces.ChunkRepository,
 	kbRepository interfaces.KnowledgeBaseRepository,
+	knowledgeRepo interfaces.KnowledgeRepository,
 	modelService interfaces.ModelService,
 	retrieveEngine interfaces.RetrieveEngineRegistry,
+	responseCache interfaces.ResponseCacheService,
 ) interfaces.ChunkService {
 	return &chunkService{
 		chunkRepository: chunkRepository,
 		kbRepository:    kbRepository,
+		knowledgeRepo:   knowledgeRepo,
 		modelService:    modelService,
 		retrieveEngine:  retrieveEngine,
+		responseCache:   responseCache,
 	}
 }
 
@@ -189,6 +195,7 @@ func (s *chunkService) UpdateChunk(ctx context.Context, chunk *types.Chunk) erro
 		})
 		return err
 	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, chunk.KnowledgeBaseID)
 
 	logger.Info(ctx, "Chunk updated successfully")
 	return nil
@@ -209,6 +216,7 @@ func (s *chunkService) UpdateChunks(ctx context.Context, chunks []*types.Chunk)
 		})
 		return err
 	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, chunkKnowledgeBaseIDs(chunks)...)
 
 	logger.Infof(ctx, "Successfully updated %d chunks", len(chunks))
 	return nil
@@ -224,6 +232,8 @@ func (s *chunkService) UpdateChunks(ctx context.Context, chunks []*types.Chunk)
 //   - error: Any error encountered during deletion
 func (s *chunkService) DeleteChunk(ctx context.Context, id string) error {
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	// Look up the knowledge base first, its cached answers are invalidated after the deletion
+	chunk, lookupErr := s.chunkRepository.GetChunkByID(ctx, tenantID, id)
 	err := s.chunkRepository.DeleteChunk(ctx, tenantID, id)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
@@ -231,6 +241,9 @@ func (s *chunkService) DeleteChunk(ctx context.Context, id string) error {
 		})
 		return err
 	}
+	if lookupErr == nil {
+		s.responseCache.InvalidateKnowledgeBases(ctx, chunk.KnowledgeBaseID)
+	}
 	logger.Info(ctx, "Chunk deleted successfully")
 	return nil
 }
@@ -253,6 +266,8 @@ func (s *chunkService) DeleteChunks(ctx context.Context, ids []string) error {
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Tenant ID: %d", tenantID)
 
+	// Look up the knowledge bases first, their cached answers are invalidated after the deletion
+	chunks, lookupErr := s.chunkRepository.ListChunksByID(ctx, tenantID, ids)
 	err := s.chunkRepository.DeleteChunks(ctx, tenantID, ids)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
@@ -261,6 +276,9 @@ func (s *chunkService) DeleteChunks(ctx context.Context, ids []string) error {
 		})
 		return err
 	}
+	if lookupErr == nil {
+		s.responseCache.InvalidateKnowledgeBases(ctx, chunkKnowledgeBaseIDs(chunks)...)
+	}
 
 	logger.Infof(ctx, "Successfully deleted %d chunks", len(ids))
 	return nil
@@ -289,6 +307,9 @@ func (s *chunkService) DeleteChunksByKnowledgeID(ctx context.Context, knowledgeI
 		})
 		return err
 	}
+	if knowledge, err := s.knowledgeRepo.GetKnowledgeByID(ctx, tenantID, knowledgeID); err == nil {
+		s.responseCache.InvalidateKnowledgeBases(ctx, knowledge.KnowledgeBaseID)
+	}
 
 	logger.Info(ctx, "All chunks under knowledge deleted successfully")
 	return nil
@@ -309,6 +330,13 @@ func (s *chunkService) DeleteByKnowledgeList(ctx context.Context, ids []string)
 		})
 		return err
 	}
+	if knowledgeList, err := s.knowledgeRepo.GetKnowledgeBatch(ctx, tenantID, ids); err == nil {
+		kbIDs := make([]string, 0, len(knowledgeList))
+		for _, knowledge := range knowledgeList {
+			kbIDs = append(kbIDs, knowledge.KnowledgeBaseID)
+		}
+		s.responseCache.InvalidateKnowledgeBases(ctx, kbIDs...)
+	}
 
 	logger.Info(ctx, "All chunks under knowledge deleted successfully")
 	return nil
@@ -434,7 +462,17 @@ func (s *chunkService) DeleteGeneratedQuestion(ctx context.Context, chunkID stri
 		})
 		return fmt.Errorf("failed to update chunk: %w", err)
 	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, chunk.KnowledgeBaseID)
 
 	logger.Infof(ctx, "Successfully deleted generated question %s from chunk %s", questionID, chunkID)
 	return nil
 }
+
+// chunkKnowledgeBaseIDs returns the knowledge bases of the chunks, for invalidating their cached answers
+func chunkKnowledgeBaseIDs(chunks []*types.Chunk) []string {
+	kbIDs := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		kbIDs = append(kbIDs, chunk.KnowledgeBaseID)
+	}
+	return kbIDs
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type cacheChunkRepo struct {
+	interfaces.ChunkRepository
+	chunks []*types.Chunk
+}
+
+func (r *cacheChunkRepo) UpdateChunks(ctx context.Context, chunks []*types.Chunk) error {
+	return nil
+}
+
+func (r *cacheChunkRepo) ListChunksByID(ctx context.Context, tenantID uint64, ids []string) ([]*types.Chunk, error) {
+	return r.chunks, nil
+}
+
+func (r *cacheChunkRepo) DeleteChunks(ctx context.Context, tenantID uint64, ids []string) error {
+	return nil
+}
+
+// recordingResponseCache records the knowledge bases whose cached answers were invalidated
+type recordingResponseCache struct {
+	interfaces.ResponseCacheService
+	invalidated []string
+}
+
+func (c *recordingResponseCache) InvalidateKnowledgeBases(ctx context.Context, kbIDs ...string) {
+	c.invalidated = append(c.invalidated, kbIDs...)
+}
+
+func TestChunkWritesInvalidateResponseCache(t *testing.T) {
+	chunks := []*types.Chunk{{ID: "c1", KnowledgeBaseID: "kb1"}, {ID: "c2", KnowledgeBaseID: "kb2"}}
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+
+	tests := []struct {
+		name  string
+		write func(svc interfaces.ChunkService) error
+	}{
+		{"update chunks", func(svc interfaces.ChunkService) error { return svc.UpdateChunks(ctx, chunks) }},
+		{"delete chunks", func(svc interfaces.ChunkService) error { return svc.DeleteChunks(ctx, []string{"c1", "c2"}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := &recordingResponseCache{}
+			svc := NewChunkService(&cacheChunkRepo{chunks: chunks}, nil, nil, nil, nil, cache)
+			if err := tt.write(svc); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			slices.Sort(cache.invalidated)
+			if !slices.Equal(cache.invalidated, []string{"kb1", "kb2"}) {
+				t.Errorf("invalidated %v, want [kb1 kb2]", cache.invalidated)
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/application/repository"
+	chatpipline "github.com/Tencent/WeKnora/internal/application/service/chat_pipline"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -23,16 +25,112 @@ var (
 
 // customAgentService implements the CustomAgentService interface
 type customAgentService struct {
-	repo interfaces.CustomAgentRepository
+	repo         interfaces.CustomAgentRepository
+	eventManager *chatpipline.EventManager
 }
 
 // NewCustomAgentService creates a new custom agent service
-func NewCustomAgentService(repo interfaces.CustomAgentRepository) interfaces.CustomAgentService {
+func NewCustomAgentService(repo interfaces.CustomAgentRepository,
+	eventManager *chatpipline.EventManager,
+) interfaces.CustomAgentService {
 	return &customAgentService{
-		repo: repo,
+		repo:         repo,
+		eventManager: eventManager,
 	}
 }
 
+// validateConfig checks the retrieval settings, the declarative pipeline against the registered chat plugins
+// and the sub-agents the agent may delegate to
+func (s *customAgentService) validateConfig(ctx context.Context, agent *types.CustomAgent) error {
+	config := &agent.Config
+	switch types.HyDEMode(config.HyDEMode) {
+	case "", types.HyDEModeAppend, types.HyDEModeReplace:
+	default:
+		return werrors.NewBadRequestError("unsupported hyde_mode: " + config.HyDEMode)
+	}
+	if err := s.validateSubAgents(ctx, agent); err != nil {
+		return err
+	}
+	if config.ToolApproval != nil {
+		for _, pattern := range config.ToolApproval.Tools {
+			if strings.TrimSpace(pattern) == "" {
+				return werrors.NewBadRequestError("tool_approval tools must be non-empty tool names or prefixes")
+			}
+		}
+	}
+	if policy := config.ToolExecution; policy != nil {
+		if policy.MaxConcurrency < 0 || policy.Timeout < 0 {
+			return werrors.NewBadRequestError("tool_execution max_concurrency and timeout must not be negative")
+		}
+		for name, seconds := range policy.ToolTimeouts {
+			if strings.TrimSpace(name) == "" || seconds <= 0 {
+				return werrors.NewBadRequestError("tool_execution tool_timeouts must map tool names to positive seconds")
+			}
+		}
+	}
+	for _, ref := range config.MCPPrompts {
+		if ref == nil || ref.ServiceID == "" || strings.TrimSpace(ref.Name) == "" {
+			return werrors.NewBadRequestError("mcp_prompts entries require service_id and name")
+		}
+	}
+	for _, ref := range config.MCPResources {
+		if ref == nil || ref.ServiceID == "" || strings.TrimSpace(ref.URI) == "" {
+			return werrors.NewBadRequestError("mcp_resources entries require service_id and uri")
+		}
+	}
+	if len(config.Pipeline) == 0 {
+		return nil
+	}
+	if err := s.eventManager.ValidatePipeline(config.Pipeline); err != nil {
+		return werrors.NewBadRequestError("invalid pipeline: " + err.Error())
+	}
+	return nil
+}
+
+// checkPipeline records on the agent whether its declared pipeline still validates,
+// e.g. after a chat plugin it uses was removed, in which case conversations fall back to the built-in pipeline
+func (s *customAgentService) checkPipeline(ctx context.Context, agent *types.CustomAgent) {
+	if len(agent.Config.Pipeline) == 0 {
+		return
+	}
+	if err := s.eventManager.ValidatePipeline(agent.Config.Pipeline); err != nil {
+		logger.Warnf(ctx, "Pipeline of agent %s is invalid, the built-in pipeline is used: %v", agent.ID, err)
+		agent.PipelineError = err.Error()
+	}
+}
+
+// validateSubAgents checks that sub-agents are only set on agent mode agents
+// and reference other existing agent mode agents
+func (s *customAgentService) validateSubAgents(ctx context.Context, agent *types.CustomAgent) error {
+	if len(agent.Config.SubAgents) == 0 {
+		return nil
+	}
+	if !agent.IsAgentMode() {
+		return werrors.NewBadRequestError("sub_agents are only supported in smart reasoning mode")
+	}
+	seen := make(map[string]bool, len(agent.Config.SubAgents))
+	for _, subAgentID := range agent.Config.SubAgents {
+		if subAgentID == "" || seen[subAgentID] {
+			return werrors.NewBadRequestError("sub_agents must be unique non-empty agent IDs")
+		}
+		seen[subAgentID] = true
+		if subAgentID == agent.ID {
+			return werrors.NewBadRequestError("an agent cannot be its own sub-agent")
+		}
+		subAgent, err := s.GetAgentByID(ctx, subAgentID)
+		if err != nil {
+			if errors.Is(err, ErrAgentNotFound) {
+				return werrors.NewBadRequestError("sub-agent not found: " + subAgentID)
+			}
+			return err
+		}
+		if !subAgent.IsAgentMode() {
+			return werrors.NewBadRequestError("sub-agent " + subAgentID + " is not in smart reasoning mode")
+		}
+	}
+	return nil
+}
+
 // CreateAgent creates a new custom agent
 func (s *customAgentService) CreateAgent(ctx context.Context, agent *types.CustomAgent) (*types.CustomAgent, error) {
 	// Validate required fields
@@ -40,6 +138,10 @@ func (s *customAgentService) CreateAgent(ctx context.Context, agent *types.Custo
 		return nil, ErrAgentNameRequired
 	}
 
+	if err := s.validateConfig(ctx, agent); err != nil {
+		return nil, err
+	}
+
 	// Generate UUID and set creation timestamps
 	if agent.ID == "" {
 		agent.ID = uuid.New().String()
@@ -101,6 +203,7 @@ func (s *customAgentService) GetAgentByID(ctx context.Context, id string) (*type
 		agent, err := s.repo.GetAgentByID(ctx, id, tenantID)
 		if err == nil {
 			// Found in database, return with customized config
+			s.checkPipeline(ctx, agent)
 			return agent, nil
 		}
 		// Not in database, return default built-in agent from registry
@@ -121,6 +224,7 @@ func (s *customAgentService) GetAgentByID(ctx context.Context, id string) (*type
 		return nil, err
 	}
 
+	s.checkPipeline(ctx, agent)
 	return agent, nil
 }
 
@@ -177,6 +281,10 @@ func (s *customAgentService) ListAgents(ctx context.Context) ([]*types.CustomAge
 		}
 	}
 
+	for _, agent := range result {
+		s.checkPipeline(ctx, agent)
+	}
+
 	return result, nil
 }
 
@@ -193,6 +301,10 @@ func (s *customAgentService) UpdateAgent(ctx context.Context, agent *types.Custo
 		return nil, ErrInvalidTenantID
 	}
 
+	if err := s.validateConfig(ctx, agent); err != nil {
+		return nil, err
+	}
+
 	// Handle built-in agents specially using registry
 	if types.IsBuiltinAgentID(agent.ID) {
 		return s.updateBuiltinAgent(ctx, agent, tenantID)
@@ -299,7 +299,7 @@ func (s *DataTableSummaryService) Handle(ctx context.Context, t *asynq.Task) err
 	}
 
 	// 4. 索引到向量数据库
-	if err := s.indexToVectorDB(ctx, chunks, resources.retrieveEngine, resources.embeddingModel); err != nil {
+	if err := s.indexToVectorDB(ctx, resources.knowledge, chunks, resources.retrieveEngine, resources.embeddingModel); err != nil {
 		s.cleanupOnFailure(ctx, resources, chunks, err)
 		return err
 	}
@@ -470,6 +470,7 @@ func (s *DataTableSummaryService) buildChunks(resources *extractionResources, ta
 // 思路：批量构建索引信息，统一索引，更新状态
 func (s *DataTableSummaryService) indexToVectorDB(
 	ctx context.Context,
+	knowledge *types.Knowledge,
 	chunks []*types.Chunk,
 	engine *retriever.CompositeRetrieveEngine,
 	embedder embedding.Embedder,
@@ -484,6 +485,7 @@ func (s *DataTableSummaryService) indexToVectorDB(
 			ChunkID:         chunk.ID,
 			KnowledgeID:     chunk.KnowledgeID,
 			KnowledgeBaseID: chunk.KnowledgeBaseID,
+			Attributes:      types.NewIndexAttributes(chunk, knowledge),
 		})
 	}
 
@@ -9,7 +9,9 @@ import (
 	"net/url"
 	"path/filepath"
 	"strings"
+	"time"
 
+	werrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/google/uuid"
 	"github.com/tencentyun/cos-go-sdk-v5"
@@ -20,6 +22,8 @@ type cosFileService struct {
 	client        *cos.Client
 	bucketURL     string
 	cosPathPrefix string
+	secretID      string
+	secretKey     string
 }
 
 // NewCosFileService creates a new COS file service instance
@@ -40,6 +44,8 @@ func NewCosFileService(bucketName, region, secretId, secretKey, cosPathPrefix st
 		client:        client,
 		bucketURL:     bucketURL,
 		cosPathPrefix: cosPathPrefix,
+		secretID:      secretId,
+		secretKey:     secretKey,
 	}, nil
 }
 
@@ -81,3 +87,20 @@ func (s *cosFileService) DeleteFile(ctx context.Context, filePath string) error
 	}
 	return nil
 }
+
+// GetFileURL returns a presigned URL downloading the file directly from COS
+func (s *cosFileService) GetFileURL(ctx context.Context,
+	filePath, fileName string, expiry time.Duration,
+) (string, error) {
+	objectName, ok := strings.CutPrefix(filePath, s.bucketURL)
+	if !ok || objectName == "" {
+		return "", fmt.Errorf("%w: invalid COS file path: %s", werrors.ErrPresignNotSupported, filePath)
+	}
+	query := downloadParams(fileName)
+	u, err := s.client.Object.GetPresignedURL(ctx, http.MethodGet, objectName,
+		s.secretID, s.secretKey, expiry, &cos.PresignedURLOptions{Query: &query})
+	if err != nil {
+		return "", fmt.Errorf("failed to presign COS file: %w", err)
+	}
+	return u.String(), nil
+}
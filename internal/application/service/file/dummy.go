@@ -5,7 +5,9 @@ import (
 	"errors"
 	"io"
 	"mime/multipart"
+	"time"
 
+	werrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/google/uuid"
 )
@@ -36,3 +38,10 @@ func (s *DummyFileService) GetFile(ctx context.Context, filePath string) (io.Rea
 func (s *DummyFileService) DeleteFile(ctx context.Context, filePath string) error {
 	return nil
 }
+
+// GetFileURL always returns ErrPresignNotSupported as dummy service doesn't store files
+func (s *DummyFileService) GetFileURL(ctx context.Context,
+	filePath, fileName string, expiry time.Duration,
+) (string, error) {
+	return "", werrors.ErrPresignNotSupported
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"time"
 
+	werrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
@@ -109,3 +110,10 @@ func (s *localFileService) DeleteFile(ctx context.Context, filePath string) erro
 	logger.Info(ctx, "File deleted successfully")
 	return nil
 }
+
+// GetFileURL is not supported, local files are only reachable through the API
+func (s *localFileService) GetFileURL(ctx context.Context,
+	filePath, fileName string, expiry time.Duration,
+) (string, error) {
+	return "", werrors.ErrPresignNotSupported
+}
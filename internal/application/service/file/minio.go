@@ -4,9 +4,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
+	"net/url"
 	"path/filepath"
+	"strings"
+	"time"
 
+	werrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
@@ -15,13 +20,15 @@ import (
 
 // minioFileService MinIO file service implementation
 type minioFileService struct {
-	client     *minio.Client
-	bucketName string
+	client        *minio.Client
+	presignClient *minio.Client // Signs download URLs for the public endpoint, nil if not configured
+	bucketName    string
 }
 
-// NewMinioFileService creates a MinIO file service
+// NewMinioFileService creates a MinIO file service.
+// publicEndpoint is the address browsers reach MinIO at, presigned downloads are disabled when it is empty.
 func NewMinioFileService(endpoint,
-	accessKeyID, secretAccessKey, bucketName string, useSSL bool,
+	accessKeyID, secretAccessKey, bucketName string, useSSL bool, publicEndpoint string,
 ) (interfaces.FileService, error) {
 	// Initialize MinIO client
 	client, err := minio.New(endpoint, &minio.Options{
@@ -45,9 +52,25 @@ func NewMinioFileService(endpoint,
 		}
 	}
 
+	// The signature covers the host, so download URLs are signed by a client of the public endpoint.
+	// The region is fixed to avoid a bucket location lookup against an endpoint the server may not reach.
+	var presignClient *minio.Client
+	if publicEndpoint != "" {
+		host, secure := parseEndpoint(publicEndpoint, useSSL)
+		presignClient, err = minio.New(host, &minio.Options{
+			Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
+			Secure: secure,
+			Region: "us-east-1",
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize MinIO presign client: %w", err)
+		}
+	}
+
 	return &minioFileService{
-		client:     client,
-		bucketName: bucketName,
+		client:        client,
+		presignClient: presignClient,
+		bucketName:    bucketName,
 	}, nil
 }
 
@@ -125,3 +148,41 @@ func (s *minioFileService) DeleteFile(ctx context.Context, filePath string) erro
 
 	return nil
 }
+
+// GetFileURL returns a presigned download URL on the public MinIO endpoint
+func (s *minioFileService) GetFileURL(ctx context.Context,
+	filePath, fileName string, expiry time.Duration,
+) (string, error) {
+	if s.presignClient == nil {
+		return "", werrors.ErrPresignNotSupported
+	}
+	objectName, ok := strings.CutPrefix(filePath, "minio://"+s.bucketName+"/")
+	if !ok || objectName == "" {
+		return "", fmt.Errorf("%w: invalid MinIO file path: %s", werrors.ErrPresignNotSupported, filePath)
+	}
+
+	u, err := s.presignClient.PresignedGetObject(ctx, s.bucketName, objectName, expiry, downloadParams(fileName))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign MinIO file: %w", err)
+	}
+	return u.String(), nil
+}
+
+// parseEndpoint accepts an endpoint as host[:port] or URL and returns the host and whether to use TLS
+func parseEndpoint(endpoint string, useSSL bool) (string, bool) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return strings.TrimSuffix(endpoint, "/"), useSSL
+	}
+	return u.Host, u.Scheme == "https"
+}
+
+// downloadParams makes presigned URLs download the object under its original file name
+func downloadParams(fileName string) url.Values {
+	params := url.Values{}
+	if fileName != "" {
+		params.Set("response-content-disposition",
+			mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	}
+	return params
+}
@@ -0,0 +1,203 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+const (
+	// defaultS3PartSize is the multipart part size used when none is configured
+	defaultS3PartSize = 16 << 20
+	// minS3PartSize is the smallest part size accepted by S3
+	minS3PartSize = 5 << 20
+	// defaultS3PartConcurrency is the number of parts uploaded in parallel when none is configured
+	defaultS3PartConcurrency = 4
+)
+
+// S3Config holds the settings of an S3-compatible object store
+type S3Config struct {
+	Endpoint        string // host[:port] or URL, e.g. s3.amazonaws.com or https://<account>.r2.cloudflarestorage.com
+	Region          string // e.g. us-east-1, "auto" for Cloudflare R2
+	AccessKeyID     string
+	SecretAccessKey string
+	BucketName      string
+	PathPrefix      string // Optional key prefix of all objects
+	UseSSL          bool   // Used when Endpoint has no scheme
+	PathStyle       bool   // Path-style addressing, required by most self-hosted stores such as Ceph RGW
+	PartSize        uint64 // Files larger than this are uploaded in parts of this size
+	PartConcurrency uint   // Number of parts uploaded in parallel
+}
+
+// s3FileService implements the FileService interface for S3-compatible object stores
+type s3FileService struct {
+	client          *minio.Client
+	bucketName      string
+	pathPrefix      string
+	partSize        uint64
+	partConcurrency uint
+}
+
+// NewS3FileService creates a file service backed by AWS S3 or an S3-compatible store.
+// Unlike MinIO the bucket is not created, it must already exist.
+func NewS3FileService(cfg S3Config) (interfaces.FileService, error) {
+	client, err := newS3Client(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(context.Background(), cfg.BucketName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %s does not exist", cfg.BucketName)
+	}
+
+	return newS3FileService(client, cfg), nil
+}
+
+// newS3Client creates the S3 client. The region is set explicitly so that
+// signing never needs a bucket location lookup.
+func newS3Client(cfg S3Config) (*minio.Client, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "s3.amazonaws.com"
+	}
+	host, secure := parseEndpoint(endpoint, cfg.UseSSL)
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	lookup := minio.BucketLookupDNS
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(host, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		Secure:       secure,
+		Region:       region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize S3 client: %w", err)
+	}
+	return client, nil
+}
+
+func newS3FileService(client *minio.Client, cfg S3Config) *s3FileService {
+	partSize := cfg.PartSize
+	if partSize == 0 {
+		partSize = defaultS3PartSize
+	}
+	if partSize < minS3PartSize {
+		partSize = minS3PartSize
+	}
+	partConcurrency := cfg.PartConcurrency
+	if partConcurrency == 0 {
+		partConcurrency = defaultS3PartConcurrency
+	}
+	return &s3FileService{
+		client:          client,
+		bucketName:      cfg.BucketName,
+		pathPrefix:      strings.Trim(cfg.PathPrefix, "/"),
+		partSize:        partSize,
+		partConcurrency: partConcurrency,
+	}
+}
+
+// SaveFile uploads a file to the bucket. Files larger than the part size
+// are sent with a multipart upload, with parts uploaded concurrently.
+func (s *s3FileService) SaveFile(ctx context.Context,
+	file *multipart.FileHeader, tenantID uint64, knowledgeID string,
+) (string, error) {
+	ext := filepath.Ext(file.Filename)
+	objectName := path.Join(s.pathPrefix,
+		fmt.Sprintf("%d/%s/%s%s", tenantID, knowledgeID, uuid.New().String(), ext))
+
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	if uint64(file.Size) > s.partSize {
+		logger.Infof(ctx, "Uploading %s to S3 in parts of %d bytes, size: %d", objectName, s.partSize, file.Size)
+	}
+	_, err = s.client.PutObject(ctx, s.bucketName, objectName, src, file.Size, minio.PutObjectOptions{
+		ContentType: file.Header.Get("Content-Type"),
+		PartSize:    s.partSize,
+		NumThreads:  s.partConcurrency,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file to S3: %w", err)
+	}
+
+	return fmt.Sprintf("s3://%s/%s", s.bucketName, objectName), nil
+}
+
+// GetFile gets a file from the bucket
+func (s *s3FileService) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	objectName, err := s.objectName(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := s.client.GetObject(ctx, s.bucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file from S3: %w", err)
+	}
+	return obj, nil
+}
+
+// DeleteFile deletes a file from the bucket
+func (s *s3FileService) DeleteFile(ctx context.Context, filePath string) error {
+	objectName, err := s.objectName(filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := s.client.RemoveObject(ctx, s.bucketName, objectName, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return nil
+}
+
+// GetFileURL returns a presigned URL downloading the file directly from the bucket
+func (s *s3FileService) GetFileURL(ctx context.Context,
+	filePath, fileName string, expiry time.Duration,
+) (string, error) {
+	objectName, err := s.objectName(filePath)
+	if err != nil {
+		// Files stored under another path format, e.g. before switching storage, are proxied instead
+		return "", fmt.Errorf("%w: %v", werrors.ErrPresignNotSupported, err)
+	}
+
+	u, err := s.client.PresignedGetObject(ctx, s.bucketName, objectName, expiry, downloadParams(fileName))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign S3 file: %w", err)
+	}
+	return u.String(), nil
+}
+
+// objectName extracts the object key from a path in the format s3://bucketName/objectName
+func (s *s3FileService) objectName(filePath string) (string, error) {
+	objectName, ok := strings.CutPrefix(filePath, "s3://"+s.bucketName+"/")
+	if !ok || objectName == "" {
+		return "", fmt.Errorf("invalid S3 file path: %s", filePath)
+	}
+	return objectName, nil
+}
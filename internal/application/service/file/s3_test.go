@@ -0,0 +1,116 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+)
+
+func TestS3FileServiceGetFileURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      S3Config
+		filePath string
+		wantHost string
+		wantPath string
+		wantErr  bool
+	}{
+		{
+			name: "virtual hosted",
+			cfg: S3Config{
+				Region:     "eu-west-1",
+				BucketName: "docs",
+				UseSSL:     true,
+			},
+			filePath: "s3://docs/1/kn-1/a.pdf",
+			wantHost: "docs.s3.dualstack.eu-west-1.amazonaws.com",
+			wantPath: "/1/kn-1/a.pdf",
+		},
+		{
+			name: "path style",
+			cfg: S3Config{
+				Endpoint:   "http://rgw.internal:7480",
+				BucketName: "docs",
+				PathStyle:  true,
+			},
+			filePath: "s3://docs/prefix/1/kn-1/a.pdf",
+			wantHost: "rgw.internal:7480",
+			wantPath: "/docs/prefix/1/kn-1/a.pdf",
+		},
+		{
+			name:     "other bucket",
+			cfg:      S3Config{BucketName: "docs"},
+			filePath: "s3://other/1/kn-1/a.pdf",
+			wantErr:  true,
+		},
+		{
+			name:     "local path",
+			cfg:      S3Config{BucketName: "docs"},
+			filePath: "/data/files/1/kn-1/a.pdf",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.AccessKeyID, tt.cfg.SecretAccessKey = "key", "secret"
+			client, err := newS3Client(tt.cfg)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			svc := newS3FileService(client, tt.cfg)
+
+			fileURL, err := svc.GetFileURL(context.Background(), tt.filePath, "报告.pdf", 15*time.Minute)
+			if tt.wantErr {
+				if !errors.Is(err, werrors.ErrPresignNotSupported) {
+					t.Fatalf("expected ErrPresignNotSupported, got URL %q, error %v", fileURL, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			u, err := url.Parse(fileURL)
+			if err != nil {
+				t.Fatalf("invalid URL %s: %v", fileURL, err)
+			}
+			if u.Host != tt.wantHost || u.Path != tt.wantPath {
+				t.Fatalf("got %s%s, want %s%s", u.Host, u.Path, tt.wantHost, tt.wantPath)
+			}
+			query := u.Query()
+			if query.Get("X-Amz-Expires") != "900" || query.Get("X-Amz-Signature") == "" {
+				t.Fatalf("URL is not presigned: %s", fileURL)
+			}
+			if !strings.HasPrefix(query.Get("response-content-disposition"), "attachment;") {
+				t.Fatalf("missing content disposition: %s", fileURL)
+			}
+		})
+	}
+}
+
+func TestNewS3FileServicePartSize(t *testing.T) {
+	client, err := newS3Client(S3Config{BucketName: "docs"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	tests := []struct {
+		partSize uint64
+		want     uint64
+	}{
+		{partSize: 0, want: defaultS3PartSize},
+		{partSize: 1 << 20, want: minS3PartSize},
+		{partSize: 64 << 20, want: 64 << 20},
+	}
+	for _, tt := range tests {
+		svc := newS3FileService(client, S3Config{BucketName: "docs", PartSize: tt.partSize})
+		if svc.partSize != tt.want {
+			t.Errorf("part size %d: got %d, want %d", tt.partSize, svc.partSize, tt.want)
+		}
+	}
+}
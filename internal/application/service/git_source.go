@@ -0,0 +1,629 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"os"
+	"path"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/application/service/gitrepo"
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+// Defaults used when the git_source section leaves a setting empty
+const (
+	defaultGitSourceMaxFileSize = 2 << 20
+	defaultGitSourceSyncTimeout = 30 * time.Minute
+	gitSourceSyncMaxRetry       = 2
+)
+
+// gitSourceCodeLanguages maps the extensions of source files to the language of the Markdown code
+// block they are wrapped in, the document reader only parses document formats
+var gitSourceCodeLanguages = map[string]string{
+	"go": "go", "py": "python", "java": "java", "kt": "kotlin", "scala": "scala", "rs": "rust",
+	"c": "c", "h": "c", "cc": "cpp", "cpp": "cpp", "hpp": "cpp", "cs": "csharp", "swift": "swift",
+	"js": "javascript", "jsx": "jsx", "ts": "typescript", "tsx": "tsx", "vue": "vue",
+	"rb": "ruby", "php": "php", "lua": "lua", "sh": "bash", "sql": "sql", "proto": "protobuf",
+	"html": "html", "css": "css", "scss": "scss", "json": "json", "yaml": "yaml", "yml": "yaml",
+	"toml": "toml", "xml": "xml", "ini": "ini",
+}
+
+// gitSourceCodeFileNames are source files recognized by name rather than extension
+var gitSourceCodeFileNames = map[string]string{
+	"Dockerfile": "dockerfile", "Makefile": "makefile",
+}
+
+// gitSourceService syncs Git repositories into knowledge bases
+type gitSourceService struct {
+	config           *config.Config
+	repo             interfaces.GitSourceRepository
+	kbService        interfaces.KnowledgeBaseService
+	knowledgeService interfaces.KnowledgeService
+	tenantRepo       interfaces.TenantRepository
+	task             *asynq.Client
+}
+
+// NewGitSourceService creates a new Git source service
+func NewGitSourceService(
+	config *config.Config,
+	repo interfaces.GitSourceRepository,
+	kbService interfaces.KnowledgeBaseService,
+	knowledgeService interfaces.KnowledgeService,
+	tenantRepo interfaces.TenantRepository,
+	task *asynq.Client,
+) interfaces.GitSourceService {
+	return &gitSourceService{
+		config:           config,
+		repo:             repo,
+		kbService:        kbService,
+		knowledgeService: knowledgeService,
+		tenantRepo:       tenantRepo,
+		task:             task,
+	}
+}
+
+// sourceConfig returns the git_source configuration, empty when the section is missing
+func (s *gitSourceService) sourceConfig() config.GitSourceConfig {
+	if s.config != nil && s.config.KnowledgeBase != nil && s.config.KnowledgeBase.GitSource != nil {
+		return *s.config.KnowledgeBase.GitSource
+	}
+	return config.GitSourceConfig{}
+}
+
+// maxFileSize returns the size limit of synced files
+func (s *gitSourceService) maxFileSize() int64 {
+	if size := s.sourceConfig().MaxFileSize; size > 0 {
+		return size
+	}
+	return defaultGitSourceMaxFileSize
+}
+
+// syncTimeout returns how long a sync may run
+func (s *gitSourceService) syncTimeout() time.Duration {
+	if timeout := s.sourceConfig().SyncTimeout; timeout > 0 {
+		return timeout
+	}
+	return defaultGitSourceSyncTimeout
+}
+
+// getKnowledgeBase gets a document knowledge base of the current tenant
+func (s *gitSourceService) getKnowledgeBase(ctx context.Context, kbID string) (*types.KnowledgeBase, error) {
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, werrors.NewNotFoundError("Knowledge base not found")
+	}
+	if kb.TenantID != ctx.Value(types.TenantIDContextKey).(uint64) {
+		return nil, werrors.NewNotFoundError("Knowledge base not found")
+	}
+	if kb.Type == types.KnowledgeBaseTypeFAQ {
+		return nil, werrors.NewBadRequestError("FAQ knowledge bases do not support Git sources")
+	}
+	return kb, nil
+}
+
+// getSource gets a Git source of a knowledge base of the current tenant
+func (s *gitSourceService) getSource(ctx context.Context, kbID string, id string) (*types.GitSource, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := s.repo.GetByID(ctx, kb.TenantID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, werrors.NewNotFoundError("Git source not found")
+		}
+		return nil, err
+	}
+	if source.KnowledgeBaseID != kbID {
+		return nil, werrors.NewNotFoundError("Git source not found")
+	}
+	return source, nil
+}
+
+// applyRequest validates a create or update request and copies it onto the source
+func (s *gitSourceService) applyRequest(source *types.GitSource, req *types.GitSourceRequest) error {
+	repoURL := strings.TrimSpace(req.RepoURL)
+	if err := gitrepo.ValidateURL(repoURL, s.sourceConfig().AllowLocal); err != nil {
+		return werrors.NewValidationError(err.Error())
+	}
+	branch := strings.TrimSpace(req.Branch)
+	if strings.HasPrefix(branch, "-") {
+		return werrors.NewValidationError("Invalid branch name")
+	}
+	if _, err := gitrepo.NewMatcher(req.Include, req.Exclude); err != nil {
+		return werrors.NewValidationError(err.Error())
+	}
+
+	source.RepoURL = repoURL
+	source.Branch = branch
+	source.Name = strings.TrimSpace(req.Name)
+	if source.Name == "" {
+		source.Name = strings.TrimSuffix(path.Base(strings.TrimRight(repoURL, "/")), ".git")
+	}
+	source.Include = req.Include
+	source.Exclude = req.Exclude
+	return nil
+}
+
+// CreateGitSource creates a Git source for a knowledge base and enqueues its first sync
+func (s *gitSourceService) CreateGitSource(ctx context.Context,
+	kbID string, req *types.GitSourceRequest,
+) (*types.GitSource, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	source := &types.GitSource{
+		ID:              uuid.New().String(),
+		TenantID:        kb.TenantID,
+		KnowledgeBaseID: kb.ID,
+		SyncStatus:      types.GitSourceSyncPending,
+	}
+	if err := s.applyRequest(source, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, source); err != nil {
+		logger.Errorf(ctx, "Failed to create Git source: %v", err)
+		return nil, err
+	}
+	logger.Infof(ctx, "Git source created: %s, kb: %s, repo: %s", source.ID, kbID, source.RepoURL)
+
+	if err := s.enqueueSync(ctx, source); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// ListGitSources lists the Git sources of a knowledge base
+func (s *gitSourceService) ListGitSources(ctx context.Context, kbID string) ([]*types.GitSource, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListByKB(ctx, kb.TenantID, kb.ID)
+}
+
+// GetGitSource gets a Git source of a knowledge base
+func (s *gitSourceService) GetGitSource(ctx context.Context, kbID string, id string) (*types.GitSource, error) {
+	return s.getSource(ctx, kbID, id)
+}
+
+// UpdateGitSource updates the repository settings of a Git source, they apply from the next sync
+func (s *gitSourceService) UpdateGitSource(ctx context.Context,
+	kbID string, id string, req *types.GitSourceRequest,
+) (*types.GitSource, error) {
+	source, err := s.getSource(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(source, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, source); err != nil {
+		logger.Errorf(ctx, "Failed to update Git source: %v", err)
+		return nil, err
+	}
+	return source, nil
+}
+
+// DeleteGitSource deletes a Git source, and the knowledge it created when deleteKnowledge is set.
+// Knowledge that is kept becomes ordinary file knowledge of the knowledge base.
+func (s *gitSourceService) DeleteGitSource(ctx context.Context, kbID string, id string, deleteKnowledge bool) error {
+	source, err := s.getSource(ctx, kbID, id)
+	if err != nil {
+		return err
+	}
+	if source.SyncStatus == types.GitSourceSyncSyncing {
+		return werrors.NewConflictError("Git source is being synced, please retry when the sync is done")
+	}
+
+	if deleteKnowledge {
+		files, err := s.repo.ListFiles(ctx, source.TenantID, source.ID)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if file.KnowledgeID == "" {
+				continue
+			}
+			if err := s.knowledgeService.DeleteKnowledge(ctx, file.KnowledgeID); err != nil &&
+				!errors.Is(err, gorm.ErrRecordNotFound) {
+				logger.Errorf(ctx, "Failed to delete knowledge %s of Git source %s: %v", file.KnowledgeID, id, err)
+				return err
+			}
+		}
+	}
+
+	if err := s.repo.Delete(ctx, source.TenantID, source.ID); err != nil {
+		logger.Errorf(ctx, "Failed to delete Git source: %v", err)
+		return err
+	}
+	logger.Infof(ctx, "Git source deleted: %s, delete knowledge: %v", id, deleteKnowledge)
+	return nil
+}
+
+// SyncGitSource enqueues a sync of a Git source
+func (s *gitSourceService) SyncGitSource(ctx context.Context, kbID string, id string) (*types.GitSource, error) {
+	source, err := s.getSource(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enqueueSync(ctx, source); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// ListGitSourceFiles lists the files a Git source has synced
+func (s *gitSourceService) ListGitSourceFiles(ctx context.Context,
+	kbID string, id string,
+) ([]*types.GitSourceFile, error) {
+	source, err := s.getSource(ctx, kbID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListFiles(ctx, source.TenantID, source.ID)
+}
+
+// enqueueSync enqueues a sync task. A source has at most one queued or running sync.
+func (s *gitSourceService) enqueueSync(ctx context.Context, source *types.GitSource) error {
+	payloadBytes, err := json.Marshal(types.GitSourceSyncPayload{
+		TenantID: source.TenantID,
+		SourceID: source.ID,
+	})
+	if err != nil {
+		return err
+	}
+	task := asynq.NewTask(types.TypeGitSourceSync, payloadBytes,
+		asynq.Queue("default"),
+		asynq.MaxRetry(gitSourceSyncMaxRetry),
+		asynq.Timeout(s.syncTimeout()),
+		asynq.TaskID("git_source_sync:"+source.ID),
+	)
+	info, err := s.task.Enqueue(task)
+	if err != nil {
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			return werrors.NewConflictError("A sync of this Git source is already queued or running")
+		}
+		return fmt.Errorf("failed to enqueue Git source sync task: %w", err)
+	}
+	logger.Infof(ctx, "Git source sync task enqueued: %s, source: %s", info.ID, source.ID)
+
+	if source.SyncStatus != types.GitSourceSyncSyncing {
+		source.SyncStatus = types.GitSourceSyncPending
+		if err := s.repo.UpdateSyncStatus(ctx, source.TenantID, source.ID, source.SyncStatus); err != nil {
+			logger.Warnf(ctx, "Failed to update Git source sync status: %v", err)
+		}
+	}
+	return nil
+}
+
+// ProcessGitSourceSync handles Asynq Git source sync tasks.
+//
+// The repository is cloned without a working tree and each selected file is compared with the blob
+// recorded at the previous sync. New files are ingested through CreateKnowledgeFromFile, changed
+// files are ingested again before their old knowledge is deleted, and the knowledge of removed files
+// is deleted. Files that fail are not recorded, so the next sync tries them again.
+func (s *gitSourceService) ProcessGitSourceSync(ctx context.Context, t *asynq.Task) error {
+	var payload types.GitSourceSyncPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal Git source sync payload: %w", err)
+	}
+
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	source, err := s.repo.GetByID(ctx, payload.TenantID, payload.SourceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warnf(ctx, "Git source %s no longer exists, skipping sync", payload.SourceID)
+			return nil
+		}
+		return err
+	}
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant info: %v", err)
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	retryCount, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	isLastRetry := retryCount >= maxRetry
+	logger.Infof(ctx, "Processing Git source sync: %s, repo: %s, branch: %s, retry: %d/%d",
+		source.ID, source.RepoURL, source.Branch, retryCount, maxRetry)
+
+	source.SyncStatus = types.GitSourceSyncSyncing
+	if err := s.repo.UpdateSyncStatus(ctx, source.TenantID, source.ID, source.SyncStatus); err != nil {
+		logger.Warnf(ctx, "Failed to update Git source sync status: %v", err)
+	}
+
+	stats, head, syncErr := s.syncSource(ctx, source)
+	now := time.Now()
+	source.SyncStats = stats
+	source.LastSyncedAt = &now
+	if syncErr != nil {
+		logger.Errorf(ctx, "Git source sync %s failed: %v", source.ID, syncErr)
+		var quotaErr *types.StorageQuotaExceededError
+		retryable := !errors.As(syncErr, &quotaErr)
+		if retryable && !isLastRetry {
+			// Keep the syncing status while asynq retries
+			return syncErr
+		}
+		source.SyncStatus = types.GitSourceSyncFailed
+		source.ErrorMessage = syncErr.Error()
+	} else {
+		source.SyncStatus = types.GitSourceSyncCompleted
+		source.ErrorMessage = ""
+		source.LastCommit = head
+		logger.Infof(ctx, "Git source sync %s completed at %s: %+v", source.ID, head, stats)
+	}
+	if err := s.repo.UpdateSyncResult(ctx, source); err != nil {
+		logger.Errorf(ctx, "Failed to save Git source sync result: %v", err)
+		return err
+	}
+	// A failure is recorded on the source, returning nil lets the source be synced again
+	return nil
+}
+
+// syncSource clones the repository and brings the knowledge of the source in line with HEAD
+func (s *gitSourceService) syncSource(ctx context.Context,
+	source *types.GitSource,
+) (types.GitSourceSyncStats, string, error) {
+	var stats types.GitSourceSyncStats
+
+	matcher, err := gitrepo.NewMatcher(source.Include, source.Exclude)
+	if err != nil {
+		return stats, "", err
+	}
+	dir, err := os.MkdirTemp("", "weknora-git-")
+	if err != nil {
+		return stats, "", fmt.Errorf("failed to create clone directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err := gitrepo.Clone(ctx, source.RepoURL, source.Branch, dir, s.sourceConfig().AllowLocal)
+	if err != nil {
+		return stats, "", err
+	}
+	head, err := repo.Head(ctx)
+	if err != nil {
+		return stats, "", err
+	}
+	files, err := repo.ListFiles(ctx)
+	if err != nil {
+		return stats, "", err
+	}
+
+	var selected []gitrepo.File
+	for _, file := range files {
+		if !matcher.Match(file.Path) {
+			continue
+		}
+		if _, _, ok := gitSourceFileName(file.Path); !ok || file.Size == 0 || file.Size > s.maxFileSize() {
+			stats.Skipped++
+			continue
+		}
+		selected = append(selected, file)
+	}
+	paths := make([]string, 0, len(selected))
+	for _, file := range selected {
+		paths = append(paths, file.Path)
+	}
+	commits, err := repo.LastCommits(ctx, paths)
+	if err != nil {
+		return stats, "", err
+	}
+
+	records, err := s.repo.ListFiles(ctx, source.TenantID, source.ID)
+	if err != nil {
+		return stats, "", err
+	}
+	existing := make(map[string]*types.GitSourceFile, len(records))
+	for _, record := range records {
+		existing[record.Path] = record
+	}
+
+	current := make(map[string]bool, len(selected))
+	for _, file := range selected {
+		current[file.Path] = true
+		commit := commits[file.Path]
+		if commit == "" {
+			// Paths git log quotes are not matched, the synced commit is the best known
+			commit = head
+		}
+
+		record := existing[file.Path]
+		if record != nil && record.BlobSHA == file.Blob {
+			if record.KnowledgeID == "" {
+				stats.Duplicated++
+			} else {
+				stats.Unchanged++
+			}
+			if record.CommitSHA != commit {
+				record.CommitSHA = commit
+				if err := s.repo.SaveFile(ctx, record); err != nil {
+					logger.Warnf(ctx, "Failed to update commit of %s: %v", file.Path, err)
+				}
+			}
+			continue
+		}
+
+		if err := s.ingestFile(ctx, source, repo, file, commit, record, &stats); err != nil {
+			return stats, "", err
+		}
+	}
+
+	// Files no longer in the repository, or no longer selected
+	for _, record := range records {
+		if current[record.Path] {
+			continue
+		}
+		if record.KnowledgeID != "" {
+			if err := s.knowledgeService.DeleteKnowledge(ctx, record.KnowledgeID); err != nil &&
+				!errors.Is(err, gorm.ErrRecordNotFound) {
+				logger.Warnf(ctx, "Failed to delete knowledge of removed file %s: %v", record.Path, err)
+				stats.Failed++
+				continue
+			}
+		}
+		if err := s.repo.DeleteFile(ctx, source.TenantID, source.ID, record.Path); err != nil {
+			return stats, "", err
+		}
+		stats.Deleted++
+	}
+	return stats, head, nil
+}
+
+// ingestFile creates the knowledge of a new or changed file and records it. Only errors that stop
+// the sync are returned, other failures are counted.
+func (s *gitSourceService) ingestFile(ctx context.Context,
+	source *types.GitSource, repo *gitrepo.Repo, file gitrepo.File, commit string,
+	record *types.GitSourceFile, stats *types.GitSourceSyncStats,
+) error {
+	content, err := repo.ReadBlob(ctx, file.Blob)
+	if err != nil {
+		return err
+	}
+	fileName, language, _ := gitSourceFileName(file.Path)
+	if language != "" {
+		if bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content) {
+			stats.Skipped++
+			return nil
+		}
+		content = wrapSourceAsMarkdown(file.Path, language, content)
+	}
+
+	header, form, err := newMultipartFile(fileName, content)
+	if err != nil {
+		return err
+	}
+	defer form.RemoveAll()
+
+	metadata := map[string]string{
+		"source":        "git",
+		"git_source_id": source.ID,
+		"git_repo":      source.RepoURL,
+		"git_path":      file.Path,
+		"git_commit":    commit,
+		"git_blob":      file.Blob,
+	}
+	knowledge, err := s.knowledgeService.CreateKnowledgeFromFile(ctx,
+		source.KnowledgeBaseID, header, metadata, nil, fileName)
+
+	newRecord := &types.GitSourceFile{
+		SourceID:  source.ID,
+		Path:      file.Path,
+		TenantID:  source.TenantID,
+		BlobSHA:   file.Blob,
+		CommitSHA: commit,
+	}
+	var dupErr *types.DuplicateKnowledgeError
+	var quotaErr *types.StorageQuotaExceededError
+	switch {
+	case err == nil:
+		newRecord.KnowledgeID = knowledge.ID
+	case errors.As(err, &dupErr):
+		// The content is already in the knowledge base, it is not owned by the source
+		stats.Duplicated++
+	case errors.As(err, &quotaErr):
+		return err
+	default:
+		logger.Warnf(ctx, "Failed to ingest %s of Git source %s: %v", file.Path, source.ID, err)
+		stats.Failed++
+		return nil
+	}
+
+	if record != nil && record.KnowledgeID != "" && record.KnowledgeID != newRecord.KnowledgeID {
+		if err := s.knowledgeService.DeleteKnowledge(ctx, record.KnowledgeID); err != nil &&
+			!errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Warnf(ctx, "Failed to delete previous knowledge of %s: %v", file.Path, err)
+		}
+	}
+	if err := s.repo.SaveFile(ctx, newRecord); err != nil {
+		return err
+	}
+	if newRecord.KnowledgeID != "" {
+		if record != nil {
+			stats.Updated++
+		} else {
+			stats.Added++
+		}
+	}
+	return nil
+}
+
+// gitSourceFileName returns the knowledge file name of a repository path and, for source files
+// wrapped in Markdown, the language of the code block. ok is false for unsupported files.
+func gitSourceFileName(filePath string) (name string, language string, ok bool) {
+	base := path.Base(filePath)
+	if language, ok := gitSourceCodeFileNames[base]; ok {
+		return filePath + ".md", language, true
+	}
+	fileType := strings.ToLower(getFileType(base))
+	if language, ok := gitSourceCodeLanguages[fileType]; ok {
+		return filePath + ".md", language, true
+	}
+	if isValidFileType(base) && !IsImageType(fileType) {
+		return filePath, "", true
+	}
+	return "", "", false
+}
+
+// wrapSourceAsMarkdown turns a source file into a Markdown document titled with its path
+func wrapSourceAsMarkdown(filePath, language string, content []byte) []byte {
+	// The fence must be longer than any run of backticks in the code
+	fence := "```"
+	for strings.Contains(string(content), fence) {
+		fence += "`"
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n\n%s%s\n", filePath, fence, language)
+	buf.Write(content)
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	buf.WriteString(fence + "\n")
+	return buf.Bytes()
+}
+
+// newMultipartFile wraps content in a multipart file header, the form uploads reach
+// CreateKnowledgeFromFile in. The form must be removed after use.
+func newMultipartFile(name string, content []byte) (*multipart.FileHeader, *multipart.Form, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", path.Base(name))
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := part.Write(content); err != nil {
+		return nil, nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(int64(len(content)) + 1024)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		_ = form.RemoveAll()
+		return nil, nil, errors.New("failed to build multipart file")
+	}
+	return files[0], form, nil
+}
@@ -0,0 +1,72 @@
+package gitrepo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// Matcher selects repository paths with include and exclude globs
+type Matcher struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// NewMatcher compiles the globs. "**" matches any number of directories, "*" matches any
+// characters except "/", and "?" matches a single character except "/". A pattern without "/"
+// matches the file name in any directory, so "*.md" selects every Markdown file, and a pattern
+// ending with "/" selects everything in the directory.
+func NewMatcher(include, exclude []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, pattern := range include {
+		re, err := compileGlob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("include: %w", err)
+		}
+		m.include = append(m.include, re)
+	}
+	for _, pattern := range exclude {
+		re, err := compileGlob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("exclude: %w", err)
+		}
+		m.exclude = append(m.exclude, re)
+	}
+	return m, nil
+}
+
+// Match reports whether the path matches one of the include globs, or there are none, and matches
+// none of the exclude globs
+func (m *Matcher) Match(path string) bool {
+	for _, re := range m.exclude {
+		if re.MatchString(path) {
+			return false
+		}
+	}
+	if len(m.include) == 0 {
+		return true
+	}
+	for _, re := range m.include {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileGlob normalizes a repository glob and compiles it with utils.CompileGlob
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "/")
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	// A directory selects everything below it
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	} else if !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	return utils.CompileGlob(pattern)
+}
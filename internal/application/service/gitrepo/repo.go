@@ -0,0 +1,227 @@
+// Package gitrepo reads the files of a Git repository through the git command line, which must be
+// installed on the host. Repositories are cloned without a working tree and files are read as blobs.
+package gitrepo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxErrorOutput bounds the git stderr kept in errors
+const maxErrorOutput = 1024
+
+// File is a regular file in the tree of a commit
+type File struct {
+	// Path is the slash separated path relative to the repository root
+	Path string
+	// Blob is the SHA of the file content, it changes whenever the content changes
+	Blob string
+	// Size is the size of the content in bytes
+	Size int64
+}
+
+// Repo is a local clone of a repository
+type Repo struct {
+	dir string
+	env []string
+}
+
+// ValidateURL checks that a repository URL uses https or http, or is a local path or file:// URL
+// when allowLocal is set. Transports running commands such as ext:: are always rejected.
+func ValidateURL(repoURL string, allowLocal bool) error {
+	if repoURL == "" {
+		return errors.New("repository URL is required")
+	}
+	if strings.HasPrefix(repoURL, "-") {
+		return fmt.Errorf("invalid repository URL: %s", repoURL)
+	}
+	if isLocal(repoURL) {
+		if !allowLocal {
+			return errors.New("local repositories are not allowed")
+		}
+		return nil
+	}
+	u, err := url.Parse(repoURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid repository URL: %s", repoURL)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("unsupported repository URL scheme: %s", u.Scheme)
+	}
+	return nil
+}
+
+// isLocal reports whether the URL is a file:// URL or a filesystem path
+func isLocal(repoURL string) bool {
+	return strings.HasPrefix(repoURL, "file://") || filepath.IsAbs(repoURL) ||
+		strings.HasPrefix(repoURL, "./") || strings.HasPrefix(repoURL, "../")
+}
+
+// Clone clones the branch of the repository into dir, the default branch when branch is empty.
+// The URL must have passed ValidateURL.
+func Clone(ctx context.Context, repoURL, branch, dir string, allowLocal bool) (*Repo, error) {
+	if err := ValidateURL(repoURL, allowLocal); err != nil {
+		return nil, err
+	}
+	protocols := "https:http"
+	if allowLocal {
+		protocols += ":file"
+	}
+	repo := &Repo{
+		dir: dir,
+		env: append(os.Environ(),
+			"GIT_TERMINAL_PROMPT=0",
+			"GIT_ALLOW_PROTOCOL="+protocols,
+			"GIT_CONFIG_NOSYSTEM=1",
+		),
+	}
+	args := []string{"clone", "--quiet", "--no-checkout", "--single-branch"}
+	if branch != "" {
+		if strings.HasPrefix(branch, "-") {
+			return nil, fmt.Errorf("invalid branch: %s", branch)
+		}
+		args = append(args, "--branch", branch)
+	}
+	args = append(args, "--", repoURL, dir)
+	if _, err := repo.run(ctx, "", args...); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// Head returns the commit SHA the clone is at
+func (r *Repo) Head(ctx context.Context) (string, error) {
+	out, err := r.run(ctx, r.dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ListFiles returns the regular files in the tree of HEAD. Symbolic links and submodules are left out.
+func (r *Repo) ListFiles(ctx context.Context) ([]File, error) {
+	out, err := r.run(ctx, r.dir, "ls-tree", "-r", "-l", "-z", "--full-tree", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	var files []File
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		// <mode> SP <type> SP <object> SP+ <size> TAB <path>
+		meta, path, ok := strings.Cut(string(entry), "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 4 || fields[1] != "blob" || (fields[0] != "100644" && fields[0] != "100755") {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		files = append(files, File{Path: path, Blob: fields[2], Size: size})
+	}
+	return files, nil
+}
+
+// ReadBlob returns the content of a blob
+func (r *Repo) ReadBlob(ctx context.Context, blob string) ([]byte, error) {
+	return r.run(ctx, r.dir, "cat-file", "blob", blob)
+}
+
+// LastCommits returns the SHA of the last commit that changed each of the paths. History is read
+// from HEAD backwards and stops once every path has been seen.
+func (r *Repo) LastCommits(ctx context.Context, paths []string) (map[string]string, error) {
+	commits := make(map[string]string, len(paths))
+	if len(paths) == 0 {
+		return commits, nil
+	}
+	wanted := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		wanted[path] = true
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// Commits are marked with a NUL prefixed line, paths follow one per line
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.quotePath=false",
+		"log", "--format=%x00%H", "--name-only", "--no-renames", "HEAD")
+	cmd.Dir = r.dir
+	cmd.Env = r.env
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	var commit string
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() && len(commits) < len(wanted) {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "\x00") {
+			commit = line[1:]
+			continue
+		}
+		if line != "" && wanted[line] {
+			if _, seen := commits[line]; !seen {
+				commits[line] = commit
+			}
+		}
+	}
+	if len(commits) == len(wanted) {
+		// The rest of the history is not needed
+		cancel()
+		_, _ = io.Copy(io.Discard, stdout)
+		_ = cmd.Wait()
+		return commits, nil
+	}
+	if err := scanner.Err(); err != nil {
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("git log: %w: %s", err, truncate(stderr.String()))
+	}
+	return commits, nil
+}
+
+// run runs a git command in dir and returns its stdout
+func (r *Repo) run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = r.env
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("git %s: %w", args[0], ctx.Err())
+		}
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, truncate(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// truncate shortens git output kept in errors
+func truncate(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) > maxErrorOutput {
+		return s[:maxErrorOutput] + "..."
+	}
+	return s
+}
@@ -0,0 +1,157 @@
+package gitrepo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// git runs a git command in dir for setting up a test repository
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// writeFile writes a file of the test repository
+func writeFile(t *testing.T, dir, path, content string) {
+	t.Helper()
+	full := filepath.Join(dir, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClone(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	origin := t.TempDir()
+	git(t, origin, "init", "--quiet", "--initial-branch=main")
+	writeFile(t, origin, "README.md", "# Readme\n")
+	writeFile(t, origin, "docs/guide.md", "guide v1\n")
+	git(t, origin, "add", ".")
+	git(t, origin, "commit", "--quiet", "-m", "first")
+	first := git(t, origin, "rev-parse", "HEAD")
+
+	writeFile(t, origin, "docs/guide.md", "guide v2\n")
+	writeFile(t, origin, "src/main.go", "package main\n")
+	if err := os.Symlink("README.md", filepath.Join(origin, "link.md")); err != nil {
+		t.Fatal(err)
+	}
+	git(t, origin, "add", ".")
+	git(t, origin, "commit", "--quiet", "-m", "second")
+	second := git(t, origin, "rev-parse", "HEAD")
+
+	ctx := context.Background()
+	if _, err := Clone(ctx, origin, "", filepath.Join(t.TempDir(), "denied"), false); err == nil {
+		t.Fatal("cloning a local repository should be rejected")
+	}
+
+	repo, err := Clone(ctx, "file://"+origin, "main", filepath.Join(t.TempDir(), "clone"), true)
+	if err != nil {
+		t.Fatalf("Clone: %v", err)
+	}
+	head, err := repo.Head(ctx)
+	if err != nil || head != second {
+		t.Fatalf("Head = %q, %v, want %q", head, err, second)
+	}
+
+	files, err := repo.ListFiles(ctx)
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	var paths []string
+	blobs := make(map[string]string)
+	for _, f := range files {
+		paths = append(paths, f.Path)
+		blobs[f.Path] = f.Blob
+	}
+	if got, want := strings.Join(paths, ","), "README.md,docs/guide.md,src/main.go"; got != want {
+		t.Fatalf("ListFiles = %s, want %s", got, want)
+	}
+
+	content, err := repo.ReadBlob(ctx, blobs["docs/guide.md"])
+	if err != nil || string(content) != "guide v2\n" {
+		t.Fatalf("ReadBlob = %q, %v", content, err)
+	}
+
+	commits, err := repo.LastCommits(ctx, paths)
+	if err != nil {
+		t.Fatalf("LastCommits: %v", err)
+	}
+	want := map[string]string{"README.md": first, "docs/guide.md": second, "src/main.go": second}
+	for path, commit := range want {
+		if commits[path] != commit {
+			t.Errorf("last commit of %s = %s, want %s", path, commits[path], commit)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		url        string
+		allowLocal bool
+		wantErr    bool
+	}{
+		{"https://github.com/Tencent/WeKnora.git", false, false},
+		{"http://git.example.com/docs.git", false, false},
+		{"file:///srv/repos/docs", false, true},
+		{"file:///srv/repos/docs", true, false},
+		{"/srv/repos/docs", true, false},
+		{"ext::sh -c touch% /tmp/pwned", true, true},
+		{"git@github.com:Tencent/WeKnora.git", false, true},
+		{"--upload-pack=touch /tmp/pwned", true, true},
+		{"", false, true},
+	}
+	for _, tt := range tests {
+		if err := ValidateURL(tt.url, tt.allowLocal); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateURL(%q, %v) error = %v, wantErr %v", tt.url, tt.allowLocal, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	m, err := NewMatcher([]string{"*.md", "src/**/*.go", "api/"}, []string{"**/vendor/**", "docs/draft-*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"README.md", true},
+		{"docs/guide/intro.md", true},
+		{"docs/draft-plan.md", false},
+		{"src/main.go", true},
+		{"src/pkg/util/util.go", true},
+		{"src/vendor/lib/lib.go", false},
+		{"api/openapi.yaml", true},
+		{"cmd/main.go", false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	all, err := NewMatcher(nil, nil)
+	if err != nil || !all.Match("any/file.txt") {
+		t.Error("a matcher without globs should match every path")
+	}
+}
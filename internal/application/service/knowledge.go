@@ -69,12 +69,14 @@ type knowledgeService struct {
 	task            *asynq.Client
 	graphEngine     interfaces.RetrieveGraphRepository
 	redisClient     *redis.Client
+	responseCache   interfaces.ResponseCacheService
 }
 
 const (
 	manualContentMaxLength = 200000
 	manualFileExtension    = ".md"
 	faqImportBatchSize     = 50 // 每批处理的FAQ条目数
+	knowledgeFileURLExpiry = 15 * time.Minute
 )
 
 // NewKnowledgeService creates a new knowledge service instance
@@ -94,6 +96,7 @@ func NewKnowledgeService(
 	graphEngine interfaces.RetrieveGraphRepository,
 	retrieveEngine interfaces.RetrieveEngineRegistry,
 	redisClient *redis.Client,
+	responseCache interfaces.ResponseCacheService,
 ) (interfaces.KnowledgeService, error) {
 	return &knowledgeService{
 		config:          config,
@@ -111,6 +114,7 @@ func NewKnowledgeService(
 		graphEngine:     graphEngine,
 		retrieveEngine:  retrieveEngine,
 		redisClient:     redisClient,
+		responseCache:   responseCache,
 	}, nil
 }
 
@@ -358,7 +362,7 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 
 // CreateKnowledgeFromURL creates a knowledge entry from a URL source
 func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
-	kbID string, url string, enableMultimodel *bool, title string,
+	kbID string, url string, enableMultimodel *bool, title string, refreshInterval int,
 ) (*types.Knowledge, error) {
 	logger.Info(ctx, "Start creating knowledge from URL")
 	logger.Infof(ctx, "Knowledge base ID: %s, URL: %s", kbID, url)
@@ -377,6 +381,9 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 		logger.Error(ctx, "Invalid or unsafe URL format")
 		return nil, ErrInvalidURL
 	}
+	if err := s.validateRefreshInterval(refreshInterval); err != nil {
+		return nil, err
+	}
 
 	// Check if URL already exists in the knowledge base
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
@@ -416,7 +423,7 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 		ID:               uuid.New().String(),
 		TenantID:         tenantID,
 		KnowledgeBaseID:  kbID,
-		Type:             "url",
+		Type:             types.KnowledgeTypeURL,
 		Title:            title,
 		Source:           url,
 		FileHash:         fileHash,
@@ -425,6 +432,7 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 		CreatedAt:        time.Now(),
 		UpdatedAt:        time.Now(),
 		EmbeddingModelID: kb.EmbeddingModelID,
+		RefreshInterval:  refreshInterval,
 	}
 
 	// Save knowledge record
@@ -800,7 +808,10 @@ func (s *knowledgeService) DeleteKnowledge(ctx context.Context, id string) error
 		return nil
 	})
 
-	if err = wg.Wait(); err != nil {
+	err = wg.Wait()
+	// Cached answers may cite the knowledge even when only part of it was removed
+	s.responseCache.InvalidateKnowledgeBases(ctx, knowledge.KnowledgeBaseID)
+	if err != nil {
 		return err
 	}
 	// Delete the knowledge entry itself from the database
@@ -912,7 +923,14 @@ func (s *knowledgeService) DeleteKnowledgeList(ctx context.Context, ids []string
 		return nil
 	})
 
-	if err = wg.Wait(); err != nil {
+	err = wg.Wait()
+	// Cached answers may cite the knowledge even when only part of it was removed
+	kbIDs := make([]string, 0, len(knowledgeList))
+	for _, knowledge := range knowledgeList {
+		kbIDs = append(kbIDs, knowledge.KnowledgeBaseID)
+	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, kbIDs...)
+	if err != nil {
 		return err
 	}
 	// 5. Delete the knowledge entry itself from the database
@@ -1015,6 +1033,9 @@ func (s *knowledgeService) processDocumentFromPassage(ctx context.Context,
 type ProcessChunksOptions struct {
 	EnableQuestionGeneration bool
 	QuestionCount            int
+	// ReplacePrevious keeps the previous chunks and their index until the new chunks are indexed,
+	// so the knowledge keeps serving its previous content when processing fails
+	ReplacePrevious bool
 }
 
 // processChunks processes chunks and creates embeddings for knowledge content
@@ -1053,35 +1074,45 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 		return
 	}
 
-	// 幂等性处理：清理旧的chunks和索引数据，避免重复数据
-	logger.Infof(ctx, "Cleaning up existing chunks and index data for knowledge: %s", knowledge.ID)
-
-	// 删除旧的chunks
-	if err := s.chunkService.DeleteChunksByKnowledgeID(ctx, knowledge.ID); err != nil {
-		logger.Warnf(ctx, "Failed to delete existing chunks (may not exist): %v", err)
-		// 不返回错误，继续处理（可能没有旧数据）
-	}
-
-	// 删除旧的索引数据
 	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
-	if err == nil {
+	if err != nil {
+		logger.GetLogger(ctx).WithField("error", err).Errorf("processChunks init retrieve engine failed")
+		span.RecordError(err)
+		return
+	}
+
+	// 替换模式下旧数据保留到新的chunks索引完成，其占用的存储在完成后释放
+	var previousStorageSize int64
+	if options.ReplacePrevious {
+		previousStorageSize = knowledge.StorageSize
+	} else {
+		// 幂等性处理：清理旧的chunks和索引数据，避免重复数据
+		logger.Infof(ctx, "Cleaning up existing chunks and index data for knowledge: %s", knowledge.ID)
+
+		// 删除旧的chunks
+		if err := s.chunkService.DeleteChunksByKnowledgeID(ctx, knowledge.ID); err != nil {
+			logger.Warnf(ctx, "Failed to delete existing chunks (may not exist): %v", err)
+			// 不返回错误，继续处理（可能没有旧数据）
+		}
+
+		// 删除旧的索引数据
 		if err := retrieveEngine.DeleteByKnowledgeIDList(ctx, []string{knowledge.ID}, embeddingModel.GetDimensions(), knowledge.Type); err != nil {
 			logger.Warnf(ctx, "Failed to delete existing index data (may not exist): %v", err)
 			// 不返回错误，继续处理（可能没有旧数据）
 		} else {
 			logger.Infof(ctx, "Successfully deleted existing index data for knowledge: %s", knowledge.ID)
 		}
-	}
 
-	// 删除知识图谱数据（如果存在）
-	namespace := types.NameSpace{KnowledgeBase: knowledge.KnowledgeBaseID, Knowledge: knowledge.ID}
-	if err := s.graphEngine.DelGraph(ctx, []types.NameSpace{namespace}); err != nil {
-		logger.Warnf(ctx, "Failed to delete existing graph data (may not exist): %v", err)
-		// 不返回错误，继续处理
-	}
+		// 删除知识图谱数据（如果存在）
+		namespace := types.NameSpace{KnowledgeBase: knowledge.KnowledgeBaseID, Knowledge: knowledge.ID}
+		if err := s.graphEngine.DelGraph(ctx, []types.NameSpace{namespace}); err != nil {
+			logger.Warnf(ctx, "Failed to delete existing graph data (may not exist): %v", err)
+			// 不返回错误，继续处理
+		}
 
-	logger.Infof(ctx, "Cleanup completed, starting to process new chunks")
+		logger.Infof(ctx, "Cleanup completed, starting to process new chunks")
+	}
 
 	// ========== DocReader 解析结果日志 ==========
 	logger.Infof(ctx, "[DocReader] ========== 解析结果概览 ==========")
@@ -1284,6 +1315,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 			ChunkID:         chunk.ID,
 			KnowledgeID:     knowledge.ID,
 			KnowledgeBaseID: knowledge.KnowledgeBaseID,
+			Attributes:      types.NewIndexAttributes(chunk, knowledge),
 		})
 	}
 
@@ -1304,7 +1336,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 			return
 		}
 		// Check if there's enough storage quota available
-		if tenantInfo.StorageUsed+totalStorageSize > tenantInfo.StorageQuota {
+		if tenantInfo.StorageUsed-previousStorageSize+totalStorageSize > tenantInfo.StorageQuota {
 			knowledge.ParseStatus = types.ParseStatusFailed
 			knowledge.ErrorMessage = "存储空间不足"
 			knowledge.UpdatedAt = time.Now()
@@ -1351,6 +1383,24 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 		knowledge.UpdatedAt = time.Now()
 		s.repo.UpdateKnowledge(ctx, knowledge)
 
+		if options.ReplacePrevious {
+			// Only the new chunks are dropped, the previous ones keep serving
+			newChunkIDs := make([]string, 0, len(insertChunks))
+			for _, chunk := range insertChunks {
+				newChunkIDs = append(newChunkIDs, chunk.ID)
+			}
+			if err := s.chunkService.DeleteChunks(ctx, newChunkIDs); err != nil {
+				logger.Errorf(ctx, "Delete chunks failed: %v", err)
+			}
+			if err := retrieveEngine.DeleteByChunkIDList(
+				ctx, newChunkIDs, embeddingModel.GetDimensions(), kb.Type,
+			); err != nil {
+				logger.Errorf(ctx, "Delete index failed: %v", err)
+			}
+			span.RecordError(err)
+			return
+		}
+
 		// delete failed chunks
 		if err := s.chunkService.DeleteChunksByKnowledgeID(ctx, knowledge.ID); err != nil {
 			logger.Errorf(ctx, "Delete chunks failed: %v", err)
@@ -1367,6 +1417,10 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	}
 	logger.GetLogger(ctx).Infof("processChunks batch index successfully, with %d index", len(indexInfoList))
 
+	if options.ReplacePrevious {
+		s.deletePreviousChunks(ctx, knowledge, insertChunks, retrieveEngine, embeddingModel.GetDimensions())
+	}
+
 	logger.Infof(ctx, "processChunks create relationship rag task")
 	if kb.ExtractConfig != nil && kb.ExtractConfig.Enabled {
 		for _, chunk := range textChunks {
@@ -1410,6 +1464,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
 		logger.GetLogger(ctx).WithField("error", err).Errorf("processChunks update knowledge failed")
 	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, knowledge.KnowledgeBaseID)
 
 	// Enqueue question generation task if enabled (async, non-blocking)
 	if options.EnableQuestionGeneration && len(textChunks) > 0 {
@@ -1429,13 +1484,40 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	}
 
 	// Update tenant's storage usage
-	tenantInfo.StorageUsed += totalStorageSize
-	if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantInfo.ID, totalStorageSize); err != nil {
+	storageDelta := totalStorageSize - previousStorageSize
+	tenantInfo.StorageUsed += storageDelta
+	if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantInfo.ID, storageDelta); err != nil {
 		logger.GetLogger(ctx).WithField("error", err).Errorf("processChunks update tenant storage used failed")
 	}
 	logger.GetLogger(ctx).Infof("processChunks successfully")
 }
 
+// deletePreviousChunks deletes the chunks, index and graph data a knowledge had before the given chunks
+// replaced them
+func (s *knowledgeService) deletePreviousChunks(ctx context.Context,
+	knowledge *types.Knowledge, chunks []*types.Chunk,
+	retrieveEngine *retriever.CompositeRetrieveEngine, dimension int,
+) {
+	keepIDs := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		keepIDs = append(keepIDs, chunk.ID)
+	}
+	deletedIDs, err := s.chunkRepo.DeleteChunksByKnowledgeIDExcept(ctx, knowledge.TenantID, knowledge.ID, keepIDs)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to delete previous chunks of knowledge %s: %v", knowledge.ID, err)
+	}
+	if len(deletedIDs) > 0 {
+		if err := retrieveEngine.DeleteByChunkIDList(ctx, deletedIDs, dimension, knowledge.Type); err != nil {
+			logger.Warnf(ctx, "Failed to delete previous index data of knowledge %s: %v", knowledge.ID, err)
+		}
+	}
+	namespace := types.NameSpace{KnowledgeBase: knowledge.KnowledgeBaseID, Knowledge: knowledge.ID}
+	if err := s.graphEngine.DelGraph(ctx, []types.NameSpace{namespace}); err != nil {
+		logger.Warnf(ctx, "Failed to delete previous graph data of knowledge %s: %v", knowledge.ID, err)
+	}
+	logger.Infof(ctx, "Replaced %d previous chunks of knowledge %s", len(deletedIDs), knowledge.ID)
+}
+
 // GetSummary generates a summary for knowledge content using an AI model
 func (s *knowledgeService) getSummary(ctx context.Context,
 	summaryModel chat.Chat, knowledge *types.Knowledge, chunks []*types.Chunk,
@@ -1748,6 +1830,7 @@ func (s *knowledgeService) ProcessSummaryGeneration(ctx context.Context, t *asyn
 			ChunkID:         summaryChunk.ID,
 			KnowledgeID:     knowledge.ID,
 			KnowledgeBaseID: knowledge.KnowledgeBaseID,
+			Attributes:      types.NewIndexAttributes(summaryChunk, knowledge),
 		}}
 
 		if err := retrieveEngine.BatchIndex(ctx, embeddingModel, indexInfo); err != nil {
@@ -1756,6 +1839,7 @@ func (s *knowledgeService) ProcessSummaryGeneration(ctx context.Context, t *asyn
 		}
 
 		logger.Infof(ctx, "Successfully created and indexed summary chunk for knowledge: %s", payload.KnowledgeID)
+		s.responseCache.InvalidateKnowledgeBases(ctx, knowledge.KnowledgeBaseID)
 	}
 
 	logger.Infof(ctx, "Successfully generated summary for knowledge: %s", payload.KnowledgeID)
@@ -1915,6 +1999,7 @@ func (s *knowledgeService) ProcessQuestionGeneration(ctx context.Context, t *asy
 				ChunkID:         chunk.ID,
 				KnowledgeID:     knowledge.ID,
 				KnowledgeBaseID: knowledge.KnowledgeBaseID,
+				Attributes:      types.NewIndexAttributes(chunk, knowledge),
 			})
 		}
 		logger.Debugf(ctx, "Generated %d questions for chunk %s", len(questions), chunk.ID)
@@ -1927,6 +2012,7 @@ func (s *knowledgeService) ProcessQuestionGeneration(ctx context.Context, t *asy
 			return fmt.Errorf("failed to index questions: %w", err)
 		}
 		logger.Infof(ctx, "Successfully indexed %d generated questions for knowledge: %s", len(indexInfoList), payload.KnowledgeID)
+		s.responseCache.InvalidateKnowledgeBases(ctx, payload.KnowledgeBaseID)
 	}
 
 	return nil
@@ -2047,6 +2133,25 @@ func (s *knowledgeService) GetKnowledgeFile(ctx context.Context, id string) (io.
 	return file, knowledge.FileName, nil
 }
 
+// GetKnowledgeFileURL returns a presigned URL downloading the file of a knowledge entry directly from storage.
+// It returns an empty URL when the storage backend cannot issue one and the file must be proxied.
+func (s *knowledgeService) GetKnowledgeFileURL(ctx context.Context, id string) (string, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, id)
+	if err != nil {
+		return "", err
+	}
+
+	fileURL, err := s.fileSvc.GetFileURL(ctx, knowledge.FilePath, knowledge.FileName, knowledgeFileURLExpiry)
+	if errors.Is(err, werrors.ErrPresignNotSupported) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return fileURL, nil
+}
+
 func (s *knowledgeService) UpdateKnowledge(ctx context.Context, knowledge *types.Knowledge) error {
 	record, err := s.repo.GetKnowledgeByID(ctx, ctx.Value(types.TenantIDContextKey).(uint64), knowledge.ID)
 	if err != nil {
@@ -2063,6 +2168,8 @@ func (s *knowledgeService) UpdateKnowledge(ctx context.Context, knowledge *types
 		logger.Errorf(ctx, "Failed to update knowledge: %v", err)
 		return err
 	}
+	// The title is part of the references of cached answers
+	s.responseCache.InvalidateKnowledgeBases(ctx, record.KnowledgeBaseID)
 	logger.Infof(ctx, "Knowledge updated successfully, ID: %s", knowledge.ID)
 	return nil
 }
@@ -2265,7 +2372,7 @@ func (s *knowledgeService) CloneKnowledgeBase(ctx context.Context, srcID, dstID
 		g.Go(func() error {
 			err := s.DeleteKnowledgeList(gctx, ids)
 			if err != nil {
-				logger.Errorf(gctx, "delete partial knowledge %v: %w", ids, err)
+				logger.Errorf(gctx, "delete partial knowledge %v: %v", ids, err)
 				return err
 			}
 			return nil
@@ -2284,12 +2391,12 @@ func (s *knowledgeService) CloneKnowledgeBase(ctx context.Context, srcID, dstID
 		g.Go(func() error {
 			srcKn, err := s.repo.GetKnowledgeByID(gctx, srcKB.TenantID, knowledge)
 			if err != nil {
-				logger.Errorf(gctx, "get knowledge %s: %w", knowledge, err)
+				logger.Errorf(gctx, "get knowledge %s: %v", knowledge, err)
 				return err
 			}
 			err = s.cloneKnowledge(gctx, srcKn, dstKB)
 			if err != nil {
-				logger.Errorf(gctx, "clone knowledge %s: %w", knowledge, err)
+				logger.Errorf(gctx, "clone knowledge %s: %v", knowledge, err)
 				return err
 			}
 			return nil
@@ -2303,6 +2410,67 @@ func (s *knowledgeService) CloneKnowledgeBase(ctx context.Context, srcID, dstID
 	return nil
 }
 
+// chunkIndexAttributes looks up the knowledge of the chunks and collects their filter attributes,
+// chunks whose knowledge no longer exists are left out
+func (s *knowledgeService) chunkIndexAttributes(
+	ctx context.Context, tenantID uint64, chunks []*types.Chunk,
+) (map[string]*types.IndexAttributes, error) {
+	knowledgeIDs := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		if !slices.Contains(knowledgeIDs, chunk.KnowledgeID) {
+			knowledgeIDs = append(knowledgeIDs, chunk.KnowledgeID)
+		}
+	}
+	knowledgeList, err := s.repo.GetKnowledgeBatch(ctx, tenantID, knowledgeIDs)
+	if err != nil {
+		return nil, err
+	}
+	knowledgeByID := make(map[string]*types.Knowledge, len(knowledgeList))
+	for _, knowledge := range knowledgeList {
+		knowledgeByID[knowledge.ID] = knowledge
+	}
+	attributes := make(map[string]*types.IndexAttributes, len(chunks))
+	for _, chunk := range chunks {
+		if knowledge, ok := knowledgeByID[chunk.KnowledgeID]; ok {
+			attributes[chunk.ID] = types.NewIndexAttributes(chunk, knowledge)
+		}
+	}
+	return attributes, nil
+}
+
+// syncChunkAttributes refreshes the filter attributes stored with the index entries of the chunks,
+// e.g. after their tags changed. Every retag goes through here, so it also invalidates the cached
+// answers of the knowledge bases, even when the index update fails after the database changed.
+func (s *knowledgeService) syncChunkAttributes(ctx context.Context, tenantID uint64, chunks []*types.Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	defer s.responseCache.InvalidateKnowledgeBases(ctx, chunkKnowledgeBaseIDs(chunks)...)
+	attributes, err := s.chunkIndexAttributes(ctx, tenantID, chunks)
+	if err != nil {
+		return err
+	}
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
+	if err != nil {
+		return err
+	}
+	return retrieveEngine.BatchUpdateChunkAttributes(ctx, attributes)
+}
+
+// syncKnowledgeAttributes refreshes the filter attributes stored with the index entries of all chunks
+// of the given knowledge, e.g. after the knowledge tag changed
+func (s *knowledgeService) syncKnowledgeAttributes(ctx context.Context, tenantID uint64, knowledgeIDs []string) error {
+	chunks, err := s.chunkRepo.ListChunksForIndexAttributes(ctx, tenantID, knowledgeIDs)
+	if err != nil {
+		return err
+	}
+	return s.syncChunkAttributes(ctx, tenantID, chunks)
+}
+
 func (s *knowledgeService) updateChunkVector(ctx context.Context, kbID string, chunks []*types.Chunk) error {
 	// Get embedding model from knowledge base
 	sourceKB, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
@@ -2314,6 +2482,12 @@ func (s *knowledgeService) updateChunkVector(ctx context.Context, kbID string, c
 		return err
 	}
 
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	attributes, err := s.chunkIndexAttributes(ctx, tenantInfo.ID, chunks)
+	if err != nil {
+		return err
+	}
+
 	// Initialize composite retrieve engine from tenant configuration
 	indexInfo := make([]*types.IndexInfo, 0, len(chunks))
 	ids := make([]string, 0, len(chunks))
@@ -2329,11 +2503,11 @@ func (s *knowledgeService) updateChunkVector(ctx context.Context, kbID string, c
 			ChunkID:         chunk.ID,
 			KnowledgeID:     chunk.KnowledgeID,
 			KnowledgeBaseID: chunk.KnowledgeBaseID,
+			Attributes:      attributes[chunk.ID],
 		})
 		ids = append(ids, chunk.ID)
 	}
 
-	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, tenantInfo.GetEffectiveEngines())
 	if err != nil {
 		return err
@@ -2350,6 +2524,7 @@ func (s *knowledgeService) updateChunkVector(ctx context.Context, kbID string, c
 	if err != nil {
 		return err
 	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, kbID)
 	return nil
 }
 
@@ -2644,6 +2819,15 @@ func (s *knowledgeService) CloneChunk(ctx context.Context, src, dst *types.Knowl
 	); err != nil {
 		return err
 	}
+	// The copies carry the source attributes, replace them with the target tags and creation time
+	attributes := make(map[string]*types.IndexAttributes, len(targetChunks))
+	for _, targetChunk := range targetChunks {
+		attributes[targetChunk.ID] = types.NewIndexAttributes(targetChunk, dst)
+	}
+	if err := retrieveEngine.BatchUpdateChunkAttributes(ctx, attributes); err != nil {
+		return err
+	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, dst.KnowledgeBaseID)
 	return nil
 }
 
@@ -3535,6 +3719,7 @@ func (s *knowledgeService) UpdateFAQEntryStatus(ctx context.Context,
 	if err := retrieveEngine.BatchUpdateChunkEnabledStatus(ctx, chunkStatusMap); err != nil {
 		return err
 	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, kb.ID)
 
 	return nil
 }
@@ -3557,6 +3742,7 @@ func (s *knowledgeService) UpdateFAQEntryFieldsBatch(ctx context.Context,
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 
 	enabledUpdates := make(map[string]bool)
+	retaggedIDs := make([]string, 0)
 
 	// Handle ByTag updates first
 	if len(req.ByTag) > 0 {
@@ -3587,6 +3773,9 @@ func (s *knowledgeService) UpdateFAQEntryFieldsBatch(ctx context.Context,
 					enabledUpdates[id] = *update.IsEnabled
 				}
 			}
+			if update.TagID != nil {
+				retaggedIDs = append(retaggedIDs, affectedIDs...)
+			}
 		}
 	}
 
@@ -3643,6 +3832,7 @@ func (s *knowledgeService) UpdateFAQEntryFieldsBatch(ctx context.Context,
 				}
 				if chunk.TagID != newTagID {
 					chunk.TagID = newTagID
+					retaggedIDs = append(retaggedIDs, chunk.ID)
 					needUpdate = true
 				}
 			}
@@ -3682,6 +3872,17 @@ func (s *knowledgeService) UpdateFAQEntryFieldsBatch(ctx context.Context,
 			return err
 		}
 	}
+	// Sync tags to retriever engines
+	if len(retaggedIDs) > 0 {
+		retagged, err := s.chunkRepo.ListChunksByID(ctx, tenantID, retaggedIDs)
+		if err != nil {
+			return err
+		}
+		if err := s.syncChunkAttributes(ctx, tenantID, retagged); err != nil {
+			return err
+		}
+	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, kb.ID)
 
 	return nil
 }
@@ -3707,7 +3908,10 @@ func (s *knowledgeService) UpdateKnowledgeTag(ctx context.Context, knowledgeID s
 	}
 
 	knowledge.TagID = resolvedTagID
-	return s.repo.UpdateKnowledge(ctx, knowledge)
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		return err
+	}
+	return s.syncKnowledgeAttributes(ctx, tenantID, []string{knowledge.ID})
 }
 
 // UpdateKnowledgeTagBatch updates tags for document knowledge items in batch.
@@ -3776,7 +3980,14 @@ func (s *knowledgeService) UpdateKnowledgeTagBatch(ctx context.Context, updates
 	}
 
 	if len(knowledgeToUpdate) > 0 {
-		return s.repo.UpdateKnowledgeBatch(ctx, knowledgeToUpdate)
+		if err := s.repo.UpdateKnowledgeBatch(ctx, knowledgeToUpdate); err != nil {
+			return err
+		}
+		updatedIDs := make([]string, 0, len(knowledgeToUpdate))
+		for _, knowledge := range knowledgeToUpdate {
+			updatedIDs = append(updatedIDs, knowledge.ID)
+		}
+		return s.syncKnowledgeAttributes(ctx, tenantID, updatedIDs)
 	}
 
 	return nil
@@ -3811,7 +4022,10 @@ func (s *knowledgeService) UpdateFAQEntryTag(ctx context.Context, kbID string, e
 
 	chunk.TagID = resolvedTagID
 	chunk.UpdatedAt = time.Now()
-	return s.chunkRepo.UpdateChunk(ctx, chunk)
+	if err := s.chunkRepo.UpdateChunk(ctx, chunk); err != nil {
+		return err
+	}
+	return s.syncChunkAttributes(ctx, tenantID, []*types.Chunk{chunk})
 }
 
 // UpdateFAQEntryTagBatch updates tags for FAQ entries in batch.
@@ -3888,7 +4102,10 @@ func (s *knowledgeService) UpdateFAQEntryTagBatch(ctx context.Context, kbID stri
 	}
 
 	if len(chunksToUpdate) > 0 {
-		return s.chunkRepo.UpdateChunks(ctx, chunksToUpdate)
+		if err := s.chunkRepo.UpdateChunks(ctx, chunksToUpdate); err != nil {
+			return err
+		}
+		return s.syncChunkAttributes(ctx, tenantID, chunksToUpdate)
 	}
 
 	return nil
@@ -4591,6 +4808,11 @@ func (s *knowledgeService) indexFAQChunks(ctx context.Context,
 		indexInfo = append(indexInfo, infoList...)
 		chunkIDs = append(chunkIDs, chunk.ID)
 	}
+	attributes, err := s.chunkIndexAttributes(ctx, tenantInfo.ID, chunks)
+	if err != nil {
+		return err
+	}
+	setIndexAttributes(indexInfo, attributes)
 	buildIndexInfoDuration := time.Since(buildIndexInfoStartTime)
 	logger.Debugf(
 		ctx,
@@ -4629,6 +4851,7 @@ func (s *knowledgeService) indexFAQChunks(ctx context.Context,
 	if err := retrieveEngine.BatchIndex(ctx, embeddingModel, indexInfo); err != nil {
 		return err
 	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, knowledge.KnowledgeBaseID)
 	batchIndexDuration := time.Since(batchIndexStartTime)
 	logger.Debugf(ctx, "indexFAQChunks: batch indexed %d index info entries in %v (avg: %v per entry)",
 		len(indexInfo), batchIndexDuration, batchIndexDuration/time.Duration(len(indexInfo)))
@@ -4696,11 +4919,17 @@ func (s *knowledgeService) deleteFAQChunkVectors(ctx context.Context,
 		indexInfo = append(indexInfo, infoList...)
 		chunkIDs = append(chunkIDs, chunk.ID)
 	}
+	attributes, err := s.chunkIndexAttributes(ctx, tenantInfo.ID, chunks)
+	if err != nil {
+		return err
+	}
+	setIndexAttributes(indexInfo, attributes)
 
 	size := retrieveEngine.EstimateStorageSize(ctx, embeddingModel, indexInfo)
 	if err := retrieveEngine.DeleteByChunkIDList(ctx, chunkIDs, embeddingModel.GetDimensions(), types.KnowledgeTypeFAQ); err != nil {
 		return err
 	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, knowledge.KnowledgeBaseID)
 	if size > 0 {
 		if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantInfo.ID, -size); err == nil {
 			tenantInfo.StorageUsed -= size
@@ -5063,6 +5292,20 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 			return fmt.Errorf("failed to read from URL: %w", err)
 		}
 		chunks = urlResp.Chunks
+		// Baseline for the change detection of scheduled refreshes, which hash the text-only crawl
+		now := time.Now()
+		knowledge.LastCheckedAt = &now
+		knowledge.LastChangedAt = &now
+		textChunks := chunks
+		if payload.EnableMultimodel {
+			textChunks, err = s.readURLText(ctx, kb, payload.URL, knowledge.Title, payload.RequestId)
+		}
+		if err != nil {
+			// The first refresh then re-indexes the page and records the hash
+			logger.Warnf(ctx, "Failed to read URL text for content hash: %v", err)
+		} else {
+			knowledge.ContentHash = urlContentHash(textChunks)
+		}
 	} else if len(payload.Passages) > 0 {
 		// 文本段落导入
 		chunks := make([]*proto.Chunk, 0, len(payload.Passages))
@@ -5724,6 +5967,8 @@ func (s *knowledgeService) cloneFAQKnowledgeBase(
 		_ = s.saveKBCloneProgress(ctx, progress)
 	}
 
+	s.responseCache.InvalidateKnowledgeBases(ctx, dstKB.ID)
+
 	// Mark as completed
 	progress.Status = types.KBCloneStatusCompleted
 	progress.Progress = 100
@@ -5809,6 +6054,467 @@ func (s *knowledgeService) GetKBCloneProgress(ctx context.Context, taskID string
 	return &progress, nil
 }
 
+const (
+	kbReembedProgressKeyPrefix = "kb_reembed_progress:"
+	kbReembedRunningKeyPrefix  = "kb_reembed_running:"
+	kbReembedProgressTTL       = 24 * time.Hour
+	kbReembedBatchSize         = 100
+	// kbReembedClockSkew widens the window of chunks re-embedded again after the switch,
+	// as chunk update times may come from the database clock
+	kbReembedClockSkew = time.Minute
+)
+
+// getKBReembedProgressKey returns the Redis key for storing KB re-embedding progress
+func getKBReembedProgressKey(taskID string) string {
+	return kbReembedProgressKeyPrefix + taskID
+}
+
+// getKBReembedRunningKey returns the Redis key for storing the running re-embedding task ID by KB ID
+func getKBReembedRunningKey(kbID string) string {
+	return kbReembedRunningKeyPrefix + kbID
+}
+
+// saveKBReembedProgress saves the KB re-embedding progress to Redis
+func (s *knowledgeService) saveKBReembedProgress(ctx context.Context, progress *types.KBReembedProgress) error {
+	progress.UpdatedAt = time.Now().Unix()
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+	return s.redisClient.Set(ctx, getKBReembedProgressKey(progress.TaskID), data, kbReembedProgressTTL).Err()
+}
+
+// GetKBReembedProgress retrieves the progress of a knowledge base re-embedding task
+func (s *knowledgeService) GetKBReembedProgress(ctx context.Context, taskID string) (*types.KBReembedProgress, error) {
+	data, err := s.redisClient.Get(ctx, getKBReembedProgressKey(taskID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, werrors.NewNotFoundError("KB re-embedding task not found")
+		}
+		return nil, fmt.Errorf("failed to get progress from Redis: %w", err)
+	}
+
+	var progress types.KBReembedProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal progress: %w", err)
+	}
+	return &progress, nil
+}
+
+// ReembedKnowledgeBase validates the target embedding model and enqueues a task that moves the
+// knowledge base to it. Only one re-embedding task may run per knowledge base at a time.
+func (s *knowledgeService) ReembedKnowledgeBase(ctx context.Context,
+	kb *types.KnowledgeBase, modelID string,
+) (*types.KBReembedProgress, error) {
+	if modelID == kb.EmbeddingModelID {
+		return nil, werrors.NewBadRequestError("Knowledge base already uses this embedding model")
+	}
+	model, err := s.modelService.GetModelByID(ctx, modelID)
+	if err != nil || model == nil {
+		return nil, werrors.NewBadRequestError("Embedding model not found")
+	}
+	if model.Type != types.ModelTypeEmbedding {
+		return nil, werrors.NewBadRequestError("Model is not an embedding model")
+	}
+
+	sourceModel, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current embedding model: %w", err)
+	}
+	targetModel, err := s.modelService.GetEmbeddingModel(ctx, modelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target embedding model: %w", err)
+	}
+	// Old and new vectors are kept apart by dimension until the switch, so the dimension must change
+	if sourceModel.GetDimensions() == targetModel.GetDimensions() {
+		return nil, werrors.NewBadRequestError(fmt.Sprintf(
+			"Target embedding model has the same dimension (%d) as the current one", targetModel.GetDimensions()))
+	}
+
+	inProgress, err := s.repo.CountKnowledgeByStatus(ctx, kb.TenantID, kb.ID,
+		[]string{types.ParseStatusPending, types.ParseStatusProcessing})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count knowledge in progress: %w", err)
+	}
+	if inProgress > 0 {
+		return nil, werrors.NewBadRequestError(fmt.Sprintf(
+			"%d documents are still being processed, please retry when they are done", inProgress))
+	}
+
+	taskID := uuid.New().String()
+	acquired, err := s.redisClient.SetNX(ctx, getKBReembedRunningKey(kb.ID), taskID, kbReembedProgressTTL).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check running re-embedding task: %w", err)
+	}
+	if !acquired {
+		runningTaskID, _ := s.redisClient.Get(ctx, getKBReembedRunningKey(kb.ID)).Result()
+		return nil, werrors.NewConflictError(fmt.Sprintf(
+			"A re-embedding task is already running for this knowledge base (task ID: %s)", runningTaskID))
+	}
+
+	payload := types.KBReembedPayload{
+		TenantID:         kb.TenantID,
+		TaskID:           taskID,
+		KnowledgeBaseID:  kb.ID,
+		SourceModelID:    kb.EmbeddingModelID,
+		EmbeddingModelID: modelID,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		_ = s.redisClient.Del(ctx, getKBReembedRunningKey(kb.ID)).Err()
+		return nil, fmt.Errorf("failed to marshal KB re-embedding payload: %w", err)
+	}
+	task := asynq.NewTask(types.TypeKBReembed, payloadBytes, asynq.Queue("default"), asynq.MaxRetry(3))
+	info, err := s.task.Enqueue(task)
+	if err != nil {
+		_ = s.redisClient.Del(ctx, getKBReembedRunningKey(kb.ID)).Err()
+		return nil, fmt.Errorf("failed to enqueue KB re-embedding task: %w", err)
+	}
+	logger.Infof(ctx, "KB re-embedding task enqueued: %s, asynq task ID: %s, kb: %s, model: %s -> %s",
+		taskID, info.ID, kb.ID, kb.EmbeddingModelID, modelID)
+
+	progress := &types.KBReembedProgress{
+		TaskID:          taskID,
+		KnowledgeBaseID: kb.ID,
+		SourceModelID:   kb.EmbeddingModelID,
+		TargetModelID:   modelID,
+		SourceDimension: sourceModel.GetDimensions(),
+		TargetDimension: targetModel.GetDimensions(),
+		Status:          types.KBCloneStatusPending,
+		Message:         "Task queued, waiting to start...",
+		CreatedAt:       time.Now().Unix(),
+	}
+	if err := s.saveKBReembedProgress(ctx, progress); err != nil {
+		logger.Warnf(ctx, "Failed to save initial KB re-embedding progress: %v", err)
+	}
+	return progress, nil
+}
+
+// ProcessKBReembed handles Asynq knowledge base re-embedding tasks.
+//
+// Every chunk is indexed again with the target model. The new vectors have another dimension than
+// the old ones, so both sets coexist and searches keep using the old vectors until the knowledge base
+// is switched to the target model in one transaction. Chunks added or changed since the task was
+// enqueued were possibly indexed with the old model only, so they are indexed again after the switch.
+// The old-dimension vectors are deleted once no document is being processed anymore.
+func (s *knowledgeService) ProcessKBReembed(ctx context.Context, t *asynq.Task) error {
+	var payload types.KBReembedPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal KB re-embedding payload: %w", err)
+	}
+
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant info: %v", err)
+		return fmt.Errorf("failed to get tenant info: %w", err)
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	retryCount, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	isLastRetry := retryCount >= maxRetry
+
+	logger.Infof(ctx, "Processing KB re-embedding task: %s, kb: %s, model: %s -> %s, retry: %d/%d",
+		payload.TaskID, payload.KnowledgeBaseID, payload.SourceModelID, payload.EmbeddingModelID, retryCount, maxRetry)
+
+	progress := &types.KBReembedProgress{
+		TaskID:          payload.TaskID,
+		KnowledgeBaseID: payload.KnowledgeBaseID,
+		SourceModelID:   payload.SourceModelID,
+		TargetModelID:   payload.EmbeddingModelID,
+		Status:          types.KBCloneStatusProcessing,
+		Message:         "Starting knowledge base re-embedding...",
+	}
+	if previous, err := s.GetKBReembedProgress(ctx, payload.TaskID); err == nil {
+		progress.CreatedAt = previous.CreatedAt
+	}
+	// Only mark as failed on the last retry; the running lock is released when the task finishes
+	handleError := func(err error, message string) {
+		if isLastRetry {
+			progress.Status = types.KBCloneStatusFailed
+			progress.Error = err.Error()
+			progress.Message = message
+			_ = s.saveKBReembedProgress(ctx, progress)
+			_ = s.redisClient.Del(ctx, getKBReembedRunningKey(payload.KnowledgeBaseID)).Err()
+		}
+	}
+	_ = s.saveKBReembedProgress(ctx, progress)
+
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, payload.KnowledgeBaseID)
+	if err != nil {
+		handleError(err, "Failed to get knowledge base")
+		return err
+	}
+	sourceModel, err := s.modelService.GetEmbeddingModel(ctx, payload.SourceModelID)
+	if err != nil {
+		handleError(err, "Failed to get current embedding model")
+		return err
+	}
+	targetModel, err := s.modelService.GetEmbeddingModel(ctx, payload.EmbeddingModelID)
+	if err != nil {
+		handleError(err, "Failed to get target embedding model")
+		return err
+	}
+	progress.SourceDimension = sourceModel.GetDimensions()
+	progress.TargetDimension = targetModel.GetDimensions()
+
+	// Only engines holding vectors are re-indexed; keyword-only engines do not depend on the model
+	var vectorEngines []types.RetrieverEngineParams
+	for _, engine := range tenantInfo.GetEffectiveEngines() {
+		if engine.RetrieverType == types.VectorRetrieverType {
+			vectorEngines = append(vectorEngines, engine)
+		}
+	}
+	retrieveEngine, err := retriever.NewCompositeRetrieveEngine(s.retrieveEngine, vectorEngines)
+	if err != nil {
+		handleError(err, "Failed to initialize retrieve engine")
+		return err
+	}
+
+	switch kb.EmbeddingModelID {
+	case payload.SourceModelID:
+		total, err := s.chunkRepo.CountChunksByKnowledgeBaseID(ctx, kb.TenantID, kb.ID)
+		if err != nil {
+			handleError(err, "Failed to count chunks")
+			return err
+		}
+		progress.Total = int(total)
+		progress.Processed = 0
+		progress.Message = fmt.Sprintf("Re-embedding %d chunks...", total)
+		_ = s.saveKBReembedProgress(ctx, progress)
+		// An earlier attempt may have indexed part of the chunks already, indexing them again would duplicate them
+		if err := s.discardReembedVectors(ctx, kb, retrieveEngine, targetModel.GetDimensions()); err != nil {
+			handleError(err, "Failed to delete vectors of an earlier attempt")
+			return err
+		}
+		if err := s.reembedChunks(ctx, kb, retrieveEngine, targetModel, time.Time{}, false, progress); err != nil {
+			if isLastRetry {
+				_ = s.discardReembedVectors(ctx, kb, retrieveEngine, targetModel.GetDimensions())
+			}
+			handleError(err, "Failed to re-embed chunks")
+			return err
+		}
+		// Searches use the model of the knowledge base, so switching it makes the new vectors live at once
+		if err := s.kbService.GetRepository().SwitchEmbeddingModel(
+			ctx, kb.TenantID, kb.ID, payload.EmbeddingModelID,
+		); err != nil {
+			handleError(err, "Failed to switch embedding model")
+			return err
+		}
+		logger.Infof(ctx, "Knowledge base %s switched to embedding model %s", kb.ID, payload.EmbeddingModelID)
+	case payload.EmbeddingModelID:
+		// A retry after the switch only has to clean up the old vectors
+		logger.Infof(ctx, "Knowledge base %s already switched to embedding model %s", kb.ID, payload.EmbeddingModelID)
+	default:
+		// Retrying cannot help, so fail the task right away, keeping the vectors the knowledge base uses
+		logger.Errorf(ctx, "Embedding model of knowledge base %s changed to %s during re-embedding",
+			kb.ID, kb.EmbeddingModelID)
+		currentModel, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to get embedding model %s, keeping re-embedded vectors: %v",
+				kb.EmbeddingModelID, err)
+		} else if currentModel.GetDimensions() != targetModel.GetDimensions() {
+			_ = s.discardReembedVectors(ctx, kb, retrieveEngine, targetModel.GetDimensions())
+		}
+		isLastRetry = true
+		handleError(fmt.Errorf("embedding model of knowledge base changed to %s", kb.EmbeddingModelID),
+			"Embedding model of knowledge base changed during re-embedding")
+		return nil
+	}
+
+	// Documents processed before the switch were indexed with the old model, and the cursor over chunk IDs
+	// skips chunks created behind it, so chunks changed since the task was enqueued are indexed again
+	var since time.Time
+	if progress.CreatedAt > 0 {
+		since = time.Unix(progress.CreatedAt, 0).Add(-kbReembedClockSkew)
+	}
+	progress.Message = "Re-embedding chunks changed during re-embedding..."
+	_ = s.saveKBReembedProgress(ctx, progress)
+	// Chunks indexed by the first pass already have vectors of the target model, which are replaced
+	if err := s.reembedChunks(ctx, kb, retrieveEngine, targetModel, since, true, progress); err != nil {
+		handleError(err, "Failed to re-embed changed chunks")
+		return err
+	}
+
+	// Documents still being processed may have embedded their chunks with the old model,
+	// so the old vectors are kept until a retry finds all of them processed
+	inProgress, err := s.repo.CountKnowledgeByStatus(ctx, kb.TenantID, kb.ID,
+		[]string{types.ParseStatusPending, types.ParseStatusProcessing})
+	if err != nil {
+		handleError(err, "Failed to count knowledge in progress")
+		return err
+	}
+	if inProgress > 0 {
+		err := fmt.Errorf("%d documents are still being processed", inProgress)
+		handleError(err, "Documents are still being processed, old vectors were kept")
+		return err
+	}
+
+	progress.Message = "Deleting old vectors..."
+	_ = s.saveKBReembedProgress(ctx, progress)
+	if err := retrieveEngine.DeleteByKnowledgeBaseIDList(
+		ctx, []string{kb.ID}, sourceModel.GetDimensions(), kb.Type,
+	); err != nil {
+		handleError(err, "Failed to delete old vectors")
+		return err
+	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, kb.ID)
+
+	progress.Status = types.KBCloneStatusCompleted
+	progress.Progress = 100
+	progress.Processed = progress.Total
+	progress.Message = "Knowledge base re-embedding completed successfully"
+	if err := s.saveKBReembedProgress(ctx, progress); err != nil {
+		logger.Errorf(ctx, "Failed to update KB re-embedding progress to completed: %v", err)
+	}
+	_ = s.redisClient.Del(ctx, getKBReembedRunningKey(kb.ID)).Err()
+
+	logger.Infof(ctx, "KB re-embedding task completed: %s", payload.TaskID)
+	return nil
+}
+
+// reembedChunks indexes the chunks of the knowledge base updated since the given time, or all of them
+// when it is zero, with the target embedding model. With replace, the vectors the chunks already have
+// for the target model are deleted first
+func (s *knowledgeService) reembedChunks(ctx context.Context,
+	kb *types.KnowledgeBase,
+	retrieveEngine *retriever.CompositeRetrieveEngine,
+	embeddingModel embedding.Embedder,
+	since time.Time,
+	replace bool,
+	progress *types.KBReembedProgress,
+) error {
+	lastID := ""
+	for {
+		chunks, err := s.chunkRepo.ListChunksByKnowledgeBaseIDAfter(
+			ctx, kb.TenantID, kb.ID, since, lastID, kbReembedBatchSize,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to list chunks: %w", err)
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		lastID = chunks[len(chunks)-1].ID
+
+		indexInfoList := make([]*types.IndexInfo, 0, len(chunks))
+		disabled := make(map[string]bool)
+		for _, chunk := range chunks {
+			if !isReembedChunk(chunk) {
+				continue
+			}
+			infoList, err := s.buildReembedIndexInfoList(ctx, kb, chunk)
+			if err != nil {
+				return fmt.Errorf("failed to build index info for chunk %s: %w", chunk.ID, err)
+			}
+			indexInfoList = append(indexInfoList, infoList...)
+			if !chunk.IsEnabled {
+				disabled[chunk.ID] = false
+			}
+		}
+		attributes, err := s.chunkIndexAttributes(ctx, kb.TenantID, chunks)
+		if err != nil {
+			return fmt.Errorf("failed to get chunk attributes: %w", err)
+		}
+		setIndexAttributes(indexInfoList, attributes)
+		if replace && len(indexInfoList) > 0 {
+			sourceIDs := make([]string, 0, len(indexInfoList))
+			for _, info := range indexInfoList {
+				sourceIDs = append(sourceIDs, info.SourceID)
+			}
+			if err := retrieveEngine.DeleteBySourceIDList(
+				ctx, sourceIDs, embeddingModel.GetDimensions(), kb.Type,
+			); err != nil {
+				return fmt.Errorf("failed to delete vectors of changed chunks: %w", err)
+			}
+		}
+		if err := retrieveEngine.BatchIndex(ctx, embeddingModel, indexInfoList); err != nil {
+			return fmt.Errorf("failed to index chunks: %w", err)
+		}
+		if len(disabled) > 0 {
+			if err := retrieveEngine.BatchUpdateChunkEnabledStatus(ctx, disabled); err != nil {
+				return fmt.Errorf("failed to update chunk enabled status: %w", err)
+			}
+		}
+
+		progress.Processed += len(chunks)
+		if progress.Total > 0 {
+			// Chunks added while the task runs can push processed past the initial count
+			progress.Progress = min(progress.Processed*100/progress.Total, 99)
+		}
+		progress.Message = fmt.Sprintf("Re-embedded %d/%d chunks", progress.Processed, progress.Total)
+		_ = s.saveKBReembedProgress(ctx, progress)
+	}
+}
+
+// discardReembedVectors deletes the vectors indexed for the target model by a failed or earlier attempt
+func (s *knowledgeService) discardReembedVectors(ctx context.Context,
+	kb *types.KnowledgeBase, retrieveEngine *retriever.CompositeRetrieveEngine, dimension int,
+) error {
+	if err := retrieveEngine.DeleteByKnowledgeBaseIDList(ctx, []string{kb.ID}, dimension, kb.Type); err != nil {
+		logger.Errorf(ctx, "Failed to delete re-embedded vectors of knowledge base %s: %v", kb.ID, err)
+		return err
+	}
+	logger.Infof(ctx, "Deleted re-embedded vectors of dimension %d of knowledge base %s", dimension, kb.ID)
+	return nil
+}
+
+// isReembedChunk reports whether the chunk has vectors in the retrieve engines
+func isReembedChunk(chunk *types.Chunk) bool {
+	if chunk.Status != int(types.ChunkStatusDefault) && chunk.Status != int(types.ChunkStatusIndexed) {
+		return false
+	}
+	switch chunk.ChunkType {
+	case types.ChunkTypeEntity, types.ChunkTypeRelationship, types.ChunkTypeWebSearch:
+		return false
+	}
+	return true
+}
+
+// setIndexAttributes sets the filter attributes of each index entry from those of its chunk
+func setIndexAttributes(indexInfoList []*types.IndexInfo, attributes map[string]*types.IndexAttributes) {
+	for _, info := range indexInfoList {
+		info.Attributes = attributes[info.ChunkID]
+	}
+}
+
+// buildReembedIndexInfoList rebuilds the index entries of a chunk the way they were created at import time
+func (s *knowledgeService) buildReembedIndexInfoList(ctx context.Context,
+	kb *types.KnowledgeBase, chunk *types.Chunk,
+) ([]*types.IndexInfo, error) {
+	if kb.Type == types.KnowledgeBaseTypeFAQ {
+		return s.buildFAQIndexInfoList(ctx, kb, chunk)
+	}
+
+	indexInfoList := []*types.IndexInfo{{
+		Content:         chunk.Content,
+		SourceID:        chunk.ID,
+		SourceType:      types.ChunkSourceType,
+		ChunkID:         chunk.ID,
+		KnowledgeID:     chunk.KnowledgeID,
+		KnowledgeBaseID: chunk.KnowledgeBaseID,
+	}}
+	meta, err := chunk.DocumentMetadata()
+	if err != nil {
+		logger.Warnf(ctx, "Failed to parse document metadata of chunk %s: %v", chunk.ID, err)
+		return indexInfoList, nil
+	}
+	if meta != nil {
+		for _, question := range meta.GeneratedQuestions {
+			indexInfoList = append(indexInfoList, &types.IndexInfo{
+				Content:         question.Question,
+				SourceID:        fmt.Sprintf("%s-%s", chunk.ID, question.ID),
+				SourceType:      types.ChunkSourceType,
+				ChunkID:         chunk.ID,
+				KnowledgeID:     chunk.KnowledgeID,
+				KnowledgeBaseID: chunk.KnowledgeBaseID,
+			})
+		}
+	}
+	return indexInfoList, nil
+}
+
 // getOrCreateTagInTarget finds or creates a tag in the target knowledge base based on the source tag.
 // It looks up the source tag by ID, then tries to find a tag with the same name in the target KB.
 // If not found, it creates a new tag with the same properties.
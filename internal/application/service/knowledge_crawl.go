@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/service/webcrawler"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	webCrawlProgressKeyPrefix = "web_crawl_progress:"
+	webCrawlProgressTTL       = 24 * time.Hour
+	// webCrawlTimeout bounds a whole crawl, pages are fetched one at a time with a per-host delay
+	webCrawlTimeout = 2 * time.Hour
+)
+
+// getWebCrawlProgressKey returns the Redis key for storing website crawl progress
+func getWebCrawlProgressKey(taskID string) string {
+	return webCrawlProgressKeyPrefix + taskID
+}
+
+// webCrawlOptions converts a crawl request into crawler options
+func webCrawlOptions(req *types.WebCrawlRequest) webcrawler.Options {
+	maxDepth := webcrawler.DefaultMaxDepth
+	if req.MaxDepth != nil {
+		maxDepth = *req.MaxDepth
+	}
+	sameHost := true
+	if req.SameHost != nil {
+		sameHost = *req.SameHost
+	}
+	return webcrawler.Options{
+		SeedURL:    req.URL,
+		SitemapURL: req.SitemapURL,
+		MaxDepth:   maxDepth,
+		MaxPages:   req.MaxPages,
+		SameHost:   sameHost,
+		Include:    req.Include,
+		Exclude:    req.Exclude,
+	}
+}
+
+// saveWebCrawlProgress saves the website crawl progress to Redis
+func (s *knowledgeService) saveWebCrawlProgress(ctx context.Context, progress *types.WebCrawlProgress) error {
+	progress.UpdatedAt = time.Now().Unix()
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal website crawl progress: %w", err)
+	}
+	return s.redisClient.Set(ctx, getWebCrawlProgressKey(progress.TaskID), data, webCrawlProgressTTL).Err()
+}
+
+// GetWebCrawlProgress retrieves the progress of a website crawl task of the current tenant
+func (s *knowledgeService) GetWebCrawlProgress(ctx context.Context, taskID string) (*types.WebCrawlProgress, error) {
+	data, err := s.redisClient.Get(ctx, getWebCrawlProgressKey(taskID)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, werrors.NewNotFoundError("Website crawl task not found")
+		}
+		return nil, fmt.Errorf("failed to get website crawl progress from Redis: %w", err)
+	}
+
+	var progress types.WebCrawlProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal website crawl progress: %w", err)
+	}
+	if tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64); ok && progress.TenantID != tenantID {
+		return nil, werrors.NewNotFoundError("Website crawl task not found")
+	}
+	return &progress, nil
+}
+
+// CrawlWebsite validates the crawl options and enqueues a task that crawls the website and creates
+// one URL knowledge per page in the knowledge base
+func (s *knowledgeService) CrawlWebsite(ctx context.Context,
+	kbID string, req *types.WebCrawlRequest,
+) (*types.WebCrawlProgress, error) {
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get knowledge base: %v", err)
+		return nil, err
+	}
+	if kb.Type == types.KnowledgeBaseTypeFAQ {
+		return nil, werrors.NewBadRequestError("FAQ knowledge bases do not support website crawling")
+	}
+	crawler, err := webcrawler.New(webCrawlOptions(req))
+	if err != nil {
+		return nil, werrors.NewValidationError(err.Error())
+	}
+	if err := s.validateRefreshInterval(req.RefreshInterval); err != nil {
+		return nil, err
+	}
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	taskID := uuid.New().String()
+	payload := types.WebCrawlPayload{
+		TenantID:        tenantID,
+		TaskID:          taskID,
+		KnowledgeBaseID: kbID,
+		Request:         *req,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal website crawl payload: %w", err)
+	}
+	// A failed crawl is not retried, the pages it already created would only be reported as duplicates
+	task := asynq.NewTask(types.TypeWebCrawl, payloadBytes,
+		asynq.Queue("default"), asynq.MaxRetry(0), asynq.Timeout(webCrawlTimeout))
+	info, err := s.task.Enqueue(task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue website crawl task: %w", err)
+	}
+	logger.Infof(ctx, "Website crawl task enqueued: %s, asynq task ID: %s, kb: %s", taskID, info.ID, kbID)
+
+	progress := &types.WebCrawlProgress{
+		TaskID:          taskID,
+		TenantID:        tenantID,
+		KnowledgeBaseID: kbID,
+		SeedURL:         req.URL,
+		SitemapURL:      req.SitemapURL,
+		Status:          types.KBCloneStatusPending,
+		MaxPages:        crawler.Options().MaxPages,
+		Message:         "Task queued, waiting to start...",
+		CreatedAt:       time.Now().Unix(),
+	}
+	if err := s.saveWebCrawlProgress(ctx, progress); err != nil {
+		logger.Warnf(ctx, "Failed to save initial website crawl progress: %v", err)
+	}
+	return progress, nil
+}
+
+// ProcessWebCrawl handles Asynq website crawl tasks. Every crawled page goes through
+// CreateKnowledgeFromURL, so pages already in the knowledge base are skipped as duplicates and each
+// new page is parsed by its own document process task.
+func (s *knowledgeService) ProcessWebCrawl(ctx context.Context, t *asynq.Task) error {
+	var payload types.WebCrawlPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal website crawl payload: %w", err)
+	}
+	req := payload.Request
+
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	progress := &types.WebCrawlProgress{
+		TaskID:          payload.TaskID,
+		TenantID:        payload.TenantID,
+		KnowledgeBaseID: payload.KnowledgeBaseID,
+		SeedURL:         req.URL,
+		SitemapURL:      req.SitemapURL,
+		Status:          types.KBCloneStatusProcessing,
+		Message:         "Crawling website...",
+	}
+	if previous, err := s.GetWebCrawlProgress(ctx, payload.TaskID); err == nil {
+		progress.CreatedAt = previous.CreatedAt
+	}
+	fail := func(err error, message string) error {
+		progress.Status = types.KBCloneStatusFailed
+		progress.Error = err.Error()
+		progress.Message = message
+		_ = s.saveWebCrawlProgress(ctx, progress)
+		return err
+	}
+
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant info: %v", err)
+		return fail(err, "Failed to get tenant info")
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	crawler, err := webcrawler.New(webCrawlOptions(&req))
+	if err != nil {
+		return fail(err, "Invalid crawl options")
+	}
+	progress.MaxPages = crawler.Options().MaxPages
+	_ = s.saveWebCrawlProgress(ctx, progress)
+
+	logger.Infof(ctx, "Processing website crawl task: %s, kb: %s, seed: %s, sitemap: %s",
+		payload.TaskID, payload.KnowledgeBaseID, req.URL, req.SitemapURL)
+
+	updateStats := func() {
+		stats := crawler.Stats()
+		progress.Discovered = stats.Discovered
+		progress.Skipped = stats.Skipped
+		progress.Progress = progress.Processed * 100 / progress.MaxPages
+	}
+	err = crawler.Run(ctx, func(ctx context.Context, page webcrawler.Page) error {
+		_, err := s.CreateKnowledgeFromURL(ctx, payload.KnowledgeBaseID, page.URL,
+			req.EnableMultimodel, "", req.RefreshInterval)
+		progress.Processed++
+		var dupErr *types.DuplicateKnowledgeError
+		var quotaErr *types.StorageQuotaExceededError
+		switch {
+		case err == nil:
+			progress.Created++
+		case errors.As(err, &dupErr):
+			progress.Duplicated++
+		case errors.As(err, &quotaErr):
+			// Every further page would fail the same way
+			progress.Failed++
+			return err
+		default:
+			progress.Failed++
+			logger.Warnf(ctx, "Failed to create knowledge from crawled page %s: %v", page.URL, err)
+		}
+		updateStats()
+		if err := s.saveWebCrawlProgress(ctx, progress); err != nil {
+			logger.Warnf(ctx, "Failed to save website crawl progress: %v", err)
+		}
+		return nil
+	})
+	updateStats()
+	if err != nil {
+		logger.Errorf(ctx, "Website crawl task %s failed: %v", payload.TaskID, err)
+		return fail(err, "Website crawl failed")
+	}
+
+	progress.Status = types.KBCloneStatusCompleted
+	progress.Progress = 100
+	progress.Message = fmt.Sprintf("Website crawl completed, %d pages created, %d already existed, %d failed",
+		progress.Created, progress.Duplicated, progress.Failed)
+	if err := s.saveWebCrawlProgress(ctx, progress); err != nil {
+		logger.Warnf(ctx, "Failed to save website crawl progress: %v", err)
+	}
+	logger.Infof(ctx, "Website crawl task %s completed: %s", payload.TaskID, progress.Message)
+	return nil
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	reembedSourceDim = 768
+	reembedTargetDim = 1024
+)
+
+type reembedEmbedder struct {
+	embedding.Embedder
+	id  string
+	dim int
+}
+
+func (e *reembedEmbedder) GetModelID() string   { return e.id }
+func (e *reembedEmbedder) GetModelName() string { return e.id }
+func (e *reembedEmbedder) GetDimensions() int   { return e.dim }
+
+type reembedModelService struct {
+	interfaces.ModelService
+	dims map[string]int
+}
+
+func (s *reembedModelService) GetEmbeddingModel(ctx context.Context, modelID string) (embedding.Embedder, error) {
+	dim, ok := s.dims[modelID]
+	if !ok {
+		return nil, errors.New("model not found")
+	}
+	return &reembedEmbedder{id: modelID, dim: dim}, nil
+}
+
+type reembedTenantRepo struct {
+	interfaces.TenantRepository
+}
+
+func (r *reembedTenantRepo) GetTenantByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	return &types.Tenant{ID: id, RetrieverEngines: types.RetrieverEngines{Engines: []types.RetrieverEngineParams{
+		{RetrieverType: types.VectorRetrieverType, RetrieverEngineType: types.PostgresRetrieverEngineType},
+	}}}, nil
+}
+
+type reembedKBService struct {
+	interfaces.KnowledgeBaseService
+	kb *types.KnowledgeBase
+}
+
+func (s *reembedKBService) GetKnowledgeBaseByID(ctx context.Context, id string) (*types.KnowledgeBase, error) {
+	kb := *s.kb
+	return &kb, nil
+}
+
+func (s *reembedKBService) GetRepository() interfaces.KnowledgeBaseRepository {
+	return &reembedKBRepo{kb: s.kb}
+}
+
+type reembedKBRepo struct {
+	interfaces.KnowledgeBaseRepository
+	kb *types.KnowledgeBase
+}
+
+func (r *reembedKBRepo) SwitchEmbeddingModel(ctx context.Context, tenantID uint64, id, modelID string) error {
+	r.kb.EmbeddingModelID = modelID
+	return nil
+}
+
+type reembedKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+}
+
+func (r *reembedKnowledgeRepo) CountKnowledgeByStatus(ctx context.Context,
+	tenantID uint64, kbID string, parseStatuses []string,
+) (int64, error) {
+	return 0, nil
+}
+
+func (r *reembedKnowledgeRepo) GetKnowledgeBatch(ctx context.Context,
+	tenantID uint64, ids []string,
+) ([]*types.Knowledge, error) {
+	knowledgeList := make([]*types.Knowledge, 0, len(ids))
+	for _, id := range ids {
+		knowledgeList = append(knowledgeList, &types.Knowledge{ID: id, FileType: "pdf"})
+	}
+	return knowledgeList, nil
+}
+
+// reembedChunkRepo lists chunks by ID; onList runs after each listing, listErr fails listings after the first
+type reembedChunkRepo struct {
+	interfaces.ChunkRepository
+	chunks  []*types.Chunk
+	lists   int
+	onList  func(repo *reembedChunkRepo)
+	listErr error
+}
+
+func (r *reembedChunkRepo) CountChunksByKnowledgeBaseID(ctx context.Context, tenantID uint64, kbID string) (int64, error) {
+	return int64(len(r.chunks)), nil
+}
+
+func (r *reembedChunkRepo) ListChunksByKnowledgeBaseIDAfter(ctx context.Context,
+	tenantID uint64, kbID string, since time.Time, afterID string, limit int,
+) ([]*types.Chunk, error) {
+	r.lists++
+	if r.listErr != nil && r.lists > 1 {
+		return nil, r.listErr
+	}
+	sort.Slice(r.chunks, func(i, j int) bool { return r.chunks[i].ID < r.chunks[j].ID })
+	var listed []*types.Chunk
+	for _, chunk := range r.chunks {
+		if chunk.ID > afterID && !chunk.UpdatedAt.Before(since) && len(listed) < limit {
+			listed = append(listed, chunk)
+		}
+	}
+	if r.onList != nil {
+		r.onList(r)
+	}
+	return listed, nil
+}
+
+// reembedVectorEngine keeps the dimensions of the vectors indexed for each chunk, a chunk indexed twice
+// with the same model has the dimension twice
+type reembedVectorEngine struct {
+	interfaces.RetrieveEngineService
+	mu      sync.Mutex
+	vectors map[string][]int
+}
+
+func (e *reembedVectorEngine) EngineType() types.RetrieverEngineType {
+	return types.PostgresRetrieverEngineType
+}
+
+func (e *reembedVectorEngine) Support() []types.RetrieverType {
+	return []types.RetrieverType{types.VectorRetrieverType}
+}
+
+func (e *reembedVectorEngine) index(chunkID string, dim int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.vectors[chunkID] = append(e.vectors[chunkID], dim)
+	slices.Sort(e.vectors[chunkID])
+}
+
+func (e *reembedVectorEngine) BatchIndex(ctx context.Context, embedder embedding.Embedder,
+	indexInfoList []*types.IndexInfo, retrieverTypes []types.RetrieverType,
+) error {
+	for _, info := range indexInfoList {
+		e.index(info.ChunkID, embedder.GetDimensions())
+	}
+	return nil
+}
+
+func (e *reembedVectorEngine) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for chunkID, dims := range e.vectors {
+		e.vectors[chunkID] = slices.DeleteFunc(dims, func(dim int) bool { return dim == dimension })
+	}
+	return nil
+}
+
+func (e *reembedVectorEngine) DeleteBySourceIDList(ctx context.Context,
+	sourceIDList []string, dimension int, knowledgeType string,
+) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// Text chunks are indexed with their ID as source ID
+	for _, sourceID := range sourceIDList {
+		e.vectors[sourceID] = slices.DeleteFunc(e.vectors[sourceID], func(dim int) bool { return dim == dimension })
+	}
+	return nil
+}
+
+func (e *reembedVectorEngine) BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error {
+	return nil
+}
+
+type reembedEngineRegistry struct {
+	interfaces.RetrieveEngineRegistry
+	engine *reembedVectorEngine
+}
+
+func (r *reembedEngineRegistry) GetRetrieveEngineService(
+	engineType types.RetrieverEngineType,
+) (interfaces.RetrieveEngineService, error) {
+	return r.engine, nil
+}
+
+type reembedResponseCache struct {
+	interfaces.ResponseCacheService
+}
+
+func (c *reembedResponseCache) InvalidateKnowledgeBases(ctx context.Context, kbIDs ...string) {}
+
+// offlineRedisClient returns a Redis client whose commands fail at once, progress is then not persisted
+func offlineRedisClient() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, errors.New("redis is offline")
+		},
+		MaxRetries: -1,
+	})
+}
+
+func reembedChunk(id string) *types.Chunk {
+	return &types.Chunk{
+		ID: id, KnowledgeID: "k1", KnowledgeBaseID: "kb1", Content: "content " + id,
+		ChunkType: types.ChunkTypeText, IsEnabled: true, UpdatedAt: time.Now(),
+	}
+}
+
+func TestProcessKBReembed(t *testing.T) {
+	tests := []struct {
+		name        string
+		kbModel     string
+		vectors     map[string][]int
+		onList      func(engine *reembedVectorEngine) func(repo *reembedChunkRepo)
+		listErr     error
+		wantErr     bool
+		wantModel   string
+		wantVectors map[string][]int
+	}{
+		{
+			name:        "switches the model",
+			kbModel:     "source",
+			vectors:     map[string][]int{"c2": {reembedSourceDim}, "c3": {reembedSourceDim}},
+			wantModel:   "target",
+			wantVectors: map[string][]int{"c2": {reembedTargetDim}, "c3": {reembedTargetDim}},
+		},
+		{
+			name:    "document uploaded during re-embedding",
+			kbModel: "source",
+			vectors: map[string][]int{"c2": {reembedSourceDim}, "c3": {reembedSourceDim}},
+			// A chunk sorting before the cursor is indexed with the old model while the first page is re-embedded
+			onList: func(engine *reembedVectorEngine) func(repo *reembedChunkRepo) {
+				return func(repo *reembedChunkRepo) {
+					if repo.lists == 1 {
+						repo.chunks = append(repo.chunks, reembedChunk("c1"))
+						engine.index("c1", reembedSourceDim)
+					}
+				}
+			},
+			wantModel: "target",
+			wantVectors: map[string][]int{
+				"c1": {reembedTargetDim}, "c2": {reembedTargetDim}, "c3": {reembedTargetDim},
+			},
+		},
+		{
+			name:        "retry after a failed attempt",
+			kbModel:     "source",
+			vectors:     map[string][]int{"c2": {reembedSourceDim, reembedTargetDim}, "c3": {reembedSourceDim}},
+			wantModel:   "target",
+			wantVectors: map[string][]int{"c2": {reembedTargetDim}, "c3": {reembedTargetDim}},
+		},
+		{
+			name:        "re-embedding fails",
+			kbModel:     "source",
+			vectors:     map[string][]int{"c2": {reembedSourceDim}, "c3": {reembedSourceDim}},
+			listErr:     errors.New("database is down"),
+			wantErr:     true,
+			wantModel:   "source",
+			wantVectors: map[string][]int{"c2": {reembedSourceDim}, "c3": {reembedSourceDim}},
+		},
+		{
+			name:    "model changed during re-embedding",
+			kbModel: "other",
+			vectors: map[string][]int{
+				"c2": {reembedSourceDim, reembedTargetDim}, "c3": {reembedSourceDim, reembedTargetDim},
+			},
+			wantModel:   "other",
+			wantVectors: map[string][]int{"c2": {reembedSourceDim}, "c3": {reembedSourceDim}},
+		},
+		{
+			name:        "model changed to one of the target dimension",
+			kbModel:     "same-dim",
+			vectors:     map[string][]int{"c2": {reembedTargetDim}, "c3": {reembedTargetDim}},
+			wantModel:   "same-dim",
+			wantVectors: map[string][]int{"c2": {reembedTargetDim}, "c3": {reembedTargetDim}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &reembedVectorEngine{vectors: tt.vectors}
+			chunkRepo := &reembedChunkRepo{
+				chunks:  []*types.Chunk{reembedChunk("c2"), reembedChunk("c3")},
+				listErr: tt.listErr,
+			}
+			if tt.onList != nil {
+				chunkRepo.onList = tt.onList(engine)
+			}
+			kbService := &reembedKBService{kb: &types.KnowledgeBase{
+				ID: "kb1", TenantID: 1, Type: types.KnowledgeBaseTypeDocument, EmbeddingModelID: tt.kbModel,
+			}}
+			svc := &knowledgeService{
+				retrieveEngine: &reembedEngineRegistry{engine: engine},
+				repo:           &reembedKnowledgeRepo{},
+				kbService:      kbService,
+				tenantRepo:     &reembedTenantRepo{},
+				chunkRepo:      chunkRepo,
+				modelService: &reembedModelService{dims: map[string]int{
+					"source": reembedSourceDim, "target": reembedTargetDim, "other": 512, "same-dim": reembedTargetDim,
+				}},
+				redisClient:   offlineRedisClient(),
+				responseCache: &reembedResponseCache{},
+			}
+
+			payload, _ := json.Marshal(types.KBReembedPayload{
+				TenantID: 1, TaskID: "task-1", KnowledgeBaseID: "kb1",
+				SourceModelID: "source", EmbeddingModelID: "target",
+			})
+			err := svc.ProcessKBReembed(context.Background(), asynq.NewTask(types.TypeKBReembed, payload))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessKBReembed error = %v, want error %v", err, tt.wantErr)
+			}
+			if kbService.kb.EmbeddingModelID != tt.wantModel {
+				t.Errorf("embedding model = %s, want %s", kbService.kb.EmbeddingModelID, tt.wantModel)
+			}
+			for chunkID, want := range tt.wantVectors {
+				if got := engine.vectors[chunkID]; !slices.Equal(got, want) {
+					t.Errorf("vectors of chunk %s have dimensions %v, want %v", chunkID, got, want)
+				}
+			}
+			if len(engine.vectors) != len(tt.wantVectors) {
+				t.Errorf("vectors of chunks %s, want %d chunks", strings.Join(mapsKeys(engine.vectors), ","),
+					len(tt.wantVectors))
+			}
+		})
+	}
+}
+
+func mapsKeys(m map[string][]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/docreader/proto"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// Defaults used when the url_refresh section leaves a setting empty
+const (
+	defaultURLRefreshBatchSize   = 100
+	defaultURLRefreshMinInterval = time.Hour
+)
+
+// urlRefreshMinInterval returns the shortest refresh interval a URL knowledge may use
+func (s *knowledgeService) urlRefreshMinInterval() time.Duration {
+	if s.config.KnowledgeBase != nil && s.config.KnowledgeBase.URLRefresh != nil &&
+		s.config.KnowledgeBase.URLRefresh.MinInterval > 0 {
+		return s.config.KnowledgeBase.URLRefresh.MinInterval
+	}
+	return defaultURLRefreshMinInterval
+}
+
+// urlRefreshBatchSize returns how many refreshes a single scan enqueues at most
+func (s *knowledgeService) urlRefreshBatchSize() int {
+	if s.config.KnowledgeBase != nil && s.config.KnowledgeBase.URLRefresh != nil &&
+		s.config.KnowledgeBase.URLRefresh.BatchSize > 0 {
+		return s.config.KnowledgeBase.URLRefresh.BatchSize
+	}
+	return defaultURLRefreshBatchSize
+}
+
+// validateRefreshInterval checks a refresh interval in seconds, 0 disables the refresh
+func (s *knowledgeService) validateRefreshInterval(refreshInterval int) error {
+	if refreshInterval < 0 {
+		return werrors.NewValidationError("刷新间隔不能为负数")
+	}
+	minInterval := s.urlRefreshMinInterval()
+	if refreshInterval > 0 && time.Duration(refreshInterval)*time.Second < minInterval {
+		return werrors.NewValidationError(fmt.Sprintf("刷新间隔不能小于%d秒", int(minInterval.Seconds())))
+	}
+	return nil
+}
+
+// UpdateKnowledgeRefreshInterval sets how often URL knowledge is re-crawled, 0 disables the refresh
+func (s *knowledgeService) UpdateKnowledgeRefreshInterval(ctx context.Context,
+	id string, refreshInterval int,
+) (*types.Knowledge, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, id)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get knowledge: %v", err)
+		return nil, werrors.NewNotFoundError("知识不存在")
+	}
+	if knowledge.Type != types.KnowledgeTypeURL {
+		return nil, werrors.NewBadRequestError("仅支持URL知识设置定时刷新")
+	}
+	if err := s.validateRefreshInterval(refreshInterval); err != nil {
+		return nil, err
+	}
+
+	// Only the column is written, a concurrent parse task saves the whole record
+	if err := s.repo.UpdateKnowledgeColumn(ctx, knowledge.ID, "refresh_interval", refreshInterval); err != nil {
+		logger.Errorf(ctx, "Failed to update knowledge refresh interval: %v", err)
+		return nil, err
+	}
+	knowledge.RefreshInterval = refreshInterval
+
+	logger.Infof(ctx, "Knowledge refresh interval updated, ID: %s, interval: %ds", knowledge.ID, refreshInterval)
+	return knowledge, nil
+}
+
+// RefreshURLKnowledge enqueues an immediate re-crawl of URL knowledge
+func (s *knowledgeService) RefreshURLKnowledge(ctx context.Context, id string) error {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, id)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get knowledge: %v", err)
+		return werrors.NewNotFoundError("知识不存在")
+	}
+	if knowledge.Type != types.KnowledgeTypeURL {
+		return werrors.NewBadRequestError("仅支持刷新URL知识")
+	}
+	if knowledge.ParseStatus != types.ParseStatusCompleted && knowledge.ParseStatus != types.ParseStatusFailed {
+		return werrors.NewConflictError("知识正在处理中，请稍后再试")
+	}
+
+	if err := s.enqueueURLRefreshTask(ctx, knowledge); err != nil {
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			return werrors.NewConflictError("该知识已有刷新任务在执行")
+		}
+		logger.Errorf(ctx, "Failed to enqueue URL refresh task: %v", err)
+		return err
+	}
+	return nil
+}
+
+// enqueueURLRefreshTask enqueues the refresh of a URL knowledge. The task ID is derived from the
+// knowledge, so a knowledge is never queued twice while its refresh is pending.
+func (s *knowledgeService) enqueueURLRefreshTask(ctx context.Context, knowledge *types.Knowledge) error {
+	payloadBytes, err := json.Marshal(types.URLRefreshPayload{
+		TenantID:    knowledge.TenantID,
+		KnowledgeID: knowledge.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	task := asynq.NewTask(types.TypeURLRefresh, payloadBytes,
+		asynq.Queue("low"), asynq.MaxRetry(3), asynq.TaskID("url_refresh:"+knowledge.ID))
+	info, err := s.task.Enqueue(task)
+	if err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Enqueued URL refresh task: id=%s knowledge_id=%s", info.ID, knowledge.ID)
+	return nil
+}
+
+// ProcessURLRefreshScan handles the periodic scan that enqueues the refresh of URL knowledge
+// whose refresh interval has elapsed
+func (s *knowledgeService) ProcessURLRefreshScan(ctx context.Context, t *asynq.Task) error {
+	knowledgeList, err := s.repo.ListURLKnowledgeDueForRefresh(ctx, time.Now(), s.urlRefreshBatchSize())
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list URL knowledge due for refresh: %v", err)
+		return err
+	}
+	if len(knowledgeList) == 0 {
+		return nil
+	}
+
+	enqueued := 0
+	for _, knowledge := range knowledgeList {
+		if err := s.enqueueURLRefreshTask(ctx, knowledge); err != nil {
+			if !errors.Is(err, asynq.ErrTaskIDConflict) {
+				logger.Warnf(ctx, "Failed to enqueue URL refresh task for knowledge %s: %v", knowledge.ID, err)
+			}
+			continue
+		}
+		enqueued++
+	}
+	logger.Infof(ctx, "URL refresh scan found %d due knowledge, enqueued %d", len(knowledgeList), enqueued)
+	return nil
+}
+
+// ProcessURLRefresh handles Asynq URL refresh tasks. The page is re-crawled and compared with the
+// stored content hash, and the knowledge is only re-chunked and re-indexed when the page changed.
+func (s *knowledgeService) ProcessURLRefresh(ctx context.Context, t *asynq.Task) error {
+	var payload types.URLRefreshPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "failed to unmarshal URL refresh task payload: %v", err)
+		return nil
+	}
+
+	requestID := uuid.New().String()
+	ctx = logger.WithRequestID(ctx, requestID)
+	ctx = logger.WithField(ctx, "url_refresh", payload.KnowledgeID)
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+
+	retryCount, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	isLastRetry := retryCount >= maxRetry
+
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "failed to get tenant: %v", err)
+		return nil
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, payload.TenantID, payload.KnowledgeID)
+	if err != nil || knowledge == nil {
+		logger.Warnf(ctx, "URL knowledge not found, skipping refresh: %s", payload.KnowledgeID)
+		return nil
+	}
+	// A parse task owns the knowledge until it finishes
+	if knowledge.ParseStatus != types.ParseStatusCompleted && knowledge.ParseStatus != types.ParseStatusFailed {
+		logger.Infof(ctx, "Knowledge is %s, skipping refresh: %s", knowledge.ParseStatus, knowledge.ID)
+		return nil
+	}
+
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, knowledge.KnowledgeBaseID)
+	if err != nil {
+		logger.Errorf(ctx, "failed to get knowledge base: %v", err)
+		return nil
+	}
+
+	textChunks, err := s.readURLText(ctx, kb, knowledge.Source, knowledge.Title, requestID)
+	if err != nil {
+		return s.handleURLRefreshError(ctx, knowledge, isLastRetry, fmt.Errorf("failed to read from URL: %w", err))
+	}
+
+	now := time.Now()
+	contentHash := urlContentHash(textChunks)
+	if contentHash == knowledge.ContentHash && knowledge.ParseStatus == types.ParseStatusCompleted {
+		if err := s.repo.UpdateKnowledgeColumn(ctx, knowledge.ID, "last_checked_at", now); err != nil {
+			logger.Errorf(ctx, "failed to update knowledge last checked time: %v", err)
+			return err
+		}
+		logger.Infof(ctx, "URL content unchanged, knowledge: %s", knowledge.ID)
+		return nil
+	}
+
+	logger.Infof(ctx, "URL content changed, re-indexing knowledge: %s", knowledge.ID)
+	chunks := textChunks
+	if kb.IsMultimodalEnabled() {
+		vlmConfig, err := s.getVLMProtoConfig(ctx, kb)
+		if err != nil {
+			logger.Warnf(ctx, "URL refresh build VLM config failed: %v", err)
+		}
+		multimodalResp, err := s.docReaderClient.ReadFromURL(ctx, &proto.ReadFromURLRequest{
+			Url:        knowledge.Source,
+			Title:      knowledge.Title,
+			ReadConfig: urlReadConfig(kb, true, vlmConfig),
+			RequestId:  requestID,
+		})
+		if err != nil {
+			return s.handleURLRefreshError(ctx, knowledge, isLastRetry, fmt.Errorf("failed to read from URL: %w", err))
+		}
+		chunks = multimodalResp.Chunks
+	}
+
+	previousStatus, previousHash, previousChangedAt := knowledge.ParseStatus, knowledge.ContentHash, knowledge.LastChangedAt
+	knowledge.ContentHash = contentHash
+	knowledge.LastCheckedAt = &now
+	knowledge.LastChangedAt = &now
+	knowledge.ParseStatus = types.ParseStatusProcessing
+	knowledge.ErrorMessage = ""
+	knowledge.UpdatedAt = now
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		logger.Errorf(ctx, "failed to update knowledge status to processing: %v", err)
+		return err
+	}
+
+	// The previous chunks keep serving until the new ones are indexed
+	options := ProcessChunksOptions{ReplacePrevious: true}
+	if kb.QuestionGenerationConfig != nil && kb.QuestionGenerationConfig.Enabled {
+		options.EnableQuestionGeneration = true
+		options.QuestionCount = kb.QuestionGenerationConfig.QuestionCount
+	}
+	s.processChunks(ctx, kb, knowledge, chunks, options)
+
+	if knowledge.ParseStatus == types.ParseStatusFailed {
+		// The previous content is still indexed, the next refresh retries the new one
+		logger.Warnf(ctx, "URL refresh failed, keeping previous content of knowledge %s: %s",
+			knowledge.ID, knowledge.ErrorMessage)
+		knowledge.ParseStatus = previousStatus
+		knowledge.ContentHash = previousHash
+		knowledge.LastChangedAt = previousChangedAt
+		knowledge.UpdatedAt = time.Now()
+		if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+			logger.Errorf(ctx, "failed to restore knowledge status after URL refresh: %v", err)
+		}
+	}
+	return nil
+}
+
+// readURLText crawls a page without multimodal processing, the content hash is computed on this text
+// so that image captions do not change it
+func (s *knowledgeService) readURLText(ctx context.Context,
+	kb *types.KnowledgeBase, url, title, requestID string,
+) ([]*proto.Chunk, error) {
+	resp, err := s.docReaderClient.ReadFromURL(ctx, &proto.ReadFromURLRequest{
+		Url:        url,
+		Title:      title,
+		ReadConfig: urlReadConfig(kb, false, nil),
+		RequestId:  requestID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Chunks, nil
+}
+
+// handleURLRefreshError retries a failed crawl. After the last retry the check is recorded, so the
+// knowledge waits a full interval before the next attempt and keeps serving its previous content.
+func (s *knowledgeService) handleURLRefreshError(ctx context.Context,
+	knowledge *types.Knowledge, isLastRetry bool, err error,
+) error {
+	if !isLastRetry {
+		return err
+	}
+	logger.Warnf(ctx, "URL refresh failed, keeping previous content of knowledge %s: %v", knowledge.ID, err)
+	if updateErr := s.repo.UpdateKnowledgeColumn(ctx, knowledge.ID, "last_checked_at", time.Now()); updateErr != nil {
+		logger.Errorf(ctx, "failed to update knowledge last checked time: %v", updateErr)
+	}
+	return nil
+}
+
+// urlReadConfig builds the docreader read config of a knowledge base for URL crawling
+func urlReadConfig(kb *types.KnowledgeBase, enableMultimodal bool, vlmConfig *proto.VLMConfig) *proto.ReadConfig {
+	return &proto.ReadConfig{
+		ChunkSize:        int32(kb.ChunkingConfig.ChunkSize),
+		ChunkOverlap:     int32(kb.ChunkingConfig.ChunkOverlap),
+		Separators:       kb.ChunkingConfig.Separators,
+		EnableMultimodal: enableMultimodal,
+		StorageConfig: &proto.StorageConfig{
+			Provider: proto.StorageProvider(
+				proto.StorageProvider_value[strings.ToUpper(kb.StorageConfig.Provider)],
+			),
+			Region:          kb.StorageConfig.Region,
+			BucketName:      kb.StorageConfig.BucketName,
+			AccessKeyId:     kb.StorageConfig.SecretID,
+			SecretAccessKey: kb.StorageConfig.SecretKey,
+			AppId:           kb.StorageConfig.AppID,
+			PathPrefix:      kb.StorageConfig.PathPrefix,
+		},
+		VlmConfig: vlmConfig,
+	}
+}
+
+// urlContentHash hashes the crawled text of a page. Images uploaded to the storage get a new URL
+// on every crawl, so they are hashed by their original URL.
+func urlContentHash(chunks []*proto.Chunk) string {
+	hash := sha256.New()
+	for _, chunk := range chunks {
+		content := chunk.Content
+		for _, image := range chunk.Images {
+			if image.Url != "" && image.OriginalUrl != "" {
+				content = strings.ReplaceAll(content, image.Url, image.OriginalUrl)
+			}
+		}
+		hash.Write([]byte(content))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
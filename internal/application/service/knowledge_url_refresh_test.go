@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"google.golang.org/grpc"
+
+	"github.com/Tencent/WeKnora/docreader/client"
+	"github.com/Tencent/WeKnora/docreader/proto"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+func TestURLContentHash(t *testing.T) {
+	page := []*proto.Chunk{{Content: "first paragraph"}, {Content: "second paragraph"}}
+	withImage := func(url string) []*proto.Chunk {
+		return []*proto.Chunk{{
+			Content: "text ![](" + url + ")",
+			Images:  []*proto.Image{{Url: url, OriginalUrl: "https://example.com/a.png"}},
+		}}
+	}
+
+	tests := []struct {
+		name      string
+		chunks    []*proto.Chunk
+		other     []*proto.Chunk
+		wantEqual bool
+	}{
+		{
+			name:      "same text",
+			chunks:    page,
+			other:     []*proto.Chunk{{Content: "first paragraph"}, {Content: "second paragraph"}},
+			wantEqual: true,
+		},
+		{
+			name:      "image uploaded to a new URL",
+			chunks:    withImage("https://storage/1.png"),
+			other:     withImage("https://storage/2.png"),
+			wantEqual: true,
+		},
+		{
+			name:   "changed text",
+			chunks: page,
+			other:  []*proto.Chunk{{Content: "first paragraph"}, {Content: "second paragraph, edited"}},
+		},
+		{
+			name:   "moved chunk boundary",
+			chunks: page,
+			other:  []*proto.Chunk{{Content: "first"}, {Content: " paragraphsecond paragraph"}},
+		},
+		{
+			name:   "no content",
+			chunks: nil,
+			other:  []*proto.Chunk{{Content: ""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := urlContentHash(tt.chunks) == urlContentHash(tt.other); got != tt.wantEqual {
+				t.Errorf("hashes equal = %v, want %v", got, tt.wantEqual)
+			}
+		})
+	}
+}
+
+// refreshDocReader serves the crawled page, recording whether each crawl was multimodal
+type refreshDocReader struct {
+	proto.DocReaderClient
+	page   []string
+	crawls []bool
+}
+
+func (r *refreshDocReader) ReadFromURL(ctx context.Context,
+	in *proto.ReadFromURLRequest, opts ...grpc.CallOption,
+) (*proto.ReadResponse, error) {
+	r.crawls = append(r.crawls, in.ReadConfig.EnableMultimodal)
+	resp := &proto.ReadResponse{}
+	for i, content := range r.page {
+		resp.Chunks = append(resp.Chunks, &proto.Chunk{Content: content, Seq: int32(i)})
+	}
+	return resp, nil
+}
+
+// refreshKnowledgeRepo stores a single knowledge and the columns updated on it
+type refreshKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+	knowledge *types.Knowledge
+	columns   []string
+}
+
+func (r *refreshKnowledgeRepo) GetKnowledgeByID(ctx context.Context,
+	tenantID uint64, id string,
+) (*types.Knowledge, error) {
+	knowledge := *r.knowledge
+	return &knowledge, nil
+}
+
+func (r *refreshKnowledgeRepo) UpdateKnowledge(ctx context.Context, knowledge *types.Knowledge) error {
+	stored := *knowledge
+	r.knowledge = &stored
+	return nil
+}
+
+func (r *refreshKnowledgeRepo) UpdateKnowledgeColumn(ctx context.Context,
+	id string, column string, value interface{},
+) error {
+	r.columns = append(r.columns, column)
+	return nil
+}
+
+type refreshChunkService struct {
+	interfaces.ChunkService
+	chunks map[string]*types.Chunk
+}
+
+func (s *refreshChunkService) CreateChunks(ctx context.Context, chunks []*types.Chunk) error {
+	for _, chunk := range chunks {
+		s.chunks[chunk.ID] = chunk
+	}
+	return nil
+}
+
+func (s *refreshChunkService) DeleteChunks(ctx context.Context, ids []string) error {
+	for _, id := range ids {
+		delete(s.chunks, id)
+	}
+	return nil
+}
+
+type refreshChunkRepo struct {
+	interfaces.ChunkRepository
+	service *refreshChunkService
+}
+
+func (r *refreshChunkRepo) DeleteChunksByKnowledgeIDExcept(ctx context.Context,
+	tenantID uint64, knowledgeID string, excludeIDs []string,
+) ([]string, error) {
+	var deleted []string
+	for id, chunk := range r.service.chunks {
+		if chunk.KnowledgeID == knowledgeID && !slices.Contains(excludeIDs, id) {
+			deleted = append(deleted, id)
+			delete(r.service.chunks, id)
+		}
+	}
+	return deleted, nil
+}
+
+// refreshVectorEngine keeps the IDs of the indexed chunks, indexErr fails the indexing
+type refreshVectorEngine struct {
+	interfaces.RetrieveEngineService
+	indexed  map[string]bool
+	indexErr error
+}
+
+func (e *refreshVectorEngine) EngineType() types.RetrieverEngineType {
+	return types.PostgresRetrieverEngineType
+}
+
+func (e *refreshVectorEngine) Support() []types.RetrieverType {
+	return []types.RetrieverType{types.VectorRetrieverType}
+}
+
+func (e *refreshVectorEngine) EstimateStorageSize(ctx context.Context, embedder embedding.Embedder,
+	indexInfoList []*types.IndexInfo, retrieverTypes []types.RetrieverType,
+) int64 {
+	return int64(len(indexInfoList))
+}
+
+func (e *refreshVectorEngine) BatchIndex(ctx context.Context, embedder embedding.Embedder,
+	indexInfoList []*types.IndexInfo, retrieverTypes []types.RetrieverType,
+) error {
+	for _, info := range indexInfoList {
+		e.indexed[info.ChunkID] = true
+	}
+	return e.indexErr
+}
+
+func (e *refreshVectorEngine) DeleteByChunkIDList(ctx context.Context,
+	indexIDList []string, dimension int, knowledgeType string,
+) error {
+	for _, id := range indexIDList {
+		delete(e.indexed, id)
+	}
+	return nil
+}
+
+type refreshEngineRegistry struct {
+	interfaces.RetrieveEngineRegistry
+	engine *refreshVectorEngine
+}
+
+func (r *refreshEngineRegistry) GetRetrieveEngineService(
+	engineType types.RetrieverEngineType,
+) (interfaces.RetrieveEngineService, error) {
+	return r.engine, nil
+}
+
+type refreshGraphRepo struct {
+	interfaces.RetrieveGraphRepository
+}
+
+func (r *refreshGraphRepo) DelGraph(ctx context.Context, namespace []types.NameSpace) error {
+	return nil
+}
+
+type refreshTenantRepo struct {
+	reembedTenantRepo
+	storageDelta int64
+}
+
+func (r *refreshTenantRepo) AdjustStorageUsed(ctx context.Context, tenantID uint64, delta int64) error {
+	r.storageDelta += delta
+	return nil
+}
+
+func TestProcessURLRefresh(t *testing.T) {
+	const previousPage = "previous content"
+
+	tests := []struct {
+		name             string
+		page             []string
+		indexErr         error
+		wantStatus       string
+		wantHash         string
+		wantChunks       []string
+		wantColumns      []string
+		wantStorageDelta int64
+	}{
+		{
+			name:        "content unchanged",
+			page:        []string{previousPage},
+			wantStatus:  types.ParseStatusCompleted,
+			wantHash:    urlContentHash([]*proto.Chunk{{Content: previousPage}}),
+			wantChunks:  []string{previousPage},
+			wantColumns: []string{"last_checked_at"},
+		},
+		{
+			name:       "content changed",
+			page:       []string{"new content", "more new content"},
+			wantStatus: types.ParseStatusCompleted,
+			wantHash: urlContentHash([]*proto.Chunk{
+				{Content: "new content"}, {Content: "more new content"},
+			}),
+			wantChunks:       []string{"more new content", "new content"},
+			wantStorageDelta: 1,
+		},
+		{
+			name:       "re-indexing fails",
+			page:       []string{"new content"},
+			indexErr:   errors.New("vector database is down"),
+			wantStatus: types.ParseStatusCompleted,
+			wantHash:   urlContentHash([]*proto.Chunk{{Content: previousPage}}),
+			wantChunks: []string{previousPage},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousChunk := &types.Chunk{
+				ID: "previous", KnowledgeID: "k1", KnowledgeBaseID: "kb1", Content: previousPage,
+			}
+			chunkService := &refreshChunkService{chunks: map[string]*types.Chunk{previousChunk.ID: previousChunk}}
+			engine := &refreshVectorEngine{indexed: map[string]bool{previousChunk.ID: true}, indexErr: tt.indexErr}
+			knowledgeRepo := &refreshKnowledgeRepo{knowledge: &types.Knowledge{
+				ID: "k1", TenantID: 1, KnowledgeBaseID: "kb1", Type: types.KnowledgeTypeURL,
+				Source: "https://example.com", ParseStatus: types.ParseStatusCompleted, StorageSize: 1,
+				ContentHash: urlContentHash([]*proto.Chunk{{Content: previousPage}}),
+			}}
+			tenantRepo := &refreshTenantRepo{}
+			docReader := &refreshDocReader{page: tt.page}
+			svc := &knowledgeService{
+				repo: knowledgeRepo,
+				kbService: &reembedKBService{kb: &types.KnowledgeBase{
+					ID: "kb1", TenantID: 1, Type: types.KnowledgeBaseTypeDocument, EmbeddingModelID: "model",
+				}},
+				tenantRepo:      tenantRepo,
+				chunkService:    chunkService,
+				chunkRepo:       &refreshChunkRepo{service: chunkService},
+				retrieveEngine:  &refreshEngineRegistry{engine: engine},
+				graphEngine:     &refreshGraphRepo{},
+				modelService:    &reembedModelService{dims: map[string]int{"model": reembedSourceDim}},
+				docReaderClient: &client.Client{DocReaderClient: docReader},
+				task:            asynq.NewClientFromRedisClient(offlineRedisClient()),
+				responseCache:   &reembedResponseCache{},
+			}
+
+			payload, _ := json.Marshal(types.URLRefreshPayload{TenantID: 1, KnowledgeID: "k1"})
+			if err := svc.ProcessURLRefresh(context.Background(), asynq.NewTask(types.TypeURLRefresh, payload)); err != nil {
+				t.Fatalf("ProcessURLRefresh: %v", err)
+			}
+
+			knowledge := knowledgeRepo.knowledge
+			if knowledge.ParseStatus != tt.wantStatus {
+				t.Errorf("parse status = %s, want %s", knowledge.ParseStatus, tt.wantStatus)
+			}
+			if knowledge.ContentHash != tt.wantHash {
+				t.Errorf("content hash = %s, want %s", knowledge.ContentHash, tt.wantHash)
+			}
+			if !slices.Equal(knowledgeRepo.columns, tt.wantColumns) {
+				t.Errorf("updated columns = %v, want %v", knowledgeRepo.columns, tt.wantColumns)
+			}
+			if tenantRepo.storageDelta != tt.wantStorageDelta {
+				t.Errorf("storage delta = %d, want %d", tenantRepo.storageDelta, tt.wantStorageDelta)
+			}
+			if slices.Contains(docReader.crawls, true) {
+				t.Errorf("crawls = %v, want text-only crawls", docReader.crawls)
+			}
+
+			var contents []string
+			for id, chunk := range chunkService.chunks {
+				contents = append(contents, chunk.Content)
+				if !engine.indexed[id] {
+					t.Errorf("chunk %q is not indexed", chunk.Content)
+				}
+			}
+			sort.Strings(contents)
+			if !slices.Equal(contents, tt.wantChunks) {
+				t.Errorf("chunks = %q, want %q", contents, tt.wantChunks)
+			}
+			if len(engine.indexed) != len(chunkService.chunks) {
+				t.Errorf("%d chunks indexed, want %d", len(engine.indexed), len(chunkService.chunks))
+			}
+			if tt.wantColumns == nil && knowledge.LastCheckedAt == nil {
+				t.Error("last checked time was not recorded")
+			}
+		})
+	}
+}
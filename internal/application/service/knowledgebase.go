@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"slices"
 	"strings"
 	"time"
@@ -20,6 +21,10 @@ import (
 // ErrInvalidTenantID represents an error for invalid tenant ID
 var ErrInvalidTenantID = errors.New("invalid tenant ID")
 
+// maxFilterChunkIDs caps how many chunk IDs a metadata filter may resolve to for engines
+// that cannot evaluate the filter themselves
+const maxFilterChunkIDs = 10000
+
 // knowledgeBaseService implements the knowledge base service interface
 type knowledgeBaseService struct {
 	repo           interfaces.KnowledgeBaseRepository
@@ -31,6 +36,7 @@ type knowledgeBaseService struct {
 	fileSvc        interfaces.FileService
 	graphEngine    interfaces.RetrieveGraphRepository
 	asynqClient    *asynq.Client
+	responseCache  interfaces.ResponseCacheService
 }
 
 // NewKnowledgeBaseService creates a new knowledge base service
@@ -43,6 +49,7 @@ func NewKnowledgeBaseService(repo interfaces.KnowledgeBaseRepository,
 	fileSvc interfaces.FileService,
 	graphEngine interfaces.RetrieveGraphRepository,
 	asynqClient *asynq.Client,
+	responseCache interfaces.ResponseCacheService,
 ) interfaces.KnowledgeBaseService {
 	return &knowledgeBaseService{
 		repo:           repo,
@@ -54,6 +61,7 @@ func NewKnowledgeBaseService(repo interfaces.KnowledgeBaseRepository,
 		fileSvc:        fileSvc,
 		graphEngine:    graphEngine,
 		asynqClient:    asynqClient,
+		responseCache:  responseCache,
 	}
 }
 
@@ -201,6 +209,10 @@ func (s *knowledgeBaseService) UpdateKnowledgeBase(ctx context.Context,
 	if config.FAQConfig != nil {
 		kb.FAQConfig = config.FAQConfig
 	}
+	// Update fusion config if provided
+	if config.FusionConfig != nil {
+		kb.FusionConfig = config.FusionConfig
+	}
 	kb.UpdatedAt = time.Now()
 	kb.EnsureDefaults()
 
@@ -211,6 +223,7 @@ func (s *knowledgeBaseService) UpdateKnowledgeBase(ctx context.Context,
 		})
 		return nil, err
 	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, kb.ID)
 
 	logger.Infof(ctx, "Knowledge base updated successfully, ID: %s, name: %s", kb.ID, kb.Name)
 	return kb, nil
@@ -240,6 +253,7 @@ func (s *knowledgeBaseService) DeleteKnowledgeBase(ctx context.Context, id strin
 		})
 		return err
 	}
+	s.responseCache.InvalidateKnowledgeBases(ctx, id)
 
 	// Step 2: Enqueue async task for heavy cleanup operations
 	payload := types.KBDeletePayload{
@@ -461,6 +475,11 @@ func (s *knowledgeBaseService) CopyKnowledgeBase(ctx context.Context,
 			cfg := *sourceKB.FAQConfig
 			faqConfig = &cfg
 		}
+		var fusionConfig *types.FusionConfig
+		if sourceKB.FusionConfig != nil {
+			cfg := *sourceKB.FusionConfig
+			fusionConfig = &cfg
+		}
 		targetKB = &types.KnowledgeBase{
 			ID:                    uuid.New().String(),
 			Name:                  sourceKB.Name,
@@ -474,6 +493,7 @@ func (s *knowledgeBaseService) CopyKnowledgeBase(ctx context.Context,
 			VLMConfig:             sourceKB.VLMConfig,
 			StorageConfig:         sourceKB.StorageConfig,
 			FAQConfig:             faqConfig,
+			FusionConfig:          fusionConfig,
 		}
 		targetKB.EnsureDefaults()
 		if err := s.repo.CreateKnowledgeBase(ctx, targetKB); err != nil {
@@ -525,31 +545,49 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		}
 		logger.Infof(ctx, "Embedding model retrieved: %v", embeddingModel)
 
-		// Generate embedding vector for the query text
+		// Texts to embed: the query, and the hypothetical passage when HyDE is used.
+		// In replace mode only the passage is embedded.
+		vectorTexts := []string{params.QueryText}
+		hypothetical := strings.TrimSpace(params.HypotheticalDoc)
+		if hypothetical != "" {
+			if params.HypotheticalOnly {
+				vectorTexts = []string{hypothetical}
+			} else {
+				vectorTexts = append(vectorTexts, hypothetical)
+			}
+			logger.Infof(ctx, "Using hypothetical document for vector retrieval, only: %v", params.HypotheticalOnly)
+		}
+
+		// Generate embedding vectors for the texts
 		logger.Info(ctx, "Starting to generate query embedding")
-		queryEmbedding, err := embeddingModel.Embed(ctx, params.QueryText)
+		embeddings, err := embeddingModel.BatchEmbed(ctx, vectorTexts)
 		if err != nil {
 			logger.Errorf(ctx, "Failed to embed query text, query text: %s, error: %v", params.QueryText, err)
 			return nil, err
 		}
-		logger.Infof(ctx, "Query embedding generated successfully, embedding vector length: %d", len(queryEmbedding))
-
-		vectorParams := types.RetrieveParams{
-			Query:            params.QueryText,
-			Embedding:        queryEmbedding,
-			KnowledgeBaseIDs: []string{id},
-			TopK:             matchCount,
-			Threshold:        params.VectorThreshold,
-			RetrieverType:    types.VectorRetrieverType,
-			KnowledgeIDs:     params.KnowledgeIDs,
+		if len(embeddings) != len(vectorTexts) {
+			return nil, fmt.Errorf("embedding count mismatch: expected %d, got %d", len(vectorTexts), len(embeddings))
 		}
+		logger.Infof(ctx, "Query embedding generated successfully, embedding vector length: %d", len(embeddings[0]))
+
+		for i, queryEmbedding := range embeddings {
+			vectorParams := types.RetrieveParams{
+				Query:            vectorTexts[i],
+				Embedding:        queryEmbedding,
+				KnowledgeBaseIDs: []string{id},
+				TopK:             matchCount,
+				Threshold:        params.VectorThreshold,
+				RetrieverType:    types.VectorRetrieverType,
+				KnowledgeIDs:     params.KnowledgeIDs,
+			}
 
-		// For FAQ knowledge base, use FAQ index
-		if kb.Type == types.KnowledgeBaseTypeFAQ {
-			vectorParams.KnowledgeType = types.KnowledgeTypeFAQ
-		}
+			// For FAQ knowledge base, use FAQ index
+			if kb.Type == types.KnowledgeBaseTypeFAQ {
+				vectorParams.KnowledgeType = types.KnowledgeTypeFAQ
+			}
 
-		retrieveParams = append(retrieveParams, vectorParams)
+			retrieveParams = append(retrieveParams, vectorParams)
+		}
 		logger.Info(ctx, "Vector retrieval parameters setup completed")
 	}
 
@@ -573,9 +611,42 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		return nil, errors.New("no retrieve params")
 	}
 
-	// Execute retrieval using the configured engines
+	// Apply metadata filter; engines that cannot evaluate it themselves need it resolved to chunk IDs first
+	if !params.Filter.IsEmpty() {
+		var filterChunkIDs []string
+		if needsFilterResolution(tenantInfo.GetEffectiveEngines()) {
+			filterChunkIDs, err = s.chunkRepo.ListChunkIDsByFilter(ctx,
+				tenantInfo.ID, []string{id}, params.KnowledgeIDs, params.Filter, maxFilterChunkIDs)
+			if err != nil {
+				logger.Errorf(ctx, "Failed to resolve metadata filter: %v", err)
+				return nil, err
+			}
+			if len(filterChunkIDs) == 0 {
+				logger.Info(ctx, "No chunks match the metadata filter")
+				return nil, nil
+			}
+			if len(filterChunkIDs) > maxFilterChunkIDs {
+				logger.Warnf(ctx, "Metadata filter matches more than %d chunks, searching the first %d only",
+					maxFilterChunkIDs, maxFilterChunkIDs)
+				filterChunkIDs = filterChunkIDs[:maxFilterChunkIDs]
+			}
+			logger.Infof(ctx, "Metadata filter resolved to %d chunks", len(filterChunkIDs))
+		}
+		for i := range retrieveParams {
+			retrieveParams[i].Filter = params.Filter
+			retrieveParams[i].FilterChunkIDs = filterChunkIDs
+		}
+	}
+
+	// Request-level fusion (e.g. from a custom agent) takes priority over the knowledge base setting
+	fusion := kb.FusionConfig
+	if params.Fusion != nil {
+		fusion = params.Fusion
+	}
+
+	// Execute retrieval using the configured engines, which merge the results of all retrievers
 	logger.Infof(ctx, "Starting retrieval, parameter count: %d", len(retrieveParams))
-	retrieveResults, err := retrieveEngine.Retrieve(ctx, retrieveParams)
+	deduplicatedChunks, totalRetrieved, err := retrieveEngine.Retrieve(ctx, retrieveParams, fusion)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"knowledge_base_id": id,
@@ -584,142 +655,19 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		return nil, err
 	}
 
-	// Collect all results from different retrievers and deduplicate by chunk ID
-	logger.Infof(ctx, "Processing retrieval results")
-
-	// Separate results by retriever type for RRF fusion
-	var vectorResults []*types.IndexWithScore
-	var keywordResults []*types.IndexWithScore
-	for _, retrieveResult := range retrieveResults {
-		logger.Infof(ctx, "Retrieval results, engine: %v, retriever: %v, count: %v",
-			retrieveResult.RetrieverEngineType,
-			retrieveResult.RetrieverType,
-			len(retrieveResult.Results),
-		)
-		if retrieveResult.RetrieverType == types.VectorRetrieverType {
-			vectorResults = append(vectorResults, retrieveResult.Results...)
-		} else {
-			keywordResults = append(keywordResults, retrieveResult.Results...)
-		}
-	}
-
 	// Early return if no results
-	if len(vectorResults) == 0 && len(keywordResults) == 0 {
+	if totalRetrieved == 0 {
 		logger.Info(ctx, "No search results found")
 		return nil, nil
 	}
-	logger.Infof(ctx, "Result count before fusion: vector=%d, keyword=%d", len(vectorResults), len(keywordResults))
-
-	var deduplicatedChunks []*types.IndexWithScore
-
-	// If only vector results (no keyword results), keep original embedding scores
-	// This is important for FAQ search which only uses vector retrieval
-	if len(keywordResults) == 0 {
-		logger.Info(ctx, "Only vector results, keeping original embedding scores")
-		chunkInfoMap := make(map[string]*types.IndexWithScore)
-		for _, r := range vectorResults {
-			if _, exists := chunkInfoMap[r.ChunkID]; !exists {
-				chunkInfoMap[r.ChunkID] = r
-			}
-		}
-		deduplicatedChunks = make([]*types.IndexWithScore, 0, len(chunkInfoMap))
-		for _, info := range chunkInfoMap {
-			deduplicatedChunks = append(deduplicatedChunks, info)
-		}
-		slices.SortFunc(deduplicatedChunks, func(a, b *types.IndexWithScore) int {
-			if a.Score > b.Score {
-				return -1
-			} else if a.Score < b.Score {
-				return 1
-			}
-			return 0
-		})
-		logger.Infof(ctx, "Result count after deduplication: %d", len(deduplicatedChunks))
-	} else {
-		// Use RRF (Reciprocal Rank Fusion) to merge results from multiple retrievers
-		// RRF score = sum(1 / (k + rank)) for each retriever where the chunk appears
-		// k=60 is a common choice that works well in practice
-		const rrfK = 60
-
-		// Build rank maps for each retriever (already sorted by score from retriever)
-		vectorRanks := make(map[string]int)
-		for i, r := range vectorResults {
-			if _, exists := vectorRanks[r.ChunkID]; !exists {
-				vectorRanks[r.ChunkID] = i + 1 // 1-indexed rank
-			}
-		}
-		keywordRanks := make(map[string]int)
-		for i, r := range keywordResults {
-			if _, exists := keywordRanks[r.ChunkID]; !exists {
-				keywordRanks[r.ChunkID] = i + 1 // 1-indexed rank
-			}
-		}
-
-		// Collect all unique chunks and compute RRF scores
-		chunkInfoMap := make(map[string]*types.IndexWithScore)
-		rrfScores := make(map[string]float64)
-
-		// Process vector results
-		for _, r := range vectorResults {
-			if _, exists := chunkInfoMap[r.ChunkID]; !exists {
-				chunkInfoMap[r.ChunkID] = r
-			}
-		}
-		// Process keyword results
-		for _, r := range keywordResults {
-			if _, exists := chunkInfoMap[r.ChunkID]; !exists {
-				chunkInfoMap[r.ChunkID] = r
-			}
-		}
-
-		// Compute RRF scores
-		for chunkID := range chunkInfoMap {
-			rrfScore := 0.0
-			if rank, ok := vectorRanks[chunkID]; ok {
-				rrfScore += 1.0 / float64(rrfK+rank)
-			}
-			if rank, ok := keywordRanks[chunkID]; ok {
-				rrfScore += 1.0 / float64(rrfK+rank)
-			}
-			rrfScores[chunkID] = rrfScore
-		}
-
-		// Convert to slice and sort by RRF score
-		deduplicatedChunks = make([]*types.IndexWithScore, 0, len(chunkInfoMap))
-		for chunkID, info := range chunkInfoMap {
-			// Store RRF score in the Score field for downstream processing
-			info.Score = rrfScores[chunkID]
-			deduplicatedChunks = append(deduplicatedChunks, info)
-		}
-		slices.SortFunc(deduplicatedChunks, func(a, b *types.IndexWithScore) int {
-			if a.Score > b.Score {
-				return -1
-			} else if a.Score < b.Score {
-				return 1
-			}
-			return 0
-		})
-
-		logger.Infof(ctx, "Result count after RRF fusion: %d", len(deduplicatedChunks))
-
-		// Log top results after RRF fusion for debugging
-		for i, chunk := range deduplicatedChunks {
-			if i < 15 {
-				vRank, vOk := vectorRanks[chunk.ChunkID]
-				kRank, kOk := keywordRanks[chunk.ChunkID]
-				logger.Debugf(ctx, "RRF rank %d: chunk_id=%s, rrf_score=%.6f, vector_rank=%v(%v), keyword_rank=%v(%v)",
-					i, chunk.ChunkID, chunk.Score, vRank, vOk, kRank, kOk)
-			}
-		}
-	}
 
 	kb.EnsureDefaults()
 
 	// Check if we need iterative retrieval for FAQ with separate indexing
 	// Only use iterative retrieval if we don't have enough unique chunks after first deduplication
-	totalRetrieved := len(vectorResults) + len(keywordResults)
+	// and every retrieval returned a full page (HyDE adds a second vector retrieval)
 	needsIterativeRetrieval := len(deduplicatedChunks) < params.MatchCount &&
-		kb.Type == types.KnowledgeBaseTypeFAQ && totalRetrieved == matchCount*2
+		kb.Type == types.KnowledgeBaseTypeFAQ && totalRetrieved == matchCount*len(retrieveParams)
 	if needsIterativeRetrieval {
 		logger.Info(ctx, "Not enough unique chunks, using iterative retrieval for FAQ")
 		// Use iterative retrieval to get more unique chunks (with negative question filtering inside)
@@ -727,6 +675,7 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 			ctx,
 			retrieveEngine,
 			retrieveParams,
+			fusion,
 			params.MatchCount,
 			params.QueryText,
 		)
@@ -744,12 +693,34 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 	return s.processSearchResults(ctx, deduplicatedChunks)
 }
 
+// nativeFilterEngines evaluate a metadata filter themselves, Postgres against the chunk tables and
+// the others against the attributes stored with each index entry
+var nativeFilterEngines = []types.RetrieverEngineType{
+	types.PostgresRetrieverEngineType,
+	types.ElasticsearchRetrieverEngineType,
+	types.QdrantRetrieverEngineType,
+	types.MilvusRetrieverEngineType,
+	types.EmbeddedRetrieverEngineType,
+}
+
+// needsFilterResolution reports whether any of the engines cannot evaluate a metadata filter,
+// so it has to be resolved to chunk IDs before retrieval
+func needsFilterResolution(engines []types.RetrieverEngineParams) bool {
+	for _, engine := range engines {
+		if !slices.Contains(nativeFilterEngines, engine.RetrieverEngineType) {
+			return true
+		}
+	}
+	return false
+}
+
 // iterativeRetrieveWithDeduplication performs iterative retrieval until enough unique chunks are found
 // This is used for FAQ knowledge bases with separate indexing mode
 // Negative question filtering is applied after each iteration to ensure we have enough valid chunks
 func (s *knowledgeBaseService) iterativeRetrieveWithDeduplication(ctx context.Context,
 	retrieveEngine *retriever.CompositeRetrieveEngine,
 	retrieveParams []types.RetrieveParams,
+	fusion *types.FusionConfig,
 	matchCount int,
 	queryText string,
 ) []*types.IndexWithScore {
@@ -766,31 +737,26 @@ func (s *knowledgeBaseService) iterativeRetrieveWithDeduplication(ctx context.Co
 		}
 
 		// Execute retrieval
-		retrieveResults, err := retrieveEngine.Retrieve(ctx, updatedParams)
+		iterationResults, totalRetrieved, err := retrieveEngine.Retrieve(ctx, updatedParams, fusion)
 		if err != nil {
 			logger.Warnf(ctx, "Iterative retrieval failed at iteration %d: %v", i+1, err)
 			break
 		}
 
-		// Collect results
-		iterationResults := []*types.IndexWithScore{}
-		for _, retrieveResult := range retrieveResults {
-			iterationResults = append(iterationResults, retrieveResult.Results...)
-		}
-
 		if len(iterationResults) == 0 {
 			logger.Infof(ctx, "No results found at iteration %d", i+1)
 			break
 		}
 
 		// Check if we got fewer results than requested - means no more results available
-		totalRetrieved := len(iterationResults)
-		if totalRetrieved < currentTopK {
+		expectedTotal := currentTopK * len(updatedParams)
+		if totalRetrieved < expectedTotal {
 			logger.Infof(
 				ctx,
-				"Retrieved %d results (less than TopK %d), no more results available",
+				"Retrieved %d results (less than TopK %d for %d retrievals), no more results available",
 				totalRetrieved,
 				currentTopK,
+				len(updatedParams),
 			)
 		}
 
@@ -832,7 +798,7 @@ func (s *knowledgeBaseService) iterativeRetrieveWithDeduplication(ctx context.Co
 		}
 
 		// Early stop: If we got fewer results than TopK, there are no more results to retrieve
-		if totalRetrieved < currentTopK {
+		if totalRetrieved < expectedTotal {
 			logger.Infof(ctx, "No more results available, stopping iteration")
 			break
 		}
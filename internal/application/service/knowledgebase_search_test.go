@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// faqQuestionsPerChunk is the number of similar questions indexed for each FAQ entry
+const faqQuestionsPerChunk = 6
+
+type searchEmbedder struct {
+	embedding.Embedder
+}
+
+func (e *searchEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i := range texts {
+		embeddings[i] = []float32{1, 0, 0}
+	}
+	return embeddings, nil
+}
+
+type searchModelService struct {
+	interfaces.ModelService
+}
+
+func (s *searchModelService) GetEmbeddingModel(ctx context.Context, modelID string) (embedding.Embedder, error) {
+	return &searchEmbedder{}, nil
+}
+
+// faqVectorEngine returns the indexed FAQ questions best first, several questions share an entry
+type faqVectorEngine struct {
+	interfaces.RetrieveEngineService
+	questions int
+	mu        sync.Mutex
+	topKs     []int
+}
+
+func (e *faqVectorEngine) EngineType() types.RetrieverEngineType {
+	return types.PostgresRetrieverEngineType
+}
+
+func (e *faqVectorEngine) Support() []types.RetrieverType {
+	return []types.RetrieverType{types.VectorRetrieverType}
+}
+
+func (e *faqVectorEngine) Retrieve(ctx context.Context, params types.RetrieveParams) ([]*types.RetrieveResult, error) {
+	e.mu.Lock()
+	e.topKs = append(e.topKs, params.TopK)
+	e.mu.Unlock()
+
+	var results []*types.IndexWithScore
+	for i := 0; i < min(params.TopK, e.questions); i++ {
+		results = append(results, &types.IndexWithScore{
+			ID:          fmt.Sprintf("q%d", i),
+			ChunkID:     fmt.Sprintf("c%d", i/faqQuestionsPerChunk),
+			KnowledgeID: "k1",
+			Score:       1 - float64(i)/100,
+			MatchType:   types.MatchTypeEmbedding,
+		})
+	}
+	return []*types.RetrieveResult{{
+		Results:             results,
+		RetrieverEngineType: types.PostgresRetrieverEngineType,
+		RetrieverType:       types.VectorRetrieverType,
+	}}, nil
+}
+
+type searchEngineRegistry struct {
+	interfaces.RetrieveEngineRegistry
+	engine *faqVectorEngine
+}
+
+func (r *searchEngineRegistry) GetRetrieveEngineService(
+	engineType types.RetrieverEngineType,
+) (interfaces.RetrieveEngineService, error) {
+	return r.engine, nil
+}
+
+type searchKBRepo struct {
+	interfaces.KnowledgeBaseRepository
+}
+
+func (r *searchKBRepo) GetKnowledgeBaseByID(ctx context.Context, id string) (*types.KnowledgeBase, error) {
+	return &types.KnowledgeBase{ID: id, TenantID: 1, Type: types.KnowledgeBaseTypeFAQ, EmbeddingModelID: "model"}, nil
+}
+
+type searchKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+}
+
+func (r *searchKnowledgeRepo) GetKnowledgeBatch(ctx context.Context,
+	tenantID uint64, ids []string,
+) ([]*types.Knowledge, error) {
+	knowledges := make([]*types.Knowledge, 0, len(ids))
+	for _, id := range ids {
+		knowledges = append(knowledges, &types.Knowledge{ID: id, TenantID: tenantID, KnowledgeBaseID: "kb1"})
+	}
+	return knowledges, nil
+}
+
+type searchChunkRepo struct {
+	interfaces.ChunkRepository
+}
+
+func (r *searchChunkRepo) ListChunksByID(ctx context.Context, tenantID uint64, ids []string) ([]*types.Chunk, error) {
+	chunks := make([]*types.Chunk, 0, len(ids))
+	for _, id := range ids {
+		chunks = append(chunks, &types.Chunk{
+			ID: id, TenantID: tenantID, KnowledgeID: "k1", KnowledgeBaseID: "kb1",
+			ChunkType: types.ChunkTypeFAQ, Content: "answer " + id,
+		})
+	}
+	return chunks, nil
+}
+
+func TestHybridSearchFAQIterativeRetrieval(t *testing.T) {
+	tests := []struct {
+		name            string
+		hypothetical    string
+		questions       int
+		wantChunks      []string
+		wantRetrievals  int
+		wantFirstTopK   int
+		wantIterateTopK int
+	}{
+		{
+			name:            "query only",
+			questions:       60,
+			wantChunks:      []string{"c0", "c1", "c2", "c3"},
+			wantRetrievals:  5,
+			wantFirstTopK:   12,
+			wantIterateTopK: 4,
+		},
+		{
+			name:            "query and hypothetical document",
+			hypothetical:    "a hypothetical answer",
+			questions:       60,
+			wantChunks:      []string{"c0", "c1", "c2", "c3"},
+			wantRetrievals:  10,
+			wantFirstTopK:   12,
+			wantIterateTopK: 4,
+		},
+		{
+			name:           "all questions retrieved at once",
+			hypothetical:   "a hypothetical answer",
+			questions:      8,
+			wantChunks:     []string{"c0", "c1"},
+			wantRetrievals: 2,
+			wantFirstTopK:  12,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := &faqVectorEngine{questions: tt.questions}
+			svc := &knowledgeBaseService{
+				repo:           &searchKBRepo{},
+				kgRepo:         &searchKnowledgeRepo{},
+				chunkRepo:      &searchChunkRepo{},
+				modelService:   &searchModelService{},
+				retrieveEngine: &searchEngineRegistry{engine: engine},
+			}
+			ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+			ctx = context.WithValue(ctx, types.TenantInfoContextKey, &types.Tenant{
+				ID: 1,
+				RetrieverEngines: types.RetrieverEngines{Engines: []types.RetrieverEngineParams{
+					{RetrieverType: types.VectorRetrieverType, RetrieverEngineType: types.PostgresRetrieverEngineType},
+				}},
+			})
+
+			results, err := svc.HybridSearch(ctx, "kb1", types.SearchParams{
+				QueryText:       "how do I reset my password",
+				MatchCount:      4,
+				HypotheticalDoc: tt.hypothetical,
+			})
+			if err != nil {
+				t.Fatalf("HybridSearch: %v", err)
+			}
+
+			var chunks []string
+			for _, result := range results {
+				chunks = append(chunks, result.ID)
+			}
+			slices.Sort(chunks)
+			if !slices.Equal(chunks, tt.wantChunks) {
+				t.Errorf("chunks = %v, want %v", chunks, tt.wantChunks)
+			}
+			if len(engine.topKs) != tt.wantRetrievals {
+				t.Errorf("retrieved %d times with top K %v, want %d times", len(engine.topKs), engine.topKs,
+					tt.wantRetrievals)
+			}
+			if engine.topKs[0] != tt.wantFirstTopK {
+				t.Errorf("first top K = %d, want %d", engine.topKs[0], tt.wantFirstTopK)
+			}
+			if tt.wantIterateTopK != 0 && !slices.Contains(engine.topKs, tt.wantIterateTopK) {
+				t.Errorf("top K %v, want an iteration with top K %d", engine.topKs, tt.wantIterateTopK)
+			}
+		})
+	}
+}
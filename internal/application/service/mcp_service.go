@@ -2,7 +2,11 @@ package service
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -12,6 +16,9 @@ import (
 	secutils "github.com/Tencent/WeKnora/internal/utils"
 )
 
+// errInvalidOAuthState is returned when an OAuth callback does not match a pending authorization
+var errInvalidOAuthState = errors.New("invalid or expired OAuth state")
+
 // mcpServiceService implements MCPServiceService interface
 type mcpServiceService struct {
 	mcpServiceRepo interfaces.MCPServiceRepository
@@ -23,10 +30,13 @@ func NewMCPServiceService(
 	mcpServiceRepo interfaces.MCPServiceRepository,
 	mcpManager *mcp.MCPManager,
 ) interfaces.MCPServiceService {
-	return &mcpServiceService{
+	s := &mcpServiceService{
 		mcpServiceRepo: mcpServiceRepo,
 		mcpManager:     mcpManager,
 	}
+	// Tokens obtained or refreshed by the MCP clients are persisted with the service
+	mcpManager.SetTokenStore(s)
+	return s
 }
 
 // CreateMCPService creates a new MCP service
@@ -47,6 +57,13 @@ func (s *mcpServiceService) CreateMCPService(ctx context.Context, service *types
 		}
 	}
 
+	if service.AuthConfig != nil {
+		service.AuthConfig.KeepSecrets(nil)
+		if err := validateAuthConfig(service.AuthConfig); err != nil {
+			return err
+		}
+	}
+
 	// Set default advanced config if not provided
 	if service.AdvancedConfig == nil {
 		service.AdvancedConfig = types.GetDefaultAdvancedConfig()
@@ -128,6 +145,8 @@ func (s *mcpServiceService) UpdateMCPService(ctx context.Context, service *types
 	if existing == nil {
 		return fmt.Errorf("MCP service not found")
 	}
+	// Header and environment variable values sent back masked are kept
+	service.KeepSecrets(existing)
 
 	// Security validation for stdio transport type when updating stdio config
 	// Determine the final transport type and stdio config after merge
@@ -156,6 +175,14 @@ func (s *mcpServiceService) UpdateMCPService(ctx context.Context, service *types
 		}
 	}
 
+	// Secrets sent back masked are kept, as are the OAuth tokens of an unchanged OAuth client
+	if service.Name != "" && service.AuthConfig != nil {
+		service.AuthConfig.KeepSecrets(existing.AuthConfig)
+		if err := validateAuthConfig(service.AuthConfig); err != nil {
+			return err
+		}
+	}
+
 	// Store old enabled state BEFORE any updates
 	oldEnabled := existing.Enabled
 
@@ -288,7 +315,8 @@ func (s *mcpServiceService) TestMCPService(
 
 	// Create temporary client for testing
 	config := &mcp.ClientConfig{
-		Service: service,
+		Service:    service,
+		TokenStore: s,
 	}
 
 	client, err := mcp.NewMCPClient(config)
@@ -304,20 +332,14 @@ func (s *mcpServiceService) TestMCPService(
 	defer cancel()
 
 	if err := client.Connect(testCtx); err != nil {
-		return &types.MCPTestResult{
-			Success: false,
-			Message: fmt.Sprintf("Connection failed: %v", err),
-		}, nil
+		return testFailure("Connection failed", err), nil
 	}
 	defer client.Disconnect()
 
 	// Initialize
 	initResult, err := client.Initialize(testCtx)
 	if err != nil {
-		return &types.MCPTestResult{
-			Success: false,
-			Message: fmt.Sprintf("Initialization failed: %v", err),
-		}, nil
+		return testFailure("Initialization failed", err), nil
 	}
 
 	// List tools
@@ -334,6 +356,13 @@ func (s *mcpServiceService) TestMCPService(
 		resources = []*types.MCPResource{}
 	}
 
+	// List prompts
+	prompts, err := client.ListPrompts(testCtx)
+	if err != nil {
+		logger.GetLogger(ctx).Warnf("Failed to list prompts: %v", err)
+		prompts = []*types.MCPPrompt{}
+	}
+
 	return &types.MCPTestResult{
 		Success: true,
 		Message: fmt.Sprintf(
@@ -343,9 +372,26 @@ func (s *mcpServiceService) TestMCPService(
 		),
 		Tools:     tools,
 		Resources: resources,
+		Prompts:   prompts,
 	}, nil
 }
 
+// testFailure builds the result of a failed connection test, telling authentication failures apart
+func testFailure(stage string, err error) *types.MCPTestResult {
+	if authErr, ok := mcp.AsAuthError(err); ok {
+		return &types.MCPTestResult{
+			Success:               false,
+			Message:               "Authentication failed: " + authErr.Message,
+			AuthFailed:            true,
+			AuthorizationRequired: authErr.AuthorizationRequired,
+		}
+	}
+	return &types.MCPTestResult{
+		Success: false,
+		Message: fmt.Sprintf("%s: %v", stage, err),
+	}
+}
+
 // GetMCPServiceTools retrieves the list of tools from an MCP service
 func (s *mcpServiceService) GetMCPServiceTools(
 	ctx context.Context,
@@ -406,6 +452,144 @@ func (s *mcpServiceService) GetMCPServiceResources(
 	return resources, nil
 }
 
+// GetMCPServicePrompts retrieves the list of prompts from an MCP service
+func (s *mcpServiceService) GetMCPServicePrompts(
+	ctx context.Context,
+	tenantID uint64,
+	id string,
+) ([]*types.MCPPrompt, error) {
+	// Get service
+	service, err := s.mcpServiceRepo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MCP service: %w", err)
+	}
+	if service == nil {
+		return nil, fmt.Errorf("MCP service not found")
+	}
+
+	// Get or create client
+	client, err := s.mcpManager.GetOrCreateClient(service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MCP client: %w", err)
+	}
+
+	// List prompts
+	prompts, err := client.ListPrompts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompts: %w", err)
+	}
+
+	return prompts, nil
+}
+
+// StartMCPServiceOAuth starts the OAuth authorization code flow of an MCP service
+func (s *mcpServiceService) StartMCPServiceOAuth(
+	ctx context.Context,
+	tenantID uint64,
+	id string,
+	redirectURL string,
+) (string, error) {
+	service, err := s.GetMCPServiceByID(ctx, tenantID, id)
+	if err != nil {
+		return "", err
+	}
+	if service.AuthConfig == nil || service.AuthConfig.OAuth == nil ||
+		service.AuthConfig.OAuth.GrantType != types.MCPOAuthAuthorizationCode {
+		return "", fmt.Errorf("MCP service does not use the OAuth authorization code grant")
+	}
+
+	oauth := service.AuthConfig.OAuth
+	if oauth.RedirectURL == "" {
+		oauth.RedirectURL = redirectURL
+	}
+	nonce, err := mcp.GenerateOAuthSecret()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate OAuth state: %w", err)
+	}
+	verifier, err := mcp.GenerateOAuthSecret()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate PKCE verifier: %w", err)
+	}
+	// The state identifies the service on the callback, which carries no tenant credentials
+	oauth.State = fmt.Sprintf("%d.%s.%s", tenantID, service.ID, nonce)
+	oauth.CodeVerifier = verifier
+
+	if err := s.mcpServiceRepo.UpdateAuthConfig(ctx, tenantID, service.ID, service.AuthConfig); err != nil {
+		logger.GetLogger(ctx).Errorf("Failed to save pending OAuth authorization: %v", err)
+		return "", fmt.Errorf("failed to save pending OAuth authorization: %w", err)
+	}
+	return mcp.AuthorizationURL(service)
+}
+
+// CompleteMCPServiceOAuth exchanges the authorization code the user was redirected with for tokens
+func (s *mcpServiceService) CompleteMCPServiceOAuth(ctx context.Context, state string, code string) error {
+	parts := strings.SplitN(state, ".", 3)
+	if len(parts) != 3 {
+		return errInvalidOAuthState
+	}
+	tenantID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return errInvalidOAuthState
+	}
+	service, err := s.mcpServiceRepo.GetByID(ctx, tenantID, parts[1])
+	if err != nil {
+		return fmt.Errorf("failed to get MCP service: %w", err)
+	}
+	if service == nil || service.AuthConfig == nil || service.AuthConfig.OAuth == nil {
+		return errInvalidOAuthState
+	}
+	oauth := service.AuthConfig.OAuth
+	if oauth.State == "" || subtle.ConstantTimeCompare([]byte(oauth.State), []byte(state)) != 1 {
+		return errInvalidOAuthState
+	}
+
+	token, err := mcp.ExchangeOAuthCode(ctx, service, code)
+	if err != nil {
+		logger.GetLogger(ctx).Errorf("Failed to exchange OAuth authorization code: %v", err)
+		return fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	oauth.Token, oauth.State, oauth.CodeVerifier = token, "", ""
+	if err := s.mcpServiceRepo.UpdateAuthConfig(ctx, tenantID, service.ID, service.AuthConfig); err != nil {
+		logger.GetLogger(ctx).Errorf("Failed to save OAuth token: %v", err)
+		return fmt.Errorf("failed to save OAuth token: %w", err)
+	}
+
+	// Reconnect with the new token
+	s.mcpManager.CloseClient(service.ID)
+	logger.GetLogger(ctx).Infof("MCP service authorized: %s (ID: %s)", secutils.SanitizeForLog(service.Name), service.ID)
+	return nil
+}
+
+// SaveOAuthToken persists a token obtained or refreshed by an MCP client, implementing mcp.TokenStore
+func (s *mcpServiceService) SaveOAuthToken(
+	ctx context.Context,
+	service *types.MCPService,
+	token *types.MCPOAuthToken,
+) error {
+	existing, err := s.mcpServiceRepo.GetByID(ctx, service.TenantID, service.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get MCP service: %w", err)
+	}
+	// Tokens of an OAuth client replaced meanwhile are dropped
+	if existing == nil || existing.AuthConfig == nil || existing.AuthConfig.OAuth == nil ||
+		!existing.AuthConfig.OAuth.SameClient(service.AuthConfig.OAuth) {
+		return nil
+	}
+	existing.AuthConfig.OAuth.Token = token
+	return s.mcpServiceRepo.UpdateAuthConfig(ctx, service.TenantID, service.ID, existing.AuthConfig)
+}
+
+// validateAuthConfig checks the OAuth client of an MCP service
+func validateAuthConfig(authConfig *types.MCPAuthConfig) error {
+	if authConfig.OAuth == nil {
+		return nil
+	}
+	if err := authConfig.OAuth.Validate(); err != nil {
+		return fmt.Errorf("invalid OAuth config: %w", err)
+	}
+	return nil
+}
+
 // equalStringSlices compares two string slices for equality
 func equalStringSlices(a, b []string) bool {
 	if len(a) != len(b) {
@@ -12,22 +12,26 @@ import (
 // messageService implements the MessageService interface for managing messaging operations
 // It handles creating, retrieving, updating, and deleting messages within sessions
 type messageService struct {
-	messageRepo interfaces.MessageRepository // Repository for message storage operations
-	sessionRepo interfaces.SessionRepository // Repository for session validation
+	messageRepo interfaces.MessageRepository      // Repository for message storage operations
+	sessionRepo interfaces.SessionRepository      // Repository for session validation
+	shareRepo   interfaces.SessionShareRepository // Repository for sessions shared with other users
 }
 
 // NewMessageService creates a new message service instance with the required repositories
 // Parameters:
 //   - messageRepo: Repository for persisting and retrieving messages
 //   - sessionRepo: Repository for validating session existence
+//   - shareRepo: Repository for checking access to sessions shared with the current user
 //
 // Returns an implementation of the MessageService interface
 func NewMessageService(messageRepo interfaces.MessageRepository,
 	sessionRepo interfaces.SessionRepository,
+	shareRepo interfaces.SessionShareRepository,
 ) interfaces.MessageService {
 	return &messageService{
 		messageRepo: messageRepo,
 		sessionRepo: sessionRepo,
+		shareRepo:   shareRepo,
 	}
 }
 
@@ -45,11 +49,14 @@ func (s *messageService) CreateMessage(ctx context.Context, message *types.Messa
 	// Check if the session exists to validate the message belongs to a valid session
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Checking if session exists, tenant ID: %d, session ID: %s", tenantID, message.SessionID)
-	_, err := s.sessionRepo.Get(ctx, tenantID, message.SessionID)
+	session, err := s.sessionRepo.Get(ctx, tenantID, message.SessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session: %v", err)
 		return nil, err
 	}
+	if err := checkSessionAccess(ctx, s.shareRepo, session, true); err != nil {
+		return nil, err
+	}
 
 	// Create the message in the repository
 	logger.Info(ctx, "Session exists, creating message")
@@ -79,11 +86,14 @@ func (s *messageService) GetMessage(ctx context.Context, sessionID string, messa
 	// Verify the session exists before attempting to retrieve the message
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Checking if session exists, tenant ID: %d", tenantID)
-	_, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
+	session, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session: %v", err)
 		return nil, err
 	}
+	if err := checkSessionAccess(ctx, s.shareRepo, session, false); err != nil {
+		return nil, err
+	}
 
 	// Retrieve the message from the repository
 	logger.Info(ctx, "Session exists, getting message")
@@ -117,11 +127,14 @@ func (s *messageService) GetMessagesBySession(ctx context.Context,
 	// Verify the session exists before retrieving messages
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Checking if session exists, tenant ID: %d", tenantID)
-	_, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
+	session, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session: %v", err)
 		return nil, err
 	}
+	if err := checkSessionAccess(ctx, s.shareRepo, session, false); err != nil {
+		return nil, err
+	}
 
 	// Retrieve paginated messages
 	logger.Info(ctx, "Session exists, getting messages")
@@ -156,11 +169,14 @@ func (s *messageService) GetRecentMessagesBySession(ctx context.Context,
 	// Verify the session exists before retrieving messages
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Checking if session exists, tenant ID: %d", tenantID)
-	_, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
+	session, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session: %v", err)
 		return nil, err
 	}
+	if err := checkSessionAccess(ctx, s.shareRepo, session, false); err != nil {
+		return nil, err
+	}
 
 	// Retrieve the most recent messages
 	logger.Info(ctx, "Session exists, getting recent messages")
@@ -195,11 +211,14 @@ func (s *messageService) GetMessagesBySessionBeforeTime(ctx context.Context,
 	// Verify the session exists before retrieving messages
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Checking if session exists, tenant ID: %d", tenantID)
-	_, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
+	session, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session: %v", err)
 		return nil, err
 	}
+	if err := checkSessionAccess(ctx, s.shareRepo, session, false); err != nil {
+		return nil, err
+	}
 
 	// Retrieve messages before the specified time
 	logger.Info(ctx, "Session exists, getting messages before time")
@@ -230,11 +249,14 @@ func (s *messageService) UpdateMessage(ctx context.Context, message *types.Messa
 	// Verify the session exists before updating the message
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Checking if session exists, tenant ID: %d", tenantID)
-	_, err := s.sessionRepo.Get(ctx, tenantID, message.SessionID)
+	session, err := s.sessionRepo.Get(ctx, tenantID, message.SessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session: %v", err)
 		return err
 	}
+	if err := checkSessionAccess(ctx, s.shareRepo, session, true); err != nil {
+		return err
+	}
 
 	// Update the message in the repository
 	logger.Info(ctx, "Session exists, updating message")
@@ -265,11 +287,14 @@ func (s *messageService) DeleteMessage(ctx context.Context, sessionID string, me
 	// Verify the session exists before deleting the message
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Checking if session exists, tenant ID: %d", tenantID)
-	_, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
+	session, err := s.sessionRepo.Get(ctx, tenantID, sessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session: %v", err)
 		return err
 	}
+	if err := checkSessionAccess(ctx, s.shareRepo, session, true); err != nil {
+		return err
+	}
 
 	// Delete the message from the repository
 	logger.Info(ctx, "Session exists, deleting message")
@@ -20,13 +20,17 @@ var ErrModelNotFound = errors.New("model not found")
 type modelService struct {
 	repo          interfaces.ModelRepository
 	ollamaService *ollama.OllamaService
+	usageService  interfaces.TokenUsageService
 }
 
 // NewModelService creates a new model service instance
-func NewModelService(repo interfaces.ModelRepository, ollamaService *ollama.OllamaService) interfaces.ModelService {
+func NewModelService(repo interfaces.ModelRepository, ollamaService *ollama.OllamaService,
+	usageService interfaces.TokenUsageService,
+) interfaces.ModelService {
 	return &modelService{
 		repo:          repo,
 		ollamaService: ollamaService,
+		usageService:  usageService,
 	}
 }
 
@@ -36,8 +40,8 @@ func NewModelService(repo interfaces.ModelRepository, ollamaService *ollama.Olla
 func (s *modelService) CreateModel(ctx context.Context, model *types.Model) error {
 	logger.Infof(ctx, "Creating model: %s, type: %s, source: %s", model.Name, model.Type, model.Source)
 
-	// Handle remote models (e.g., OpenAI, Azure)
-	if model.Source == types.ModelSourceRemote {
+	// Handle remote models (e.g., OpenAI, Azure, Anthropic)
+	if model.Source == types.ModelSourceRemote || model.Source == types.ModelSourceAnthropic {
 		logger.Info(ctx, "Remote model detected, setting status to active")
 		model.Status = types.ModelStatusActive
 
@@ -263,7 +267,7 @@ func (s *modelService) GetEmbeddingModel(ctx context.Context, modelId string) (e
 	}
 
 	logger.Info(ctx, "Embedding model initialized successfully")
-	return embedder, nil
+	return newMeteredEmbedder(embedder, s.usageService), nil
 }
 
 // GetRerankModel retrieves and initializes a reranking model instance
@@ -297,12 +301,20 @@ func (s *modelService) GetRerankModel(ctx context.Context, modelId string) (rera
 	}
 
 	logger.Info(ctx, "Rerank model initialized successfully")
-	return reranker, nil
+	return newMeteredReranker(reranker, s.usageService), nil
 }
 
 // GetChatModel retrieves and initializes a chat model instance
 // Takes a model ID and returns a Chat interface implementation
 func (s *modelService) GetChatModel(ctx context.Context, modelId string) (chat.Chat, error) {
+	return s.GetChatModelWithFallback(ctx, modelId, nil)
+}
+
+// GetChatModelWithFallback retrieves and initializes a chat model instance that fails over
+// to the fallback models in order. A nil fallback uses the fallback declared by the model.
+func (s *modelService) GetChatModelWithFallback(ctx context.Context,
+	modelId string, fallback *types.ModelFallback,
+) (chat.Chat, error) {
 	// Check if model ID is empty
 	if modelId == "" {
 		logger.Error(ctx, "Model ID is empty")
@@ -328,14 +340,7 @@ func (s *modelService) GetChatModel(ctx context.Context, modelId string) (chat.C
 
 	logger.Infof(ctx, "Getting chat model: %s, source: %s", model.Name, model.Source)
 
-	// Initialize the chat model with model configuration
-	chatModel, err := chat.NewChat(&chat.ChatConfig{
-		ModelID:   model.ID,
-		APIKey:    model.Parameters.APIKey,
-		BaseURL:   model.Parameters.BaseURL,
-		ModelName: model.Name,
-		Source:    model.Source,
-	})
+	chatModel, err := newChatModel(model)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"model_id":   model.ID,
@@ -344,7 +349,52 @@ func (s *modelService) GetChatModel(ctx context.Context, modelId string) (chat.C
 		return nil, err
 	}
 
-	return chatModel, nil
+	if fallback == nil {
+		fallback = model.Parameters.Fallback
+	}
+	if fallback == nil || len(fallback.ModelIDs) == 0 {
+		return newMeteredChat(chatModel, s.usageService), nil
+	}
+
+	// Fallback models are used as they are, their own fallbacks are not followed
+	seen := map[string]bool{model.ID: true}
+	fallbackModels := make([]chat.Chat, 0, len(fallback.ModelIDs))
+	for _, fallbackID := range fallback.ModelIDs {
+		if seen[fallbackID] {
+			continue
+		}
+		seen[fallbackID] = true
+
+		fallbackModel, err := s.repo.GetByID(ctx, tenantID, fallbackID)
+		if err != nil || fallbackModel == nil || fallbackModel.Type != types.ModelTypeKnowledgeQA {
+			logger.Warnf(ctx, "Skipping fallback model %s of %s: not a chat model of the tenant", fallbackID, model.ID)
+			continue
+		}
+		fallbackChat, err := newChatModel(fallbackModel)
+		if err != nil {
+			logger.Warnf(ctx, "Skipping fallback model %s of %s: %v", fallbackID, model.ID, err)
+			continue
+		}
+		fallbackModels = append(fallbackModels, fallbackChat)
+	}
+	if len(fallbackModels) == 0 {
+		return newMeteredChat(chatModel, s.usageService), nil
+	}
+
+	logger.Infof(ctx, "Chat model %s fails over to %d fallback models", model.ID, len(fallbackModels))
+	return newMeteredChat(chat.NewFallbackChat(chatModel, fallbackModels, fallback), s.usageService), nil
+}
+
+// newChatModel initializes a chat model with the model configuration
+func newChatModel(model *types.Model) (chat.Chat, error) {
+	return chat.NewChat(&chat.ChatConfig{
+		ModelID:   model.ID,
+		APIKey:    model.Parameters.APIKey,
+		BaseURL:   model.Parameters.BaseURL,
+		ModelName: model.Name,
+		Source:    model.Source,
+		Provider:  model.Parameters.Provider,
+	})
 }
 
 // Note: default model selection logic has been removed; models no longer
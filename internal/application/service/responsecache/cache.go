@@ -0,0 +1,176 @@
+package responsecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/redis/go-redis/v9"
+)
+
+// Storage types
+const (
+	TypeMemory = "memory"
+	TypeRedis  = "redis"
+)
+
+// Defaults used when the configuration leaves a setting empty
+const (
+	defaultSimilarityThreshold = 0.95
+	defaultMaxEntries          = 200
+)
+
+// responseCache implements the ResponseCacheService interface
+type responseCache struct {
+	enabled    bool
+	storage    Storage
+	threshold  float64
+	maxEntries int
+}
+
+// NewResponseCache creates the response cache configured by the response_cache section
+func NewResponseCache(cfg *config.Config, redisClient *redis.Client) (interfaces.ResponseCacheService, error) {
+	cacheCfg := cfg.ResponseCache
+	if cacheCfg == nil || !cacheCfg.Enabled {
+		return &responseCache{}, nil
+	}
+
+	var storage Storage
+	switch cacheCfg.Type {
+	case TypeRedis:
+		var err error
+		storage, err = NewRedisStorage(redisClient, cacheCfg.TTL, cacheCfg.Prefix)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		storage = NewMemoryStorage(cacheCfg.TTL)
+	}
+	return newResponseCache(storage, cacheCfg.SimilarityThreshold, cacheCfg.MaxEntries), nil
+}
+
+// newResponseCache creates an enabled response cache over a storage
+func newResponseCache(storage Storage, threshold float64, maxEntries int) *responseCache {
+	if threshold <= 0 {
+		threshold = defaultSimilarityThreshold
+	}
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	return &responseCache{
+		enabled:    true,
+		storage:    storage,
+		threshold:  threshold,
+		maxEntries: maxEntries,
+	}
+}
+
+// Enabled reports whether the response cache is turned on
+func (c *responseCache) Enabled() bool {
+	return c.enabled
+}
+
+// ScopeKey hashes the scope together with the current generation of every scoped knowledge base
+func (c *responseCache) ScopeKey(ctx context.Context, scope *types.ResponseCacheScope) (string, error) {
+	kbIDs := slices.Clone(scope.KnowledgeBaseIDs)
+	slices.Sort(kbIDs)
+	kbIDs = slices.Compact(kbIDs)
+	knowledgeIDs := slices.Clone(scope.KnowledgeIDs)
+	slices.Sort(knowledgeIDs)
+
+	generations, err := c.storage.Generations(ctx, kbIDs)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(struct {
+		TenantID         uint64   `json:"tenant_id"`
+		KnowledgeBaseIDs []string `json:"knowledge_base_ids"`
+		Generations      []int64  `json:"generations"`
+		KnowledgeIDs     []string `json:"knowledge_ids"`
+		EmbeddingModelID string   `json:"embedding_model_id"`
+		ConfigHash       string   `json:"config_hash"`
+	}{scope.TenantID, kbIDs, generations, knowledgeIDs, scope.EmbeddingModelID, scope.ConfigHash})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Lookup returns the most similar cached answer reaching the similarity threshold
+func (c *responseCache) Lookup(ctx context.Context,
+	scopeKey string, embedding []float32,
+) (*types.ResponseCacheEntry, float64, error) {
+	entries, err := c.storage.Load(ctx, scopeKey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var best *types.ResponseCacheEntry
+	bestScore := 0.0
+	for _, entry := range entries {
+		score := cosineSimilarity(embedding, entry.Embedding)
+		if score >= c.threshold && score > bestScore {
+			best, bestScore = entry, score
+		}
+	}
+	return best, bestScore, nil
+}
+
+// Store caches an answer under the scope key
+func (c *responseCache) Store(ctx context.Context, scopeKey string, entry *types.ResponseCacheEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	return c.storage.Append(ctx, scopeKey, entry, c.maxEntries)
+}
+
+// InvalidateKnowledgeBases advances the generation of the knowledge bases, which changes the key
+// of every scope covering them. Failures are logged and never fail the knowledge change.
+func (c *responseCache) InvalidateKnowledgeBases(ctx context.Context, kbIDs ...string) {
+	if !c.enabled {
+		return
+	}
+	ids := make([]string, 0, len(kbIDs))
+	for _, kbID := range kbIDs {
+		if kbID != "" && !slices.Contains(ids, kbID) {
+			ids = append(ids, kbID)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	// The knowledge change already happened, so the invalidation must not be canceled with the request
+	if err := c.storage.Bump(context.WithoutCancel(ctx), ids); err != nil {
+		logger.Warnf(ctx, "Failed to invalidate response cache of knowledge bases %v: %v", ids, err)
+		return
+	}
+	logger.Infof(ctx, "Invalidated response cache of knowledge bases %v", ids)
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, 0 when they are not comparable
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,118 @@
+package responsecache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestResponseCache_LookupAndInvalidate(t *testing.T) {
+	ctx := context.Background()
+	cache := newResponseCache(NewMemoryStorage(time.Hour), 0.9, 0)
+	scope := &types.ResponseCacheScope{
+		TenantID:         1,
+		KnowledgeBaseIDs: []string{"kb-2", "kb-1"},
+		EmbeddingModelID: "embedding",
+		ConfigHash:       "config",
+	}
+
+	key, err := cache.ScopeKey(ctx, scope)
+	if err != nil {
+		t.Fatalf("scope key: %v", err)
+	}
+	if err := cache.Store(ctx, key, &types.ResponseCacheEntry{
+		Query:     "how do I reset my password",
+		Embedding: []float32{1, 0, 0},
+		Answer:    "Use the reset link.",
+	}); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	// The order of knowledge bases does not change the scope
+	reordered := *scope
+	reordered.KnowledgeBaseIDs = []string{"kb-1", "kb-2"}
+	if got, _ := cache.ScopeKey(ctx, &reordered); got != key {
+		t.Errorf("scope key depends on knowledge base order")
+	}
+
+	entry, score, err := cache.Lookup(ctx, key, []float32{0.99, 0.1, 0})
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	if entry == nil || entry.Answer != "Use the reset link." {
+		t.Fatalf("expected a hit, got %v (score %.3f)", entry, score)
+	}
+
+	if entry, _, _ := cache.Lookup(ctx, key, []float32{0, 1, 0}); entry != nil {
+		t.Errorf("dissimilar query should miss, got %q", entry.Answer)
+	}
+
+	otherConfig := *scope
+	otherConfig.ConfigHash = "other"
+	otherKey, _ := cache.ScopeKey(ctx, &otherConfig)
+	if entry, _, _ := cache.Lookup(ctx, otherKey, []float32{1, 0, 0}); entry != nil {
+		t.Errorf("other agent config should miss")
+	}
+
+	cache.InvalidateKnowledgeBases(ctx, "kb-1")
+	newKey, _ := cache.ScopeKey(ctx, scope)
+	if newKey == key {
+		t.Fatalf("scope key unchanged after invalidation")
+	}
+	if entry, _, _ := cache.Lookup(ctx, newKey, []float32{1, 0, 0}); entry != nil {
+		t.Errorf("invalidated answer served")
+	}
+}
+
+func TestResponseCache_MaxEntries(t *testing.T) {
+	ctx := context.Background()
+	cache := newResponseCache(NewMemoryStorage(time.Hour), 0.9, 2)
+
+	for i, vector := range [][]float32{{1, 0}, {0, 1}, {1, 1}} {
+		if err := cache.Store(ctx, "scope", &types.ResponseCacheEntry{
+			Embedding: vector,
+			Answer:    string(rune('a' + i)),
+		}); err != nil {
+			t.Fatalf("store: %v", err)
+		}
+	}
+
+	if entry, _, _ := cache.Lookup(ctx, "scope", []float32{1, 0}); entry != nil {
+		t.Errorf("oldest answer should have been evicted, got %q", entry.Answer)
+	}
+	if entry, _, _ := cache.Lookup(ctx, "scope", []float32{0, 1}); entry == nil || entry.Answer != "b" {
+		t.Errorf("expected answer b, got %v", entry)
+	}
+}
+
+func TestResponseCache_Disabled(t *testing.T) {
+	cache := &responseCache{}
+	if cache.Enabled() {
+		t.Fatal("zero cache should be disabled")
+	}
+	// Invalidating a disabled cache is a no-op and must not touch the nil storage
+	cache.InvalidateKnowledgeBases(context.Background(), "kb-1")
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{"identical", []float32{1, 2, 3}, []float32{1, 2, 3}, 1},
+		{"orthogonal", []float32{1, 0}, []float32{0, 1}, 0},
+		{"length mismatch", []float32{1, 0}, []float32{1, 0, 0}, 0},
+		{"zero vector", []float32{0, 0}, []float32{1, 0}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cosineSimilarity(tt.a, tt.b)
+			if got < tt.want-1e-6 || got > tt.want+1e-6 {
+				t.Errorf("cosineSimilarity = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package responsecache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// memorySweepInterval is how often expired scopes are removed from memory
+const memorySweepInterval = time.Minute
+
+// memoryScope holds the cached answers of a scope
+type memoryScope struct {
+	entries   []*types.ResponseCacheEntry
+	expiresAt time.Time
+}
+
+// memoryStorage implements Storage using in-memory storage
+type memoryStorage struct {
+	ttl         time.Duration
+	scopes      map[string]*memoryScope
+	generations map[string]int64
+	lastSweep   time.Time
+	mu          sync.RWMutex
+}
+
+// NewMemoryStorage creates a new memory-based storage
+func NewMemoryStorage(ttl time.Duration) Storage {
+	if ttl == 0 {
+		ttl = 24 * time.Hour // Default TTL 24 hours
+	}
+	return &memoryStorage{
+		ttl:         ttl,
+		scopes:      make(map[string]*memoryScope),
+		generations: make(map[string]int64),
+		lastSweep:   time.Now(),
+	}
+}
+
+// Load loads the cached answers of a scope from memory
+func (ms *memoryStorage) Load(ctx context.Context, scopeKey string) ([]*types.ResponseCacheEntry, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	scope, exists := ms.scopes[scopeKey]
+	if !exists || time.Now().After(scope.expiresAt) {
+		return nil, nil
+	}
+
+	// Return a copy to avoid external modifications
+	entries := make([]*types.ResponseCacheEntry, len(scope.entries))
+	copy(entries, scope.entries)
+	return entries, nil
+}
+
+// Append stores an answer of a scope in memory
+func (ms *memoryStorage) Append(ctx context.Context,
+	scopeKey string, entry *types.ResponseCacheEntry, maxEntries int,
+) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	ms.sweep(now)
+
+	scope, exists := ms.scopes[scopeKey]
+	if !exists || now.After(scope.expiresAt) {
+		scope = &memoryScope{}
+		ms.scopes[scopeKey] = scope
+	}
+	scope.entries = append(scope.entries, entry)
+	if maxEntries > 0 && len(scope.entries) > maxEntries {
+		scope.entries = scope.entries[len(scope.entries)-maxEntries:]
+	}
+	scope.expiresAt = now.Add(ms.ttl)
+	return nil
+}
+
+// sweep removes expired scopes, which include the scopes orphaned by a generation bump.
+// The caller must hold the write lock.
+func (ms *memoryStorage) sweep(now time.Time) {
+	if now.Sub(ms.lastSweep) < memorySweepInterval {
+		return
+	}
+	for key, scope := range ms.scopes {
+		if now.After(scope.expiresAt) {
+			delete(ms.scopes, key)
+		}
+	}
+	ms.lastSweep = now
+}
+
+// Generations returns the generations of knowledge bases from memory
+func (ms *memoryStorage) Generations(ctx context.Context, kbIDs []string) ([]int64, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	generations := make([]int64, len(kbIDs))
+	for i, kbID := range kbIDs {
+		generations[i] = ms.generations[kbID]
+	}
+	return generations, nil
+}
+
+// Bump advances the generations of knowledge bases in memory
+func (ms *memoryStorage) Bump(ctx context.Context, kbIDs []string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	for _, kbID := range kbIDs {
+		ms.generations[kbID]++
+	}
+	return nil
+}
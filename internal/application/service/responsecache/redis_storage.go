@@ -0,0 +1,140 @@
+package responsecache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisStorage implements Storage using Redis. The answers of a scope are kept in a list,
+// and the generation of each knowledge base is a counter without expiry.
+type redisStorage struct {
+	client *redis.Client
+	ttl    time.Duration
+	prefix string
+}
+
+// NewRedisStorage creates a new Redis-based storage
+func NewRedisStorage(client *redis.Client, ttl time.Duration, prefix string) (Storage, error) {
+	// Validate connection
+	_, err := client.Ping(context.Background()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	if ttl == 0 {
+		ttl = 24 * time.Hour // Default TTL 24 hours
+	}
+
+	if prefix == "" {
+		prefix = "response_cache:" // Default prefix
+	}
+
+	return &redisStorage{
+		client: client,
+		ttl:    ttl,
+		prefix: prefix,
+	}, nil
+}
+
+// scopeKey builds the Redis key holding the answers of a scope
+func (rs *redisStorage) scopeKey(scopeKey string) string {
+	return fmt.Sprintf("%sscope:%s", rs.prefix, scopeKey)
+}
+
+// generationKey builds the Redis key holding the generation of a knowledge base
+func (rs *redisStorage) generationKey(kbID string) string {
+	return fmt.Sprintf("%sgen:%s", rs.prefix, kbID)
+}
+
+// Load loads the cached answers of a scope from Redis
+func (rs *redisStorage) Load(ctx context.Context, scopeKey string) ([]*types.ResponseCacheEntry, error) {
+	values, err := rs.client.LRange(ctx, rs.scopeKey(scopeKey), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get from Redis: %w", err)
+	}
+
+	entries := make([]*types.ResponseCacheEntry, 0, len(values))
+	for _, value := range values {
+		var entry types.ResponseCacheEntry
+		if err := json.Unmarshal([]byte(value), &entry); err != nil {
+			// Skip entries written by an incompatible version
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, nil
+}
+
+// Append stores an answer of a scope in Redis
+func (rs *redisStorage) Append(ctx context.Context,
+	scopeKey string, entry *types.ResponseCacheEntry, maxEntries int,
+) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal entry: %w", err)
+	}
+
+	key := rs.scopeKey(scopeKey)
+	pipe := rs.client.TxPipeline()
+	pipe.RPush(ctx, key, data)
+	if maxEntries > 0 {
+		pipe.LTrim(ctx, key, int64(-maxEntries), -1)
+	}
+	pipe.Expire(ctx, key, rs.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save to Redis: %w", err)
+	}
+	return nil
+}
+
+// Generations returns the generations of knowledge bases from Redis
+func (rs *redisStorage) Generations(ctx context.Context, kbIDs []string) ([]int64, error) {
+	generations := make([]int64, len(kbIDs))
+	if len(kbIDs) == 0 {
+		return generations, nil
+	}
+
+	keys := make([]string, len(kbIDs))
+	for i, kbID := range kbIDs {
+		keys[i] = rs.generationKey(kbID)
+	}
+	values, err := rs.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get from Redis: %w", err)
+	}
+	for i, value := range values {
+		// Missing keys are knowledge bases that never changed since the cache was enabled
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+		generation, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid generation of knowledge base %s: %w", kbIDs[i], err)
+		}
+		generations[i] = generation
+	}
+	return generations, nil
+}
+
+// Bump advances the generations of knowledge bases in Redis
+func (rs *redisStorage) Bump(ctx context.Context, kbIDs []string) error {
+	if len(kbIDs) == 0 {
+		return nil
+	}
+
+	pipe := rs.client.TxPipeline()
+	for _, kbID := range kbIDs {
+		pipe.Incr(ctx, rs.generationKey(kbID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save to Redis: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+// Package responsecache provides a semantic cache for knowledge QA answers
+package responsecache
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Storage defines the interface for storing cached answers and knowledge base generations.
+// This separates storage implementation from the matching logic.
+type Storage interface {
+	// Load loads the cached answers stored under a scope key
+	Load(ctx context.Context, scopeKey string) ([]*types.ResponseCacheEntry, error)
+
+	// Append stores an answer under a scope key, keeping at most maxEntries of the newest answers
+	Append(ctx context.Context, scopeKey string, entry *types.ResponseCacheEntry, maxEntries int) error
+
+	// Generations returns the current generation of each knowledge base, in the given order
+	Generations(ctx context.Context, kbIDs []string) ([]int64, error)
+
+	// Bump advances the generation of the knowledge bases
+	Bump(ctx context.Context, kbIDs []string) error
+}
@@ -29,9 +29,47 @@ type CompositeRetrieveEngine struct {
 	engineInfos []*engineInfo
 }
 
-// Retrieve performs retrieval operations by delegating to the appropriate engine
-// based on the retriever type specified in the parameters
+// Retrieve runs the retrievals on the engines supporting their retriever types and fuses the result
+// lists into one list ranked by the fusion settings, see FuseResults. It also returns the number of
+// results the retrievers returned before fusion.
 func (c *CompositeRetrieveEngine) Retrieve(ctx context.Context,
+	retrieveParams []types.RetrieveParams, fusion *types.FusionConfig,
+) ([]*types.IndexWithScore, int, error) {
+	retrieveResults, err := c.retrieveLists(ctx, retrieveParams)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	totalRetrieved := 0
+	for _, retrieveResult := range retrieveResults {
+		logger.Infof(ctx, "Retrieval results, engine: %v, retriever: %v, count: %v",
+			retrieveResult.RetrieverEngineType,
+			retrieveResult.RetrieverType,
+			len(retrieveResult.Results),
+		)
+		totalRetrieved += len(retrieveResult.Results)
+	}
+	if totalRetrieved == 0 {
+		return nil, 0, nil
+	}
+
+	// A single retriever keeps its raw scores, which FAQ search relies on as it only uses vector retrieval
+	fused := FuseResults(retrieveResults, fusion)
+	logger.Infof(ctx, "Result count before fusion: %d, after fusion: %d, fusion method: %s",
+		totalRetrieved, len(fused), fusion.WithDefaults().Method)
+	for i, chunk := range fused {
+		if i >= 15 {
+			break
+		}
+		logger.Debugf(ctx, "Fusion rank %d: chunk_id=%s, score=%.6f, sources=%+v",
+			i, chunk.ChunkID, chunk.Score, chunk.Sources)
+	}
+	return fused, totalRetrieved, nil
+}
+
+// retrieveLists performs retrieval operations by delegating to the appropriate engine
+// based on the retriever type specified in the parameters, one result list per retrieval
+func (c *CompositeRetrieveEngine) retrieveLists(ctx context.Context,
 	retrieveParams []types.RetrieveParams,
 ) ([]*types.RetrieveResult, error) {
 	return concurrentRetrieve(ctx, retrieveParams,
@@ -115,6 +153,16 @@ func (c *CompositeRetrieveEngine) BatchUpdateChunkEnabledStatus(
 	})
 }
 
+// BatchUpdateChunkAttributes replaces the filter attributes stored with the index entries of chunks in batch
+func (c *CompositeRetrieveEngine) BatchUpdateChunkAttributes(
+	ctx context.Context,
+	chunkAttributes map[string]*types.IndexAttributes,
+) error {
+	return c.concurrentExecWithError(ctx, func(ctx context.Context, engineInfo *engineInfo) error {
+		return engineInfo.retrieveEngine.BatchUpdateChunkAttributes(ctx, chunkAttributes)
+	})
+}
+
 // concurrentRetrieve is a helper function for concurrent processing of retrieval parameters
 // and collecting results
 func concurrentRetrieve(
@@ -301,6 +349,23 @@ func (c *CompositeRetrieveEngine) DeleteByKnowledgeIDList(ctx context.Context,
 	})
 }
 
+// DeleteByKnowledgeBaseIDList deletes vector embeddings of the given dimension by knowledge base ID list
+// from all registered repositories
+func (c *CompositeRetrieveEngine) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	return c.concurrentExecWithError(ctx, func(ctx context.Context, engineInfo *engineInfo) error {
+		if err := engineInfo.retrieveEngine.DeleteByKnowledgeBaseIDList(
+			ctx, knowledgeBaseIDList, dimension, knowledgeType,
+		); err != nil {
+			logger.GetLogger(ctx).Errorf("Repository %s failed to delete knowledge base ID list: %v",
+				engineInfo.retrieveEngine.EngineType(), err)
+			return err
+		}
+		return nil
+	})
+}
+
 // EstimateStorageSize estimates the storage size required for the provided index information
 func (c *CompositeRetrieveEngine) EstimateStorageSize(ctx context.Context,
 	embedder embedding.Embedder, indexInfoList []*types.IndexInfo,
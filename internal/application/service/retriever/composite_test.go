@@ -0,0 +1,45 @@
+package retriever
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type listRetrieveEngine struct {
+	interfaces.RetrieveEngineService
+	lists map[types.RetrieverType]*types.RetrieveResult
+}
+
+func (e *listRetrieveEngine) Retrieve(ctx context.Context, params types.RetrieveParams) ([]*types.RetrieveResult, error) {
+	return []*types.RetrieveResult{e.lists[params.RetrieverType]}, nil
+}
+
+func TestCompositeRetrieveFusesResults(t *testing.T) {
+	engine := &listRetrieveEngine{lists: map[types.RetrieverType]*types.RetrieveResult{
+		types.VectorRetrieverType: newResultList(types.VectorRetrieverType,
+			map[string]float64{"a": 0.9, "b": 0.7}, "a", "b"),
+		types.KeywordsRetrieverType: newResultList(types.KeywordsRetrieverType,
+			map[string]float64{"b": 12, "c": 8}, "b", "c"),
+	}}
+	composite := &CompositeRetrieveEngine{engineInfos: []*engineInfo{{
+		retrieveEngine: engine,
+		retrieverType:  []types.RetrieverType{types.VectorRetrieverType, types.KeywordsRetrieverType},
+	}}}
+
+	fused, total, err := composite.Retrieve(context.Background(), []types.RetrieveParams{
+		{RetrieverType: types.VectorRetrieverType},
+		{RetrieverType: types.KeywordsRetrieverType},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Retrieve: %v", err)
+	}
+	if total != 4 {
+		t.Errorf("total retrieved = %d, want 4", total)
+	}
+	if len(fused) != 3 || fused[0].ChunkID != "b" || len(fused[0].Sources) != 2 {
+		t.Fatalf("unexpected fused results: %+v", fused)
+	}
+}
@@ -0,0 +1,121 @@
+package retriever
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// FuseResults merges the result lists of several retrievers into one list ranked by the
+// configured fusion method, deduplicated by chunk ID. Each returned item records the rank and
+// raw score it had in every contributing list in Sources.
+//
+// When only one list has results, its raw scores are kept as-is, since there is nothing to
+// fuse and callers such as FAQ search rely on the original similarity scores.
+func FuseResults(results []*types.RetrieveResult, fusion *types.FusionConfig) []*types.IndexWithScore {
+	cfg := fusion.WithDefaults()
+
+	lists := make([]*types.RetrieveResult, 0, len(results))
+	for _, result := range results {
+		if result != nil && len(result.Results) > 0 {
+			lists = append(lists, result)
+		}
+	}
+	if len(lists) == 0 {
+		return nil
+	}
+
+	fused := make(map[string]*types.IndexWithScore)
+	// scores holds the normalized score of each chunk per list, keyed by list index
+	scores := make(map[string]map[int]float64)
+	for listIdx, list := range lists {
+		normalized := normalizeScores(list.Results)
+		seen := make(map[string]bool, len(list.Results))
+		rank := 0
+		for i, r := range list.Results {
+			if seen[r.ChunkID] {
+				continue
+			}
+			seen[r.ChunkID] = true
+			rank++
+
+			item, ok := fused[r.ChunkID]
+			if !ok {
+				// Copy so the retriever's own result is not mutated
+				clone := *r
+				clone.Sources = nil
+				item = &clone
+				fused[r.ChunkID] = item
+				scores[r.ChunkID] = make(map[int]float64)
+			}
+			item.Sources = append(item.Sources, types.RetrieverScore{
+				RetrieverEngineType: list.RetrieverEngineType,
+				RetrieverType:       list.RetrieverType,
+				Rank:                rank,
+				Score:               r.Score,
+			})
+
+			switch cfg.Method {
+			case types.FusionMethodWeighted:
+				scores[r.ChunkID][listIdx] = cfg.Weight(list.RetrieverType) * normalized[i]
+			case types.FusionMethodMax:
+				scores[r.ChunkID][listIdx] = normalized[i]
+			default:
+				scores[r.ChunkID][listIdx] = 1.0 / float64(cfg.RRFK+rank)
+			}
+		}
+	}
+
+	merged := make([]*types.IndexWithScore, 0, len(fused))
+	for chunkID, item := range fused {
+		if len(lists) > 1 {
+			item.Score = combineScores(cfg.Method, scores[chunkID])
+		}
+		merged = append(merged, item)
+	}
+	slices.SortFunc(merged, func(a, b *types.IndexWithScore) int {
+		if a.Score > b.Score {
+			return -1
+		} else if a.Score < b.Score {
+			return 1
+		}
+		return strings.Compare(a.ChunkID, b.ChunkID)
+	})
+	return merged
+}
+
+// combineScores merges the per-list scores of one chunk according to the fusion method
+func combineScores(method types.FusionMethod, scores map[int]float64) float64 {
+	result := 0.0
+	for _, score := range scores {
+		if method == types.FusionMethodMax {
+			result = max(result, score)
+		} else {
+			result += score
+		}
+	}
+	return result
+}
+
+// normalizeScores min-max normalizes the scores of one retriever list to [0, 1].
+// If every score is equal, all results get 1.
+func normalizeScores(results []*types.IndexWithScore) []float64 {
+	normalized := make([]float64, len(results))
+	if len(results) == 0 {
+		return normalized
+	}
+	minScore, maxScore := results[0].Score, results[0].Score
+	for _, r := range results[1:] {
+		minScore = min(minScore, r.Score)
+		maxScore = max(maxScore, r.Score)
+	}
+	for i, r := range results {
+		if maxScore == minScore {
+			normalized[i] = 1
+			continue
+		}
+		normalized[i] = (r.Score - minScore) / (maxScore - minScore)
+	}
+	return normalized
+}
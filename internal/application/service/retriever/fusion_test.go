@@ -0,0 +1,107 @@
+package retriever
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func newResultList(retrieverType types.RetrieverType, scores map[string]float64, order ...string) *types.RetrieveResult {
+	result := &types.RetrieveResult{
+		RetrieverEngineType: types.PostgresRetrieverEngineType,
+		RetrieverType:       retrieverType,
+	}
+	for _, chunkID := range order {
+		result.Results = append(result.Results, &types.IndexWithScore{ChunkID: chunkID, Score: scores[chunkID]})
+	}
+	return result
+}
+
+func TestFuseResults(t *testing.T) {
+	vector := newResultList(types.VectorRetrieverType,
+		map[string]float64{"a": 0.9, "b": 0.7, "c": 0.5}, "a", "b", "c")
+	keywords := newResultList(types.KeywordsRetrieverType,
+		map[string]float64{"c": 12, "d": 8, "a": 2}, "c", "d", "a")
+
+	tests := []struct {
+		name   string
+		fusion *types.FusionConfig
+		want   []string
+		scores map[string]float64
+	}{
+		{
+			name:   "default rrf",
+			fusion: nil,
+			want:   []string{"a", "c", "b", "d"},
+			scores: map[string]float64{"a": 1.0/61 + 1.0/63, "c": 1.0/63 + 1.0/61, "b": 1.0 / 62, "d": 1.0 / 62},
+		},
+		{
+			name:   "rrf with custom k",
+			fusion: &types.FusionConfig{Method: types.FusionMethodRRF, RRFK: 1},
+			want:   []string{"a", "c", "b", "d"},
+			scores: map[string]float64{"a": 1.0/2 + 1.0/4, "b": 1.0 / 3},
+		},
+		{
+			name:   "weighted favors vector",
+			fusion: &types.FusionConfig{Method: types.FusionMethodWeighted, VectorWeight: 0.8, KeywordWeight: 0.2},
+			want:   []string{"a", "b", "c", "d"},
+			scores: map[string]float64{"a": 0.8, "b": 0.4, "c": 0.2, "d": 0.2 * 0.6},
+		},
+		{
+			name:   "max",
+			fusion: &types.FusionConfig{Method: types.FusionMethodMax},
+			want:   []string{"a", "c", "d", "b"},
+			scores: map[string]float64{"a": 1, "c": 1, "b": 0.5, "d": 0.6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FuseResults([]*types.RetrieveResult{vector, keywords}, tt.fusion)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d results, got %d", len(tt.want), len(got))
+			}
+			for i, chunkID := range tt.want {
+				if got[i].ChunkID != chunkID {
+					t.Fatalf("position %d: expected %s, got %s", i, chunkID, got[i].ChunkID)
+				}
+				if want, ok := tt.scores[chunkID]; ok && math.Abs(got[i].Score-want) > 1e-9 {
+					t.Errorf("chunk %s: expected score %.6f, got %.6f", chunkID, want, got[i].Score)
+				}
+			}
+		})
+	}
+
+	// Inputs must not be mutated
+	if vector.Results[0].Score != 0.9 || vector.Results[0].Sources != nil {
+		t.Fatalf("retriever results were mutated: %+v", vector.Results[0])
+	}
+}
+
+func TestFuseResultsProvenance(t *testing.T) {
+	vector := newResultList(types.VectorRetrieverType, map[string]float64{"a": 0.9, "b": 0.7}, "a", "b", "a")
+	keywords := newResultList(types.KeywordsRetrieverType, map[string]float64{"b": 3}, "b")
+
+	got := FuseResults([]*types.RetrieveResult{vector, keywords}, nil)
+	for _, r := range got {
+		if r.ChunkID != "b" {
+			continue
+		}
+		if len(r.Sources) != 2 {
+			t.Fatalf("expected 2 sources for b, got %+v", r.Sources)
+		}
+		if r.Sources[0].RetrieverType != types.VectorRetrieverType || r.Sources[0].Rank != 2 || r.Sources[0].Score != 0.7 {
+			t.Errorf("unexpected vector provenance: %+v", r.Sources[0])
+		}
+		if r.Sources[1].RetrieverType != types.KeywordsRetrieverType || r.Sources[1].Rank != 1 || r.Sources[1].Score != 3 {
+			t.Errorf("unexpected keyword provenance: %+v", r.Sources[1])
+		}
+	}
+
+	// A single list keeps raw scores, deduplicated by chunk ID
+	single := FuseResults([]*types.RetrieveResult{vector}, nil)
+	if len(single) != 2 || single[0].ChunkID != "a" || single[0].Score != 0.9 {
+		t.Fatalf("unexpected single-list result: %+v", single)
+	}
+}
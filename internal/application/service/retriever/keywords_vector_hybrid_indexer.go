@@ -132,6 +132,13 @@ func (v *KeywordsVectorHybridRetrieveEngineService) DeleteByKnowledgeIDList(ctx
 	return v.indexRepository.DeleteByKnowledgeIDList(ctx, knowledgeIDList, dimension, knowledgeType)
 }
 
+// DeleteByKnowledgeBaseIDList deletes the vectors of the given dimension by their knowledge base IDs
+func (v *KeywordsVectorHybridRetrieveEngineService) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	return v.indexRepository.DeleteByKnowledgeBaseIDList(ctx, knowledgeBaseIDList, dimension, knowledgeType)
+}
+
 // Support returns the retriever types supported by this engine
 func (v *KeywordsVectorHybridRetrieveEngineService) Support() []types.RetrieverType {
 	return v.indexRepository.Support()
@@ -181,3 +188,11 @@ func (v *KeywordsVectorHybridRetrieveEngineService) BatchUpdateChunkEnabledStatu
 ) error {
 	return v.indexRepository.BatchUpdateChunkEnabledStatus(ctx, chunkStatusMap)
 }
+
+// BatchUpdateChunkAttributes replaces the filter attributes stored with the index entries of chunks in batch
+func (v *KeywordsVectorHybridRetrieveEngineService) BatchUpdateChunkAttributes(
+	ctx context.Context,
+	chunkAttributes map[string]*types.IndexAttributes,
+) error {
+	return v.indexRepository.BatchUpdateChunkAttributes(ctx, chunkAttributes)
+}
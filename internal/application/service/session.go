@@ -2,15 +2,20 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/application/repository"
 	chatpipline "github.com/Tencent/WeKnora/internal/application/service/chat_pipline"
 	llmcontext "github.com/Tencent/WeKnora/internal/application/service/llmcontext"
 	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/chat"
@@ -19,6 +24,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 )
@@ -30,18 +36,22 @@ func generateEventID(suffix string) string {
 
 // sessionService implements the SessionService interface for managing conversation sessions
 type sessionService struct {
-	cfg                  *config.Config                   // Application configuration
-	sessionRepo          interfaces.SessionRepository     // Repository for session data
-	messageRepo          interfaces.MessageRepository     // Repository for message data
-	knowledgeBaseService interfaces.KnowledgeBaseService  // Service for knowledge base operations
-	modelService         interfaces.ModelService          // Service for model operations
-	tenantService        interfaces.TenantService         // Service for tenant operations
-	eventManager         *chatpipline.EventManager        // Event manager for chat pipeline
-	agentService         interfaces.AgentService          // Service for agent operations
-	sessionStorage       llmcontext.ContextStorage        // Session storage
-	knowledgeService     interfaces.KnowledgeService      // Service for knowledge operations
-	chunkService         interfaces.ChunkService          // Service for chunk operations
-	webSearchStateRepo   interfaces.WebSearchStateService // Service for web search state
+	cfg                  *config.Config                     // Application configuration
+	sessionRepo          interfaces.SessionRepository       // Repository for session data
+	messageRepo          interfaces.MessageRepository       // Repository for message data
+	knowledgeBaseService interfaces.KnowledgeBaseService    // Service for knowledge base operations
+	modelService         interfaces.ModelService            // Service for model operations
+	tenantService        interfaces.TenantService           // Service for tenant operations
+	eventManager         *chatpipline.EventManager          // Event manager for chat pipeline
+	agentService         interfaces.AgentService            // Service for agent operations
+	sessionStorage       llmcontext.ContextStorage          // Session storage
+	knowledgeService     interfaces.KnowledgeService        // Service for knowledge operations
+	chunkService         interfaces.ChunkService            // Service for chunk operations
+	webSearchStateRepo   interfaces.WebSearchStateService   // Service for web search state
+	customAgentService   interfaces.CustomAgentService      // Service for custom agents (sub-agent lookup)
+	approvalRepo         interfaces.AgentApprovalRepository // Repository for agent runs paused for tool approval
+	shareRepo            interfaces.SessionShareRepository  // Repository for sessions shared with other users
+	memberRepo           interfaces.TenantMemberRepository  // Repository for share target validation
 }
 
 // NewSessionService creates a new session service instance with all required dependencies
@@ -57,6 +67,10 @@ func NewSessionService(cfg *config.Config,
 	agentService interfaces.AgentService,
 	sessionStorage llmcontext.ContextStorage,
 	webSearchStateRepo interfaces.WebSearchStateService,
+	customAgentService interfaces.CustomAgentService,
+	approvalRepo interfaces.AgentApprovalRepository,
+	shareRepo interfaces.SessionShareRepository,
+	memberRepo interfaces.TenantMemberRepository,
 ) interfaces.SessionService {
 	return &sessionService{
 		cfg:                  cfg,
@@ -71,6 +85,10 @@ func NewSessionService(cfg *config.Config,
 		agentService:         agentService,
 		sessionStorage:       sessionStorage,
 		webSearchStateRepo:   webSearchStateRepo,
+		customAgentService:   customAgentService,
+		approvalRepo:         approvalRepo,
+		shareRepo:            shareRepo,
+		memberRepo:           memberRepo,
 	}
 }
 
@@ -84,7 +102,12 @@ func (s *sessionService) CreateSession(ctx context.Context, session *types.Sessi
 		return nil, errors.New("tenant ID is required")
 	}
 
-	logger.Infof(ctx, "Creating session, tenant ID: %d", session.TenantID)
+	// Sessions belong to the user who created them
+	if user := sessionUser(ctx); user != nil && session.UserID == "" {
+		session.UserID = user.ID
+	}
+
+	logger.Infof(ctx, "Creating session, tenant ID: %d, user ID: %s", session.TenantID, session.UserID)
 
 	// Create session in repository
 	createdSession, err := s.sessionRepo.Create(ctx, session)
@@ -119,19 +142,44 @@ func (s *sessionService) GetSession(ctx context.Context, id string) (*types.Sess
 		})
 		return nil, err
 	}
+	if err := checkSessionAccess(ctx, s.shareRepo, session, false); err != nil {
+		return nil, err
+	}
 
 	logger.Infof(ctx, "Session retrieved successfully, ID: %s, tenant ID: %d", session.ID, session.TenantID)
 	return session, nil
 }
 
-// GetSessionsByTenant retrieves all sessions for the current tenant
+// GetWritableSession retrieves a session the current user may chat in or modify
+func (s *sessionService) GetWritableSession(ctx context.Context, id string) (*types.Session, error) {
+	if id == "" {
+		logger.Error(ctx, "Failed to get session: session ID cannot be empty")
+		return nil, errors.New("session id is required")
+	}
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	session, err := s.sessionRepo.Get(ctx, tenantID, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": id,
+			"tenant_id":  tenantID,
+		})
+		return nil, err
+	}
+	if err := checkSessionAccess(ctx, s.shareRepo, session, true); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetSessionsByTenant retrieves all sessions of the current user, or of the tenant for API key requests
 func (s *sessionService) GetSessionsByTenant(ctx context.Context) ([]*types.Session, error) {
 	// Get tenant ID from context
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	logger.Infof(ctx, "Retrieving all sessions for tenant, tenant ID: %d", tenantID)
 
 	// Get sessions from repository
-	sessions, err := s.sessionRepo.GetByTenantID(ctx, tenantID)
+	sessions, err := s.sessionRepo.GetByTenantID(ctx, tenantID, sessionListFilter(ctx, false))
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"tenant_id": tenantID,
@@ -145,14 +193,32 @@ func (s *sessionService) GetSessionsByTenant(ctx context.Context) ([]*types.Sess
 	return sessions, nil
 }
 
-// GetPagedSessionsByTenant retrieves sessions for the current tenant with pagination
+// GetPagedSessionsByTenant retrieves sessions of the current user, or of the tenant for API key requests,
+// with pagination
 func (s *sessionService) GetPagedSessionsByTenant(ctx context.Context,
 	pagination *types.Pagination,
+) (*types.PageResult, error) {
+	return s.getPagedSessions(ctx, sessionListFilter(ctx, false), pagination)
+}
+
+// GetPagedSharedSessions retrieves sessions other users shared with the current user, with pagination
+func (s *sessionService) GetPagedSharedSessions(ctx context.Context,
+	pagination *types.Pagination,
+) (*types.PageResult, error) {
+	filter := sessionListFilter(ctx, true)
+	if filter == nil {
+		return nil, werrors.NewBadRequestError("Shared sessions are only available to logged in users")
+	}
+	return s.getPagedSessions(ctx, filter, pagination)
+}
+
+func (s *sessionService) getPagedSessions(ctx context.Context,
+	filter *types.SessionListFilter, pagination *types.Pagination,
 ) (*types.PageResult, error) {
 	// Get tenant ID from context
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	// Get paged sessions from repository
-	sessions, total, err := s.sessionRepo.GetPagedByTenantID(ctx, tenantID, pagination)
+	sessions, total, err := s.sessionRepo.GetPagedByTenantID(ctx, tenantID, filter, pagination)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"tenant_id": tenantID,
@@ -173,8 +239,18 @@ func (s *sessionService) UpdateSession(ctx context.Context, session *types.Sessi
 		return errors.New("session id is required")
 	}
 
+	existing, err := s.sessionRepo.Get(ctx, session.TenantID, session.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkSessionAccess(ctx, s.shareRepo, existing, true); err != nil {
+		return err
+	}
+	// The owner cannot be changed through an update
+	session.UserID = existing.UserID
+
 	// Update session in repository
-	err := s.sessionRepo.Update(ctx, session)
+	err = s.sessionRepo.Update(ctx, session)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"session_id": session.ID,
@@ -198,13 +274,21 @@ func (s *sessionService) DeleteSession(ctx context.Context, id string) error {
 	// Get tenant ID from context
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 
+	session, err := s.sessionRepo.Get(ctx, tenantID, id)
+	if err != nil {
+		return err
+	}
+	if err := checkSessionAccess(ctx, s.shareRepo, session, true); err != nil {
+		return err
+	}
+
 	// Cleanup temporary KB stored in Redis for this session
 	if err := s.webSearchStateRepo.DeleteWebSearchTempKBState(ctx, id); err != nil {
 		logger.Warnf(ctx, "Failed to cleanup temporary KB for session %s: %v", id, err)
 	}
 
 	// Delete session from repository
-	err := s.sessionRepo.Delete(ctx, tenantID, id)
+	err = s.sessionRepo.Delete(ctx, tenantID, id)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"session_id": id,
@@ -212,7 +296,83 @@ func (s *sessionService) DeleteSession(ctx context.Context, id string) error {
 		})
 		return err
 	}
+	if err := s.shareRepo.DeleteBySession(ctx, tenantID, id); err != nil {
+		logger.Warnf(ctx, "Failed to delete shares of session %s: %v", id, err)
+	}
+
+	return nil
+}
+
+// ShareSession gives a user, or every user of the tenant, read-only access to a session
+func (s *sessionService) ShareSession(ctx context.Context,
+	sessionID string, shareType types.SessionShareType, userID string,
+) (*types.SessionShare, error) {
+	session, err := s.GetWritableSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch shareType {
+	case types.SessionShareTypeTenant:
+		userID = ""
+	case types.SessionShareTypeUser:
+		if userID == "" {
+			return nil, werrors.NewBadRequestError("user_id is required to share with a user")
+		}
+		if userID == session.UserID {
+			return nil, werrors.NewBadRequestError("Cannot share a session with its owner")
+		}
+		if _, err := s.memberRepo.Get(ctx, session.TenantID, userID); err != nil {
+			return nil, werrors.NewBadRequestError("User not found in tenant")
+		}
+	default:
+		return nil, werrors.NewBadRequestError(fmt.Sprintf("Invalid share type: %s", shareType))
+	}
+
+	share := &types.SessionShare{
+		TenantID:  session.TenantID,
+		SessionID: session.ID,
+		ShareType: shareType,
+		UserID:    userID,
+	}
+	if user := sessionUser(ctx); user != nil {
+		share.CreatedBy = user.ID
+	}
+	share, err = s.shareRepo.Create(ctx, share)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+			"share_type": shareType,
+		})
+		return nil, err
+	}
+
+	logger.Infof(ctx, "Session %s shared, type: %s, user ID: %s", sessionID, shareType, userID)
+	return share, nil
+}
+
+// ListSessionShares lists who a session is shared with
+func (s *sessionService) ListSessionShares(ctx context.Context, sessionID string) ([]*types.SessionShare, error) {
+	session, err := s.GetWritableSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.shareRepo.ListBySession(ctx, session.TenantID, session.ID)
+}
 
+// UnshareSession revokes a share of a session
+func (s *sessionService) UnshareSession(ctx context.Context, sessionID, shareID string) error {
+	session, err := s.GetWritableSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if err := s.shareRepo.Delete(ctx, session.TenantID, session.ID, shareID); err != nil {
+		if errors.Is(err, repository.ErrSessionShareNotFound) {
+			return werrors.NewNotFoundError("Session share not found")
+		}
+		return err
+	}
+	logger.Infof(ctx, "Session %s share %s revoked", sessionID, shareID)
 	return nil
 }
 
@@ -225,6 +385,7 @@ func (s *sessionService) GenerateTitle(ctx context.Context,
 		logger.Error(ctx, "Failed to generate title: session cannot be empty")
 		return "", errors.New("session cannot be empty")
 	}
+	ctx = context.WithValue(ctx, types.SessionIDContextKey, session.ID)
 
 	// Skip if title already exists
 	if session.Title != "" {
@@ -403,6 +564,7 @@ func (s *sessionService) KnowledgeQA(
 	eventBus *event.EventBus,
 	customAgent *types.CustomAgent,
 ) error {
+	ctx = context.WithValue(ctx, types.SessionIDContextKey, session.ID)
 	logger.Infof(
 		ctx,
 		"Knowledge base question answering parameters, session ID: %s, query: %s, webSearchEnabled: %v",
@@ -425,6 +587,9 @@ func (s *sessionService) KnowledgeQA(
 		return err
 	}
 
+	// Fallback models of the chat model, nil uses the fallback declared by the model
+	var chatModelFallback *types.ModelFallback
+
 	// Initialize default values from config.yaml
 	rewritePromptSystem := s.cfg.Conversation.RewritePromptSystem
 	rewritePromptUser := s.cfg.Conversation.RewritePromptUser
@@ -439,7 +604,10 @@ func (s *sessionService) KnowledgeQA(
 	fallbackPrompt := s.cfg.Conversation.FallbackPrompt
 	enableRewrite := s.cfg.Conversation.EnableRewrite
 	enableQueryExpansion := s.cfg.Conversation.EnableQueryExpansion
+	enableHyDE := false
+	hydeMode := types.HyDEModeAppend
 	rerankModelID := ""
+	var fusionConfig *types.FusionConfig
 
 	summaryConfig := types.SummaryConfig{
 		Prompt:              s.cfg.Conversation.Summary.Prompt,
@@ -463,6 +631,7 @@ func (s *sessionService) KnowledgeQA(
 		// Override model ID
 		if customAgent.Config.ModelID != "" {
 			chatModelID = customAgent.Config.ModelID
+			chatModelFallback = customAgent.Config.ModelFallback
 			logger.Infof(ctx, "Using custom agent's model_id: %s", chatModelID)
 		}
 		// Override system prompt
@@ -504,9 +673,16 @@ func (s *sessionService) KnowledgeQA(
 		if customAgent.Config.RerankModelID != "" {
 			rerankModelID = customAgent.Config.RerankModelID
 		}
+		if customAgent.Config.FusionConfig != nil {
+			fusionConfig = customAgent.Config.FusionConfig
+		}
 		// Override rewrite settings
 		enableRewrite = customAgent.Config.EnableRewrite
 		enableQueryExpansion = customAgent.Config.EnableQueryExpansion
+		enableHyDE = customAgent.Config.EnableHyDE
+		if customAgent.Config.HyDEMode != "" {
+			hydeMode = types.HyDEMode(customAgent.Config.HyDEMode)
+		}
 		if customAgent.Config.RewritePromptSystem != "" {
 			rewritePromptSystem = customAgent.Config.RewritePromptSystem
 		}
@@ -575,11 +751,13 @@ func (s *sessionService) KnowledgeQA(
 		VectorThreshold:      vectorThreshold,
 		KeywordThreshold:     keywordThreshold,
 		EmbeddingTopK:        embeddingTopK,
+		FusionConfig:         fusionConfig,
 		RerankModelID:        rerankModelID,
 		RerankTopK:           rerankTopK,
 		RerankThreshold:      rerankThreshold,
 		MaxRounds:            maxRounds,
 		ChatModelID:          chatModelID,
+		ModelFallback:        chatModelFallback,
 		SummaryConfig:        summaryConfig,
 		FallbackStrategy:     fallbackStrategy,
 		FallbackResponse:     fallbackResponse,
@@ -591,16 +769,20 @@ func (s *sessionService) KnowledgeQA(
 		RewritePromptUser:    rewritePromptUser,
 		EnableRewrite:        enableRewrite,
 		EnableQueryExpansion: enableQueryExpansion,
+		EnableHyDE:           enableHyDE,
+		HyDEMode:             hydeMode,
 		// FAQ Strategy Settings
 		FAQPriorityEnabled:       faqPriorityEnabled,
 		FAQDirectAnswerThreshold: faqDirectAnswerThreshold,
 		FAQScoreBoost:            faqScoreBoost,
+		AgentConfigHash:          customAgentConfigHash(customAgent),
 	}
 
 	// Determine pipeline based on knowledge bases availability and web search setting
 	// If no knowledge bases are selected AND web search is disabled, use pure chat pipeline
 	// Otherwise use rag_stream pipeline (which handles both KB search and web search)
 	var pipeline []types.EventType
+	ragPipeline := false
 	if len(knowledgeBaseIDs) == 0 && len(knowledgeIDs) == 0 && !webSearchEnabled {
 		logger.Info(ctx, "No knowledge bases selected and web search disabled, using chat pipeline")
 		// For pure chat, UserContent is the Query (since INTO_CHAT_MESSAGE is skipped)
@@ -621,11 +803,24 @@ func (s *sessionService) KnowledgeQA(
 			logger.Info(ctx, "Knowledge bases selected, using rag_stream pipeline")
 		}
 		pipeline = types.Pipline["rag_stream"]
+		ragPipeline = true
+	}
+	stages := types.NewPipelineStages(pipeline)
+
+	// A custom agent may declare its own stage list, replacing the built-in rag_stream pipeline.
+	// The agent API reports a pipeline that no longer validates as pipeline_error.
+	if ragPipeline && customAgent != nil && len(customAgent.Config.Pipeline) > 0 {
+		if err := s.eventManager.ValidatePipeline(customAgent.Config.Pipeline); err != nil {
+			logger.Warnf(ctx, "Pipeline of agent %s is invalid, using built-in pipeline: %v", customAgent.ID, err)
+		} else {
+			logger.Infof(ctx, "Using custom agent's pipeline with %d stages", len(customAgent.Config.Pipeline))
+			stages = customAgent.Config.Pipeline
+		}
 	}
 
 	// Start knowledge QA event processing
 	logger.Info(ctx, "Triggering question answering event")
-	err = s.KnowledgeQAByEvent(ctx, chatManage, pipeline)
+	err = s.KnowledgeQAByStages(ctx, chatManage, stages)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"session_id": session.ID,
@@ -736,7 +931,7 @@ func (s *sessionService) selectChatModelID(
 			}
 			if kb != nil && kb.SummaryModelID != "" {
 				model, err := s.modelService.GetModelByID(ctx, kb.SummaryModelID)
-				if err == nil && model != nil && model.Source == types.ModelSourceRemote {
+				if err == nil && model != nil && (model.Source == types.ModelSourceRemote || model.Source == types.ModelSourceAnthropic) {
 					logger.Info(ctx, "Using Remote summary model from knowledge base")
 					return kb.SummaryModelID, nil
 				}
@@ -880,9 +1075,31 @@ func (s *sessionService) buildSearchTargets(
 	return targets, nil
 }
 
+// customAgentConfigHash identifies the configuration of a custom agent, so that cached answers
+// are neither shared between agents nor kept across configuration changes
+func customAgentConfigHash(customAgent *types.CustomAgent) string {
+	if customAgent == nil {
+		return ""
+	}
+	data, err := json.Marshal(customAgent.Config)
+	if err != nil {
+		return customAgent.ID
+	}
+	sum := sha256.Sum256(append([]byte(customAgent.ID+":"), data...))
+	return hex.EncodeToString(sum[:])
+}
+
 // KnowledgeQAByEvent processes knowledge QA through a series of events in the pipeline
 func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 	chatManage *types.ChatManage, eventList []types.EventType,
+) error {
+	return s.KnowledgeQAByStages(ctx, chatManage, types.NewPipelineStages(eventList))
+}
+
+// KnowledgeQAByStages runs a declarative pipeline, applying each stage's parameters
+// to chatManage only while that stage is being triggered
+func (s *sessionService) KnowledgeQAByStages(ctx context.Context,
+	chatManage *types.ChatManage, stages []types.PipelineStage,
 ) error {
 	ctx, span := tracing.ContextWithSpan(ctx, "SessionService.KnowledgeQAByEvent")
 	defer span.End()
@@ -893,8 +1110,8 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 
 	// Prepare method list for logging and tracing
 	methods := []string{}
-	for _, event := range eventList {
-		methods = append(methods, string(event))
+	for _, stage := range stages {
+		methods = append(methods, string(stage.Event))
 	}
 
 	// Set up tracing attributes
@@ -906,9 +1123,12 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 	)
 
 	// Process each event in sequence
-	for _, eventType := range eventList {
+	for _, stage := range stages {
+		eventType := stage.Event
 		logger.Infof(ctx, "Starting to trigger event: %v", eventType)
+		restore := stage.Apply(chatManage)
 		err := s.eventManager.Trigger(ctx, eventType, chatManage)
+		restore()
 
 		// Handle case where search returns no results
 		if err == chatpipline.ErrSearchNothing {
@@ -922,6 +1142,12 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 			return nil
 		}
 
+		// The answer was served from the response cache, the remaining stages are skipped
+		if err == chatpipline.ErrResponseCacheHit {
+			logger.Infof(ctx, "Event %v answered from response cache", eventType)
+			return nil
+		}
+
 		// Handle other errors
 		if err != nil {
 			logger.Errorf(ctx, "Event triggering failed, event: %v, error type: %s, description: %s, error: %v",
@@ -941,8 +1167,9 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 // SearchKnowledge performs knowledge base search without LLM summarization
 // knowledgeBaseIDs: list of knowledge base IDs to search (supports multi-KB)
 // knowledgeIDs: list of specific knowledge (file) IDs to search
+// filter: optional metadata filter restricting the searched chunks
 func (s *sessionService) SearchKnowledge(ctx context.Context,
-	knowledgeBaseIDs []string, knowledgeIDs []string, query string,
+	knowledgeBaseIDs []string, knowledgeIDs []string, query string, filter *types.RetrieveFilter,
 ) ([]*types.SearchResult, error) {
 	logger.Info(ctx, "Start knowledge base search without LLM summary")
 	logger.Infof(ctx, "Knowledge base search parameters, knowledge base IDs: %v, knowledge IDs: %v, query: %s",
@@ -979,6 +1206,7 @@ func (s *sessionService) SearchKnowledge(ctx context.Context,
 		RerankTopK:       s.cfg.Conversation.RerankTopK,       // Use default configuration
 		RerankThreshold:  s.cfg.Conversation.RerankThreshold,  // Use default configuration
 		MaxRounds:        s.cfg.Conversation.MaxRounds,
+		RetrieveFilter:   filter,
 	}
 
 	// Get default models
@@ -1065,6 +1293,7 @@ func (s *sessionService) AgentQA(
 	knowledgeIDs []string,
 ) error {
 	sessionID := session.ID
+	ctx = context.WithValue(ctx, types.SessionIDContextKey, sessionID)
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 	sessionJSON, err := json.Marshal(session)
 	if err != nil {
@@ -1074,17 +1303,110 @@ func (s *sessionService) AgentQA(
 	logger.Infof(ctx, "Start agent-based question answering, session ID: %s, tenant ID: %d, query: %s, session: %s",
 		sessionID, tenantID, query, string(sessionJSON))
 
-	// Build effective agent configuration by merging session and tenant configs
-	// All config now comes from customAgent parameter
-
-	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
-
 	// customAgent is required for AgentQA
 	if customAgent == nil {
 		logger.Warnf(ctx, "Custom agent not provided for session: %s", sessionID)
 		return errors.New("custom agent configuration is required for agent QA")
 	}
 
+	// Build effective agent configuration, all config comes from customAgent
+	agentConfig, summaryModel, rerankModel, err := s.buildAgentRuntime(ctx, customAgent, knowledgeBaseIDs, knowledgeIDs)
+	if err != nil {
+		return err
+	}
+
+	// Resolve custom agents this agent may delegate to
+	agentConfig.SubAgents = s.resolveSubAgents(ctx, customAgent)
+
+	// Get or create contextManager for this session
+	contextManager := s.getContextManagerForSession(ctx, session, summaryModel)
+
+	// Set system prompt for the current agent in context manager
+	// This ensures the context uses the correct system prompt when switching agents
+	systemPrompt := agentConfig.ResolveSystemPrompt(agentConfig.WebSearchEnabled)
+	if systemPrompt != "" {
+		if err := contextManager.SetSystemPrompt(ctx, sessionID, systemPrompt); err != nil {
+			logger.Warnf(ctx, "Failed to set system prompt in context manager: %v", err)
+		} else {
+			logger.Infof(ctx, "System prompt updated in context manager for agent")
+		}
+	}
+
+	// Get LLM context from context manager
+	llmContext, err := s.getContextForSession(ctx, contextManager, sessionID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get LLM context: %v, continuing without history", err)
+		llmContext = []chat.Message{}
+	}
+	logger.Infof(ctx, "Loaded %d messages from LLM context manager", len(llmContext))
+
+	// Apply multi-turn configuration for Agent mode
+	// Note: In Agent mode, context is managed by contextManager with compression strategies,
+	// so we don't apply HistoryTurns limit here. HistoryTurns is used in normal (KnowledgeQA) mode.
+	if !agentConfig.MultiTurnEnabled {
+		// Multi-turn disabled, clear history
+		logger.Infof(ctx, "Multi-turn disabled for this agent, clearing history context")
+		llmContext = []chat.Message{}
+	}
+
+	// Create agent engine with EventBus and ContextManager
+	logger.Info(ctx, "Creating agent engine")
+	engine, err := s.agentService.CreateAgentEngine(
+		ctx,
+		agentConfig,
+		summaryModel,
+		rerankModel,
+		eventBus,
+		contextManager,
+		session.ID,
+		s,
+	)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to create agent engine: %v", err)
+		return err
+	}
+
+	// Execute agent with streaming (asynchronously)
+	// Events will be emitted to EventBus and handled by the Handler layer
+	logger.Info(ctx, "Executing agent with streaming")
+	state, err := engine.Execute(ctx, sessionID, assistantMessageID, query, llmContext)
+	if err != nil {
+		logger.Errorf(ctx, "Agent execution failed: %v", err)
+		// Emit error event to the EventBus used by this agent
+		eventBus.Emit(ctx, event.Event{
+			Type:      event.EventError,
+			SessionID: sessionID,
+			Data: event.ErrorData{
+				Error:     err.Error(),
+				Stage:     "agent_execution",
+				SessionID: sessionID,
+			},
+		})
+	}
+	if state != nil && state.Pause != nil {
+		return s.saveAgentApproval(ctx, &types.AgentApproval{
+			SessionID:        sessionID,
+			MessageID:        assistantMessageID,
+			AgentID:          customAgent.ID,
+			KnowledgeBaseIDs: knowledgeBaseIDs,
+			KnowledgeIDs:     knowledgeIDs,
+			RequestedBy:      sessionUserID(ctx),
+		}, state.Pause)
+	}
+	// Return empty - events will be handled by Handler via EventBus subscription
+	return nil
+}
+
+// buildAgentRuntime builds the runtime agent configuration, chat model and rerank model of a custom agent.
+// knowledgeBaseIDs and knowledgeIDs (request-level @ mentions) take priority over the agent's own knowledge bases.
+func (s *sessionService) buildAgentRuntime(
+	ctx context.Context,
+	customAgent *types.CustomAgent,
+	knowledgeBaseIDs []string,
+	knowledgeIDs []string,
+) (*types.AgentConfig, chat.Chat, rerank.Reranker, error) {
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+
 	// Ensure defaults are set
 	customAgent.EnsureDefaults()
 
@@ -1100,6 +1422,11 @@ func (s *sessionService) AgentQA(
 		HistoryTurns:        customAgent.Config.HistoryTurns,
 		MCPSelectionMode:    customAgent.Config.MCPSelectionMode,
 		MCPServices:         customAgent.Config.MCPServices,
+		MCPPrompts:          customAgent.Config.MCPPrompts,
+		MCPResources:        customAgent.Config.MCPResources,
+		FusionConfig:        customAgent.Config.FusionConfig,
+		ToolApproval:        customAgent.Config.ToolApproval,
+		ToolExecution:       customAgent.Config.ToolExecution,
 	}
 
 	// Resolve knowledge bases: request-level @ mentions take priority over agent config
@@ -1142,7 +1469,7 @@ func (s *sessionService) AgentQA(
 		}
 	}
 
-	logger.Infof(ctx, "Merged agent config from tenant %d and session %s", tenantInfo.ID, sessionID)
+	logger.Infof(ctx, "Merged agent config from tenant %d and custom agent %s", tenantInfo.ID, customAgent.ID)
 
 	// Log knowledge bases if present
 	if len(agentConfig.KnowledgeBases) > 0 {
@@ -1167,13 +1494,13 @@ func (s *sessionService) AgentQA(
 	summaryModelID := customAgent.Config.ModelID
 	if summaryModelID == "" {
 		logger.Warnf(ctx, "No summary model configured for custom agent %s", customAgent.ID)
-		return errors.New("summary model (model_id) is not configured in custom agent settings")
+		return nil, nil, nil, errors.New("summary model (model_id) is not configured in custom agent settings")
 	}
 
-	summaryModel, err := s.modelService.GetChatModel(ctx, summaryModelID)
+	summaryModel, err := s.modelService.GetChatModelWithFallback(ctx, summaryModelID, customAgent.Config.ModelFallback)
 	if err != nil {
 		logger.Warnf(ctx, "Failed to get chat model: %v", err)
-		return fmt.Errorf("failed to get chat model: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to get chat model: %w", err)
 	}
 
 	// Get rerank model from custom agent config (only required when knowledge bases are configured)
@@ -1183,51 +1510,208 @@ func (s *sessionService) AgentQA(
 		rerankModelID := customAgent.Config.RerankModelID
 		if rerankModelID == "" {
 			logger.Warnf(ctx, "No rerank model configured for custom agent %s, but knowledge bases are specified", customAgent.ID)
-			return errors.New("rerank model (rerank_model_id) is not configured in custom agent settings")
+			return nil, nil, nil, errors.New("rerank model (rerank_model_id) is not configured in custom agent settings")
 		}
 
 		rerankModel, err = s.modelService.GetRerankModel(ctx, rerankModelID)
 		if err != nil {
 			logger.Warnf(ctx, "Failed to get rerank model: %v", err)
-			return fmt.Errorf("failed to get rerank model: %w", err)
+			return nil, nil, nil, fmt.Errorf("failed to get rerank model: %w", err)
 		}
 	} else {
 		logger.Infof(ctx, "No knowledge bases configured, skipping rerank model initialization")
 	}
 
-	// Get or create contextManager for this session
-	contextManager := s.getContextManagerForSession(ctx, session, summaryModel)
+	return agentConfig, summaryModel, rerankModel, nil
+}
 
-	// Set system prompt for the current agent in context manager
-	// This ensures the context uses the correct system prompt when switching agents
-	systemPrompt := agentConfig.ResolveSystemPrompt(agentConfig.WebSearchEnabled)
-	if systemPrompt != "" {
-		if err := contextManager.SetSystemPrompt(ctx, sessionID, systemPrompt); err != nil {
-			logger.Warnf(ctx, "Failed to set system prompt in context manager: %v", err)
-		} else {
-			logger.Infof(ctx, "System prompt updated in context manager for agent")
+// resolveSubAgents loads the custom agents the given agent may invoke as tools.
+// Missing agents, the agent itself and agents not running in agent mode are skipped.
+func (s *sessionService) resolveSubAgents(ctx context.Context, customAgent *types.CustomAgent) []*types.SubAgentInfo {
+	subAgents := make([]*types.SubAgentInfo, 0, len(customAgent.Config.SubAgents))
+	for _, agentID := range customAgent.Config.SubAgents {
+		if agentID == customAgent.ID {
+			continue
+		}
+		subAgent, err := s.customAgentService.GetAgentByID(ctx, agentID)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to load sub-agent %s of agent %s: %v", agentID, customAgent.ID, err)
+			continue
+		}
+		if !subAgent.IsAgentMode() {
+			logger.Warnf(ctx, "Sub-agent %s of agent %s is not in agent mode, skipping", agentID, customAgent.ID)
+			continue
 		}
+		subAgents = append(subAgents, &types.SubAgentInfo{
+			ID:          subAgent.ID,
+			Name:        subAgent.Name,
+			Description: subAgent.Description,
+		})
 	}
+	return subAgents
+}
 
-	// Get LLM context from context manager
-	llmContext, err := s.getContextForSession(ctx, contextManager, sessionID)
+// RunSubAgent runs a custom agent on a task delegated by another agent.
+// The sub-agent starts without conversation history and cannot delegate further.
+func (s *sessionService) RunSubAgent(
+	ctx context.Context,
+	sessionID, agentID, task string,
+	eventBus *event.EventBus,
+) (*types.AgentState, error) {
+	ctx = context.WithValue(ctx, types.SessionIDContextKey, sessionID)
+	customAgent, err := s.customAgentService.GetAgentByID(ctx, agentID)
 	if err != nil {
-		logger.Warnf(ctx, "Failed to get LLM context: %v, continuing without history", err)
-		llmContext = []chat.Message{}
+		return nil, fmt.Errorf("failed to get sub-agent: %w", err)
+	}
+	if !customAgent.IsAgentMode() {
+		return nil, fmt.Errorf("custom agent %s is not in agent mode", agentID)
 	}
-	logger.Infof(ctx, "Loaded %d messages from LLM context manager", len(llmContext))
 
-	// Apply multi-turn configuration for Agent mode
-	// Note: In Agent mode, context is managed by contextManager with compression strategies,
-	// so we don't apply HistoryTurns limit here. HistoryTurns is used in normal (KnowledgeQA) mode.
-	if !agentConfig.MultiTurnEnabled {
-		// Multi-turn disabled, clear history
-		logger.Infof(ctx, "Multi-turn disabled for this agent, clearing history context")
-		llmContext = []chat.Message{}
+	agentConfig, chatModel, rerankModel, err := s.buildAgentRuntime(ctx, customAgent, nil, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	// Create agent engine with EventBus and ContextManager
-	logger.Info(ctx, "Creating agent engine")
+	engine, err := s.agentService.CreateAgentEngine(
+		ctx,
+		agentConfig,
+		chatModel,
+		rerankModel,
+		eventBus,
+		nil,
+		sessionID,
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sub-agent engine: %w", err)
+	}
+
+	logger.Infof(ctx, "Running sub-agent %s (%s) for session %s", customAgent.Name, agentID, sessionID)
+	state, err := engine.Execute(ctx, sessionID, uuid.New().String(), task, []chat.Message{})
+	if err != nil {
+		return state, err
+	}
+	// Delegated runs cannot be paused, tools gated by the sub-agent's approval policy are refused
+	if state.Pause != nil {
+		return state, fmt.Errorf("sub-agent %s requires approval for tool %s, which is not supported in delegated runs",
+			customAgent.Name, state.Pause.ToolCalls[0].Name)
+	}
+	return state, nil
+}
+
+const (
+	// agentApprovalHeartbeat is how often a resuming approval is refreshed while its run is alive
+	agentApprovalHeartbeat = time.Minute
+	// agentApprovalStaleAfter is how long a resuming approval may go without a refresh before the sweep fails it
+	agentApprovalStaleAfter = 5 * time.Minute
+	// agentApprovalInterruptedNote is appended to the answer of a run the sweep failed
+	agentApprovalInterruptedNote = "\n\n[The agent run was interrupted after the tool calls were approved and did not finish.]"
+)
+
+// saveAgentApproval persists an agent run paused for tool approval so it can be resumed, even after a restart
+func (s *sessionService) saveAgentApproval(
+	ctx context.Context,
+	approval *types.AgentApproval,
+	pause *types.AgentPause,
+) error {
+	approval.ID = uuid.New().String()
+	approval.TenantID = ctx.Value(types.TenantIDContextKey).(uint64)
+	approval.ToolCalls = pause.ToolCalls
+	approval.Snapshot = pause.Snapshot
+	approval.Status = types.AgentApprovalStatusPending
+	if err := s.approvalRepo.Create(ctx, approval); err != nil {
+		logger.Errorf(ctx, "Failed to save agent approval for message %s: %v", approval.MessageID, err)
+		return fmt.Errorf("failed to save agent approval: %w", err)
+	}
+	logger.Infof(ctx, "Agent paused for approval, session: %s, message: %s, approval: %s",
+		approval.SessionID, approval.MessageID, approval.ID)
+	return nil
+}
+
+// ResolveAgentApproval records the operator decision on the pending tool calls of an assistant message
+func (s *sessionService) ResolveAgentApproval(
+	ctx context.Context,
+	sessionID, messageID string,
+	approved bool,
+	reason string,
+) (*types.AgentApproval, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	approval, err := s.approvalRepo.GetPendingByMessage(ctx, tenantID, sessionID, messageID)
+	if err != nil {
+		if errors.Is(err, repository.ErrAgentApprovalNotFound) {
+			return nil, werrors.NewNotFoundError("no tool calls are waiting for approval on this message")
+		}
+		return nil, err
+	}
+
+	// The user who started the run can abort it but not approve its own tool calls. Without a user on
+	// both sides (e.g. an API key) the two cannot be told apart, so such calls can only be rejected.
+	resolvedBy := sessionUserID(ctx)
+	if approved && (resolvedBy == "" || approval.RequestedBy == "") {
+		logger.Warnf(ctx, "Approval %s denied without a requesting and an approving user", approval.ID)
+		return nil, werrors.NewForbiddenError("tool calls can only be approved by a signed-in user on a run started by another signed-in user")
+	}
+	if approved && resolvedBy == approval.RequestedBy {
+		logger.Warnf(ctx, "User %s denied approving own tool calls, approval: %s", resolvedBy, approval.ID)
+		return nil, werrors.NewForbiddenError("tool calls cannot be approved by the user who triggered them")
+	}
+
+	// Approved runs stay resuming until ResumeAgentQA finishes, see ProcessAgentApprovalSweep
+	status := types.AgentApprovalStatusRejected
+	if approved {
+		status = types.AgentApprovalStatusResuming
+	}
+	ok, err := s.approvalRepo.Resolve(ctx, tenantID, approval.ID, status, reason, resolvedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, werrors.NewConflictError("the tool calls have already been approved or rejected")
+	}
+	approval.Status = status
+	approval.Reason = reason
+	approval.ResolvedBy = resolvedBy
+	logger.Infof(ctx, "Agent approval %s resolved as %s, session: %s, message: %s",
+		approval.ID, status, sessionID, messageID)
+	return approval, nil
+}
+
+// ResumeAgentQA resumes an agent run paused for tool approval once the approval has been resolved.
+// The agent engine is rebuilt from the custom agent so that the run continues after a restart.
+// An approved run moves from resuming to approved when it finishes and to failed when it errors.
+func (s *sessionService) ResumeAgentQA(
+	ctx context.Context,
+	session *types.Session,
+	approval *types.AgentApproval,
+	eventBus *event.EventBus,
+) (err error) {
+	ctx = context.WithValue(ctx, types.SessionIDContextKey, session.ID)
+	if approval.Status == types.AgentApprovalStatusResuming {
+		stop := s.keepApprovalResuming(ctx, approval)
+		defer func() {
+			stop()
+			r := recover()
+			s.finishApprovalResume(ctx, approval, err == nil && r == nil)
+			if r != nil {
+				panic(r)
+			}
+		}()
+	}
+
+	customAgent, err := s.customAgentService.GetAgentByID(ctx, approval.AgentID)
+	if err != nil {
+		return fmt.Errorf("failed to get custom agent: %w", err)
+	}
+
+	agentConfig, summaryModel, rerankModel, err := s.buildAgentRuntime(
+		ctx, customAgent, approval.KnowledgeBaseIDs, approval.KnowledgeIDs,
+	)
+	if err != nil {
+		return err
+	}
+	agentConfig.SubAgents = s.resolveSubAgents(ctx, customAgent)
+
+	contextManager := s.getContextManagerForSession(ctx, session, summaryModel)
 	engine, err := s.agentService.CreateAgentEngine(
 		ctx,
 		agentConfig,
@@ -1236,29 +1720,111 @@ func (s *sessionService) AgentQA(
 		eventBus,
 		contextManager,
 		session.ID,
+		s,
 	)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to create agent engine: %v", err)
 		return err
 	}
 
-	// Execute agent with streaming (asynchronously)
-	// Events will be emitted to EventBus and handled by the Handler layer
-	logger.Info(ctx, "Executing agent with streaming")
-	if _, err := engine.Execute(ctx, sessionID, assistantMessageID, query, llmContext); err != nil {
-		logger.Errorf(ctx, "Agent execution failed: %v", err)
-		// Emit error event to the EventBus used by this agent
-		eventBus.Emit(ctx, event.Event{
-			Type:      event.EventError,
-			SessionID: sessionID,
-			Data: event.ErrorData{
-				Error:     err.Error(),
-				Stage:     "agent_execution",
-				SessionID: sessionID,
-			},
-		})
+	approved := approval.Status == types.AgentApprovalStatusResuming
+	logger.Infof(ctx, "Resuming agent, session: %s, message: %s, approved: %v", session.ID, approval.MessageID, approved)
+	state, err := engine.Resume(ctx, session.ID, approval.MessageID, approval.Snapshot, approved, approval.Reason)
+	if err != nil {
+		return err
+	}
+	// The resumed run may stop again on a later round
+	if state != nil && state.Pause != nil {
+		return s.saveAgentApproval(ctx, &types.AgentApproval{
+			SessionID:        session.ID,
+			MessageID:        approval.MessageID,
+			AgentID:          approval.AgentID,
+			KnowledgeBaseIDs: approval.KnowledgeBaseIDs,
+			KnowledgeIDs:     approval.KnowledgeIDs,
+			RequestedBy:      approval.RequestedBy,
+		}, state.Pause)
+	}
+	return nil
+}
+
+// keepApprovalResuming refreshes the resuming approval while the run is alive,
+// so that the sweep only fails runs whose server went away. The returned func stops it.
+func (s *sessionService) keepApprovalResuming(ctx context.Context, approval *types.AgentApproval) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(agentApprovalHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.approvalRepo.UpdateStatus(ctx, approval.TenantID, approval.ID,
+					types.AgentApprovalStatusResuming, types.AgentApprovalStatusResuming); err != nil {
+					logger.Warnf(ctx, "Failed to refresh resuming approval %s: %v", approval.ID, err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// finishApprovalResume records whether the resumed run of an approval finished
+func (s *sessionService) finishApprovalResume(ctx context.Context, approval *types.AgentApproval, succeeded bool) {
+	status := types.AgentApprovalStatusFailed
+	if succeeded {
+		status = types.AgentApprovalStatusApproved
+	}
+	// The run may have outlived the request, record the result regardless of cancellation
+	ok, err := s.approvalRepo.UpdateStatus(context.WithoutCancel(ctx), approval.TenantID, approval.ID,
+		types.AgentApprovalStatusResuming, status)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to record resume result of approval %s: %v", approval.ID, err)
+		return
+	}
+	if !ok {
+		logger.Warnf(ctx, "Approval %s was no longer resuming when its run finished", approval.ID)
+		return
+	}
+	approval.Status = status
+}
+
+// ProcessAgentApprovalSweep fails approved agent runs that stopped resuming without finishing,
+// e.g. because the server restarted. Their tool calls may already have run, so they are not re-run.
+func (s *sessionService) ProcessAgentApprovalSweep(ctx context.Context, t *asynq.Task) error {
+	approvals, err := s.approvalRepo.ListByStatusBefore(ctx,
+		types.AgentApprovalStatusResuming, time.Now().Add(-agentApprovalStaleAfter))
+	if err != nil {
+		logger.Errorf(ctx, "Failed to list interrupted agent approvals: %v", err)
+		return err
+	}
+	for _, approval := range approvals {
+		ok, err := s.approvalRepo.UpdateStatus(ctx, approval.TenantID, approval.ID,
+			types.AgentApprovalStatusResuming, types.AgentApprovalStatusFailed)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to fail interrupted approval %s: %v", approval.ID, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		logger.Warnf(ctx, "Agent run of approval %s was interrupted, session: %s, message: %s",
+			approval.ID, approval.SessionID, approval.MessageID)
+
+		// Close the message so that clients stop waiting on its stream
+		message, err := s.messageRepo.GetMessage(ctx, approval.SessionID, approval.MessageID)
+		if err != nil || message == nil || message.IsCompleted {
+			continue
+		}
+		message.Content += agentApprovalInterruptedNote
+		message.IsCompleted = true
+		message.UpdatedAt = time.Now()
+		if err := s.messageRepo.UpdateMessage(ctx, message); err != nil {
+			logger.Errorf(ctx, "Failed to complete interrupted message %s: %v", message.ID, err)
+		}
 	}
-	// Return empty - events will be handled by Handler via EventBus subscription
 	return nil
 }
 
@@ -1359,7 +1925,7 @@ func (s *sessionService) handleModelFallback(ctx context.Context, chatManage *ty
 	}
 
 	// Get chat model
-	chatModel, err := s.modelService.GetChatModel(ctx, chatManage.ChatModelID)
+	chatModel, err := s.modelService.GetChatModelWithFallback(ctx, chatManage.ChatModelID, chatManage.ModelFallback)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get chat model for fallback: %v, falling back to fixed response", err)
 		s.handleFixedFallback(ctx, chatManage)
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// sessionUser returns the user of a request authenticated with a login token.
+// It is nil for API key requests, which act on behalf of the whole tenant.
+func sessionUser(ctx context.Context) *types.User {
+	user, _ := ctx.Value(types.UserContextKey).(*types.User)
+	return user
+}
+
+// sessionUserID returns the ID of the user of a request, empty for API key requests
+func sessionUserID(ctx context.Context) string {
+	if user := sessionUser(ctx); user != nil {
+		return user.ID
+	}
+	return ""
+}
+
+// sessionListFilter returns the filter restricting session lists to the current user
+func sessionListFilter(ctx context.Context, shared bool) *types.SessionListFilter {
+	user := sessionUser(ctx)
+	if user == nil {
+		return nil
+	}
+	return &types.SessionListFilter{UserID: user.ID, Shared: shared}
+}
+
+// checkSessionAccess verifies that the current user may read the session, or modify it when write is set.
+// Owners have full access, users the session is shared with can only read it. Sessions without an owner,
+// created with the API key, belong to the tenant and are only open to its admins.
+// Sessions the user cannot see are reported as not found to avoid disclosing them.
+func checkSessionAccess(ctx context.Context,
+	shareRepo interfaces.SessionShareRepository, session *types.Session, write bool,
+) error {
+	user := sessionUser(ctx)
+	if user == nil || session.UserID == user.ID {
+		return nil
+	}
+	if session.UserID == "" {
+		role, _ := ctx.Value(types.TenantRoleContextKey).(types.TenantRole)
+		if role.Includes(types.TenantRoleAdmin) {
+			return nil
+		}
+		logger.Warnf(ctx, "User %s denied access to tenant session %s", user.ID, session.ID)
+		return werrors.ErrSessionNotFound
+	}
+
+	shared, err := shareRepo.IsSharedWith(ctx, session.TenantID, session.ID, user.ID)
+	if err != nil {
+		return err
+	}
+	if !shared {
+		logger.Warnf(ctx, "User %s denied access to session %s", user.ID, session.ID)
+		return werrors.ErrSessionNotFound
+	}
+	if write {
+		return werrors.NewForbiddenError("Session is shared read-only")
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// approvalRepo keeps a single approval, pending until it is resolved
+type approvalRepo struct {
+	interfaces.AgentApprovalRepository
+	approval *types.AgentApproval
+}
+
+func (r *approvalRepo) GetPendingByMessage(ctx context.Context,
+	tenantID uint64, sessionID, messageID string,
+) (*types.AgentApproval, error) {
+	approval := *r.approval
+	return &approval, nil
+}
+
+func (r *approvalRepo) Resolve(ctx context.Context,
+	tenantID uint64, id string, status types.AgentApprovalStatus, reason, resolvedBy string,
+) (bool, error) {
+	if r.approval.Status != types.AgentApprovalStatusPending {
+		return false, nil
+	}
+	r.approval.Status = status
+	r.approval.ResolvedBy = resolvedBy
+	return true, nil
+}
+
+func (r *approvalRepo) UpdateStatus(ctx context.Context,
+	tenantID uint64, id string, from, to types.AgentApprovalStatus,
+) (bool, error) {
+	if r.approval.Status != from {
+		return false, nil
+	}
+	r.approval.Status = to
+	return true, nil
+}
+
+func (r *approvalRepo) ListByStatusBefore(ctx context.Context,
+	status types.AgentApprovalStatus, before time.Time,
+) ([]*types.AgentApproval, error) {
+	if r.approval.Status != status || !r.approval.UpdatedAt.Before(before) {
+		return nil, nil
+	}
+	approval := *r.approval
+	return []*types.AgentApproval{&approval}, nil
+}
+
+func TestResolveAgentApproval(t *testing.T) {
+	tests := []struct {
+		name         string
+		user         string
+		noRequester  bool
+		approved     bool
+		wantCode     int
+		wantStatus   types.AgentApprovalStatus
+		wantResolver string
+	}{
+		{
+			name: "approved by another user", user: "operator", approved: true,
+			wantStatus: types.AgentApprovalStatusResuming, wantResolver: "operator",
+		},
+		{
+			name: "approved by the user who triggered the call", user: "requester", approved: true,
+			wantCode: http.StatusForbidden, wantStatus: types.AgentApprovalStatusPending,
+		},
+		{
+			name: "rejected by the user who triggered the call", user: "requester",
+			wantStatus: types.AgentApprovalStatusRejected, wantResolver: "requester",
+		},
+		{
+			name: "approved with the API key", approved: true,
+			wantCode: http.StatusForbidden, wantStatus: types.AgentApprovalStatusPending,
+		},
+		{
+			name:       "rejected with the API key",
+			wantStatus: types.AgentApprovalStatusRejected,
+		},
+		{
+			name: "approved on a run started with the API key", user: "operator", noRequester: true, approved: true,
+			wantCode: http.StatusForbidden, wantStatus: types.AgentApprovalStatusPending,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requestedBy := "requester"
+			if tt.noRequester {
+				requestedBy = ""
+			}
+			repo := &approvalRepo{approval: &types.AgentApproval{
+				ID: "a1", TenantID: 1, SessionID: "s1", MessageID: "m1",
+				Status: types.AgentApprovalStatusPending, RequestedBy: requestedBy,
+			}}
+			svc := &sessionService{approvalRepo: repo}
+			ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+			if tt.user != "" {
+				ctx = context.WithValue(ctx, types.UserContextKey, &types.User{ID: tt.user, TenantID: 1})
+			}
+
+			_, err := svc.ResolveAgentApproval(ctx, "s1", "m1", tt.approved, "")
+			if tt.wantCode != 0 {
+				appErr, ok := werrors.IsAppError(err)
+				if !ok || appErr.HTTPCode != tt.wantCode {
+					t.Fatalf("ResolveAgentApproval error = %v, want status %d", err, tt.wantCode)
+				}
+			} else if err != nil {
+				t.Fatalf("ResolveAgentApproval: %v", err)
+			}
+			if repo.approval.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", repo.approval.Status, tt.wantStatus)
+			}
+			if repo.approval.ResolvedBy != tt.wantResolver {
+				t.Errorf("resolved by %q, want %q", repo.approval.ResolvedBy, tt.wantResolver)
+			}
+		})
+	}
+}
+
+type failingAgentService struct {
+	interfaces.CustomAgentService
+}
+
+func (s *failingAgentService) GetAgentByID(ctx context.Context, id string) (*types.CustomAgent, error) {
+	return nil, errors.New("agent not found")
+}
+
+func TestResumeAgentQAFailsApproval(t *testing.T) {
+	repo := &approvalRepo{approval: &types.AgentApproval{
+		ID: "a1", TenantID: 1, SessionID: "s1", MessageID: "m1", Status: types.AgentApprovalStatusResuming,
+	}}
+	svc := &sessionService{approvalRepo: repo, customAgentService: &failingAgentService{}}
+
+	approval := *repo.approval
+	if err := svc.ResumeAgentQA(context.Background(), &types.Session{ID: "s1"}, &approval, nil); err == nil {
+		t.Fatal("ResumeAgentQA succeeded without an agent")
+	}
+	if repo.approval.Status != types.AgentApprovalStatusFailed {
+		t.Errorf("status = %s, want %s", repo.approval.Status, types.AgentApprovalStatusFailed)
+	}
+}
+
+type sweepMessageRepo struct {
+	interfaces.MessageRepository
+	message *types.Message
+}
+
+func (r *sweepMessageRepo) GetMessage(ctx context.Context, sessionID, id string) (*types.Message, error) {
+	return r.message, nil
+}
+
+func (r *sweepMessageRepo) UpdateMessage(ctx context.Context, message *types.Message) error {
+	r.message = message
+	return nil
+}
+
+func TestProcessAgentApprovalSweep(t *testing.T) {
+	tests := []struct {
+		name          string
+		updatedAt     time.Time
+		wantStatus    types.AgentApprovalStatus
+		wantCompleted bool
+	}{
+		{"interrupted run", time.Now().Add(-time.Hour), types.AgentApprovalStatusFailed, true},
+		{"running resume", time.Now(), types.AgentApprovalStatusResuming, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &approvalRepo{approval: &types.AgentApproval{
+				ID: "a1", TenantID: 1, SessionID: "s1", MessageID: "m1",
+				Status: types.AgentApprovalStatusResuming, UpdatedAt: tt.updatedAt,
+			}}
+			messages := &sweepMessageRepo{message: &types.Message{ID: "m1", SessionID: "s1", Content: "partial"}}
+			svc := &sessionService{approvalRepo: repo, messageRepo: messages}
+
+			if err := svc.ProcessAgentApprovalSweep(context.Background(), nil); err != nil {
+				t.Fatalf("ProcessAgentApprovalSweep: %v", err)
+			}
+			if repo.approval.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", repo.approval.Status, tt.wantStatus)
+			}
+			if messages.message.IsCompleted != tt.wantCompleted {
+				t.Errorf("message completed = %v, want %v", messages.message.IsCompleted, tt.wantCompleted)
+			}
+		})
+	}
+}
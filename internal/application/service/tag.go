@@ -25,6 +25,7 @@ type knowledgeTagService struct {
 	retrieveEngine interfaces.RetrieveEngineRegistry
 	modelService   interfaces.ModelService
 	task           *asynq.Client
+	responseCache  interfaces.ResponseCacheService
 }
 
 // NewKnowledgeTagService creates a new tag service.
@@ -35,6 +36,7 @@ func NewKnowledgeTagService(
 	retrieveEngine interfaces.RetrieveEngineRegistry,
 	modelService interfaces.ModelService,
 	task *asynq.Client,
+	responseCache interfaces.ResponseCacheService,
 ) (interfaces.KnowledgeTagService, error) {
 	return &knowledgeTagService{
 		kbService:      kbService,
@@ -43,6 +45,7 @@ func NewKnowledgeTagService(
 		retrieveEngine: retrieveEngine,
 		modelService:   modelService,
 		task:           task,
+		responseCache:  responseCache,
 	}, nil
 }
 
@@ -203,6 +206,7 @@ func (s *knowledgeTagService) DeleteTag(ctx context.Context, id string, force bo
 		if len(deletedIDs) > 0 {
 			s.enqueueIndexDeleteTask(ctx, tenantID, kb.ID, kb.EmbeddingModelID, string(kb.Type), deletedIDs, tenantInfo.GetEffectiveEngines())
 		}
+		s.responseCache.InvalidateKnowledgeBases(ctx, kb.ID)
 
 		logger.Infof(ctx, "Deleted %d chunks under tag %s", len(deletedIDs), tag.ID)
 		return nil
@@ -52,7 +52,7 @@ func (s *tenantService) CreateTenant(ctx context.Context, tenant *types.Tenant)
 	logger.Infof(ctx, "Creating tenant, name: %s", tenant.Name)
 
 	// Create tenant with initial values
-	tenant.APIKey = s.generateApiKey(0)
+	tenant.APIKey = types.SecretString(s.generateApiKey(0))
 	tenant.Status = "active"
 	tenant.CreatedAt = time.Now()
 	tenant.UpdatedAt = time.Now()
@@ -66,7 +66,7 @@ func (s *tenantService) CreateTenant(ctx context.Context, tenant *types.Tenant)
 	}
 
 	logger.Infof(ctx, "Tenant created successfully, ID: %d, generating official API Key", tenant.ID)
-	tenant.APIKey = s.generateApiKey(tenant.ID)
+	tenant.APIKey = types.SecretString(s.generateApiKey(tenant.ID))
 	if err := s.repo.UpdateTenant(ctx, tenant); err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"tenant_id":   tenant.ID,
@@ -121,7 +121,7 @@ func (s *tenantService) UpdateTenant(ctx context.Context, tenant *types.Tenant)
 	// Generate new API key if empty
 	if tenant.APIKey == "" {
 		logger.Info(ctx, "API Key is empty, generating new API Key")
-		tenant.APIKey = s.generateApiKey(tenant.ID)
+		tenant.APIKey = types.SecretString(s.generateApiKey(tenant.ID))
 	}
 
 	tenant.UpdatedAt = time.Now()
@@ -194,7 +194,7 @@ func (s *tenantService) UpdateAPIKey(ctx context.Context, id uint64) (string, er
 	}
 
 	logger.Infof(ctx, "Generating new API Key for tenant, ID: %d", id)
-	tenant.APIKey = s.generateApiKey(tenant.ID)
+	tenant.APIKey = types.SecretString(s.generateApiKey(tenant.ID))
 
 	if err := s.repo.UpdateTenant(ctx, tenant); err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
@@ -204,7 +204,7 @@ func (s *tenantService) UpdateAPIKey(ctx context.Context, id uint64) (string, er
 	}
 
 	logger.Infof(ctx, "Tenant API Key updated successfully, ID: %d", id)
-	return tenant.APIKey, nil
+	return string(tenant.APIKey), nil
 }
 
 // generateApiKey generates a secure API key for tenant authentication
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// tenantMemberService implements the TenantMemberService interface
+type tenantMemberService struct {
+	memberRepo interfaces.TenantMemberRepository
+	grantRepo  interfaces.KnowledgeBaseGrantRepository
+	userRepo   interfaces.UserRepository
+	kbRepo     interfaces.KnowledgeBaseRepository
+}
+
+// NewTenantMemberService creates a new tenant member service
+func NewTenantMemberService(
+	memberRepo interfaces.TenantMemberRepository,
+	grantRepo interfaces.KnowledgeBaseGrantRepository,
+	userRepo interfaces.UserRepository,
+	kbRepo interfaces.KnowledgeBaseRepository,
+) interfaces.TenantMemberService {
+	return &tenantMemberService{
+		memberRepo: memberRepo,
+		grantRepo:  grantRepo,
+		userRepo:   userRepo,
+		kbRepo:     kbRepo,
+	}
+}
+
+// requestRole returns the role of the request in its tenant, set by the auth middleware
+func requestRole(ctx context.Context) types.TenantRole {
+	role, _ := ctx.Value(types.TenantRoleContextKey).(types.TenantRole)
+	return role
+}
+
+// GetRole returns the role of a user in a tenant, empty if the user is not a member
+func (s *tenantMemberService) GetRole(ctx context.Context, tenantID uint64, userID string) (types.TenantRole, error) {
+	member, err := s.memberRepo.Get(ctx, tenantID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTenantMemberNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// GetKnowledgeBaseRole returns the role of the current request on a knowledge base
+func (s *tenantMemberService) GetKnowledgeBaseRole(
+	ctx context.Context, knowledgeBaseID string,
+) (types.TenantRole, error) {
+	role := requestRole(ctx)
+	user := sessionUser(ctx)
+	if user == nil || role.Includes(types.TenantRoleAdmin) {
+		return role, nil
+	}
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	grant, err := s.grantRepo.Get(ctx, tenantID, knowledgeBaseID, user.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrKnowledgeBaseGrantNotFound) {
+			return role, nil
+		}
+		return "", err
+	}
+	return types.MaxTenantRole(role, grant.Role), nil
+}
+
+// ListMembers lists the members of the current tenant
+func (s *tenantMemberService) ListMembers(ctx context.Context) ([]*types.TenantMember, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	return s.memberRepo.List(ctx, tenantID)
+}
+
+// AddMember adds a user, identified by ID or email, to the current tenant
+func (s *tenantMemberService) AddMember(ctx context.Context,
+	userID, email string, role types.TenantRole,
+) (*types.TenantMember, error) {
+	if err := checkAssignableRole(ctx, role); err != nil {
+		return nil, err
+	}
+
+	var user *types.User
+	var err error
+	switch {
+	case userID != "":
+		user, err = s.userRepo.GetUserByID(ctx, userID)
+	case email != "":
+		user, err = s.userRepo.GetUserByEmail(ctx, email)
+	default:
+		return nil, werrors.NewBadRequestError("user_id or email is required")
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, werrors.NewNotFoundError("User not found")
+		}
+		return nil, err
+	}
+
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if _, err := s.memberRepo.Get(ctx, tenantID, user.ID); err == nil {
+		return nil, werrors.NewConflictError("User is already a member of the tenant")
+	} else if !errors.Is(err, repository.ErrTenantMemberNotFound) {
+		return nil, err
+	}
+
+	member := &types.TenantMember{TenantID: tenantID, UserID: user.ID, Role: role}
+	if err := s.memberRepo.Create(ctx, member); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"user_id": user.ID})
+		return nil, err
+	}
+	member.User = user
+
+	logger.Infof(ctx, "User %s added to tenant %d as %s", user.ID, tenantID, role)
+	return member, nil
+}
+
+// UpdateMemberRole changes the role of a member of the current tenant
+func (s *tenantMemberService) UpdateMemberRole(ctx context.Context,
+	userID string, role types.TenantRole,
+) (*types.TenantMember, error) {
+	if err := checkAssignableRole(ctx, role); err != nil {
+		return nil, err
+	}
+	member, err := s.getManageableMember(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role == types.TenantRoleOwner && role != types.TenantRoleOwner {
+		if err := s.checkNotLastOwner(ctx, member.TenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.memberRepo.UpdateRole(ctx, member.TenantID, userID, role); err != nil {
+		return nil, err
+	}
+	member.Role = role
+
+	logger.Infof(ctx, "Role of user %s in tenant %d changed to %s", userID, member.TenantID, role)
+	return member, nil
+}
+
+// RemoveMember removes a user and their knowledge base grants from the current tenant
+func (s *tenantMemberService) RemoveMember(ctx context.Context, userID string) error {
+	member, err := s.getManageableMember(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role == types.TenantRoleOwner {
+		if err := s.checkNotLastOwner(ctx, member.TenantID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.grantRepo.DeleteByUser(ctx, member.TenantID, userID); err != nil {
+		return err
+	}
+	if err := s.memberRepo.Delete(ctx, member.TenantID, userID); err != nil {
+		return err
+	}
+
+	logger.Infof(ctx, "User %s removed from tenant %d", userID, member.TenantID)
+	return nil
+}
+
+// ListKnowledgeBaseGrants lists the grants of a knowledge base
+func (s *tenantMemberService) ListKnowledgeBaseGrants(
+	ctx context.Context, knowledgeBaseID string,
+) ([]*types.KnowledgeBaseGrant, error) {
+	kb, err := s.getKnowledgeBase(ctx, knowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	return s.grantRepo.ListByKnowledgeBase(ctx, kb.TenantID, kb.ID)
+}
+
+// GrantKnowledgeBase grants a member a role on a knowledge base, replacing an existing grant.
+// Granting the viewer role has no effect since every member can read the tenant's knowledge bases.
+func (s *tenantMemberService) GrantKnowledgeBase(ctx context.Context,
+	knowledgeBaseID, userID string, role types.TenantRole,
+) (*types.KnowledgeBaseGrant, error) {
+	if role != types.TenantRoleEditor && role != types.TenantRoleAdmin {
+		return nil, werrors.NewBadRequestError("Knowledge base grants must be editor or admin")
+	}
+	kb, err := s.getKnowledgeBase(ctx, knowledgeBaseID)
+	if err != nil {
+		return nil, err
+	}
+	actorRole, err := s.GetKnowledgeBaseRole(ctx, kb.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !actorRole.Includes(role) {
+		return nil, werrors.NewForbiddenError(fmt.Sprintf("Cannot grant the %s role", role))
+	}
+	if _, err := s.memberRepo.Get(ctx, kb.TenantID, userID); err != nil {
+		if errors.Is(err, repository.ErrTenantMemberNotFound) {
+			return nil, werrors.NewBadRequestError("User is not a member of the tenant")
+		}
+		return nil, err
+	}
+
+	grant := &types.KnowledgeBaseGrant{
+		TenantID:        kb.TenantID,
+		KnowledgeBaseID: kb.ID,
+		UserID:          userID,
+		Role:            role,
+	}
+	if user := sessionUser(ctx); user != nil {
+		grant.CreatedBy = user.ID
+	}
+	grant, err = s.grantRepo.Upsert(ctx, grant)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_base_id": knowledgeBaseID,
+			"user_id":           userID,
+		})
+		return nil, err
+	}
+
+	logger.Infof(ctx, "User %s granted %s on knowledge base %s", userID, role, knowledgeBaseID)
+	return grant, nil
+}
+
+// RevokeKnowledgeBaseGrant revokes the grant of a member on a knowledge base
+func (s *tenantMemberService) RevokeKnowledgeBaseGrant(ctx context.Context, knowledgeBaseID, userID string) error {
+	kb, err := s.getKnowledgeBase(ctx, knowledgeBaseID)
+	if err != nil {
+		return err
+	}
+	if err := s.grantRepo.Delete(ctx, kb.TenantID, kb.ID, userID); err != nil {
+		if errors.Is(err, repository.ErrKnowledgeBaseGrantNotFound) {
+			return werrors.NewNotFoundError(err.Error())
+		}
+		return err
+	}
+
+	logger.Infof(ctx, "Grant of user %s on knowledge base %s revoked", userID, knowledgeBaseID)
+	return nil
+}
+
+// checkAssignableRole verifies that the role exists and that the current request may assign it
+func checkAssignableRole(ctx context.Context, role types.TenantRole) error {
+	if !role.IsValid() {
+		return werrors.NewBadRequestError(fmt.Sprintf("Invalid role: %s", role))
+	}
+	if !requestRole(ctx).Includes(role) {
+		return werrors.NewForbiddenError(fmt.Sprintf("Cannot assign the %s role", role))
+	}
+	return nil
+}
+
+// getManageableMember gets a member of the current tenant whose role does not exceed the current request's
+func (s *tenantMemberService) getManageableMember(ctx context.Context, userID string) (*types.TenantMember, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	member, err := s.memberRepo.Get(ctx, tenantID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTenantMemberNotFound) {
+			return nil, werrors.NewNotFoundError(err.Error())
+		}
+		return nil, err
+	}
+	if !requestRole(ctx).Includes(member.Role) {
+		return nil, werrors.NewForbiddenError(fmt.Sprintf("Cannot manage a member with the %s role", member.Role))
+	}
+	return member, nil
+}
+
+// checkNotLastOwner prevents a tenant from losing its last owner
+func (s *tenantMemberService) checkNotLastOwner(ctx context.Context, tenantID uint64) error {
+	owners, err := s.memberRepo.CountByRole(ctx, tenantID, types.TenantRoleOwner)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return werrors.NewBadRequestError("A tenant must keep at least one owner")
+	}
+	return nil
+}
+
+// getKnowledgeBase gets a knowledge base of the current tenant
+func (s *tenantMemberService) getKnowledgeBase(ctx context.Context, id string) (*types.KnowledgeBase, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrKnowledgeBaseNotFound) {
+			return nil, werrors.NewNotFoundError(err.Error())
+		}
+		return nil, err
+	}
+	if kb.TenantID != tenantID {
+		return nil, werrors.NewNotFoundError(repository.ErrKnowledgeBaseNotFound.Error())
+	}
+	return kb, nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// monthUsageTTL is how long the cached monthly usage of a tenant is trusted before it is
+// reloaded, so that usage recorded by other instances is picked up
+const monthUsageTTL = time.Minute
+
+// monthUsage is the cached token usage of a tenant in a month
+type monthUsage struct {
+	month    time.Time
+	used     int64
+	loadedAt time.Time
+}
+
+// tokenUsageService implements the TokenUsageService interface
+type tokenUsageService struct {
+	repo       interfaces.TokenUsageRepository
+	tenantRepo interfaces.TenantRepository
+
+	mu     sync.Mutex
+	months map[uint64]*monthUsage
+}
+
+// NewTokenUsageService creates a new token usage service
+func NewTokenUsageService(
+	repo interfaces.TokenUsageRepository, tenantRepo interfaces.TenantRepository,
+) interfaces.TokenUsageService {
+	return &tokenUsageService{
+		repo:       repo,
+		tenantRepo: tenantRepo,
+		months:     make(map[uint64]*monthUsage),
+	}
+}
+
+// startOfMonth returns the start of the month (UTC) containing t
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// RecordUsage records the tokens of a model call for the tenant and session of the context.
+// Failures are logged and never fail the model call.
+func (s *tokenUsageService) RecordUsage(ctx context.Context, usage *types.TokenUsage) {
+	if usage.TenantID == 0 {
+		usage.TenantID, _ = ctx.Value(types.TenantIDContextKey).(uint64)
+	}
+	if usage.SessionID == "" {
+		usage.SessionID, _ = ctx.Value(types.SessionIDContextKey).(string)
+	}
+	if usage.TenantID == 0 || usage.TotalTokens <= 0 {
+		return
+	}
+	usage.CreatedAt = time.Now()
+
+	// The call may have finished because the request was canceled, the usage still counts
+	if err := s.repo.Create(context.WithoutCancel(ctx), usage); err != nil {
+		logger.Warnf(ctx, "Failed to record token usage of model %s: %v", usage.ModelID, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached, ok := s.months[usage.TenantID]; ok && cached.month.Equal(startOfMonth(usage.CreatedAt)) {
+		cached.used += usage.TotalTokens
+	}
+}
+
+// CheckQuota returns a TokenQuotaExceededError when the tenant of the context
+// has used up its monthly token quota
+func (s *tokenUsageService) CheckQuota(ctx context.Context) error {
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+	if tenantID == 0 {
+		return nil
+	}
+
+	quota, err := s.tokenQuota(ctx, tenantID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get token quota of tenant %d, skipping quota check: %v", tenantID, err)
+		return nil
+	}
+	if quota <= 0 {
+		return nil
+	}
+
+	used, err := s.monthUsed(ctx, tenantID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get token usage of tenant %d, skipping quota check: %v", tenantID, err)
+		return nil
+	}
+	if used >= quota {
+		logger.Warnf(ctx, "Tenant %d exceeded its monthly token quota: used %d of %d", tenantID, used, quota)
+		return types.NewTokenQuotaExceededError()
+	}
+	return nil
+}
+
+// tokenQuota returns the monthly token quota of a tenant
+func (s *tokenUsageService) tokenQuota(ctx context.Context, tenantID uint64) (int64, error) {
+	if tenant, ok := ctx.Value(types.TenantInfoContextKey).(*types.Tenant); ok && tenant != nil && tenant.ID == tenantID {
+		return tenant.TokenQuota, nil
+	}
+	tenant, err := s.tenantRepo.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return 0, err
+	}
+	return tenant.TokenQuota, nil
+}
+
+// monthUsed returns the tokens used by a tenant in the current month
+func (s *tokenUsageService) monthUsed(ctx context.Context, tenantID uint64) (int64, error) {
+	now := time.Now()
+	month := startOfMonth(now)
+
+	s.mu.Lock()
+	cached, ok := s.months[tenantID]
+	if ok && cached.month.Equal(month) && now.Sub(cached.loadedAt) < monthUsageTTL {
+		used := cached.used
+		s.mu.Unlock()
+		return used, nil
+	}
+	s.mu.Unlock()
+
+	used, err := s.repo.SumSince(ctx, tenantID, month)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	s.months[tenantID] = &monthUsage{month: month, used: used, loadedAt: now}
+	s.mu.Unlock()
+	return used, nil
+}
+
+// GetUsageReport reports the token usage of a tenant with daily and per-model breakdowns
+func (s *tokenUsageService) GetUsageReport(
+	ctx context.Context, tenantID uint64, query *types.TokenUsageQuery,
+) (*types.TokenUsageReport, error) {
+	tenant, err := s.tenantRepo.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"tenant_id": tenantID})
+		return nil, err
+	}
+
+	daily, err := s.repo.Daily(ctx, tenantID, query)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"tenant_id": tenantID})
+		return nil, err
+	}
+	byModel, err := s.repo.ByModel(ctx, tenantID, query)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"tenant_id": tenantID})
+		return nil, err
+	}
+	monthUsed, err := s.repo.SumSince(ctx, tenantID, startOfMonth(time.Now()))
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"tenant_id": tenantID})
+		return nil, err
+	}
+
+	report := &types.TokenUsageReport{
+		TenantID:   tenantID,
+		From:       query.From,
+		To:         query.To,
+		TokenQuota: tenant.TokenQuota,
+		MonthUsed:  monthUsed,
+		Daily:      daily,
+		ByModel:    byModel,
+	}
+	for _, day := range daily {
+		report.Total.Calls += day.Calls
+		report.Total.PromptTokens += day.PromptTokens
+		report.Total.CompletionTokens += day.CompletionTokens
+		report.Total.TotalTokens += day.TotalTokens
+	}
+	return report, nil
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/models/rerank"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// estimateTokens estimates the token count of texts (rough approximation: 4 characters ≈ 1 token)
+func estimateTokens(texts ...string) int64 {
+	var chars int64
+	for _, text := range texts {
+		chars += int64(len(text))
+	}
+	if chars == 0 {
+		return 0
+	}
+	return chars/4 + 1
+}
+
+// estimateMessageTokens estimates the prompt tokens of chat messages
+func estimateMessageTokens(messages []chat.Message) int64 {
+	texts := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		texts = append(texts, msg.Role, msg.Content)
+		for _, tc := range msg.ToolCalls {
+			texts = append(texts, tc.Function.Name, tc.Function.Arguments)
+		}
+	}
+	return estimateTokens(texts...)
+}
+
+// meteredChat checks the token quota before every call and records the tokens it used
+type meteredChat struct {
+	model chat.Chat
+	usage interfaces.TokenUsageService
+}
+
+// newMeteredChat wraps a chat model with token accounting
+func newMeteredChat(model chat.Chat, usage interfaces.TokenUsageService) chat.Chat {
+	return &meteredChat{model: model, usage: usage}
+}
+
+// record records a chat call, answeredBy is the model that answered after a fallback
+func (c *meteredChat) record(ctx context.Context, answeredBy string, usage *types.TokenUsage) {
+	usage.ModelID = c.GetModelID()
+	if answeredBy != "" {
+		usage.ModelID = answeredBy
+	}
+	usage.ModelType = types.ModelTypeKnowledgeQA
+	c.usage.RecordUsage(ctx, usage)
+}
+
+// Chat 进行非流式聊天
+func (c *meteredChat) Chat(ctx context.Context,
+	messages []chat.Message, opts *chat.ChatOptions,
+) (*types.ChatResponse, error) {
+	if err := c.usage.CheckQuota(ctx); err != nil {
+		return nil, err
+	}
+	resp, err := c.model.Chat(ctx, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &types.TokenUsage{
+		PromptTokens:     int64(resp.Usage.PromptTokens),
+		CompletionTokens: int64(resp.Usage.CompletionTokens),
+		TotalTokens:      int64(resp.Usage.TotalTokens),
+	}
+	if usage.TotalTokens == 0 {
+		usage.PromptTokens = estimateMessageTokens(messages)
+		usage.CompletionTokens = estimateTokens(resp.Content)
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		usage.Estimated = true
+	}
+	c.record(ctx, resp.ModelID, usage)
+	return resp, nil
+}
+
+// ChatStream 进行流式聊天，流式响应不返回用量，按内容长度估算
+func (c *meteredChat) ChatStream(ctx context.Context,
+	messages []chat.Message, opts *chat.ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	if err := c.usage.CheckQuota(ctx); err != nil {
+		return nil, err
+	}
+	stream, err := c.model.ChatStream(ctx, messages, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	promptTokens := estimateMessageTokens(messages)
+	streamChan := make(chan types.StreamResponse)
+	go func() {
+		defer close(streamChan)
+		var completion []string
+		var answeredBy string
+		for response := range stream {
+			completion = append(completion, response.Content)
+			for _, tc := range response.ToolCalls {
+				completion = append(completion, tc.Function.Name, tc.Function.Arguments)
+			}
+			if response.ModelID != "" {
+				answeredBy = response.ModelID
+			}
+			streamChan <- response
+		}
+		completionTokens := estimateTokens(completion...)
+		c.record(ctx, answeredBy, &types.TokenUsage{
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			TotalTokens:      promptTokens + completionTokens,
+			Estimated:        true,
+		})
+	}()
+	return streamChan, nil
+}
+
+// GetModelName 获取模型名称
+func (c *meteredChat) GetModelName() string {
+	return c.model.GetModelName()
+}
+
+// GetModelID 获取模型ID
+func (c *meteredChat) GetModelID() string {
+	return c.model.GetModelID()
+}
+
+// meteredEmbedder checks the token quota before every call and records the tokens it used
+type meteredEmbedder struct {
+	embedding.Embedder
+	usage interfaces.TokenUsageService
+}
+
+// newMeteredEmbedder wraps an embedding model with token accounting
+func newMeteredEmbedder(model embedding.Embedder, usage interfaces.TokenUsageService) embedding.Embedder {
+	return &meteredEmbedder{Embedder: model, usage: usage}
+}
+
+// record records the estimated tokens of embedded texts
+func (e *meteredEmbedder) record(ctx context.Context, texts ...string) {
+	tokens := estimateTokens(texts...)
+	e.usage.RecordUsage(ctx, &types.TokenUsage{
+		ModelID:      e.GetModelID(),
+		ModelType:    types.ModelTypeEmbedding,
+		PromptTokens: tokens,
+		TotalTokens:  tokens,
+		Estimated:    true,
+	})
+}
+
+// Embed converts text to vector
+func (e *meteredEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := e.usage.CheckQuota(ctx); err != nil {
+		return nil, err
+	}
+	vector, err := e.Embedder.Embed(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	e.record(ctx, text)
+	return vector, nil
+}
+
+// BatchEmbed converts multiple texts to vectors in batch
+func (e *meteredEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := e.usage.CheckQuota(ctx); err != nil {
+		return nil, err
+	}
+	vectors, err := e.Embedder.BatchEmbed(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	e.record(ctx, texts...)
+	return vectors, nil
+}
+
+// meteredReranker checks the token quota before every call and records the tokens it used
+type meteredReranker struct {
+	rerank.Reranker
+	usage interfaces.TokenUsageService
+}
+
+// newMeteredReranker wraps a rerank model with token accounting
+func newMeteredReranker(model rerank.Reranker, usage interfaces.TokenUsageService) rerank.Reranker {
+	return &meteredReranker{Reranker: model, usage: usage}
+}
+
+// Rerank reranks documents based on relevance to the query
+func (r *meteredReranker) Rerank(ctx context.Context, query string, documents []string) ([]rerank.RankResult, error) {
+	if err := r.usage.CheckQuota(ctx); err != nil {
+		return nil, err
+	}
+	results, err := r.Reranker.Rerank(ctx, query, documents)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every document is scored together with the query
+	var tokens int64
+	for _, doc := range documents {
+		tokens += estimateTokens(query, doc)
+	}
+	r.usage.RecordUsage(ctx, &types.TokenUsage{
+		ModelID:      r.GetModelID(),
+		ModelType:    types.ModelTypeRerank,
+		PromptTokens: tokens,
+		TotalTokens:  tokens,
+		Estimated:    true,
+	})
+	return results, nil
+}
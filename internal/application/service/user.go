@@ -49,6 +49,7 @@ type userService struct {
 	userRepo      interfaces.UserRepository
 	tokenRepo     interfaces.AuthTokenRepository
 	tenantService interfaces.TenantService
+	memberRepo    interfaces.TenantMemberRepository
 }
 
 // NewUserService creates a new user service instance
@@ -56,11 +57,13 @@ func NewUserService(
 	userRepo interfaces.UserRepository,
 	tokenRepo interfaces.AuthTokenRepository,
 	tenantService interfaces.TenantService,
+	memberRepo interfaces.TenantMemberRepository,
 ) interfaces.UserService {
 	return &userService{
 		userRepo:      userRepo,
 		tokenRepo:     tokenRepo,
 		tenantService: tenantService,
+		memberRepo:    memberRepo,
 	}
 }
 
@@ -123,6 +126,13 @@ func (s *userService) Register(ctx context.Context, req *types.RegisterRequest)
 		return nil, errors.New("failed to create user")
 	}
 
+	// The user owns the workspace created for them
+	member := &types.TenantMember{TenantID: createdTenant.ID, UserID: user.ID, Role: types.TenantRoleOwner}
+	if err := s.memberRepo.Create(ctx, member); err != nil {
+		logger.Errorf(ctx, "Failed to add user to workspace: %v", err)
+		return nil, errors.New("failed to create workspace")
+	}
+
 	logger.Info(ctx, "User registered successfully")
 	return user, nil
 }
@@ -192,7 +202,7 @@ func (s *userService) Login(ctx context.Context, req *types.LoginRequest) (*type
 		Success:      true,
 		Message:      "Login successful",
 		User:         user,
-		Tenant:       tenant,
+		Tenant:       tenant.Redacted(),
 		Token:        accessToken,
 		RefreshToken: refreshToken,
 	}, nil
@@ -419,7 +429,7 @@ func (s *userService) RevokeToken(ctx context.Context, tokenString string) error
 
 // GetCurrentUser gets current user from context
 func (s *userService) GetCurrentUser(ctx context.Context) (*types.User, error) {
-	user, ok := ctx.Value("user").(*types.User)
+	user, ok := ctx.Value(types.UserContextKey).(*types.User)
 	if !ok {
 		return nil, errors.New("user not found in context")
 	}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type loginUserRepo struct {
+	interfaces.UserRepository
+	user *types.User
+}
+
+func (r *loginUserRepo) GetUserByEmail(ctx context.Context, email string) (*types.User, error) {
+	return r.user, nil
+}
+
+type loginTokenRepo struct {
+	interfaces.AuthTokenRepository
+}
+
+func (r *loginTokenRepo) CreateToken(ctx context.Context, token *types.AuthToken) error {
+	return nil
+}
+
+type loginTenantService struct {
+	interfaces.TenantService
+	tenant *types.Tenant
+}
+
+func (s *loginTenantService) GetTenantByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	return s.tenant, nil
+}
+
+func TestLoginRedactsTenantSecrets(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenant := &types.Tenant{
+		ID:              1,
+		APIKey:          "sk-tenant-0123456789abcdef",
+		WebSearchConfig: &types.WebSearchConfig{APIKey: "ws-key-0123456789abcdef"},
+	}
+	svc := NewUserService(
+		&loginUserRepo{user: &types.User{ID: "u1", Email: "viewer@example.com", PasswordHash: string(hash),
+			TenantID: 1, IsActive: true}},
+		&loginTokenRepo{},
+		&loginTenantService{tenant: tenant},
+		nil,
+	)
+
+	resp, err := svc.Login(context.Background(), &types.LoginRequest{Email: "viewer@example.com", Password: "password"})
+	if err != nil || !resp.Success {
+		t.Fatalf("Login = %+v, %v", resp, err)
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{string(tenant.APIKey), tenant.WebSearchConfig.APIKey} {
+		if strings.Contains(string(body), secret) {
+			t.Errorf("login response contains secret %q", secret)
+		}
+	}
+	if tenant.APIKey != "sk-tenant-0123456789abcdef" {
+		t.Errorf("stored tenant was modified: %q", tenant.APIKey)
+	}
+}
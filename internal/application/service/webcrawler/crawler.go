@@ -0,0 +1,432 @@
+// Package webcrawler discovers the pages of a website from a seed URL or a sitemap, following links
+// breadth first within the configured depth, page count, host and glob limits while respecting robots.txt.
+package webcrawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// Crawl limits
+const (
+	DefaultMaxDepth = 2
+	MaxDepthLimit   = 10
+	DefaultMaxPages = 100
+	MaxPagesLimit   = 1000
+)
+
+const (
+	// UserAgent is sent with every request of the crawler
+	UserAgent = "WeKnoraCrawler/1.0 (+https://github.com/Tencent/WeKnora)"
+	// robotsAgentToken is the token matched against the User-agent lines of robots.txt
+	robotsAgentToken = "WeKnoraCrawler"
+
+	fetchTimeout     = 15 * time.Second
+	maxBodySize      = 5 << 20
+	maxRedirects     = 5
+	maxSitemaps      = 50
+	defaultHostDelay = 200 * time.Millisecond
+	maxCrawlDelay    = 10 * time.Second
+)
+
+// Options configures a crawl. Exactly one of SeedURL and SitemapURL is set.
+type Options struct {
+	// SeedURL is the page the crawl starts from
+	SeedURL string
+	// SitemapURL is a sitemap.xml or sitemap index whose pages start the crawl
+	SitemapURL string
+	// MaxDepth is how many links away from the start pages the crawl goes, 0 only visits the start pages
+	MaxDepth int
+	// MaxPages is the maximum number of pages reported to the visitor
+	MaxPages int
+	// SameHost restricts the crawl to the host of the seed URL or sitemap
+	SameHost bool
+	// Include limits the pages to those matching one of the globs, all pages when empty
+	Include []string
+	// Exclude drops the pages matching one of the globs
+	Exclude []string
+}
+
+// Page is a crawled HTML page
+type Page struct {
+	URL   string
+	Depth int
+}
+
+// Stats counts the work of a crawl
+type Stats struct {
+	// Discovered is the number of distinct in-scope URLs found so far
+	Discovered int `json:"discovered"`
+	// Pages is the number of pages reported to the visitor
+	Pages int `json:"pages"`
+	// Skipped is the number of URLs dropped by robots.txt, fetch errors or non-HTML content
+	Skipped int `json:"skipped"`
+}
+
+// Crawler crawls a website. It is not safe for concurrent use.
+type Crawler struct {
+	opts     Options
+	start    *url.URL
+	include  []*glob
+	exclude  []*glob
+	client   *http.Client
+	allowIP  func(ip net.IP) bool
+	robots   map[string]*robotsRules
+	lastHit  map[string]time.Time
+	visited  map[string]bool
+	stats    Stats
+	sleepFor func(ctx context.Context, d time.Duration) error
+}
+
+// New validates the options and creates a crawler
+func New(opts Options) (*Crawler, error) {
+	opts.SeedURL = strings.TrimSpace(opts.SeedURL)
+	opts.SitemapURL = strings.TrimSpace(opts.SitemapURL)
+	if (opts.SeedURL == "") == (opts.SitemapURL == "") {
+		return nil, errors.New("exactly one of the seed URL and the sitemap URL is required")
+	}
+	startURL := opts.SeedURL
+	if startURL == "" {
+		startURL = opts.SitemapURL
+	}
+	if !secutils.IsValidURL(startURL) {
+		return nil, fmt.Errorf("invalid URL: %s", startURL)
+	}
+	start, err := url.Parse(startURL)
+	if err != nil || start.Host == "" {
+		return nil, fmt.Errorf("invalid URL: %s", startURL)
+	}
+
+	if opts.MaxDepth < 0 || opts.MaxDepth > MaxDepthLimit {
+		return nil, fmt.Errorf("max depth must be between 0 and %d", MaxDepthLimit)
+	}
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = DefaultMaxPages
+	}
+	if opts.MaxPages > MaxPagesLimit {
+		return nil, fmt.Errorf("max pages must not exceed %d", MaxPagesLimit)
+	}
+
+	include, err := compileGlobs(opts.Include)
+	if err != nil {
+		return nil, fmt.Errorf("include: %w", err)
+	}
+	exclude, err := compileGlobs(opts.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("exclude: %w", err)
+	}
+
+	c := &Crawler{
+		opts:     opts,
+		start:    normalizeURL(start),
+		include:  include,
+		exclude:  exclude,
+		allowIP:  isPublicIP,
+		robots:   make(map[string]*robotsRules),
+		lastHit:  make(map[string]time.Time),
+		visited:  make(map[string]bool),
+		sleepFor: sleepContext,
+	}
+	c.client = c.newHTTPClient()
+	return c, nil
+}
+
+// Options returns the options of the crawl with the defaults applied
+func (c *Crawler) Options() Options {
+	return c.opts
+}
+
+// Stats returns the counters of the crawl so far
+func (c *Crawler) Stats() Stats {
+	return c.stats
+}
+
+// Run crawls breadth first and calls visit for every in-scope HTML page until MaxPages pages were
+// visited or no URL is left. An error from visit stops the crawl and is returned.
+func (c *Crawler) Run(ctx context.Context, visit func(ctx context.Context, page Page) error) error {
+	var queue []Page
+	if c.opts.SitemapURL != "" {
+		for _, loc := range c.sitemapPages(ctx) {
+			u, err := url.Parse(loc)
+			if err != nil {
+				continue
+			}
+			u = normalizeURL(u)
+			if c.inScope(u) && c.markVisited(u) {
+				queue = append(queue, Page{URL: u.String(), Depth: 0})
+			}
+		}
+	} else {
+		c.markVisited(c.start)
+		queue = append(queue, Page{URL: c.start.String(), Depth: 0})
+	}
+
+	for len(queue) > 0 && c.stats.Pages < c.opts.MaxPages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page := queue[0]
+		queue = queue[1:]
+
+		u, _ := url.Parse(page.URL)
+		if !c.robotsAllowed(ctx, u) {
+			c.stats.Skipped++
+			continue
+		}
+
+		final, doc, err := c.fetchPage(ctx, u)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.stats.Skipped++
+			continue
+		}
+		// The seed is always fetched for its links, but only visited when it is in scope
+		isSeed := c.opts.SeedURL != "" && page.Depth == 0
+		inScope := c.inScope(final)
+		// A redirect may leave the scope or land on a page that was already visited
+		if final.String() != u.String() && ((!inScope && !isSeed) || !c.markVisited(final)) {
+			c.stats.Skipped++
+			continue
+		}
+
+		if inScope {
+			if err := visit(ctx, Page{URL: final.String(), Depth: page.Depth}); err != nil {
+				return err
+			}
+			c.stats.Pages++
+		}
+
+		if page.Depth >= c.opts.MaxDepth {
+			continue
+		}
+		for _, link := range extractLinks(doc, final) {
+			if c.inScope(link) && c.markVisited(link) {
+				queue = append(queue, Page{URL: link.String(), Depth: page.Depth + 1})
+			}
+		}
+	}
+	return nil
+}
+
+// markVisited records a URL as discovered, returning false when it already was
+func (c *Crawler) markVisited(u *url.URL) bool {
+	key := u.String()
+	if c.visited[key] {
+		return false
+	}
+	c.visited[key] = true
+	c.stats.Discovered++
+	return true
+}
+
+// inScope reports whether a URL passes the host and glob filters
+func (c *Crawler) inScope(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	if c.opts.SameHost && !strings.EqualFold(u.Host, c.start.Host) {
+		return false
+	}
+	if len(c.include) > 0 && !matchAny(c.include, u) {
+		return false
+	}
+	return !matchAny(c.exclude, u)
+}
+
+// robotsAllowed checks the robots.txt of the host, which is fetched once per host. A missing
+// robots.txt allows everything, while an unreachable one disallows the host.
+func (c *Crawler) robotsAllowed(ctx context.Context, u *url.URL) bool {
+	host := u.Scheme + "://" + u.Host
+	rules, ok := c.robots[host]
+	if !ok {
+		rules = c.fetchRobots(ctx, host)
+		c.robots[host] = rules
+	}
+	if rules == nil {
+		return false
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return rules.allowed(path)
+}
+
+// fetchRobots fetches and parses robots.txt, nil means the host must not be crawled
+func (c *Crawler) fetchRobots(ctx context.Context, host string) *robotsRules {
+	resp, err := c.get(ctx, host+"/robots.txt")
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return parseRobots(io.LimitReader(resp.Body, maxBodySize), robotsAgentToken)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return &robotsRules{}
+	default:
+		return nil
+	}
+}
+
+// sitemapPages collects the page URLs of the sitemap, following nested sitemaps of a sitemap index
+func (c *Crawler) sitemapPages(ctx context.Context) []string {
+	var pages []string
+	pending := []string{c.start.String()}
+	seen := map[string]bool{}
+	for len(pending) > 0 && len(seen) < maxSitemaps {
+		sitemapURL := pending[0]
+		pending = pending[1:]
+		if seen[sitemapURL] || !secutils.IsValidURL(sitemapURL) {
+			continue
+		}
+		seen[sitemapURL] = true
+
+		resp, err := c.get(ctx, sitemapURL)
+		if err != nil {
+			c.stats.Skipped++
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			c.stats.Skipped++
+			continue
+		}
+		locs, nested, err := parseSitemap(io.LimitReader(resp.Body, maxBodySize))
+		resp.Body.Close()
+		if err != nil {
+			c.stats.Skipped++
+			continue
+		}
+		pages = append(pages, locs...)
+		pending = append(pending, nested...)
+	}
+	return pages
+}
+
+// fetchPage fetches an HTML page and returns its URL after redirects
+func (c *Crawler) fetchPage(ctx context.Context, u *url.URL) (*url.URL, *goquery.Document, error) {
+	resp, err := c.get(ctx, u.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	if !strings.Contains(contentType, "text/html") && !strings.Contains(contentType, "application/xhtml") {
+		return nil, nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, nil, err
+	}
+	return normalizeURL(resp.Request.URL), doc, nil
+}
+
+// get sends a GET request, waiting for the crawl delay of the host first
+func (c *Crawler) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	if err := c.waitHost(ctx, req.URL); err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
+
+// waitHost keeps the requests to a host apart by its robots.txt crawl delay
+func (c *Crawler) waitHost(ctx context.Context, u *url.URL) error {
+	host := u.Scheme + "://" + u.Host
+	delay := defaultHostDelay
+	if rules := c.robots[host]; rules != nil && rules.crawlDelay > delay {
+		delay = min(rules.crawlDelay, maxCrawlDelay)
+	}
+	if last, ok := c.lastHit[host]; ok {
+		if wait := delay - time.Since(last); wait > 0 {
+			if err := c.sleepFor(ctx, wait); err != nil {
+				return err
+			}
+		}
+	}
+	c.lastHit[host] = time.Now()
+	return nil
+}
+
+// extractLinks returns the normalized absolute http(s) links of a page
+func extractLinks(doc *goquery.Document, base *url.URL) []*url.URL {
+	var links []*url.URL
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		href = strings.TrimSpace(href)
+		if href == "" || strings.HasPrefix(href, "#") {
+			return
+		}
+		if rel, _ := s.Attr("rel"); strings.Contains(strings.ToLower(rel), "nofollow") {
+			return
+		}
+		ref, err := url.Parse(href)
+		if err != nil {
+			return
+		}
+		link := normalizeURL(base.ResolveReference(ref))
+		if !secutils.IsValidURL(link.String()) {
+			return
+		}
+		links = append(links, link)
+	})
+	return links
+}
+
+// normalizeURL drops the fragment and default port and lowercases the scheme and host,
+// so the same page is visited once
+func normalizeURL(u *url.URL) *url.URL {
+	n := *u
+	n.Fragment = ""
+	n.RawFragment = ""
+	n.Scheme = strings.ToLower(n.Scheme)
+	host := strings.ToLower(n.Host)
+	if (n.Scheme == "http" && strings.HasSuffix(host, ":80")) ||
+		(n.Scheme == "https" && strings.HasSuffix(host, ":443")) {
+		host = host[:strings.LastIndex(host, ":")]
+	}
+	n.Host = host
+	if n.Path == "" {
+		n.Path = "/"
+		n.RawPath = ""
+	}
+	return &n
+}
+
+// sleepContext sleeps for the duration unless the context is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,296 @@
+package webcrawler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestSite serves a small website: / links to /docs/a, /docs/b and /blog/post,
+// /docs/a links to /docs/a/deep and /private/secret, which robots.txt disallows
+func newTestSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	pages := map[string]string{
+		"/":               `<a href="/docs/a">a</a><a href="docs/b#section">b</a><a href="/blog/post">blog</a>`,
+		"/docs/a":         `<a href="/docs/a/deep">deep</a><a href="/private/secret">secret</a>`,
+		"/docs/b":         `<a href="/">home</a><a href="https://other.example.com/">other</a>`,
+		"/docs/a/deep":    `deep`,
+		"/blog/post":      `post`,
+		"/private/secret": `secret`,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n")
+	})
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		base := "http://" + r.Host
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>%s/docs/b</loc></url>
+  <url><loc>%s/blog/post</loc></url>
+</urlset>`, base, base)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<html><body>%s</body></html>", body)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// crawlPaths runs a crawl and returns the paths of the visited pages
+func crawlPaths(t *testing.T, opts Options) ([]string, Stats) {
+	t.Helper()
+	crawler, err := New(opts)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	crawler.sleepFor = func(ctx context.Context, d time.Duration) error { return nil }
+	crawler.allowIP = func(ip net.IP) bool { return true }
+
+	var paths []string
+	err = crawler.Run(context.Background(), func(ctx context.Context, page Page) error {
+		u, _ := url.Parse(page.URL)
+		paths = append(paths, u.Path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return paths, crawler.Stats()
+}
+
+func TestCrawler_Run(t *testing.T) {
+	server := newTestSite(t)
+
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{
+			name: "depth and robots",
+			opts: Options{SeedURL: server.URL, MaxDepth: 2, SameHost: true},
+			want: []string{"/", "/docs/a", "/docs/b", "/blog/post", "/docs/a/deep"},
+		},
+		{
+			name: "depth zero",
+			opts: Options{SeedURL: server.URL, MaxDepth: 0, SameHost: true},
+			want: []string{"/"},
+		},
+		{
+			name: "max pages",
+			opts: Options{SeedURL: server.URL, MaxDepth: 2, MaxPages: 2, SameHost: true},
+			want: []string{"/", "/docs/a"},
+		},
+		{
+			name: "include and exclude",
+			opts: Options{
+				SeedURL: server.URL, MaxDepth: 2, SameHost: true,
+				Include: []string{"/docs/**"}, Exclude: []string{"/docs/a/*"},
+			},
+			want: []string{"/docs/a", "/docs/b"},
+		},
+		{
+			name: "sitemap",
+			opts: Options{SitemapURL: server.URL + "/sitemap.xml", SameHost: true},
+			want: []string{"/docs/b", "/blog/post"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := crawlPaths(t, tt.opts)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("visited %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrawler_VisitErrorStopsCrawl(t *testing.T) {
+	server := newTestSite(t)
+	crawler, err := New(Options{SeedURL: server.URL, MaxDepth: 2, SameHost: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	crawler.sleepFor = func(ctx context.Context, d time.Duration) error { return nil }
+	crawler.allowIP = func(ip net.IP) bool { return true }
+
+	stop := fmt.Errorf("quota exceeded")
+	err = crawler.Run(context.Background(), func(ctx context.Context, page Page) error {
+		return stop
+	})
+	if err != stop {
+		t.Fatalf("Run error = %v, want %v", err, stop)
+	}
+}
+
+func TestCrawler_RejectsNonPublicAddresses(t *testing.T) {
+	server := newTestSite(t)
+	crawler, err := New(Options{SeedURL: server.URL, MaxDepth: 2, SameHost: true})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	crawler.sleepFor = func(ctx context.Context, d time.Duration) error { return nil }
+
+	var visited []string
+	err = crawler.Run(context.Background(), func(ctx context.Context, page Page) error {
+		visited = append(visited, page.URL)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(visited) != 0 {
+		t.Errorf("visited %v on a loopback address, want none", visited)
+	}
+}
+
+func TestCrawler_RejectsRedirectToNonPublicAddress(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://[::1]/admin", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	crawler, err := New(Options{SeedURL: server.URL})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	// Treat the IPv4 loopback of the test server as public, the redirect target stays internal
+	crawler.allowIP = func(ip net.IP) bool { return ip.Equal(net.IPv4(127, 0, 0, 1)) }
+
+	resp, err := crawler.client.Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("expected the redirect to be rejected")
+	}
+	if !strings.Contains(err.Error(), "non-public address") {
+		t.Errorf("error = %v, want a non-public address error", err)
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:10.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"no start", Options{}},
+		{"seed and sitemap", Options{SeedURL: "https://a.com", SitemapURL: "https://a.com/sitemap.xml"}},
+		{"unsupported scheme", Options{SeedURL: "ftp://a.com"}},
+		{"depth too large", Options{SeedURL: "https://a.com", MaxDepth: MaxDepthLimit + 1}},
+		{"too many pages", Options{SeedURL: "https://a.com", MaxPages: MaxPagesLimit + 1}},
+		{"empty glob", Options{SeedURL: "https://a.com", Include: []string{" "}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestRobotsRules(t *testing.T) {
+	robots := `
+# comment
+User-agent: Googlebot
+Disallow: /
+
+User-agent: *
+Disallow: /private/
+Allow: /private/public
+Disallow: /*.pdf$
+Crawl-delay: 2
+`
+	rules := parseRobots(strings.NewReader(robots), robotsAgentToken)
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/docs/page", true},
+		{"/private/x", false},
+		{"/private/public/x", true},
+		{"/files/a.pdf", false},
+		{"/files/a.pdf?x=1", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.path); got != tt.want {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if rules.crawlDelay != 2*time.Second {
+		t.Errorf("crawl delay = %v, want 2s", rules.crawlDelay)
+	}
+
+	specific := parseRobots(strings.NewReader("User-agent: weknoracrawler\nDisallow: /\n"), robotsAgentToken)
+	if specific.allowed("/docs") {
+		t.Error("group of the crawler should apply")
+	}
+}
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		url     string
+		want    bool
+	}{
+		{"/docs/*", "https://a.com/docs/intro", true},
+		{"/docs/*", "https://a.com/docs/guide/intro", false},
+		{"/docs/**", "https://a.com/docs/guide/intro", true},
+		{"/docs/**/intro", "https://a.com/docs/intro", true},
+		{"/v?/api", "https://a.com/v2/api", true},
+		{"https://a.com/blog/**", "https://a.com/blog/2024/post", true},
+		{"https://a.com/blog/**", "https://b.com/blog/2024/post", false},
+	}
+	for _, tt := range tests {
+		g, err := compileGlob(tt.pattern)
+		if err != nil {
+			t.Fatalf("compileGlob(%q): %v", tt.pattern, err)
+		}
+		u, _ := url.Parse(tt.url)
+		if got := g.match(u); got != tt.want {
+			t.Errorf("%q match %q = %v, want %v", tt.pattern, tt.url, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package webcrawler
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// glob is a compiled include or exclude pattern
+type glob struct {
+	pattern string
+	// fullURL is set when the pattern starts with a scheme and is matched against the whole URL,
+	// otherwise it is matched against the path
+	fullURL bool
+	re      *regexp.Regexp
+}
+
+// compileGlob compiles a glob pattern with utils.CompileGlob, "**" matches any characters
+// and "**/" any number of directories
+func compileGlob(pattern string) (*glob, error) {
+	pattern = strings.TrimSpace(pattern)
+	re, err := secutils.CompileGlob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	lower := strings.ToLower(pattern)
+	return &glob{
+		pattern: pattern,
+		fullURL: strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"),
+		re:      re,
+	}, nil
+}
+
+// compileGlobs compiles a list of glob patterns
+func compileGlobs(patterns []string) ([]*glob, error) {
+	globs := make([]*glob, 0, len(patterns))
+	for _, pattern := range patterns {
+		g, err := compileGlob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		globs = append(globs, g)
+	}
+	return globs, nil
+}
+
+// match reports whether the URL matches the pattern
+func (g *glob) match(u *url.URL) bool {
+	if g.fullURL {
+		return g.re.MatchString(u.String())
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return g.re.MatchString(path)
+}
+
+// matchAny reports whether the URL matches one of the patterns
+func matchAny(globs []*glob, u *url.URL) bool {
+	for _, g := range globs {
+		if g.match(u) {
+			return true
+		}
+	}
+	return false
+}
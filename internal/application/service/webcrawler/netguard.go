@@ -0,0 +1,87 @@
+package webcrawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// isPublicIP reports whether the crawler may connect to ip, rejecting private, loopback,
+// link-local and unspecified addresses so a crawl cannot reach the internal network
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsInterfaceLocalMulticast() && !ip.IsUnspecified()
+}
+
+// resolvePublic resolves host and fails unless every address it resolves to is allowed
+func (c *Crawler) resolvePublic(ctx context.Context, host string) ([]net.IP, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no address for host %s", host)
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		if !c.allowIP(addr.IP) {
+			return nil, fmt.Errorf("host %s resolves to non-public address %s", host, addr.IP)
+		}
+		ips = append(ips, addr.IP)
+	}
+	return ips, nil
+}
+
+// dialContext connects to one of the checked addresses of the host rather than resolving it again,
+// so the host cannot switch to an internal address between the check and the connection
+func (c *Crawler) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := c.resolvePublic(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: fetchTimeout}
+	var dialErr error
+	for _, ip := range ips {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		dialErr = errors.Join(dialErr, err)
+	}
+	return nil, dialErr
+}
+
+// checkRedirect limits the redirects and applies the URL and address checks to their targets
+func (c *Crawler) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("too many redirects")
+	}
+	if !secutils.IsValidURL(req.URL.String()) {
+		return fmt.Errorf("redirect to invalid URL: %s", req.URL)
+	}
+	if _, err := c.resolvePublic(req.Context(), req.URL.Hostname()); err != nil {
+		return fmt.Errorf("redirect to %s: %w", req.URL, err)
+	}
+	return nil
+}
+
+// newHTTPClient creates the client of the crawler. It connects directly, without the environment
+// proxy, since addresses behind a proxy cannot be checked.
+func (c *Crawler) newHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = c.dialContext
+	return &http.Client{
+		Timeout:       fetchTimeout,
+		Transport:     transport,
+		CheckRedirect: c.checkRedirect,
+	}
+}
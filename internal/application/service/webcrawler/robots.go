@@ -0,0 +1,151 @@
+package webcrawler
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsRule is an Allow or Disallow line of robots.txt
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsRules holds the robots.txt rules that apply to the crawler on a host
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// robotsGroup is a group of robots.txt lines sharing the same user agents
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+// parseRobots parses robots.txt and keeps the group matching the user agent token, falling back
+// to the "*" group. A missing or empty robots.txt allows everything.
+func parseRobots(r io.Reader, agent string) *robotsRules {
+	agent = strings.ToLower(agent)
+
+	var groups []*robotsGroup
+	var current *robotsGroup
+	// A run of User-agent lines opens a new group, a rule line closes the run
+	inAgents := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			// An empty Disallow allows everything and adds no rule
+			if value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+		case "crawl-delay":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+
+	var matched, wildcard *robotsGroup
+	for _, group := range groups {
+		for _, ua := range group.agents {
+			if ua == "*" {
+				if wildcard == nil {
+					wildcard = group
+				}
+			} else if ua != "" && strings.Contains(agent, ua) && matched == nil {
+				matched = group
+			}
+		}
+	}
+	if matched == nil {
+		matched = wildcard
+	}
+	if matched == nil {
+		return &robotsRules{}
+	}
+	return &robotsRules{rules: matched.rules, crawlDelay: matched.crawlDelay}
+}
+
+// allowed reports whether the path (with query) may be crawled. The longest matching rule wins,
+// and Allow wins over Disallow on a tie.
+func (r *robotsRules) allowed(path string) bool {
+	if r == nil {
+		return true
+	}
+	bestLen := -1
+	allow := true
+	for _, rule := range r.rules {
+		if !robotsPatternMatch(rule.pattern, path) {
+			continue
+		}
+		if len(rule.pattern) > bestLen || (len(rule.pattern) == bestLen && rule.allow) {
+			bestLen = len(rule.pattern)
+			allow = rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsPatternMatch matches a robots.txt path pattern, where "*" matches any characters and a
+// trailing "$" anchors the end of the path
+func robotsPatternMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i := 1; i < len(parts); i++ {
+		part := parts[i]
+		if i == len(parts)-1 && anchored {
+			return strings.HasSuffix(path[pos:], part)
+		}
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+	if anchored && len(parts) == 1 {
+		return pos == len(path)
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package webcrawler
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// sitemapDocument covers both a sitemap (urlset) and a sitemap index (sitemapindex)
+type sitemapDocument struct {
+	XMLName  xml.Name     `xml:""`
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+// sitemapLoc is a <url> or <sitemap> entry
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// parseSitemap returns the page URLs of a sitemap and the nested sitemap URLs of a sitemap index
+func parseSitemap(r io.Reader) (pages []string, sitemaps []string, err error) {
+	var doc sitemapDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range doc.URLs {
+		if loc := strings.TrimSpace(entry.Loc); loc != "" {
+			pages = append(pages, loc)
+		}
+	}
+	for _, entry := range doc.Sitemaps {
+		if loc := strings.TrimSpace(entry.Loc); loc != "" {
+			sitemaps = append(sitemaps, loc)
+		}
+	}
+	return pages, sitemaps, nil
+}
@@ -27,6 +27,8 @@ type Config struct {
 	ExtractManager  *ExtractManagerConfig  `yaml:"extract"          json:"extract"`
 	WebSearch       *WebSearchConfig       `yaml:"web_search"       json:"web_search"`
 	PromptTemplates *PromptTemplatesConfig `yaml:"prompt_templates" json:"prompt_templates"`
+	ResponseCache   *ResponseCacheConfig   `yaml:"response_cache"   json:"response_cache"`
+	Security        *SecurityConfig        `yaml:"security"         json:"-"`
 }
 
 type DocReaderConfig struct {
@@ -62,6 +64,8 @@ type ConversationConfig struct {
 	ExtractRelationshipsPrompt string         `yaml:"extract_relationships_prompt"  json:"extract_relationships_prompt"`
 	// GenerateQuestionsPrompt is used to generate questions for document chunks to improve recall
 	GenerateQuestionsPrompt string `yaml:"generate_questions_prompt" json:"generate_questions_prompt"`
+	// HyDEPrompt is used to generate a hypothetical answer passage for retrieval, {{query}} is replaced
+	HyDEPrompt string `yaml:"hyde_prompt" json:"hyde_prompt"`
 }
 
 // SummaryConfig 摘要配置
@@ -95,6 +99,8 @@ type KnowledgeBaseConfig struct {
 	SplitMarkers    []string               `yaml:"split_markers"    json:"split_markers"`
 	KeepSeparator   bool                   `yaml:"keep_separator"   json:"keep_separator"`
 	ImageProcessing *ImageProcessingConfig `yaml:"image_processing" json:"image_processing"`
+	URLRefresh      *URLRefreshConfig      `yaml:"url_refresh"      json:"url_refresh"`
+	GitSource       *GitSourceConfig       `yaml:"git_source"       json:"git_source"`
 }
 
 // ImageProcessingConfig 图像处理配置
@@ -102,6 +108,20 @@ type ImageProcessingConfig struct {
 	EnableMultimodal bool `yaml:"enable_multimodal" json:"enable_multimodal"`
 }
 
+// URLRefreshConfig URL知识定时刷新配置
+type URLRefreshConfig struct {
+	ScanInterval time.Duration `yaml:"scan_interval" json:"scan_interval"` // 扫描到期URL知识的间隔
+	BatchSize    int           `yaml:"batch_size"    json:"batch_size"`    // 每次扫描最多触发的刷新数
+	MinInterval  time.Duration `yaml:"min_interval"  json:"min_interval"`  // 允许设置的最小刷新间隔
+}
+
+// GitSourceConfig Git仓库同步配置
+type GitSourceConfig struct {
+	AllowLocal  bool          `yaml:"allow_local"   json:"allow_local"`   // 是否允许本地路径和 file:// 仓库
+	MaxFileSize int64         `yaml:"max_file_size" json:"max_file_size"` // 同步的单个文件大小上限（字节）
+	SyncTimeout time.Duration `yaml:"sync_timeout"  json:"sync_timeout"`  // 单次同步的超时时间
+}
+
 // TenantConfig 租户配置
 type TenantConfig struct {
 	DefaultSessionName        string `yaml:"default_session_name"        json:"default_session_name"`
@@ -154,6 +174,24 @@ type RedisConfig struct {
 	TTL      time.Duration `yaml:"ttl"      json:"ttl"`      // 过期时间(小时)
 }
 
+// ResponseCacheConfig 语义响应缓存配置
+type ResponseCacheConfig struct {
+	Enabled             bool          `yaml:"enabled"              json:"enabled"`              // 是否启用
+	Type                string        `yaml:"type"                 json:"type"`                 // 类型: "memory" 或 "redis"
+	SimilarityThreshold float64       `yaml:"similarity_threshold" json:"similarity_threshold"` // 命中所需的最小余弦相似度
+	TTL                 time.Duration `yaml:"ttl"                  json:"ttl"`                  // 缓存过期时间
+	MaxEntries          int           `yaml:"max_entries"          json:"max_entries"`          // 每个作用域保留的最大条目数
+	Prefix              string        `yaml:"prefix"               json:"prefix"`               // Redis 键前缀
+}
+
+// SecurityConfig 安全配置
+type SecurityConfig struct {
+	// MasterKey 加密数据库中敏感配置（API Key、密钥等）的主密钥，为空时使用 TENANT_AES_KEY
+	MasterKey string `yaml:"master_key"`
+	// PreviousMasterKeys 轮换前使用的旧主密钥，逗号分隔，仅用于解密
+	PreviousMasterKeys string `yaml:"previous_master_keys"`
+}
+
 // ExtractManagerConfig 抽取管理器配置
 type ExtractManagerConfig struct {
 	ExtractGraph  *types.PromptTemplateStructured `yaml:"extract_graph"  json:"extract_graph"`
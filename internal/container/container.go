@@ -16,6 +16,7 @@ import (
 
 	esv7 "github.com/elastic/go-elasticsearch/v7"
 	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/milvus-io/milvus/client/v2/milvusclient"
 	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
 	"github.com/panjf2000/ants/v2"
 	"github.com/qdrant/go-client/qdrant"
@@ -28,6 +29,8 @@ import (
 	"github.com/Tencent/WeKnora/internal/application/repository"
 	elasticsearchRepoV7 "github.com/Tencent/WeKnora/internal/application/repository/retriever/elasticsearch/v7"
 	elasticsearchRepoV8 "github.com/Tencent/WeKnora/internal/application/repository/retriever/elasticsearch/v8"
+	embeddedRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/embedded"
+	milvusRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/milvus"
 	neo4jRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/neo4j"
 	postgresRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/postgres"
 	qdrantRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/qdrant"
@@ -35,6 +38,7 @@ import (
 	chatpipline "github.com/Tencent/WeKnora/internal/application/service/chat_pipline"
 	"github.com/Tencent/WeKnora/internal/application/service/file"
 	"github.com/Tencent/WeKnora/internal/application/service/llmcontext"
+	"github.com/Tencent/WeKnora/internal/application/service/responsecache"
 	"github.com/Tencent/WeKnora/internal/application/service/retriever"
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/database"
@@ -43,6 +47,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/handler/session"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/mcp"
+	"github.com/Tencent/WeKnora/internal/mcpserver"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/models/utils/ollama"
 	"github.com/Tencent/WeKnora/internal/router"
@@ -50,6 +55,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/tracing"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
 )
 
 // BuildContainer constructs the dependency injection container
@@ -66,12 +72,14 @@ func BuildContainer(container *dig.Container) *dig.Container {
 
 	// Core infrastructure configuration
 	must(container.Provide(config.LoadConfig))
+	must(container.Invoke(initSecretKeys))
 	must(container.Provide(initTracer))
 	must(container.Provide(initDatabase))
 	must(container.Provide(initFileService))
 	must(container.Provide(initRedisClient))
 	must(container.Provide(initAntsPool))
 	must(container.Provide(initContextStorage))
+	must(container.Provide(responsecache.NewResponseCache))
 
 	// Register goroutine pool cleanup handler
 	must(container.Invoke(registerPoolCleanup))
@@ -100,6 +108,12 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(neo4jRepo.NewNeo4jRepository))
 	must(container.Provide(repository.NewMCPServiceRepository))
 	must(container.Provide(repository.NewCustomAgentRepository))
+	must(container.Provide(repository.NewAgentApprovalRepository))
+	must(container.Provide(repository.NewSessionShareRepository))
+	must(container.Provide(repository.NewTenantMemberRepository))
+	must(container.Provide(repository.NewTokenUsageRepository))
+	must(container.Provide(repository.NewKnowledgeBaseGrantRepository))
+	must(container.Provide(repository.NewGitSourceRepository))
 	must(container.Provide(service.NewWebSearchStateService))
 
 	// MCP manager for managing MCP client connections
@@ -111,11 +125,14 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(service.NewKnowledgeService))
 	must(container.Provide(service.NewChunkService))
 	must(container.Provide(service.NewKnowledgeTagService))
+	must(container.Provide(service.NewGitSourceService))
 	must(container.Provide(embedding.NewBatchEmbedder))
+	must(container.Provide(service.NewTokenUsageService))
 	must(container.Provide(service.NewModelService))
 	must(container.Provide(service.NewDatasetService))
 	must(container.Provide(service.NewEvaluationService))
 	must(container.Provide(service.NewUserService))
+	must(container.Provide(service.NewTenantMemberService))
 
 	// Extract services - register individual extracters with names
 	must(container.Provide(service.NewChunkExtractService, dig.Name("chunkExtracter")))
@@ -153,6 +170,8 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Invoke(chatpipline.NewPluginStreamFilter))
 	must(container.Invoke(chatpipline.NewPluginFilterTopK))
 	must(container.Invoke(chatpipline.NewPluginRewrite))
+	must(container.Invoke(chatpipline.NewPluginHyDE))
+	must(container.Invoke(chatpipline.NewPluginResponseCache))
 	must(container.Invoke(chatpipline.NewPluginLoadHistory))
 	must(container.Invoke(chatpipline.NewPluginExtractEntity))
 	must(container.Invoke(chatpipline.NewPluginSearchEntity))
@@ -160,11 +179,14 @@ func BuildContainer(container *dig.Container) *dig.Container {
 
 	// HTTP handlers layer
 	must(container.Provide(handler.NewTenantHandler))
+	must(container.Provide(handler.NewTenantMemberHandler))
+	must(container.Provide(handler.NewTokenUsageHandler))
 	must(container.Provide(handler.NewKnowledgeBaseHandler))
 	must(container.Provide(handler.NewKnowledgeHandler))
 	must(container.Provide(handler.NewChunkHandler))
 	must(container.Provide(handler.NewFAQHandler))
 	must(container.Provide(handler.NewTagHandler))
+	must(container.Provide(handler.NewGitSourceHandler))
 	must(container.Provide(session.NewHandler))
 	must(container.Provide(handler.NewMessageHandler))
 	must(container.Provide(handler.NewModelHandler))
@@ -176,10 +198,28 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(handler.NewWebSearchHandler))
 	must(container.Provide(handler.NewCustomAgentHandler))
 
+	// MCP server exposing the knowledge bases to external agents
+	must(container.Provide(mcpserver.NewServer))
+
 	// Router configuration
 	must(container.Provide(router.NewRouter))
 	must(container.Invoke(router.RunAsynqServer))
+	must(container.Invoke(router.RunAsynqScheduler))
+
+	return container
+}
 
+// BuildDatabaseContainer constructs a container providing only the configuration and the database
+// Used by the commands maintaining the stored data, without starting the application services
+// Parameters:
+//   - container: Base dig container to add dependencies to
+//
+// Returns:
+//   - Container with the configuration and database connection registered
+func BuildDatabaseContainer(container *dig.Container) *dig.Container {
+	must(container.Provide(config.LoadConfig))
+	must(container.Invoke(initSecretKeys))
+	must(container.Provide(initDatabase))
 	return container
 }
 
@@ -234,6 +274,27 @@ func initContextStorage(redisClient *redis.Client) (llmcontext.ContextStorage, e
 	return storage, nil
 }
 
+// initSecretKeys configures the master keys encrypting the secrets stored in the database
+// Falls back to the WEKNORA_MASTER_KEY and TENANT_AES_KEY environment variables if not configured
+// Parameters:
+//   - cfg: Application configuration
+//
+// Returns:
+//   - Error if the master keys are invalid
+func initSecretKeys(cfg *config.Config) error {
+	var current string
+	var previous []string
+	if cfg.Security != nil {
+		current = cfg.Security.MasterKey
+		for _, key := range strings.Split(cfg.Security.PreviousMasterKeys, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				previous = append(previous, key)
+			}
+		}
+	}
+	return secutils.SetMasterKeys(current, previous)
+}
+
 // initDatabase initializes database connection
 // Creates and configures database connection based on environment configuration
 // Supports multiple database backends (PostgreSQL)
@@ -338,7 +399,7 @@ func initDatabase(cfg *config.Config) (*gorm.DB, error) {
 
 // initFileService initializes file storage service
 // Creates the appropriate file storage service based on configuration
-// Supports multiple storage backends (MinIO, COS, local filesystem)
+// Supports multiple storage backends (MinIO, S3-compatible, COS, local filesystem)
 // Parameters:
 //   - cfg: Application configuration
 //
@@ -360,7 +421,28 @@ func initFileService(cfg *config.Config) (interfaces.FileService, error) {
 			os.Getenv("MINIO_SECRET_ACCESS_KEY"),
 			os.Getenv("MINIO_BUCKET_NAME"),
 			strings.EqualFold(os.Getenv("MINIO_USE_SSL"), "true"),
+			os.Getenv("MINIO_PUBLIC_ENDPOINT"),
 		)
+	case "s3":
+		if os.Getenv("S3_ACCESS_KEY_ID") == "" ||
+			os.Getenv("S3_SECRET_ACCESS_KEY") == "" ||
+			os.Getenv("S3_BUCKET_NAME") == "" {
+			return nil, fmt.Errorf("missing S3 configuration")
+		}
+		partSizeMB, _ := strconv.ParseUint(os.Getenv("S3_MULTIPART_PART_SIZE_MB"), 10, 64)
+		partConcurrency, _ := strconv.ParseUint(os.Getenv("S3_MULTIPART_CONCURRENCY"), 10, 32)
+		return file.NewS3FileService(file.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			BucketName:      os.Getenv("S3_BUCKET_NAME"),
+			PathPrefix:      os.Getenv("S3_PATH_PREFIX"),
+			UseSSL:          !strings.EqualFold(os.Getenv("S3_USE_SSL"), "false"),
+			PathStyle:       strings.EqualFold(os.Getenv("S3_FORCE_PATH_STYLE"), "true"),
+			PartSize:        partSizeMB << 20,
+			PartConcurrency: uint(partConcurrency),
+		})
 	case "cos":
 		if os.Getenv("COS_BUCKET_NAME") == "" ||
 			os.Getenv("COS_REGION") == "" ||
@@ -387,15 +469,18 @@ func initFileService(cfg *config.Config) (interfaces.FileService, error) {
 
 // initRetrieveEngineRegistry initializes the retrieval engine registry
 // Sets up and configures various search engine backends based on configuration
-// Supports multiple retrieval engines (PostgreSQL, ElasticsearchV7, ElasticsearchV8)
+// Supports multiple retrieval engines (PostgreSQL, ElasticsearchV7, ElasticsearchV8, Qdrant, Milvus, Embedded)
 // Parameters:
 //   - db: Database connection
 //   - cfg: Application configuration
+//   - cleaner: Resource cleaner used to flush the embedded index on shutdown
 //
 // Returns:
 //   - Configured retrieval engine registry
 //   - Error if initialization fails
-func initRetrieveEngineRegistry(db *gorm.DB, cfg *config.Config) (interfaces.RetrieveEngineRegistry, error) {
+func initRetrieveEngineRegistry(db *gorm.DB, cfg *config.Config,
+	cleaner interfaces.ResourceCleaner,
+) (interfaces.RetrieveEngineRegistry, error) {
 	registry := retriever.NewRetrieveEngineRegistry()
 	retrieveDriver := strings.Split(os.Getenv("RETRIEVE_DRIVER"), ",")
 	log := logger.GetLogger(context.Background())
@@ -501,6 +586,57 @@ func initRetrieveEngineRegistry(db *gorm.DB, cfg *config.Config) (interfaces.Ret
 			}
 		}
 	}
+
+	if slices.Contains(retrieveDriver, "milvus") {
+		milvusAddress := os.Getenv("MILVUS_ADDRESS")
+		if milvusAddress == "" {
+			milvusAddress = "localhost:19530"
+		}
+
+		log.Infof("Connecting to Milvus at %s", milvusAddress)
+
+		client, err := milvusclient.New(context.Background(), &milvusclient.ClientConfig{
+			Address:  milvusAddress,
+			Username: os.Getenv("MILVUS_USERNAME"),
+			Password: os.Getenv("MILVUS_PASSWORD"),
+			DBName:   os.Getenv("MILVUS_DB_NAME"),
+			APIKey:   os.Getenv("MILVUS_API_KEY"),
+		})
+		if err != nil {
+			log.Errorf("Create milvus client failed: %v", err)
+		} else {
+			milvusRepository := milvusRepo.NewMilvusRetrieveEngineRepository(client)
+			if err := registry.Register(
+				retriever.NewKVHybridRetrieveEngine(
+					milvusRepository, types.MilvusRetrieverEngineType,
+				),
+			); err != nil {
+				log.Errorf("Register milvus retrieve engine failed: %v", err)
+			} else {
+				log.Infof("Register milvus retrieve engine success")
+			}
+		}
+	}
+
+	if slices.Contains(retrieveDriver, "embedded") {
+		embeddedRepository, err := embeddedRepo.NewEmbeddedRetrieveEngineRepository(os.Getenv("EMBEDDED_INDEX_DIR"))
+		if err != nil {
+			log.Errorf("Create embedded index failed: %v", err)
+		} else {
+			if closer, ok := embeddedRepository.(interface{ Close() error }); ok {
+				cleaner.RegisterWithName("EmbeddedIndex", closer.Close)
+			}
+			if err := registry.Register(
+				retriever.NewKVHybridRetrieveEngine(
+					embeddedRepository, types.EmbeddedRetrieverEngineType,
+				),
+			); err != nil {
+				log.Errorf("Register embedded retrieve engine failed: %v", err)
+			} else {
+				log.Infof("Register embedded retrieve engine success")
+			}
+		}
+	}
 	return registry, nil
 }
 
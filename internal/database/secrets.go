@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// rotateBatchSize is the number of rows re-encrypted per batch
+const rotateBatchSize = 100
+
+// RotateSecrets re-encrypts every secret stored in the database with the current master key.
+// Secrets are decrypted with whichever configured master key encrypted them, plaintext secrets
+// stored before encryption at rest was enabled are encrypted too. Runs in a single transaction.
+func RotateSecrets(ctx context.Context, db *gorm.DB) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		tables := []struct {
+			name   string
+			rotate func(tx *gorm.DB) (int64, error)
+		}{
+			{"tenants", func(tx *gorm.DB) (int64, error) {
+				return rotateTable[types.Tenant](tx, "api_key", "web_search_config")
+			}},
+			{"models", func(tx *gorm.DB) (int64, error) {
+				return rotateTable[types.Model](tx, "parameters")
+			}},
+			{"knowledge_bases", func(tx *gorm.DB) (int64, error) {
+				return rotateTable[types.KnowledgeBase](tx, "cos_config")
+			}},
+			{"mcp_services", func(tx *gorm.DB) (int64, error) {
+				return rotateTable[types.MCPService](tx, "headers", "auth_config", "env_vars")
+			}},
+		}
+		for _, table := range tables {
+			count, err := table.rotate(tx)
+			if err != nil {
+				return fmt.Errorf("failed to rotate secrets of %s: %w", table.name, err)
+			}
+			logger.Infof(ctx, "Re-encrypted secrets of %d rows in %s", count, table.name)
+		}
+		return nil
+	})
+}
+
+// rotateTable loads the secret columns of every row, soft-deleted ones included, and writes them back.
+// Loading decrypts the secrets and writing encrypts them with the current master key.
+func rotateTable[T any](tx *gorm.DB, columns ...string) (int64, error) {
+	var rows []*T
+	var count int64
+	result := tx.Unscoped().Select(append([]string{"id"}, columns...)).
+		FindInBatches(&rows, rotateBatchSize, func(batch *gorm.DB, _ int) error {
+			for _, row := range rows {
+				if err := tx.Unscoped().Model(row).Select(columns).UpdateColumns(row).Error; err != nil {
+					return err
+				}
+			}
+			count += int64(len(rows))
+			return nil
+		})
+	return count, result.Error
+}
@@ -0,0 +1,6 @@
+package errors
+
+import "errors"
+
+// ErrPresignNotSupported is returned by file services that cannot issue presigned download URLs
+var ErrPresignNotSupported = errors.New("presigned url not supported by storage backend")
@@ -53,6 +53,10 @@ const (
 	EventAgentReflection  EventType = "reflection"   // Agent 反思
 	EventAgentReferences  EventType = "references"   // 知识引用
 	EventAgentFinalAnswer EventType = "final_answer" // 最终答案
+	EventAgentSubAgent    EventType = "sub_agent"    // 子智能体事件（嵌套在父工具调用下）
+
+	// Human-in-the-loop events
+	EventAgentApprovalRequired EventType = "approval_required" // 工具调用等待人工审批，Agent 暂停
 
 	// Error events
 	EventError EventType = "error" // 错误事件
@@ -185,6 +185,7 @@ type AgentReferencesData struct {
 type AgentFinalAnswerData struct {
 	Content string `json:"content"`
 	Done    bool   `json:"done"`
+	ModelID string `json:"model_id,omitempty"` // Chat model that generated the answer
 }
 
 // AgentReflectionData represents agent reflection data
@@ -195,6 +196,25 @@ type AgentReflectionData struct {
 	Done       bool   `json:"done"` // Whether streaming is complete
 }
 
+// AgentSubAgentData wraps an event of a sub-agent, nested under the parent agent's tool call
+type AgentSubAgentData struct {
+	ParentToolCallID string      `json:"parent_tool_call_id"` // Tool call of the parent agent that invoked the sub-agent
+	AgentID          string      `json:"agent_id"`
+	AgentName        string      `json:"agent_name"`
+	EventType        EventType   `json:"event_type"` // Original event type emitted by the sub-agent
+	Content          string      `json:"content,omitempty"`
+	Done             bool        `json:"done"`
+	Data             interface{} `json:"data,omitempty"` // Original event data
+}
+
+// AgentApprovalRequiredData represents tool calls waiting for operator approval.
+// The agent run is paused until POST /sessions/:id/approve is called for the message.
+type AgentApprovalRequiredData struct {
+	MessageID string      `json:"message_id"` // Assistant message of the paused run
+	ToolCalls interface{} `json:"tool_calls"` // types.PendingToolCalls
+	Iteration int         `json:"iteration"`
+}
+
 // SessionTitleData represents session title update data
 type SessionTitleData struct {
 	SessionID string `json:"session_id"`
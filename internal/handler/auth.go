@@ -289,7 +289,7 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		"success": true,
 		"data": gin.H{
 			"user":   userInfo,
-			"tenant": tenant,
+			"tenant": tenant.Redacted(),
 		},
 	})
 }
@@ -64,6 +64,11 @@ func (h *CustomAgentHandler) CreateAgent(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
+	if err := req.Config.FusionConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid fusion configuration", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
 
 	// Build agent object
 	agent := &types.CustomAgent{
@@ -84,6 +89,10 @@ func (h *CustomAgentHandler) CreateAgent(c *gin.Context) {
 			c.Error(errors.NewBadRequestError(err.Error()))
 			return
 		}
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
 		c.Error(errors.NewInternalServerError(err.Error()))
 		return
 	}
@@ -201,6 +210,11 @@ func (h *CustomAgentHandler) UpdateAgent(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
+	if err := req.Config.FusionConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid fusion configuration", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
 
 	// Build agent object
 	agent := &types.CustomAgent{
@@ -228,6 +242,10 @@ func (h *CustomAgentHandler) UpdateAgent(c *gin.Context) {
 		case service.ErrAgentNameRequired:
 			c.Error(errors.NewBadRequestError(err.Error()))
 		default:
+			if appErr, ok := errors.IsAppError(err); ok {
+				c.Error(appErr)
+				return
+			}
 			c.Error(errors.NewInternalServerError(err.Error()))
 		}
 		return
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// GitSourceHandler handles Git repository sources of knowledge bases.
+type GitSourceHandler struct {
+	service interfaces.GitSourceService
+}
+
+// NewGitSourceHandler creates a new GitSourceHandler.
+func NewGitSourceHandler(service interfaces.GitSourceService) *GitSourceHandler {
+	return &GitSourceHandler{service: service}
+}
+
+// ListGitSources godoc
+// @Summary      获取Git仓库源列表
+// @Description  获取知识库下配置的所有Git仓库源及其同步状态
+// @Tags         Git仓库源
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "Git仓库源列表"
+// @Failure      404  {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/git-sources [get]
+func (h *GitSourceHandler) ListGitSources(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	sources, err := h.service.ListGitSources(ctx, kbID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"kb_id": kbID})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sources,
+	})
+}
+
+// CreateGitSource godoc
+// @Summary      创建Git仓库源
+// @Description  为知识库添加Git仓库源，并立即排队首次同步。仓库中匹配的Markdown、文档和源码文件会被导入为知识
+// @Tags         Git仓库源
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "知识库ID"
+// @Param        request  body      types.GitSourceRequest  true  "仓库配置"
+// @Success      201      {object}  map[string]interface{}  "创建的Git仓库源"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/git-sources [post]
+func (h *GitSourceHandler) CreateGitSource(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.GitSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind create git source payload", err)
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	source, err := h.service.CreateGitSource(ctx, kbID, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"kb_id": kbID})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    source,
+	})
+}
+
+// GetGitSource godoc
+// @Summary      获取Git仓库源详情
+// @Description  获取Git仓库源的配置、同步状态和最近一次同步的统计
+// @Tags         Git仓库源
+// @Produce      json
+// @Param        id         path      string  true  "知识库ID"
+// @Param        source_id  path      string  true  "Git仓库源ID"
+// @Success      200        {object}  map[string]interface{}  "Git仓库源"
+// @Failure      404        {object}  errors.AppError         "Git仓库源不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/git-sources/{source_id} [get]
+func (h *GitSourceHandler) GetGitSource(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+	sourceID := secutils.SanitizeForLog(c.Param("source_id"))
+
+	source, err := h.service.GetGitSource(ctx, kbID, sourceID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"kb_id": kbID, "source_id": sourceID})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    source,
+	})
+}
+
+// UpdateGitSource godoc
+// @Summary      更新Git仓库源
+// @Description  更新Git仓库源的仓库地址、分支和路径过滤规则，在下一次同步时生效
+// @Tags         Git仓库源
+// @Accept       json
+// @Produce      json
+// @Param        id         path      string                  true  "知识库ID"
+// @Param        source_id  path      string                  true  "Git仓库源ID"
+// @Param        request    body      types.GitSourceRequest  true  "仓库配置"
+// @Success      200        {object}  map[string]interface{}  "更新后的Git仓库源"
+// @Failure      400        {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/git-sources/{source_id} [put]
+func (h *GitSourceHandler) UpdateGitSource(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+	sourceID := secutils.SanitizeForLog(c.Param("source_id"))
+
+	var req types.GitSourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind update git source payload", err)
+		c.Error(errors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	source, err := h.service.UpdateGitSource(ctx, kbID, sourceID, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"kb_id": kbID, "source_id": sourceID})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    source,
+	})
+}
+
+// DeleteGitSource godoc
+// @Summary      删除Git仓库源
+// @Description  删除Git仓库源。默认保留已导入的知识，delete_knowledge=true 时一并删除
+// @Tags         Git仓库源
+// @Produce      json
+// @Param        id                path      string  true   "知识库ID"
+// @Param        source_id         path      string  true   "Git仓库源ID"
+// @Param        delete_knowledge  query     bool    false  "是否删除该仓库源导入的知识"
+// @Success      200               {object}  map[string]interface{}  "删除成功"
+// @Failure      404               {object}  errors.AppError         "Git仓库源不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/git-sources/{source_id} [delete]
+func (h *GitSourceHandler) DeleteGitSource(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+	sourceID := secutils.SanitizeForLog(c.Param("source_id"))
+
+	deleteKnowledge := false
+	if v := c.Query("delete_knowledge"); v != "" {
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			c.Error(errors.NewBadRequestError("delete_knowledge 参数不合法"))
+			return
+		}
+		deleteKnowledge = parsed
+	}
+
+	if err := h.service.DeleteGitSource(ctx, kbID, sourceID, deleteKnowledge); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"kb_id": kbID, "source_id": sourceID})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// SyncGitSource godoc
+// @Summary      同步Git仓库源
+// @Description  排队同步Git仓库源：新增文件被导入，内容变化的文件被重新导入，仓库中已删除的文件对应的知识被删除
+// @Tags         Git仓库源
+// @Produce      json
+// @Param        id         path      string  true  "知识库ID"
+// @Param        source_id  path      string  true  "Git仓库源ID"
+// @Success      202        {object}  map[string]interface{}  "已排队的Git仓库源"
+// @Failure      409        {object}  errors.AppError         "同步任务已在进行中"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/git-sources/{source_id}/sync [post]
+func (h *GitSourceHandler) SyncGitSource(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+	sourceID := secutils.SanitizeForLog(c.Param("source_id"))
+
+	source, err := h.service.SyncGitSource(ctx, kbID, sourceID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"kb_id": kbID, "source_id": sourceID})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    source,
+	})
+}
+
+// ListGitSourceFiles godoc
+// @Summary      获取Git仓库源文件列表
+// @Description  获取Git仓库源已同步的文件，及每个文件对应的知识ID、Blob SHA和最后修改该文件的提交SHA
+// @Tags         Git仓库源
+// @Produce      json
+// @Param        id         path      string  true  "知识库ID"
+// @Param        source_id  path      string  true  "Git仓库源ID"
+// @Success      200        {object}  map[string]interface{}  "文件列表"
+// @Failure      404        {object}  errors.AppError         "Git仓库源不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/git-sources/{source_id}/files [get]
+func (h *GitSourceHandler) ListGitSourceFiles(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+	sourceID := secutils.SanitizeForLog(c.Param("source_id"))
+
+	files, err := h.service.ListGitSourceFiles(ctx, kbID, sourceID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"kb_id": kbID, "source_id": sourceID})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    files,
+	})
+}
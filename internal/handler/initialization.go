@@ -303,9 +303,12 @@ func (h *InitializationHandler) UpdateKBConfig(c *gin.Context) {
 		switch strings.ToLower(req.Multimodal.StorageType) {
 		case "cos":
 			if req.Multimodal.COS != nil {
+				// 回传脱敏后的 SecretKey 时保留原值
+				secretKey := req.Multimodal.COS.SecretKey
+				types.KeepRedactedSecret(&secretKey, kb.StorageConfig.SecretKey)
 				kb.StorageConfig = types.StorageConfig{
 					SecretID:   req.Multimodal.COS.SecretID,
-					SecretKey:  req.Multimodal.COS.SecretKey,
+					SecretKey:  secretKey,
 					Region:     req.Multimodal.COS.Region,
 					BucketName: req.Multimodal.COS.BucketName,
 					AppID:      req.Multimodal.COS.AppID,
@@ -632,6 +635,8 @@ func (h *InitializationHandler) processInitializationModels(
 		}
 
 		if existingModel != nil {
+			// 回传脱敏后的 API Key 时保留原值
+			types.KeepRedactedSecret(&model.Parameters.APIKey, existingModel.Parameters.APIKey)
 			existingModel.Name = model.Name
 			existingModel.Source = model.Source
 			existingModel.Description = model.Description
@@ -723,13 +728,16 @@ func (h *InitializationHandler) applyKnowledgeBaseInitialization(
 		switch req.Multimodal.StorageType {
 		case "cos":
 			if req.Multimodal.COS != nil {
+				// 回传脱敏后的 SecretKey 时保留原值
+				secretKey := req.Multimodal.COS.SecretKey
+				types.KeepRedactedSecret(&secretKey, kb.StorageConfig.SecretKey)
 				kb.StorageConfig = types.StorageConfig{
 					Provider:   req.Multimodal.StorageType,
 					BucketName: req.Multimodal.COS.BucketName,
 					AppID:      req.Multimodal.COS.AppID,
 					PathPrefix: req.Multimodal.COS.PathPrefix,
 					SecretID:   req.Multimodal.COS.SecretID,
-					SecretKey:  req.Multimodal.COS.SecretKey,
+					SecretKey:  secretKey,
 					Region:     req.Multimodal.COS.Region,
 				}
 			}
@@ -1297,9 +1305,9 @@ func (h *InitializationHandler) buildConfigResponse(ctx context.Context, models
 		if model == nil {
 			continue
 		}
-		// Hide sensitive information for builtin models
+		// Hide sensitive information for builtin models, mask the API key of the others
 		baseURL := model.Parameters.BaseURL
-		apiKey := model.Parameters.APIKey
+		apiKey := types.RedactSecret(model.Parameters.APIKey)
 		if model.IsBuiltin {
 			baseURL = ""
 			apiKey = ""
@@ -1388,7 +1396,7 @@ func (h *InitializationHandler) buildConfigResponse(ctx context.Context, models
 			case "cos":
 				multimodal["cos"] = map[string]interface{}{
 					"secretId":   kb.StorageConfig.SecretID,
-					"secretKey":  kb.StorageConfig.SecretKey,
+					"secretKey":  types.RedactSecret(kb.StorageConfig.SecretKey),
 					"region":     kb.StorageConfig.Region,
 					"bucketName": kb.StorageConfig.BucketName,
 					"appId":      kb.StorageConfig.AppID,
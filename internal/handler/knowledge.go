@@ -190,7 +190,7 @@ func (h *KnowledgeHandler) CreateKnowledgeFromFile(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        id       path      string  true  "知识库ID"
-// @Param        request  body      object{url=string,enable_multimodel=bool,title=string}  true  "URL请求"
+// @Param        request  body      object{url=string,enable_multimodel=bool,title=string,refresh_interval=int}  true  "URL请求"
 // @Success      201      {object}  map[string]interface{}  "创建的知识"
 // @Failure      400      {object}  errors.AppError         "请求参数错误"
 // @Failure      409      {object}  map[string]interface{}  "URL重复"
@@ -213,6 +213,7 @@ func (h *KnowledgeHandler) CreateKnowledgeFromURL(c *gin.Context) {
 		URL              string `json:"url" binding:"required"`
 		EnableMultimodel *bool  `json:"enable_multimodel"`
 		Title            string `json:"title"`
+		RefreshInterval  int    `json:"refresh_interval"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Error(ctx, "Failed to parse URL request", err)
@@ -229,12 +230,18 @@ func (h *KnowledgeHandler) CreateKnowledgeFromURL(c *gin.Context) {
 	)
 
 	// Create knowledge entry from the URL
-	knowledge, err := h.kgService.CreateKnowledgeFromURL(ctx, kbID, req.URL, req.EnableMultimodel, req.Title)
+	knowledge, err := h.kgService.CreateKnowledgeFromURL(
+		ctx, kbID, req.URL, req.EnableMultimodel, req.Title, req.RefreshInterval,
+	)
 	// Check for duplicate knowledge error
 	if err != nil {
 		if h.handleDuplicateKnowledgeError(c, err, knowledge, "url") {
 			return
 		}
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
 		return
@@ -467,13 +474,15 @@ func (h *KnowledgeHandler) DeleteKnowledge(c *gin.Context) {
 
 // DownloadKnowledgeFile godoc
 // @Summary      下载知识文件
-// @Description  下载知识条目关联的原始文件
+// @Description  下载知识条目关联的原始文件。对象存储支持预签名时重定向到存储地址，redirect=false 时由服务端转发文件内容
 // @Tags         知识管理
 // @Accept       json
 // @Produce      application/octet-stream
-// @Param        id   path      string  true  "知识ID"
-// @Success      200  {file}    file    "文件内容"
-// @Failure      400  {object}  errors.AppError  "请求参数错误"
+// @Param        id        path      string  true   "知识ID"
+// @Param        redirect  query     bool    false  "是否重定向到预签名地址，默认 true"
+// @Success      200       {file}    file    "文件内容"
+// @Success      302       {string}  string  "重定向到预签名下载地址"
+// @Failure      400       {object}  errors.AppError  "请求参数错误"
 // @Security     Bearer
 // @Security     ApiKeyAuth
 // @Router       /knowledge/{id}/download [get]
@@ -492,6 +501,21 @@ func (h *KnowledgeHandler) DownloadKnowledgeFile(c *gin.Context) {
 
 	logger.Infof(ctx, "Retrieving knowledge file, ID: %s", secutils.SanitizeForLog(id))
 
+	// Let the client download large files directly from object storage when possible
+	if c.Query("redirect") != "false" {
+		fileURL, err := h.kgService.GetKnowledgeFileURL(ctx, id)
+		if err != nil {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to retrieve file").WithDetails(err.Error()))
+			return
+		}
+		if fileURL != "" {
+			logger.Infof(ctx, "Redirecting to presigned URL of knowledge file, ID: %s", id)
+			c.Redirect(http.StatusFound, fileURL)
+			return
+		}
+	}
+
 	// Get file content and filename
 	file, filename, err := h.kgService.GetKnowledgeFile(ctx, id)
 	if err != nil {
@@ -685,6 +709,166 @@ func (h *KnowledgeHandler) UpdateManualKnowledge(c *gin.Context) {
 	})
 }
 
+// UpdateKnowledgeRefreshInterval godoc
+// @Summary      设置URL知识定时刷新
+// @Description  设置URL知识的重新抓取间隔（秒），0 表示关闭定时刷新
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                     true  "知识ID"
+// @Param        request  body      object{refresh_interval=int}  true  "刷新间隔"
+// @Success      200      {object}  map[string]interface{}     "更新后的知识"
+// @Failure      400      {object}  errors.AppError            "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/refresh-schedule [put]
+func (h *KnowledgeHandler) UpdateKnowledgeRefreshInterval(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	var req struct {
+		RefreshInterval *int `json:"refresh_interval" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse refresh schedule request", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	knowledge, err := h.kgService.UpdateKnowledgeRefreshInterval(ctx, id, *req.RefreshInterval)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+		})
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    knowledge,
+	})
+}
+
+// RefreshURLKnowledge godoc
+// @Summary      立即刷新URL知识
+// @Description  立即重新抓取URL知识，内容发生变化时重新分块并建立索引
+// @Tags         知识管理
+// @Produce      json
+// @Param        id   path      string                  true  "知识ID"
+// @Success      202  {object}  map[string]interface{}  "刷新任务已提交"
+// @Failure      409  {object}  errors.AppError         "知识正在处理中"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/refresh [post]
+func (h *KnowledgeHandler) RefreshURLKnowledge(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	if err := h.kgService.RefreshURLKnowledge(ctx, id); err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+		})
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "URL refresh task submitted",
+	})
+}
+
+// CrawlWebsite godoc
+// @Summary      爬取网站创建知识
+// @Description  从种子URL或sitemap.xml出发爬取网站，遵守robots.txt，为每个页面创建一条URL知识
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                 true  "知识库ID"
+// @Param        request  body      types.WebCrawlRequest  true  "爬取请求"
+// @Success      202      {object}  map[string]interface{} "爬取任务进度"
+// @Failure      400      {object}  errors.AppError        "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge/crawl [post]
+func (h *KnowledgeHandler) CrawlWebsite(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, kbID, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req types.WebCrawlRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse website crawl request", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	logger.Infof(ctx, "Creating website crawl, knowledge base ID: %s, URL: %s, sitemap: %s",
+		kbID, secutils.SanitizeForLog(req.URL), secutils.SanitizeForLog(req.SitemapURL))
+
+	progress, err := h.kgService.CrawlWebsite(ctx, kbID, &req)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
+// GetWebCrawlProgress godoc
+// @Summary      获取网站爬取进度
+// @Description  获取网站爬取任务的进度
+// @Tags         知识管理
+// @Produce      json
+// @Param        task_id  path      string  true  "任务ID"
+// @Success      200      {object}  map[string]interface{}  "进度信息"
+// @Failure      404      {object}  errors.AppError         "任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/crawl/progress/{task_id} [get]
+func (h *KnowledgeHandler) GetWebCrawlProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	taskID := c.Param("task_id")
+	if taskID == "" {
+		logger.Error(ctx, "Task ID is empty")
+		c.Error(errors.NewBadRequestError("Task ID cannot be empty"))
+		return
+	}
+
+	progress, err := h.kgService.GetWebCrawlProgress(ctx, taskID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
 type knowledgeTagBatchRequest struct {
 	Updates map[string]*string `json:"updates" binding:"required,min=1"`
 }
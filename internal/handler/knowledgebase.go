@@ -70,6 +70,16 @@ func (h *KnowledgeBaseHandler) HybridSearch(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
+	if err := req.Fusion.Validate(); err != nil {
+		logger.Error(ctx, "Invalid fusion configuration", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if err := req.Filter.Validate(); err != nil {
+		logger.Error(ctx, "Invalid metadata filter", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
 
 	logger.Infof(ctx, "Executing hybrid search, knowledge base ID: %s, query: %s",
 		secutils.SanitizeForLog(id), secutils.SanitizeForLog(req.QueryText))
@@ -119,6 +129,11 @@ func (h *KnowledgeBaseHandler) CreateKnowledgeBase(c *gin.Context) {
 		c.Error(err)
 		return
 	}
+	if err := req.FusionConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid fusion configuration", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
 
 	logger.Infof(ctx, "Creating knowledge base, name: %s", secutils.SanitizeForLog(req.Name))
 	// Create knowledge base using the service
@@ -133,7 +148,7 @@ func (h *KnowledgeBaseHandler) CreateKnowledgeBase(c *gin.Context) {
 		secutils.SanitizeForLog(kb.ID), secutils.SanitizeForLog(kb.Name))
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
-		"data":    kb,
+		"data":    kb.Redacted(),
 	})
 }
 
@@ -199,7 +214,7 @@ func (h *KnowledgeBaseHandler) GetKnowledgeBase(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    kb,
+		"data":    kb.Redacted(),
 	})
 }
 
@@ -227,10 +242,19 @@ func (h *KnowledgeBaseHandler) ListKnowledgeBases(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    kbs,
+		"data":    redactKnowledgeBases(kbs),
 	})
 }
 
+// redactKnowledgeBases returns copies of the knowledge bases with their storage secret keys masked
+func redactKnowledgeBases(kbs []*types.KnowledgeBase) []*types.KnowledgeBase {
+	redacted := make([]*types.KnowledgeBase, 0, len(kbs))
+	for _, kb := range kbs {
+		redacted = append(redacted, kb.Redacted())
+	}
+	return redacted
+}
+
 // UpdateKnowledgeBaseRequest defines the request body structure for updating a knowledge base
 type UpdateKnowledgeBaseRequest struct {
 	Name        string                     `json:"name"        binding:"required"`
@@ -269,6 +293,11 @@ func (h *KnowledgeBaseHandler) UpdateKnowledgeBase(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
 		return
 	}
+	if err := req.Config.FusionConfig.Validate(); err != nil {
+		logger.Error(ctx, "Invalid fusion configuration", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
 
 	logger.Infof(ctx, "Updating knowledge base, ID: %s, name: %s",
 		secutils.SanitizeForLog(id), secutils.SanitizeForLog(req.Name))
@@ -285,7 +314,7 @@ func (h *KnowledgeBaseHandler) UpdateKnowledgeBase(c *gin.Context) {
 		secutils.SanitizeForLog(id))
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    kb,
+		"data":    kb.Redacted(),
 	})
 }
 
@@ -464,6 +493,93 @@ func (h *KnowledgeBaseHandler) GetKBCloneProgress(c *gin.Context) {
 	})
 }
 
+// ReembedKnowledgeBaseRequest defines the request for re-embedding a knowledge base
+type ReembedKnowledgeBaseRequest struct {
+	EmbeddingModelID string `json:"embedding_model_id" binding:"required"`
+}
+
+// ReembedKnowledgeBase godoc
+// @Summary      重新向量化知识库
+// @Description  使用新的嵌入模型（向量维度不同）重新生成知识库的全部向量（异步任务），完成后原子切换并删除旧向量
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                       true  "知识库ID"
+// @Param        request  body      ReembedKnowledgeBaseRequest  true  "重新向量化请求"
+// @Success      200      {object}  map[string]interface{}       "任务进度"
+// @Failure      400      {object}  errors.AppError              "请求参数错误"
+// @Failure      409      {object}  errors.AppError              "已有任务在进行中"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/reembed [post]
+func (h *KnowledgeBaseHandler) ReembedKnowledgeBase(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	kb, _, err := h.validateAndGetKnowledgeBase(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req ReembedKnowledgeBaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	progress, err := h.knowledgeService.ReembedKnowledgeBase(ctx, kb, req.EmbeddingModelID)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
+// GetKBReembedProgress godoc
+// @Summary      获取知识库重新向量化进度
+// @Description  获取知识库重新向量化任务的进度
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        task_id  path      string  true  "任务ID"
+// @Success      200      {object}  map[string]interface{}  "进度信息"
+// @Failure      404      {object}  errors.AppError         "任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/reembed/progress/{task_id} [get]
+func (h *KnowledgeBaseHandler) GetKBReembedProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	taskID := c.Param("task_id")
+	if taskID == "" {
+		logger.Error(ctx, "Task ID is empty")
+		c.Error(errors.NewBadRequestError("Task ID cannot be empty"))
+		return
+	}
+
+	progress, err := h.knowledgeService.GetKBReembedProgress(ctx, taskID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
 // validateExtractConfig validates the graph configuration parameters
 func validateExtractConfig(config *types.ExtractConfig) error {
 	logger.Errorf(context.Background(), "Validating extract configuration: %+v", config)
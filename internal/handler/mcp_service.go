@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
+	"html"
 	"net/http"
 
 	"github.com/Tencent/WeKnora/internal/errors"
@@ -58,6 +61,7 @@ func (h *MCPServiceHandler) CreateMCPService(c *gin.Context) {
 		c.Error(errors.NewInternalServerError("Failed to create MCP service: " + err.Error()))
 		return
 	}
+	service.MaskSensitiveData()
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -128,6 +132,7 @@ func (h *MCPServiceHandler) GetMCPService(c *gin.Context) {
 		c.Error(errors.NewNotFoundError("MCP service not found"))
 		return
 	}
+	service.MaskSensitiveData()
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -233,12 +238,13 @@ func (h *MCPServiceHandler) UpdateMCPService(c *gin.Context) {
 		}
 	}
 	if authConfig, ok := updateData["auth_config"].(map[string]interface{}); ok {
+		// Decode the whole config, including custom headers and the OAuth client
 		service.AuthConfig = &types.MCPAuthConfig{}
-		if apiKey, ok := authConfig["api_key"].(string); ok {
-			service.AuthConfig.APIKey = apiKey
-		}
-		if token, ok := authConfig["token"].(string); ok {
-			service.AuthConfig.Token = token
+		data, _ := json.Marshal(authConfig)
+		if err := json.Unmarshal(data, service.AuthConfig); err != nil {
+			logger.Error(ctx, "Failed to parse MCP service auth config", err)
+			c.Error(errors.NewBadRequestError("Invalid auth_config: " + err.Error()))
+			return
 		}
 	}
 	if advancedConfig, ok := updateData["advanced_config"].(map[string]interface{}); ok {
@@ -261,6 +267,8 @@ func (h *MCPServiceHandler) UpdateMCPService(c *gin.Context) {
 	}
 
 	logger.Infof(ctx, "MCP service updated successfully: %s", secutils.SanitizeForLog(serviceID))
+	// The auth config now carries the stored secrets
+	service.MaskSensitiveData()
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    service,
@@ -419,3 +427,127 @@ func (h *MCPServiceHandler) GetMCPServiceResources(c *gin.Context) {
 		"data":    resources,
 	})
 }
+
+// GetMCPServicePrompts godoc
+// @Summary      获取MCP服务提示词列表
+// @Description  获取MCP服务提供的提示词模板列表，可在智能体配置中固定为系统提示词片段
+// @Tags         MCP服务
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "MCP服务ID"
+// @Success      200  {object}  map[string]interface{}  "提示词列表"
+// @Failure      500  {object}  errors.AppError         "服务器错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /mcp-services/{id}/prompts [get]
+func (h *MCPServiceHandler) GetMCPServicePrompts(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID := secutils.SanitizeForLog(c.Param("id"))
+
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+	if tenantID == 0 {
+		logger.Error(ctx, "Tenant ID is empty")
+		c.Error(errors.NewBadRequestError("Tenant ID cannot be empty"))
+		return
+	}
+
+	prompts, err := h.mcpServiceService.GetMCPServicePrompts(ctx, tenantID, serviceID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"service_id": secutils.SanitizeForLog(serviceID)})
+		c.Error(errors.NewInternalServerError("Failed to get MCP service prompts: " + err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    prompts,
+	})
+}
+
+// AuthorizeMCPServiceOAuth godoc
+// @Summary      发起MCP服务OAuth授权
+// @Description  为使用授权码模式的MCP服务生成授权地址，用户在浏览器中完成授权
+// @Tags         MCP服务
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "MCP服务ID"
+// @Success      200  {object}  map[string]interface{}  "授权地址"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /mcp-services/{id}/oauth/authorize [post]
+func (h *MCPServiceHandler) AuthorizeMCPServiceOAuth(c *gin.Context) {
+	ctx := c.Request.Context()
+	serviceID := secutils.SanitizeForLog(c.Param("id"))
+
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+	if tenantID == 0 {
+		logger.Error(ctx, "Tenant ID is empty")
+		c.Error(errors.NewBadRequestError("Tenant ID cannot be empty"))
+		return
+	}
+
+	authorizationURL, err := h.mcpServiceService.StartMCPServiceOAuth(ctx, tenantID, serviceID, oauthCallbackURL(c))
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"service_id": secutils.SanitizeForLog(serviceID)})
+		c.Error(errors.NewBadRequestError("Failed to start OAuth authorization: " + err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"authorization_url": authorizationURL,
+		},
+	})
+}
+
+// OAuthCallback godoc
+// @Summary      MCP服务OAuth授权回调
+// @Description  授权服务器在用户完成授权后重定向至此，换取并保存访问令牌。无需认证
+// @Tags         MCP服务
+// @Produce      html
+// @Param        code   query     string  false  "授权码"
+// @Param        state  query     string  true   "授权状态"
+// @Success      200    {string}  string  "授权结果页面"
+// @Router       /mcp-services/oauth/callback [get]
+func (h *MCPServiceHandler) OAuthCallback(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	if authError := c.Query("error"); authError != "" {
+		logger.Warnf(ctx, "MCP service OAuth authorization denied: %s", secutils.SanitizeForLog(authError))
+		oauthResultPage(c, http.StatusBadRequest,
+			fmt.Sprintf("Authorization failed: %s %s", authError, c.Query("error_description")))
+		return
+	}
+
+	if err := h.mcpServiceService.CompleteMCPServiceOAuth(ctx, c.Query("state"), c.Query("code")); err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		oauthResultPage(c, http.StatusBadRequest, "Authorization failed: "+err.Error())
+		return
+	}
+	oauthResultPage(c, http.StatusOK, "Authorization completed, you can close this window.")
+}
+
+// oauthCallbackURL returns the URL of the OAuth callback as seen by the browser
+func oauthCallbackURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	host := c.Request.Host
+	if forwardedHost := c.GetHeader("X-Forwarded-Host"); forwardedHost != "" {
+		host = forwardedHost
+	}
+	return fmt.Sprintf("%s://%s/api/v1/mcp-services/oauth/callback", scheme, host)
+}
+
+// oauthResultPage renders the page the browser lands on after the OAuth authorization
+func oauthResultPage(c *gin.Context, status int, message string) {
+	page := fmt.Sprintf("<!DOCTYPE html><html><head><meta charset=\"utf-8\"><title>WeKnora</title></head>"+
+		"<body><p>%s</p></body></html>", html.EscapeString(message))
+	c.Data(status, "text/html; charset=utf-8", []byte(page))
+}
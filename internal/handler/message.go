@@ -70,7 +70,7 @@ func (h *MessageHandler) LoadMessages(c *gin.Context) {
 		messages, err := h.MessageService.GetRecentMessagesBySession(ctx, sessionID, limitInt)
 		if err != nil {
 			logger.ErrorWithFields(ctx, err, nil)
-			c.Error(errors.NewInternalServerError(err.Error()))
+			c.Error(messageServiceError(err))
 			return
 		}
 
@@ -104,7 +104,7 @@ func (h *MessageHandler) LoadMessages(c *gin.Context) {
 	messages, err := h.MessageService.GetMessagesBySessionBeforeTime(ctx, sessionID, beforeTime, limitInt)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
-		c.Error(errors.NewInternalServerError(err.Error()))
+		c.Error(messageServiceError(err))
 		return
 	}
 
@@ -146,7 +146,7 @@ func (h *MessageHandler) DeleteMessage(c *gin.Context) {
 	// Delete the message using the message service
 	if err := h.MessageService.DeleteMessage(ctx, sessionID, messageID); err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
-		c.Error(errors.NewInternalServerError(err.Error()))
+		c.Error(messageServiceError(err))
 		return
 	}
 
@@ -156,3 +156,15 @@ func (h *MessageHandler) DeleteMessage(c *gin.Context) {
 		"message": "Message deleted successfully",
 	})
 }
+
+// messageServiceError converts a message service error to an API error,
+// sessions the user cannot access are reported as not found
+func messageServiceError(err error) *errors.AppError {
+	if err == errors.ErrSessionNotFound {
+		return errors.NewNotFoundError(err.Error())
+	}
+	if appErr, ok := errors.IsAppError(err); ok {
+		return appErr
+	}
+	return errors.NewInternalServerError(err.Error())
+}
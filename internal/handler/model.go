@@ -30,10 +30,13 @@ func NewModelHandler(service interfaces.ModelService) *ModelHandler {
 }
 
 // hideSensitiveInfo hides sensitive information (APIKey, BaseURL) for builtin models
-// Returns a copy of the model with sensitive fields cleared if it's a builtin model
+// Returns a copy of the model with sensitive fields cleared if it's a builtin model,
+// or with the APIKey masked otherwise
 func hideSensitiveInfo(model *types.Model) *types.Model {
 	if !model.IsBuiltin {
-		return model
+		redacted := *model
+		redacted.Parameters.APIKey = types.RedactSecret(model.Parameters.APIKey)
+		return &redacted
 	}
 
 	// Create a copy with sensitive information hidden
@@ -51,6 +54,7 @@ func hideSensitiveInfo(model *types.Model) *types.Model {
 			// Keep other parameters like embedding dimensions
 			EmbeddingParameters: model.Parameters.EmbeddingParameters,
 			ParameterSize:       model.Parameters.ParameterSize,
+			Fallback:            model.Parameters.Fallback,
 		},
 		IsBuiltin: model.IsBuiltin,
 		Status:    model.Status,
@@ -292,7 +296,10 @@ func (h *ModelHandler) UpdateModel(c *gin.Context) {
 	}
 	model.Description = req.Description
 	// Check if any Parameters field is set (can't use struct comparison due to map field)
-	if req.Parameters.BaseURL != "" || req.Parameters.APIKey != "" || req.Parameters.Provider != "" {
+	if req.Parameters.BaseURL != "" || req.Parameters.APIKey != "" || req.Parameters.Provider != "" ||
+		req.Parameters.Fallback != nil {
+		// The API key sent back masked is kept
+		types.KeepRedactedSecret(&req.Parameters.APIKey, model.Parameters.APIKey)
 		model.Parameters = req.Parameters
 	}
 	model.Source = req.Source
@@ -63,11 +63,31 @@ func (h *AgentStreamHandler) Subscribe() {
 	h.eventBus.On(event.EventAgentReferences, h.handleReferences)
 	h.eventBus.On(event.EventAgentFinalAnswer, h.handleFinalAnswer)
 	h.eventBus.On(event.EventAgentReflection, h.handleReflection)
+	h.eventBus.On(event.EventAgentSubAgent, h.handleSubAgent)
+	h.eventBus.On(event.EventAgentApprovalRequired, h.handleApprovalRequired)
 	h.eventBus.On(event.EventError, h.handleError)
 	h.eventBus.On(event.EventSessionTitle, h.handleSessionTitle)
 	h.eventBus.On(event.EventAgentComplete, h.handleComplete)
 }
 
+// KnowledgeReferences returns the knowledge references of the assistant message received so far
+func (h *AgentStreamHandler) KnowledgeReferences() types.References {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	refs := h.assistantMessage.KnowledgeReferences
+	if refs == nil {
+		return nil
+	}
+	return append(make(types.References, 0, len(refs)), refs...)
+}
+
+// UpdateAssistantMessage runs fn on the assistant message while no event updates it
+func (h *AgentStreamHandler) UpdateAssistantMessage(fn func(message *types.Message)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fn(h.assistantMessage)
+}
+
 // handleThought handles agent thought events
 func (h *AgentStreamHandler) handleThought(ctx context.Context, evt event.Event) error {
 	data, ok := evt.Data.(event.AgentThoughtData)
@@ -290,6 +310,9 @@ func (h *AgentStreamHandler) handleFinalAnswer(ctx context.Context, evt event.Ev
 
 	// Accumulate final answer locally for assistant message (database)
 	h.finalAnswer += data.Content
+	if data.ModelID != "" {
+		h.assistantMessage.ModelID = data.ModelID
+	}
 
 	// Calculate duration if done
 	var metadata map[string]interface{}
@@ -307,6 +330,9 @@ func (h *AgentStreamHandler) handleFinalAnswer(ctx context.Context, evt event.Ev
 			"event_id": evt.ID,
 		}
 	}
+	if data.ModelID != "" {
+		metadata["model_id"] = data.ModelID
+	}
 	h.mu.Unlock()
 
 	// Append this chunk to stream (frontend will accumulate by event ID)
@@ -345,6 +371,59 @@ func (h *AgentStreamHandler) handleReflection(ctx context.Context, evt event.Eve
 	return nil
 }
 
+// handleSubAgent handles events of sub-agents, nested under the parent tool call
+func (h *AgentStreamHandler) handleSubAgent(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentSubAgentData)
+	if !ok {
+		return nil
+	}
+
+	// Append this chunk to stream (frontend will accumulate by event ID)
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeSubAgent,
+		Content:   data.Content,
+		Done:      data.Done,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"parent_tool_call_id": data.ParentToolCallID,
+			"agent_id":            data.AgentID,
+			"agent_name":          data.AgentName,
+			"event_type":          data.EventType,
+			"data":                data.Data,
+		},
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append sub-agent event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
+// handleApprovalRequired handles tool calls waiting for approval, the run pauses until they are decided
+func (h *AgentStreamHandler) handleApprovalRequired(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentApprovalRequiredData)
+	if !ok {
+		return nil
+	}
+
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeApprovalRequired,
+		Content:   "",
+		Done:      true,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"message_id": data.MessageID,
+			"tool_calls": data.ToolCalls,
+			"iteration":  data.Iteration,
+		},
+	}); err != nil {
+		logger.GetLogger(h.ctx).Errorf("Append approval required event to stream failed: %v", err)
+	}
+
+	return nil
+}
+
 // handleError handles error events
 func (h *AgentStreamHandler) handleError(ctx context.Context, evt event.Event) error {
 	data, ok := evt.Data.(event.ErrorData)
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ApproveToolCalls godoc
+// @Summary      审批工具调用
+// @Description  批准或拒绝 Agent 暂停等待审批的工具调用。批准后 Agent 在后台继续执行，拒绝则终止本次执行；
+// @Description  后续事件写入原消息的流中，可通过 continue-stream 接口获取。审批人需要 editor 及以上角色，
+// @Description  并能查看该会话（会话所有者或被分享的用户）；触发工具调用的用户只能拒绝，不能批准；
+// @Description  审批人或触发者没有登录用户身份（如 API Key）时只能拒绝。批准后状态为 resuming，
+// @Description  继续执行结束后变为 approved，执行失败或因服务重启中断则变为 failed
+// @Tags         问答
+// @Accept       json
+// @Produce      json
+// @Param        session_id  path      string                   true  "会话ID"
+// @Param        request     body      ApproveToolCallsRequest  true  "审批请求"
+// @Success      200         {object}  map[string]interface{}   "审批结果"
+// @Failure      403         {object}  errors.AppError          "无审批权限"
+// @Failure      404         {object}  errors.AppError          "没有等待审批的工具调用"
+// @Failure      409         {object}  errors.AppError          "工具调用已被审批"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/approve [post]
+func (h *Handler) ApproveToolCalls(c *gin.Context) {
+	ctx := logger.CloneContext(c.Request.Context())
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	if sessionID == "" {
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	var request ApproveToolCallsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error(ctx, "Failed to parse approval request", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	messageID := secutils.SanitizeForLog(request.MessageID)
+	logger.Infof(ctx, "Tool approval for session: %s, message: %s, approved: %v", sessionID, messageID, request.Approved)
+
+	// Approvers are operators the session is shared with, they need not own it
+	session, err := h.sessionService.GetSession(ctx, sessionID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get session, session ID: %s, error: %v", sessionID, err)
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			c.Error(errors.NewNotFoundError("Session not found"))
+		}
+		return
+	}
+
+	assistantMessage, err := h.messageService.GetMessage(ctx, sessionID, messageID)
+	if err != nil || assistantMessage == nil {
+		logger.Warnf(ctx, "Message not found, session ID: %s, message ID: %s", sessionID, messageID)
+		c.Error(errors.NewNotFoundError("Message not found"))
+		return
+	}
+
+	approval, err := h.sessionService.ResolveAgentApproval(ctx, sessionID, messageID, request.Approved, request.Reason)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": messageID,
+		})
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			c.Error(errors.NewInternalServerError(err.Error()))
+		}
+		return
+	}
+
+	// Resume in the background, events are appended to the stream of the original message
+	eventBus := event.NewEventBus()
+	asyncCtx := logger.CloneContext(ctx)
+	h.setupStreamHandler(asyncCtx, sessionID, messageID, assistantMessage.RequestID, assistantMessage, eventBus)
+
+	// The message stays incomplete if the run pauses again
+	var paused atomic.Bool
+	eventBus.On(event.EventAgentApprovalRequired, func(ctx context.Context, evt event.Event) error {
+		paused.Store(true)
+		return nil
+	})
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				buf := make([]byte, 1024)
+				runtime.Stack(buf, true)
+				logger.ErrorWithFields(asyncCtx,
+					errors.NewInternalServerError(fmt.Sprintf("Agent resume panicked: %v\n%s", r, string(buf))),
+					map[string]interface{}{"session_id": sessionID})
+			}
+			if !paused.Load() {
+				h.completeAssistantMessage(asyncCtx, assistantMessage)
+			}
+			logger.Infof(asyncCtx, "Agent resume completed for session: %s", sessionID)
+		}()
+
+		if err := h.sessionService.ResumeAgentQA(asyncCtx, session, approval, eventBus); err != nil {
+			logger.ErrorWithFields(asyncCtx, err, nil)
+			eventBus.Emit(asyncCtx, event.Event{
+				Type:      event.EventError,
+				SessionID: sessionID,
+				Data: event.ErrorData{
+					Error:     err.Error(),
+					Stage:     "agent_execution",
+					SessionID: sessionID,
+				},
+			})
+		}
+	}()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    approval,
+	})
+}
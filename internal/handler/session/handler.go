@@ -121,7 +121,7 @@ func (h *Handler) GetSession(c *gin.Context) {
 	logger.Info(ctx, "Start retrieving session")
 
 	// Get session ID from URL parameter
-	id := secutils.SanitizeForLog(c.Param("id"))
+	id := secutils.SanitizeForLog(c.Param("session_id"))
 	if id == "" {
 		logger.Error(ctx, "Session ID is empty")
 		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
@@ -152,12 +152,13 @@ func (h *Handler) GetSession(c *gin.Context) {
 
 // GetSessionsByTenant godoc
 // @Summary      获取会话列表
-// @Description  获取当前租户的会话列表，支持分页
+// @Description  获取当前用户的会话列表，支持分页；使用 API Key 访问时返回租户下的全部会话。shared=true 时返回其他用户分享给当前用户的会话
 // @Tags         会话
 // @Accept       json
 // @Produce      json
-// @Param        page       query     int  false  "页码"
-// @Param        page_size  query     int  false  "每页数量"
+// @Param        page       query     int   false  "页码"
+// @Param        page_size  query     int   false  "每页数量"
+// @Param        shared     query     bool  false  "是否只返回分享给当前用户的会话"
 // @Success      200        {object}  map[string]interface{}  "会话列表"
 // @Failure      400        {object}  errors.AppError         "请求参数错误"
 // @Security     Bearer
@@ -175,10 +176,20 @@ func (h *Handler) GetSessionsByTenant(c *gin.Context) {
 	}
 
 	// Use paginated query to get sessions
-	result, err := h.sessionService.GetPagedSessionsByTenant(ctx, &pagination)
+	var result *types.PageResult
+	var err error
+	if c.Query("shared") == "true" {
+		result, err = h.sessionService.GetPagedSharedSessions(ctx, &pagination)
+	} else {
+		result, err = h.sessionService.GetPagedSessionsByTenant(ctx, &pagination)
+	}
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
-		c.Error(errors.NewInternalServerError(err.Error()))
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			c.Error(errors.NewInternalServerError(err.Error()))
+		}
 		return
 	}
 
@@ -209,7 +220,7 @@ func (h *Handler) UpdateSession(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	// Get session ID from URL parameter
-	id := secutils.SanitizeForLog(c.Param("id"))
+	id := secutils.SanitizeForLog(c.Param("session_id"))
 	if id == "" {
 		logger.Error(ctx, "Session ID is empty")
 		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
@@ -242,6 +253,10 @@ func (h *Handler) UpdateSession(c *gin.Context) {
 			c.Error(errors.NewNotFoundError(err.Error()))
 			return
 		}
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
 		return
@@ -271,7 +286,7 @@ func (h *Handler) DeleteSession(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	// Get session ID from URL parameter
-	id := secutils.SanitizeForLog(c.Param("id"))
+	id := secutils.SanitizeForLog(c.Param("session_id"))
 	if id == "" {
 		logger.Error(ctx, "Session ID is empty")
 		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
@@ -285,6 +300,10 @@ func (h *Handler) DeleteSession(c *gin.Context) {
 			c.Error(errors.NewNotFoundError(err.Error()))
 			return
 		}
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
 		return
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/application/service"
+	"github.com/Tencent/WeKnora/internal/middleware"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type accessSessionRepo struct {
+	interfaces.SessionRepository
+	sessions map[string]*types.Session
+}
+
+func (r *accessSessionRepo) Get(ctx context.Context, tenantID uint64, id string) (*types.Session, error) {
+	session := *r.sessions[id]
+	return &session, nil
+}
+
+func (r *accessSessionRepo) Update(ctx context.Context, session *types.Session) error {
+	return nil
+}
+
+// accessShareRepo shares the session "shared" with the user "reader"
+type accessShareRepo struct {
+	interfaces.SessionShareRepository
+}
+
+func (r *accessShareRepo) IsSharedWith(ctx context.Context, tenantID uint64, sessionID, userID string) (bool, error) {
+	return sessionID == "shared" && userID == "reader", nil
+}
+
+func TestSessionAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	sessionRepo := &accessSessionRepo{sessions: map[string]*types.Session{
+		"owned":  {ID: "owned", TenantID: 1, UserID: "owner"},
+		"shared": {ID: "shared", TenantID: 1, UserID: "owner"},
+		"tenant": {ID: "tenant", TenantID: 1},
+	}}
+	sessionService := service.NewSessionService(nil, sessionRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, nil, &accessShareRepo{}, nil)
+	handler := NewHandler(sessionService, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name      string
+		user      string
+		role      types.TenantRole
+		session   string
+		wantRead  int
+		wantWrite int
+	}{
+		{name: "owner", user: "owner", role: types.TenantRoleViewer, session: "owned",
+			wantRead: http.StatusOK, wantWrite: http.StatusOK},
+		{name: "other member", user: "member", role: types.TenantRoleEditor, session: "owned",
+			wantRead: http.StatusNotFound, wantWrite: http.StatusNotFound},
+		{name: "member the session is shared with", user: "reader", role: types.TenantRoleViewer, session: "shared",
+			wantRead: http.StatusOK, wantWrite: http.StatusForbidden},
+		{name: "admin on a member session", user: "admin", role: types.TenantRoleAdmin, session: "owned",
+			wantRead: http.StatusNotFound, wantWrite: http.StatusNotFound},
+		{name: "member on a session without owner", user: "member", role: types.TenantRoleEditor, session: "tenant",
+			wantRead: http.StatusNotFound, wantWrite: http.StatusNotFound},
+		{name: "admin on a session without owner", user: "admin", role: types.TenantRoleAdmin, session: "tenant",
+			wantRead: http.StatusOK, wantWrite: http.StatusOK},
+		{name: "API key on a member session", session: "owned",
+			wantRead: http.StatusOK, wantWrite: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(middleware.ErrorHandler(), func(c *gin.Context) {
+				ctx := context.WithValue(c.Request.Context(), types.TenantIDContextKey, uint64(1))
+				ctx = context.WithValue(ctx, types.TenantRoleContextKey, tt.role)
+				if tt.user != "" {
+					ctx = context.WithValue(ctx, types.UserContextKey, &types.User{ID: tt.user, TenantID: 1})
+				}
+				c.Set(types.TenantIDContextKey.String(), uint64(1))
+				c.Request = c.Request.WithContext(ctx)
+			})
+			router.GET("/sessions/:session_id", handler.GetSession)
+			router.PUT("/sessions/:session_id", handler.UpdateSession)
+
+			read := httptest.NewRecorder()
+			router.ServeHTTP(read, httptest.NewRequest(http.MethodGet, "/sessions/"+tt.session, nil))
+			if read.Code != tt.wantRead {
+				t.Errorf("read status = %d, want %d", read.Code, tt.wantRead)
+			}
+
+			write := httptest.NewRecorder()
+			router.ServeHTTP(write, httptest.NewRequest(http.MethodPut, "/sessions/"+tt.session,
+				strings.NewReader(`{"title":"renamed"}`)))
+			if write.Code != tt.wantWrite {
+				t.Errorf("write status = %d, want %d", write.Code, tt.wantWrite)
+			}
+		})
+	}
+}
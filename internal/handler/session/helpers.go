@@ -47,6 +47,13 @@ func buildStreamResponse(evt interfaces.StreamEvent, requestID string) *types.St
 		Data:         evt.Data,
 	}
 
+	// Model that generated the answer
+	if evt.Type == types.ResponseTypeAnswer {
+		if modelID, ok := evt.Data["model_id"].(string); ok {
+			response.ModelID = modelID
+		}
+	}
+
 	// Extract session_id and assistant_message_id for agent_query events
 	if evt.Type == types.ResponseTypeAgentQuery {
 		if sid, ok := evt.Data["session_id"].(string); ok {
@@ -0,0 +1,563 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/middleware"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// chatCompletionTitleLength is the maximum length of the title of sessions created by chat completions
+const chatCompletionTitleLength = 50
+
+// ChatMessageContent is the content of a chat completion message, either a string
+// or an array of content parts of which only the text parts are kept
+type ChatMessageContent string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (m *ChatMessageContent) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*m = ChatMessageContent(text)
+		return nil
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return fmt.Errorf("message content must be a string or an array of content parts")
+	}
+	texts := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part.Type == "text" {
+			texts = append(texts, part.Text)
+		}
+	}
+	*m = ChatMessageContent(strings.Join(texts, "\n"))
+	return nil
+}
+
+// ChatCompletionMessage is a message of a chat completion request
+type ChatCompletionMessage struct {
+	Role    string             `json:"role"    binding:"required"` // "system", "user", "assistant" or "tool"
+	Content ChatMessageContent `json:"content"`
+}
+
+// ChatCompletionRequest is an OpenAI compatible chat completion request.
+// The model is the ID of the custom agent answering the request.
+type ChatCompletionRequest struct {
+	Model    string                  `json:"model"    binding:"required"` // Custom agent ID
+	Messages []ChatCompletionMessage `json:"messages" binding:"required"` // Conversation, the last message is the query
+	Stream   bool                    `json:"stream"`                      // Whether to stream chunks over SSE
+	User     string                  `json:"user"`                        // End user identifier, only logged
+
+	// WeKnora extensions
+	SessionID        string   `json:"session_id"`         // Continue an existing session instead of the messages history
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids"` // Knowledge bases to search, overrides the agent's
+	KnowledgeIDs     []string `json:"knowledge_ids"`      // Knowledge (files) to search
+	WebSearchEnabled bool     `json:"web_search_enabled"` // Whether web search is enabled for this request
+}
+
+// chatCompletionDelta is the incremental message of a chat completion chunk
+type chatCompletionDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// chatCompletionChunkChoice is a choice of a chat completion chunk
+type chatCompletionChunkChoice struct {
+	Index        int                 `json:"index"`
+	Delta        chatCompletionDelta `json:"delta"`
+	FinishReason *string             `json:"finish_reason"`
+}
+
+// chatCompletionChunk is a streamed chat completion chunk
+type chatCompletionChunk struct {
+	ID      string                      `json:"id"`
+	Object  string                      `json:"object"`
+	Created int64                       `json:"created"`
+	Model   string                      `json:"model"`
+	Choices []chatCompletionChunkChoice `json:"choices"`
+
+	// WeKnora extensions
+	SessionID  string           `json:"session_id,omitempty"`
+	References types.References `json:"references,omitempty"`
+}
+
+// chatCompletionResponseMessage is the message of a chat completion choice
+type chatCompletionResponseMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatCompletionChoice is a choice of a chat completion
+type chatCompletionChoice struct {
+	Index        int                           `json:"index"`
+	Message      chatCompletionResponseMessage `json:"message"`
+	FinishReason string                        `json:"finish_reason"`
+}
+
+// chatCompletionResponse is a non-streamed chat completion
+type chatCompletionResponse struct {
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"`
+	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
+	Choices []chatCompletionChoice `json:"choices"`
+
+	// WeKnora extensions
+	SessionID  string           `json:"session_id"`
+	References types.References `json:"references"`
+}
+
+// chatCompletionModel is a custom agent listed as a model
+type chatCompletionModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+	Name    string `json:"name"`
+}
+
+// chatCompletionEvent is an event of a running chat completion
+type chatCompletionEvent struct {
+	content string
+	done    bool
+	paused  bool
+	err     error
+}
+
+// ListModels godoc
+// @Summary      列出模型（OpenAI 兼容）
+// @Description  以 OpenAI 模型列表格式列出当前租户的智能体，模型ID即智能体ID
+// @Tags         OpenAI兼容
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "模型列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /v1/models [get]
+func (h *Handler) ListModels(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	agents, err := h.customAgentService.ListAgents(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError("Failed to list agents").WithDetails(err.Error()))
+		return
+	}
+
+	models := make([]chatCompletionModel, 0, len(agents))
+	for _, agent := range agents {
+		models = append(models, chatCompletionModel{
+			ID:      agent.ID,
+			Object:  "model",
+			Created: agent.CreatedAt.Unix(),
+			OwnedBy: "weknora",
+			Name:    agent.Name,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"object": "list",
+		"data":   models,
+	})
+}
+
+// ChatCompletions godoc
+// @Summary      对话补全（OpenAI 兼容）
+// @Description  OpenAI Chat Completions 兼容接口，model 为智能体ID，按智能体模式执行知识问答或 Agent 问答。
+// @Description  stream 为 true 时以 SSE 返回 chat.completion.chunk，知识引用放在扩展字段 references 中
+// @Tags         OpenAI兼容
+// @Accept       json
+// @Produce      json,text/event-stream
+// @Param        request  body      ChatCompletionRequest   true  "对话补全请求"
+// @Success      200      {object}  map[string]interface{}  "对话补全结果"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "智能体或会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /v1/chat/completions [post]
+func (h *Handler) ChatCompletions(c *gin.Context) {
+	ctx := logger.CloneContext(c.Request.Context())
+
+	var request ChatCompletionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error(ctx, "Failed to parse request data", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	query, history, err := splitChatMessages(request.Messages)
+	if err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	model := secutils.SanitizeForLog(request.Model)
+	logger.Infof(ctx, "[ChatCompletions] Request: model=%s, messages=%d, stream=%v, session_id=%s, user=%s",
+		model, len(request.Messages), request.Stream,
+		secutils.SanitizeForLog(request.SessionID), secutils.SanitizeForLog(request.User))
+
+	customAgent, err := h.customAgentService.GetAgentByID(ctx, request.Model)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get custom agent for model %s: %v", model, err)
+		c.Error(errors.NewNotFoundError(fmt.Sprintf("Model not found: %s", model)))
+		return
+	}
+
+	session, err := h.chatCompletionSession(ctx, request.SessionID, query, history)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	requestID := getRequestID(c)
+	reqCtx := &qaRequestContext{
+		ctx:         ctx,
+		c:           c,
+		sessionID:   session.ID,
+		requestID:   secutils.SanitizeForLog(requestID),
+		query:       secutils.SanitizeForLog(query),
+		session:     session,
+		customAgent: customAgent,
+		assistantMessage: &types.Message{
+			SessionID:   session.ID,
+			Role:        "assistant",
+			RequestID:   requestID,
+			IsCompleted: false,
+		},
+		knowledgeBaseIDs: secutils.SanitizeForLogArray(request.KnowledgeBaseIDs),
+		knowledgeIDs:     secutils.SanitizeForLogArray(request.KnowledgeIDs),
+		webSearchEnabled: request.WebSearchEnabled,
+	}
+
+	events, cancel, err := h.startChatCompletion(reqCtx)
+	if err != nil {
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	defer cancel()
+
+	completionID := "chatcmpl-" + reqCtx.assistantMessage.ID
+	if request.Stream {
+		h.streamChatCompletion(reqCtx, completionID, model, events)
+	} else {
+		h.writeChatCompletion(reqCtx, completionID, model, events)
+	}
+}
+
+// splitChatMessages returns the query, the last user message, and the user and assistant messages before it
+func splitChatMessages(messages []ChatCompletionMessage) (string, []ChatCompletionMessage, error) {
+	if len(messages) == 0 {
+		return "", nil, fmt.Errorf("messages cannot be empty")
+	}
+	last := messages[len(messages)-1]
+	if last.Role != "user" || strings.TrimSpace(string(last.Content)) == "" {
+		return "", nil, fmt.Errorf("the last message must be a non-empty user message")
+	}
+
+	var history []ChatCompletionMessage
+	for _, message := range messages[:len(messages)-1] {
+		if (message.Role == "user" || message.Role == "assistant") && message.Content != "" {
+			history = append(history, message)
+		}
+	}
+	return string(last.Content), history, nil
+}
+
+// chatCompletionSession returns the session a chat completion runs in: the requested session,
+// or a new one seeded with the conversation history of the request
+func (h *Handler) chatCompletionSession(ctx context.Context,
+	sessionID, query string, history []ChatCompletionMessage,
+) (*types.Session, error) {
+	if sessionID != "" {
+		return h.sessionService.GetWritableSession(ctx, secutils.SanitizeForLog(sessionID))
+	}
+
+	tenantID, _ := ctx.Value(types.TenantIDContextKey).(uint64)
+	title := []rune(query)
+	if len(title) > chatCompletionTitleLength {
+		title = title[:chatCompletionTitleLength]
+	}
+	session, err := h.sessionService.CreateSession(ctx, &types.Session{
+		TenantID: tenantID,
+		Title:    string(title),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Earlier turns are stored as completed messages, a user message and the assistant
+	// message answering it share a request ID so that they are loaded as one round
+	createdAt := time.Now().Add(-time.Duration(len(history)) * time.Millisecond)
+	var roundID string
+	for i, message := range history {
+		if message.Role == "user" || roundID == "" {
+			roundID = uuid.New().String()
+		}
+		if _, err := h.messageService.CreateMessage(ctx, &types.Message{
+			SessionID:   session.ID,
+			Role:        message.Role,
+			Content:     string(message.Content),
+			RequestID:   roundID,
+			CreatedAt:   createdAt.Add(time.Duration(i) * time.Millisecond),
+			IsCompleted: true,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return session, nil
+}
+
+// startChatCompletion creates the messages of a chat completion and runs the agent in the
+// background, the returned channel receives its answer until an event marks the end of the run
+func (h *Handler) startChatCompletion(
+	reqCtx *qaRequestContext,
+) (<-chan chatCompletionEvent, context.CancelFunc, error) {
+	ctx := reqCtx.ctx
+	sessionID := reqCtx.sessionID
+
+	if err := h.createUserMessage(ctx, sessionID, reqCtx.query, reqCtx.requestID, nil); err != nil {
+		return nil, nil, err
+	}
+	assistantMessage, err := h.createAssistantMessage(ctx, reqCtx.assistantMessage)
+	if err != nil {
+		return nil, nil, err
+	}
+	reqCtx.assistantMessage = assistantMessage
+
+	eventBus := event.NewEventBus()
+	asyncCtx, cancelRun := context.WithCancel(logger.CloneContext(ctx))
+
+	// The run is also written to the stream manager so that it can be followed from the web UI
+	h.writeAgentQueryEvent(ctx, sessionID, assistantMessage.ID)
+	reqCtx.streamHandler = h.setupStreamHandler(
+		asyncCtx, sessionID, assistantMessage.ID, reqCtx.requestID, assistantMessage, eventBus,
+	)
+
+	// finished is closed once the client stops reading events, so that late events do not block the run.
+	// An agent run is only cancelled when the client goes away, it ends on its own once AgentQA returns.
+	agentMode := reqCtx.customAgent.IsAgentMode()
+	events := make(chan chatCompletionEvent, 64)
+	finished := make(chan struct{})
+	send := func(evt chatCompletionEvent) {
+		select {
+		case events <- evt:
+		case <-finished:
+		}
+	}
+	cancel := func() {
+		close(finished)
+		if !agentMode || reqCtx.c.Request.Context().Err() != nil {
+			cancelRun()
+		}
+	}
+
+	eventBus.On(event.EventAgentFinalAnswer, func(ctx context.Context, evt event.Event) error {
+		if data, ok := evt.Data.(event.AgentFinalAnswerData); ok {
+			send(chatCompletionEvent{content: data.Content, done: data.Done})
+		}
+		return nil
+	})
+	eventBus.On(event.EventError, func(ctx context.Context, evt event.Event) error {
+		if data, ok := evt.Data.(event.ErrorData); ok {
+			send(chatCompletionEvent{err: fmt.Errorf("%s", data.Error)})
+		}
+		return nil
+	})
+	eventBus.On(event.EventAgentApprovalRequired, func(ctx context.Context, evt event.Event) error {
+		send(chatCompletionEvent{paused: true})
+		return nil
+	})
+
+	go func() {
+		if agentMode {
+			defer cancelRun()
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				buf := make([]byte, 10240)
+				runtime.Stack(buf, true)
+				err := errors.NewInternalServerError(fmt.Sprintf("Chat completion panicked: %v\n%s", r, string(buf)))
+				logger.ErrorWithFields(asyncCtx, err, map[string]interface{}{"session_id": sessionID})
+				send(chatCompletionEvent{err: err})
+			}
+		}()
+
+		var err error
+		if agentMode {
+			err = h.sessionService.AgentQA(asyncCtx, reqCtx.session, reqCtx.query, assistantMessage.ID,
+				eventBus, reqCtx.customAgent, reqCtx.knowledgeBaseIDs, reqCtx.knowledgeIDs)
+		} else {
+			err = h.sessionService.KnowledgeQA(asyncCtx, reqCtx.session, reqCtx.query,
+				reqCtx.knowledgeBaseIDs, reqCtx.knowledgeIDs, assistantMessage.ID, "",
+				reqCtx.webSearchEnabled, eventBus, reqCtx.customAgent)
+		}
+		if err != nil {
+			logger.ErrorWithFields(asyncCtx, err, map[string]interface{}{"session_id": sessionID})
+			send(chatCompletionEvent{err: err})
+			return
+		}
+		// AgentQA returns when the run ends, KnowledgeQA once the answer starts streaming
+		if agentMode {
+			send(chatCompletionEvent{done: true})
+		}
+	}()
+
+	return events, cancel, nil
+}
+
+// finishChatCompletion stores the answer of a chat completion in the assistant message
+func (h *Handler) finishChatCompletion(reqCtx *qaRequestContext, answer string) {
+	reqCtx.streamHandler.UpdateAssistantMessage(func(message *types.Message) {
+		message.Content = answer
+		h.completeAssistantMessage(reqCtx.ctx, message)
+	})
+	logger.Infof(reqCtx.ctx, "Chat completion finished, session ID: %s, message ID: %s",
+		reqCtx.sessionID, reqCtx.assistantMessage.ID)
+}
+
+// writeChatCompletion waits for the end of a chat completion and writes it as a single response
+func (h *Handler) writeChatCompletion(reqCtx *qaRequestContext, completionID, model string,
+	events <-chan chatCompletionEvent,
+) {
+	c := reqCtx.c
+	var answer strings.Builder
+	finishReason := "stop"
+
+wait:
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			logger.Warnf(reqCtx.ctx, "Client disconnected during chat completion, session ID: %s", reqCtx.sessionID)
+			h.finishChatCompletion(reqCtx, answer.String())
+			return
+		case evt := <-events:
+			if evt.err != nil {
+				h.finishChatCompletion(reqCtx, answer.String())
+				c.Error(errors.NewInternalServerError("Chat completion failed").WithDetails(evt.err.Error()))
+				return
+			}
+			answer.WriteString(evt.content)
+			if evt.paused {
+				// A run paused for tool approval is completed later by ApproveToolCalls
+				finishReason = "tool_approval"
+				break wait
+			}
+			if evt.done {
+				h.finishChatCompletion(reqCtx, answer.String())
+				break wait
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, chatCompletionResponse{
+		ID:      completionID,
+		Object:  "chat.completion",
+		Created: reqCtx.assistantMessage.CreatedAt.Unix(),
+		Model:   model,
+		Choices: []chatCompletionChoice{{
+			Message:      chatCompletionResponseMessage{Role: "assistant", Content: answer.String()},
+			FinishReason: finishReason,
+		}},
+		SessionID:  reqCtx.sessionID,
+		References: reqCtx.streamHandler.KnowledgeReferences(),
+	})
+}
+
+// streamChatCompletion streams a chat completion as chat.completion.chunk server-sent events
+func (h *Handler) streamChatCompletion(reqCtx *qaRequestContext, completionID, model string,
+	events <-chan chatCompletionEvent,
+) {
+	c := reqCtx.c
+	setSSEHeaders(c)
+
+	created := reqCtx.assistantMessage.CreatedAt.Unix()
+	writeChunk := func(delta chatCompletionDelta, finishReason string, references types.References) {
+		chunk := chatCompletionChunk{
+			ID:      completionID,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   model,
+			Choices: []chatCompletionChunkChoice{{Delta: delta}},
+		}
+		if finishReason != "" {
+			chunk.Choices[0].FinishReason = &finishReason
+		}
+		if delta.Role != "" {
+			chunk.SessionID = reqCtx.sessionID
+		}
+		chunk.References = references
+		writeSSEData(c, chunk)
+	}
+
+	writeChunk(chatCompletionDelta{Role: "assistant"}, "", nil)
+
+	var answer strings.Builder
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			logger.Warnf(reqCtx.ctx, "Client disconnected during chat completion, session ID: %s", reqCtx.sessionID)
+			h.finishChatCompletion(reqCtx, answer.String())
+			return false
+		case evt := <-events:
+			if evt.err != nil {
+				h.finishChatCompletion(reqCtx, answer.String())
+				writeSSEData(c, middleware.OpenAIError(http.StatusInternalServerError, evt.err.Error()))
+				return false
+			}
+			if evt.content != "" {
+				answer.WriteString(evt.content)
+				writeChunk(chatCompletionDelta{Content: evt.content}, "", nil)
+			}
+			if !evt.done && !evt.paused {
+				return true
+			}
+
+			finishReason := "stop"
+			if evt.paused {
+				// A run paused for tool approval is completed later by ApproveToolCalls
+				finishReason = "tool_approval"
+			} else {
+				h.finishChatCompletion(reqCtx, answer.String())
+			}
+			if refs := reqCtx.streamHandler.KnowledgeReferences(); len(refs) > 0 {
+				writeChunk(chatCompletionDelta{}, "", refs)
+			}
+			writeChunk(chatCompletionDelta{}, finishReason, nil)
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			c.Writer.Flush()
+			return false
+		}
+	})
+}
+
+// writeSSEData writes a data-only server-sent event as used by OpenAI compatible streams
+func writeSSEData(c *gin.Context, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.Errorf(c.Request.Context(), "Failed to marshal SSE data: %v", err)
+		return
+	}
+	fmt.Fprintf(c.Writer, "data: %s\n\n", payload)
+	c.Writer.Flush()
+}
@@ -0,0 +1,219 @@
+package session
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+func TestSplitChatMessages(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantQuery   string
+		wantHistory int
+		wantErr     bool
+	}{
+		{
+			name:      "single user message",
+			body:      `[{"role":"user","content":"hello"}]`,
+			wantQuery: "hello",
+		},
+		{
+			name: "history without system and tool messages",
+			body: `[{"role":"system","content":"be brief"},{"role":"user","content":"hi"},
+				{"role":"assistant","content":"hello"},{"role":"tool","content":"{}"},{"role":"user","content":"again"}]`,
+			wantQuery:   "again",
+			wantHistory: 2,
+		},
+		{
+			name:      "content parts",
+			body:      `[{"role":"user","content":[{"type":"text","text":"a"},{"type":"image_url"},{"type":"text","text":"b"}]}]`,
+			wantQuery: "a\nb",
+		},
+		{
+			name:    "last message from assistant",
+			body:    `[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"}]`,
+			wantErr: true,
+		},
+		{
+			name:    "empty query",
+			body:    `[{"role":"user","content":null}]`,
+			wantErr: true,
+		},
+		{
+			name:    "no messages",
+			body:    `[]`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []ChatCompletionMessage
+			if err := json.Unmarshal([]byte(tt.body), &messages); err != nil {
+				t.Fatalf("unmarshal messages: %v", err)
+			}
+			query, history, err := splitChatMessages(messages)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if query != tt.wantQuery {
+				t.Errorf("got query %q, want %q", query, tt.wantQuery)
+			}
+			if len(history) != tt.wantHistory {
+				t.Errorf("got %d history messages, want %d", len(history), tt.wantHistory)
+			}
+		})
+	}
+}
+
+// completionSessionService runs an agent that keeps updating the assistant message after its answer is done
+type completionSessionService struct {
+	interfaces.SessionService
+}
+
+func (s *completionSessionService) CreateSession(ctx context.Context, session *types.Session) (*types.Session, error) {
+	session.ID = "s1"
+	return session, nil
+}
+
+func (s *completionSessionService) AgentQA(ctx context.Context, session *types.Session, query string,
+	assistantMessageID string, eventBus *event.EventBus, customAgent *types.CustomAgent,
+	knowledgeBaseIDs []string, knowledgeIDs []string,
+) error {
+	first := &types.SearchResult{ID: "chunk-1", Content: "first"}
+	second := &types.SearchResult{ID: "chunk-2", Content: "second"}
+	emit := func(eventType event.EventType, data interface{}) {
+		eventBus.Emit(ctx, event.Event{Type: eventType, SessionID: session.ID, Data: data})
+	}
+	emit(event.EventAgentReferences, event.AgentReferencesData{References: []*types.SearchResult{first}})
+	emit(event.EventAgentFinalAnswer, event.AgentFinalAnswerData{Content: "hello"})
+	emit(event.EventAgentFinalAnswer, event.AgentFinalAnswerData{Done: true})
+	emit(event.EventAgentComplete, event.AgentCompleteData{
+		MessageID:     assistantMessageID,
+		KnowledgeRefs: []interface{}{first, second},
+		AgentSteps:    []types.AgentStep{{Iteration: 1}},
+	})
+	return nil
+}
+
+type completionMessageService struct {
+	interfaces.MessageService
+	mu      sync.Mutex
+	answers []string
+}
+
+func (s *completionMessageService) CreateMessage(ctx context.Context, message *types.Message) (*types.Message, error) {
+	message.ID = uuid.New().String()
+	return message, nil
+}
+
+func (s *completionMessageService) UpdateMessage(ctx context.Context, message *types.Message) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var stored types.Message
+	_ = json.Unmarshal(payload, &stored)
+	s.answers = append(s.answers, stored.Content)
+	return nil
+}
+
+type completionStreamManager struct {
+	interfaces.StreamManager
+}
+
+func (m *completionStreamManager) AppendEvent(ctx context.Context,
+	sessionID, messageID string, event interfaces.StreamEvent,
+) error {
+	return nil
+}
+
+type completionAgentService struct {
+	interfaces.CustomAgentService
+}
+
+func (s *completionAgentService) GetAgentByID(ctx context.Context, id string) (*types.CustomAgent, error) {
+	return &types.CustomAgent{ID: id, Config: types.CustomAgentConfig{AgentMode: types.AgentModeSmartReasoning}}, nil
+}
+
+// TestChatCompletionsReferences runs the handler while the agent keeps updating the assistant message,
+// run with -race to check that the references are read safely
+func TestChatCompletionsReferences(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, stream := range []bool{false, true} {
+		t.Run(map[bool]string{false: "response", true: "stream"}[stream], func(t *testing.T) {
+			messageService := &completionMessageService{}
+			handler := NewHandler(&completionSessionService{}, messageService, &completionStreamManager{},
+				nil, nil, &completionAgentService{})
+			router := gin.New()
+			router.POST("/v1/chat/completions", handler.ChatCompletions)
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			body, _ := json.Marshal(map[string]interface{}{
+				"model":    "agent-1",
+				"stream":   stream,
+				"messages": []map[string]string{{"role": "user", "content": "hi"}},
+			})
+			resp, err := http.Post(server.URL+"/v1/chat/completions", "application/json", strings.NewReader(string(body)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d", resp.StatusCode)
+			}
+
+			var references types.References
+			if stream {
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+					if !ok || data == "[DONE]" {
+						continue
+					}
+					var chunk chatCompletionChunk
+					if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+						t.Fatalf("unmarshal chunk %s: %v", data, err)
+					}
+					references = append(references, chunk.References...)
+				}
+			} else {
+				var completion chatCompletionResponse
+				if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+					t.Fatal(err)
+				}
+				if got := completion.Choices[0].Message.Content; got != "hello" {
+					t.Errorf("answer = %q, want hello", got)
+				}
+				references = completion.References
+			}
+
+			if len(references) == 0 || references[0].ID != "chunk-1" {
+				t.Errorf("references = %v, want chunk-1 first", references)
+			}
+			messageService.mu.Lock()
+			defer messageService.mu.Unlock()
+			if len(messageService.answers) == 0 || messageService.answers[0] != "hello" {
+				t.Errorf("stored answers = %q, want hello", messageService.answers)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"runtime"
+	"sync/atomic"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/errors"
@@ -31,6 +32,8 @@ type qaRequestContext struct {
 	summaryModelID   string
 	webSearchEnabled bool
 	mentionedItems   types.MentionedItems
+	// streamHandler guards the assistant message while the run updates it in the background
+	streamHandler *AgentStreamHandler
 }
 
 // parseQARequest parses and validates a QA request, returns the request context
@@ -64,10 +67,13 @@ func (h *Handler) parseQARequest(c *gin.Context, logPrefix string) (*qaRequestCo
 			logPrefix, sessionID, secutils.SanitizeForLog(string(requestJSON)))
 	}
 
-	// Get session
-	session, err := h.sessionService.GetSession(ctx, sessionID)
+	// Get session, shared sessions are read-only
+	session, err := h.sessionService.GetWritableSession(ctx, sessionID)
 	if err != nil {
 		logger.Errorf(ctx, "Failed to get session, session ID: %s, error: %v", sessionID, err)
+		if appErr, ok := errors.IsAppError(err); ok {
+			return nil, nil, appErr
+		}
 		return nil, nil, errors.NewNotFoundError("Session not found")
 	}
 
@@ -211,6 +217,11 @@ func (h *Handler) SearchKnowledge(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("At least one knowledge_base_id, knowledge_base_ids or knowledge_ids must be provided"))
 		return
 	}
+	if err := request.Filter.Validate(); err != nil {
+		logger.Error(ctx, "Invalid metadata filter", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
 
 	logger.Infof(
 		ctx,
@@ -221,7 +232,8 @@ func (h *Handler) SearchKnowledge(c *gin.Context) {
 	)
 
 	// Directly call knowledge retrieval service without LLM summarization
-	searchResults, err := h.sessionService.SearchKnowledge(ctx, knowledgeBaseIDs, request.KnowledgeIDs, request.Query)
+	searchResults, err := h.sessionService.SearchKnowledge(ctx, knowledgeBaseIDs, request.KnowledgeIDs, request.Query,
+		request.Filter)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError(err.Error()))
@@ -424,6 +436,13 @@ func (h *Handler) executeAgentModeQA(reqCtx *qaRequestContext) {
 	// Setup SSE stream
 	streamCtx := h.setupSSEStream(reqCtx)
 
+	// A run paused for tool approval is completed later by ApproveToolCalls
+	var paused atomic.Bool
+	streamCtx.eventBus.On(event.EventAgentApprovalRequired, func(ctx context.Context, evt event.Event) error {
+		paused.Store(true)
+		return nil
+	})
+
 	// Execute AgentQA asynchronously
 	go func() {
 		defer func() {
@@ -434,7 +453,9 @@ func (h *Handler) executeAgentModeQA(reqCtx *qaRequestContext) {
 					errors.NewInternalServerError(fmt.Sprintf("Agent QA service panicked: %v\n%s", r, string(buf))),
 					map[string]interface{}{"session_id": sessionID})
 			}
-			h.completeAssistantMessage(streamCtx.asyncCtx, streamCtx.assistantMessage)
+			if !paused.Load() {
+				h.completeAssistantMessage(streamCtx.asyncCtx, streamCtx.assistantMessage)
+			}
 			logger.Infof(streamCtx.asyncCtx, "Agent QA service completed for session: %s", sessionID)
 		}()
 
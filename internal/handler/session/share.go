@@ -0,0 +1,133 @@
+package session
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ShareSession godoc
+// @Summary      分享会话
+// @Description  将会话以只读方式分享给租户内的指定用户（share_type=user）或租户内所有用户（share_type=tenant）。仅会话所有者可操作
+// @Tags         会话
+// @Accept       json
+// @Produce      json
+// @Param        session_id  path      string               true  "会话ID"
+// @Param        request     body      ShareSessionRequest  true  "分享请求"
+// @Success      201         {object}  map[string]interface{}  "分享记录"
+// @Failure      400         {object}  errors.AppError         "请求参数错误"
+// @Failure      404         {object}  errors.AppError         "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/shares [post]
+func (h *Handler) ShareSession(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	if sessionID == "" {
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	var request ShareSessionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error(ctx, "Failed to parse share request", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	share, err := h.sessionService.ShareSession(ctx, sessionID, request.ShareType, secutils.SanitizeForLog(request.UserID))
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"session_id": sessionID})
+		c.Error(shareServiceError(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    share,
+	})
+}
+
+// ListSessionShares godoc
+// @Summary      获取会话分享列表
+// @Description  获取会话被分享的用户或租户。仅会话所有者可查看
+// @Tags         会话
+// @Accept       json
+// @Produce      json
+// @Param        session_id  path      string  true  "会话ID"
+// @Success      200         {object}  map[string]interface{}  "分享列表"
+// @Failure      404         {object}  errors.AppError         "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/shares [get]
+func (h *Handler) ListSessionShares(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	if sessionID == "" {
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	shares, err := h.sessionService.ListSessionShares(ctx, sessionID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"session_id": sessionID})
+		c.Error(shareServiceError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    shares,
+	})
+}
+
+// UnshareSession godoc
+// @Summary      取消会话分享
+// @Description  撤销会话的一条分享记录。仅会话所有者可操作
+// @Tags         会话
+// @Accept       json
+// @Produce      json
+// @Param        session_id  path      string  true  "会话ID"
+// @Param        share_id    path      string  true  "分享记录ID"
+// @Success      200         {object}  map[string]interface{}  "取消成功"
+// @Failure      404         {object}  errors.AppError         "会话或分享记录不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/shares/{share_id} [delete]
+func (h *Handler) UnshareSession(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	shareID := secutils.SanitizeForLog(c.Param("share_id"))
+	if sessionID == "" {
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	if err := h.sessionService.UnshareSession(ctx, sessionID, shareID); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+			"share_id":   shareID,
+		})
+		c.Error(shareServiceError(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Session share revoked",
+	})
+}
+
+// shareServiceError converts a session sharing error to an API error
+func shareServiceError(err error) *errors.AppError {
+	if err == errors.ErrSessionNotFound {
+		return errors.NewNotFoundError(err.Error())
+	}
+	if appErr, ok := errors.IsAppError(err); ok {
+		return appErr
+	}
+	return errors.NewInternalServerError(err.Error())
+}
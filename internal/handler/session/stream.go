@@ -237,8 +237,8 @@ func (h *Handler) StopSession(c *gin.Context) {
 		return
 	}
 
-	// Verify message belongs to the current tenant
-	session, err := h.sessionService.GetSession(ctx, sessionID)
+	// Verify message belongs to the current tenant and the user may stop it
+	session, err := h.sessionService.GetWritableSession(ctx, sessionID)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"session_id": sessionID,
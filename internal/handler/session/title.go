@@ -42,11 +42,15 @@ func (h *Handler) GenerateTitle(c *gin.Context) {
 		return
 	}
 
-	// Get session from database
-	session, err := h.sessionService.GetSession(ctx, sessionID)
+	// Get session from database, the title is stored on the session
+	session, err := h.sessionService.GetWritableSession(ctx, sessionID)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
-		c.Error(errors.NewInternalServerError(err.Error()))
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			c.Error(errors.NewInternalServerError(err.Error()))
+		}
 		return
 	}
 
@@ -77,9 +77,24 @@ type SearchKnowledgeRequest struct {
 	KnowledgeBaseID  string   `json:"knowledge_base_id"`                     // Single knowledge base ID (for backward compatibility)
 	KnowledgeBaseIDs []string `json:"knowledge_base_ids"`                    // IDs of knowledge bases to search (multi-KB support)
 	KnowledgeIDs     []string `json:"knowledge_ids"`                         // IDs of specific knowledge (files) to search
+	// Optional metadata filter (tag IDs, file types, created_at range, chunk metadata)
+	Filter *types.RetrieveFilter `json:"filter,omitempty"`
 }
 
 // StopSessionRequest represents the stop session request
 type StopSessionRequest struct {
 	MessageID string `json:"message_id" binding:"required"`
 }
+
+// ShareSessionRequest represents a request to share a session read-only
+type ShareSessionRequest struct {
+	ShareType types.SessionShareType `json:"share_type" binding:"required"` // "user" or "tenant"
+	UserID    string                 `json:"user_id"`                       // Target user, required for user shares
+}
+
+// ApproveToolCallsRequest represents the decision on tool calls an agent run is waiting on
+type ApproveToolCallsRequest struct {
+	MessageID string `json:"message_id" binding:"required"` // Assistant message of the paused agent run
+	Approved  bool   `json:"approved"`                      // true resumes the run, false aborts it
+	Reason    string `json:"reason"`                        // Optional operator comment
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 
@@ -87,7 +88,7 @@ func (h *TenantHandler) CreateTenant(c *gin.Context) {
 	)
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
-		"data":    createdTenant,
+		"data":    createdTenant.Redacted(),
 	})
 }
 
@@ -129,7 +130,50 @@ func (h *TenantHandler) GetTenant(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    tenant,
+		"data":    tenant.Redacted(),
+	})
+}
+
+// RevealTenantAPIKey godoc
+// @Summary      获取租户API Key
+// @Description  获取租户的完整API Key，查询接口只返回脱敏后的值
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "租户ID"
+// @Success      200  {object}  map[string]interface{}  "API Key"
+// @Failure      400  {object}  errors.AppError         "请求参数错误"
+// @Failure      404  {object}  errors.AppError         "租户不存在"
+// @Security     Bearer
+// @Router       /tenants/{id}/api-key/reveal [post]
+func (h *TenantHandler) RevealTenantAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Errorf(ctx, "Invalid tenant ID: %s", secutils.SanitizeForLog(c.Param("id")))
+		c.Error(errors.NewBadRequestError("Invalid tenant ID"))
+		return
+	}
+
+	tenant, err := h.service.GetTenantByID(ctx, id)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			logger.Error(ctx, "Failed to retrieve tenant: application error", appErr)
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to retrieve tenant").WithDetails(err.Error()))
+		}
+		return
+	}
+
+	logger.Infof(ctx, "Tenant API key revealed, ID: %d", id)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"api_key": string(tenant.APIKey),
+		},
 	})
 }
 
@@ -167,6 +211,19 @@ func (h *TenantHandler) UpdateTenant(c *gin.Context) {
 	logger.Infof(ctx, "Updating tenant, ID: %d, Name: %s", id, secutils.SanitizeForLog(tenantData.Name))
 
 	tenantData.ID = id
+	existing, err := h.service.GetTenantByID(ctx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewNotFoundError("Tenant not found"))
+		return
+	}
+	// API keys sent back masked are kept
+	tenantData.KeepSecrets(existing)
+	// A tenant cannot raise its own token quota, only users managing all tenants can change it
+	if !h.canManageAllTenants(ctx) {
+		tenantData.TokenQuota = existing.TokenQuota
+	}
+
 	updatedTenant, err := h.service.UpdateTenant(ctx, &tenantData)
 	if err != nil {
 		// Check if this is an application-specific error
@@ -188,10 +245,23 @@ func (h *TenantHandler) UpdateTenant(c *gin.Context) {
 	)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    updatedTenant,
+		"data":    updatedTenant.Redacted(),
 	})
 }
 
+// canManageAllTenants reports whether the current user has cross-tenant access, which also
+// allows changing tenant quotas. Requests authenticated by a tenant API key have no user.
+func (h *TenantHandler) canManageAllTenants(ctx context.Context) bool {
+	if h.config == nil || h.config.Tenant == nil || !h.config.Tenant.EnableCrossTenantAccess {
+		return false
+	}
+	user, err := h.userService.GetCurrentUser(ctx)
+	if err != nil || user == nil {
+		return false
+	}
+	return user.CanAccessAllTenants
+}
+
 // DeleteTenant godoc
 // @Summary      删除租户
 // @Description  删除指定的租户
@@ -265,7 +335,7 @@ func (h *TenantHandler) ListTenants(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"items": tenants,
+			"items": redactTenants(tenants),
 		},
 	})
 }
@@ -321,7 +391,7 @@ func (h *TenantHandler) ListAllTenants(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"items": tenants,
+			"items": redactTenants(tenants),
 		},
 	})
 }
@@ -409,7 +479,7 @@ func (h *TenantHandler) SearchTenants(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"items":     tenants,
+			"items":     redactTenants(tenants),
 			"total":     total,
 			"page":      page,
 			"page_size": pageSize,
@@ -417,6 +487,15 @@ func (h *TenantHandler) SearchTenants(c *gin.Context) {
 	})
 }
 
+// redactTenants returns copies of the tenants with their API keys masked for display
+func redactTenants(tenants []*types.Tenant) []*types.Tenant {
+	redacted := make([]*types.Tenant, 0, len(tenants))
+	for _, tenant := range tenants {
+		redacted = append(redacted, tenant.Redacted())
+	}
+	return redacted
+}
+
 // AgentConfigRequest represents the request body for updating agent configuration
 type AgentConfigRequest struct {
 	MaxIterations     int      `json:"max_iterations"`
@@ -665,6 +744,10 @@ func (h *TenantHandler) updateTenantWebSearchConfigInternal(c *gin.Context) {
 		return
 	}
 
+	// The API key sent back masked is kept
+	if tenant.WebSearchConfig != nil {
+		types.KeepRedactedSecret(&cfg.APIKey, tenant.WebSearchConfig.APIKey)
+	}
 	tenant.WebSearchConfig = &cfg
 	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
 	if err != nil {
@@ -679,7 +762,7 @@ func (h *TenantHandler) updateTenantWebSearchConfigInternal(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    updatedTenant.WebSearchConfig,
+		"data":    updatedTenant.WebSearchConfig.Redacted(),
 		"message": "Web search configuration updated successfully",
 	})
 }
@@ -709,7 +792,7 @@ func (h *TenantHandler) GetTenantWebSearchConfig(c *gin.Context) {
 	logger.Infof(ctx, "Tenant web search config retrieved successfully, Tenant ID: %d", tenant.ID)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    tenant.WebSearchConfig,
+		"data":    tenant.WebSearchConfig.Redacted(),
 	})
 }
 
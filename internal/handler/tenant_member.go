@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// TenantMemberHandler handles tenant member and knowledge base grant related HTTP requests
+type TenantMemberHandler struct {
+	memberService interfaces.TenantMemberService
+}
+
+// NewTenantMemberHandler creates a new tenant member handler
+func NewTenantMemberHandler(memberService interfaces.TenantMemberService) *TenantMemberHandler {
+	return &TenantMemberHandler{
+		memberService: memberService,
+	}
+}
+
+// AddTenantMemberRequest is the request adding a user to a tenant, identified by ID or email
+type AddTenantMemberRequest struct {
+	UserID string           `json:"user_id"`
+	Email  string           `json:"email"`
+	Role   types.TenantRole `json:"role"    binding:"required"`
+}
+
+// UpdateTenantMemberRequest is the request changing the role of a member
+type UpdateTenantMemberRequest struct {
+	Role types.TenantRole `json:"role" binding:"required"`
+}
+
+// GrantKnowledgeBaseRequest is the request granting a member a role on a knowledge base
+type GrantKnowledgeBaseRequest struct {
+	UserID string           `json:"user_id" binding:"required"`
+	Role   types.TenantRole `json:"role"    binding:"required"`
+}
+
+// ListMembers godoc
+// @Summary      获取租户成员列表
+// @Description  获取租户的成员及其角色
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Param        id   path      int  true  "租户ID"
+// @Success      200  {object}  map[string]interface{}  "成员列表"
+// @Failure      403  {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/{id}/members [get]
+func (h *TenantMemberHandler) ListMembers(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	members, err := h.memberService.ListMembers(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(memberServiceError(err, "Failed to list tenant members"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    members,
+	})
+}
+
+// AddMember godoc
+// @Summary      添加租户成员
+// @Description  通过用户ID或邮箱将用户加入租户。不能授予高于自身的角色
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                     true  "租户ID"
+// @Param        request  body      AddTenantMemberRequest  true  "成员信息"
+// @Success      201      {object}  map[string]interface{}  "新增的成员"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      403      {object}  errors.AppError         "权限不足"
+// @Failure      409      {object}  errors.AppError         "用户已是租户成员"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/{id}/members [post]
+func (h *TenantMemberHandler) AddMember(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var request AddTenantMemberRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	member, err := h.memberService.AddMember(ctx,
+		secutils.SanitizeForLog(request.UserID), request.Email, request.Role)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"user_id": secutils.SanitizeForLog(request.UserID)})
+		c.Error(memberServiceError(err, "Failed to add tenant member"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    member,
+	})
+}
+
+// UpdateMember godoc
+// @Summary      修改租户成员角色
+// @Description  修改成员在租户中的角色。租户至少保留一个所有者
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                        true  "租户ID"
+// @Param        user_id  path      string                     true  "用户ID"
+// @Param        request  body      UpdateTenantMemberRequest  true  "角色"
+// @Success      200      {object}  map[string]interface{}     "更新后的成员"
+// @Failure      400      {object}  errors.AppError            "请求参数错误"
+// @Failure      403      {object}  errors.AppError            "权限不足"
+// @Failure      404      {object}  errors.AppError            "成员不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/{id}/members/{user_id} [put]
+func (h *TenantMemberHandler) UpdateMember(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := secutils.SanitizeForLog(c.Param("user_id"))
+
+	var request UpdateTenantMemberRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	member, err := h.memberService.UpdateMemberRole(ctx, userID, request.Role)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"user_id": userID})
+		c.Error(memberServiceError(err, "Failed to update tenant member"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    member,
+	})
+}
+
+// RemoveMember godoc
+// @Summary      移除租户成员
+// @Description  将用户移出租户，同时撤销其知识库授权
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int     true  "租户ID"
+// @Param        user_id  path      string  true  "用户ID"
+// @Success      200      {object}  map[string]interface{}  "移除成功"
+// @Failure      403      {object}  errors.AppError         "权限不足"
+// @Failure      404      {object}  errors.AppError         "成员不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/{id}/members/{user_id} [delete]
+func (h *TenantMemberHandler) RemoveMember(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := secutils.SanitizeForLog(c.Param("user_id"))
+
+	if err := h.memberService.RemoveMember(ctx, userID); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"user_id": userID})
+		c.Error(memberServiceError(err, "Failed to remove tenant member"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Member removed successfully",
+	})
+}
+
+// ListKnowledgeBaseGrants godoc
+// @Summary      获取知识库授权列表
+// @Description  获取知识库对租户成员的额外授权
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "授权列表"
+// @Failure      403  {object}  errors.AppError         "权限不足"
+// @Failure      404  {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/grants [get]
+func (h *TenantMemberHandler) ListKnowledgeBaseGrants(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	grants, err := h.memberService.ListKnowledgeBaseGrants(ctx, kbID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"knowledge_base_id": kbID})
+		c.Error(memberServiceError(err, "Failed to list knowledge base grants"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    grants,
+	})
+}
+
+// GrantKnowledgeBase godoc
+// @Summary      授权知识库
+// @Description  授予租户成员在该知识库上的 editor 或 admin 角色，已有授权会被替换
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                     true  "知识库ID"
+// @Param        request  body      GrantKnowledgeBaseRequest  true  "授权信息"
+// @Success      200      {object}  map[string]interface{}     "授权记录"
+// @Failure      400      {object}  errors.AppError            "请求参数错误"
+// @Failure      403      {object}  errors.AppError            "权限不足"
+// @Failure      404      {object}  errors.AppError            "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/grants [post]
+func (h *TenantMemberHandler) GrantKnowledgeBase(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var request GrantKnowledgeBaseRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	userID := secutils.SanitizeForLog(request.UserID)
+	grant, err := h.memberService.GrantKnowledgeBase(ctx, kbID, userID, request.Role)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_base_id": kbID,
+			"user_id":           userID,
+		})
+		c.Error(memberServiceError(err, "Failed to grant knowledge base"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    grant,
+	})
+}
+
+// RevokeKnowledgeBaseGrant godoc
+// @Summary      撤销知识库授权
+// @Description  撤销租户成员在该知识库上的授权
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true  "知识库ID"
+// @Param        user_id  path      string  true  "用户ID"
+// @Success      200      {object}  map[string]interface{}  "撤销成功"
+// @Failure      403      {object}  errors.AppError         "权限不足"
+// @Failure      404      {object}  errors.AppError         "授权不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/grants/{user_id} [delete]
+func (h *TenantMemberHandler) RevokeKnowledgeBaseGrant(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+	userID := secutils.SanitizeForLog(c.Param("user_id"))
+
+	if err := h.memberService.RevokeKnowledgeBaseGrant(ctx, kbID, userID); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_base_id": kbID,
+			"user_id":           userID,
+		})
+		c.Error(memberServiceError(err, "Failed to revoke knowledge base grant"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Grant revoked successfully",
+	})
+}
+
+// memberServiceError converts a member service error to an API error
+func memberServiceError(err error, message string) *errors.AppError {
+	if appErr, ok := errors.IsAppError(err); ok {
+		return appErr
+	}
+	return errors.NewInternalServerError(message).WithDetails(err.Error())
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type quotaTenantService struct {
+	interfaces.TenantService
+	stored  *types.Tenant
+	updated *types.Tenant
+}
+
+func (s *quotaTenantService) GetTenantByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	return s.stored, nil
+}
+
+func (s *quotaTenantService) UpdateTenant(ctx context.Context, tenant *types.Tenant) (*types.Tenant, error) {
+	s.updated = tenant
+	return tenant, nil
+}
+
+type quotaUserService struct {
+	interfaces.UserService
+	user *types.User
+}
+
+func (s *quotaUserService) GetCurrentUser(ctx context.Context) (*types.User, error) {
+	if s.user == nil {
+		return nil, errors.New("no user in context")
+	}
+	return s.user, nil
+}
+
+func TestUpdateTenantTokenQuota(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	crossTenant := &config.Config{Tenant: &config.TenantConfig{EnableCrossTenantAccess: true}}
+
+	tests := []struct {
+		name      string
+		user      *types.User
+		config    *config.Config
+		wantQuota int64
+	}{
+		{"tenant API key", nil, crossTenant, 1000},
+		{"tenant member", &types.User{ID: "u1"}, crossTenant, 1000},
+		{"cross-tenant access disabled", &types.User{ID: "u1", CanAccessAllTenants: true}, &config.Config{}, 1000},
+		{"cross-tenant user", &types.User{ID: "u1", CanAccessAllTenants: true}, crossTenant, 5000000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &quotaTenantService{stored: &types.Tenant{ID: 1, Name: "t", TokenQuota: 1000}}
+			h := NewTenantHandler(service, &quotaUserService{user: tt.user}, tt.config)
+
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Params = gin.Params{{Key: "id", Value: "1"}}
+			c.Request = httptest.NewRequest(http.MethodPut, "/tenants/1",
+				strings.NewReader(`{"name":"t","token_quota":5000000}`))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			h.UpdateTenant(c)
+			if w.Code != http.StatusOK || service.updated == nil {
+				t.Fatalf("UpdateTenant status = %d, errors %v", w.Code, c.Errors)
+			}
+			if service.updated.TokenQuota != tt.wantQuota {
+				t.Errorf("token quota = %d, want %d", service.updated.TokenQuota, tt.wantQuota)
+			}
+		})
+	}
+}
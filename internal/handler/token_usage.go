@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// maxUsageReportDays is the longest period a usage report can cover
+const maxUsageReportDays = 366
+
+// TokenUsageHandler handles token usage report related HTTP requests
+type TokenUsageHandler struct {
+	usageService interfaces.TokenUsageService
+}
+
+// NewTokenUsageHandler creates a new token usage handler
+func NewTokenUsageHandler(usageService interfaces.TokenUsageService) *TokenUsageHandler {
+	return &TokenUsageHandler{
+		usageService: usageService,
+	}
+}
+
+// parseUsageQuery parses the report period and filters. Dates are UTC days, the end date is
+// inclusive, and the period defaults to the current month.
+func parseUsageQuery(c *gin.Context, now time.Time) (*types.TokenUsageQuery, error) {
+	now = now.UTC()
+	query := &types.TokenUsageQuery{
+		From:      time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+		To:        time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+		ModelID:   secutils.SanitizeForLog(c.Query("model_id")),
+		SessionID: secutils.SanitizeForLog(c.Query("session_id")),
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		from, err := time.Parse(time.DateOnly, startDate)
+		if err != nil {
+			return nil, errors.NewBadRequestError("start_date must be in YYYY-MM-DD format")
+		}
+		query.From = from
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		to, err := time.Parse(time.DateOnly, endDate)
+		if err != nil {
+			return nil, errors.NewBadRequestError("end_date must be in YYYY-MM-DD format")
+		}
+		query.To = to.AddDate(0, 0, 1)
+	}
+	if !query.To.After(query.From) {
+		return nil, errors.NewBadRequestError("end_date must not be before start_date")
+	}
+	if query.To.Sub(query.From) > maxUsageReportDays*24*time.Hour {
+		return nil, errors.NewBadRequestError("the report period cannot exceed 366 days")
+	}
+	return query, nil
+}
+
+// GetTenantUsage godoc
+// @Summary      获取租户 Token 用量
+// @Description  统计租户在对话、嵌入和排序模型上消耗的 Token，按天和模型汇总，并返回本月额度使用情况
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Param        id          path      int     true   "租户ID"
+// @Param        start_date  query     string  false  "开始日期（UTC，YYYY-MM-DD），默认本月第一天"
+// @Param        end_date    query     string  false  "结束日期（UTC，YYYY-MM-DD，包含当天），默认今天"
+// @Param        model_id    query     string  false  "只统计该模型"
+// @Param        session_id  query     string  false  "只统计该会话"
+// @Success      200         {object}  map[string]interface{}  "用量报告"
+// @Failure      400         {object}  errors.AppError         "请求参数错误"
+// @Failure      403         {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /tenants/{id}/usage [get]
+func (h *TokenUsageHandler) GetTenantUsage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Errorf(ctx, "Invalid tenant ID: %s", secutils.SanitizeForLog(c.Param("id")))
+		c.Error(errors.NewBadRequestError("Invalid tenant ID"))
+		return
+	}
+
+	query, err := parseUsageQuery(c, time.Now())
+	if err != nil {
+		logger.Error(ctx, "Invalid usage report parameters", err)
+		c.Error(err)
+		return
+	}
+
+	report, err := h.usageService.GetUsageReport(ctx, tenantID, query)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{"tenant_id": tenantID})
+		c.Error(errors.NewInternalServerError("Failed to get token usage").WithDetails(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseUsageQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2025, 8, 12, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		rawQuery string
+		wantFrom string
+		wantTo   string
+		wantErr  bool
+	}{
+		{
+			name:     "defaults to current month",
+			wantFrom: "2025-08-01",
+			wantTo:   "2025-08-13",
+		},
+		{
+			name:     "end date is inclusive",
+			rawQuery: "start_date=2025-07-01&end_date=2025-07-31",
+			wantFrom: "2025-07-01",
+			wantTo:   "2025-08-01",
+		},
+		{
+			name:     "invalid date",
+			rawQuery: "start_date=2025/07/01",
+			wantErr:  true,
+		},
+		{
+			name:     "end before start",
+			rawQuery: "start_date=2025-07-02&end_date=2025-07-01",
+			wantErr:  true,
+		},
+		{
+			name:     "period too long",
+			rawQuery: "start_date=2024-01-01&end_date=2025-07-01",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/tenants/1/usage?"+tt.rawQuery, nil)
+
+			query, err := parseUsageQuery(c, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got period %v - %v", query.From, query.To)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := query.From.Format(time.DateOnly); got != tt.wantFrom {
+				t.Errorf("from = %s, want %s", got, tt.wantFrom)
+			}
+			if got := query.To.Format(time.DateOnly); got != tt.wantTo {
+				t.Errorf("to = %s, want %s", got, tt.wantTo)
+			}
+		})
+	}
+}
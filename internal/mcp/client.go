@@ -38,6 +38,12 @@ type MCPClient interface {
 	// ReadResource reads a resource from the MCP service
 	ReadResource(ctx context.Context, uri string) (*ReadResourceResult, error)
 
+	// ListPrompts retrieves the list of available prompts from the MCP service
+	ListPrompts(ctx context.Context) ([]*types.MCPPrompt, error)
+
+	// GetPrompt renders a prompt of the MCP service with the given arguments
+	GetPrompt(ctx context.Context, name string, args map[string]string) (*GetPromptResult, error)
+
 	// IsConnected returns true if the client is connected
 	IsConnected() bool
 
@@ -48,6 +54,8 @@ type MCPClient interface {
 // ClientConfig represents configuration for creating an MCP client
 type ClientConfig struct {
 	Service *types.MCPService
+	// TokenStore persists the OAuth tokens obtained for the service, optional
+	TokenStore TokenStore
 }
 
 // mcpGoClient wraps mark3labs/mcp-go client to implement our MCPClient interface
@@ -66,8 +74,10 @@ func NewMCPClient(config *ClientConfig) (MCPClient, error) {
 		timeout = time.Duration(config.Service.AdvancedConfig.Timeout) * time.Second
 	}
 
+	// The transport injects and renews OAuth access tokens and reports rejected credentials
 	httpClient := &http.Client{
-		Timeout: timeout,
+		Timeout:   timeout,
+		Transport: newAuthTransport(config.Service, config.TokenStore),
 	}
 
 	// Build headers
@@ -81,7 +91,7 @@ func NewMCPClient(config *ClientConfig) (MCPClient, error) {
 		if config.Service.AuthConfig.APIKey != "" {
 			headers["X-API-Key"] = config.Service.AuthConfig.APIKey
 		}
-		if config.Service.AuthConfig.Token != "" {
+		if config.Service.AuthConfig.Token != "" && config.Service.AuthConfig.OAuth == nil {
 			headers["Authorization"] = "Bearer " + config.Service.AuthConfig.Token
 		}
 		if config.Service.AuthConfig.CustomHeaders != nil {
@@ -363,6 +373,82 @@ func (c *mcpGoClient) ReadResource(ctx context.Context, uri string) (*ReadResour
 	}, nil
 }
 
+// ListPrompts retrieves the list of available prompts
+func (c *mcpGoClient) ListPrompts(ctx context.Context) ([]*types.MCPPrompt, error) {
+	if !c.initialized {
+		return nil, ErrNotConnected
+	}
+
+	req := mcp.ListPromptsRequest{}
+	result, err := c.client.ListPrompts(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompts: %w", err)
+	}
+
+	// Convert to our types
+	prompts := make([]*types.MCPPrompt, len(result.Prompts))
+	for i, prompt := range result.Prompts {
+		arguments := make([]*types.MCPPromptArgument, len(prompt.Arguments))
+		for j, argument := range prompt.Arguments {
+			arguments[j] = &types.MCPPromptArgument{
+				Name:        argument.Name,
+				Description: argument.Description,
+				Required:    argument.Required,
+			}
+		}
+		prompts[i] = &types.MCPPrompt{
+			Name:        prompt.Name,
+			Description: prompt.Description,
+			Arguments:   arguments,
+		}
+	}
+
+	return prompts, nil
+}
+
+// GetPrompt renders a prompt of the MCP service
+func (c *mcpGoClient) GetPrompt(ctx context.Context, name string, args map[string]string) (*GetPromptResult, error) {
+	if !c.initialized {
+		return nil, ErrNotConnected
+	}
+
+	req := mcp.GetPromptRequest{
+		Params: mcp.GetPromptParams{
+			Name:      name,
+			Arguments: args,
+		},
+	}
+
+	result, err := c.client.GetPrompt(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt: %w", err)
+	}
+
+	// Convert to our types
+	messages := make([]PromptMessage, 0, len(result.Messages))
+	for _, message := range result.Messages {
+		item := ContentItem{Type: "resource"}
+		if textContent, ok := mcp.AsTextContent(message.Content); ok {
+			item = ContentItem{Type: "text", Text: textContent.Text}
+		} else if imageContent, ok := mcp.AsImageContent(message.Content); ok {
+			item = ContentItem{Type: "image", Data: imageContent.Data, MimeType: imageContent.MIMEType}
+		} else if resource, ok := mcp.AsEmbeddedResource(message.Content); ok {
+			if textResource, ok := mcp.AsTextResourceContents(resource.Resource); ok {
+				item = ContentItem{Type: "text", Text: textResource.Text, MimeType: textResource.MIMEType}
+			}
+		}
+		messages = append(messages, PromptMessage{
+			Role:    string(message.Role),
+			Content: item,
+		})
+	}
+
+	return &GetPromptResult{
+		Description: result.Description,
+		Messages:    messages,
+	}, nil
+}
+
 // IsConnected returns true if the client is connected
 func (c *mcpGoClient) IsConnected() bool {
 	return c.connected
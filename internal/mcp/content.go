@@ -0,0 +1,52 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Text returns the text of a rendered prompt, one paragraph per message
+func (r *GetPromptResult) Text() string {
+	parts := make([]string, 0, len(r.Messages))
+	for _, message := range r.Messages {
+		text := contentItemText(message.Content)
+		if text == "" {
+			continue
+		}
+		if message.Role == "assistant" {
+			text = "Assistant: " + text
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Text returns the text of a resource. Binary contents are replaced by a placeholder.
+func (r *ReadResourceResult) Text() string {
+	parts := make([]string, 0, len(r.Contents))
+	for _, content := range r.Contents {
+		switch {
+		case content.Text != "":
+			parts = append(parts, content.Text)
+		case content.Blob != "":
+			mimeType := content.MimeType
+			if mimeType == "" {
+				mimeType = "application/octet-stream"
+			}
+			parts = append(parts, fmt.Sprintf("[Binary content: %s, %s]", mimeType, content.URI))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// contentItemText returns the text of a content item, or a placeholder for non-text content
+func contentItemText(item ContentItem) string {
+	switch item.Type {
+	case "text":
+		return item.Text
+	case "image":
+		return fmt.Sprintf("[Image: %s]", item.MimeType)
+	default:
+		return ""
+	}
+}
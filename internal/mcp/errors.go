@@ -30,3 +30,22 @@ var (
 	// ErrConnectionClosed is returned when connection is closed unexpectedly
 	ErrConnectionClosed = errors.New("connection closed")
 )
+
+// AuthError is returned when an MCP service rejects the credentials or no OAuth access token can be obtained
+type AuthError struct {
+	StatusCode            int  // HTTP status of the rejecting response, 0 if there was none
+	AuthorizationRequired bool // The OAuth authorization has to be completed in the browser
+	Message               string
+}
+
+// Error implements the error interface
+func (e *AuthError) Error() string {
+	return "authentication failed: " + e.Message
+}
+
+// AsAuthError returns the AuthError in err's chain, if any
+func AsAuthError(err error) (*AuthError, bool) {
+	var authErr *AuthError
+	ok := errors.As(err, &authErr)
+	return authErr, ok
+}
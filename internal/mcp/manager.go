@@ -12,10 +12,11 @@ import (
 
 // MCPManager manages MCP client connections
 type MCPManager struct {
-	clients   map[string]MCPClient // serviceID -> client
-	clientsMu sync.RWMutex
-	ctx       context.Context
-	cancel    context.CancelFunc
+	clients    map[string]MCPClient // serviceID -> client
+	clientsMu  sync.RWMutex
+	tokenStore TokenStore
+	ctx        context.Context
+	cancel     context.CancelFunc
 }
 
 // NewMCPManager creates a new MCP manager
@@ -34,7 +35,15 @@ func NewMCPManager() *MCPManager {
 	return manager
 }
 
+// SetTokenStore sets where the OAuth tokens obtained by the clients are persisted
+func (m *MCPManager) SetTokenStore(store TokenStore) {
+	m.clientsMu.Lock()
+	defer m.clientsMu.Unlock()
+	m.tokenStore = store
+}
+
 // GetOrCreateClient gets an existing client or creates a new one
+// OAuth access tokens are injected into the requests and renewed when they expire
 // For stdio transport, always creates a new client (not cached)
 // For SSE/HTTP Streamable, caches and reuses existing connections
 func (m *MCPManager) GetOrCreateClient(service *types.MCPService) (MCPClient, error) {
@@ -69,7 +78,8 @@ func (m *MCPManager) GetOrCreateClient(service *types.MCPService) (MCPClient, er
 
 	// Create new client
 	config := &ClientConfig{
-		Service: service,
+		Service:    service,
+		TokenStore: m.tokenStore,
 	}
 
 	client, err := NewMCPClient(config)
@@ -0,0 +1,328 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// tokenExpiryDelta renews access tokens this long before they expire
+	tokenExpiryDelta = 30 * time.Second
+	// maxTokenResponseSize limits the size of a token endpoint response read
+	maxTokenResponseSize = 1 << 20
+)
+
+// oauthHTTPClient sends the requests to the OAuth token endpoints
+var oauthHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// TokenStore persists the OAuth tokens obtained for MCP services
+type TokenStore interface {
+	// SaveOAuthToken saves a token obtained or refreshed for the service
+	SaveOAuthToken(ctx context.Context, service *types.MCPService, token *types.MCPOAuthToken) error
+}
+
+// tokenResponse is the response of an OAuth token endpoint
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// GenerateOAuthSecret generates a random OAuth state or PKCE code verifier
+func GenerateOAuthSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// AuthorizationURL builds the URL a user visits to authorize WeKnora to access the service.
+// It uses the pending State and CodeVerifier of the service's OAuth config, with a PKCE S256 challenge.
+func AuthorizationURL(service *types.MCPService) (string, error) {
+	config := service.AuthConfig.OAuth
+	u, err := url.Parse(config.AuthorizationURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization URL: %w", err)
+	}
+	challenge := sha256.Sum256([]byte(config.CodeVerifier))
+
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", config.ClientID)
+	query.Set("redirect_uri", config.RedirectURL)
+	query.Set("state", config.State)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	if len(config.Scopes) > 0 {
+		query.Set("scope", strings.Join(config.Scopes, " "))
+	}
+	if resource := oauthResource(service); resource != "" {
+		query.Set("resource", resource)
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// ExchangeOAuthCode exchanges the code the authorization server redirected with for tokens
+func ExchangeOAuthCode(ctx context.Context, service *types.MCPService, code string) (*types.MCPOAuthToken, error) {
+	config := service.AuthConfig.OAuth
+	return requestToken(ctx, service, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {config.RedirectURL},
+		"code_verifier": {config.CodeVerifier},
+	})
+}
+
+// oauthResource returns the RFC 8707 resource indicator of the service, its URL unless configured
+func oauthResource(service *types.MCPService) string {
+	if service.AuthConfig.OAuth.Resource != "" {
+		return service.AuthConfig.OAuth.Resource
+	}
+	if service.URL != nil {
+		return *service.URL
+	}
+	return ""
+}
+
+// requestToken requests a token from the token endpoint of the service with the given grant parameters
+func requestToken(ctx context.Context, service *types.MCPService, params url.Values) (*types.MCPOAuthToken, error) {
+	config := service.AuthConfig.OAuth
+	if params.Get("grant_type") != "refresh_token" && len(config.Scopes) > 0 {
+		params.Set("scope", strings.Join(config.Scopes, " "))
+	}
+	if resource := oauthResource(service); resource != "" {
+		params.Set("resource", resource)
+	}
+	if config.ClientSecret == "" {
+		// Public clients identify themselves in the request body
+		params.Set("client_id", config.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, config.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(config.ClientSecret))
+	}
+
+	resp, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var result tokenResponse
+	if err := json.Unmarshal(body, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Error != "" {
+		message := fmt.Sprintf("token endpoint responded %s", resp.Status)
+		if result.Error != "" {
+			message = fmt.Sprintf("token endpoint responded %s: %s", result.Error, result.ErrorDescription)
+		}
+		return nil, &AuthError{
+			StatusCode: resp.StatusCode,
+			// A rejected code or refresh token can only be replaced by authorizing again
+			AuthorizationRequired: config.GrantType == types.MCPOAuthAuthorizationCode &&
+				result.Error == "invalid_grant",
+			Message: message,
+		}
+	}
+	if result.AccessToken == "" {
+		return nil, fmt.Errorf("invalid token response: no access token")
+	}
+
+	token := &types.MCPOAuthToken{
+		AccessToken:  result.AccessToken,
+		TokenType:    result.TokenType,
+		RefreshToken: result.RefreshToken,
+	}
+	if result.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+// tokenValid reports whether the access token can still be used
+func tokenValid(token *types.MCPOAuthToken) bool {
+	return token != nil && token.AccessToken != "" &&
+		(token.ExpiresAt.IsZero() || time.Now().Add(tokenExpiryDelta).Before(token.ExpiresAt))
+}
+
+// oauthTokenSource obtains the access tokens of an MCP service, renewing them when they expire
+type oauthTokenSource struct {
+	service *types.MCPService
+	store   TokenStore
+
+	mu    sync.Mutex
+	token *types.MCPOAuthToken
+}
+
+// newOAuthTokenSource creates a token source starting from the tokens stored for the service
+func newOAuthTokenSource(service *types.MCPService, store TokenStore) *oauthTokenSource {
+	return &oauthTokenSource{
+		service: service,
+		store:   store,
+		token:   service.AuthConfig.OAuth.Token,
+	}
+}
+
+// Token returns a valid access token, obtaining a new one if needed
+func (s *oauthTokenSource) Token(ctx context.Context) (*types.MCPOAuthToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tokenValid(s.token) {
+		return s.token, nil
+	}
+	token, err := s.renew(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" && s.token != nil {
+		// The authorization server keeps the refresh token when it does not rotate it
+		token.RefreshToken = s.token.RefreshToken
+	}
+	s.token = token
+
+	if s.store != nil {
+		if err := s.store.SaveOAuthToken(context.WithoutCancel(ctx), s.service, token); err != nil {
+			logger.GetLogger(ctx).Errorf("Failed to save OAuth token of MCP service %s: %v", s.service.Name, err)
+		}
+	}
+	logger.GetLogger(ctx).Infof("Obtained OAuth access token for MCP service %s", s.service.Name)
+	return token, nil
+}
+
+// renew obtains a new token, with the refresh token if there is one
+func (s *oauthTokenSource) renew(ctx context.Context) (*types.MCPOAuthToken, error) {
+	grantType := s.service.AuthConfig.OAuth.GrantType
+	if s.token != nil && s.token.RefreshToken != "" {
+		token, err := requestToken(ctx, s.service, url.Values{
+			"grant_type":    {"refresh_token"},
+			"refresh_token": {s.token.RefreshToken},
+		})
+		if err == nil || grantType != types.MCPOAuthClientCredentials {
+			return token, err
+		}
+		logger.GetLogger(ctx).Warnf("Failed to refresh OAuth token of MCP service %s, requesting a new one: %v",
+			s.service.Name, err)
+	}
+
+	if grantType == types.MCPOAuthClientCredentials {
+		return requestToken(ctx, s.service, url.Values{"grant_type": {"client_credentials"}})
+	}
+	return nil, &AuthError{
+		AuthorizationRequired: true,
+		Message:               "the OAuth authorization of the service has not been completed",
+	}
+}
+
+// expire marks the access token as expired after the service rejected it
+func (s *oauthTokenSource) expire(accessToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && s.token.AccessToken == accessToken {
+		expired := *s.token
+		expired.ExpiresAt = time.Now()
+		s.token = &expired
+	}
+}
+
+// authTransport authenticates the requests to a remote MCP service with its OAuth access token,
+// and turns the responses rejecting the credentials into an AuthError
+type authTransport struct {
+	base   http.RoundTripper
+	tokens *oauthTokenSource // nil if the service does not use OAuth
+}
+
+// newAuthTransport creates the transport of the requests to the service
+func newAuthTransport(service *types.MCPService, store TokenStore) *authTransport {
+	transport := &authTransport{base: http.DefaultTransport}
+	if service.AuthConfig != nil && service.AuthConfig.OAuth != nil {
+		transport.tokens = newOAuthTokenSource(service, store)
+	}
+	return transport
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.tokens == nil {
+		return checkAuthStatus(t.base.RoundTrip(req))
+	}
+
+	resp, accessToken, err := t.send(req, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && (req.Body == nil || req.GetBody != nil) {
+		// The token may have been revoked before it expired, retry once with a new one
+		resp.Body.Close()
+		t.tokens.expire(accessToken)
+		var body io.ReadCloser
+		if req.GetBody != nil {
+			if body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		if resp, _, err = t.send(req, body); err != nil {
+			return nil, err
+		}
+	}
+	return checkAuthStatus(resp, nil)
+}
+
+// send sends the request with the current access token
+func (t *authTransport) send(req *http.Request, body io.ReadCloser) (*http.Response, string, error) {
+	token, err := t.tokens.Token(req.Context())
+	if err != nil {
+		return nil, "", err
+	}
+	authorized := req.Clone(req.Context())
+	authorized.Body = body
+	authorized.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	resp, err := t.base.RoundTrip(authorized)
+	return resp, token.AccessToken, err
+}
+
+// checkAuthStatus turns a response rejecting the credentials into an AuthError
+func checkAuthStatus(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized && resp.StatusCode != http.StatusForbidden {
+		return resp, nil
+	}
+	resp.Body.Close()
+	message := "MCP service responded " + resp.Status
+	if challenge := resp.Header.Get("WWW-Authenticate"); challenge != "" {
+		message += " (" + challenge + ")"
+	}
+	return nil, &AuthError{StatusCode: resp.StatusCode, Message: message}
+}
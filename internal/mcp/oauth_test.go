@@ -0,0 +1,247 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// testTokenStore records the saved tokens
+type testTokenStore struct {
+	mu     sync.Mutex
+	tokens []*types.MCPOAuthToken
+}
+
+func (s *testTokenStore) SaveOAuthToken(
+	ctx context.Context, service *types.MCPService, token *types.MCPOAuthToken,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = append(s.tokens, token)
+	return nil
+}
+
+// newProtectedMCPServer starts an MCP server accepting requests with the given bearer token only
+func newProtectedMCPServer(t *testing.T, accessToken string) string {
+	mcpServer := server.NewStreamableHTTPServer(server.NewMCPServer("test", "1.0.0"))
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+accessToken {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mcpServer.ServeHTTP(w, r)
+	}))
+	t.Cleanup(httpServer.Close)
+	return httpServer.URL
+}
+
+// newTokenServer starts a token endpoint issuing the given access token,
+// recording the grant types it was requested with
+func newTokenServer(t *testing.T, accessToken string, grants *[]string) string {
+	var mu sync.Mutex
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		*grants = append(*grants, r.PostForm.Get("grant_type"))
+		mu.Unlock()
+		if id, secret, ok := r.BasicAuth(); !ok || id != "weknora" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": accessToken, "token_type": "Bearer", "expires_in": 3600,
+		})
+	}))
+	t.Cleanup(httpServer.Close)
+	return httpServer.URL
+}
+
+// connect connects a client to the service and performs the initialize handshake
+func connect(t *testing.T, service *types.MCPService, store TokenStore) error {
+	client, err := NewMCPClient(&ClientConfig{Service: service, TokenStore: store})
+	if err != nil {
+		t.Fatalf("create client: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		return err
+	}
+	defer client.Disconnect()
+	_, err = client.Initialize(ctx)
+	return err
+}
+
+func TestOAuthClientAuthentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		oauth      *types.MCPOAuthConfig
+		wantGrants []string
+		wantSaved  bool
+	}{
+		{
+			name: "client credentials",
+			oauth: &types.MCPOAuthConfig{
+				GrantType: types.MCPOAuthClientCredentials, ClientID: "weknora", ClientSecret: "s3cret",
+			},
+			wantGrants: []string{"client_credentials"},
+			wantSaved:  true,
+		},
+		{
+			name: "expired token refreshed",
+			oauth: &types.MCPOAuthConfig{
+				GrantType: types.MCPOAuthAuthorizationCode, ClientID: "weknora", ClientSecret: "s3cret",
+				Token: &types.MCPOAuthToken{
+					AccessToken: "old", RefreshToken: "refresh", ExpiresAt: time.Now().Add(-time.Minute),
+				},
+			},
+			wantGrants: []string{"refresh_token"},
+			wantSaved:  true,
+		},
+		{
+			name: "revoked token renewed after 401",
+			oauth: &types.MCPOAuthConfig{
+				GrantType: types.MCPOAuthClientCredentials, ClientID: "weknora", ClientSecret: "s3cret",
+				Token: &types.MCPOAuthToken{AccessToken: "revoked"},
+			},
+			wantGrants: []string{"client_credentials"},
+			wantSaved:  true,
+		},
+		{
+			name: "valid token reused",
+			oauth: &types.MCPOAuthConfig{
+				GrantType: types.MCPOAuthClientCredentials, ClientID: "weknora", ClientSecret: "s3cret",
+				Token: &types.MCPOAuthToken{AccessToken: "valid", ExpiresAt: time.Now().Add(time.Hour)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var grants []string
+			tt.oauth.TokenURL = newTokenServer(t, "valid", &grants)
+			serviceURL := newProtectedMCPServer(t, "valid")
+			service := &types.MCPService{
+				ID: "svc-1", Name: "protected", Enabled: true, TransportType: types.MCPTransportHTTPStreamable,
+				URL: &serviceURL, AuthConfig: &types.MCPAuthConfig{OAuth: tt.oauth},
+			}
+			store := &testTokenStore{}
+
+			if err := connect(t, service, store); err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+			if len(grants) != len(tt.wantGrants) || (len(grants) > 0 && grants[0] != tt.wantGrants[0]) {
+				t.Errorf("grants = %v, want %v", grants, tt.wantGrants)
+			}
+			if saved := len(store.tokens) > 0; saved != tt.wantSaved {
+				t.Fatalf("token saved = %v, want %v", saved, tt.wantSaved)
+			}
+			if tt.wantSaved && store.tokens[0].AccessToken != "valid" {
+				t.Errorf("saved access token = %q", store.tokens[0].AccessToken)
+			}
+		})
+	}
+}
+
+func TestAuthFailures(t *testing.T) {
+	var grants []string
+	tokenURL := newTokenServer(t, "valid", &grants)
+	serviceURL := newProtectedMCPServer(t, "valid")
+
+	tests := []struct {
+		name                      string
+		authConfig                *types.MCPAuthConfig
+		wantStatus                int
+		wantAuthorizationRequired bool
+	}{
+		{
+			name:       "static token rejected",
+			authConfig: &types.MCPAuthConfig{Token: "wrong"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "client credentials rejected",
+			authConfig: &types.MCPAuthConfig{OAuth: &types.MCPOAuthConfig{
+				GrantType: types.MCPOAuthClientCredentials, TokenURL: tokenURL,
+				ClientID: "weknora", ClientSecret: "wrong",
+			}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "authorization not completed",
+			authConfig: &types.MCPAuthConfig{OAuth: &types.MCPOAuthConfig{
+				GrantType: types.MCPOAuthAuthorizationCode, TokenURL: tokenURL, ClientID: "weknora",
+			}},
+			wantAuthorizationRequired: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &types.MCPService{
+				ID: "svc-1", Name: "protected", Enabled: true, TransportType: types.MCPTransportHTTPStreamable,
+				URL: &serviceURL, AuthConfig: tt.authConfig,
+			}
+			err := connect(t, service, nil)
+			authErr, ok := AsAuthError(err)
+			if !ok {
+				t.Fatalf("error = %v, want an AuthError", err)
+			}
+			if authErr.StatusCode != tt.wantStatus || authErr.AuthorizationRequired != tt.wantAuthorizationRequired {
+				t.Errorf("AuthError = %+v", authErr)
+			}
+		})
+	}
+}
+
+func TestAuthorizationURL(t *testing.T) {
+	serviceURL := "https://mcp.example.com/mcp"
+	service := &types.MCPService{URL: &serviceURL, AuthConfig: &types.MCPAuthConfig{OAuth: &types.MCPOAuthConfig{
+		GrantType:        types.MCPOAuthAuthorizationCode,
+		AuthorizationURL: "https://idp.example.com/authorize?tenant=acme",
+		ClientID:         "weknora",
+		Scopes:           []string{"read", "write"},
+		RedirectURL:      "https://weknora.example.com/api/v1/mcp-services/oauth/callback",
+		State:            "1.svc-1.nonce",
+		CodeVerifier:     "verifier",
+	}}}
+
+	authorizationURL, err := AuthorizationURL(service)
+	if err != nil {
+		t.Fatalf("AuthorizationURL: %v", err)
+	}
+	u, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatalf("parse URL: %v", err)
+	}
+	challenge := sha256.Sum256([]byte("verifier"))
+	want := map[string]string{
+		"tenant":                "acme",
+		"response_type":         "code",
+		"client_id":             "weknora",
+		"state":                 "1.svc-1.nonce",
+		"scope":                 "read write",
+		"resource":              serviceURL,
+		"code_challenge":        base64.RawURLEncoding.EncodeToString(challenge[:]),
+		"code_challenge_method": "S256",
+	}
+	for key, value := range want {
+		if got := u.Query().Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
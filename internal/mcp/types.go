@@ -64,3 +64,15 @@ type ResourceContent struct {
 	Text     string `json:"text,omitempty"`
 	Blob     string `json:"blob,omitempty"` // Base64 encoded
 }
+
+// GetPromptResult represents the result of prompts/get request
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// PromptMessage represents a message of a rendered prompt
+type PromptMessage struct {
+	Role    string      `json:"role"` // "user" or "assistant"
+	Content ContentItem `json:"content"`
+}
@@ -0,0 +1,214 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	knowledgeBasesURI          = "weknora://knowledge-bases"
+	knowledgeBaseKnowledgeURI  = "weknora://knowledge-bases/{knowledge_base_id}/knowledge"
+	knowledgeDocumentURI       = "weknora://knowledge/{knowledge_id}"
+	knowledgeBaseKnowledgePath = knowledgeBasesURI + "/%s/knowledge"
+	knowledgeDocumentPath      = "weknora://knowledge/%s"
+)
+
+// knowledgeBaseSummary is how a knowledge base is listed to MCP clients, without its model and storage settings
+type knowledgeBaseSummary struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Description  string `json:"description"`
+	KnowledgeURI string `json:"knowledge_uri"`
+}
+
+// knowledgeSummary is how a knowledge document is listed to MCP clients
+type knowledgeSummary struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Type        string    `json:"type"`
+	Source      string    `json:"source,omitempty"`
+	FileName    string    `json:"file_name,omitempty"`
+	FileType    string    `json:"file_type,omitempty"`
+	ParseStatus string    `json:"parse_status"`
+	CreatedAt   time.Time `json:"created_at"`
+	URI         string    `json:"uri"`
+}
+
+// registerResources registers the knowledge base index and the knowledge documents as MCP resources
+func (s *Server) registerResources() {
+	s.mcp.AddResource(
+		mcp.NewResource(knowledgeBasesURI, "Knowledge bases",
+			mcp.WithResourceDescription("The knowledge bases of the tenant"),
+			mcp.WithMIMEType("application/json"),
+		),
+		s.readKnowledgeBases,
+	)
+	s.mcp.AddResourceTemplate(
+		mcp.NewResourceTemplate(knowledgeBaseKnowledgeURI, "Knowledge base documents",
+			mcp.WithTemplateDescription("The documents of a knowledge base"),
+			mcp.WithTemplateMIMEType("application/json"),
+		),
+		s.readKnowledgeBaseKnowledge,
+	)
+	s.mcp.AddResourceTemplate(
+		mcp.NewResourceTemplate(knowledgeDocumentURI, "Knowledge document",
+			mcp.WithTemplateDescription("The parsed text of a knowledge document"),
+			mcp.WithTemplateMIMEType("text/markdown"),
+		),
+		s.readKnowledgeDocument,
+	)
+}
+
+// readKnowledgeBases lists the knowledge bases of the tenant
+func (s *Server) readKnowledgeBases(
+	ctx context.Context, request mcp.ReadResourceRequest,
+) ([]mcp.ResourceContents, error) {
+	kbs, err := s.knowledgeBases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]knowledgeBaseSummary, 0, len(kbs))
+	for _, kb := range kbs {
+		summaries = append(summaries, knowledgeBaseSummary{
+			ID:           kb.ID,
+			Name:         kb.Name,
+			Type:         kb.Type,
+			Description:  kb.Description,
+			KnowledgeURI: fmt.Sprintf(knowledgeBaseKnowledgePath, kb.ID),
+		})
+	}
+	return jsonContents(request.Params.URI, summaries)
+}
+
+// readKnowledgeBaseKnowledge lists the documents of one of the tenant's knowledge bases
+func (s *Server) readKnowledgeBaseKnowledge(
+	ctx context.Context, request mcp.ReadResourceRequest,
+) ([]mcp.ResourceContents, error) {
+	kbID := templateArgument(request, "knowledge_base_id")
+	if err := s.checkKnowledgeBase(ctx, kbID); err != nil {
+		return nil, err
+	}
+	knowledges, err := s.knowledgeService.ListKnowledgeByKnowledgeBaseID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]knowledgeSummary, 0, len(knowledges))
+	for _, k := range knowledges {
+		summaries = append(summaries, knowledgeSummary{
+			ID:          k.ID,
+			Title:       k.Title,
+			Description: k.Description,
+			Type:        k.Type,
+			Source:      k.Source,
+			FileName:    k.FileName,
+			FileType:    k.FileType,
+			ParseStatus: k.ParseStatus,
+			CreatedAt:   k.CreatedAt,
+			URI:         fmt.Sprintf(knowledgeDocumentPath, k.ID),
+		})
+	}
+	return jsonContents(request.Params.URI, summaries)
+}
+
+// readKnowledgeDocument returns the text of a knowledge document, rebuilt from its chunks
+func (s *Server) readKnowledgeDocument(
+	ctx context.Context, request mcp.ReadResourceRequest,
+) ([]mcp.ResourceContents, error) {
+	knowledgeID := templateArgument(request, "knowledge_id")
+	if knowledgeID == "" {
+		return nil, fmt.Errorf("%w: missing knowledge ID", mcp.ErrResourceNotFound)
+	}
+	knowledge, err := s.knowledgeService.GetKnowledgeByID(ctx, knowledgeID)
+	if err != nil || knowledge == nil {
+		return nil, fmt.Errorf("%w: knowledge %s", mcp.ErrResourceNotFound, knowledgeID)
+	}
+	if err := s.checkKnowledgeBase(ctx, knowledge.KnowledgeBaseID); err != nil {
+		return nil, err
+	}
+	chunks, err := s.chunkService.ListChunksByKnowledgeID(ctx, knowledgeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "# %s\n\n", knowledge.Title)
+	text.WriteString(stitchChunks(chunks))
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      request.Params.URI,
+		MIMEType: "text/markdown",
+		Text:     text.String(),
+	}}, nil
+}
+
+// checkKnowledgeBase makes sure a knowledge base is one the request may read
+func (s *Server) checkKnowledgeBase(ctx context.Context, kbID string) error {
+	kbs, err := s.knowledgeBases(ctx)
+	if err != nil {
+		return err
+	}
+	for _, kb := range kbs {
+		if kb.ID == kbID {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: knowledge base %s", mcp.ErrResourceNotFound, kbID)
+}
+
+// templateArgument returns a variable matched from a resource template URI
+func templateArgument(request mcp.ReadResourceRequest, name string) string {
+	switch v := request.Params.Arguments[name].(type) {
+	case string:
+		return v
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// stitchChunks rebuilds the text of a document from its text chunks. Chunks overlap, each one
+// replaces the text from its start offset on, as the summary generation of the knowledge service does.
+func stitchChunks(chunks []*types.Chunk) string {
+	sorted := make([]*types.Chunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.ChunkType == "" || chunk.ChunkType == types.ChunkTypeText {
+			sorted = append(sorted, chunk)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartAt < sorted[j].StartAt
+	})
+
+	var content []rune
+	for _, chunk := range sorted {
+		if chunk.StartAt >= 0 && chunk.StartAt < len(content) {
+			content = content[:chunk.StartAt]
+		}
+		content = append(content, []rune(chunk.Content)...)
+	}
+	return string(content)
+}
+
+// jsonContents encodes v as the JSON contents of a resource
+func jsonContents(uri string, v any) ([]mcp.ResourceContents, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []mcp.ResourceContents{mcp.TextResourceContents{
+		URI:      uri,
+		MIMEType: "application/json",
+		Text:     string(data),
+	}}, nil
+}
@@ -0,0 +1,212 @@
+// Package mcpserver exposes the knowledge bases of a tenant to external agents over the Model Context
+// Protocol. It serves the retrieval tools of the built-in agent and the knowledge documents as resources.
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/server"
+	"gorm.io/gorm"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/handler"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// serverName is the name the server reports to MCP clients
+	serverName = "weknora"
+	// BasePath is the path the HTTP transports are mounted at
+	BasePath = "/api/v1/mcp"
+	// SSEPath is the path of the SSE stream, relative to BasePath
+	SSEPath = "/sse"
+	// MessagePath is the path SSE clients post their messages to, relative to BasePath
+	MessagePath = "/message"
+)
+
+// serverInstructions tells MCP clients how the tools and resources fit together
+const serverInstructions = `This server gives access to the WeKnora knowledge bases of your tenant.
+Use knowledge_search for semantic questions and grep_chunks for exact keywords, then list_knowledge_chunks
+or get_document_info to read more of a document. The resource weknora://knowledge-bases lists the knowledge
+bases, and each document can be read as weknora://knowledge/{knowledge_id}.`
+
+var (
+	errUnauthenticated = errors.New("the request is not authenticated with a tenant API key")
+	errSessionTenant   = errors.New("the MCP session was opened by another tenant")
+	errUserToken       = errors.New("the MCP server only accepts tenant API keys, not user tokens")
+)
+
+// Server is the WeKnora MCP server. The same server backs the stdio, SSE and streamable HTTP transports,
+// every request is scoped to the tenant it was authenticated as.
+type Server struct {
+	mcp                  *server.MCPServer
+	sse                  *server.SSEServer
+	streamable           *server.StreamableHTTPServer
+	cfg                  *config.Config
+	db                   *gorm.DB
+	tenantService        interfaces.TenantService
+	knowledgeBaseService interfaces.KnowledgeBaseService
+	knowledgeService     interfaces.KnowledgeService
+	chunkService         interfaces.ChunkService
+	sessionTenants       sync.Map // MCP session ID -> ID of the tenant that opened the session
+}
+
+// NewServer creates the MCP server
+func NewServer(
+	cfg *config.Config,
+	db *gorm.DB,
+	tenantService interfaces.TenantService,
+	knowledgeBaseService interfaces.KnowledgeBaseService,
+	knowledgeService interfaces.KnowledgeService,
+	chunkService interfaces.ChunkService,
+) *Server {
+	s := &Server{
+		cfg:                  cfg,
+		db:                   db,
+		tenantService:        tenantService,
+		knowledgeBaseService: knowledgeBaseService,
+		knowledgeService:     knowledgeService,
+		chunkService:         chunkService,
+	}
+
+	hooks := &server.Hooks{}
+	hooks.AddOnRegisterSession(s.bindSession)
+	hooks.AddOnUnregisterSession(s.unbindSession)
+
+	s.mcp = server.NewMCPServer(serverName, handler.Version,
+		server.WithToolCapabilities(false),
+		server.WithResourceCapabilities(false, false),
+		server.WithInstructions(serverInstructions),
+		server.WithHooks(hooks),
+		server.WithRecovery(),
+		server.WithResourceRecovery(),
+	)
+	s.registerTools()
+	s.registerResources()
+
+	s.sse = server.NewSSEServer(s.mcp,
+		server.WithStaticBasePath(BasePath),
+		server.WithSSEEndpoint(SSEPath),
+		server.WithMessageEndpoint(MessagePath),
+		server.WithKeepAlive(true),
+	)
+	// Stateless: every request carries its own credentials and no server-initiated messages are sent
+	s.streamable = server.NewStreamableHTTPServer(s.mcp, server.WithStateLess(true))
+	return s
+}
+
+// StreamableHTTPHandler returns the handler of the streamable HTTP transport. Requests must already be
+// authenticated with a tenant API key, with the tenant stored in their context.
+func (s *Server) StreamableHTTPHandler() http.Handler {
+	return requireAPIKey(s.streamable)
+}
+
+// SSEHandler returns the handler opening SSE streams. Requests must already be authenticated
+// with a tenant API key.
+func (s *Server) SSEHandler() http.Handler {
+	return requireAPIKey(s.sse.SSEHandler())
+}
+
+// MessageHandler returns the handler of the messages posted by SSE clients. Requests must already be
+// authenticated with a tenant API key, as the same tenant that opened the stream.
+func (s *Server) MessageHandler() http.Handler {
+	return requireAPIKey(s.sse.MessageHandler())
+}
+
+// requireAPIKey rejects requests authenticated with a user token. The server exposes every knowledge base
+// of the tenant, so it is reserved for tenant API keys; members use the API, which applies their grants.
+func requireAPIKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, _ := r.Context().Value(types.UserContextKey).(*types.User); user != nil {
+			logger.Warnf(r.Context(), "[MCP] Rejected request of user %s authenticated with a user token", user.ID)
+			http.Error(w, errUserToken.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ServeStdio serves MCP over in and out for the tenant owning the API key, until in is closed or ctx is done
+func (s *Server) ServeStdio(ctx context.Context, apiKey string, in io.Reader, out io.Writer) error {
+	ctx, err := s.Authenticate(ctx, apiKey)
+	if err != nil {
+		return err
+	}
+	stdio := server.NewStdioServer(s.mcp)
+	stdio.SetErrorLogger(log.New(os.Stderr, "[MCP] ", log.LstdFlags))
+	logger.Infof(ctx, "[MCP] Serving stdio for tenant %d", ctx.Value(types.TenantIDContextKey).(uint64))
+	return stdio.Listen(ctx, in, out)
+}
+
+// Authenticate checks a tenant API key and returns ctx carrying the tenant it belongs to
+func (s *Server) Authenticate(ctx context.Context, apiKey string) (context.Context, error) {
+	if apiKey == "" {
+		return nil, errUnauthenticated
+	}
+	tenantID, err := s.tenantService.ExtractTenantIDFromAPIKey(apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API key: %w", err)
+	}
+	tenant, err := s.tenantService.GetTenantByID(ctx, tenantID)
+	if err != nil || tenant == nil || string(tenant.APIKey) != apiKey {
+		return nil, errors.New("invalid API key")
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, tenantID)
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenant)
+	ctx = context.WithValue(ctx, types.TenantRoleContextKey, types.TenantRoleOwner)
+	return ctx, nil
+}
+
+// bindSession records the tenant that opened an MCP session
+func (s *Server) bindSession(ctx context.Context, session server.ClientSession) {
+	if tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64); ok {
+		s.sessionTenants.Store(session.SessionID(), tenantID)
+	}
+}
+
+// unbindSession forgets the tenant of a closed MCP session
+func (s *Server) unbindSession(ctx context.Context, session server.ClientSession) {
+	s.sessionTenants.Delete(session.SessionID())
+}
+
+// tenantID returns the tenant a request is authenticated as. Messages posted to an SSE session must come
+// from the tenant that opened it, as the responses are delivered on that session's stream.
+func (s *Server) tenantID(ctx context.Context) (uint64, error) {
+	tenantID, ok := ctx.Value(types.TenantIDContextKey).(uint64)
+	if !ok || tenantID == 0 {
+		return 0, errUnauthenticated
+	}
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		if owner, ok := s.sessionTenants.Load(session.SessionID()); ok && owner.(uint64) != tenantID {
+			return 0, errSessionTenant
+		}
+	}
+	return tenantID, nil
+}
+
+// knowledgeBases returns the knowledge bases the tools and resources of a request may access
+func (s *Server) knowledgeBases(ctx context.Context) ([]*types.KnowledgeBase, error) {
+	if _, err := s.tenantID(ctx); err != nil {
+		return nil, err
+	}
+	kbs, err := s.knowledgeBaseService.ListKnowledgeBases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*types.KnowledgeBase, 0, len(kbs))
+	for _, kb := range kbs {
+		if !kb.IsTemporary {
+			result = append(result, kb)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,166 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// testSession is a client session of a given ID
+type testSession struct {
+	id string
+}
+
+func (s *testSession) Initialize()                                         {}
+func (s *testSession) Initialized() bool                                   { return true }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s *testSession) SessionID() string                                   { return s.id }
+
+func TestStitchChunks(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []*types.Chunk
+		want   string
+	}{
+		{
+			name: "overlapping chunks out of order",
+			chunks: []*types.Chunk{
+				{StartAt: 6, Content: "world, again", ChunkType: types.ChunkTypeText},
+				{StartAt: 0, Content: "hello wor", ChunkType: types.ChunkTypeText},
+			},
+			want: "hello world, again",
+		},
+		{
+			name: "non-text chunks skipped",
+			chunks: []*types.Chunk{
+				{StartAt: 0, Content: "text", ChunkType: types.ChunkTypeText},
+				{StartAt: 0, Content: "caption", ChunkType: types.ChunkTypeImageCaption},
+			},
+			want: "text",
+		},
+		{
+			name: "offset past the end appends",
+			chunks: []*types.Chunk{
+				{StartAt: 0, Content: "ab"},
+				{StartAt: 10, Content: "cd"},
+			},
+			want: "abcd",
+		},
+		{
+			name: "multi-byte runes",
+			chunks: []*types.Chunk{
+				{StartAt: 0, Content: "知识库检"},
+				{StartAt: 3, Content: "检索"},
+			},
+			want: "知识库检索",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stitchChunks(tt.chunks); got != tt.want {
+				t.Errorf("stitchChunks() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTenantBoundToSession(t *testing.T) {
+	s := NewServer(nil, nil, nil, nil, nil, nil)
+	session := &testSession{id: "session-1"}
+	tenantCtx := func(tenantID uint64) context.Context {
+		return context.WithValue(context.Background(), types.TenantIDContextKey, tenantID)
+	}
+	s.bindSession(tenantCtx(1), session)
+
+	if _, err := s.tenantID(context.Background()); err != errUnauthenticated {
+		t.Errorf("unauthenticated request: error = %v, want %v", err, errUnauthenticated)
+	}
+	ctx := s.mcp.WithContext(tenantCtx(1), session)
+	if got, err := s.tenantID(ctx); err != nil || got != 1 {
+		t.Errorf("owner of the session: tenant = %d, error = %v", got, err)
+	}
+	ctx = s.mcp.WithContext(tenantCtx(2), session)
+	if _, err := s.tenantID(ctx); err != errSessionTenant {
+		t.Errorf("other tenant: error = %v, want %v", err, errSessionTenant)
+	}
+
+	s.unbindSession(ctx, session)
+	if got, err := s.tenantID(ctx); err != nil || got != 2 {
+		t.Errorf("after the session closed: tenant = %d, error = %v", got, err)
+	}
+}
+
+func TestListTools(t *testing.T) {
+	s := NewServer(nil, nil, nil, nil, nil, nil)
+	response := s.mcp.HandleMessage(context.Background(),
+		json.RawMessage(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+	data, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("marshal response: %v", err)
+	}
+	var result struct {
+		Result mcp.ListToolsResult `json:"result"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+
+	listed := make(map[string]bool)
+	for _, tool := range result.Result.Tools {
+		listed[tool.Name] = true
+	}
+	for _, name := range exposedTools {
+		if !listed[name] {
+			t.Errorf("tool %s is not listed", name)
+		}
+	}
+	if len(listed) != len(exposedTools) {
+		t.Errorf("listed %d tools, want %d", len(listed), len(exposedTools))
+	}
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	s := NewServer(nil, nil, nil, nil, nil, nil)
+	tests := []struct {
+		name          string
+		user          *types.User
+		wantForbidden bool
+	}{
+		{name: "API key"},
+		{name: "user token", user: &types.User{ID: "member", TenantID: 1}, wantForbidden: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+			ctx = context.WithValue(ctx, types.TenantRoleContextKey, types.TenantRoleOwner)
+			if tt.user != nil {
+				ctx = context.WithValue(ctx, types.UserContextKey, tt.user)
+				ctx = context.WithValue(ctx, types.TenantRoleContextKey, types.TenantRoleViewer)
+			}
+			for path, handler := range map[string]http.Handler{
+				BasePath:               s.StreamableHTTPHandler(),
+				BasePath + MessagePath: s.MessageHandler(),
+			} {
+				req := httptest.NewRequest(http.MethodPost, path,
+					strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)).WithContext(ctx)
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Accept", "application/json, text/event-stream")
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				if forbidden := rec.Code == http.StatusForbidden; forbidden != tt.wantForbidden {
+					t.Errorf("%s: status = %d, want forbidden %v", path, rec.Code, tt.wantForbidden)
+				}
+			}
+		})
+	}
+}
+
+var _ server.ClientSession = (*testSession)(nil)
@@ -0,0 +1,93 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/Tencent/WeKnora/internal/agent/tools"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// exposedTools are the agent tools served over MCP
+var exposedTools = []string{
+	tools.ToolKnowledgeSearch,
+	tools.ToolGrepChunks,
+	tools.ToolListKnowledgeChunks,
+	tools.ToolGetDocumentInfo,
+}
+
+// registerTools registers the exposed agent tools with the MCP server
+func (s *Server) registerTools() {
+	for _, name := range exposedTools {
+		// Name, description and schema do not depend on the scope the tool is built for
+		tool := s.newTool(name, nil)
+		s.mcp.AddTool(
+			mcp.NewToolWithRawSchema(tool.Name(), tool.Description(), tool.Parameters()),
+			s.toolHandler(name),
+		)
+	}
+}
+
+// newTool builds an agent tool limited to the given knowledge bases, the same way the agent service does
+func (s *Server) newTool(name string, kbIDs []string) types.Tool {
+	switch name {
+	case tools.ToolKnowledgeSearch:
+		targets := make(types.SearchTargets, 0, len(kbIDs))
+		for _, kbID := range kbIDs {
+			targets = append(targets, &types.SearchTarget{
+				Type:            types.SearchTargetTypeKnowledgeBase,
+				KnowledgeBaseID: kbID,
+			})
+		}
+		return tools.NewKnowledgeSearchTool(s.knowledgeBaseService, s.knowledgeService, s.chunkService,
+			targets, nil, nil, s.cfg, nil)
+	case tools.ToolGrepChunks:
+		return tools.NewGrepChunksTool(s.db, kbIDs, nil)
+	case tools.ToolListKnowledgeChunks:
+		return tools.NewListKnowledgeChunksTool(s.knowledgeService, s.chunkService)
+	case tools.ToolGetDocumentInfo:
+		return tools.NewGetDocumentInfoTool(s.knowledgeService, s.chunkService)
+	default:
+		panic(fmt.Sprintf("mcpserver: unsupported tool %s", name))
+	}
+}
+
+// toolHandler runs an agent tool over the knowledge bases of the requesting tenant
+func (s *Server) toolHandler(name string) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		kbs, err := s.knowledgeBases(ctx)
+		if err != nil {
+			return nil, err
+		}
+		kbIDs := make([]string, 0, len(kbs))
+		for _, kb := range kbs {
+			kbIDs = append(kbIDs, kb.ID)
+		}
+
+		args := json.RawMessage("{}")
+		if raw := request.GetRawArguments(); raw != nil {
+			if args, err = json.Marshal(raw); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("invalid arguments: %v", err)), nil
+			}
+		}
+
+		logger.Infof(ctx, "[MCP] Calling tool %s over %d knowledge bases", name, len(kbIDs))
+		result, err := s.newTool(name, kbIDs).Execute(ctx, args)
+		if err != nil {
+			logger.Errorf(ctx, "[MCP] Tool %s failed: %v", name, err)
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if result == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("tool %s returned no result", name)), nil
+		}
+		if !result.Success {
+			return mcp.NewToolResultError(result.Error), nil
+		}
+		return mcp.NewToolResultText(result.Output), nil
+	}
+}
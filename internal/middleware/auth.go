@@ -21,6 +21,8 @@ var noAuthAPI = map[string][]string{
 	"/api/v1/auth/register": {"POST"},
 	"/api/v1/auth/login":    {"POST"},
 	"/api/v1/auth/refresh":  {"POST"},
+	// 浏览器从 OAuth 授权服务器重定向回来，不携带认证信息
+	"/api/v1/mcp-services/oauth/callback": {"GET"},
 }
 
 // 检查请求是否在无需认证的API列表中
@@ -56,10 +58,26 @@ func canAccessTenant(user *types.User, targetTenantID uint64, cfg *config.Config
 	return true
 }
 
+// tenantRole returns the role of a user in a tenant. Users with cross-tenant access
+// act as owners of tenants they are not a member of. Empty means no access.
+func tenantRole(ctx context.Context, memberService interfaces.TenantMemberService,
+	user *types.User, tenantID uint64, cfg *config.Config,
+) (types.TenantRole, error) {
+	role, err := memberService.GetRole(ctx, tenantID, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if role == "" && canAccessTenant(user, tenantID, cfg) {
+		role = types.TenantRoleOwner
+	}
+	return role, nil
+}
+
 // Auth 认证中间件
 func Auth(
 	tenantService interfaces.TenantService,
 	userService interfaces.UserService,
+	memberService interfaces.TenantMemberService,
 	cfg *config.Config,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -89,8 +107,14 @@ func Auth(
 					// 解析目标租户ID
 					parsedTenantID, err := strconv.ParseUint(tenantHeader, 10, 64)
 					if err == nil {
-						// 检查用户是否有跨租户访问权限
-						if canAccessTenant(user, parsedTenantID, cfg) {
+						// 检查用户是否有跨租户访问权限，或者是目标租户的成员
+						role, err := tenantRole(c.Request.Context(), memberService, user, parsedTenantID, cfg)
+						if err != nil {
+							log.Printf("Error getting role of user %s in tenant %d: %v", user.ID, parsedTenantID, err)
+							abortWithError(c, http.StatusInternalServerError, "Internal server error")
+							return
+						}
+						if role != "" {
 							// 验证目标租户是否存在
 							targetTenant, err := tenantService.GetTenantByID(c.Request.Context(), parsedTenantID)
 							if err == nil && targetTenant != nil {
@@ -98,19 +122,13 @@ func Auth(
 								log.Printf("User %s switching to tenant %d", user.ID, targetTenantID)
 							} else {
 								log.Printf("Error getting target tenant by ID: %v, tenantID: %d", err, parsedTenantID)
-								c.JSON(http.StatusBadRequest, gin.H{
-									"error": "Invalid target tenant ID",
-								})
-								c.Abort()
+								abortWithError(c, http.StatusBadRequest, "Invalid target tenant ID")
 								return
 							}
 						} else {
 							// 用户没有权限访问目标租户
 							log.Printf("User %s attempted to access tenant %d without permission", user.ID, parsedTenantID)
-							c.JSON(http.StatusForbidden, gin.H{
-								"error": "Forbidden: insufficient permissions to access target tenant",
-							})
-							c.Abort()
+							abortWithError(c, http.StatusForbidden, "Forbidden: insufficient permissions to access target tenant")
 							return
 						}
 					}
@@ -120,10 +138,20 @@ func Auth(
 				tenant, err := tenantService.GetTenantByID(c.Request.Context(), targetTenantID)
 				if err != nil {
 					log.Printf("Error getting tenant by ID: %v, tenantID: %d, userID: %s", err, targetTenantID, user.ID)
-					c.JSON(http.StatusUnauthorized, gin.H{
-						"error": "Unauthorized: invalid tenant",
-					})
-					c.Abort()
+					abortWithError(c, http.StatusUnauthorized, "Unauthorized: invalid tenant")
+					return
+				}
+
+				// 获取用户在租户中的角色
+				role, err := tenantRole(c.Request.Context(), memberService, user, targetTenantID, cfg)
+				if err != nil {
+					log.Printf("Error getting role of user %s in tenant %d: %v", user.ID, targetTenantID, err)
+					abortWithError(c, http.StatusInternalServerError, "Internal server error")
+					return
+				}
+				if role == "" {
+					log.Printf("User %s is not a member of tenant %d", user.ID, targetTenantID)
+					abortWithError(c, http.StatusForbidden, "Forbidden: not a member of the tenant")
 					return
 				}
 
@@ -131,15 +159,12 @@ func Auth(
 				c.Set(types.TenantIDContextKey.String(), targetTenantID)
 				c.Set(types.TenantInfoContextKey.String(), tenant)
 				c.Set("user", user)
-				c.Request = c.Request.WithContext(
-					context.WithValue(
-						context.WithValue(
-							context.WithValue(c.Request.Context(), types.TenantIDContextKey, targetTenantID),
-							types.TenantInfoContextKey, tenant,
-						),
-						"user", user,
-					),
-				)
+				c.Set(types.TenantRoleContextKey.String(), role)
+				ctx := context.WithValue(c.Request.Context(), types.TenantIDContextKey, targetTenantID)
+				ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenant)
+				ctx = context.WithValue(ctx, types.UserContextKey, user)
+				ctx = context.WithValue(ctx, types.TenantRoleContextKey, role)
+				c.Request = c.Request.WithContext(ctx)
 				c.Next()
 				return
 			}
@@ -147,14 +172,15 @@ func Auth(
 
 		// 尝试X-API-Key认证（兼容模式）
 		apiKey := c.GetHeader("X-API-Key")
+		if apiKey == "" && strings.HasPrefix(authHeader, "Bearer ") {
+			// OpenAI 兼容客户端以 Bearer Token 的形式发送 API Key
+			apiKey = strings.TrimPrefix(authHeader, "Bearer ")
+		}
 		if apiKey != "" {
 			// Get tenant information
 			tenantID, err := tenantService.ExtractTenantIDFromAPIKey(apiKey)
 			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Unauthorized: invalid API key format",
-				})
-				c.Abort()
+				abortWithError(c, http.StatusUnauthorized, "Unauthorized: invalid API key format")
 				return
 			}
 
@@ -162,37 +188,29 @@ func Auth(
 			t, err := tenantService.GetTenantByID(c.Request.Context(), tenantID)
 			if err != nil {
 				log.Printf("Error getting tenant by ID: %v, tenantID: %d", err, tenantID)
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Unauthorized: invalid API key",
-				})
-				c.Abort()
+				abortWithError(c, http.StatusUnauthorized, "Unauthorized: invalid API key")
 				return
 			}
 
-			if t == nil || t.APIKey != apiKey {
-				c.JSON(http.StatusUnauthorized, gin.H{
-					"error": "Unauthorized: invalid API key",
-				})
-				c.Abort()
+			if t == nil || string(t.APIKey) != apiKey {
+				abortWithError(c, http.StatusUnauthorized, "Unauthorized: invalid API key")
 				return
 			}
 
-			// Store tenant ID in context
+			// Store tenant ID in context, the API key has full access to its tenant
 			c.Set(types.TenantIDContextKey.String(), tenantID)
 			c.Set(types.TenantInfoContextKey.String(), t)
-			c.Request = c.Request.WithContext(
-				context.WithValue(
-					context.WithValue(c.Request.Context(), types.TenantIDContextKey, tenantID),
-					types.TenantInfoContextKey, t,
-				),
-			)
+			c.Set(types.TenantRoleContextKey.String(), types.TenantRoleOwner)
+			ctx := context.WithValue(c.Request.Context(), types.TenantIDContextKey, tenantID)
+			ctx = context.WithValue(ctx, types.TenantInfoContextKey, t)
+			ctx = context.WithValue(ctx, types.TenantRoleContextKey, types.TenantRoleOwner)
+			c.Request = c.Request.WithContext(ctx)
 			c.Next()
 			return
 		}
 
 		// 没有提供任何认证信息
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: missing authentication"})
-		c.Abort()
+		abortWithError(c, http.StatusUnauthorized, "Unauthorized: missing authentication")
 	}
 }
 
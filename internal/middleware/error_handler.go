@@ -19,6 +19,16 @@ func ErrorHandler() gin.HandlerFunc {
 			// 获取最后一个错误
 			err := c.Errors.Last().Err
 
+			// OpenAI 兼容接口使用 OpenAI 的错误格式
+			if IsOpenAICompatible(c) {
+				if appErr, ok := errors.IsAppError(err); ok {
+					c.JSON(appErr.HTTPCode, openAIAppError(appErr))
+				} else {
+					c.JSON(http.StatusInternalServerError, OpenAIError(http.StatusInternalServerError, "Internal server error"))
+				}
+				return
+			}
+
 			// 检查是否为应用错误
 			if appErr, ok := errors.IsAppError(err); ok {
 				// 返回应用错误
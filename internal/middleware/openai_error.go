@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+)
+
+// OpenAICompatiblePrefix is the path prefix of the OpenAI compatible routes
+const OpenAICompatiblePrefix = "/v1"
+
+// IsOpenAICompatible reports whether the request is served by an OpenAI compatible route,
+// whose errors are rendered as {"error":{"message","type","code"}} like the OpenAI API
+func IsOpenAICompatible(c *gin.Context) bool {
+	path := c.Request.URL.Path
+	return path == OpenAICompatiblePrefix || strings.HasPrefix(path, OpenAICompatiblePrefix+"/")
+}
+
+// OpenAIError builds an error body of the OpenAI API for the HTTP status
+func OpenAIError(status int, message string) gin.H {
+	var errType, code string
+	switch {
+	case status == http.StatusUnauthorized:
+		errType, code = "authentication_error", "invalid_api_key"
+	case status == http.StatusForbidden:
+		errType, code = "permission_error", "permission_denied"
+	case status == http.StatusNotFound:
+		errType, code = "invalid_request_error", "not_found"
+	case status == http.StatusConflict:
+		errType, code = "invalid_request_error", "conflict"
+	case status == http.StatusTooManyRequests:
+		errType, code = "rate_limit_error", "rate_limit_exceeded"
+	case status >= http.StatusInternalServerError:
+		errType, code = "server_error", "internal_error"
+	default:
+		errType, code = "invalid_request_error", "invalid_request"
+	}
+	return gin.H{"error": gin.H{
+		"message": message,
+		"type":    errType,
+		"code":    code,
+	}}
+}
+
+// openAIAppError builds the OpenAI error body of an application error, the details are part of the message
+func openAIAppError(appErr *errors.AppError) gin.H {
+	message := appErr.Message
+	if appErr.Details != nil {
+		message = fmt.Sprintf("%s: %v", message, appErr.Details)
+	}
+	return OpenAIError(appErr.HTTPCode, message)
+}
+
+// abortWithError ends the request with an error message, in the OpenAI format on the OpenAI compatible routes
+func abortWithError(c *gin.Context, status int, message string) {
+	if IsOpenAICompatible(c) {
+		c.AbortWithStatusJSON(status, OpenAIError(status, message))
+		return
+	}
+	c.AbortWithStatusJSON(status, gin.H{"error": message})
+}
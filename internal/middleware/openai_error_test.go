@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+)
+
+func TestOpenAIErrorFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler())
+	notFound := func(c *gin.Context) { c.Error(errors.NewNotFoundError("Model not found: x")) }
+	router.POST("/v1/chat/completions", notFound)
+	router.POST("/api/v1/chat/completions", notFound)
+	router.GET("/v1/models", func(c *gin.Context) {
+		abortWithError(c, http.StatusUnauthorized, "Unauthorized: missing authentication")
+	})
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantType   string
+		wantCode   string
+	}{
+		{http.MethodPost, "/v1/chat/completions", http.StatusNotFound, "invalid_request_error", "not_found"},
+		{http.MethodGet, "/v1/models", http.StatusUnauthorized, "authentication_error", "invalid_api_key"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		var body struct {
+			Success *bool `json:"success"`
+			Error   struct {
+				Message string `json:"message"`
+				Type    string `json:"type"`
+				Code    string `json:"code"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: invalid body %q: %v", tt.path, w.Body.String(), err)
+		}
+		if w.Code != tt.wantStatus || body.Error.Type != tt.wantType || body.Error.Code != tt.wantCode ||
+			body.Error.Message == "" || body.Success != nil {
+			t.Errorf("%s: status %d, body %s", tt.path, w.Code, w.Body.String())
+		}
+	}
+
+	// Other routes keep the application error format
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/chat/completions", nil))
+	var body struct {
+		Success bool `json:"success"`
+		Error   struct {
+			Code errors.ErrorCode `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error.Code != errors.ErrNotFound {
+		t.Errorf("application error body = %s", w.Body.String())
+	}
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// knowledgeBaseResolver returns the knowledge base a request operates on
+type knowledgeBaseResolver func(c *gin.Context) (string, error)
+
+// AccessControl builds the middlewares enforcing tenant roles on routes.
+// It must run after Auth, which stores the role of the request in its context.
+type AccessControl struct {
+	memberService    interfaces.TenantMemberService
+	knowledgeService interfaces.KnowledgeService
+	chunkService     interfaces.ChunkService
+}
+
+// NewAccessControl creates the role based access control middlewares
+func NewAccessControl(
+	memberService interfaces.TenantMemberService,
+	knowledgeService interfaces.KnowledgeService,
+	chunkService interfaces.ChunkService,
+) *AccessControl {
+	return &AccessControl{
+		memberService:    memberService,
+		knowledgeService: knowledgeService,
+		chunkService:     chunkService,
+	}
+}
+
+// RequireRole rejects requests whose role in the tenant does not include role
+func (a *AccessControl) RequireRole(role types.TenantRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requestRole(c).Includes(role) {
+			forbid(c, role)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireTenantRole is RequireRole for routes addressing a tenant by its :id parameter,
+// it also rejects requests for tenants other than the one the request is authenticated for
+func (a *AccessControl) RequireTenantRole(role types.TenantRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.Error(errors.NewBadRequestError("Invalid tenant ID"))
+			c.Abort()
+			return
+		}
+		if tenantID != c.GetUint64(types.TenantIDContextKey.String()) {
+			c.Error(errors.NewForbiddenError("Forbidden: cannot access another tenant"))
+			c.Abort()
+			return
+		}
+		if !requestRole(c).Includes(role) {
+			forbid(c, role)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireKnowledgeBaseRole rejects requests whose role on the knowledge base
+// identified by the path parameter, including its grants, does not include role
+func (a *AccessControl) RequireKnowledgeBaseRole(param string, role types.TenantRole) gin.HandlerFunc {
+	return a.requireKnowledgeBaseRole(role, func(c *gin.Context) (string, error) {
+		return c.Param(param), nil
+	})
+}
+
+// RequireKnowledgeRole is RequireKnowledgeBaseRole for the knowledge base of the knowledge
+// identified by the path parameter
+func (a *AccessControl) RequireKnowledgeRole(param string, role types.TenantRole) gin.HandlerFunc {
+	return a.requireKnowledgeBaseRole(role, func(c *gin.Context) (string, error) {
+		knowledge, err := a.knowledgeService.GetKnowledgeByID(c.Request.Context(), c.Param(param))
+		if err != nil {
+			return "", err
+		}
+		return knowledge.KnowledgeBaseID, nil
+	})
+}
+
+// RequireChunkRole is RequireKnowledgeBaseRole for the knowledge base of the chunk
+// identified by the path parameter
+func (a *AccessControl) RequireChunkRole(param string, role types.TenantRole) gin.HandlerFunc {
+	return a.requireKnowledgeBaseRole(role, func(c *gin.Context) (string, error) {
+		chunk, err := a.chunkService.GetChunkByID(c.Request.Context(), c.Param(param))
+		if err != nil {
+			return "", err
+		}
+		return chunk.KnowledgeBaseID, nil
+	})
+}
+
+// requireKnowledgeBaseRole checks the tenant role first and only looks up knowledge base
+// grants when it is not sufficient, so requests whose knowledge base cannot be resolved
+// are rejected unless the tenant role suffices.
+func (a *AccessControl) requireKnowledgeBaseRole(role types.TenantRole, resolve knowledgeBaseResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if requestRole(c).Includes(role) {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		kbID, err := resolve(c)
+		if err != nil || kbID == "" {
+			logger.Warnf(ctx, "Failed to resolve knowledge base for access check: %v", err)
+			forbid(c, role)
+			return
+		}
+		kbRole, err := a.memberService.GetKnowledgeBaseRole(ctx, kbID)
+		if err != nil {
+			logger.Errorf(ctx, "Failed to get role on knowledge base %s: %v", kbID, err)
+			c.Error(errors.NewInternalServerError("Failed to check permissions"))
+			c.Abort()
+			return
+		}
+		if !kbRole.Includes(role) {
+			forbid(c, role)
+			return
+		}
+		c.Next()
+	}
+}
+
+// requestRole returns the role of the request in its tenant
+func requestRole(c *gin.Context) types.TenantRole {
+	role, _ := c.Request.Context().Value(types.TenantRoleContextKey).(types.TenantRole)
+	return role
+}
+
+// forbid aborts the request for lacking the role
+func forbid(c *gin.Context, role types.TenantRole) {
+	logger.Warnf(c.Request.Context(), "Request to %s %s requires the %s role", c.Request.Method, c.FullPath(), role)
+	c.Error(errors.NewForbiddenError("Forbidden: requires the " + string(role) + " role"))
+	c.Abort()
+}
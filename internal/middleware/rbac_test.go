@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// grantMemberService resolves knowledge base roles from a fixed set of grants
+type grantMemberService struct {
+	interfaces.TenantMemberService
+	grants map[string]types.TenantRole
+}
+
+func (s *grantMemberService) GetKnowledgeBaseRole(ctx context.Context, kbID string) (types.TenantRole, error) {
+	role, _ := ctx.Value(types.TenantRoleContextKey).(types.TenantRole)
+	return types.MaxTenantRole(role, s.grants[kbID]), nil
+}
+
+func TestAccessControl(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	access := NewAccessControl(&grantMemberService{
+		grants: map[string]types.TenantRole{"kb-granted": types.TenantRoleEditor},
+	}, nil, nil)
+
+	tests := []struct {
+		name       string
+		role       types.TenantRole
+		middleware gin.HandlerFunc
+		route      string
+		path       string
+		wantStatus int
+	}{
+		{
+			name:       "viewer cannot edit",
+			role:       types.TenantRoleViewer,
+			middleware: access.RequireRole(types.TenantRoleEditor),
+			route:      "/models",
+			path:       "/models",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "admin includes editor",
+			role:       types.TenantRoleAdmin,
+			middleware: access.RequireRole(types.TenantRoleEditor),
+			route:      "/models",
+			path:       "/models",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "no role",
+			middleware: access.RequireRole(types.TenantRoleViewer),
+			route:      "/models",
+			path:       "/models",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "grant raises viewer",
+			role:       types.TenantRoleViewer,
+			middleware: access.RequireKnowledgeBaseRole("id", types.TenantRoleEditor),
+			route:      "/knowledge-bases/:id",
+			path:       "/knowledge-bases/kb-granted",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "grant limited to its knowledge base",
+			role:       types.TenantRoleViewer,
+			middleware: access.RequireKnowledgeBaseRole("id", types.TenantRoleEditor),
+			route:      "/knowledge-bases/:id",
+			path:       "/knowledge-bases/kb-other",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "grant below required role",
+			role:       types.TenantRoleViewer,
+			middleware: access.RequireKnowledgeBaseRole("id", types.TenantRoleAdmin),
+			route:      "/knowledge-bases/:id",
+			path:       "/knowledge-bases/kb-granted",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "own tenant",
+			role:       types.TenantRoleOwner,
+			middleware: access.RequireTenantRole(types.TenantRoleOwner),
+			route:      "/tenants/:id",
+			path:       "/tenants/1",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "other tenant",
+			role:       types.TenantRoleOwner,
+			middleware: access.RequireTenantRole(types.TenantRoleViewer),
+			route:      "/tenants/:id",
+			path:       "/tenants/2",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(ErrorHandler(), func(c *gin.Context) {
+				c.Set(types.TenantIDContextKey.String(), uint64(1))
+				ctx := context.WithValue(c.Request.Context(), types.TenantIDContextKey, uint64(1))
+				if tt.role != "" {
+					ctx = context.WithValue(ctx, types.TenantRoleContextKey, tt.role)
+				}
+				c.Request = c.Request.WithContext(ctx)
+			})
+			r.PUT(tt.route, tt.middleware, func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
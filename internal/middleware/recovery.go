@@ -22,6 +22,10 @@ func Recovery() gin.HandlerFunc {
 				log.Printf("[PANIC] %s | %v | %s", requestID, err, stacktrace)
 
 				// 返回500错误
+				if IsOpenAICompatible(c) {
+					c.AbortWithStatusJSON(500, OpenAIError(500, fmt.Sprintf("Internal Server Error: %v", err)))
+					return
+				}
 				c.AbortWithStatusJSON(500, gin.H{
 					"error":   "Internal Server Error",
 					"message": fmt.Sprintf("%v", err),
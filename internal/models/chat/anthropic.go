@@ -0,0 +1,517 @@
+package chat
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// anthropicAPIVersion Messages API 版本
+	anthropicAPIVersion = "2023-06-01"
+	// anthropicDefaultMaxTokens Messages API 要求 max_tokens，未配置时使用该值
+	anthropicDefaultMaxTokens = 4096
+	// anthropicThinkingBudget 启用思考时的 token 预算，最小值为 1024
+	anthropicThinkingBudget = 2048
+)
+
+// AnthropicChat 实现了基于 Anthropic Messages API 的聊天
+type AnthropicChat struct {
+	modelName string
+	modelID   string
+	baseURL   string
+	apiKey    string
+	client    *http.Client
+}
+
+// NewAnthropicChat 创建 Anthropic Messages API 聊天实例
+func NewAnthropicChat(chatConfig *ChatConfig) (*AnthropicChat, error) {
+	baseURL := strings.TrimSuffix(chatConfig.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = provider.AnthropicBaseURL
+	}
+	return &AnthropicChat{
+		modelName: chatConfig.ModelName,
+		modelID:   chatConfig.ModelID,
+		baseURL:   baseURL,
+		apiKey:    chatConfig.APIKey,
+		client:    &http.Client{},
+	}, nil
+}
+
+// anthropicContentBlock Messages API 的内容块
+type anthropicContentBlock struct {
+	Type string `json:"type"` // text, tool_use, tool_result, thinking
+
+	// text
+	Text string `json:"text,omitempty"`
+
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// tool_result
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+
+	// thinking
+	Thinking  string `json:"thinking,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// anthropicMessage Messages API 的消息
+type anthropicMessage struct {
+	Role    string                  `json:"role"` // user, assistant
+	Content []anthropicContentBlock `json:"content"`
+}
+
+// anthropicTool Messages API 的工具定义
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// anthropicToolChoice Messages API 的工具选择
+type anthropicToolChoice struct {
+	Type string `json:"type"` // auto, any, none, tool
+	Name string `json:"name,omitempty"`
+}
+
+// anthropicThinking Messages API 的思考配置
+type anthropicThinking struct {
+	Type         string `json:"type"` // enabled
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+// anthropicRequest Messages API 请求
+type anthropicRequest struct {
+	Model       string               `json:"model"`
+	MaxTokens   int                  `json:"max_tokens"`
+	System      string               `json:"system,omitempty"`
+	Messages    []anthropicMessage   `json:"messages"`
+	Temperature *float64             `json:"temperature,omitempty"`
+	TopP        *float64             `json:"top_p,omitempty"`
+	Tools       []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice  *anthropicToolChoice `json:"tool_choice,omitempty"`
+	Thinking    *anthropicThinking   `json:"thinking,omitempty"`
+	Stream      bool                 `json:"stream,omitempty"`
+}
+
+// anthropicUsage Messages API 的 token 用量
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// anthropicResponse Messages API 非流式响应
+type anthropicResponse struct {
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      anthropicUsage          `json:"usage"`
+}
+
+// anthropicError Messages API 错误
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// anthropicStreamEvent Messages API 流式事件，不同事件类型使用不同字段
+type anthropicStreamEvent struct {
+	Type         string                 `json:"type"`
+	Index        int                    `json:"index"`
+	ContentBlock *anthropicContentBlock `json:"content_block,omitempty"` // content_block_start
+	Delta        *struct {
+		Type        string `json:"type"` // text_delta, input_json_delta, thinking_delta, signature_delta
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		Thinking    string `json:"thinking"`
+	} `json:"delta,omitempty"`
+	Error *anthropicError `json:"error,omitempty"` // error
+}
+
+// convertMessages 转换消息格式为 Messages API 格式，system 消息合并为系统提示词，
+// tool 消息转换为 user 消息中的 tool_result 块，连续的同角色消息合并为一条
+func (c *AnthropicChat) convertMessages(messages []Message) (string, []anthropicMessage) {
+	var systemPrompts []string
+	result := make([]anthropicMessage, 0, len(messages))
+
+	for _, msg := range messages {
+		role := msg.Role
+		var blocks []anthropicContentBlock
+
+		switch msg.Role {
+		case "system":
+			if msg.Content != "" {
+				systemPrompts = append(systemPrompts, msg.Content)
+			}
+			continue
+		case "tool":
+			role = "user"
+			blocks = append(blocks, anthropicContentBlock{
+				Type:      "tool_result",
+				ToolUseID: msg.ToolCallID,
+				Content:   msg.Content,
+			})
+		case "assistant":
+			if msg.Content != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				input := json.RawMessage(tc.Function.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, anthropicContentBlock{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Function.Name,
+					Input: input,
+				})
+			}
+		default:
+			role = "user"
+			if msg.Content != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: msg.Content})
+			}
+		}
+
+		if len(blocks) == 0 {
+			continue
+		}
+		if n := len(result); n > 0 && result[n-1].Role == role {
+			result[n-1].Content = append(result[n-1].Content, blocks...)
+			continue
+		}
+		result = append(result, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	return strings.Join(systemPrompts, "\n\n"), result
+}
+
+// buildRequest 构建 Messages API 请求参数
+func (c *AnthropicChat) buildRequest(messages []Message, opts *ChatOptions, isStream bool) *anthropicRequest {
+	system, anthropicMessages := c.convertMessages(messages)
+	req := &anthropicRequest{
+		Model:     c.modelName,
+		MaxTokens: anthropicDefaultMaxTokens,
+		System:    system,
+		Messages:  anthropicMessages,
+		Stream:    isStream,
+	}
+	if opts == nil {
+		return req
+	}
+
+	if opts.MaxCompletionTokens > 0 {
+		req.MaxTokens = opts.MaxCompletionTokens
+	} else if opts.MaxTokens > 0 {
+		req.MaxTokens = opts.MaxTokens
+	}
+
+	// 思考与 temperature/top_p 不兼容；继续工具调用时上一轮的思考块未保留，也不能启用思考
+	if opts.Thinking != nil && *opts.Thinking && !continuesToolUse(messages) {
+		req.Thinking = &anthropicThinking{Type: "enabled", BudgetTokens: anthropicThinkingBudget}
+		if req.MaxTokens <= anthropicThinkingBudget {
+			req.MaxTokens += anthropicThinkingBudget
+		}
+	} else {
+		if opts.Temperature > 0 {
+			// Messages API 的 temperature 范围为 0~1
+			temperature := min(opts.Temperature, 1)
+			req.Temperature = &temperature
+		}
+		if opts.TopP > 0 {
+			topP := opts.TopP
+			req.TopP = &topP
+		}
+	}
+
+	if len(opts.Tools) > 0 {
+		req.Tools = make([]anthropicTool, 0, len(opts.Tools))
+		for _, tool := range opts.Tools {
+			schema := tool.Function.Parameters
+			if len(schema) == 0 {
+				schema = json.RawMessage(`{"type":"object","properties":{}}`)
+			}
+			req.Tools = append(req.Tools, anthropicTool{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				InputSchema: schema,
+			})
+		}
+	}
+
+	// 思考模式下只支持 auto 和 none，其它选择交由模型默认行为
+	if opts.ToolChoice != "" {
+		switch opts.ToolChoice {
+		case "auto", "none":
+			req.ToolChoice = &anthropicToolChoice{Type: opts.ToolChoice}
+		case "required":
+			if req.Thinking == nil {
+				req.ToolChoice = &anthropicToolChoice{Type: "any"}
+			}
+		default:
+			if req.Thinking == nil {
+				req.ToolChoice = &anthropicToolChoice{Type: "tool", Name: opts.ToolChoice}
+			}
+		}
+	}
+
+	// Messages API 没有 JSON 输出模式，与 OpenAI 兼容实现一样在提示中给出 schema
+	if len(opts.Format) > 0 && len(req.Messages) > 0 {
+		last := &req.Messages[len(req.Messages)-1]
+		last.Content = append(last.Content, anthropicContentBlock{
+			Type: "text",
+			Text: fmt.Sprintf("Use this JSON schema: %s", opts.Format),
+		})
+	}
+
+	return req
+}
+
+// continuesToolUse 判断对话是否在工具调用结果之后继续
+func continuesToolUse(messages []Message) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		switch messages[i].Role {
+		case "tool":
+			return true
+		case "assistant":
+			return len(messages[i].ToolCalls) > 0
+		case "user":
+			return false
+		}
+	}
+	return false
+}
+
+// doRequest 发送 Messages API 请求，非 200 响应转换为错误
+func (c *AnthropicChat) doRequest(ctx context.Context, req *anthropicRequest) (*http.Response, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	logger.Infof(ctx, "[LLM Request] model=%s, stream=%v, provider=anthropic, messages=%d, tools=%d",
+		c.modelName, req.Stream, len(req.Messages), len(req.Tools))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/messages", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var errResp struct {
+			Error anthropicError `json:"error"`
+		}
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error.Message != "" {
+			return nil, &StatusError{
+				StatusCode: resp.StatusCode,
+				Message:    errResp.Error.Type + ": " + errResp.Error.Message,
+			}
+		}
+		return nil, &StatusError{StatusCode: resp.StatusCode, Message: string(body)}
+	}
+	return resp, nil
+}
+
+// convertStopReason 将 stop_reason 转换为 OpenAI 风格的 finish_reason
+func convertStopReason(stopReason string) string {
+	switch stopReason {
+	case "tool_use":
+		return "tool_calls"
+	case "max_tokens":
+		return "length"
+	case "":
+		return ""
+	default:
+		return "stop"
+	}
+}
+
+// toolCallFromBlock 将 tool_use 块转换为工具调用
+func toolCallFromBlock(block *anthropicContentBlock, arguments string) types.LLMToolCall {
+	if arguments == "" {
+		arguments = "{}"
+	}
+	return types.LLMToolCall{
+		ID:   block.ID,
+		Type: "function",
+		Function: types.FunctionCall{
+			Name:      block.Name,
+			Arguments: arguments,
+		},
+	}
+}
+
+// Chat 进行非流式聊天
+func (c *AnthropicChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	resp, err := c.doRequest(ctx, c.buildRequest(messages, opts, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var msgResp anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&msgResp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	response := &types.ChatResponse{FinishReason: convertStopReason(msgResp.StopReason)}
+	response.Usage.PromptTokens = msgResp.Usage.InputTokens
+	response.Usage.CompletionTokens = msgResp.Usage.OutputTokens
+	response.Usage.TotalTokens = msgResp.Usage.InputTokens + msgResp.Usage.OutputTokens
+
+	var content strings.Builder
+	for i := range msgResp.Content {
+		block := &msgResp.Content[i]
+		switch block.Type {
+		case "text":
+			content.WriteString(block.Text)
+		case "tool_use":
+			response.ToolCalls = append(response.ToolCalls, toolCallFromBlock(block, string(block.Input)))
+		}
+	}
+	response.Content = content.String()
+
+	return response, nil
+}
+
+// ChatStream 进行流式聊天
+func (c *AnthropicChat) ChatStream(ctx context.Context,
+	messages []Message, opts *ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	resp, err := c.doRequest(ctx, c.buildRequest(messages, opts, true))
+	if err != nil {
+		return nil, fmt.Errorf("create chat completion stream: %w", err)
+	}
+
+	streamChan := make(chan types.StreamResponse)
+
+	go func() {
+		defer close(streamChan)
+		defer resp.Body.Close()
+
+		// 按内容块索引记录 tool_use 块及其增量 JSON 参数
+		toolBlocks := make(map[int]*anthropicContentBlock)
+		toolArgs := make(map[int]*strings.Builder)
+		var toolOrder []int
+
+		buildToolCalls := func() []types.LLMToolCall {
+			if len(toolOrder) == 0 {
+				return nil
+			}
+			result := make([]types.LLMToolCall, 0, len(toolOrder))
+			for _, index := range toolOrder {
+				result = append(result, toolCallFromBlock(toolBlocks[index], toolArgs[index].String()))
+			}
+			return result
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			var evt anthropicStreamEvent
+			if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &evt); err != nil {
+				logger.Warnf(ctx, "Failed to decode Anthropic stream event: %v", err)
+				continue
+			}
+
+			switch evt.Type {
+			case "content_block_start":
+				if evt.ContentBlock != nil && evt.ContentBlock.Type == "tool_use" {
+					toolBlocks[evt.Index] = evt.ContentBlock
+					toolArgs[evt.Index] = &strings.Builder{}
+					toolOrder = append(toolOrder, evt.Index)
+					streamChan <- types.StreamResponse{
+						ResponseType: types.ResponseTypeToolCall,
+						Data: map[string]interface{}{
+							"tool_name":    evt.ContentBlock.Name,
+							"tool_call_id": evt.ContentBlock.ID,
+						},
+					}
+				}
+			case "content_block_delta":
+				if evt.Delta == nil {
+					continue
+				}
+				switch evt.Delta.Type {
+				case "text_delta":
+					if evt.Delta.Text != "" {
+						streamChan <- types.StreamResponse{
+							ResponseType: types.ResponseTypeAnswer,
+							Content:      evt.Delta.Text,
+						}
+					}
+				case "thinking_delta":
+					if evt.Delta.Thinking != "" {
+						streamChan <- types.StreamResponse{
+							ResponseType: types.ResponseTypeThinking,
+							Content:      evt.Delta.Thinking,
+						}
+					}
+				case "input_json_delta":
+					if args, ok := toolArgs[evt.Index]; ok {
+						args.WriteString(evt.Delta.PartialJSON)
+					}
+				}
+			case "message_stop":
+				streamChan <- types.StreamResponse{
+					ResponseType: types.ResponseTypeAnswer,
+					Done:         true,
+					ToolCalls:    buildToolCalls(),
+				}
+				return
+			case "error":
+				if evt.Error != nil {
+					logger.Errorf(ctx, "Anthropic stream error: %s: %s", evt.Error.Type, evt.Error.Message)
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			logger.Errorf(ctx, "Failed to read Anthropic stream: %v", err)
+		}
+
+		// 流异常结束时，与 OpenAI 兼容实现一样发送包含已收集工具调用的结束响应
+		streamChan <- types.StreamResponse{
+			ResponseType: types.ResponseTypeAnswer,
+			Done:         true,
+			ToolCalls:    buildToolCalls(),
+		}
+	}()
+
+	return streamChan, nil
+}
+
+// GetModelName 获取模型名称
+func (c *AnthropicChat) GetModelName() string {
+	return c.modelName
+}
+
+// GetModelID 获取模型ID
+func (c *AnthropicChat) GetModelID() string {
+	return c.modelID
+}
@@ -0,0 +1,258 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAnthropicStandIn starts a local stand-in for the Messages API that records the request
+// and replies with the handler's response
+func newAnthropicStandIn(t *testing.T, reply func(w http.ResponseWriter, req *anthropicRequest)) (*AnthropicChat, *anthropicRequest) {
+	t.Helper()
+	received := &anthropicRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicAPIVersion, r.Header.Get("anthropic-version"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(received))
+		reply(w, received)
+	}))
+	t.Cleanup(server.Close)
+
+	chat, err := NewAnthropicChat(&ChatConfig{
+		Source:    types.ModelSourceAnthropic,
+		BaseURL:   server.URL + "/v1/",
+		ModelName: "claude-test",
+		APIKey:    "test-key",
+		ModelID:   "model-1",
+	})
+	require.NoError(t, err)
+	return chat, received
+}
+
+func TestAnthropicChat(t *testing.T) {
+	chat, received := newAnthropicStandIn(t, func(w http.ResponseWriter, req *anthropicRequest) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+			"content": [
+				{"type": "thinking", "thinking": "hmm", "signature": "sig"},
+				{"type": "text", "text": "Let me search."},
+				{"type": "tool_use", "id": "toolu_2", "name": "search", "input": {"query": "comets"}}
+			],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 10, "output_tokens": 5}
+		}`)
+	})
+
+	messages := []Message{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "system", Content: "Answer briefly."},
+		{Role: "user", Content: "What is a comet?"},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			{ID: "toolu_1", Type: "function", Function: FunctionCall{Name: "search", Arguments: `{"query":"comet"}`}},
+			{ID: "toolu_3", Type: "function", Function: FunctionCall{Name: "list", Arguments: `{}`}},
+		}},
+		{Role: "tool", ToolCallID: "toolu_1", Name: "search", Content: "A comet is an icy body."},
+		{Role: "tool", ToolCallID: "toolu_3", Name: "list", Content: "[]"},
+	}
+	resp, err := chat.Chat(context.Background(), messages, &ChatOptions{
+		Temperature: 1.5,
+		MaxTokens:   512,
+		ToolChoice:  "required",
+		Tools: []Tool{{Type: "function", Function: FunctionDef{
+			Name:        "search",
+			Description: "Search the knowledge base",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"query":{"type":"string"}}}`),
+		}}},
+	})
+	require.NoError(t, err)
+
+	// Request mapping
+	assert.Equal(t, "claude-test", received.Model)
+	assert.Equal(t, 512, received.MaxTokens)
+	assert.Equal(t, "You are helpful.\n\nAnswer briefly.", received.System)
+	require.NotNil(t, received.Temperature)
+	assert.Equal(t, 1.0, *received.Temperature)
+	require.NotNil(t, received.ToolChoice)
+	assert.Equal(t, "any", received.ToolChoice.Type)
+	require.Len(t, received.Tools, 1)
+	assert.JSONEq(t, `{"type":"object","properties":{"query":{"type":"string"}}}`, string(received.Tools[0].InputSchema))
+
+	require.Len(t, received.Messages, 3)
+	assert.Equal(t, "user", received.Messages[0].Role)
+	assert.Equal(t, "assistant", received.Messages[1].Role)
+	require.Len(t, received.Messages[1].Content, 2)
+	assert.Equal(t, "tool_use", received.Messages[1].Content[0].Type)
+	assert.Equal(t, "toolu_1", received.Messages[1].Content[0].ID)
+	assert.JSONEq(t, `{"query":"comet"}`, string(received.Messages[1].Content[0].Input))
+	// Consecutive tool results are sent as one user message
+	assert.Equal(t, "user", received.Messages[2].Role)
+	require.Len(t, received.Messages[2].Content, 2)
+	assert.Equal(t, "tool_result", received.Messages[2].Content[0].Type)
+	assert.Equal(t, "toolu_1", received.Messages[2].Content[0].ToolUseID)
+	assert.Equal(t, "A comet is an icy body.", received.Messages[2].Content[0].Content)
+	assert.Equal(t, "toolu_3", received.Messages[2].Content[1].ToolUseID)
+
+	// Response mapping
+	assert.Equal(t, "Let me search.", resp.Content)
+	assert.Equal(t, "tool_calls", resp.FinishReason)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "toolu_2", resp.ToolCalls[0].ID)
+	assert.Equal(t, "function", resp.ToolCalls[0].Type)
+	assert.Equal(t, "search", resp.ToolCalls[0].Function.Name)
+	assert.JSONEq(t, `{"query":"comets"}`, resp.ToolCalls[0].Function.Arguments)
+	assert.Equal(t, 15, resp.Usage.TotalTokens)
+}
+
+func TestAnthropicChatThinking(t *testing.T) {
+	enabled := true
+	tests := []struct {
+		name         string
+		messages     []Message
+		wantThinking bool
+	}{
+		{
+			name:         "new turn",
+			messages:     []Message{{Role: "user", Content: "hi"}},
+			wantThinking: true,
+		},
+		{
+			name: "continuing tool use",
+			messages: []Message{
+				{Role: "user", Content: "hi"},
+				{Role: "assistant", ToolCalls: []ToolCall{{ID: "toolu_1", Function: FunctionCall{Name: "search"}}}},
+				{Role: "tool", ToolCallID: "toolu_1", Content: "result"},
+			},
+			wantThinking: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat, received := newAnthropicStandIn(t, func(w http.ResponseWriter, req *anthropicRequest) {
+				fmt.Fprint(w, `{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`)
+			})
+			resp, err := chat.Chat(context.Background(), tt.messages, &ChatOptions{
+				Temperature: 0.5,
+				MaxTokens:   1024,
+				Thinking:    &enabled,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "stop", resp.FinishReason)
+
+			if tt.wantThinking {
+				require.NotNil(t, received.Thinking)
+				assert.Equal(t, "enabled", received.Thinking.Type)
+				assert.Greater(t, received.MaxTokens, received.Thinking.BudgetTokens)
+				assert.Nil(t, received.Temperature, "temperature is not supported with thinking")
+			} else {
+				assert.Nil(t, received.Thinking)
+				assert.NotNil(t, received.Temperature)
+			}
+		})
+	}
+}
+
+func TestAnthropicChatStream(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"content":[],"usage":{"input_tokens":10,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Think"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"ping"}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Hello"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":" world"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"search","input":{}}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"query\":"}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"comets\"}"}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":20}}`,
+		`{"type":"message_stop"}`,
+	}
+	chat, received := newAnthropicStandIn(t, func(w http.ResponseWriter, req *anthropicRequest) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, data := range events {
+			var evt struct {
+				Type string `json:"type"`
+			}
+			assert.NoError(t, json.Unmarshal([]byte(data), &evt))
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.Type, data)
+		}
+	})
+
+	stream, err := chat.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	require.NoError(t, err)
+
+	var responses []types.StreamResponse
+	for response := range stream {
+		responses = append(responses, response)
+	}
+	assert.True(t, received.Stream)
+
+	require.Len(t, responses, 5)
+	assert.Equal(t, types.ResponseTypeThinking, responses[0].ResponseType)
+	assert.Equal(t, "Think", responses[0].Content)
+	assert.Equal(t, types.ResponseTypeAnswer, responses[1].ResponseType)
+	assert.Equal(t, "Hello", responses[1].Content)
+	assert.Equal(t, " world", responses[2].Content)
+	assert.Equal(t, types.ResponseTypeToolCall, responses[3].ResponseType)
+	assert.Equal(t, "toolu_1", responses[3].Data["tool_call_id"])
+	assert.Equal(t, "search", responses[3].Data["tool_name"])
+
+	last := responses[4]
+	assert.True(t, last.Done)
+	require.Len(t, last.ToolCalls, 1)
+	assert.Equal(t, "toolu_1", last.ToolCalls[0].ID)
+	assert.JSONEq(t, `{"query":"comets"}`, last.ToolCalls[0].Function.Arguments)
+}
+
+func TestAnthropicChatError(t *testing.T) {
+	chat, _ := newAnthropicStandIn(t, func(w http.ResponseWriter, req *anthropicRequest) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: field required"}}`)
+	})
+
+	_, err := chat.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid_request_error")
+	assert.Contains(t, err.Error(), "max_tokens: field required")
+}
+
+func TestNewChatAnthropic(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *ChatConfig
+	}{
+		{
+			name:   "anthropic source",
+			config: &ChatConfig{Source: types.ModelSourceAnthropic, ModelName: "claude-test"},
+		},
+		{
+			name:   "remote source with anthropic provider",
+			config: &ChatConfig{Source: types.ModelSourceRemote, Provider: "anthropic", BaseURL: "https://proxy.example.com/v1"},
+		},
+		{
+			name:   "remote source with anthropic base URL",
+			config: &ChatConfig{Source: types.ModelSourceRemote, BaseURL: "https://api.anthropic.com/v1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat, err := NewChat(tt.config)
+			require.NoError(t, err)
+			assert.IsType(t, &AnthropicChat{}, chat)
+		})
+	}
+}
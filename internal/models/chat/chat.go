@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/Tencent/WeKnora/internal/models/provider"
 	"github.com/Tencent/WeKnora/internal/models/utils/ollama"
 	"github.com/Tencent/WeKnora/internal/runtime"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -100,7 +101,17 @@ func NewChat(config *ChatConfig) (Chat, error) {
 		}
 		return chat, nil
 	case string(types.ModelSourceRemote):
+		// Anthropic 使用原生 Messages API，其余服务商使用 OpenAI 兼容接口
+		providerName := provider.ProviderName(config.Provider)
+		if providerName == "" {
+			providerName = provider.DetectProvider(config.BaseURL)
+		}
+		if providerName == provider.ProviderAnthropic {
+			return NewAnthropicChat(config)
+		}
 		return NewRemoteAPIChat(config)
+	case string(types.ModelSourceAnthropic):
+		return NewAnthropicChat(config)
 	default:
 		return nil, fmt.Errorf("unsupported chat model source: %s", config.Source)
 	}
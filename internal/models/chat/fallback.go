@@ -0,0 +1,267 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	ollamaapi "github.com/ollama/ollama/api"
+	"github.com/sashabaranov/go-openai"
+)
+
+// StatusError is returned when a model API answers with a non-success HTTP status
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("API request failed with status: %d", e.StatusCode)
+	}
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// errorClass is the class of a failed model request, which decides whether it is retried
+type errorClass string
+
+const (
+	// errorClassFatal errors are returned without retrying or failing over
+	errorClassFatal       errorClass = "fatal"
+	errorClassRateLimit   errorClass = "rate_limit"
+	errorClassTimeout     errorClass = "timeout"
+	errorClassServerError errorClass = "server_error"
+)
+
+// errAttemptTimeout is the cause of attempts cancelled by the per-attempt timeout
+var errAttemptTimeout = errors.New("attempt timeout")
+
+// Default retry policies for error classes the fallback config leaves empty
+var defaultRetryPolicies = map[errorClass]types.ModelRetryPolicy{
+	errorClassRateLimit:   {MaxRetries: 2, InitialBackoffMs: 1000, MaxBackoffMs: 8000},
+	errorClassTimeout:     {MaxRetries: 1, InitialBackoffMs: 500, MaxBackoffMs: 500},
+	errorClassServerError: {MaxRetries: 1, InitialBackoffMs: 500, MaxBackoffMs: 2000},
+}
+
+// classifyError returns the class of a model request error
+func classifyError(err error) errorClass {
+	if errors.Is(err, context.Canceled) {
+		return errorClassFatal
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return errorClassTimeout
+	}
+
+	statusCode := 0
+	var apiErr *openai.APIError
+	var requestErr *openai.RequestError
+	var statusErr *StatusError
+	var ollamaErr ollamaapi.StatusError
+	switch {
+	case errors.As(err, &apiErr):
+		statusCode = apiErr.HTTPStatusCode
+	case errors.As(err, &requestErr):
+		statusCode = requestErr.HTTPStatusCode
+	case errors.As(err, &statusErr):
+		statusCode = statusErr.StatusCode
+	case errors.As(err, &ollamaErr):
+		statusCode = ollamaErr.StatusCode
+	}
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return errorClassRateLimit
+	case statusCode == http.StatusRequestTimeout || statusCode == http.StatusGatewayTimeout:
+		return errorClassTimeout
+	case statusCode >= http.StatusInternalServerError:
+		return errorClassServerError
+	case statusCode != 0:
+		return errorClassFatal
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return errorClassTimeout
+		}
+		return errorClassServerError
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return errorClassServerError
+	}
+	return errorClassFatal
+}
+
+// FallbackChat sends requests to the primary model and fails over to the fallback models in order
+// when a request is rate limited, times out or hits a server error, after retrying the model
+// according to the retry policy of the error class. Streams fail over only while they are opened,
+// a stream that breaks after it started is not replayed on another model.
+type FallbackChat struct {
+	models         []Chat
+	policies       map[errorClass]types.ModelRetryPolicy
+	attemptTimeout time.Duration
+}
+
+// NewFallbackChat creates a chat that fails over from primary to fallbacks
+func NewFallbackChat(primary Chat, fallbacks []Chat, config *types.ModelFallback) *FallbackChat {
+	policies := make(map[errorClass]types.ModelRetryPolicy, len(defaultRetryPolicies))
+	for class, policy := range defaultRetryPolicies {
+		policies[class] = policy
+	}
+	var attemptTimeout time.Duration
+	if config != nil {
+		attemptTimeout = time.Duration(config.AttemptTimeoutMs) * time.Millisecond
+		for class, policy := range map[errorClass]*types.ModelRetryPolicy{
+			errorClassRateLimit:   config.RateLimit,
+			errorClassTimeout:     config.Timeout,
+			errorClassServerError: config.ServerError,
+		} {
+			if policy != nil {
+				policies[class] = *policy
+			}
+		}
+	}
+	return &FallbackChat{
+		models:         append([]Chat{primary}, fallbacks...),
+		policies:       policies,
+		attemptTimeout: attemptTimeout,
+	}
+}
+
+// backoff returns how long to wait before the given retry, starting at 1
+func backoff(policy types.ModelRetryPolicy, retry int) time.Duration {
+	wait := time.Duration(policy.InitialBackoffMs) * time.Millisecond
+	maxWait := time.Duration(policy.MaxBackoffMs) * time.Millisecond
+	for i := 1; i < retry && (maxWait <= 0 || wait < maxWait); i++ {
+		wait *= 2
+	}
+	if maxWait > 0 && wait > maxWait {
+		wait = maxWait
+	}
+	return wait
+}
+
+// attempt calls send on a model with the per-attempt timeout. The attempt context is only cancelled
+// by the timeout while send runs, or by the returned release once the caller is done with the answer,
+// so that a stream opened in time keeps reading after send returns.
+func (c *FallbackChat) attempt(ctx context.Context,
+	model Chat, send func(ctx context.Context, model Chat) error,
+) (release func(), err error) {
+	if c.attemptTimeout <= 0 {
+		return func() {}, send(ctx, model)
+	}
+	attemptCtx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(c.attemptTimeout, func() { cancel(errAttemptTimeout) })
+	err = send(attemptCtx, model)
+	timer.Stop()
+	if err == nil {
+		return func() { cancel(nil) }, nil
+	}
+	timedOut := errors.Is(context.Cause(attemptCtx), errAttemptTimeout)
+	cancel(nil)
+	if timedOut && ctx.Err() == nil {
+		// The model sees a cancelled context, which alone would not be retried
+		return nil, fmt.Errorf("no answer within %v: %w (%v)", c.attemptTimeout, context.DeadlineExceeded, err)
+	}
+	return nil, err
+}
+
+// do calls send on every model in order until one succeeds and returns the model that answered,
+// with the release to call once its answer has been read
+func (c *FallbackChat) do(ctx context.Context,
+	send func(ctx context.Context, model Chat) error,
+) (Chat, func(), error) {
+	var lastErr error
+	for i, model := range c.models {
+		retries := make(map[errorClass]int)
+		for {
+			release, err := c.attempt(ctx, model, send)
+			if err == nil {
+				if i > 0 {
+					logger.Infof(ctx, "Chat request answered by fallback model %s (%s)",
+						model.GetModelID(), model.GetModelName())
+				}
+				return model, release, nil
+			}
+			lastErr = err
+
+			class := classifyError(err)
+			if class == errorClassFatal || ctx.Err() != nil {
+				return nil, nil, err
+			}
+			policy := c.policies[class]
+			if retries[class] >= policy.MaxRetries {
+				logger.Warnf(ctx, "Model %s failed with %s error, failing over: %v", model.GetModelID(), class, err)
+				break
+			}
+			retries[class]++
+			wait := backoff(policy, retries[class])
+			logger.Warnf(ctx, "Model %s failed with %s error, retrying (%d/%d) in %v: %v",
+				model.GetModelID(), class, retries[class], policy.MaxRetries, wait, err)
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+	}
+	return nil, nil, fmt.Errorf("all %d models failed: %w", len(c.models), lastErr)
+}
+
+// Chat 进行非流式聊天
+func (c *FallbackChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	var resp *types.ChatResponse
+	model, release, err := c.do(ctx, func(ctx context.Context, model Chat) error {
+		var err error
+		resp, err = model.Chat(ctx, messages, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	release()
+	resp.ModelID = model.GetModelID()
+	return resp, nil
+}
+
+// ChatStream 进行流式聊天
+func (c *FallbackChat) ChatStream(ctx context.Context,
+	messages []Message, opts *ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	var stream <-chan types.StreamResponse
+	model, release, err := c.do(ctx, func(ctx context.Context, model Chat) error {
+		var err error
+		stream, err = model.ChatStream(ctx, messages, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	modelID := model.GetModelID()
+	streamChan := make(chan types.StreamResponse)
+	go func() {
+		defer close(streamChan)
+		defer release()
+		for response := range stream {
+			response.ModelID = modelID
+			streamChan <- response
+		}
+	}()
+	return streamChan, nil
+}
+
+// GetModelName 获取主模型名称
+func (c *FallbackChat) GetModelName() string {
+	return c.models[0].GetModelName()
+}
+
+// GetModelID 获取主模型ID
+func (c *FallbackChat) GetModelID() string {
+	return c.models[0].GetModelID()
+}
@@ -0,0 +1,275 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedChat fails with the scripted errors in order and succeeds once they are used up
+type scriptedChat struct {
+	id    string
+	errs  []error
+	calls int
+}
+
+func (c *scriptedChat) next() error {
+	c.calls++
+	if c.calls <= len(c.errs) {
+		return c.errs[c.calls-1]
+	}
+	return nil
+}
+
+func (c *scriptedChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	if err := c.next(); err != nil {
+		return nil, err
+	}
+	return &types.ChatResponse{Content: "answer from " + c.id}, nil
+}
+
+func (c *scriptedChat) ChatStream(ctx context.Context,
+	messages []Message, opts *ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	if err := c.next(); err != nil {
+		return nil, err
+	}
+	stream := make(chan types.StreamResponse, 2)
+	stream <- types.StreamResponse{ResponseType: types.ResponseTypeAnswer, Content: "answer from " + c.id}
+	stream <- types.StreamResponse{ResponseType: types.ResponseTypeAnswer, Done: true}
+	close(stream)
+	return stream, nil
+}
+
+func (c *scriptedChat) GetModelName() string { return c.id + "-name" }
+
+func (c *scriptedChat) GetModelID() string { return c.id }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want errorClass
+	}{
+		{"openai rate limit", &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests}, errorClassRateLimit},
+		{"wrapped server error", fmt.Errorf("create chat completion: %w",
+			&openai.RequestError{HTTPStatusCode: http.StatusBadGateway}), errorClassServerError},
+		{"gateway timeout", &StatusError{StatusCode: http.StatusGatewayTimeout}, errorClassTimeout},
+		{"bad request", &StatusError{StatusCode: http.StatusBadRequest}, errorClassFatal},
+		{"deadline", fmt.Errorf("send request: %w", context.DeadlineExceeded), errorClassTimeout},
+		{"canceled", context.Canceled, errorClassFatal},
+		{"unknown", errors.New("no response from API"), errorClassFatal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyError(tt.err))
+		})
+	}
+}
+
+func TestFallbackChat(t *testing.T) {
+	rateLimited := &StatusError{StatusCode: http.StatusTooManyRequests}
+	unavailable := &StatusError{StatusCode: http.StatusServiceUnavailable}
+	fast := &types.ModelRetryPolicy{MaxRetries: 1, InitialBackoffMs: 1, MaxBackoffMs: 1}
+	config := &types.ModelFallback{RateLimit: fast, Timeout: fast, ServerError: fast}
+
+	tests := []struct {
+		name         string
+		primaryErrs  []error
+		fallbackErrs []error
+		wantModelID  string
+		wantErr      bool
+		wantPrimary  int
+		wantFallback int
+	}{
+		{
+			name:        "primary answers",
+			wantModelID: "primary",
+			wantPrimary: 1,
+		},
+		{
+			name:        "retry succeeds on primary",
+			primaryErrs: []error{rateLimited},
+			wantModelID: "primary",
+			wantPrimary: 2,
+		},
+		{
+			name:         "fails over after retries",
+			primaryErrs:  []error{unavailable, unavailable},
+			wantModelID:  "fallback",
+			wantPrimary:  2,
+			wantFallback: 1,
+		},
+		{
+			name:        "fatal error does not fail over",
+			primaryErrs: []error{&StatusError{StatusCode: http.StatusUnauthorized}},
+			wantErr:     true,
+			wantPrimary: 1,
+		},
+		{
+			name:         "all models fail",
+			primaryErrs:  []error{unavailable, unavailable},
+			fallbackErrs: []error{rateLimited, rateLimited},
+			wantErr:      true,
+			wantPrimary:  2,
+			wantFallback: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, stream := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s stream=%v", tt.name, stream), func(t *testing.T) {
+				primary := &scriptedChat{id: "primary", errs: tt.primaryErrs}
+				fallback := &scriptedChat{id: "fallback", errs: tt.fallbackErrs}
+				chat := NewFallbackChat(primary, []Chat{fallback}, config)
+				assert.Equal(t, "primary", chat.GetModelID())
+
+				var modelID, content string
+				var err error
+				if stream {
+					var responses <-chan types.StreamResponse
+					responses, err = chat.ChatStream(context.Background(), nil, nil)
+					if err == nil {
+						for response := range responses {
+							assert.Equal(t, tt.wantModelID, response.ModelID)
+							modelID = response.ModelID
+							content += response.Content
+						}
+					}
+				} else {
+					var resp *types.ChatResponse
+					resp, err = chat.Chat(context.Background(), nil, nil)
+					if err == nil {
+						modelID, content = resp.ModelID, resp.Content
+					}
+				}
+
+				assert.Equal(t, tt.wantPrimary, primary.calls)
+				assert.Equal(t, tt.wantFallback, fallback.calls)
+				if tt.wantErr {
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantModelID, modelID)
+				assert.Equal(t, "answer from "+tt.wantModelID, content)
+			})
+		}
+	}
+}
+
+// hangingChat answers only once delay has passed, or fails with the context error when it is cancelled
+// first. Its streams open at once and send the answer after delay.
+type hangingChat struct {
+	id    string
+	delay time.Duration
+	calls atomic.Int32
+}
+
+func (c *hangingChat) wait(ctx context.Context) error {
+	select {
+	case <-time.After(c.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *hangingChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	c.calls.Add(1)
+	if err := c.wait(ctx); err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	return &types.ChatResponse{Content: "answer from " + c.id}, nil
+}
+
+func (c *hangingChat) ChatStream(ctx context.Context,
+	messages []Message, opts *ChatOptions,
+) (<-chan types.StreamResponse, error) {
+	c.calls.Add(1)
+	stream := make(chan types.StreamResponse, 1)
+	go func() {
+		defer close(stream)
+		if err := c.wait(ctx); err != nil {
+			stream <- types.StreamResponse{ResponseType: types.ResponseTypeError, Content: err.Error(), Done: true}
+			return
+		}
+		stream <- types.StreamResponse{ResponseType: types.ResponseTypeAnswer, Content: "answer from " + c.id, Done: true}
+	}()
+	return stream, nil
+}
+
+func (c *hangingChat) GetModelName() string { return c.id + "-name" }
+
+func (c *hangingChat) GetModelID() string { return c.id }
+
+func TestFallbackChatAttemptTimeout(t *testing.T) {
+	fast := &types.ModelRetryPolicy{MaxRetries: 1, InitialBackoffMs: 1, MaxBackoffMs: 1}
+	config := &types.ModelFallback{Timeout: fast, AttemptTimeoutMs: 50}
+
+	t.Run("fails over after attempts time out", func(t *testing.T) {
+		primary := &hangingChat{id: "primary", delay: time.Minute}
+		fallback := &scriptedChat{id: "fallback"}
+		chat := NewFallbackChat(primary, []Chat{fallback}, config)
+
+		resp, err := chat.Chat(context.Background(), nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "fallback", resp.ModelID)
+		assert.Equal(t, int32(2), primary.calls.Load())
+		assert.Equal(t, 1, fallback.calls)
+	})
+
+	t.Run("answer within the timeout", func(t *testing.T) {
+		primary := &hangingChat{id: "primary", delay: time.Millisecond}
+		chat := NewFallbackChat(primary, []Chat{&scriptedChat{id: "fallback"}}, config)
+
+		resp, err := chat.Chat(context.Background(), nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "primary", resp.ModelID)
+		assert.Equal(t, int32(1), primary.calls.Load())
+	})
+
+	t.Run("stream opened in time outlives the timeout", func(t *testing.T) {
+		primary := &hangingChat{id: "primary", delay: 150 * time.Millisecond}
+		chat := NewFallbackChat(primary, []Chat{&scriptedChat{id: "fallback"}}, config)
+
+		responses, err := chat.ChatStream(context.Background(), nil, nil)
+		require.NoError(t, err)
+		var content string
+		for response := range responses {
+			assert.Equal(t, "primary", response.ModelID)
+			content += response.Content
+		}
+		assert.Equal(t, "answer from primary", content)
+	})
+
+	t.Run("caller cancellation is not retried", func(t *testing.T) {
+		primary := &hangingChat{id: "primary", delay: time.Minute}
+		fallback := &scriptedChat{id: "fallback"}
+		chat := NewFallbackChat(primary, []Chat{fallback}, config)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := chat.Chat(ctx, nil, nil)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, int32(1), primary.calls.Load())
+		assert.Equal(t, 0, fallback.calls)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	policy := types.ModelRetryPolicy{MaxRetries: 5, InitialBackoffMs: 100, MaxBackoffMs: 350}
+	assert.Equal(t, "100ms", backoff(policy, 1).String())
+	assert.Equal(t, "200ms", backoff(policy, 2).String())
+	assert.Equal(t, "350ms", backoff(policy, 3).String())
+	assert.Equal(t, "350ms", backoff(policy, 5).String())
+}
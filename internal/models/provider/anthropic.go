@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// AnthropicBaseURL Anthropic Messages API BaseURL
+	AnthropicBaseURL = "https://api.anthropic.com/v1"
+)
+
+// AnthropicProvider 实现 Anthropic 的 Provider 接口，使用原生 Messages API 而非 OpenAI 兼容接口
+type AnthropicProvider struct{}
+
+func init() {
+	Register(&AnthropicProvider{})
+}
+
+// Info 返回 Anthropic provider 的元数据
+func (p *AnthropicProvider) Info() ProviderInfo {
+	return ProviderInfo{
+		Name:        ProviderAnthropic,
+		DisplayName: "Anthropic",
+		Description: "claude-sonnet-4-5, claude-haiku-4-5, etc.",
+		DefaultURLs: map[types.ModelType]string{
+			types.ModelTypeKnowledgeQA: AnthropicBaseURL,
+		},
+		ModelTypes: []types.ModelType{
+			types.ModelTypeKnowledgeQA,
+		},
+		RequiresAuth: true,
+	}
+}
+
+// ValidateConfig 验证 Anthropic provider 配置
+func (p *AnthropicProvider) ValidateConfig(config *Config) error {
+	if config.APIKey == "" {
+		return fmt.Errorf("API key is required for Anthropic provider")
+	}
+	if config.ModelName == "" {
+		return fmt.Errorf("model name is required")
+	}
+	return nil
+}
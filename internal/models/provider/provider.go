@@ -39,6 +39,8 @@ const (
 	ProviderMiniMax ProviderName = "minimax"
 	// 小米 Mimo
 	ProviderMimo ProviderName = "mimo"
+	// Anthropic Claude (Messages API)
+	ProviderAnthropic ProviderName = "anthropic"
 )
 
 // AllProviders 返回所有注册的提供者名称
@@ -54,6 +56,7 @@ func AllProviders() []ProviderName {
 		ProviderMiniMax,
 		ProviderOpenAI,
 		ProviderGemini,
+		ProviderAnthropic,
 		ProviderOpenRouter,
 		ProviderJina,
 		ProviderMimo,
@@ -208,6 +211,8 @@ func DetectProvider(baseURL string) ProviderName {
 		return ProviderMiniMax
 	case containsAny(baseURL, "mimo.xiaomi.com"):
 		return ProviderMimo
+	case containsAny(baseURL, "api.anthropic.com"):
+		return ProviderAnthropic
 	default:
 		return ProviderGeneric
 	}
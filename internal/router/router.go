@@ -12,7 +12,9 @@ import (
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/handler"
 	"github.com/Tencent/WeKnora/internal/handler/session"
+	"github.com/Tencent/WeKnora/internal/mcpserver"
 	"github.com/Tencent/WeKnora/internal/middleware"
+	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 
 	_ "github.com/Tencent/WeKnora/docs" // swagger docs
@@ -35,6 +37,9 @@ type RouterParams struct {
 	KnowledgeHandler      *handler.KnowledgeHandler
 	TenantHandler         *handler.TenantHandler
 	TenantService         interfaces.TenantService
+	TenantMemberService   interfaces.TenantMemberService
+	TenantMemberHandler   *handler.TenantMemberHandler
+	TokenUsageHandler     *handler.TokenUsageHandler
 	ChunkHandler          *handler.ChunkHandler
 	SessionHandler        *session.Handler
 	MessageHandler        *handler.MessageHandler
@@ -48,6 +53,8 @@ type RouterParams struct {
 	FAQHandler            *handler.FAQHandler
 	TagHandler            *handler.TagHandler
 	CustomAgentHandler    *handler.CustomAgentHandler
+	GitSourceHandler      *handler.GitSourceHandler
+	MCPServer             *mcpserver.Server
 }
 
 // NewRouter 创建新的路由
@@ -87,38 +94,49 @@ func NewRouter(params RouterParams) *gin.Engine {
 	}
 
 	// 认证中间件
-	r.Use(middleware.Auth(params.TenantService, params.UserService, params.Config))
+	r.Use(middleware.Auth(params.TenantService, params.UserService, params.TenantMemberService, params.Config))
 
 	// 添加OpenTelemetry追踪中间件
 	r.Use(middleware.TracingMiddleware())
 
+	// 基于角色的访问控制
+	access := middleware.NewAccessControl(params.TenantMemberService, params.KnowledgeService, params.ChunkService)
+
 	// 需要认证的API路由
 	v1 := r.Group("/api/v1")
 	{
 		RegisterAuthRoutes(v1, params.AuthHandler)
-		RegisterTenantRoutes(v1, params.TenantHandler)
-		RegisterKnowledgeBaseRoutes(v1, params.KBHandler)
-		RegisterKnowledgeTagRoutes(v1, params.TagHandler)
-		RegisterKnowledgeRoutes(v1, params.KnowledgeHandler)
-		RegisterFAQRoutes(v1, params.FAQHandler)
-		RegisterChunkRoutes(v1, params.ChunkHandler)
-		RegisterSessionRoutes(v1, params.SessionHandler)
+		RegisterTenantRoutes(v1, params.TenantHandler, access)
+		RegisterTenantMemberRoutes(v1, params.TenantMemberHandler, access)
+		RegisterTokenUsageRoutes(v1, params.TokenUsageHandler, access)
+		RegisterKnowledgeBaseRoutes(v1, params.KBHandler, access)
+		RegisterKnowledgeTagRoutes(v1, params.TagHandler, access)
+		RegisterGitSourceRoutes(v1, params.GitSourceHandler, access)
+		RegisterKnowledgeRoutes(v1, params.KnowledgeHandler, access)
+		RegisterFAQRoutes(v1, params.FAQHandler, access)
+		RegisterChunkRoutes(v1, params.ChunkHandler, access)
+		RegisterSessionRoutes(v1, params.SessionHandler, access)
 		RegisterChatRoutes(v1, params.SessionHandler)
 		RegisterMessageRoutes(v1, params.MessageHandler)
-		RegisterModelRoutes(v1, params.ModelHandler)
-		RegisterEvaluationRoutes(v1, params.EvaluationHandler)
-		RegisterInitializationRoutes(v1, params.InitializationHandler)
+		RegisterModelRoutes(v1, params.ModelHandler, access)
+		RegisterEvaluationRoutes(v1, params.EvaluationHandler, access)
+		RegisterInitializationRoutes(v1, params.InitializationHandler, access)
 		RegisterSystemRoutes(v1, params.SystemHandler)
-		RegisterMCPServiceRoutes(v1, params.MCPServiceHandler)
+		RegisterMCPServiceRoutes(v1, params.MCPServiceHandler, access)
+		RegisterMCPServerRoutes(v1, params.MCPServer)
 		RegisterWebSearchRoutes(v1, params.WebSearchHandler)
-		RegisterCustomAgentRoutes(v1, params.CustomAgentHandler)
+		RegisterCustomAgentRoutes(v1, params.CustomAgentHandler, access)
 	}
 
+	// OpenAI 兼容接口，供 OpenAI SDK 以 /v1 为 base URL 直接调用
+	RegisterOpenAICompatibleRoutes(r.Group(middleware.OpenAICompatiblePrefix), params.SessionHandler)
+
 	return r
 }
 
 // RegisterChunkRoutes 注册分块相关的路由
-func RegisterChunkRoutes(r *gin.RouterGroup, handler *handler.ChunkHandler) {
+func RegisterChunkRoutes(r *gin.RouterGroup, handler *handler.ChunkHandler, access *middleware.AccessControl) {
+	editor := types.TenantRoleEditor
 	// 分块路由组
 	chunks := r.Group("/chunks")
 	{
@@ -127,27 +145,30 @@ func RegisterChunkRoutes(r *gin.RouterGroup, handler *handler.ChunkHandler) {
 		// 通过chunk_id获取单个chunk（不需要knowledge_id）
 		chunks.GET("/by-id/:id", handler.GetChunkByIDOnly)
 		// 删除分块
-		chunks.DELETE("/:knowledge_id/:id", handler.DeleteChunk)
+		chunks.DELETE("/:knowledge_id/:id", access.RequireKnowledgeRole("knowledge_id", editor), handler.DeleteChunk)
 		// 删除知识下的所有分块
-		chunks.DELETE("/:knowledge_id", handler.DeleteChunksByKnowledgeID)
+		chunks.DELETE("/:knowledge_id", access.RequireKnowledgeRole("knowledge_id", editor), handler.DeleteChunksByKnowledgeID)
 		// 更新分块信息
-		chunks.PUT("/:knowledge_id/:id", handler.UpdateChunk)
+		chunks.PUT("/:knowledge_id/:id", access.RequireKnowledgeRole("knowledge_id", editor), handler.UpdateChunk)
 		// 删除单个生成的问题（通过问题ID）
-		chunks.DELETE("/by-id/:id/questions", handler.DeleteGeneratedQuestion)
+		chunks.DELETE("/by-id/:id/questions", access.RequireChunkRole("id", editor), handler.DeleteGeneratedQuestion)
 	}
 }
 
 // RegisterKnowledgeRoutes 注册知识相关的路由
-func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandler) {
+func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandler, access *middleware.AccessControl) {
+	editor := types.TenantRoleEditor
 	// 知识库下的知识路由组
 	kb := r.Group("/knowledge-bases/:id/knowledge")
 	{
 		// 从文件创建知识
-		kb.POST("/file", handler.CreateKnowledgeFromFile)
+		kb.POST("/file", access.RequireKnowledgeBaseRole("id", editor), handler.CreateKnowledgeFromFile)
 		// 从URL创建知识
-		kb.POST("/url", handler.CreateKnowledgeFromURL)
+		kb.POST("/url", access.RequireKnowledgeBaseRole("id", editor), handler.CreateKnowledgeFromURL)
 		// 手工 Markdown 录入
-		kb.POST("/manual", handler.CreateManualKnowledge)
+		kb.POST("/manual", access.RequireKnowledgeBaseRole("id", editor), handler.CreateManualKnowledge)
+		// 爬取网站创建知识
+		kb.POST("/crawl", access.RequireKnowledgeBaseRole("id", editor), handler.CrawlWebsite)
 		// 获取知识库下的知识列表
 		kb.GET("", handler.ListKnowledge)
 	}
@@ -157,42 +178,49 @@ func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandl
 	{
 		// 批量获取知识
 		k.GET("/batch", handler.GetKnowledgeBatch)
+		// 获取网站爬取进度
+		k.GET("/crawl/progress/:task_id", handler.GetWebCrawlProgress)
 		// 获取知识详情
 		k.GET("/:id", handler.GetKnowledge)
 		// 删除知识
-		k.DELETE("/:id", handler.DeleteKnowledge)
+		k.DELETE("/:id", access.RequireKnowledgeRole("id", editor), handler.DeleteKnowledge)
 		// 更新知识
-		k.PUT("/:id", handler.UpdateKnowledge)
+		k.PUT("/:id", access.RequireKnowledgeRole("id", editor), handler.UpdateKnowledge)
 		// 更新手工 Markdown 知识
-		k.PUT("/manual/:id", handler.UpdateManualKnowledge)
+		k.PUT("/manual/:id", access.RequireKnowledgeRole("id", editor), handler.UpdateManualKnowledge)
+		// 设置 URL 知识定时刷新
+		k.PUT("/:id/refresh-schedule", access.RequireKnowledgeRole("id", editor), handler.UpdateKnowledgeRefreshInterval)
+		// 立即刷新 URL 知识
+		k.POST("/:id/refresh", access.RequireKnowledgeRole("id", editor), handler.RefreshURLKnowledge)
 		// 获取知识文件
 		k.GET("/:id/download", handler.DownloadKnowledgeFile)
 		// 更新图像分块信息
-		k.PUT("/image/:id/:chunk_id", handler.UpdateImageInfo)
-		// 批量更新知识标签
-		k.PUT("/tags", handler.UpdateKnowledgeTagBatch)
+		k.PUT("/image/:id/:chunk_id", access.RequireKnowledgeRole("id", editor), handler.UpdateImageInfo)
+		// 批量更新知识标签，涉及多个知识，要求租户级编辑权限
+		k.PUT("/tags", access.RequireRole(editor), handler.UpdateKnowledgeTagBatch)
 		// 搜索知识
 		k.GET("/search", handler.SearchKnowledge)
 	}
 }
 
 // RegisterFAQRoutes 注册 FAQ 相关路由
-func RegisterFAQRoutes(r *gin.RouterGroup, handler *handler.FAQHandler) {
+func RegisterFAQRoutes(r *gin.RouterGroup, handler *handler.FAQHandler, access *middleware.AccessControl) {
 	if handler == nil {
 		return
 	}
+	editor := access.RequireKnowledgeBaseRole("id", types.TenantRoleEditor)
 	faq := r.Group("/knowledge-bases/:id/faq")
 	{
 		faq.GET("/entries", handler.ListEntries)
 		faq.GET("/entries/export", handler.ExportEntries)
 		faq.GET("/entries/:entry_id", handler.GetEntry)
-		faq.POST("/entries", handler.UpsertEntries)
-		faq.POST("/entry", handler.CreateEntry)
-		faq.PUT("/entries/:entry_id", handler.UpdateEntry)
+		faq.POST("/entries", editor, handler.UpsertEntries)
+		faq.POST("/entry", editor, handler.CreateEntry)
+		faq.PUT("/entries/:entry_id", editor, handler.UpdateEntry)
 		// Unified batch update API - supports is_enabled, is_recommended, tag_id
-		faq.PUT("/entries/fields", handler.UpdateEntryFieldsBatch)
-		faq.PUT("/entries/tags", handler.UpdateEntryTagBatch)
-		faq.DELETE("/entries", handler.DeleteEntries)
+		faq.PUT("/entries/fields", editor, handler.UpdateEntryFieldsBatch)
+		faq.PUT("/entries/tags", editor, handler.UpdateEntryTagBatch)
+		faq.DELETE("/entries", editor, handler.DeleteEntries)
 		faq.POST("/search", handler.SearchFAQ)
 	}
 	// FAQ import progress route (outside of knowledge-base scope)
@@ -203,40 +231,67 @@ func RegisterFAQRoutes(r *gin.RouterGroup, handler *handler.FAQHandler) {
 }
 
 // RegisterKnowledgeBaseRoutes 注册知识库相关的路由
-func RegisterKnowledgeBaseRoutes(r *gin.RouterGroup, handler *handler.KnowledgeBaseHandler) {
+func RegisterKnowledgeBaseRoutes(r *gin.RouterGroup, handler *handler.KnowledgeBaseHandler, access *middleware.AccessControl) {
 	// 知识库路由组
 	kb := r.Group("/knowledge-bases")
 	{
 		// 创建知识库
-		kb.POST("", handler.CreateKnowledgeBase)
+		kb.POST("", access.RequireRole(types.TenantRoleEditor), handler.CreateKnowledgeBase)
 		// 获取知识库列表
 		kb.GET("", handler.ListKnowledgeBases)
 		// 获取知识库详情
 		kb.GET("/:id", handler.GetKnowledgeBase)
 		// 更新知识库
-		kb.PUT("/:id", handler.UpdateKnowledgeBase)
+		kb.PUT("/:id", access.RequireKnowledgeBaseRole("id", types.TenantRoleAdmin), handler.UpdateKnowledgeBase)
 		// 删除知识库
-		kb.DELETE("/:id", handler.DeleteKnowledgeBase)
+		kb.DELETE("/:id", access.RequireKnowledgeBaseRole("id", types.TenantRoleAdmin), handler.DeleteKnowledgeBase)
 		// 混合搜索
 		kb.GET("/:id/hybrid-search", handler.HybridSearch)
 		// 拷贝知识库
-		kb.POST("/copy", handler.CopyKnowledgeBase)
+		kb.POST("/copy", access.RequireRole(types.TenantRoleEditor), handler.CopyKnowledgeBase)
 		// 获取知识库复制进度
 		kb.GET("/copy/progress/:task_id", handler.GetKBCloneProgress)
+		// 使用新的嵌入模型重新向量化知识库
+		kb.POST("/:id/reembed", access.RequireKnowledgeBaseRole("id", types.TenantRoleAdmin), handler.ReembedKnowledgeBase)
+		// 获取知识库重新向量化进度
+		kb.GET("/reembed/progress/:task_id", handler.GetKBReembedProgress)
 	}
 }
 
 // RegisterKnowledgeTagRoutes 注册知识库标签相关路由
-func RegisterKnowledgeTagRoutes(r *gin.RouterGroup, tagHandler *handler.TagHandler) {
+func RegisterKnowledgeTagRoutes(r *gin.RouterGroup, tagHandler *handler.TagHandler, access *middleware.AccessControl) {
 	if tagHandler == nil {
 		return
 	}
+	editor := access.RequireKnowledgeBaseRole("id", types.TenantRoleEditor)
 	kbTags := r.Group("/knowledge-bases/:id/tags")
 	{
 		kbTags.GET("", tagHandler.ListTags)
-		kbTags.POST("", tagHandler.CreateTag)
-		kbTags.PUT("/:tag_id", tagHandler.UpdateTag)
-		kbTags.DELETE("/:tag_id", tagHandler.DeleteTag)
+		kbTags.POST("", editor, tagHandler.CreateTag)
+		kbTags.PUT("/:tag_id", editor, tagHandler.UpdateTag)
+		kbTags.DELETE("/:tag_id", editor, tagHandler.DeleteTag)
+	}
+}
+
+// RegisterGitSourceRoutes 注册知识库Git仓库源相关路由
+func RegisterGitSourceRoutes(
+	r *gin.RouterGroup, gitSourceHandler *handler.GitSourceHandler, access *middleware.AccessControl,
+) {
+	if gitSourceHandler == nil {
+		return
+	}
+	editor := access.RequireKnowledgeBaseRole("id", types.TenantRoleEditor)
+	sources := r.Group("/knowledge-bases/:id/git-sources")
+	{
+		sources.GET("", gitSourceHandler.ListGitSources)
+		sources.POST("", editor, gitSourceHandler.CreateGitSource)
+		sources.GET("/:source_id", gitSourceHandler.GetGitSource)
+		sources.PUT("/:source_id", editor, gitSourceHandler.UpdateGitSource)
+		sources.DELETE("/:source_id", editor, gitSourceHandler.DeleteGitSource)
+		// 手动触发同步
+		sources.POST("/:source_id/sync", editor, gitSourceHandler.SyncGitSource)
+		// 已同步的文件及其提交SHA
+		sources.GET("/:source_id/files", gitSourceHandler.ListGitSourceFiles)
 	}
 }
 
@@ -253,16 +308,22 @@ func RegisterMessageRoutes(r *gin.RouterGroup, handler *handler.MessageHandler)
 }
 
 // RegisterSessionRoutes 注册路由
-func RegisterSessionRoutes(r *gin.RouterGroup, handler *session.Handler) {
+func RegisterSessionRoutes(r *gin.RouterGroup, handler *session.Handler, access *middleware.AccessControl) {
 	sessions := r.Group("/sessions")
 	{
 		sessions.POST("", handler.CreateSession)
-		sessions.GET("/:id", handler.GetSession)
+		sessions.GET("/:session_id", handler.GetSession)
 		sessions.GET("", handler.GetSessionsByTenant)
-		sessions.PUT("/:id", handler.UpdateSession)
-		sessions.DELETE("/:id", handler.DeleteSession)
+		sessions.PUT("/:session_id", handler.UpdateSession)
+		sessions.DELETE("/:session_id", handler.DeleteSession)
 		sessions.POST("/:session_id/generate_title", handler.GenerateTitle)
 		sessions.POST("/:session_id/stop", handler.StopSession)
+		// 审批 Agent 等待中的工具调用
+		sessions.POST("/:session_id/approve", access.RequireRole(types.TenantRoleEditor), handler.ApproveToolCalls)
+		// 会话分享（只读）
+		sessions.POST("/:session_id/shares", handler.ShareSession)
+		sessions.GET("/:session_id/shares", handler.ListSessionShares)
+		sessions.DELETE("/:session_id/shares/:share_id", handler.UnshareSession)
 		// 继续接收活跃流
 		sessions.GET("/continue-stream/:session_id", handler.ContinueStream)
 	}
@@ -288,8 +349,14 @@ func RegisterChatRoutes(r *gin.RouterGroup, handler *session.Handler) {
 	}
 }
 
+// RegisterOpenAICompatibleRoutes 注册 OpenAI 兼容的对话补全路由，模型即智能体
+func RegisterOpenAICompatibleRoutes(r *gin.RouterGroup, handler *session.Handler) {
+	r.GET("/models", handler.ListModels)
+	r.POST("/chat/completions", handler.ChatCompletions)
+}
+
 // RegisterTenantRoutes 注册租户相关的路由
-func RegisterTenantRoutes(r *gin.RouterGroup, handler *handler.TenantHandler) {
+func RegisterTenantRoutes(r *gin.RouterGroup, handler *handler.TenantHandler, access *middleware.AccessControl) {
 	// 添加获取所有租户的路由（需要跨租户权限）
 	r.GET("/tenants/all", handler.ListAllTenants)
 	// 添加搜索租户的路由（需要跨租户权限，支持分页和搜索）
@@ -298,42 +365,70 @@ func RegisterTenantRoutes(r *gin.RouterGroup, handler *handler.TenantHandler) {
 	tenantRoutes := r.Group("/tenants")
 	{
 		tenantRoutes.POST("", handler.CreateTenant)
-		tenantRoutes.GET("/:id", handler.GetTenant)
-		tenantRoutes.PUT("/:id", handler.UpdateTenant)
-		tenantRoutes.DELETE("/:id", handler.DeleteTenant)
+		tenantRoutes.GET("/:id", access.RequireTenantRole(types.TenantRoleViewer), handler.GetTenant)
+		tenantRoutes.PUT("/:id", access.RequireTenantRole(types.TenantRoleAdmin), handler.UpdateTenant)
+		tenantRoutes.DELETE("/:id", access.RequireTenantRole(types.TenantRoleOwner), handler.DeleteTenant)
+		tenantRoutes.POST("/:id/api-key/reveal", access.RequireTenantRole(types.TenantRoleAdmin),
+			handler.RevealTenantAPIKey)
 		tenantRoutes.GET("", handler.ListTenants)
 
 		// Generic KV configuration management (tenant-level)
 		// Tenant ID is obtained from authentication context
 		tenantRoutes.GET("/kv/:key", handler.GetTenantKV)
-		tenantRoutes.PUT("/kv/:key", handler.UpdateTenantKV)
+		tenantRoutes.PUT("/kv/:key", access.RequireRole(types.TenantRoleAdmin), handler.UpdateTenantKV)
+	}
+}
+
+// RegisterTenantMemberRoutes 注册租户成员和知识库授权相关的路由
+func RegisterTenantMemberRoutes(r *gin.RouterGroup, handler *handler.TenantMemberHandler, access *middleware.AccessControl) {
+	members := r.Group("/tenants/:id/members")
+	{
+		members.GET("", access.RequireTenantRole(types.TenantRoleViewer), handler.ListMembers)
+		members.POST("", access.RequireTenantRole(types.TenantRoleAdmin), handler.AddMember)
+		members.PUT("/:user_id", access.RequireTenantRole(types.TenantRoleAdmin), handler.UpdateMember)
+		members.DELETE("/:user_id", access.RequireTenantRole(types.TenantRoleAdmin), handler.RemoveMember)
+	}
+
+	// 知识库授权，为成员单独提升在某个知识库上的角色
+	grants := r.Group("/knowledge-bases/:id/grants")
+	{
+		kbAdmin := access.RequireKnowledgeBaseRole("id", types.TenantRoleAdmin)
+		grants.GET("", kbAdmin, handler.ListKnowledgeBaseGrants)
+		grants.POST("", kbAdmin, handler.GrantKnowledgeBase)
+		grants.DELETE("/:user_id", kbAdmin, handler.RevokeKnowledgeBaseGrant)
 	}
 }
 
+// RegisterTokenUsageRoutes 注册 Token 用量统计相关的路由
+func RegisterTokenUsageRoutes(r *gin.RouterGroup, handler *handler.TokenUsageHandler, access *middleware.AccessControl) {
+	r.GET("/tenants/:id/usage", access.RequireTenantRole(types.TenantRoleAdmin), handler.GetTenantUsage)
+}
+
 // RegisterModelRoutes 注册模型相关的路由
-func RegisterModelRoutes(r *gin.RouterGroup, handler *handler.ModelHandler) {
+func RegisterModelRoutes(r *gin.RouterGroup, handler *handler.ModelHandler, access *middleware.AccessControl) {
+	admin := access.RequireRole(types.TenantRoleAdmin)
 	// 模型路由组
 	models := r.Group("/models")
 	{
 		// 获取模型厂商列表
 		models.GET("/providers", handler.ListModelProviders)
 		// 创建模型
-		models.POST("", handler.CreateModel)
+		models.POST("", admin, handler.CreateModel)
 		// 获取模型列表
 		models.GET("", handler.ListModels)
 		// 获取单个模型
 		models.GET("/:id", handler.GetModel)
 		// 更新模型
-		models.PUT("/:id", handler.UpdateModel)
+		models.PUT("/:id", admin, handler.UpdateModel)
 		// 删除模型
-		models.DELETE("/:id", handler.DeleteModel)
+		models.DELETE("/:id", admin, handler.DeleteModel)
 	}
 }
 
-func RegisterEvaluationRoutes(r *gin.RouterGroup, handler *handler.EvaluationHandler) {
+func RegisterEvaluationRoutes(r *gin.RouterGroup, handler *handler.EvaluationHandler, access *middleware.AccessControl) {
 	evaluationRoutes := r.Group("/evaluation")
 	{
-		evaluationRoutes.POST("/", handler.Evaluation)
+		evaluationRoutes.POST("/", access.RequireRole(types.TenantRoleEditor), handler.Evaluation)
 		evaluationRoutes.GET("/", handler.GetEvaluationResult)
 	}
 }
@@ -349,17 +444,19 @@ func RegisterAuthRoutes(r *gin.RouterGroup, handler *handler.AuthHandler) {
 	r.POST("/auth/change-password", handler.ChangePassword)
 }
 
-func RegisterInitializationRoutes(r *gin.RouterGroup, handler *handler.InitializationHandler) {
+func RegisterInitializationRoutes(r *gin.RouterGroup, handler *handler.InitializationHandler, access *middleware.AccessControl) {
+	kbAdmin := access.RequireKnowledgeBaseRole("kbId", types.TenantRoleAdmin)
+
 	// 初始化接口
 	r.GET("/initialization/config/:kbId", handler.GetCurrentConfigByKB)
-	r.POST("/initialization/initialize/:kbId", handler.InitializeByKB)
-	r.PUT("/initialization/config/:kbId", handler.UpdateKBConfig) // 新的简化版接口，只传模型ID
+	r.POST("/initialization/initialize/:kbId", kbAdmin, handler.InitializeByKB)
+	r.PUT("/initialization/config/:kbId", kbAdmin, handler.UpdateKBConfig) // 新的简化版接口，只传模型ID
 
 	// Ollama相关接口
 	r.GET("/initialization/ollama/status", handler.CheckOllamaStatus)
 	r.GET("/initialization/ollama/models", handler.ListOllamaModels)
 	r.POST("/initialization/ollama/models/check", handler.CheckOllamaModels)
-	r.POST("/initialization/ollama/models/download", handler.DownloadOllamaModel)
+	r.POST("/initialization/ollama/models/download", access.RequireRole(types.TenantRoleAdmin), handler.DownloadOllamaModel)
 	r.GET("/initialization/ollama/download/progress/:taskId", handler.GetDownloadProgress)
 	r.GET("/initialization/ollama/download/tasks", handler.ListDownloadTasks)
 
@@ -383,25 +480,50 @@ func RegisterSystemRoutes(r *gin.RouterGroup, handler *handler.SystemHandler) {
 }
 
 // RegisterMCPServiceRoutes registers MCP service routes
-func RegisterMCPServiceRoutes(r *gin.RouterGroup, handler *handler.MCPServiceHandler) {
+func RegisterMCPServiceRoutes(r *gin.RouterGroup, handler *handler.MCPServiceHandler, access *middleware.AccessControl) {
+	admin := access.RequireRole(types.TenantRoleAdmin)
 	mcpServices := r.Group("/mcp-services")
 	{
 		// Create MCP service
-		mcpServices.POST("", handler.CreateMCPService)
+		mcpServices.POST("", admin, handler.CreateMCPService)
 		// List MCP services
 		mcpServices.GET("", handler.ListMCPServices)
 		// Get MCP service by ID
 		mcpServices.GET("/:id", handler.GetMCPService)
 		// Update MCP service
-		mcpServices.PUT("/:id", handler.UpdateMCPService)
+		mcpServices.PUT("/:id", admin, handler.UpdateMCPService)
 		// Delete MCP service
-		mcpServices.DELETE("/:id", handler.DeleteMCPService)
+		mcpServices.DELETE("/:id", admin, handler.DeleteMCPService)
 		// Test MCP service connection
-		mcpServices.POST("/:id/test", handler.TestMCPService)
+		mcpServices.POST("/:id/test", admin, handler.TestMCPService)
 		// Get MCP service tools
 		mcpServices.GET("/:id/tools", handler.GetMCPServiceTools)
 		// Get MCP service resources
 		mcpServices.GET("/:id/resources", handler.GetMCPServiceResources)
+		// Get MCP service prompts
+		mcpServices.GET("/:id/prompts", handler.GetMCPServicePrompts)
+		// Start the OAuth authorization of an MCP service
+		mcpServices.POST("/:id/oauth/authorize", admin, handler.AuthorizeMCPServiceOAuth)
+		// OAuth callback the authorization server redirects the browser to
+		mcpServices.GET("/oauth/callback", handler.OAuthCallback)
+	}
+}
+
+// RegisterMCPServerRoutes mounts the WeKnora MCP server, scoped to the authenticated tenant
+func RegisterMCPServerRoutes(r *gin.RouterGroup, mcpServer *mcpserver.Server) {
+	if mcpServer == nil {
+		return
+	}
+	streamable := gin.WrapH(mcpServer.StreamableHTTPHandler())
+	mcp := r.Group("/mcp")
+	{
+		// Streamable HTTP transport
+		mcp.POST("", streamable)
+		mcp.GET("", streamable)
+		mcp.DELETE("", streamable)
+		// SSE transport
+		mcp.GET(mcpserver.SSEPath, gin.WrapH(mcpServer.SSEHandler()))
+		mcp.POST(mcpserver.MessagePath, gin.WrapH(mcpServer.MessageHandler()))
 	}
 }
 
@@ -416,22 +538,23 @@ func RegisterWebSearchRoutes(r *gin.RouterGroup, webSearchHandler *handler.WebSe
 }
 
 // RegisterCustomAgentRoutes registers custom agent routes
-func RegisterCustomAgentRoutes(r *gin.RouterGroup, agentHandler *handler.CustomAgentHandler) {
+func RegisterCustomAgentRoutes(r *gin.RouterGroup, agentHandler *handler.CustomAgentHandler, access *middleware.AccessControl) {
+	admin := access.RequireRole(types.TenantRoleAdmin)
 	agents := r.Group("/agents")
 	{
 		// Get placeholder definitions (must be before /:id to avoid conflict)
 		agents.GET("/placeholders", agentHandler.GetPlaceholders)
 		// Create custom agent
-		agents.POST("", agentHandler.CreateAgent)
+		agents.POST("", admin, agentHandler.CreateAgent)
 		// List all agents (including built-in)
 		agents.GET("", agentHandler.ListAgents)
 		// Get agent by ID
 		agents.GET("/:id", agentHandler.GetAgent)
 		// Update agent
-		agents.PUT("/:id", agentHandler.UpdateAgent)
+		agents.PUT("/:id", admin, agentHandler.UpdateAgent)
 		// Delete agent
-		agents.DELETE("/:id", agentHandler.DeleteAgent)
+		agents.DELETE("/:id", admin, agentHandler.DeleteAgent)
 		// Copy agent
-		agents.POST("/:id/copy", agentHandler.CopyAgent)
+		agents.POST("/:id/copy", admin, agentHandler.CopyAgent)
 	}
 }
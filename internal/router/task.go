@@ -1,10 +1,12 @@
 package router
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/hibiken/asynq"
@@ -18,6 +20,8 @@ type AsynqTaskParams struct {
 	KnowledgeService     interfaces.KnowledgeService
 	KnowledgeBaseService interfaces.KnowledgeBaseService
 	TagService           interfaces.KnowledgeTagService
+	GitSourceService     interfaces.GitSourceService
+	SessionService       interfaces.SessionService
 	ChunkExtracter       interfaces.TaskHandler `name:"chunkExtracter"`
 	DataTableSummary     interfaces.TaskHandler `name:"dataTableSummary"`
 }
@@ -81,12 +85,26 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	// Register KB clone handler
 	mux.HandleFunc(types.TypeKBClone, params.KnowledgeService.ProcessKBClone)
 
+	// Register KB re-embedding handler
+	mux.HandleFunc(types.TypeKBReembed, params.KnowledgeService.ProcessKBReembed)
+
 	// Register index delete handler
 	mux.HandleFunc(types.TypeIndexDelete, params.TagService.ProcessIndexDelete)
 
 	// Register KB delete handler
 	mux.HandleFunc(types.TypeKBDelete, params.KnowledgeBaseService.ProcessKBDelete)
 
+	// Register URL refresh handlers
+	mux.HandleFunc(types.TypeURLRefreshScan, params.KnowledgeService.ProcessURLRefreshScan)
+	mux.HandleFunc(types.TypeURLRefresh, params.KnowledgeService.ProcessURLRefresh)
+	mux.HandleFunc(types.TypeWebCrawl, params.KnowledgeService.ProcessWebCrawl)
+
+	// Register Git source sync handler
+	mux.HandleFunc(types.TypeGitSourceSync, params.GitSourceService.ProcessGitSourceSync)
+
+	// Register the sweep of interrupted agent approvals
+	mux.HandleFunc(types.TypeAgentApprovalSweep, params.SessionService.ProcessAgentApprovalSweep)
+
 	go func() {
 		// Start the server
 		if err := params.Server.Run(mux); err != nil {
@@ -95,3 +113,44 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	}()
 	return mux
 }
+
+// defaultURLRefreshScanInterval is how often URL knowledge due for a refresh is looked up
+const defaultURLRefreshScanInterval = 5 * time.Minute
+
+// agentApprovalSweepInterval is how often approved agent runs that never finished resuming are looked up
+const agentApprovalSweepInterval = 5 * time.Minute
+
+// RunAsynqScheduler registers the periodic tasks and starts the scheduler.
+// Every instance runs a scheduler, the periodic tasks are unique so they are enqueued only once per period.
+func RunAsynqScheduler(cfg *config.Config, cleaner interfaces.ResourceCleaner) error {
+	scanInterval := defaultURLRefreshScanInterval
+	if cfg.KnowledgeBase != nil && cfg.KnowledgeBase.URLRefresh != nil &&
+		cfg.KnowledgeBase.URLRefresh.ScanInterval > 0 {
+		scanInterval = cfg.KnowledgeBase.URLRefresh.ScanInterval
+	}
+
+	scheduler := asynq.NewScheduler(getAsynqRedisClientOpt(), nil)
+	if _, err := scheduler.Register(
+		fmt.Sprintf("@every %s", scanInterval),
+		asynq.NewTask(types.TypeURLRefreshScan, nil),
+		asynq.Queue("low"), asynq.MaxRetry(0), asynq.Unique(scanInterval),
+	); err != nil {
+		return err
+	}
+	if _, err := scheduler.Register(
+		fmt.Sprintf("@every %s", agentApprovalSweepInterval),
+		asynq.NewTask(types.TypeAgentApprovalSweep, nil),
+		asynq.Queue("low"), asynq.MaxRetry(0), asynq.Unique(agentApprovalSweepInterval),
+	); err != nil {
+		return err
+	}
+	if err := scheduler.Start(); err != nil {
+		return err
+	}
+
+	cleaner.RegisterWithName("AsynqScheduler", func() error {
+		scheduler.Shutdown()
+		return nil
+	})
+	return nil
+}
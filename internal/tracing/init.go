@@ -94,8 +94,11 @@ func InitTracer() (*Tracer, error) {
 	}, nil
 }
 
-// GetTracer gets global Tracer
+// GetTracer gets global Tracer, falling back to the global provider when InitTracer was not called
 func GetTracer() trace.Tracer {
+	if tracer == nil {
+		return otel.Tracer(AppName)
+	}
 	return tracer
 }
 
@@ -29,6 +29,19 @@ type AgentConfig struct {
 	// MCP service selection
 	MCPSelectionMode string   `json:"mcp_selection_mode"` // MCP selection mode: "all", "selected", "none"
 	MCPServices      []string `json:"mcp_services"`       // Selected MCP service IDs (when mode is "selected")
+	// MCP prompts pinned as system prompt fragments and MCP resources attached as context
+	MCPPrompts   []*MCPPromptRef   `json:"mcp_prompts,omitempty"`
+	MCPResources []*MCPResourceRef `json:"mcp_resources,omitempty"`
+	// Pinned MCP prompts and resources fetched for this run (runtime only)
+	MCPContext []*MCPContextItem `json:"-"`
+	// Result fusion override for the knowledge_search tool
+	FusionConfig *FusionConfig `json:"fusion_config,omitempty"`
+	// Custom agents that can be invoked as tools (runtime only)
+	SubAgents []*SubAgentInfo `json:"-"`
+	// Tools whose calls must be approved by an operator before they run
+	ToolApproval *ToolApprovalPolicy `json:"tool_approval,omitempty"`
+	// Concurrency and timeouts of the tool calls of one round
+	ToolExecution *ToolExecutionPolicy `json:"tool_execution,omitempty"`
 }
 
 // SessionAgentConfig represents session-level agent configuration
@@ -131,6 +144,9 @@ type ToolCall struct {
 	Result     *ToolResult            `json:"result"`               // Execution result (contains Output)
 	Reflection string                 `json:"reflection,omitempty"` // Agent's reflection on this tool call result (if enabled)
 	Duration   int64                  `json:"duration"`             // Execution time in milliseconds
+	SubAgent   *SubAgentTrace         `json:"sub_agent,omitempty"`  // Trace of the delegated agent if the tool is a sub-agent
+	StartedAt  time.Time              `json:"started_at"`           // When the tool started running
+	FinishedAt time.Time              `json:"finished_at"`          // When the tool finished or timed out
 }
 
 // AgentStep represents one iteration of the ReAct loop
@@ -139,6 +155,8 @@ type AgentStep struct {
 	Thought   string     `json:"thought"`    // LLM's reasoning/thinking (Think phase)
 	ToolCalls []ToolCall `json:"tool_calls"` // Tools called in this step (Act phase)
 	Timestamp time.Time  `json:"timestamp"`  // When this step occurred
+	// Wall-clock milliseconds of the Act phase, less than the sum of the tool durations when calls overlap
+	ToolsDuration int64 `json:"tools_duration"`
 }
 
 // GetObservations returns observations from all tool calls in this step
@@ -163,6 +181,29 @@ type AgentState struct {
 	IsComplete    bool            `json:"is_complete"`    // Whether agent has finished
 	FinalAnswer   string          `json:"final_answer"`   // The final answer to the query
 	KnowledgeRefs []*SearchResult `json:"knowledge_refs"` // Collected knowledge references
+	// Traces of sub-agents invoked as tools, in invocation order
+	SubAgentTraces []*SubAgentTrace `json:"sub_agent_traces,omitempty"`
+	// Set when the agent stopped to wait for tool approval (IsComplete is false)
+	Pause *AgentPause `json:"-"`
+}
+
+// SubAgentInfo describes a custom agent that another agent may invoke as a tool (runtime only)
+type SubAgentInfo struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// SubAgentTrace records the execution of a custom agent invoked as a tool by another agent
+type SubAgentTrace struct {
+	ToolCallID  string      `json:"tool_call_id"`    // ID of the parent tool call that invoked the sub-agent
+	AgentID     string      `json:"agent_id"`        // Custom agent ID of the sub-agent
+	AgentName   string      `json:"agent_name"`      // Custom agent name of the sub-agent
+	Task        string      `json:"task"`            // Task delegated by the parent agent
+	Steps       []AgentStep `json:"steps"`           // ReAct steps taken by the sub-agent
+	FinalAnswer string      `json:"final_answer"`    // Answer returned to the parent agent
+	Duration    int64       `json:"duration"`        // Execution time in milliseconds
+	Error       string      `json:"error,omitempty"` // Error message if the sub-agent failed
 }
 
 // FunctionDefinition represents a function definition for LLM function calling
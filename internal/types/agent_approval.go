@@ -0,0 +1,127 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// ToolApprovalPolicy decides which agent tool calls must be approved by an operator before they run
+type ToolApprovalPolicy struct {
+	// Tool names requiring approval. A trailing "*" matches by prefix, e.g. "mcp.*" gates all MCP tools
+	Tools []string `yaml:"tools" json:"tools"`
+}
+
+// RequiresApproval reports whether calls of the given tool must be approved
+func (p *ToolApprovalPolicy) RequiresApproval(toolName string) bool {
+	if p == nil {
+		return false
+	}
+	for _, pattern := range p.Tools {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(toolName, prefix) {
+				return true
+			}
+		} else if pattern == toolName {
+			return true
+		}
+	}
+	return false
+}
+
+// AgentApprovalStatus represents the status of a pending tool approval
+type AgentApprovalStatus string
+
+const (
+	// AgentApprovalStatusPending is waiting for an operator decision
+	AgentApprovalStatusPending AgentApprovalStatus = "pending"
+	// AgentApprovalStatusResuming means the tool calls were approved and the agent run is resuming
+	AgentApprovalStatusResuming AgentApprovalStatus = "resuming"
+	// AgentApprovalStatusApproved means the tool calls were approved and the resumed agent run finished
+	AgentApprovalStatusApproved AgentApprovalStatus = "approved"
+	// AgentApprovalStatusRejected means the tool calls were rejected and the agent run was aborted
+	AgentApprovalStatusRejected AgentApprovalStatus = "rejected"
+	// AgentApprovalStatusFailed means the tool calls were approved but the resumed agent run failed
+	// or was interrupted, e.g. by a server restart
+	AgentApprovalStatusFailed AgentApprovalStatus = "failed"
+)
+
+// PendingToolCall is a tool call waiting for approval
+type PendingToolCall struct {
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// PendingToolCalls represents a list of pending tool calls stored as JSON
+type PendingToolCalls []PendingToolCall
+
+// Value implements the driver.Valuer interface for database serialization
+func (p PendingToolCalls) Value() (driver.Value, error) {
+	if p == nil {
+		return json.Marshal([]PendingToolCall{})
+	}
+	return json.Marshal(p)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (p *PendingToolCalls) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, p)
+}
+
+// AgentSnapshot is the serialized execution state of a paused agent, opaque outside the agent engine
+type AgentSnapshot json.RawMessage
+
+// Value implements the driver.Valuer interface for database serialization
+func (s AgentSnapshot) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return "{}", nil
+	}
+	return string(s), nil
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (s *AgentSnapshot) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = append((*s)[:0], v...)
+	case string:
+		*s = AgentSnapshot(v)
+	}
+	return nil
+}
+
+// AgentPause is returned by the agent engine when it stops to wait for tool approval
+type AgentPause struct {
+	ToolCalls PendingToolCalls `json:"tool_calls"` // Tool calls requiring approval
+	Snapshot  AgentSnapshot    `json:"-"`          // State needed to resume the agent
+}
+
+// AgentApproval is the persisted state of an agent run waiting for tool approval.
+// It survives server restarts so that the run can be resumed from the approve endpoint.
+type AgentApproval struct {
+	ID               string              `json:"id" gorm:"type:varchar(36);primaryKey"`
+	TenantID         uint64              `json:"tenant_id"`
+	SessionID        string              `json:"session_id" gorm:"type:varchar(36)"`
+	MessageID        string              `json:"message_id" gorm:"type:varchar(36)"` // Assistant message being generated
+	AgentID          string              `json:"agent_id" gorm:"type:varchar(36)"`
+	KnowledgeBaseIDs StringArray         `json:"knowledge_base_ids" gorm:"type:json"`
+	KnowledgeIDs     StringArray         `json:"knowledge_ids" gorm:"type:json"`
+	ToolCalls        PendingToolCalls    `json:"tool_calls" gorm:"type:json"`
+	Snapshot         AgentSnapshot       `json:"-" gorm:"type:json"`
+	Status           AgentApprovalStatus `json:"status" gorm:"type:varchar(32)"`
+	Reason           string              `json:"reason,omitempty" gorm:"type:text"`    // Operator comment on the decision
+	RequestedBy      string              `json:"requested_by" gorm:"type:varchar(36)"` // User whose request started the run, empty for API key requests
+	ResolvedBy       string              `json:"resolved_by,omitempty" gorm:"type:varchar(36)"`
+	ResolvedAt       *time.Time          `json:"resolved_at,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+}
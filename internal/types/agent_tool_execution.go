@@ -0,0 +1,49 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// ToolExecutionPolicy controls how the tool calls an agent requests in one round are executed
+type ToolExecutionPolicy struct {
+	// Maximum number of tool calls running at the same time, 1 runs them one after another
+	MaxConcurrency int `yaml:"max_concurrency" json:"max_concurrency"`
+	// Timeout of a tool call in seconds
+	Timeout int `yaml:"timeout" json:"timeout"`
+	// Timeouts in seconds of specific tools. A trailing "*" matches by prefix, e.g. "mcp_*"
+	ToolTimeouts map[string]int `yaml:"tool_timeouts" json:"tool_timeouts,omitempty"`
+}
+
+// Concurrency returns the maximum number of concurrent tool calls, or fallback when not set
+func (p *ToolExecutionPolicy) Concurrency(fallback int) int {
+	if p == nil || p.MaxConcurrency <= 0 {
+		return fallback
+	}
+	return p.MaxConcurrency
+}
+
+// TimeoutFor returns the timeout of a call of the given tool. An exact tool entry wins over the
+// longest matching prefix, which wins over the policy timeout, and fallback applies when none is set.
+func (p *ToolExecutionPolicy) TimeoutFor(toolName string, fallback time.Duration) time.Duration {
+	if p == nil {
+		return fallback
+	}
+	if seconds, ok := p.ToolTimeouts[toolName]; ok && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	matched, seconds := -1, 0
+	for pattern, s := range p.ToolTimeouts {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if ok && s > 0 && len(prefix) > matched && strings.HasPrefix(toolName, prefix) {
+			matched, seconds = len(prefix), s
+		}
+	}
+	if matched >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if p.Timeout > 0 {
+		return time.Duration(p.Timeout) * time.Second
+	}
+	return fallback
+}
@@ -25,6 +25,8 @@ type ChatResponse struct {
 	ToolCalls []LLMToolCall `json:"tool_calls,omitempty"`
 	// Finish reason
 	FinishReason string `json:"finish_reason,omitempty"` // "stop", "tool_calls", "length", etc.
+	// ID of the model that answered, set when the request went through a fallback chain
+	ModelID string `json:"model_id,omitempty"`
 	// Usage information
 	Usage struct {
 		// Prompt tokens
@@ -60,6 +62,10 @@ const (
 	ResponseTypeAgentQuery ResponseType = "agent_query"
 	// Complete response type (agent complete)
 	ResponseTypeComplete ResponseType = "complete"
+	// Sub-agent response type (nested event of an agent invoked as a tool)
+	ResponseTypeSubAgent ResponseType = "sub_agent"
+	// Approval required response type (agent paused until tool calls are approved)
+	ResponseTypeApprovalRequired ResponseType = "approval_required"
 )
 
 // StreamResponse stream response
@@ -82,6 +88,8 @@ type StreamResponse struct {
 	ToolCalls []LLMToolCall `json:"tool_calls,omitempty"`
 	// Additional metadata for enhanced display
 	Data map[string]interface{} `json:"data,omitempty"`
+	// ID of the model that answered, set when the request went through a fallback chain
+	ModelID string `json:"model_id,omitempty"`
 }
 
 // References references
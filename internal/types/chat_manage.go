@@ -12,11 +12,13 @@ type ChatManage struct {
 	KnowledgeIDs     []string `json:"knowledge_ids,omitempty"` // IDs of specific files to search (optional)
 	// SearchTargets is the pre-computed unified search targets
 	// Computed once at request entry point, used throughout the pipeline
-	SearchTargets    SearchTargets `json:"-"`
-	VectorThreshold  float64       `json:"vector_threshold"`  // Minimum score threshold for vector search results
-	KeywordThreshold float64       `json:"keyword_threshold"` // Minimum score threshold for keyword search results
-	EmbeddingTopK    int           `json:"embedding_top_k"`   // Number of top results to retrieve from embedding search
-	VectorDatabase   string        `json:"vector_database"`   // Vector database type/name to use
+	SearchTargets    SearchTargets   `json:"-"`
+	VectorThreshold  float64         `json:"vector_threshold"`  // Minimum score threshold for vector search results
+	KeywordThreshold float64         `json:"keyword_threshold"` // Minimum score threshold for keyword search results
+	EmbeddingTopK    int             `json:"embedding_top_k"`   // Number of top results to retrieve from embedding search
+	VectorDatabase   string          `json:"vector_database"`   // Vector database type/name to use
+	FusionConfig     *FusionConfig   `json:"fusion_config"`     // Result fusion override for hybrid search (optional)
+	RetrieveFilter   *RetrieveFilter `json:"retrieve_filter"`   // Metadata filter applied to knowledge search (optional)
 
 	RerankModelID   string  `json:"rerank_model_id"`  // Model ID for reranking search results
 	RerankTopK      int     `json:"rerank_top_k"`     // Number of top results after reranking
@@ -25,15 +27,18 @@ type ChatManage struct {
 	MaxRounds int `json:"max_rounds"` // Maximum history rounds used for rewrite/context
 
 	ChatModelID      string           `json:"chat_model_id"`     // ID of the chat model to use
+	ModelFallback    *ModelFallback   `json:"model_fallback"`    // Fallback models of the chat model (optional)
 	SummaryConfig    SummaryConfig    `json:"summary_config"`    // Configuration for summary generation
 	FallbackStrategy FallbackStrategy `json:"fallback_strategy"` // Strategy when no relevant results are found
 	FallbackResponse string           `json:"fallback_response"` // Default response when fallback occurs
 	FallbackPrompt   string           `json:"fallback_prompt"`   // Prompt for model-based fallback response
 
-	EnableRewrite        bool   `json:"enable_rewrite"`         // Whether to enable rewrite
-	EnableQueryExpansion bool   `json:"enable_query_expansion"` // Whether to enable query expansion with LLM
-	RewritePromptSystem  string `json:"rewrite_prompt_system"`  // Custom system prompt for rewrite stage
-	RewritePromptUser    string `json:"rewrite_prompt_user"`    // Custom user prompt for rewrite stage
+	EnableRewrite        bool     `json:"enable_rewrite"`         // Whether to enable rewrite
+	EnableQueryExpansion bool     `json:"enable_query_expansion"` // Whether to enable query expansion with LLM
+	EnableHyDE           bool     `json:"enable_hyde"`            // Whether to retrieve with a hypothetical answer passage
+	HyDEMode             HyDEMode `json:"hyde_mode"`              // How the hypothetical passage is combined with the query
+	RewritePromptSystem  string   `json:"rewrite_prompt_system"`  // Custom system prompt for rewrite stage
+	RewritePromptUser    string   `json:"rewrite_prompt_user"`    // Custom user prompt for rewrite stage
 
 	// Internal fields for pipeline data processing
 	SearchResult    []*SearchResult   `json:"-"` // Results from search phase
@@ -44,6 +49,7 @@ type ChatManage struct {
 	EntityKnowledge map[string]string `json:"-"` // KnowledgeID -> KnowledgeBaseID mapping for graph-enabled files
 	GraphResult     *GraphData        `json:"-"` // Graph data from search phase
 	UserContent     string            `json:"-"` // Processed user content
+	HypotheticalDoc string            `json:"-"` // Hypothetical answer passage generated for HyDE retrieval
 	ChatResponse    *ChatResponse     `json:"-"` // Final response from chat model
 
 	// Event system for streaming responses
@@ -58,6 +64,9 @@ type ChatManage struct {
 	FAQPriorityEnabled       bool    `json:"-"` // Whether FAQ priority strategy is enabled
 	FAQDirectAnswerThreshold float64 `json:"-"` // Threshold for direct FAQ answer (similarity > this value)
 	FAQScoreBoost            float64 `json:"-"` // Score multiplier for FAQ results
+
+	// AgentConfigHash identifies the custom agent configuration answering the request (optional)
+	AgentConfigHash string `json:"-"`
 }
 
 // Clone creates a deep copy of the ChatManage object
@@ -96,10 +105,13 @@ func (c *ChatManage) Clone() *ChatManage {
 		EmbeddingTopK:    c.EmbeddingTopK,
 		MaxRounds:        c.MaxRounds,
 		VectorDatabase:   c.VectorDatabase,
+		FusionConfig:     c.FusionConfig,
+		RetrieveFilter:   c.RetrieveFilter,
 		RerankModelID:    c.RerankModelID,
 		RerankTopK:       c.RerankTopK,
 		RerankThreshold:  c.RerankThreshold,
 		ChatModelID:      c.ChatModelID,
+		ModelFallback:    c.ModelFallback,
 		SummaryConfig: SummaryConfig{
 			MaxTokens:           c.SummaryConfig.MaxTokens,
 			RepeatPenalty:       c.SummaryConfig.RepeatPenalty,
@@ -121,11 +133,14 @@ func (c *ChatManage) Clone() *ChatManage {
 		RewritePromptUser:    c.RewritePromptUser,
 		EnableRewrite:        c.EnableRewrite,
 		EnableQueryExpansion: c.EnableQueryExpansion,
+		EnableHyDE:           c.EnableHyDE,
+		HyDEMode:             c.HyDEMode,
 		TenantID:             c.TenantID,
 		// FAQ Strategy Settings
 		FAQPriorityEnabled:       c.FAQPriorityEnabled,
 		FAQDirectAnswerThreshold: c.FAQDirectAnswerThreshold,
 		FAQScoreBoost:            c.FAQScoreBoost,
+		AgentConfigHash:          c.AgentConfigHash,
 	}
 }
 
@@ -135,6 +150,8 @@ type EventType string
 const (
 	LOAD_HISTORY           EventType = "load_history"           // Load conversation history without rewriting
 	REWRITE_QUERY          EventType = "rewrite_query"          // Query rewriting for better retrieval
+	RESPONSE_CACHE         EventType = "response_cache"         // Answer from the semantic response cache
+	HYDE_GENERATE          EventType = "hyde_generate"          // Generate a hypothetical answer passage for retrieval
 	CHUNK_SEARCH           EventType = "chunk_search"           // Search for relevant chunks
 	CHUNK_SEARCH_PARALLEL  EventType = "chunk_search_parallel"  // Parallel search: chunks + entities
 	ENTITY_SEARCH          EventType = "entity_search"          // Search for relevant entities
@@ -171,6 +188,8 @@ var Pipline = map[string][]EventType{
 	},
 	"rag_stream": { // Streaming Retrieval Augmented Generation
 		REWRITE_QUERY,
+		RESPONSE_CACHE,        // No-op unless the response cache is enabled, stops the pipeline on a hit
+		HYDE_GENERATE,         // No-op unless HyDE is enabled
 		CHUNK_SEARCH_PARALLEL, // Parallel: CHUNK_SEARCH + ENTITY_SEARCH
 		CHUNK_RERANK,
 		CHUNK_MERGE,
@@ -181,3 +200,13 @@ var Pipline = map[string][]EventType{
 		STREAM_FILTER,
 	},
 }
+
+// HyDEMode controls how the hypothetical document is used for vector retrieval
+type HyDEMode string
+
+const (
+	// HyDEModeAppend embeds the hypothetical passage in addition to the query
+	HyDEModeAppend HyDEMode = "append"
+	// HyDEModeReplace embeds only the hypothetical passage; keyword retrieval still uses the query
+	HyDEModeReplace HyDEMode = "replace"
+)
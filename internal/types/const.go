@@ -12,6 +12,12 @@ const (
 	RequestIDContextKey ContextKey = "RequestID"
 	// LoggerContextKey is the context key for logger
 	LoggerContextKey ContextKey = "Logger"
+	// UserContextKey is the context key for the user authenticated with a login token
+	UserContextKey ContextKey = "User"
+	// TenantRoleContextKey is the context key for the role of the request in its tenant
+	TenantRoleContextKey ContextKey = "TenantRole"
+	// SessionIDContextKey is the context key for the chat session a request belongs to
+	SessionIDContextKey ContextKey = "SessionID"
 )
 
 // String returns the string representation of the context key
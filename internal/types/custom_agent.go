@@ -53,6 +53,9 @@ type CustomAgent struct {
 
 	// Agent configuration
 	Config CustomAgentConfig `yaml:"config" json:"config" gorm:"type:json"`
+	// Why the declared pipeline no longer validates against the registered chat plugins, if it does not.
+	// Conversations then run the built-in pipeline instead.
+	PipelineError string `yaml:"pipeline_error" json:"pipeline_error,omitempty" gorm:"-"`
 
 	// Timestamps
 	CreatedAt time.Time      `yaml:"created_at" json:"created_at"`
@@ -73,6 +76,8 @@ type CustomAgentConfig struct {
 	// ===== Model Settings =====
 	// Model ID to use for conversations
 	ModelID string `yaml:"model_id" json:"model_id"`
+	// Fallback chat models, overrides the fallback declared by the model
+	ModelFallback *ModelFallback `yaml:"model_fallback" json:"model_fallback,omitempty"`
 	// ReRank model ID for retrieval
 	RerankModelID string `yaml:"rerank_model_id" json:"rerank_model_id"`
 	// Temperature for LLM (0-1)
@@ -91,6 +96,16 @@ type CustomAgentConfig struct {
 	MCPSelectionMode string `yaml:"mcp_selection_mode" json:"mcp_selection_mode"`
 	// Selected MCP service IDs (only used when MCPSelectionMode is "selected")
 	MCPServices []string `yaml:"mcp_services" json:"mcp_services"`
+	// MCP prompts appended to the system prompt (only for agent type)
+	MCPPrompts []*MCPPromptRef `yaml:"mcp_prompts" json:"mcp_prompts,omitempty"`
+	// MCP resources attached to the agent's context (only for agent type)
+	MCPResources []*MCPResourceRef `yaml:"mcp_resources" json:"mcp_resources,omitempty"`
+	// Custom agents (agent mode) this agent may invoke as tools (only for agent type)
+	SubAgents []string `yaml:"sub_agents" json:"sub_agents,omitempty"`
+	// Tools whose calls pause the agent until an operator approves them (only for agent type)
+	ToolApproval *ToolApprovalPolicy `yaml:"tool_approval" json:"tool_approval,omitempty"`
+	// Concurrency and timeouts of the tool calls requested in one round (only for agent type)
+	ToolExecution *ToolExecutionPolicy `yaml:"tool_execution" json:"tool_execution,omitempty"`
 
 	// ===== Knowledge Base Settings =====
 	// Knowledge base selection mode: "all" = all KBs, "selected" = specific KBs, "none" = no KB
@@ -129,10 +144,16 @@ type CustomAgentConfig struct {
 	RerankTopK int `yaml:"rerank_top_k" json:"rerank_top_k"`
 	// Rerank threshold
 	RerankThreshold float64 `yaml:"rerank_threshold" json:"rerank_threshold"`
+	// Result fusion for hybrid search; overrides the knowledge base setting when set
+	FusionConfig *FusionConfig `yaml:"fusion_config" json:"fusion_config,omitempty"`
 
 	// ===== Advanced Settings (mainly for normal mode) =====
 	// Whether to enable query expansion
 	EnableQueryExpansion bool `yaml:"enable_query_expansion" json:"enable_query_expansion"`
+	// Whether to retrieve with a hypothetical answer passage generated by the chat model (HyDE)
+	EnableHyDE bool `yaml:"enable_hyde" json:"enable_hyde"`
+	// HyDE mode: "append" embeds the passage in addition to the query, "replace" embeds only the passage
+	HyDEMode string `yaml:"hyde_mode" json:"hyde_mode"`
 	// Whether to enable query rewrite for multi-turn conversations
 	EnableRewrite bool `yaml:"enable_rewrite" json:"enable_rewrite"`
 	// Rewrite prompt system message
@@ -145,6 +166,8 @@ type CustomAgentConfig struct {
 	FallbackResponse string `yaml:"fallback_response" json:"fallback_response"`
 	// Fallback prompt (when FallbackStrategy is "model")
 	FallbackPrompt string `yaml:"fallback_prompt" json:"fallback_prompt"`
+	// Chat pipeline stages (only for normal mode); replaces the built-in rag_stream pipeline when set
+	Pipeline []PipelineStage `yaml:"pipeline" json:"pipeline,omitempty"`
 }
 
 // Value implements driver.Valuer interface for CustomAgentConfig
@@ -1,5 +1,12 @@
 package types
 
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strconv"
+)
+
 // SourceType represents the type of content source
 type SourceType int
 
@@ -36,4 +43,75 @@ type IndexInfo struct {
 	KnowledgeBaseID string     // ID of the knowledge base
 	KnowledgeType   string     // Type of the knowledge (e.g., "faq", "manual")
 	IsEnabled       bool       // Whether the chunk is enabled for retrieval
+	// Attributes evaluated by metadata filters, nil when the engine does not need them
+	Attributes *IndexAttributes
+}
+
+// IndexAttributes are the chunk and knowledge attributes stored with an index entry,
+// so engines outside the database can evaluate a RetrieveFilter themselves
+type IndexAttributes struct {
+	// Tags of the chunk and of its knowledge
+	TagIDs []string
+	// File type of the knowledge
+	FileType string
+	// Creation time of the knowledge in Unix milliseconds
+	CreatedAt int64
+	// Top-level scalar values of the chunk metadata, encoded with MetadataPair
+	MetadataPairs []string
+}
+
+// NewIndexAttributes collects the filter attributes of a chunk of the given knowledge
+func NewIndexAttributes(chunk *Chunk, knowledge *Knowledge) *IndexAttributes {
+	return &IndexAttributes{
+		TagIDs:        IndexTagIDs(chunk.TagID, knowledge.TagID),
+		FileType:      knowledge.FileType,
+		CreatedAt:     knowledge.CreatedAt.UnixMilli(),
+		MetadataPairs: chunkMetadataPairs(chunk.Metadata),
+	}
+}
+
+// IndexTagIDs returns the tags stored for a chunk, a filter on tags matches the chunk or the knowledge tag
+func IndexTagIDs(chunkTagID, knowledgeTagID string) []string {
+	tagIDs := make([]string, 0, 2)
+	if chunkTagID != "" {
+		tagIDs = append(tagIDs, chunkTagID)
+	}
+	if knowledgeTagID != "" && knowledgeTagID != chunkTagID {
+		tagIDs = append(tagIDs, knowledgeTagID)
+	}
+	return tagIDs
+}
+
+// MetadataPair encodes a metadata key and value as a single keyword, so engines can match
+// metadata with the same term filter whatever the key
+func MetadataPair(key, value string) string {
+	pair, _ := json.Marshal([2]string{key, value})
+	return string(pair)
+}
+
+// chunkMetadataPairs encodes the top-level scalar values of chunk metadata the way Postgres
+// renders them with ->>, nested objects and arrays are not matched by metadata filters
+func chunkMetadataPairs(metadata JSON) []string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(metadata))
+	decoder.UseNumber()
+	var values map[string]any
+	if err := decoder.Decode(&values); err != nil {
+		return nil
+	}
+	pairs := make([]string, 0, len(values))
+	for key, value := range values {
+		switch v := value.(type) {
+		case string:
+			pairs = append(pairs, MetadataPair(key, v))
+		case json.Number:
+			pairs = append(pairs, MetadataPair(key, v.String()))
+		case bool:
+			pairs = append(pairs, MetadataPair(key, strconv.FormatBool(v)))
+		}
+	}
+	slices.Sort(pairs)
+	return pairs
 }
@@ -7,9 +7,15 @@ const (
 	TypeQuestionGeneration = "question:generation" // 问题生成任务
 	TypeSummaryGeneration  = "summary:generation"  // 摘要生成任务
 	TypeKBClone            = "kb:clone"            // 知识库复制任务
+	TypeKBReembed          = "kb:reembed"          // 知识库重新向量化任务
 	TypeIndexDelete        = "index:delete"        // 索引删除任务
 	TypeKBDelete           = "kb:delete"           // 知识库删除任务
 	TypeDataTableSummary   = "datatable:summary"   // 表格摘要任务
+	TypeURLRefreshScan     = "url:refresh_scan"    // URL知识定时刷新扫描任务
+	TypeURLRefresh         = "url:refresh"         // URL知识刷新任务
+	TypeWebCrawl           = "web:crawl"           // 网站爬取任务
+	TypeGitSourceSync      = "git_source:sync"     // Git仓库同步任务
+	TypeAgentApprovalSweep = "approval:sweep"      // 中断的Agent审批清理任务
 )
 
 // ExtractChunkPayload represents the extract chunk task payload
@@ -35,6 +41,12 @@ type DocumentProcessPayload struct {
 	QuestionCount            int      `json:"question_count,omitempty"`   // 每个chunk生成的问题数量
 }
 
+// URLRefreshPayload represents the URL knowledge refresh task payload
+type URLRefreshPayload struct {
+	TenantID    uint64 `json:"tenant_id"`
+	KnowledgeID string `json:"knowledge_id"`
+}
+
 // FAQImportPayload represents the FAQ import task payload
 type FAQImportPayload struct {
 	TenantID    uint64            `json:"tenant_id"`
@@ -68,6 +80,15 @@ type KBClonePayload struct {
 	TargetID string `json:"target_id"`
 }
 
+// KBReembedPayload represents the knowledge base re-embedding task payload
+type KBReembedPayload struct {
+	TenantID         uint64 `json:"tenant_id"`
+	TaskID           string `json:"task_id"`
+	KnowledgeBaseID  string `json:"knowledge_base_id"`
+	SourceModelID    string `json:"source_model_id"`
+	EmbeddingModelID string `json:"embedding_model_id"`
+}
+
 // IndexDeletePayload represents the index delete task payload
 type IndexDeletePayload struct {
 	TenantID         uint64                  `json:"tenant_id"`
@@ -110,6 +131,24 @@ type KBCloneProgress struct {
 	UpdatedAt int64             `json:"updated_at"` // 最后更新时间
 }
 
+// KBReembedProgress represents the progress of a knowledge base re-embedding task
+type KBReembedProgress struct {
+	TaskID          string            `json:"task_id"`
+	KnowledgeBaseID string            `json:"knowledge_base_id"`
+	SourceModelID   string            `json:"source_model_id"`  // 原嵌入模型
+	TargetModelID   string            `json:"target_model_id"`  // 新嵌入模型
+	SourceDimension int               `json:"source_dimension"` // 原向量维度
+	TargetDimension int               `json:"target_dimension"` // 新向量维度
+	Status          KBCloneTaskStatus `json:"status"`
+	Progress        int               `json:"progress"`   // 0-100
+	Total           int               `json:"total"`      // 总分块数
+	Processed       int               `json:"processed"`  // 已处理分块数
+	Message         string            `json:"message"`    // 状态消息
+	Error           string            `json:"error"`      // 错误信息
+	CreatedAt       int64             `json:"created_at"` // 任务创建时间
+	UpdatedAt       int64             `json:"updated_at"` // 最后更新时间
+}
+
 // ChunkContext represents chunk content with surrounding context
 type ChunkContext struct {
 	ChunkID     string `json:"chunk_id"`
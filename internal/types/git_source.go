@@ -0,0 +1,116 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Git source sync status
+const (
+	GitSourceSyncPending   = "pending"
+	GitSourceSyncSyncing   = "syncing"
+	GitSourceSyncCompleted = "completed"
+	GitSourceSyncFailed    = "failed"
+)
+
+// GitSource is a Git repository whose Markdown and source files are synced into a knowledge base
+type GitSource struct {
+	// Unique identifier of the source (UUID)
+	ID string `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	// Tenant ID
+	TenantID uint64 `json:"tenant_id"`
+	// Knowledge base the files are synced into
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);index"`
+	// Display name
+	Name string `json:"name"              gorm:"type:varchar(255)"`
+	// Repository URL, https or, when allowed by the configuration, a local path or file:// URL
+	RepoURL string `json:"repo_url"          gorm:"type:text"`
+	// Branch to sync, the default branch of the repository when empty
+	Branch string `json:"branch"            gorm:"type:varchar(255)"`
+	// Globs of the paths to sync, all supported files when empty
+	Include StringArray `json:"include"           gorm:"type:json"`
+	// Globs of the paths to skip
+	Exclude StringArray `json:"exclude"           gorm:"type:json"`
+	// Commit SHA of the last successful sync
+	LastCommit string `json:"last_commit"       gorm:"type:varchar(64)"`
+	// Sync status: pending, syncing, completed or failed
+	SyncStatus string `json:"sync_status"       gorm:"type:varchar(32)"`
+	// Counters of the last sync
+	SyncStats GitSourceSyncStats `json:"sync_stats"        gorm:"type:json"`
+	// Error of the last failed sync
+	ErrorMessage string `json:"error_message"     gorm:"type:text"`
+	// Time the last sync finished
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	// Creation time
+	CreatedAt time.Time `json:"created_at"`
+	// Last updated time
+	UpdatedAt time.Time `json:"updated_at"`
+	// Deletion time
+	DeletedAt gorm.DeletedAt `json:"deleted_at"        gorm:"index"`
+}
+
+// GitSourceSyncStats counts the files handled by a sync
+type GitSourceSyncStats struct {
+	Added      int `json:"added"`      // Files ingested for the first time
+	Updated    int `json:"updated"`    // Files whose content changed and were ingested again
+	Deleted    int `json:"deleted"`    // Files removed from the repository, their knowledge is deleted
+	Unchanged  int `json:"unchanged"`  // Files whose content did not change
+	Duplicated int `json:"duplicated"` // Files whose content already exists in the knowledge base
+	Skipped    int `json:"skipped"`    // Files of unsupported type, too large or binary
+	Failed     int `json:"failed"`     // Files that could not be ingested, retried on the next sync
+}
+
+// Value implements the driver.Valuer interface, used to convert GitSourceSyncStats to database value
+func (s GitSourceSyncStats) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database value to GitSourceSyncStats
+func (s *GitSourceSyncStats) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, s)
+}
+
+// GitSourceFile records the knowledge a repository file was ingested as
+type GitSourceFile struct {
+	// Git source ID
+	SourceID string `json:"source_id"    gorm:"type:varchar(36);primaryKey"`
+	// Slash separated path relative to the repository root
+	Path string `json:"path"         gorm:"type:text;primaryKey"`
+	// Tenant ID
+	TenantID uint64 `json:"tenant_id"`
+	// Knowledge created from the file, empty when its content duplicates other knowledge
+	KnowledgeID string `json:"knowledge_id" gorm:"type:varchar(36)"`
+	// Blob SHA of the ingested content
+	BlobSHA string `json:"blob_sha"     gorm:"type:varchar(64)"`
+	// SHA of the last commit that changed the file
+	CommitSHA string `json:"commit_sha"   gorm:"type:varchar(64)"`
+	// Creation time
+	CreatedAt time.Time `json:"created_at"`
+	// Last updated time
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GitSourceRequest is the request body for creating or updating a Git source
+type GitSourceRequest struct {
+	Name    string   `json:"name"`
+	RepoURL string   `json:"repo_url"`
+	Branch  string   `json:"branch"`
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+// GitSourceSyncPayload represents the Git source sync task payload
+type GitSourceSyncPayload struct {
+	TenantID uint64 `json:"tenant_id"`
+	SourceID string `json:"source_id"`
+}
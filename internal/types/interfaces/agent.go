@@ -26,11 +26,32 @@ type AgentEngine interface {
 		sessionID, messageID, query string,
 		llmContext []chat.Message,
 	) (*types.AgentState, error)
+
+	// Resume continues a run that paused for tool approval from its persisted snapshot.
+	// Approved tool calls are executed and the loop continues, otherwise the run is aborted.
+	Resume(
+		ctx context.Context,
+		sessionID, messageID string,
+		snapshot types.AgentSnapshot,
+		approved bool,
+		reason string,
+	) (*types.AgentState, error)
+}
+
+// SubAgentRunner runs a custom agent on a task delegated by another agent.
+// Events of the sub-agent are emitted to the given EventBus.
+type SubAgentRunner interface {
+	RunSubAgent(
+		ctx context.Context,
+		sessionID, agentID, task string,
+		eventBus *event.EventBus,
+	) (*types.AgentState, error)
 }
 
 // AgentService defines the interface for agent-related operations
 type AgentService interface {
 	// CreateAgentEngine creates an agent engine with the given configuration, EventBus, and ContextManager
+	// subAgentRunner is used to execute config.SubAgents, nil disables sub-agent tools
 	CreateAgentEngine(
 		ctx context.Context,
 		config *types.AgentConfig,
@@ -39,6 +60,7 @@ type AgentService interface {
 		eventBus *event.EventBus,
 		contextManager ContextManager,
 		sessionID string,
+		subAgentRunner SubAgentRunner,
 	) (AgentEngine, error)
 
 	// ValidateConfig validates an agent configuration
@@ -0,0 +1,26 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// AgentApprovalRepository persists agent runs paused for tool approval
+type AgentApprovalRepository interface {
+	// Create stores a pending approval
+	Create(ctx context.Context, approval *types.AgentApproval) error
+	// GetPendingByMessage gets the pending approval of an assistant message
+	GetPendingByMessage(ctx context.Context, tenantID uint64, sessionID, messageID string) (*types.AgentApproval, error)
+	// Resolve records the decision on a pending approval
+	// Returns false if the approval is no longer pending (already decided by another request)
+	Resolve(ctx context.Context,
+		tenantID uint64, id string, status types.AgentApprovalStatus, reason, resolvedBy string,
+	) (bool, error)
+	// UpdateStatus moves an approval from one status to another
+	// Returns false if the approval is no longer in the from status
+	UpdateStatus(ctx context.Context, tenantID uint64, id string, from, to types.AgentApprovalStatus) (bool, error)
+	// ListByStatusBefore lists the approvals of all tenants in the given status last updated before the given time
+	ListByStatusBefore(ctx context.Context, status types.AgentApprovalStatus, before time.Time) ([]*types.AgentApproval, error)
+}
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/types"
 )
@@ -51,8 +52,16 @@ type ChunkRepository interface {
 	// DeleteChunksByTagID deletes all chunks with the specified tag ID
 	// Returns the IDs of deleted chunks for index cleanup
 	DeleteChunksByTagID(ctx context.Context, tenantID uint64, kbID string, tagID string, excludeIDs []string) ([]string, error)
+	// DeleteChunksByKnowledgeIDExcept deletes the chunks of a knowledge except the given ones
+	// Returns the IDs of deleted chunks for index cleanup
+	DeleteChunksByKnowledgeIDExcept(ctx context.Context, tenantID uint64, knowledgeID string, excludeIDs []string) ([]string, error)
 	// CountChunksByKnowledgeBaseID counts the number of chunks in a knowledge base.
 	CountChunksByKnowledgeBaseID(ctx context.Context, tenantID uint64, kbID string) (int64, error)
+	// ListChunksByKnowledgeBaseIDAfter lists up to limit chunks of a knowledge base with an ID greater than afterID,
+	// ordered by ID, for walking all chunks of a knowledge base page by page.
+	// Only chunks updated at or after since are listed, unless since is zero.
+	ListChunksByKnowledgeBaseIDAfter(ctx context.Context, tenantID uint64, kbID string,
+		since time.Time, afterID string, limit int) ([]*types.Chunk, error)
 	// DeleteUnindexedChunks deletes unindexed chunks by knowledge id and chunk index range
 	DeleteUnindexedChunks(ctx context.Context, tenantID uint64, knowledgeID string) ([]*types.Chunk, error)
 	// ListAllFAQChunksByKnowledgeID lists all FAQ chunks for a knowledge ID
@@ -70,10 +79,17 @@ type ChunkRepository interface {
 	// UpdateChunkFieldsByTagID updates fields for all chunks with the specified tag ID.
 	// Supports updating is_enabled, flags, and tag_id fields.
 	// newTagID: if not nil, updates tag_id to this value (empty string means uncategorized)
+	// Returns the IDs of the chunks whose is_enabled or tag_id is updated.
 	UpdateChunkFieldsByTagID(ctx context.Context, tenantID uint64, kbID string, tagID string, isEnabled *bool, setFlags types.ChunkFlags, clearFlags types.ChunkFlags, newTagID *string, excludeIDs []string) ([]string, error)
 	// FAQChunkDiff compares FAQ chunks between two knowledge bases and returns the differences.
 	// Returns: chunksToAdd (content_hash in src but not in dst), chunksToDelete (content_hash in dst but not in src)
 	FAQChunkDiff(ctx context.Context, srcTenantID uint64, srcKBID string, dstTenantID uint64, dstKBID string) (chunksToAdd []string, chunksToDelete []string, err error)
+	// ListChunkIDsByFilter lists the IDs of chunks in the given knowledge bases (and optionally knowledge)
+	// that match the retrieve filter. At most limit+1 IDs are returned so callers can detect overflow.
+	ListChunkIDsByFilter(ctx context.Context, tenantID uint64, kbIDs []string, knowledgeIDs []string, filter *types.RetrieveFilter, limit int) ([]string, error)
+	// ListChunksForIndexAttributes lists the chunks of the given knowledge with only the fields
+	// their index attributes are built from (id, knowledge_id, tag_id, metadata) and their knowledge_base_id
+	ListChunksForIndexAttributes(ctx context.Context, tenantID uint64, knowledgeIDs []string) ([]*types.Chunk, error)
 }
 
 // ChunkService defines the interface for chunk service operations
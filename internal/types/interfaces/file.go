@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"mime/multipart"
+	"time"
 )
 
 // FileService is the interface for file services.
@@ -15,4 +16,8 @@ type FileService interface {
 	GetFile(ctx context.Context, filePath string) (io.ReadCloser, error)
 	// DeleteFile deletes a file.
 	DeleteFile(ctx context.Context, filePath string) error
+	// GetFileURL returns a presigned URL downloading the file directly from storage as fileName.
+	// Backends without direct access, or paths not in the format of the backend, return
+	// errors.ErrPresignNotSupported so that the file is proxied instead.
+	GetFileURL(ctx context.Context, filePath, fileName string, expiry time.Duration) (string, error)
 }
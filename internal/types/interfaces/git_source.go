@@ -0,0 +1,52 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// GitSourceService defines the service syncing Git repositories into knowledge bases
+type GitSourceService interface {
+	// CreateGitSource creates a Git source for a knowledge base and enqueues its first sync
+	CreateGitSource(ctx context.Context, kbID string, req *types.GitSourceRequest) (*types.GitSource, error)
+	// ListGitSources lists the Git sources of a knowledge base
+	ListGitSources(ctx context.Context, kbID string) ([]*types.GitSource, error)
+	// GetGitSource gets a Git source of a knowledge base
+	GetGitSource(ctx context.Context, kbID string, id string) (*types.GitSource, error)
+	// UpdateGitSource updates the repository settings of a Git source, they apply from the next sync
+	UpdateGitSource(ctx context.Context, kbID string, id string, req *types.GitSourceRequest) (*types.GitSource, error)
+	// DeleteGitSource deletes a Git source, and the knowledge it created when deleteKnowledge is set
+	DeleteGitSource(ctx context.Context, kbID string, id string, deleteKnowledge bool) error
+	// SyncGitSource enqueues a sync of a Git source
+	SyncGitSource(ctx context.Context, kbID string, id string) (*types.GitSource, error)
+	// ListGitSourceFiles lists the files a Git source has synced
+	ListGitSourceFiles(ctx context.Context, kbID string, id string) ([]*types.GitSourceFile, error)
+	// ProcessGitSourceSync handles Asynq Git source sync tasks
+	ProcessGitSourceSync(ctx context.Context, t *asynq.Task) error
+}
+
+// GitSourceRepository defines the Git source repository interface
+type GitSourceRepository interface {
+	// Create creates a Git source
+	Create(ctx context.Context, source *types.GitSource) error
+	// Update saves a Git source
+	Update(ctx context.Context, source *types.GitSource) error
+	// GetByID gets a Git source by ID
+	GetByID(ctx context.Context, tenantID uint64, id string) (*types.GitSource, error)
+	// ListByKB lists the Git sources of a knowledge base
+	ListByKB(ctx context.Context, tenantID uint64, kbID string) ([]*types.GitSource, error)
+	// Delete deletes a Git source and its file records
+	Delete(ctx context.Context, tenantID uint64, id string) error
+	// UpdateSyncStatus sets the sync status of a Git source
+	UpdateSyncStatus(ctx context.Context, tenantID uint64, id string, status string) error
+	// UpdateSyncResult saves the sync status, counters, error and commit of a Git source
+	UpdateSyncResult(ctx context.Context, source *types.GitSource) error
+	// ListFiles lists the file records of a Git source
+	ListFiles(ctx context.Context, tenantID uint64, sourceID string) ([]*types.GitSourceFile, error)
+	// SaveFile creates or updates a file record
+	SaveFile(ctx context.Context, file *types.GitSourceFile) error
+	// DeleteFile deletes a file record
+	DeleteFile(ctx context.Context, tenantID uint64, sourceID string, path string) error
+}
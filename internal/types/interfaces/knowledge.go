@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"mime/multipart"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/hibiken/asynq"
@@ -27,6 +28,7 @@ type KnowledgeService interface {
 		url string,
 		enableMultimodel *bool,
 		title string,
+		refreshInterval int,
 	) (*types.Knowledge, error)
 	// CreateKnowledgeFromPassage creates knowledge from text passages.
 	CreateKnowledgeFromPassage(ctx context.Context, kbID string, passage []string) (*types.Knowledge, error)
@@ -60,6 +62,8 @@ type KnowledgeService interface {
 	DeleteKnowledge(ctx context.Context, id string) error
 	// GetKnowledgeFile retrieves the file associated with the knowledge.
 	GetKnowledgeFile(ctx context.Context, id string) (io.ReadCloser, string, error)
+	// GetKnowledgeFileURL returns a presigned download URL of the knowledge file, empty if unsupported by storage.
+	GetKnowledgeFileURL(ctx context.Context, id string) (string, error)
 	// UpdateKnowledge updates knowledge information.
 	UpdateKnowledge(ctx context.Context, knowledge *types.Knowledge) error
 	// UpdateManualKnowledge updates manual Markdown knowledge content.
@@ -117,12 +121,32 @@ type KnowledgeService interface {
 	ProcessQuestionGeneration(ctx context.Context, t *asynq.Task) error
 	// ProcessSummaryGeneration handles Asynq summary generation tasks
 	ProcessSummaryGeneration(ctx context.Context, t *asynq.Task) error
+	// UpdateKnowledgeRefreshInterval sets how often URL knowledge is re-crawled, 0 disables the refresh.
+	UpdateKnowledgeRefreshInterval(ctx context.Context, id string, refreshInterval int) (*types.Knowledge, error)
+	// RefreshURLKnowledge enqueues an immediate re-crawl of URL knowledge.
+	RefreshURLKnowledge(ctx context.Context, id string) error
+	// ProcessURLRefreshScan handles the periodic scan for URL knowledge due for a refresh
+	ProcessURLRefreshScan(ctx context.Context, t *asynq.Task) error
+	// ProcessURLRefresh handles Asynq URL refresh tasks
+	ProcessURLRefresh(ctx context.Context, t *asynq.Task) error
+	// CrawlWebsite validates the crawl options and enqueues a website crawl into the knowledge base
+	CrawlWebsite(ctx context.Context, kbID string, req *types.WebCrawlRequest) (*types.WebCrawlProgress, error)
+	// ProcessWebCrawl handles Asynq website crawl tasks
+	ProcessWebCrawl(ctx context.Context, t *asynq.Task) error
+	// GetWebCrawlProgress retrieves the progress of a website crawl task
+	GetWebCrawlProgress(ctx context.Context, taskID string) (*types.WebCrawlProgress, error)
 	// ProcessKBClone handles Asynq knowledge base clone tasks
 	ProcessKBClone(ctx context.Context, t *asynq.Task) error
 	// GetKBCloneProgress retrieves the progress of a knowledge base clone task
 	GetKBCloneProgress(ctx context.Context, taskID string) (*types.KBCloneProgress, error)
 	// SaveKBCloneProgress saves the progress of a knowledge base clone task
 	SaveKBCloneProgress(ctx context.Context, progress *types.KBCloneProgress) error
+	// ReembedKnowledgeBase enqueues a task that re-embeds a knowledge base with another embedding model
+	ReembedKnowledgeBase(ctx context.Context, kb *types.KnowledgeBase, modelID string) (*types.KBReembedProgress, error)
+	// ProcessKBReembed handles Asynq knowledge base re-embedding tasks
+	ProcessKBReembed(ctx context.Context, t *asynq.Task) error
+	// GetKBReembedProgress retrieves the progress of a knowledge base re-embedding task
+	GetKBReembedProgress(ctx context.Context, taskID string) (*types.KBReembedProgress, error)
 	// GetFAQImportProgress retrieves the progress of an FAQ import task
 	GetFAQImportProgress(ctx context.Context, taskID string) (*types.FAQImportProgress, error)
 	// SearchKnowledge searches knowledge items by keyword across the tenant.
@@ -166,4 +190,7 @@ type KnowledgeRepository interface {
 	CountKnowledgeByStatus(ctx context.Context, tenantID uint64, kbID string, parseStatuses []string) (int64, error)
 	// SearchKnowledge searches knowledge items by keyword across the tenant.
 	SearchKnowledge(ctx context.Context, tenantID uint64, keyword string, offset, limit int) ([]*types.Knowledge, bool, error)
+	// ListURLKnowledgeDueForRefresh lists URL knowledge of all tenants whose refresh interval has elapsed,
+	// least recently checked first.
+	ListURLKnowledgeDueForRefresh(ctx context.Context, now time.Time, limit int) ([]*types.Knowledge, error)
 }
@@ -161,4 +161,14 @@ type KnowledgeBaseRepository interface {
 	// Returns:
 	//   - Possible errors such as record not existing, database errors, etc.
 	DeleteKnowledgeBase(ctx context.Context, id string) error
+
+	// SwitchEmbeddingModel atomically points a knowledge base and all of its knowledge at another embedding model
+	// Parameters:
+	//   - ctx: Context information
+	//   - tenantID: Tenant ID
+	//   - id: Knowledge base ID
+	//   - modelID: New embedding model ID
+	// Returns:
+	//   - Possible errors such as record not existing, database errors, etc.
+	SwitchEmbeddingModel(ctx context.Context, tenantID uint64, id string, modelID string) error
 }
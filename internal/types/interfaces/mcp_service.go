@@ -26,6 +26,9 @@ type MCPServiceRepository interface {
 	// Update updates an MCP service
 	Update(ctx context.Context, service *types.MCPService) error
 
+	// UpdateAuthConfig updates the auth config of an MCP service only
+	UpdateAuthConfig(ctx context.Context, tenantID uint64, id string, authConfig *types.MCPAuthConfig) error
+
 	// Delete deletes an MCP service (soft delete)
 	Delete(ctx context.Context, tenantID uint64, id string) error
 }
@@ -58,4 +61,14 @@ type MCPServiceService interface {
 
 	// GetMCPServiceResources retrieves the list of resources from an MCP service
 	GetMCPServiceResources(ctx context.Context, tenantID uint64, id string) ([]*types.MCPResource, error)
+
+	// GetMCPServicePrompts retrieves the list of prompts from an MCP service
+	GetMCPServicePrompts(ctx context.Context, tenantID uint64, id string) ([]*types.MCPPrompt, error)
+
+	// StartMCPServiceOAuth starts the OAuth authorization code flow of an MCP service
+	// and returns the URL the user authorizes WeKnora at
+	StartMCPServiceOAuth(ctx context.Context, tenantID uint64, id string, redirectURL string) (string, error)
+
+	// CompleteMCPServiceOAuth exchanges the authorization code the user was redirected with for tokens
+	CompleteMCPServiceOAuth(ctx context.Context, state string, code string) error
 }
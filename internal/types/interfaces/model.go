@@ -27,6 +27,9 @@ type ModelService interface {
 	GetRerankModel(ctx context.Context, modelId string) (rerank.Reranker, error)
 	// GetChatModel gets a chat model
 	GetChatModel(ctx context.Context, modelId string) (chat.Chat, error)
+	// GetChatModelWithFallback gets a chat model that fails over to the given fallback models,
+	// a nil fallback uses the fallback declared by the model
+	GetChatModelWithFallback(ctx context.Context, modelId string, fallback *types.ModelFallback) (chat.Chat, error)
 }
 
 // ModelRepository defines the model repository interface
@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// ResponseCacheService caches knowledge QA answers and serves them to semantically similar queries
+type ResponseCacheService interface {
+	// Enabled reports whether the response cache is turned on
+	Enabled() bool
+	// ScopeKey resolves the key of a scope. The key changes whenever knowledge in the scoped
+	// knowledge bases changes, so answers stored under an older key are never served again.
+	ScopeKey(ctx context.Context, scope *types.ResponseCacheScope) (string, error)
+	// Lookup returns the cached answer most similar to the query embedding,
+	// or nil when no cached answer reaches the similarity threshold
+	Lookup(ctx context.Context, scopeKey string, embedding []float32) (*types.ResponseCacheEntry, float64, error)
+	// Store caches an answer under the scope key
+	Store(ctx context.Context, scopeKey string, entry *types.ResponseCacheEntry) error
+	// InvalidateKnowledgeBases drops the cached answers of every scope covering the knowledge bases
+	InvalidateKnowledgeBases(ctx context.Context, kbIDs ...string)
+}
@@ -52,10 +52,18 @@ type RetrieveEngineRepository interface {
 	// DeleteByKnowledgeIDList deletes the index info by knowledge id list
 	DeleteByKnowledgeIDList(ctx context.Context, knowledgeIDList []string, dimension int, knowledgeType string) error
 
+	// DeleteByKnowledgeBaseIDList deletes the index info of the knowledge bases whose vectors have the given
+	// dimension, keeping entries written with an embedding model of another dimension
+	DeleteByKnowledgeBaseIDList(ctx context.Context, knowledgeBaseIDList []string, dimension int, knowledgeType string) error
+
 	// BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
 	// chunkStatusMap: map of chunk ID to enabled status (true = enabled, false = disabled)
 	BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error
 
+	// BatchUpdateChunkAttributes replaces the filter attributes stored with the index entries of chunks in batch
+	// chunkAttributes: map of chunk ID to its attributes
+	BatchUpdateChunkAttributes(ctx context.Context, chunkAttributes map[string]*types.IndexAttributes) error
+
 	// RetrieveEngine retrieves the engine
 	RetrieveEngine
 }
@@ -115,10 +123,17 @@ type RetrieveEngineService interface {
 	// DeleteByKnowledgeIDList deletes the index info by knowledge id list
 	DeleteByKnowledgeIDList(ctx context.Context, knowledgeIDList []string, dimension int, knowledgeType string) error
 
+	// DeleteByKnowledgeBaseIDList deletes the index info of the knowledge bases stored with the given dimension
+	DeleteByKnowledgeBaseIDList(ctx context.Context, knowledgeBaseIDList []string, dimension int, knowledgeType string) error
+
 	// BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
 	// chunkStatusMap: map of chunk ID to enabled status (true = enabled, false = disabled)
 	BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error
 
+	// BatchUpdateChunkAttributes replaces the filter attributes stored with the index entries of chunks in batch
+	// chunkAttributes: map of chunk ID to its attributes
+	BatchUpdateChunkAttributes(ctx context.Context, chunkAttributes map[string]*types.IndexAttributes) error
+
 	// RetrieveEngine retrieves the engine
 	RetrieveEngine
 }
@@ -5,22 +5,35 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
 )
 
 // SessionService defines the session service interface
 type SessionService interface {
 	// CreateSession creates a session
 	CreateSession(ctx context.Context, session *types.Session) (*types.Session, error)
-	// GetSession gets a session
+	// GetSession gets a session the current user may read
 	GetSession(ctx context.Context, id string) (*types.Session, error)
-	// GetSessionsByTenant gets all sessions of a tenant
+	// GetWritableSession gets a session the current user may chat in or modify, shared sessions are read-only
+	GetWritableSession(ctx context.Context, id string) (*types.Session, error)
+	// GetSessionsByTenant gets all sessions of the current user, or of the tenant for API key requests
 	GetSessionsByTenant(ctx context.Context) ([]*types.Session, error)
-	// GetPagedSessionsByTenant gets paged sessions of a tenant
+	// GetPagedSessionsByTenant gets paged sessions of the current user, or of the tenant for API key requests
 	GetPagedSessionsByTenant(ctx context.Context, page *types.Pagination) (*types.PageResult, error)
+	// GetPagedSharedSessions gets paged sessions other users shared with the current user
+	GetPagedSharedSessions(ctx context.Context, page *types.Pagination) (*types.PageResult, error)
 	// UpdateSession updates a session
 	UpdateSession(ctx context.Context, session *types.Session) error
 	// DeleteSession deletes a session
 	DeleteSession(ctx context.Context, id string) error
+	// ShareSession gives a user, or every user of the tenant, read-only access to a session
+	ShareSession(ctx context.Context,
+		sessionID string, shareType types.SessionShareType, userID string,
+	) (*types.SessionShare, error)
+	// ListSessionShares lists who a session is shared with
+	ListSessionShares(ctx context.Context, sessionID string) ([]*types.SessionShare, error)
+	// UnshareSession revokes a share of a session
+	UnshareSession(ctx context.Context, sessionID, shareID string) error
 	// GenerateTitle generates a title for the current conversation
 	// modelID: optional model ID to use for title generation (if empty, uses first available KnowledgeQA model)
 	GenerateTitle(ctx context.Context, session *types.Session, messages []types.Message, modelID string) (string, error)
@@ -45,7 +58,8 @@ type SessionService interface {
 	// SearchKnowledge performs knowledge-based search, without summarization
 	// knowledgeBaseIDs: list of knowledge base IDs to search (supports multi-KB)
 	// knowledgeIDs: list of specific knowledge (file) IDs to search
-	SearchKnowledge(ctx context.Context, knowledgeBaseIDs []string, knowledgeIDs []string, query string) ([]*types.SearchResult, error)
+	SearchKnowledge(ctx context.Context, knowledgeBaseIDs []string, knowledgeIDs []string, query string,
+		filter *types.RetrieveFilter) ([]*types.SearchResult, error)
 	// AgentQA performs agent-based question answering with conversation history and streaming support
 	// eventBus is optional - if nil, uses service's default EventBus
 	// customAgent is optional - if provided, uses custom agent configuration instead of tenant defaults
@@ -59,6 +73,18 @@ type SessionService interface {
 		knowledgeBaseIDs []string,
 		knowledgeIDs []string,
 	) error
+	// ResolveAgentApproval records the decision on tool calls an agent run is waiting on
+	// for the given assistant message, it fails if no approval is pending
+	ResolveAgentApproval(ctx context.Context,
+		sessionID, messageID string, approved bool, reason string,
+	) (*types.AgentApproval, error)
+	// ResumeAgentQA resumes an agent run paused for tool approval once the approval is resolved
+	// Events are emitted through eventBus like AgentQA
+	ResumeAgentQA(ctx context.Context,
+		session *types.Session, approval *types.AgentApproval, eventBus *event.EventBus,
+	) error
+	// ProcessAgentApprovalSweep handles the periodic sweep failing approved agent runs that never finished resuming
+	ProcessAgentApprovalSweep(ctx context.Context, t *asynq.Task) error
 	// ClearContext clears the LLM context for a session
 	ClearContext(ctx context.Context, sessionID string) error
 }
@@ -69,12 +95,28 @@ type SessionRepository interface {
 	Create(ctx context.Context, session *types.Session) (*types.Session, error)
 	// Get gets a session
 	Get(ctx context.Context, tenantID uint64, id string) (*types.Session, error)
-	// GetByTenantID gets all sessions of a tenant
-	GetByTenantID(ctx context.Context, tenantID uint64) ([]*types.Session, error)
-	// GetPagedByTenantID gets paged sessions of a tenant
-	GetPagedByTenantID(ctx context.Context, tenantID uint64, page *types.Pagination) ([]*types.Session, int64, error)
+	// GetByTenantID gets all sessions of a tenant, narrowed by filter when it is not nil
+	GetByTenantID(ctx context.Context, tenantID uint64, filter *types.SessionListFilter) ([]*types.Session, error)
+	// GetPagedByTenantID gets paged sessions of a tenant, narrowed by filter when it is not nil
+	GetPagedByTenantID(ctx context.Context,
+		tenantID uint64, filter *types.SessionListFilter, page *types.Pagination,
+	) ([]*types.Session, int64, error)
 	// Update updates a session
 	Update(ctx context.Context, session *types.Session) error
 	// Delete deletes a session
 	Delete(ctx context.Context, tenantID uint64, id string) error
 }
+
+// SessionShareRepository defines the session share repository interface
+type SessionShareRepository interface {
+	// Create creates a share, sharing a session twice with the same audience returns the existing share
+	Create(ctx context.Context, share *types.SessionShare) (*types.SessionShare, error)
+	// ListBySession lists the shares of a session
+	ListBySession(ctx context.Context, tenantID uint64, sessionID string) ([]*types.SessionShare, error)
+	// Delete deletes a share of a session
+	Delete(ctx context.Context, tenantID uint64, sessionID, id string) error
+	// DeleteBySession deletes all shares of a session
+	DeleteBySession(ctx context.Context, tenantID uint64, sessionID string) error
+	// IsSharedWith reports whether a session is shared with the user, directly or through its tenant
+	IsSharedWith(ctx context.Context, tenantID uint64, sessionID, userID string) (bool, error)
+}
@@ -0,0 +1,63 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// TenantMemberService defines the tenant member and knowledge base grant service interface
+type TenantMemberService interface {
+	// GetRole returns the role of a user in a tenant, empty if the user is not a member
+	GetRole(ctx context.Context, tenantID uint64, userID string) (types.TenantRole, error)
+	// GetKnowledgeBaseRole returns the role of the current request on a knowledge base,
+	// which is its tenant role raised by the grants of the knowledge base
+	GetKnowledgeBaseRole(ctx context.Context, knowledgeBaseID string) (types.TenantRole, error)
+
+	// ListMembers lists the members of the current tenant
+	ListMembers(ctx context.Context) ([]*types.TenantMember, error)
+	// AddMember adds a user, identified by ID or email, to the current tenant
+	AddMember(ctx context.Context, userID, email string, role types.TenantRole) (*types.TenantMember, error)
+	// UpdateMemberRole changes the role of a member of the current tenant
+	UpdateMemberRole(ctx context.Context, userID string, role types.TenantRole) (*types.TenantMember, error)
+	// RemoveMember removes a user from the current tenant
+	RemoveMember(ctx context.Context, userID string) error
+
+	// ListKnowledgeBaseGrants lists the grants of a knowledge base
+	ListKnowledgeBaseGrants(ctx context.Context, knowledgeBaseID string) ([]*types.KnowledgeBaseGrant, error)
+	// GrantKnowledgeBase grants a member a role on a knowledge base, replacing an existing grant
+	GrantKnowledgeBase(ctx context.Context,
+		knowledgeBaseID, userID string, role types.TenantRole) (*types.KnowledgeBaseGrant, error)
+	// RevokeKnowledgeBaseGrant revokes the grant of a member on a knowledge base
+	RevokeKnowledgeBaseGrant(ctx context.Context, knowledgeBaseID, userID string) error
+}
+
+// TenantMemberRepository defines the tenant member repository interface
+type TenantMemberRepository interface {
+	// Create creates a member
+	Create(ctx context.Context, member *types.TenantMember) error
+	// Get gets the membership of a user in a tenant
+	Get(ctx context.Context, tenantID uint64, userID string) (*types.TenantMember, error)
+	// List lists the members of a tenant with their users
+	List(ctx context.Context, tenantID uint64) ([]*types.TenantMember, error)
+	// UpdateRole updates the role of a member
+	UpdateRole(ctx context.Context, tenantID uint64, userID string, role types.TenantRole) error
+	// Delete deletes a member
+	Delete(ctx context.Context, tenantID uint64, userID string) error
+	// CountByRole counts the members of a tenant with the role
+	CountByRole(ctx context.Context, tenantID uint64, role types.TenantRole) (int64, error)
+}
+
+// KnowledgeBaseGrantRepository defines the knowledge base grant repository interface
+type KnowledgeBaseGrantRepository interface {
+	// Upsert creates a grant or updates the role of the existing grant
+	Upsert(ctx context.Context, grant *types.KnowledgeBaseGrant) (*types.KnowledgeBaseGrant, error)
+	// Get gets the grant of a user on a knowledge base
+	Get(ctx context.Context, tenantID uint64, knowledgeBaseID, userID string) (*types.KnowledgeBaseGrant, error)
+	// ListByKnowledgeBase lists the grants of a knowledge base
+	ListByKnowledgeBase(ctx context.Context, tenantID uint64, knowledgeBaseID string) ([]*types.KnowledgeBaseGrant, error)
+	// Delete deletes the grant of a user on a knowledge base
+	Delete(ctx context.Context, tenantID uint64, knowledgeBaseID, userID string) error
+	// DeleteByUser deletes all grants of a user in a tenant
+	DeleteByUser(ctx context.Context, tenantID uint64, userID string) error
+}
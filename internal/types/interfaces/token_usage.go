@@ -0,0 +1,31 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// TokenUsageService defines the token usage accounting and quota service interface
+type TokenUsageService interface {
+	// RecordUsage records the tokens of a model call for the tenant and session of the context
+	RecordUsage(ctx context.Context, usage *types.TokenUsage)
+	// CheckQuota returns a TokenQuotaExceededError when the tenant of the context
+	// has used up its monthly token quota
+	CheckQuota(ctx context.Context) error
+	// GetUsageReport reports the token usage of a tenant with daily and per-model breakdowns
+	GetUsageReport(ctx context.Context, tenantID uint64, query *types.TokenUsageQuery) (*types.TokenUsageReport, error)
+}
+
+// TokenUsageRepository defines the token usage repository interface
+type TokenUsageRepository interface {
+	// Create creates a usage record
+	Create(ctx context.Context, usage *types.TokenUsage) error
+	// SumSince sums the tokens used by a tenant since the given time
+	SumSince(ctx context.Context, tenantID uint64, since time.Time) (int64, error)
+	// Daily sums the usage of a tenant per day
+	Daily(ctx context.Context, tenantID uint64, query *types.TokenUsageQuery) ([]*types.DailyTokenUsage, error)
+	// ByModel sums the usage of a tenant per model
+	ByModel(ctx context.Context, tenantID uint64, query *types.TokenUsageQuery) ([]*types.ModelTokenUsage, error)
+}
@@ -14,6 +14,8 @@ const (
 	KnowledgeTypeManual = "manual"
 	// KnowledgeTypeFAQ represents the FAQ knowledge type
 	KnowledgeTypeFAQ = "faq"
+	// KnowledgeTypeURL represents the knowledge type crawled from a URL
+	KnowledgeTypeURL = "url"
 )
 
 // Knowledge parse status constants
@@ -101,6 +103,14 @@ type Knowledge struct {
 	ProcessedAt *time.Time `json:"processed_at"`
 	// Error message of the knowledge
 	ErrorMessage string `json:"error_message"`
+	// Interval in seconds between re-crawls of URL knowledge, 0 disables the refresh
+	RefreshInterval int `json:"refresh_interval"`
+	// Hash of the crawled content of URL knowledge, used to detect page changes
+	ContentHash string `json:"content_hash"`
+	// Last time the URL of the knowledge was re-crawled
+	LastCheckedAt *time.Time `json:"last_checked_at"`
+	// Last time the crawled content of the URL knowledge changed
+	LastChangedAt *time.Time `json:"last_changed_at"`
 	// Deletion time of the knowledge
 	DeletedAt gorm.DeletedAt `json:"deleted_at"         gorm:"index"`
 	// Knowledge base name (not stored in database, populated on query)
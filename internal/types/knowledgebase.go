@@ -3,8 +3,10 @@ package types
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"time"
 
+	secutils "github.com/Tencent/WeKnora/internal/utils"
 	"gorm.io/gorm"
 )
 
@@ -67,6 +69,8 @@ type KnowledgeBase struct {
 	FAQConfig *FAQConfig `yaml:"faq_config"              json:"faq_config"              gorm:"column:faq_config;type:json"`
 	// QuestionGenerationConfig stores question generation configuration for document knowledge bases
 	QuestionGenerationConfig *QuestionGenerationConfig `yaml:"question_generation_config" json:"question_generation_config" gorm:"column:question_generation_config;type:json"`
+	// FusionConfig controls how vector and keyword results are merged in hybrid search
+	FusionConfig *FusionConfig `yaml:"fusion_config"           json:"fusion_config"           gorm:"column:fusion_config;type:json"`
 	// Creation time of the knowledge base
 	CreatedAt time.Time `yaml:"created_at"              json:"created_at"`
 	// Last updated time of the knowledge base
@@ -91,6 +95,8 @@ type KnowledgeBaseConfig struct {
 	ImageProcessingConfig ImageProcessingConfig `yaml:"image_processing_config" json:"image_processing_config"`
 	// FAQ configuration (only for FAQ type knowledge bases)
 	FAQConfig *FAQConfig `yaml:"faq_config"              json:"faq_config"`
+	// Result fusion configuration for hybrid search
+	FusionConfig *FusionConfig `yaml:"fusion_config"           json:"fusion_config"`
 }
 
 // ChunkingConfig represents the document splitting configuration
@@ -123,10 +129,17 @@ type StorageConfig struct {
 	Provider string `yaml:"provider"    json:"provider"`
 }
 
+// Value implements the driver.Valuer interface, encrypting the secret key
 func (c StorageConfig) Value() (driver.Value, error) {
+	secretKey, err := secutils.EncryptSecret(c.SecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt storage secret key: %w", err)
+	}
+	c.SecretKey = secretKey
 	return json.Marshal(c)
 }
 
+// Scan implements the sql.Scanner interface, decrypting the secret key
 func (c *StorageConfig) Scan(value interface{}) error {
 	if value == nil {
 		return nil
@@ -135,7 +148,15 @@ func (c *StorageConfig) Scan(value interface{}) error {
 	if !ok {
 		return nil
 	}
-	return json.Unmarshal(b, c)
+	if err := json.Unmarshal(b, c); err != nil {
+		return err
+	}
+	secretKey, err := secutils.DecryptSecret(c.SecretKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt storage secret key: %w", err)
+	}
+	c.SecretKey = secretKey
+	return nil
 }
 
 // ImageProcessingConfig represents the image processing configuration
@@ -345,3 +366,13 @@ func (kb *KnowledgeBase) IsMultimodalEnabled() bool {
 	}
 	return false
 }
+
+// Redacted 返回存储配置密钥脱敏后的知识库副本，用于接口返回
+func (kb *KnowledgeBase) Redacted() *KnowledgeBase {
+	if kb == nil {
+		return nil
+	}
+	redacted := *kb
+	redacted.StorageConfig.SecretKey = RedactSecret(kb.StorageConfig.SecretKey)
+	return &redacted
+}
@@ -3,8 +3,12 @@ package types
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
 	"time"
 
+	secutils "github.com/Tencent/WeKnora/internal/utils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -38,13 +42,51 @@ type MCPService struct {
 }
 
 // MCPHeaders represents HTTP headers as a map
+// Header values are encrypted when stored, see Value and Scan
 type MCPHeaders map[string]string
 
 // MCPAuthConfig represents authentication configuration for MCP service
+// Secrets are encrypted when stored, see Value and Scan
 type MCPAuthConfig struct {
 	APIKey        string            `json:"api_key,omitempty"`
 	Token         string            `json:"token,omitempty"`
-	CustomHeaders map[string]string `json:"custom_headers,omitempty"`
+	CustomHeaders map[string]string `json:"custom_headers,omitempty"` // Static headers sent with every request
+	OAuth         *MCPOAuthConfig   `json:"oauth,omitempty"`          // OAuth 2.1 client, replaces Token when set
+}
+
+// MCPOAuthGrantType is the OAuth 2.1 grant used to obtain access tokens for an MCP service
+type MCPOAuthGrantType string
+
+const (
+	// MCPOAuthClientCredentials authenticates WeKnora itself as a confidential client
+	MCPOAuthClientCredentials MCPOAuthGrantType = "client_credentials"
+	// MCPOAuthAuthorizationCode has a user authorize WeKnora in the browser, using PKCE
+	MCPOAuthAuthorizationCode MCPOAuthGrantType = "authorization_code"
+)
+
+// MCPOAuthConfig represents the OAuth 2.1 client configuration of an MCP service
+type MCPOAuthConfig struct {
+	GrantType        MCPOAuthGrantType `json:"grant_type"`
+	AuthorizationURL string            `json:"authorization_url,omitempty"` // Required for authorization_code
+	TokenURL         string            `json:"token_url"`
+	ClientID         string            `json:"client_id"`
+	ClientSecret     string            `json:"client_secret,omitempty"` // Empty for public clients
+	Scopes           []string          `json:"scopes,omitempty"`
+	Resource         string            `json:"resource,omitempty"`     // RFC 8707 resource indicator
+	RedirectURL      string            `json:"redirect_url,omitempty"` // Defaults to the WeKnora callback
+
+	// Managed by WeKnora
+	Token        *MCPOAuthToken `json:"token,omitempty"`
+	State        string         `json:"state,omitempty"`         // State of the pending authorization
+	CodeVerifier string         `json:"code_verifier,omitempty"` // PKCE verifier of the pending authorization
+}
+
+// MCPOAuthToken represents the tokens obtained for an MCP service
+type MCPOAuthToken struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"` // Zero if the token does not expire
 }
 
 // MCPAdvancedConfig represents advanced configuration for MCP service
@@ -61,6 +103,7 @@ type MCPStdioConfig struct {
 }
 
 // MCPEnvVars represents environment variables as a map
+// Values are encrypted when stored, see Value and Scan
 type MCPEnvVars map[string]string
 
 // MCPTool represents a tool exposed by an MCP service
@@ -78,12 +121,57 @@ type MCPResource struct {
 	MimeType    string `json:"mimeType,omitempty"`
 }
 
+// MCPPrompt represents a prompt template exposed by an MCP service
+type MCPPrompt struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description,omitempty"`
+	Arguments   []*MCPPromptArgument `json:"arguments,omitempty"`
+}
+
+// MCPPromptArgument describes an argument a prompt template accepts
+type MCPPromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// MCPPromptRef pins a prompt of an MCP service as a fragment of an agent's system prompt
+type MCPPromptRef struct {
+	ServiceID string            `yaml:"service_id" json:"service_id"`
+	Name      string            `yaml:"name"       json:"name"`
+	Arguments map[string]string `yaml:"arguments"  json:"arguments,omitempty"`
+}
+
+// MCPResourceRef attaches a resource of an MCP service to an agent's context
+type MCPResourceRef struct {
+	ServiceID string `yaml:"service_id" json:"service_id"`
+	URI       string `yaml:"uri"        json:"uri"`
+}
+
+// MCPContextItem is a pinned MCP prompt or resource, fetched for one agent run
+type MCPContextItem struct {
+	Kind        string // MCPContextPrompt or MCPContextResource
+	ServiceName string // Name of the MCP service it comes from
+	Name        string // Prompt name or resource URI
+	Content     string
+}
+
+const (
+	MCPContextPrompt   = "prompt"
+	MCPContextResource = "resource"
+)
+
 // MCPTestResult represents the result of testing an MCP service connection
 type MCPTestResult struct {
 	Success   bool           `json:"success"`
 	Message   string         `json:"message,omitempty"`
 	Tools     []*MCPTool     `json:"tools,omitempty"`
 	Resources []*MCPResource `json:"resources,omitempty"`
+	Prompts   []*MCPPrompt   `json:"prompts,omitempty"`
+	// AuthFailed is set when the service rejected the credentials or no access token could be obtained
+	AuthFailed bool `json:"auth_failed,omitempty"`
+	// AuthorizationRequired is set when the OAuth authorization has to be (re)done in the browser
+	AuthorizationRequired bool `json:"authorization_required,omitempty"`
 }
 
 // BeforeCreate is a GORM hook that runs before creating a new MCP service
@@ -94,15 +182,19 @@ func (m *MCPService) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-// Value implements driver.Valuer interface for MCPHeaders
+// Value implements driver.Valuer interface for MCPHeaders, encrypting the header values
 func (h MCPHeaders) Value() (driver.Value, error) {
 	if h == nil {
 		return nil, nil
 	}
-	return json.Marshal(h)
+	encrypted, err := encryptSecrets(h)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt MCP headers: %w", err)
+	}
+	return json.Marshal(encrypted)
 }
 
-// Scan implements sql.Scanner interface for MCPHeaders
+// Scan implements sql.Scanner interface for MCPHeaders, decrypting the header values
 func (h *MCPHeaders) Scan(value interface{}) error {
 	if value == nil {
 		*h = nil
@@ -112,18 +204,31 @@ func (h *MCPHeaders) Scan(value interface{}) error {
 	if !ok {
 		return nil
 	}
-	return json.Unmarshal(b, h)
+	var stored map[string]string
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return err
+	}
+	decrypted, err := decryptSecrets(stored)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt MCP headers: %w", err)
+	}
+	*h = decrypted
+	return nil
 }
 
-// Value implements driver.Valuer interface for MCPAuthConfig
+// Value implements driver.Valuer interface for MCPAuthConfig, encrypting the secrets
 func (c *MCPAuthConfig) Value() (driver.Value, error) {
 	if c == nil {
 		return nil, nil
 	}
-	return json.Marshal(c)
+	encrypted, err := c.mapSecrets(secutils.EncryptSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt MCP auth config: %w", err)
+	}
+	return json.Marshal(encrypted)
 }
 
-// Scan implements sql.Scanner interface for MCPAuthConfig
+// Scan implements sql.Scanner interface for MCPAuthConfig, decrypting the secrets
 func (c *MCPAuthConfig) Scan(value interface{}) error {
 	if value == nil {
 		return nil
@@ -132,7 +237,110 @@ func (c *MCPAuthConfig) Scan(value interface{}) error {
 	if !ok {
 		return nil
 	}
-	return json.Unmarshal(b, c)
+	if err := json.Unmarshal(b, c); err != nil {
+		return err
+	}
+	decrypted, err := c.mapSecrets(secutils.DecryptSecret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt MCP auth config: %w", err)
+	}
+	*c = *decrypted
+	return nil
+}
+
+// mapSecrets returns a copy of the config with every secret passed through fn
+func (c *MCPAuthConfig) mapSecrets(fn func(string) (string, error)) (*MCPAuthConfig, error) {
+	out := *c
+	secrets := []*string{&out.APIKey, &out.Token}
+	customHeaders, err := mapSecretValues(c.CustomHeaders, fn)
+	if err != nil {
+		return nil, err
+	}
+	out.CustomHeaders = customHeaders
+	if c.OAuth != nil {
+		oauth := *c.OAuth
+		out.OAuth = &oauth
+		secrets = append(secrets, &oauth.ClientSecret, &oauth.CodeVerifier)
+		if c.OAuth.Token != nil {
+			token := *c.OAuth.Token
+			oauth.Token = &token
+			secrets = append(secrets, &token.AccessToken, &token.RefreshToken)
+		}
+	}
+	for _, secret := range secrets {
+		mapped, err := fn(*secret)
+		if err != nil {
+			return nil, err
+		}
+		*secret = mapped
+	}
+	return &out, nil
+}
+
+// Validate checks that the OAuth client configuration is complete
+func (c *MCPOAuthConfig) Validate() error {
+	switch c.GrantType {
+	case MCPOAuthClientCredentials:
+		if c.ClientSecret == "" {
+			return fmt.Errorf("client_secret is required for the client_credentials grant")
+		}
+	case MCPOAuthAuthorizationCode:
+		if err := validateOAuthURL("authorization_url", c.AuthorizationURL); err != nil {
+			return err
+		}
+		if c.RedirectURL != "" {
+			if err := validateOAuthURL("redirect_url", c.RedirectURL); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported OAuth grant type: %q", c.GrantType)
+	}
+	if c.ClientID == "" {
+		return fmt.Errorf("client_id is required")
+	}
+	return validateOAuthURL("token_url", c.TokenURL)
+}
+
+// validateOAuthURL checks that an OAuth endpoint is an absolute http(s) URL
+func validateOAuthURL(field, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%s must be an absolute http(s) URL", field)
+	}
+	return nil
+}
+
+// SameClient reports whether two OAuth configurations obtain tokens from the same client for the same access
+func (c *MCPOAuthConfig) SameClient(other *MCPOAuthConfig) bool {
+	return c.GrantType == other.GrantType && c.TokenURL == other.TokenURL && c.ClientID == other.ClientID &&
+		c.Resource == other.Resource && slices.Equal(c.Scopes, other.Scopes)
+}
+
+// KeepSecrets carries over the secrets of the stored config that an update sends back masked,
+// and the OAuth tokens as long as they were obtained by the same client
+// Tokens sent by the client are dropped, they are only obtained by WeKnora
+func (c *MCPAuthConfig) KeepSecrets(stored *MCPAuthConfig) {
+	if c.OAuth != nil {
+		c.OAuth.Token, c.OAuth.State, c.OAuth.CodeVerifier = nil, "", ""
+	}
+	if stored == nil {
+		return
+	}
+	KeepRedactedSecret(&c.APIKey, stored.APIKey)
+	KeepRedactedSecret(&c.Token, stored.Token)
+	KeepRedactedSecretValues(c.CustomHeaders, stored.CustomHeaders)
+	if c.OAuth == nil || stored.OAuth == nil {
+		return
+	}
+	KeepRedactedSecret(&c.OAuth.ClientSecret, stored.OAuth.ClientSecret)
+	if c.OAuth.SameClient(stored.OAuth) && c.OAuth.ClientSecret == stored.OAuth.ClientSecret {
+		c.OAuth.Token = stored.OAuth.Token
+		c.OAuth.State, c.OAuth.CodeVerifier = stored.OAuth.State, stored.OAuth.CodeVerifier
+	}
 }
 
 // Value implements driver.Valuer interface for MCPAdvancedConfig
@@ -175,15 +383,19 @@ func (c *MCPStdioConfig) Scan(value interface{}) error {
 	return json.Unmarshal(b, c)
 }
 
-// Value implements driver.Valuer interface for MCPEnvVars
+// Value implements driver.Valuer interface for MCPEnvVars, encrypting the values
 func (e MCPEnvVars) Value() (driver.Value, error) {
 	if e == nil {
 		return nil, nil
 	}
-	return json.Marshal(e)
+	encrypted, err := encryptSecrets(e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt MCP environment variables: %w", err)
+	}
+	return json.Marshal(encrypted)
 }
 
-// Scan implements sql.Scanner interface for MCPEnvVars
+// Scan implements sql.Scanner interface for MCPEnvVars, decrypting the values
 func (e *MCPEnvVars) Scan(value interface{}) error {
 	if value == nil {
 		*e = nil
@@ -193,7 +405,16 @@ func (e *MCPEnvVars) Scan(value interface{}) error {
 	if !ok {
 		return nil
 	}
-	return json.Unmarshal(b, e)
+	var stored map[string]string
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return err
+	}
+	decrypted, err := decryptSecrets(stored)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt MCP environment variables: %w", err)
+	}
+	*e = decrypted
+	return nil
 }
 
 // GetDefaultAdvancedConfig returns default advanced configuration
@@ -205,8 +426,17 @@ func GetDefaultAdvancedConfig() *MCPAdvancedConfig {
 	}
 }
 
+// KeepSecrets carries over the header and environment variable values of the stored service
+// that an update sends back masked
+func (m *MCPService) KeepSecrets(stored *MCPService) {
+	KeepRedactedSecretValues(m.Headers, stored.Headers)
+	KeepRedactedSecretValues(m.EnvVars, stored.EnvVars)
+}
+
 // MaskSensitiveData masks sensitive information in the MCP service for display
 func (m *MCPService) MaskSensitiveData() {
+	m.Headers = RedactSecretValues(m.Headers)
+	m.EnvVars = RedactSecretValues(m.EnvVars)
 	if m.AuthConfig != nil {
 		if m.AuthConfig.APIKey != "" {
 			m.AuthConfig.APIKey = maskString(m.AuthConfig.APIKey)
@@ -214,6 +444,23 @@ func (m *MCPService) MaskSensitiveData() {
 		if m.AuthConfig.Token != "" {
 			m.AuthConfig.Token = maskString(m.AuthConfig.Token)
 		}
+		for key, value := range m.AuthConfig.CustomHeaders {
+			m.AuthConfig.CustomHeaders[key] = maskString(value)
+		}
+		if oauth := m.AuthConfig.OAuth; oauth != nil {
+			if oauth.ClientSecret != "" {
+				oauth.ClientSecret = maskString(oauth.ClientSecret)
+			}
+			if oauth.Token != nil {
+				token := *oauth.Token
+				token.AccessToken = maskString(token.AccessToken)
+				if token.RefreshToken != "" {
+					token.RefreshToken = maskString(token.RefreshToken)
+				}
+				oauth.Token = &token
+			}
+			oauth.State, oauth.CodeVerifier = "", ""
+		}
 	}
 }
 
@@ -67,6 +67,9 @@ type Message struct {
 	Content string `json:"content"`
 	// Message role: "user", "assistant", "system"
 	Role string `json:"role"`
+	// ID of the chat model that generated the answer, which differs from the configured
+	// model when the request failed over to a fallback model (only for assistant messages)
+	ModelID string `json:"model_id,omitempty"`
 	// References to knowledge chunks used in the response
 	KnowledgeReferences References `json:"knowledge_references"  gorm:"type:json,column:knowledge_references"`
 	// Agent execution steps (only for assistant messages generated by agent)
@@ -3,8 +3,10 @@ package types
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"time"
 
+	secutils "github.com/Tencent/WeKnora/internal/utils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -46,6 +48,7 @@ const (
 	ModelSourceSiliconFlow ModelSource = "siliconflow" // SiliconFlow model
 	ModelSourceJina        ModelSource = "jina"        // Jina AI model
 	ModelSourceOpenRouter  ModelSource = "openrouter"  // OpenRouter model
+	ModelSourceAnthropic   ModelSource = "anthropic"   // Anthropic Messages API model
 )
 
 // EmbeddingParameters represents the embedding parameters for a model
@@ -59,9 +62,38 @@ type ModelParameters struct {
 	APIKey              string              `yaml:"api_key"              json:"api_key"`
 	InterfaceType       string              `yaml:"interface_type"       json:"interface_type"`
 	EmbeddingParameters EmbeddingParameters `yaml:"embedding_parameters" json:"embedding_parameters"`
-	ParameterSize       string              `yaml:"parameter_size"       json:"parameter_size"` // Ollama model parameter size (e.g., "7B", "13B", "70B")
-	Provider            string              `yaml:"provider"             json:"provider"`       // Provider identifier: openai, aliyun, zhipu, generic
-	ExtraConfig         map[string]string   `yaml:"extra_config"         json:"extra_config"`   // Provider-specific configuration
+	ParameterSize       string              `yaml:"parameter_size"       json:"parameter_size"`     // Ollama model parameter size (e.g., "7B", "13B", "70B")
+	Provider            string              `yaml:"provider"             json:"provider"`           // Provider identifier: openai, aliyun, zhipu, generic
+	ExtraConfig         map[string]string   `yaml:"extra_config"         json:"extra_config"`       // Provider-specific configuration
+	Fallback            *ModelFallback      `yaml:"fallback"             json:"fallback,omitempty"` // Chat models to fail over to
+}
+
+// ModelRetryPolicy is how a chat model request is retried on one class of errors
+// before failing over to the next model
+type ModelRetryPolicy struct {
+	// Retries on the same model, 0 fails over immediately
+	MaxRetries int `yaml:"max_retries"        json:"max_retries"`
+	// Backoff before the first retry, doubled on every further retry
+	InitialBackoffMs int `yaml:"initial_backoff_ms" json:"initial_backoff_ms"`
+	// Upper bound of the backoff
+	MaxBackoffMs int `yaml:"max_backoff_ms"     json:"max_backoff_ms"`
+}
+
+// ModelFallback declares the ordered chat models a request fails over to when the
+// model times out, is rate limited or is unavailable, with the retry policy per error class.
+// Policies left empty use the defaults.
+type ModelFallback struct {
+	// Chat models tried in order after the primary model
+	ModelIDs []string `yaml:"model_ids"    json:"model_ids"`
+	// Policy for rate limited requests (HTTP 429)
+	RateLimit *ModelRetryPolicy `yaml:"rate_limit"   json:"rate_limit,omitempty"`
+	// Policy for timeouts
+	Timeout *ModelRetryPolicy `yaml:"timeout"      json:"timeout,omitempty"`
+	// Policy for server errors (HTTP 5xx) and connection failures
+	ServerError *ModelRetryPolicy `yaml:"server_error" json:"server_error,omitempty"`
+	// Time limit of each attempt, a request or stream not answered in time is handled as a timeout.
+	// 0 leaves the attempts unbounded.
+	AttemptTimeoutMs int `yaml:"attempt_timeout_ms" json:"attempt_timeout_ms,omitempty"`
 }
 
 // Model represents the AI model
@@ -95,11 +127,18 @@ type Model struct {
 }
 
 // Value implements the driver.Valuer interface, used to convert ModelParameters to database value
+// The API key is encrypted
 func (c ModelParameters) Value() (driver.Value, error) {
+	apiKey, err := secutils.EncryptSecret(c.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt model API key: %w", err)
+	}
+	c.APIKey = apiKey
 	return json.Marshal(c)
 }
 
 // Scan implements the sql.Scanner interface, used to convert database value to ModelParameters
+// The API key is decrypted
 func (c *ModelParameters) Scan(value interface{}) error {
 	if value == nil {
 		return nil
@@ -108,7 +147,15 @@ func (c *ModelParameters) Scan(value interface{}) error {
 	if !ok {
 		return nil
 	}
-	return json.Unmarshal(b, c)
+	if err := json.Unmarshal(b, c); err != nil {
+		return err
+	}
+	apiKey, err := secutils.DecryptSecret(c.APIKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt model API key: %w", err)
+	}
+	c.APIKey = apiKey
+	return nil
 }
 
 // BeforeCreate is a GORM hook that runs before creating a new model record
@@ -0,0 +1,150 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Parameters a pipeline stage may override on ChatManage while it runs
+const (
+	StageParamTopK             = "top_k" // Alias of rerank_top_k, used by FILTER_TOP_K
+	StageParamEmbeddingTopK    = "embedding_top_k"
+	StageParamVectorThreshold  = "vector_threshold"
+	StageParamKeywordThreshold = "keyword_threshold"
+	StageParamRerankTopK       = "rerank_top_k"
+	StageParamRerankThreshold  = "rerank_threshold"
+	StageParamRerankModelID    = "rerank_model_id"
+)
+
+// PipelineStage is a single step of a declarative chat pipeline.
+// Params override the matching ChatManage fields for the duration of the stage only.
+type PipelineStage struct {
+	Event  EventType              `yaml:"event" json:"event"`
+	Params map[string]interface{} `yaml:"params" json:"params,omitempty"`
+}
+
+// NewPipelineStages converts a plain event list into pipeline stages without parameters
+func NewPipelineStages(events []EventType) []PipelineStage {
+	stages := make([]PipelineStage, 0, len(events))
+	for _, event := range events {
+		stages = append(stages, PipelineStage{Event: event})
+	}
+	return stages
+}
+
+// Validate checks that the stage names an event and that its parameters are known and well typed
+func (s PipelineStage) Validate() error {
+	if s.Event == "" {
+		return fmt.Errorf("pipeline stage event is required")
+	}
+	keys := make([]string, 0, len(s.Params))
+	for key := range s.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := s.Params[key]
+		switch key {
+		case StageParamTopK, StageParamEmbeddingTopK, StageParamRerankTopK:
+			n, ok := stageParamInt(value)
+			if !ok || n <= 0 {
+				return fmt.Errorf("stage %s: %s must be a positive integer", s.Event, key)
+			}
+		case StageParamVectorThreshold, StageParamKeywordThreshold, StageParamRerankThreshold:
+			f, ok := stageParamFloat(value)
+			if !ok || f < 0 {
+				return fmt.Errorf("stage %s: %s must be a non-negative number", s.Event, key)
+			}
+		case StageParamRerankModelID:
+			str, ok := value.(string)
+			if !ok || strings.TrimSpace(str) == "" {
+				return fmt.Errorf("stage %s: %s must be a non-empty string", s.Event, key)
+			}
+		default:
+			return fmt.Errorf("stage %s: unsupported parameter %s", s.Event, key)
+		}
+	}
+	return nil
+}
+
+// Apply overrides ChatManage fields with the stage parameters and returns a function
+// restoring the overridden fields. Parameters are expected to have passed Validate.
+func (s PipelineStage) Apply(chatManage *ChatManage) (restore func()) {
+	var restores []func()
+	for key, value := range s.Params {
+		switch key {
+		case StageParamTopK, StageParamRerankTopK:
+			if n, ok := stageParamInt(value); ok {
+				prev := chatManage.RerankTopK
+				chatManage.RerankTopK = n
+				restores = append(restores, func() { chatManage.RerankTopK = prev })
+			}
+		case StageParamEmbeddingTopK:
+			if n, ok := stageParamInt(value); ok {
+				prev := chatManage.EmbeddingTopK
+				chatManage.EmbeddingTopK = n
+				restores = append(restores, func() { chatManage.EmbeddingTopK = prev })
+			}
+		case StageParamVectorThreshold:
+			if f, ok := stageParamFloat(value); ok {
+				prev := chatManage.VectorThreshold
+				chatManage.VectorThreshold = f
+				restores = append(restores, func() { chatManage.VectorThreshold = prev })
+			}
+		case StageParamKeywordThreshold:
+			if f, ok := stageParamFloat(value); ok {
+				prev := chatManage.KeywordThreshold
+				chatManage.KeywordThreshold = f
+				restores = append(restores, func() { chatManage.KeywordThreshold = prev })
+			}
+		case StageParamRerankThreshold:
+			if f, ok := stageParamFloat(value); ok {
+				prev := chatManage.RerankThreshold
+				chatManage.RerankThreshold = f
+				restores = append(restores, func() { chatManage.RerankThreshold = prev })
+			}
+		case StageParamRerankModelID:
+			if str, ok := value.(string); ok {
+				prev := chatManage.RerankModelID
+				chatManage.RerankModelID = str
+				restores = append(restores, func() { chatManage.RerankModelID = prev })
+			}
+		}
+	}
+	return func() {
+		// Undo in reverse order so that top_k and rerank_top_k set together restore cleanly
+		for i := len(restores) - 1; i >= 0; i-- {
+			restores[i]()
+		}
+	}
+}
+
+// stageParamInt reads an integer parameter, accepting the float64 produced by JSON decoding
+func stageParamInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		if v != float64(int(v)) {
+			return 0, false
+		}
+		return int(v), true
+	}
+	return 0, false
+}
+
+// stageParamFloat reads a numeric parameter
+func stageParamFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
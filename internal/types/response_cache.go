@@ -0,0 +1,24 @@
+package types
+
+import "time"
+
+// ResponseCacheScope identifies the answers that can be reused for a knowledge QA request.
+// Answers are only shared between requests searching the same knowledge with the same settings.
+type ResponseCacheScope struct {
+	TenantID         uint64   `json:"tenant_id"`
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids"`
+	KnowledgeIDs     []string `json:"knowledge_ids,omitempty"`
+	EmbeddingModelID string   `json:"embedding_model_id"`
+	// ConfigHash covers the agent and pipeline settings that shape the answer
+	ConfigHash string `json:"config_hash"`
+}
+
+// ResponseCacheEntry is a cached knowledge QA answer
+type ResponseCacheEntry struct {
+	Query      string          `json:"query"`
+	Embedding  []float32       `json:"embedding"`
+	Answer     string          `json:"answer"`
+	References []*SearchResult `json:"references"`
+	ModelID    string          `json:"model_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
@@ -1,5 +1,13 @@
 package types
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
 // RetrieverEngineType represents the type of retriever engine
 type RetrieverEngineType string
 
@@ -10,6 +18,8 @@ const (
 	InfinityRetrieverEngineType      RetrieverEngineType = "infinity"
 	ElasticFaissRetrieverEngineType  RetrieverEngineType = "elasticfaiss"
 	QdrantRetrieverEngineType        RetrieverEngineType = "qdrant"
+	MilvusRetrieverEngineType        RetrieverEngineType = "milvus"
+	EmbeddedRetrieverEngineType      RetrieverEngineType = "embedded"
 )
 
 // RetrieverType represents the type of retriever
@@ -36,6 +46,11 @@ type RetrieveParams struct {
 	ExcludeKnowledgeIDs []string
 	// Excluded chunk IDs
 	ExcludeChunkIDs []string
+	// Structured filter on chunk and knowledge attributes
+	Filter *RetrieveFilter
+	// Allowed chunk IDs resolved from Filter for engines that cannot evaluate it themselves.
+	// Postgres joins the chunk tables and the other built-in engines match the index attributes instead.
+	FilterChunkIDs []string
 	// Number of results to return
 	TopK int
 	// Similarity threshold
@@ -48,6 +63,84 @@ type RetrieveParams struct {
 	RetrieverType RetrieverType // Retriever type
 }
 
+// RetrieveFilter restricts retrieval by chunk and knowledge attributes.
+// All conditions are combined with AND; values inside one condition are combined with OR.
+type RetrieveFilter struct {
+	// Tag IDs, matched against the chunk tag (FAQ entries) or the knowledge tag (documents)
+	TagIDs []string `json:"tag_ids,omitempty"`
+	// Knowledge file types, e.g. "pdf", "docx", "manual"
+	FileTypes []string `json:"file_types,omitempty"`
+	// Only knowledge created at or after this time
+	CreatedAfter *time.Time `json:"created_after,omitempty"`
+	// Only knowledge created before this time
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	// Exact matches on top-level keys of Chunk.Metadata
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// IsEmpty reports whether the filter has no conditions
+func (f *RetrieveFilter) IsEmpty() bool {
+	return f == nil || (len(f.TagIDs) == 0 && len(f.FileTypes) == 0 &&
+		f.CreatedAfter == nil && f.CreatedBefore == nil && len(f.Metadata) == 0)
+}
+
+// MetadataPairs returns the metadata conditions encoded with MetadataPair, sorted by key
+func (f *RetrieveFilter) MetadataPairs() []string {
+	pairs := make([]string, 0, len(f.Metadata))
+	for key, value := range f.Metadata {
+		pairs = append(pairs, MetadataPair(key, value))
+	}
+	slices.Sort(pairs)
+	return pairs
+}
+
+// Matches reports whether an index entry with the given attributes satisfies the filter,
+// entries without attributes only match an empty filter
+func (f *RetrieveFilter) Matches(attrs *IndexAttributes) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	if attrs == nil {
+		return false
+	}
+	if len(f.TagIDs) > 0 && !slices.ContainsFunc(attrs.TagIDs, func(tagID string) bool {
+		return slices.Contains(f.TagIDs, tagID)
+	}) {
+		return false
+	}
+	if len(f.FileTypes) > 0 && !slices.Contains(f.FileTypes, attrs.FileType) {
+		return false
+	}
+	if f.CreatedAfter != nil && attrs.CreatedAt < f.CreatedAfter.UnixMilli() {
+		return false
+	}
+	if f.CreatedBefore != nil && attrs.CreatedAt >= f.CreatedBefore.UnixMilli() {
+		return false
+	}
+	for _, pair := range f.MetadataPairs() {
+		if !slices.Contains(attrs.MetadataPairs, pair) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks that the filter is consistent
+func (f *RetrieveFilter) Validate() error {
+	if f == nil {
+		return nil
+	}
+	if f.CreatedAfter != nil && f.CreatedBefore != nil && !f.CreatedAfter.Before(*f.CreatedBefore) {
+		return fmt.Errorf("created_after must be earlier than created_before")
+	}
+	for key := range f.Metadata {
+		if key == "" {
+			return fmt.Errorf("metadata filter key cannot be empty")
+		}
+	}
+	return nil
+}
+
 // RetrieverEngineParams represents the parameters for retriever engine
 type RetrieverEngineParams struct {
 	// Retriever engine type
@@ -78,6 +171,21 @@ type IndexWithScore struct {
 	MatchType MatchType
 	// IsEnabled
 	IsEnabled bool
+	// Sources records the rank and raw score this result had in each retriever list
+	// that contributed to it (set by result fusion)
+	Sources []RetrieverScore
+}
+
+// RetrieverScore is the provenance of a fused result within a single retriever list
+type RetrieverScore struct {
+	// Retriever engine type
+	RetrieverEngineType RetrieverEngineType `json:"retriever_engine_type"`
+	// Retriever type
+	RetrieverType RetrieverType `json:"retriever_type"`
+	// 1-based rank within the retriever list
+	Rank int `json:"rank"`
+	// Raw score returned by the retriever
+	Score float64 `json:"score"`
 }
 
 // GetScore returns the score for ScoreComparable interface
@@ -92,3 +200,104 @@ type RetrieveResult struct {
 	RetrieverType       RetrieverType       // Retrieval type
 	Error               error               // Retrieval error
 }
+
+// FusionMethod represents the method used to merge results from multiple retrievers
+type FusionMethod string
+
+// FusionMethod constants
+const (
+	// FusionMethodRRF scores each result by sum(1 / (k + rank)) over the retrievers it appears in
+	FusionMethodRRF FusionMethod = "rrf"
+	// FusionMethodWeighted min-max normalizes each retriever's scores and combines them linearly
+	FusionMethodWeighted FusionMethod = "weighted"
+	// FusionMethodMax min-max normalizes each retriever's scores and keeps the highest one
+	FusionMethodMax FusionMethod = "max"
+)
+
+const (
+	// DefaultRRFK is the RRF rank constant; 60 is a common choice that works well in practice
+	DefaultRRFK = 60
+	// DefaultVectorWeight is the vector retriever weight for weighted fusion
+	DefaultVectorWeight = 0.5
+	// DefaultKeywordWeight is the keyword retriever weight for weighted fusion
+	DefaultKeywordWeight = 0.5
+)
+
+// FusionConfig configures how results from multiple retrievers are merged into one ranked list
+type FusionConfig struct {
+	// Fusion method: "rrf" (default), "weighted" or "max"
+	Method FusionMethod `yaml:"method"         json:"method"`
+	// RRF rank constant (only for "rrf", default 60)
+	RRFK int `yaml:"rrf_k"          json:"rrf_k,omitempty"`
+	// Weight of the vector retriever (only for "weighted")
+	VectorWeight float64 `yaml:"vector_weight"  json:"vector_weight,omitempty"`
+	// Weight of the keyword retriever (only for "weighted")
+	KeywordWeight float64 `yaml:"keyword_weight" json:"keyword_weight,omitempty"`
+}
+
+// Validate checks that the fusion configuration is usable
+func (c *FusionConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.Method {
+	case "", FusionMethodRRF, FusionMethodWeighted, FusionMethodMax:
+	default:
+		return fmt.Errorf("unsupported fusion method: %s", c.Method)
+	}
+	if c.RRFK < 0 {
+		return fmt.Errorf("rrf_k must not be negative")
+	}
+	if c.VectorWeight < 0 || c.KeywordWeight < 0 {
+		return fmt.Errorf("fusion weights must not be negative")
+	}
+	return nil
+}
+
+// WithDefaults returns a copy of the configuration with unset fields filled in
+func (c *FusionConfig) WithDefaults() FusionConfig {
+	var result FusionConfig
+	if c != nil {
+		result = *c
+	}
+	if result.Method == "" {
+		result.Method = FusionMethodRRF
+	}
+	if result.RRFK == 0 {
+		result.RRFK = DefaultRRFK
+	}
+	if result.VectorWeight == 0 && result.KeywordWeight == 0 {
+		result.VectorWeight = DefaultVectorWeight
+		result.KeywordWeight = DefaultKeywordWeight
+	}
+	return result
+}
+
+// Weight returns the weight of a retriever type for weighted fusion
+func (c FusionConfig) Weight(retrieverType RetrieverType) float64 {
+	switch retrieverType {
+	case VectorRetrieverType:
+		return c.VectorWeight
+	case KeywordsRetrieverType:
+		return c.KeywordWeight
+	default:
+		return 0
+	}
+}
+
+// Value implements the driver.Valuer interface, used to convert FusionConfig to database value
+func (c FusionConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface, used to convert database value to FusionConfig
+func (c *FusionConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
@@ -98,6 +98,14 @@ type SearchParams struct {
 	DisableKeywordsMatch bool     `json:"disable_keywords_match"`
 	DisableVectorMatch   bool     `json:"disable_vector_match"`
 	KnowledgeIDs         []string `json:"knowledge_ids"`
+	// Fusion overrides the knowledge base's result fusion configuration when set
+	Fusion *FusionConfig `json:"fusion,omitempty"`
+	// Filter restricts results by tag, file type, creation time and chunk metadata
+	Filter *RetrieveFilter `json:"filter,omitempty"`
+	// HypotheticalDoc is an extra passage embedded for vector retrieval (HyDE)
+	HypotheticalDoc string `json:"hypothetical_doc,omitempty"`
+	// HypotheticalOnly uses only the hypothetical passage for vector retrieval
+	HypotheticalOnly bool `json:"hypothetical_only,omitempty"`
 }
 
 // Value implements the driver.Valuer interface, used to convert SearchResult to database value
@@ -0,0 +1,107 @@
+package types
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// RedactedSecret is the placeholder some clients send back for a secret they were shown redacted
+const RedactedSecret = "***"
+
+// SecretString is a string encrypted at rest, such as an API key
+type SecretString string
+
+// Value implements driver.Valuer interface for SecretString, encrypting the secret
+func (s SecretString) Value() (driver.Value, error) {
+	encrypted, err := secutils.EncryptSecret(string(s))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	return encrypted, nil
+}
+
+// Scan implements sql.Scanner interface for SecretString, decrypting the secret
+func (s *SecretString) Scan(value interface{}) error {
+	var stored string
+	switch v := value.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("unsupported secret type %T", value)
+	}
+	decrypted, err := secutils.DecryptSecret(stored)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	*s = SecretString(decrypted)
+	return nil
+}
+
+// RedactSecret masks a secret for display, showing only its first 4 and last 4 characters
+func RedactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return maskString(secret)
+}
+
+// KeepRedactedSecret restores the stored secret if the value is the redacted form it was displayed as,
+// so that clients can send back the configuration they were shown without overwriting the secret
+func KeepRedactedSecret(value *string, stored string) {
+	if stored != "" && (*value == maskString(stored) || *value == RedactedSecret) {
+		*value = stored
+	}
+}
+
+// KeepRedactedSecretValues restores the stored values of a map of secrets sent back redacted
+func KeepRedactedSecretValues(values map[string]string, stored map[string]string) {
+	for key, value := range values {
+		KeepRedactedSecret(&value, stored[key])
+		values[key] = value
+	}
+}
+
+// RedactSecretValues returns a copy of a map of secrets with every value redacted
+func RedactSecretValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(values))
+	for key, value := range values {
+		redacted[key] = RedactSecret(value)
+	}
+	return redacted
+}
+
+// encryptSecrets returns a copy of the map with every value encrypted
+func encryptSecrets(values map[string]string) (map[string]string, error) {
+	return mapSecretValues(values, secutils.EncryptSecret)
+}
+
+// decryptSecrets returns a copy of the map with every value decrypted
+func decryptSecrets(values map[string]string) (map[string]string, error) {
+	return mapSecretValues(values, secutils.DecryptSecret)
+}
+
+// mapSecretValues returns a copy of the map with every value passed through fn
+func mapSecretValues(values map[string]string, fn func(string) (string, error)) (map[string]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	mapped := make(map[string]string, len(values))
+	for key, value := range values {
+		out, err := fn(value)
+		if err != nil {
+			return nil, err
+		}
+		mapped[key] = out
+	}
+	return mapped, nil
+}
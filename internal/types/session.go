@@ -79,6 +79,9 @@ type Session struct {
 	Description string `json:"description"`
 	// Tenant ID
 	TenantID uint64 `json:"tenant_id"   gorm:"index"`
+	// Owner user ID. Sessions without an owner (created with an API key or before
+	// per-user ownership) are visible to the whole tenant
+	UserID string `json:"user_id"     gorm:"type:varchar(36);index"`
 
 	// // Strategy configuration
 	// KnowledgeBaseID   string              `json:"knowledge_base_id"`                    // 关联的知识库ID
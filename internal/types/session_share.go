@@ -0,0 +1,44 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SessionShareType is the audience a session is shared with
+type SessionShareType string
+
+const (
+	// SessionShareTypeUser shares a session with a single user
+	SessionShareTypeUser SessionShareType = "user"
+	// SessionShareTypeTenant shares a session with every user of the tenant
+	SessionShareTypeTenant SessionShareType = "tenant"
+)
+
+// SessionShare grants read-only access to a session to users other than its owner
+type SessionShare struct {
+	ID        string           `json:"id"         gorm:"type:varchar(36);primaryKey"`
+	TenantID  uint64           `json:"tenant_id"`
+	SessionID string           `json:"session_id" gorm:"type:varchar(36)"`
+	ShareType SessionShareType `json:"share_type" gorm:"type:varchar(16)"`
+	UserID    string           `json:"user_id"    gorm:"type:varchar(36)"` // Target user, empty for tenant shares
+	CreatedBy string           `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+func (s *SessionShare) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == "" {
+		s.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// SessionListFilter narrows the sessions listed for the current user
+type SessionListFilter struct {
+	// UserID lists the sessions of this user, together with sessions without an owner
+	UserID string
+	// Shared lists the sessions shared with UserID instead of the ones it owns
+	Shared bool
+}
@@ -27,6 +27,14 @@ var retrieverEngineMapping = map[string][]RetrieverEngineParams{
 		{RetrieverType: KeywordsRetrieverType, RetrieverEngineType: QdrantRetrieverEngineType},
 		{RetrieverType: VectorRetrieverType, RetrieverEngineType: QdrantRetrieverEngineType},
 	},
+	"milvus": {
+		{RetrieverType: KeywordsRetrieverType, RetrieverEngineType: MilvusRetrieverEngineType},
+		{RetrieverType: VectorRetrieverType, RetrieverEngineType: MilvusRetrieverEngineType},
+	},
+	"embedded": {
+		{RetrieverType: KeywordsRetrieverType, RetrieverEngineType: EmbeddedRetrieverEngineType},
+		{RetrieverType: VectorRetrieverType, RetrieverEngineType: EmbeddedRetrieverEngineType},
+	},
 }
 
 // GetDefaultRetrieverEngines returns the default retriever engines based on RETRIEVE_DRIVER env
@@ -58,7 +66,7 @@ type Tenant struct {
 	// Description
 	Description string `yaml:"description"         json:"description"`
 	// API key
-	APIKey string `yaml:"api_key"             json:"api_key"`
+	APIKey SecretString `yaml:"api_key"             json:"api_key"             gorm:"type:text"`
 	// Status
 	Status string `yaml:"status"              json:"status"              gorm:"default:'active'"`
 	// Retriever engines
@@ -69,6 +77,8 @@ type Tenant struct {
 	StorageQuota int64 `yaml:"storage_quota"       json:"storage_quota"       gorm:"default:10737418240"`
 	// Storage used (Bytes)
 	StorageUsed int64 `yaml:"storage_used"        json:"storage_used"        gorm:"default:0"`
+	// Monthly quota of chat, embedding and rerank tokens, 0 means unlimited
+	TokenQuota int64 `yaml:"token_quota"         json:"token_quota"         gorm:"default:0"`
 	// Deprecated: AgentConfig is deprecated, use CustomAgent (builtin-smart-reasoning) config instead.
 	// This field is kept for backward compatibility and will be removed in future versions.
 	AgentConfig *AgentConfig `yaml:"agent_config"        json:"agent_config"        gorm:"type:jsonb"`
@@ -100,6 +110,27 @@ func (t *Tenant) GetEffectiveEngines() []RetrieverEngineParams {
 	return GetDefaultRetrieverEngines()
 }
 
+// Redacted returns a copy of the tenant with its API keys masked for display
+func (t *Tenant) Redacted() *Tenant {
+	if t == nil {
+		return nil
+	}
+	redacted := *t
+	redacted.APIKey = SecretString(RedactSecret(string(t.APIKey)))
+	redacted.WebSearchConfig = t.WebSearchConfig.Redacted()
+	return &redacted
+}
+
+// KeepSecrets restores the API keys of the stored tenant that an update sends back masked
+func (t *Tenant) KeepSecrets(stored *Tenant) {
+	apiKey := string(t.APIKey)
+	KeepRedactedSecret(&apiKey, string(stored.APIKey))
+	t.APIKey = SecretString(apiKey)
+	if t.WebSearchConfig != nil && stored.WebSearchConfig != nil {
+		KeepRedactedSecret(&t.WebSearchConfig.APIKey, stored.WebSearchConfig.APIKey)
+	}
+}
+
 // BeforeCreate is a hook function that is called before creating a tenant
 func (t *Tenant) BeforeCreate(tx *gorm.DB) error {
 	if t.RetrieverEngines.Engines == nil {
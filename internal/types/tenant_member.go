@@ -0,0 +1,92 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TenantRole is the role of a user in a tenant
+type TenantRole string
+
+const (
+	// TenantRoleOwner can do everything, including deleting the tenant and managing other owners
+	TenantRoleOwner TenantRole = "owner"
+	// TenantRoleAdmin manages members, models, MCP services, agents and tenant settings
+	TenantRoleAdmin TenantRole = "admin"
+	// TenantRoleEditor creates and edits knowledge bases and their content
+	TenantRoleEditor TenantRole = "editor"
+	// TenantRoleViewer can only read knowledge and chat
+	TenantRoleViewer TenantRole = "viewer"
+)
+
+// tenantRoleLevels ranks the roles, a role includes the permissions of all lower roles
+var tenantRoleLevels = map[TenantRole]int{
+	TenantRoleViewer: 1,
+	TenantRoleEditor: 2,
+	TenantRoleAdmin:  3,
+	TenantRoleOwner:  4,
+}
+
+// IsValid reports whether the role is one of the known roles
+func (r TenantRole) IsValid() bool {
+	_, ok := tenantRoleLevels[r]
+	return ok
+}
+
+// Includes reports whether the role grants at least the permissions of other.
+// An empty or unknown role includes nothing.
+func (r TenantRole) Includes(other TenantRole) bool {
+	level, ok := tenantRoleLevels[r]
+	return ok && level >= tenantRoleLevels[other]
+}
+
+// MaxTenantRole returns the higher of two roles
+func MaxTenantRole(a, b TenantRole) TenantRole {
+	if tenantRoleLevels[b] > tenantRoleLevels[a] {
+		return b
+	}
+	return a
+}
+
+// TenantMember is the membership of a user in a tenant
+type TenantMember struct {
+	ID        string     `json:"id"         gorm:"type:varchar(36);primaryKey"`
+	TenantID  uint64     `json:"tenant_id"  gorm:"uniqueIndex:idx_tenant_members_user"`
+	UserID    string     `json:"user_id"    gorm:"type:varchar(36);uniqueIndex:idx_tenant_members_user"`
+	Role      TenantRole `json:"role"       gorm:"type:varchar(16)"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+
+	// Association relationship, not stored in the database
+	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+// BeforeCreate assigns an ID to new members
+func (m *TenantMember) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// KnowledgeBaseGrant raises the role of a tenant member on a single knowledge base
+type KnowledgeBaseGrant struct {
+	ID              string     `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64     `json:"tenant_id"`
+	KnowledgeBaseID string     `json:"knowledge_base_id" gorm:"type:varchar(36);uniqueIndex:idx_kb_grants_user"`
+	UserID          string     `json:"user_id"           gorm:"type:varchar(36);uniqueIndex:idx_kb_grants_user"`
+	Role            TenantRole `json:"role"              gorm:"type:varchar(16)"`
+	CreatedBy       string     `json:"created_by"        gorm:"type:varchar(36)"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate assigns an ID to new grants
+func (g *KnowledgeBaseGrant) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == "" {
+		g.ID = uuid.New().String()
+	}
+	return nil
+}
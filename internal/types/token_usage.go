@@ -0,0 +1,79 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TokenUsage is the tokens consumed by one chat, embedding or rerank model call
+type TokenUsage struct {
+	ID               string    `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID         uint64    `json:"tenant_id"         gorm:"index:idx_token_usages_tenant_time"`
+	ModelID          string    `json:"model_id"          gorm:"type:varchar(64)"`
+	ModelType        ModelType `json:"model_type"        gorm:"type:varchar(32)"`
+	SessionID        string    `json:"session_id"        gorm:"type:varchar(36)"`
+	PromptTokens     int64     `json:"prompt_tokens"`
+	CompletionTokens int64     `json:"completion_tokens"`
+	TotalTokens      int64     `json:"total_tokens"`
+	// Estimated is true when the model did not report its usage and the tokens were
+	// estimated from the text length
+	Estimated bool      `json:"estimated"`
+	CreatedAt time.Time `json:"created_at"        gorm:"index:idx_token_usages_tenant_time"`
+}
+
+// BeforeCreate assigns an ID to new usage records
+func (u *TokenUsage) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == "" {
+		u.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// TokenUsageQuery filters a token usage report
+type TokenUsageQuery struct {
+	// Start of the report, inclusive
+	From time.Time
+	// End of the report, exclusive
+	To time.Time
+	// Only count calls of the model (optional)
+	ModelID string
+	// Only count calls of the session (optional)
+	SessionID string
+}
+
+// TokenUsageSummary is the token usage of a group of model calls
+type TokenUsageSummary struct {
+	Calls            int64 `json:"calls"`
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// DailyTokenUsage is the token usage of one day (UTC)
+type DailyTokenUsage struct {
+	Date string `json:"date"`
+	TokenUsageSummary
+}
+
+// ModelTokenUsage is the token usage of one model
+type ModelTokenUsage struct {
+	ModelID   string    `json:"model_id"`
+	ModelType ModelType `json:"model_type"`
+	TokenUsageSummary
+}
+
+// TokenUsageReport is the token usage of a tenant over a period
+type TokenUsageReport struct {
+	TenantID uint64    `json:"tenant_id"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+	// Monthly token quota of the tenant, 0 means unlimited
+	TokenQuota int64 `json:"token_quota"`
+	// Tokens used in the current month, which the quota applies to
+	MonthUsed int64              `json:"month_used"`
+	Total     TokenUsageSummary  `json:"total"`
+	Daily     []*DailyTokenUsage `json:"daily"`
+	ByModel   []*ModelTokenUsage `json:"by_model"`
+}
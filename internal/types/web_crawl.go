@@ -0,0 +1,44 @@
+package types
+
+// WebCrawlRequest 网站爬取请求，从种子URL或sitemap.xml出发，为每个页面创建一条URL知识
+type WebCrawlRequest struct {
+	URL              string   `json:"url"`               // 种子URL，与 sitemap_url 二选一
+	SitemapURL       string   `json:"sitemap_url"`       // sitemap.xml 地址，支持 sitemap index
+	MaxDepth         *int     `json:"max_depth"`         // 最大链接深度，种子页为 0，默认 2
+	MaxPages         int      `json:"max_pages"`         // 最多创建的页面数
+	SameHost         *bool    `json:"same_host"`         // 是否只爬取同一主机，默认 true
+	Include          []string `json:"include"`           // 包含规则（glob），为空时不限制
+	Exclude          []string `json:"exclude"`           // 排除规则（glob）
+	EnableMultimodel *bool    `json:"enable_multimodel"` // 是否启用多模态处理
+	RefreshInterval  int      `json:"refresh_interval"`  // 页面定时刷新间隔（秒），0 表示不刷新
+}
+
+// WebCrawlPayload represents the website crawl task payload
+type WebCrawlPayload struct {
+	TenantID        uint64          `json:"tenant_id"`
+	TaskID          string          `json:"task_id"`
+	KnowledgeBaseID string          `json:"knowledge_base_id"`
+	Request         WebCrawlRequest `json:"request"`
+}
+
+// WebCrawlProgress represents the progress of a website crawl task
+type WebCrawlProgress struct {
+	TaskID          string            `json:"task_id"`
+	TenantID        uint64            `json:"tenant_id"`
+	KnowledgeBaseID string            `json:"knowledge_base_id"`
+	SeedURL         string            `json:"seed_url,omitempty"`
+	SitemapURL      string            `json:"sitemap_url,omitempty"`
+	Status          KBCloneTaskStatus `json:"status"`
+	Progress        int               `json:"progress"`   // 0-100
+	MaxPages        int               `json:"max_pages"`  // 页面数上限
+	Discovered      int               `json:"discovered"` // 已发现的URL数
+	Processed       int               `json:"processed"`  // 已处理页面数
+	Created         int               `json:"created"`    // 新建知识数
+	Duplicated      int               `json:"duplicated"` // 已存在而跳过的页面数
+	Skipped         int               `json:"skipped"`    // 被 robots.txt、范围或抓取失败跳过的URL数
+	Failed          int               `json:"failed"`     // 创建知识失败的页面数
+	Message         string            `json:"message"`    // 状态消息
+	Error           string            `json:"error"`      // 错误信息
+	CreatedAt       int64             `json:"created_at"` // 任务创建时间
+	UpdatedAt       int64             `json:"updated_at"` // 最后更新时间
+}
@@ -3,7 +3,10 @@ package types
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"time"
+
+	secutils "github.com/Tencent/WeKnora/internal/utils"
 )
 
 // WebSearchConfig represents the web search configuration for a tenant
@@ -21,12 +24,17 @@ type WebSearchConfig struct {
 	DocumentFragments  int    `json:"document_fragments,omitempty"`  // 文档片段数量（用于RAG压缩）
 }
 
-// Value implements driver.Valuer interface for WebSearchConfig
+// Value implements driver.Valuer interface for WebSearchConfig, encrypting the API key
 func (c WebSearchConfig) Value() (driver.Value, error) {
+	apiKey, err := secutils.EncryptSecret(c.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt web search API key: %w", err)
+	}
+	c.APIKey = apiKey
 	return json.Marshal(c)
 }
 
-// Scan implements sql.Scanner interface for WebSearchConfig
+// Scan implements sql.Scanner interface for WebSearchConfig, decrypting the API key
 func (c *WebSearchConfig) Scan(value interface{}) error {
 	if value == nil {
 		return nil
@@ -35,7 +43,25 @@ func (c *WebSearchConfig) Scan(value interface{}) error {
 	if !ok {
 		return nil
 	}
-	return json.Unmarshal(b, c)
+	if err := json.Unmarshal(b, c); err != nil {
+		return err
+	}
+	apiKey, err := secutils.DecryptSecret(c.APIKey)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt web search API key: %w", err)
+	}
+	c.APIKey = apiKey
+	return nil
+}
+
+// Redacted returns a copy of the config with the API key masked for display
+func (c *WebSearchConfig) Redacted() *WebSearchConfig {
+	if c == nil {
+		return nil
+	}
+	redacted := *c
+	redacted.APIKey = RedactSecret(c.APIKey)
+	return &redacted
 }
 
 // WebSearchResult represents a single web search result
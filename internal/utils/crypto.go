@@ -0,0 +1,297 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	// encryptedSecretPrefix 标识加密存储的敏感配置值
+	encryptedSecretPrefix = "enc:"
+	// legacySecretPrefix 标识直接使用 TENANT_AES_KEY 派生密钥加密的旧版密文
+	legacySecretPrefix = "enc:v1:"
+	// envelopeSecretPrefix 标识信封加密的密文，格式为 enc:v2:<主密钥ID>:<加密的数据密钥>:<加密的数据>
+	envelopeSecretPrefix = "enc:v2:"
+	// dataKeySize 每个值独立生成的数据密钥长度
+	dataKeySize = 32
+)
+
+// ErrSecretKeyMissing 未配置敏感配置的加密密钥
+var ErrSecretKeyMissing = errors.New(
+	"secret encryption key is not configured, please set WEKNORA_MASTER_KEY or TENANT_AES_KEY")
+
+// masterKey 加密数据密钥的主密钥
+type masterKey struct {
+	id  string
+	key []byte
+}
+
+// newMasterKey 由配置的主密钥派生 AES-256 密钥和用于标识密文的密钥 ID
+func newMasterKey(raw string) masterKey {
+	key := sha256.Sum256([]byte("weknora-master-key:" + raw))
+	id := sha256.Sum256([]byte("weknora-key-id:" + raw))
+	return masterKey{id: hex.EncodeToString(id[:4]), key: key[:]}
+}
+
+// keyring 当前主密钥和轮换前的旧主密钥
+type keyring struct {
+	current  *masterKey
+	previous []masterKey
+}
+
+var (
+	keyringMu  sync.RWMutex
+	keyringSet *keyring // 通过 SetMasterKeys 配置，为空时从环境变量读取
+)
+
+// SetMasterKeys 设置加密敏感配置的主密钥，previous 为密钥轮换前使用的旧主密钥，仅用于解密。
+// current 为空时回退到环境变量 WEKNORA_MASTER_KEY 和 TENANT_AES_KEY。
+func SetMasterKeys(current string, previous []string) error {
+	ring, err := newKeyring(current, previous)
+	if err != nil {
+		return err
+	}
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+	keyringSet = ring
+	return nil
+}
+
+// newKeyring 创建密钥环，忽略未解析的环境变量引用
+func newKeyring(current string, previous []string) (*keyring, error) {
+	if !configuredKey(current) {
+		current = os.Getenv("WEKNORA_MASTER_KEY")
+	}
+	if !configuredKey(current) {
+		current = os.Getenv("TENANT_AES_KEY")
+	}
+	if len(previous) == 0 {
+		previous = strings.Split(os.Getenv("WEKNORA_PREVIOUS_MASTER_KEYS"), ",")
+	}
+
+	ring := &keyring{}
+	if configuredKey(current) {
+		key := newMasterKey(current)
+		ring.current = &key
+	}
+	for _, raw := range previous {
+		raw = strings.TrimSpace(raw)
+		if !configuredKey(raw) || raw == current {
+			continue
+		}
+		key := newMasterKey(raw)
+		if ring.current != nil && key.id == ring.current.id {
+			return nil, errors.New("master key IDs collide, please choose another master key")
+		}
+		ring.previous = append(ring.previous, key)
+	}
+	return ring, nil
+}
+
+// configuredKey 判断密钥是否已配置，未设置的环境变量引用（如 ${WEKNORA_MASTER_KEY}）视为未配置
+func configuredKey(raw string) bool {
+	raw = strings.TrimSpace(raw)
+	return raw != "" && !(strings.HasPrefix(raw, "${") && strings.HasSuffix(raw, "}"))
+}
+
+// currentKeyring 返回当前生效的密钥环
+func currentKeyring() (*keyring, error) {
+	keyringMu.RLock()
+	ring := keyringSet
+	keyringMu.RUnlock()
+	if ring != nil {
+		return ring, nil
+	}
+	return newKeyring("", nil)
+}
+
+// find 根据密钥 ID 查找主密钥
+func (r *keyring) find(id string) (*masterKey, bool) {
+	if r.current != nil && r.current.id == id {
+		return r.current, true
+	}
+	for i := range r.previous {
+		if r.previous[i].id == id {
+			return &r.previous[i], true
+		}
+	}
+	return nil, false
+}
+
+// IsEncryptedSecret 判断值是否为 EncryptSecret 生成的密文
+func IsEncryptedSecret(value string) bool {
+	return strings.HasPrefix(value, legacySecretPrefix) || strings.HasPrefix(value, envelopeSecretPrefix)
+}
+
+// NeedsReencryption 判断值是否需要用当前主密钥重新加密：明文、旧版密文或由旧主密钥加密的密文
+func NeedsReencryption(value string) bool {
+	if value == "" {
+		return false
+	}
+	if !strings.HasPrefix(value, envelopeSecretPrefix) {
+		return true
+	}
+	ring, err := currentKeyring()
+	if err != nil || ring.current == nil {
+		return false
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, envelopeSecretPrefix), ":")
+	return id != ring.current.id
+}
+
+// EncryptSecret 使用信封加密保护敏感配置（密钥、令牌等）：每个值使用随机数据密钥 AES-GCM 加密，
+// 数据密钥再由当前主密钥加密后随密文保存。空值和已加密的值原样返回。
+func EncryptSecret(plaintext string) (string, error) {
+	if plaintext == "" || IsEncryptedSecret(plaintext) {
+		return plaintext, nil
+	}
+	ring, err := currentKeyring()
+	if err != nil {
+		return "", err
+	}
+	if ring.current == nil {
+		return "", ErrSecretKeyMissing
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return "", err
+	}
+	wrappedKey, err := seal(ring.current.key, dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return envelopeSecretPrefix + ring.current.id + ":" +
+		base64.RawURLEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密 EncryptSecret 生成的密文，未加密的历史明文原样返回
+func DecryptSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, envelopeSecretPrefix):
+		return decryptEnvelope(strings.TrimPrefix(value, envelopeSecretPrefix))
+	case strings.HasPrefix(value, legacySecretPrefix):
+		return decryptLegacy(strings.TrimPrefix(value, legacySecretPrefix))
+	case strings.HasPrefix(value, encryptedSecretPrefix):
+		return "", errors.New("unsupported encrypted secret version")
+	default:
+		return value, nil
+	}
+}
+
+// ReencryptSecret 使用当前主密钥重新加密敏感配置，用于主密钥轮换
+func ReencryptSecret(value string) (string, error) {
+	if !NeedsReencryption(value) {
+		return value, nil
+	}
+	plaintext, err := DecryptSecret(value)
+	if err != nil {
+		return "", err
+	}
+	return EncryptSecret(plaintext)
+}
+
+// decryptEnvelope 解密信封加密的密文
+func decryptEnvelope(payload string) (string, error) {
+	parts := strings.Split(payload, ":")
+	if len(parts) != 3 {
+		return "", errors.New("invalid encrypted secret format")
+	}
+	ring, err := currentKeyring()
+	if err != nil {
+		return "", err
+	}
+	key, ok := ring.find(parts[0])
+	if !ok {
+		return "", fmt.Errorf("master key %s of the encrypted secret is not configured", parts[0])
+	}
+	wrappedKey, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", errors.New("invalid encrypted secret encoding")
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", errors.New("invalid encrypted secret encoding")
+	}
+	dataKey, err := open(key.key, wrappedKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataKey, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// decryptLegacy 解密旧版密文，其密钥直接由 TENANT_AES_KEY 派生
+func decryptLegacy(payload string) (string, error) {
+	sealed, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", errors.New("invalid encrypted secret encoding")
+	}
+	tenantKey := os.Getenv("TENANT_AES_KEY")
+	if tenantKey == "" {
+		return "", ErrSecretKeyMissing
+	}
+	key := sha256.Sum256([]byte("weknora-secret:" + tenantKey))
+	plaintext, err := open(key[:], sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// seal 使用 AES-GCM 加密，返回 nonce 与密文的拼接
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open 解密 seal 生成的密文
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("invalid encrypted secret length")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt secret, the encryption key may have changed")
+	}
+	return plaintext, nil
+}
+
+// newGCM 创建 AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// useMasterKeys configures the master keys for the duration of the test
+func useMasterKeys(t *testing.T, current string, previous ...string) {
+	t.Helper()
+	t.Setenv("WEKNORA_MASTER_KEY", "")
+	t.Setenv("WEKNORA_PREVIOUS_MASTER_KEYS", "")
+	if err := SetMasterKeys(current, previous); err != nil {
+		t.Fatalf("SetMasterKeys: %v", err)
+	}
+	t.Cleanup(func() {
+		keyringMu.Lock()
+		keyringSet = nil
+		keyringMu.Unlock()
+	})
+}
+
+// encrypt encrypts the plaintext, failing the test on error
+func encrypt(t *testing.T, plaintext string) string {
+	t.Helper()
+	encrypted, err := EncryptSecret(plaintext)
+	if err != nil {
+		t.Fatalf("EncryptSecret: %v", err)
+	}
+	return encrypted
+}
+
+// legacySecret encrypts the plaintext in the format used before envelope encryption
+func legacySecret(t *testing.T, tenantKey, plaintext string) string {
+	t.Helper()
+	key := sha256.Sum256([]byte("weknora-secret:" + tenantKey))
+	sealed, err := seal(key[:], []byte(plaintext))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	return legacySecretPrefix + base64.RawURLEncoding.EncodeToString(sealed)
+}
+
+// tamper changes one character in the middle of the encrypted data
+func tamper(value string) string {
+	i := len(value) - 10
+	replacement := "A"
+	if value[i] == 'A' {
+		replacement = "B"
+	}
+	return value[:i] + replacement + value[i+1:]
+}
+
+func TestEncryptSecret(t *testing.T) {
+	useMasterKeys(t, "master-key")
+
+	encrypted := encrypt(t, "sk-secret")
+	if !strings.HasPrefix(encrypted, envelopeSecretPrefix) || strings.Contains(encrypted, "sk-secret") {
+		t.Fatalf("encrypted = %q", encrypted)
+	}
+	if again := encrypt(t, "sk-secret"); again == encrypted {
+		t.Error("encrypting twice gave the same ciphertext")
+	}
+	if again := encrypt(t, encrypted); again != encrypted {
+		t.Error("encrypted secret was encrypted again")
+	}
+	if empty := encrypt(t, ""); empty != "" {
+		t.Errorf("empty secret encrypted to %q", empty)
+	}
+	if NeedsReencryption(encrypted) {
+		t.Error("secret encrypted with the current key needs re-encryption")
+	}
+
+	decrypted, err := DecryptSecret(encrypted)
+	if err != nil || decrypted != "sk-secret" {
+		t.Fatalf("DecryptSecret = %q, %v", decrypted, err)
+	}
+}
+
+func TestDecryptSecret(t *testing.T) {
+	t.Setenv("TENANT_AES_KEY", "tenant-key")
+	useMasterKeys(t, "old-key")
+	oldSecret := encrypt(t, "sk-old")
+	useMasterKeys(t, "new-key", "old-key")
+	newSecret := encrypt(t, "sk-new")
+
+	tests := []struct {
+		name            string
+		value           string
+		want            string
+		wantErr         bool
+		wantReencrypted bool
+	}{
+		{name: "current key", value: newSecret, want: "sk-new"},
+		{name: "previous key", value: oldSecret, want: "sk-old", wantReencrypted: true},
+		{
+			name: "legacy format", value: legacySecret(t, "tenant-key", "sk-legacy"),
+			want: "sk-legacy", wantReencrypted: true,
+		},
+		{name: "plaintext", value: "sk-plain", want: "sk-plain", wantReencrypted: true},
+		{name: "tampered", value: tamper(newSecret), wantErr: true},
+		{name: "unknown key", value: "enc:v2:00000000:AAAA:AAAA", wantErr: true},
+		{name: "unknown version", value: "enc:v9:AAAA", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecryptSecret(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecryptSecret error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DecryptSecret = %q, want %q", got, tt.want)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if NeedsReencryption(tt.value) != tt.wantReencrypted {
+				t.Errorf("NeedsReencryption = %v, want %v", !tt.wantReencrypted, tt.wantReencrypted)
+			}
+			reencrypted, err := ReencryptSecret(tt.value)
+			if err != nil {
+				t.Fatalf("ReencryptSecret: %v", err)
+			}
+			if NeedsReencryption(reencrypted) {
+				t.Error("re-encrypted secret still needs re-encryption")
+			}
+			if got, _ := DecryptSecret(reencrypted); got != tt.want {
+				t.Errorf("re-encrypted secret decrypts to %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMasterKeyFallback(t *testing.T) {
+	t.Setenv("TENANT_AES_KEY", "")
+	useMasterKeys(t, "${WEKNORA_MASTER_KEY}")
+	if _, err := EncryptSecret("sk-secret"); err != ErrSecretKeyMissing {
+		t.Fatalf("EncryptSecret error = %v, want ErrSecretKeyMissing", err)
+	}
+
+	t.Setenv("TENANT_AES_KEY", "tenant-key")
+	useMasterKeys(t, "")
+	encrypted := encrypt(t, "sk-secret")
+	useMasterKeys(t, "tenant-key")
+	if decrypted, err := DecryptSecret(encrypted); err != nil || decrypted != "sk-secret" {
+		t.Fatalf("DecryptSecret = %q, %v", decrypted, err)
+	}
+}
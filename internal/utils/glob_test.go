@@ -0,0 +1,34 @@
+package utils
+
+import "testing"
+
+func TestCompileGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"docs/*.md", "docs/intro.md", true},
+		{"docs/*.md", "docs/guide/intro.md", false},
+		{"docs/**", "docs/guide/intro.md", true},
+		{"docs/**/*.md", "docs/intro.md", true},
+		{"docs/**/*.md", "docs/guide/v2/intro.md", true},
+		{"**/vendor/**", "vendor/lib.go", true},
+		{"v?/api", "v2/api", true},
+		{"v?/api", "v10/api", false},
+		{"a.b", "axb", false},
+	}
+	for _, tt := range tests {
+		re, err := CompileGlob(tt.pattern)
+		if err != nil {
+			t.Fatalf("CompileGlob(%q): %v", tt.pattern, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("%q match %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+
+	if _, err := CompileGlob(""); err == nil {
+		t.Error("an empty pattern should be rejected")
+	}
+}
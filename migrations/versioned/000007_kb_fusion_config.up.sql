@@ -0,0 +1,7 @@
+-- Add fusion_config column to knowledge_bases table
+-- This column stores how vector and keyword results are merged in hybrid search (rrf, weighted or max)
+
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS fusion_config JSONB NULL;
+
+-- Add comment for the column
+COMMENT ON COLUMN knowledge_bases.fusion_config IS 'Result fusion configuration for hybrid search (method, rrf_k, vector_weight, keyword_weight)';
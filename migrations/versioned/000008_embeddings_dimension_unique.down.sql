@@ -0,0 +1,12 @@
+-- Restore the (source_id, source_type) unique key of embeddings.
+-- Fails if a source is still indexed at more than one dimension (re-embedding in progress).
+
+DO $$
+BEGIN
+    IF to_regclass('embeddings') IS NULL THEN
+        RETURN;
+    END IF;
+
+    DROP INDEX IF EXISTS embeddings_unique_source_dimension;
+    CREATE UNIQUE INDEX IF NOT EXISTS embeddings_unique_source ON embeddings(source_id, source_type);
+END $$;
@@ -0,0 +1,21 @@
+-- Migration: embeddings_dimension_unique (conditional)
+-- Description: Include dimension in the unique key of embeddings, so a chunk can be indexed with a new
+-- embedding model of another dimension while the old vectors are still served (knowledge base re-embedding)
+
+DO $$
+BEGIN
+    IF current_setting('app.skip_embedding', true) = 'true' THEN
+        RAISE NOTICE 'Skipping migration embeddings_dimension_unique (app.skip_embedding=true)';
+        RETURN;
+    END IF;
+
+    IF to_regclass('embeddings') IS NULL THEN
+        RAISE NOTICE '[Migration 000008] embeddings table does not exist, skipping';
+        RETURN;
+    END IF;
+
+    DROP INDEX IF EXISTS embeddings_unique_source;
+    CREATE UNIQUE INDEX IF NOT EXISTS embeddings_unique_source_dimension ON embeddings(source_id, source_type, dimension);
+
+    RAISE NOTICE '[Migration 000008] Unique key of embeddings now includes dimension';
+END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000009_agent_approvals (rollback)
+-- Description: Remove agent_approvals table
+DO $$ BEGIN RAISE NOTICE '[Migration 000009 DOWN] Dropping table: agent_approvals'; END $$;
+
+DROP INDEX IF EXISTS idx_agent_approvals_message;
+DROP INDEX IF EXISTS idx_agent_approvals_status;
+DROP TABLE IF EXISTS agent_approvals;
@@ -0,0 +1,27 @@
+-- Migration: 000009_agent_approvals
+-- Description: Add agent_approvals table storing agent runs paused for human approval of tool calls
+DO $$ BEGIN RAISE NOTICE '[Migration 000009] Creating table: agent_approvals'; END $$;
+
+CREATE TABLE IF NOT EXISTS agent_approvals (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id INTEGER NOT NULL,
+    session_id VARCHAR(36) NOT NULL,
+    message_id VARCHAR(36) NOT NULL,
+    agent_id VARCHAR(36) NOT NULL,
+    knowledge_base_ids JSONB NOT NULL DEFAULT '[]',
+    knowledge_ids JSONB NOT NULL DEFAULT '[]',
+    tool_calls JSONB NOT NULL DEFAULT '[]',
+    snapshot JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    reason TEXT,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_approvals_message ON agent_approvals(tenant_id, session_id, message_id);
+CREATE INDEX IF NOT EXISTS idx_agent_approvals_status ON agent_approvals(status);
+
+COMMENT ON COLUMN agent_approvals.snapshot IS 'Serialized agent engine state used to resume the run after the decision';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000009] Agent approvals setup completed!'; END $$;
@@ -0,0 +1,12 @@
+-- Migration: 000010_session_ownership (rollback)
+-- Description: Remove session sharing and session owners
+DO $$ BEGIN RAISE NOTICE '[Migration 000010 DOWN] Dropping table: session_shares'; END $$;
+
+DROP INDEX IF EXISTS idx_session_shares_user;
+DROP INDEX IF EXISTS idx_session_shares_target;
+DROP TABLE IF EXISTS session_shares;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000010 DOWN] Dropping owner column from sessions'; END $$;
+
+DROP INDEX IF EXISTS idx_sessions_user_id;
+ALTER TABLE sessions DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,41 @@
+-- Migration: 000010_session_ownership
+-- Description: Add session owners and session sharing so that chat history is private to its user
+DO $$ BEGIN RAISE NOTICE '[Migration 000010] Adding owner column to sessions'; END $$;
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_id VARCHAR(36) NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(tenant_id, user_id);
+
+COMMENT ON COLUMN sessions.user_id IS 'Owner of the session, empty for sessions created with the API key, which only tenant admins can access';
+
+-- Existing sessions are assigned to the first user of their tenant, which is the user
+-- who registered the tenant. Sessions of tenants without users are left to the API key.
+DO $$ BEGIN RAISE NOTICE '[Migration 000010] Assigning existing sessions to tenant owners'; END $$;
+
+UPDATE sessions s
+SET user_id = u.id
+FROM (
+    SELECT DISTINCT ON (tenant_id) id, tenant_id
+    FROM users
+    WHERE deleted_at IS NULL
+    ORDER BY tenant_id, created_at
+) u
+WHERE s.tenant_id = u.tenant_id AND s.user_id = '';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000010] Creating table: session_shares'; END $$;
+
+CREATE TABLE IF NOT EXISTS session_shares (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id INTEGER NOT NULL,
+    session_id VARCHAR(36) NOT NULL,
+    share_type VARCHAR(16) NOT NULL,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_session_shares_target ON session_shares(session_id, share_type, user_id);
+CREATE INDEX IF NOT EXISTS idx_session_shares_user ON session_shares(tenant_id, user_id);
+
+COMMENT ON TABLE session_shares IS 'Read-only access to sessions granted by their owner to a user or the whole tenant';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000010] Session ownership setup completed!'; END $$;
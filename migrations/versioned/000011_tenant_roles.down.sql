@@ -0,0 +1,13 @@
+-- Migration: 000011_tenant_roles (rollback)
+-- Description: Remove tenant roles and knowledge base grants
+DO $$ BEGIN RAISE NOTICE '[Migration 000011 DOWN] Dropping table: knowledge_base_grants'; END $$;
+
+DROP INDEX IF EXISTS idx_kb_grants_tenant_user;
+DROP INDEX IF EXISTS idx_kb_grants_user;
+DROP TABLE IF EXISTS knowledge_base_grants;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000011 DOWN] Dropping table: tenant_members'; END $$;
+
+DROP INDEX IF EXISTS idx_tenant_members_user_id;
+DROP INDEX IF EXISTS idx_tenant_members_user;
+DROP TABLE IF EXISTS tenant_members;
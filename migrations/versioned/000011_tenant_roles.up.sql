@@ -0,0 +1,46 @@
+-- Migration: 000011_tenant_roles
+-- Description: Add tenant roles and knowledge base grants for role-based access control
+DO $$ BEGIN RAISE NOTICE '[Migration 000011] Creating table: tenant_members'; END $$;
+
+CREATE TABLE IF NOT EXISTS tenant_members (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id INTEGER NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    role VARCHAR(16) NOT NULL DEFAULT 'viewer',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tenant_members_user ON tenant_members(tenant_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_tenant_members_user_id ON tenant_members(user_id);
+
+COMMENT ON TABLE tenant_members IS 'Users of a tenant and their role: owner, admin, editor or viewer';
+
+-- Every existing user keeps full control of the tenant they belong to
+DO $$ BEGIN RAISE NOTICE '[Migration 000011] Making existing users owners of their tenants'; END $$;
+
+INSERT INTO tenant_members (tenant_id, user_id, role)
+SELECT tenant_id, id, 'owner'
+FROM users
+WHERE deleted_at IS NULL AND tenant_id IS NOT NULL
+ON CONFLICT (tenant_id, user_id) DO NOTHING;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000011] Creating table: knowledge_base_grants'; END $$;
+
+CREATE TABLE IF NOT EXISTS knowledge_base_grants (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    role VARCHAR(16) NOT NULL,
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kb_grants_user ON knowledge_base_grants(knowledge_base_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_kb_grants_tenant_user ON knowledge_base_grants(tenant_id, user_id);
+
+COMMENT ON TABLE knowledge_base_grants IS 'Roles on single knowledge bases raising the tenant role of a member';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000011] Tenant roles setup completed!'; END $$;
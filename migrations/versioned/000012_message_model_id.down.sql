@@ -0,0 +1,5 @@
+-- Migration: 000012_message_model_id (rollback)
+-- Description: Remove the answering chat model from messages
+DO $$ BEGIN RAISE NOTICE '[Migration 000012 DOWN] Dropping model_id column from messages'; END $$;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS model_id;
@@ -0,0 +1,9 @@
+-- Migration: 000012_message_model_id
+-- Description: Record the chat model that generated each answer, which differs from the configured model after a fallback
+DO $$ BEGIN RAISE NOTICE '[Migration 000012] Adding model_id column to messages'; END $$;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS model_id VARCHAR(64) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN messages.model_id IS 'Chat model that generated the answer, empty for user messages and older answers';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000012] Message model ID setup completed!'; END $$;
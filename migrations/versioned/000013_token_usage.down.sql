@@ -0,0 +1,10 @@
+-- Migration: 000013_token_usage (rollback)
+-- Description: Remove token usage accounting and token quotas
+DO $$ BEGIN RAISE NOTICE '[Migration 000013 DOWN] Dropping table: token_usages'; END $$;
+
+DROP INDEX IF EXISTS idx_token_usages_tenant_time;
+DROP TABLE IF EXISTS token_usages;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000013 DOWN] Dropping token_quota column from tenants'; END $$;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS token_quota;
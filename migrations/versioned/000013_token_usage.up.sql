@@ -0,0 +1,29 @@
+-- Migration: 000013_token_usage
+-- Description: Add token usage accounting of model calls and monthly token quotas for tenants
+DO $$ BEGIN RAISE NOTICE '[Migration 000013] Adding token_quota column to tenants'; END $$;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS token_quota BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN tenants.token_quota IS 'Monthly quota of chat, embedding and rerank tokens, 0 means unlimited';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000013] Creating table: token_usages'; END $$;
+
+CREATE TABLE IF NOT EXISTS token_usages (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id INTEGER NOT NULL,
+    model_id VARCHAR(64) NOT NULL DEFAULT '',
+    model_type VARCHAR(32) NOT NULL DEFAULT '',
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    total_tokens BIGINT NOT NULL DEFAULT 0,
+    estimated BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_token_usages_tenant_time ON token_usages(tenant_id, created_at);
+
+COMMENT ON TABLE token_usages IS 'Tokens consumed by each chat, embedding and rerank model call';
+COMMENT ON COLUMN token_usages.estimated IS 'Whether the tokens were estimated from the text length because the model did not report usage';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000013] Token usage setup completed!'; END $$;
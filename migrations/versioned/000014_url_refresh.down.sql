@@ -0,0 +1,10 @@
+-- Migration: 000014_url_refresh (rollback)
+-- Description: Remove the URL refresh schedule and change detection from knowledges
+DO $$ BEGIN RAISE NOTICE '[Migration 000014 DOWN] Dropping URL refresh columns from knowledges'; END $$;
+
+DROP INDEX IF EXISTS idx_knowledges_url_refresh;
+
+ALTER TABLE knowledges DROP COLUMN IF EXISTS last_changed_at;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS last_checked_at;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS content_hash;
+ALTER TABLE knowledges DROP COLUMN IF EXISTS refresh_interval;
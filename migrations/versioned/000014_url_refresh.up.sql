@@ -0,0 +1,18 @@
+-- Migration: 000014_url_refresh
+-- Description: Schedule periodic re-crawls of URL knowledge and detect page changes by content hash
+DO $$ BEGIN RAISE NOTICE '[Migration 000014] Adding URL refresh columns to knowledges'; END $$;
+
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS refresh_interval INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS last_checked_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS last_changed_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_knowledges_url_refresh ON knowledges(last_checked_at)
+    WHERE refresh_interval > 0 AND deleted_at IS NULL;
+
+COMMENT ON COLUMN knowledges.refresh_interval IS 'Seconds between re-crawls of URL knowledge, 0 disables the refresh';
+COMMENT ON COLUMN knowledges.content_hash IS 'SHA-256 of the crawled content of URL knowledge';
+COMMENT ON COLUMN knowledges.last_checked_at IS 'Last time the URL was re-crawled';
+COMMENT ON COLUMN knowledges.last_changed_at IS 'Last time the crawled content changed';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000014] URL refresh setup completed!'; END $$;
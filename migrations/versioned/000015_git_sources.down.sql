@@ -0,0 +1,6 @@
+-- Migration: 000015_git_sources (rollback)
+-- Description: Remove Git repository sources, the knowledge they created is kept
+DO $$ BEGIN RAISE NOTICE '[Migration 000015 DOWN] Dropping tables: git_source_files, git_sources'; END $$;
+
+DROP TABLE IF EXISTS git_source_files;
+DROP TABLE IF EXISTS git_sources;
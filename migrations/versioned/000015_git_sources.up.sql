@@ -0,0 +1,51 @@
+-- Migration: 000015_git_sources
+-- Description: Add Git repository sources that sync Markdown and source files into knowledge bases
+DO $$ BEGIN RAISE NOTICE '[Migration 000015] Creating table: git_sources'; END $$;
+
+CREATE TABLE IF NOT EXISTS git_sources (
+    id VARCHAR(36) PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    repo_url TEXT NOT NULL,
+    branch VARCHAR(255) NOT NULL DEFAULT '',
+    include JSONB,
+    exclude JSONB,
+    last_commit VARCHAR(64) NOT NULL DEFAULT '',
+    sync_status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    sync_stats JSONB,
+    error_message TEXT NOT NULL DEFAULT '',
+    last_synced_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_git_sources_tenant_kb ON git_sources(tenant_id, knowledge_base_id);
+CREATE INDEX IF NOT EXISTS idx_git_sources_deleted_at ON git_sources(deleted_at);
+
+COMMENT ON TABLE git_sources IS 'Git repositories whose files are synced into a knowledge base';
+COMMENT ON COLUMN git_sources.last_commit IS 'Commit SHA of the last successful sync';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000015] Creating table: git_source_files'; END $$;
+
+CREATE TABLE IF NOT EXISTS git_source_files (
+    source_id VARCHAR(36) NOT NULL,
+    path TEXT NOT NULL,
+    tenant_id INTEGER NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL DEFAULT '',
+    blob_sha VARCHAR(64) NOT NULL DEFAULT '',
+    commit_sha VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (source_id, path)
+);
+
+CREATE INDEX IF NOT EXISTS idx_git_source_files_knowledge_id ON git_source_files(knowledge_id);
+
+COMMENT ON TABLE git_source_files IS 'Knowledge each file of a Git source was ingested as';
+COMMENT ON COLUMN git_source_files.knowledge_id IS 'Empty when the file content duplicates other knowledge of the knowledge base';
+COMMENT ON COLUMN git_source_files.blob_sha IS 'Blob SHA of the ingested content, compared on re-sync to detect changes';
+COMMENT ON COLUMN git_source_files.commit_sha IS 'SHA of the last commit that changed the file';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000015] Git sources setup completed!'; END $$;
@@ -0,0 +1,6 @@
+-- Migration: 000016_encrypted_secrets (rollback)
+-- Description: Restore tenants.api_key, the API keys must have been decrypted back to plaintext first
+DO $$ BEGIN RAISE NOTICE '[Migration 000016 DOWN] Altering column: tenants.api_key'; END $$;
+
+ALTER TABLE tenants ALTER COLUMN api_key TYPE VARCHAR(64);
+CREATE INDEX IF NOT EXISTS idx_tenants_api_key ON tenants(api_key);
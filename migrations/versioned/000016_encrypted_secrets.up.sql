@@ -0,0 +1,6 @@
+-- Migration: 000016_encrypted_secrets
+-- Description: Widen tenants.api_key to hold the encrypted API key, which is no longer looked up by value
+DO $$ BEGIN RAISE NOTICE '[Migration 000016] Altering column: tenants.api_key'; END $$;
+
+DROP INDEX IF EXISTS idx_tenants_api_key;
+ALTER TABLE tenants ALTER COLUMN api_key TYPE TEXT;
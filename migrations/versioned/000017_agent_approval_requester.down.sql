@@ -0,0 +1,6 @@
+-- Migration: 000017_agent_approval_requester (rollback)
+-- Description: Remove the requester columns from agent_approvals
+DO $$ BEGIN RAISE NOTICE '[Migration 000017 DOWN] Dropping requester columns from agent_approvals'; END $$;
+
+ALTER TABLE agent_approvals DROP COLUMN IF EXISTS resolved_by;
+ALTER TABLE agent_approvals DROP COLUMN IF EXISTS requested_by;
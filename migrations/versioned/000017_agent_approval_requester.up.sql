@@ -0,0 +1,11 @@
+-- Migration: 000017_agent_approval_requester
+-- Description: Record who started and who resolved agent runs paused for tool approval
+DO $$ BEGIN RAISE NOTICE '[Migration 000017] Adding requester columns to agent_approvals'; END $$;
+
+ALTER TABLE agent_approvals ADD COLUMN IF NOT EXISTS requested_by VARCHAR(36) NOT NULL DEFAULT '';
+ALTER TABLE agent_approvals ADD COLUMN IF NOT EXISTS resolved_by VARCHAR(36) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN agent_approvals.requested_by IS 'User whose request started the run, who cannot approve its tool calls. Empty for API key requests';
+COMMENT ON COLUMN agent_approvals.resolved_by IS 'User who approved or rejected the tool calls. Empty for API key requests';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000017] Agent approval requester setup completed!'; END $$;